@@ -1,9 +1,22 @@
 package main
 
-import "github.com/MatTwix/Pull-Request-Assigner/internal/app"
+import (
+	"os"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/app"
+)
 
 const configPath = "./configs/config.yml"
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "import":
+			os.Exit(app.Import(configPath, os.Args[2:]))
+		case "snapshot":
+			os.Exit(app.Snapshot(configPath, os.Args[2:]))
+		}
+	}
+
 	app.Run(configPath)
 }
@@ -17,14 +17,14 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/pullRequest/create": {
-            "post": {
+        "/audit": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Создает пулл реквест и автоматически назначает до 2 ревьюверов из команды автора",
+                "description": "Возвращает записи журнала аудита от новых к старым: кто (actor- ключ API), что (action), над какой сущностью, состояние до и после изменения и идентификатор запроса (X-Request-Id). Следующая страница- по next_cursor из ответа",
                 "consumes": [
                     "application/json"
                 ],
@@ -32,29 +32,91 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "PullRequests"
+                    "Audit"
                 ],
-                "summary": "Создать пулл реквест",
+                "summary": "Журнал изменений",
                 "parameters": [
                     {
-                        "description": "PullRequest payload",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/internal_controller_http_v1.createPRRequest"
-                        }
+                        "type": "string",
+                        "description": "Ключ API, например api_key:12 или config:admin",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Действие, например pull_request.merge",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "user",
+                            "team",
+                            "pull_request",
+                            "repository",
+                            "tenant",
+                            "api_key",
+                            "webhook_subscription",
+                            "vcs",
+                            "chatops"
+                        ],
+                        "type": "string",
+                        "description": "Тип сущности",
+                        "name": "entity_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Идентификатор сущности",
+                        "name": "entity_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Идентификатор запроса",
+                        "name": "request_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода (RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода (RFC3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Период до текущего момента вместо from (например 24h, 30d)",
+                        "name": "window",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы (по умолчанию 50, не больше 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "next_cursor предыдущей страницы",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.PullRequestCreateOutput"
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.AuditOutput"
                         }
                     },
                     "400": {
-                        "description": "Неверное тело запроса",
+                        "description": "Неверные параметры запроса",
                         "schema": {
                             "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
                         }
@@ -65,18 +127,6 @@ const docTemplate = `{
                             "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Автор/команда не найдены",
-                        "schema": {
-                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
-                        }
-                    },
-                    "409": {
-                        "description": "PR уже существует",
-                        "schema": {
-                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
-                        }
-                    },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
@@ -86,14 +136,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/pullRequest/merge": {
+        "/chatops/identities/delete": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Идемпотентно устанавливает статус пулл реквеста \"MERGED\"",
+                "description": "После удаления slash-команды пользователя Slack не выполняются",
                 "consumes": [
                     "application/json"
                 ],
@@ -101,26 +151,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "PullRequests"
+                    "ChatOps"
                 ],
-                "summary": "Установить статус пулл реквеста \"MERGED\"",
+                "summary": "Удалить сопоставление пользователя Slack",
                 "parameters": [
                     {
-                        "description": "Merge payload",
+                        "description": "Identity payload",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/internal_controller_http_v1.mergePRRequest"
+                            "$ref": "#/definitions/internal_controller_http_v1.deleteChatOpsIdentityRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.PullRequestMergeOutput"
-                        }
+                    "204": {
+                        "description": "Сопоставление удалено"
                     },
                     "400": {
                         "description": "Неверное тело запроса",
@@ -135,7 +182,7 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "PR не найден",
+                        "description": "Сопоставление не найдено",
                         "schema": {
                             "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
                         }
@@ -149,14 +196,54 @@ const docTemplate = `{
                 }
             }
         },
-        "/pullRequest/reassign": {
+        "/chatops/identities/list": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает сопоставления пользователей Slack пользователям организации",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ChatOps"
+                ],
+                "summary": "Список сопоставлений пользователей Slack",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.ChatOpsIdentitiesOutput"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/chatops/identities/set": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Меняет одного ревьювера на рандомного из его команды",
+                "description": "Задает пользователя, от имени которого выполняются slash-команды пользователя Slack (user_id из Slack, например U012AB3CD), заменяя прежнее сопоставление. is_admin разрешает мержить чужие PR",
                 "consumes": [
                     "application/json"
                 ],
@@ -164,17 +251,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "PullRequests"
+                    "ChatOps"
                 ],
-                "summary": "Переназначить ревьювера",
+                "summary": "Сопоставить пользователя Slack пользователю",
                 "parameters": [
                     {
-                        "description": "Reassign payload",
+                        "description": "Identity payload",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/internal_controller_http_v1.reassignRequest"
+                            "$ref": "#/definitions/internal_controller_http_v1.setChatOpsIdentityRequest"
                         }
                     }
                 ],
@@ -182,7 +269,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.PullRequestReassignOutput"
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.ChatOpsOutputIdentity"
                         }
                     },
                     "400": {
@@ -198,13 +285,7 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "PR или пользователь не найден",
-                        "schema": {
-                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
-                        }
-                    },
-                    "409": {
-                        "description": "Нарушение правил переназначения",
+                        "description": "Пользователь не найден",
                         "schema": {
                             "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
                         }
@@ -218,14 +299,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/team/add": {
+        "/chatops/setSigningSecret": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Создает или обновляет команду с указанными пользователями",
+                "description": "Задает секрет, которым подписываются slash-команды организации (Signing Secret приложения Slack). Если секрет не передан- генерируется. Секрет возвращается только в этом ответе",
                 "consumes": [
                     "application/json"
                 ],
@@ -233,29 +314,29 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Teams"
+                    "ChatOps"
                 ],
-                "summary": "Создать команду с участниками",
+                "summary": "Задать секрет подписи slash-команд",
                 "parameters": [
                     {
-                        "description": "Team payload",
+                        "description": "Secret payload",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/internal_controller_http_v1.addTeamRequest"
+                            "$ref": "#/definitions/internal_controller_http_v1.setChatOpsSigningSecretRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamAddOutput"
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.ChatOpsSigningSecretOutput"
                         }
                     },
                     "400": {
-                        "description": "Команда уже существует или неверное тело запроса",
+                        "description": "Неверное тело запроса",
                         "schema": {
                             "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
                         }
@@ -275,9 +356,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/team/get": {
-            "get": {
-                "description": "Возвращает информацию о команде и ее пользователях",
+        "/notifications/chatChannels/add": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Регистрирует входящий webhook Slack/Mattermost, в который отправляются уведомления команды: назначение ревьювера (pull_request.created), переназначение (pull_request.reviewer_reassigned), превышение SLA ревью (pull_request.sla_breached) и мердж (pull_request.merged); пустой event_types- все эти события. templates- text/template сообщения для типа события (поля: .EventType, .PullRequest, .Recipient.UserID, .Recipient.Username, .OldReviewerID, .NewReviewerID, .AssignedAt, .ReviewSLA), для остальных событий используются стандартные сообщения. Неуспешные отправки повторяются с экспоненциальной задержкой. URL должен указывать на публичный адрес, редиректы не выполняются; в ответах путь URL скрыт",
                 "consumes": [
                     "application/json"
                 ],
@@ -285,27 +371,29 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Teams"
+                    "Notifications"
                 ],
-                "summary": "Получить команду с участниками",
+                "summary": "Подключить чат к команде",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Имя команды",
-                        "name": "team_name",
-                        "in": "query",
-                        "required": true
+                        "description": "Chat channel payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.addChatChannelRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamGetOutput"
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.ChatChannelOutput"
                         }
                     },
                     "400": {
-                        "description": "Неверное имя команды",
+                        "description": "Неверное тело запроса, шаблон или непубличный URL",
                         "schema": {
                             "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
                         }
@@ -322,6 +410,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
                         }
                     },
+                    "409": {
+                        "description": "Этот URL уже подключен к команде",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Внутренняя ошибка сервера",
                         "schema": {
@@ -331,9 +425,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/teams/deactivate": {
+        "/notifications/chatChannels/delete": {
             "post": {
-                "description": "Быстрый метод для массовой деактивации членов определенной команды",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Удаляет чат вместе с историей его уведомлений",
                 "consumes": [
                     "application/json"
                 ],
@@ -341,26 +440,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Teams"
+                    "Notifications"
                 ],
-                "summary": "Деактивация всех членов команды",
+                "summary": "Отключить чат",
                 "parameters": [
                     {
-                        "description": "Team to deactivate name",
+                        "description": "Chat channel payload",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/internal_controller_http_v1.deactivateTeamRequest"
+                            "$ref": "#/definitions/internal_controller_http_v1.deleteChatChannelRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamSetIsActiveTeamOutput"
-                        }
+                    "204": {
+                        "description": "Чат удален"
                     },
                     "400": {
                         "description": "Неверное тело запроса",
@@ -368,8 +464,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
                         }
                     },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
                     "404": {
-                        "description": "Команда не найдена",
+                        "description": "Чат не найден",
                         "schema": {
                             "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
                         }
@@ -383,14 +485,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/getReview": {
+        "/notifications/chatChannels/list": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Возвращает список пулл реквестов, назначенных пользователю",
+                "description": "Возвращает подключенные чаты команды, без team_name- всех команд организации",
                 "consumes": [
                     "application/json"
                 ],
@@ -398,29 +500,22 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Notifications"
                 ],
-                "summary": "Получить пулл реквесты, в которых пользователь является ревьювером",
+                "summary": "Список чатов",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "user_id пользователя",
-                        "name": "user_id",
-                        "in": "query",
-                        "required": true
+                        "description": "Название команды",
+                        "name": "team_name",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.UserGetReviewOutput"
-                        }
-                    },
-                    "400": {
-                        "description": "Неверный user_id",
-                        "schema": {
-                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.ChatChannelsOutput"
                         }
                     },
                     "401": {
@@ -438,14 +533,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/setIsActive": {
+        "/notifications/chatChannels/setIsActive": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Позволяет активировать и деактивировать пользователя",
+                "description": "Выключенный чат не получает новые уведомления, уже созданные продолжают отправляться",
                 "consumes": [
                     "application/json"
                 ],
@@ -453,17 +548,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Notifications"
                 ],
-                "summary": "Установить is_active флаг пользователя",
+                "summary": "Включить/выключить чат",
                 "parameters": [
                     {
-                        "description": "User payload",
+                        "description": "Chat channel status payload",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/internal_controller_http_v1.setIsActiveRequest"
+                            "$ref": "#/definitions/internal_controller_http_v1.setChatChannelIsActiveRequest"
                         }
                     }
                 ],
@@ -471,7 +566,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.UserSetIsActiveOutput"
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.ChatChannelOutput"
                         }
                     },
                     "400": {
@@ -487,7 +582,7 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Пользователь не найден",
+                        "description": "Чат не найден",
                         "schema": {
                             "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
                         }
@@ -500,64 +595,5795 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "github_com_MatTwix_Pull-Request-Assigner_internal_service.PullRequestCreateOutput": {
-            "type": "object",
-            "properties": {
-                "pr": {
-                    "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.PullRequestCreateOutputPR"
-                }
-            }
         },
-        "github_com_MatTwix_Pull-Request-Assigner_internal_service.PullRequestCreateOutputPR": {
-            "type": "object",
-            "properties": {
-                "assigned_reviewers": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
+        "/notifications/list": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
                     }
-                },
-                "author_id": {
-                    "type": "string"
-                },
-                "created_at": {
-                    "type": "string"
-                },
-                "pull_request_id": {
-                    "type": "string"
-                },
-                "pull_request_name": {
-                    "type": "string"
-                },
-                "status": {
-                    "type": "string"
-                }
-            }
-        },
-        "github_com_MatTwix_Pull-Request-Assigner_internal_service.PullRequestMergeOutput": {
-            "type": "object",
-            "properties": {
-                "pr": {
-                    "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.PullRequestMergeOutputPR"
-                }
-            }
-        },
-        "github_com_MatTwix_Pull-Request-Assigner_internal_service.PullRequestMergeOutputPR": {
-            "type": "object",
-            "properties": {
+                ],
+                "description": "Возвращает уведомления от новых к старым. status=DEAD- уведомления, исчерпавшие попытки, SKIPPED- уведомления, которым нечего отправлять (например, сводка, все ревью которой завершены). Следующая страница- по next_cursor из ответа",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "История уведомлений",
+                "parameters": [
+                    {
+                        "enum": [
+                            "chat",
+                            "email",
+                            "telegram"
+                        ],
+                        "type": "string",
+                        "description": "Канал",
+                        "name": "channel",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "PENDING",
+                            "SENT",
+                            "DEAD",
+                            "SKIPPED"
+                        ],
+                        "type": "string",
+                        "description": "Статус уведомления",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы (по умолчанию 50, не больше 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "next_cursor предыдущей страницы",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.NotificationsOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications/retry": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает уведомление в статусе DEAD в очередь с новым набором попыток. Уведомления в других статусах не меняются (requeued=false)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "Повторить уведомление",
+                "parameters": [
+                    {
+                        "description": "Notification payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.retryNotificationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.NotificationRetryOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Уведомление не найдено",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pullRequest/close": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Идемпотентно устанавливает статус пулл реквеста \"CLOSED\", ревьюверы освобождаются как после мержа. Закрытый PR можно открыть снова",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "PullRequests"
+                ],
+                "summary": "Закрыть пулл реквест без мержа",
+                "parameters": [
+                    {
+                        "description": "Close payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.setPRStatusRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ожидаемая версия (ETag), при несовпадении- 412",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.PullRequestStatusOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "PR не найден",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "PR уже смержен",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия изменилась",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pullRequest/create": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Создает пулл реквест и автоматически назначает ревьюверов (по умолчанию до 2) из указанной команды автора. Если команда не указана, ревьюверы назначаются из команды-владельца репозитория, а при ее отсутствии- из основной команды автора. Настройки репозитория переопределяют настройки команды",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "PullRequests"
+                ],
+                "summary": "Создать пулл реквест",
+                "parameters": [
+                    {
+                        "description": "PullRequest payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.createPRRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.PullRequestCreateOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Автор/команда/репозиторий не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "PR уже существует или автор не состоит в команде",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pullRequest/get": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает пулл реквест с текущими ревьюверами. Для PR из GitHub при заданном API токене (/vcs/setAPIToken) в reviewer_sync- состояние запроса ревьюверов в GitHub: PENDING (ожидает отправки или повтора), SYNCED, FAILED (попытки исчерпаны или GitHub отклонил запрос)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "PullRequests"
+                ],
+                "summary": "Получить пулл реквест",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор PR",
+                        "name": "pull_request_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.PullRequestGetOutput"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Версия PR для If-Match"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "PR не найден",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pullRequest/merge": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Идемпотентно устанавливает статус пулл реквеста \"MERGED\"",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "PullRequests"
+                ],
+                "summary": "Установить статус пулл реквеста \"MERGED\"",
+                "parameters": [
+                    {
+                        "description": "Merge payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.mergePRRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ожидаемая версия (ETag), при несовпадении- 412",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.PullRequestMergeOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "PR не найден",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия изменилась",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pullRequest/reassign": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Меняет одного ревьювера на рандомного из его команды",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "PullRequests"
+                ],
+                "summary": "Переназначить ревьювера",
+                "parameters": [
+                    {
+                        "description": "Reassign payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.reassignRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ожидаемая версия (ETag), при несовпадении- 412",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.PullRequestReassignOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "PR или пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Нарушение правил переназначения",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия изменилась",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pullRequest/reopen": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Идемпотентно возвращает закрытому без мержа пулл реквесту статус \"OPEN\", его ревьюверы снова заняты",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "PullRequests"
+                ],
+                "summary": "Открыть закрытый пулл реквест снова",
+                "parameters": [
+                    {
+                        "description": "Reopen payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.setPRStatusRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ожидаемая версия (ETag), при несовпадении- 412",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.PullRequestStatusOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "PR не найден",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "PR уже смержен",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия изменилась",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pullRequest/resyncReviewers": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Ставит в очередь запрос текущих ревьюверов PR в GitHub (и отзыв запросов у переназначенных) с новым набором попыток, например после статуса FAILED",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "PullRequests"
+                ],
+                "summary": "Повторить запрос ревьюверов в GitHub",
+                "parameters": [
+                    {
+                        "description": "Resync payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.setPRStatusRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.ReviewerSyncResyncOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "PR не найден",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "PR не из GitHub или API токен не задан",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/reconciliation/corrections": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает исправления, внесенные сверкой с провайдером, от новых к старым. Неудавшееся исправление записывается один раз для PR и вида расхождения: повторные неудачи увеличивают attempts, успешное исправление позже заполняет resolved_at. Следующая страница- по next_cursor из ответа",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reconciliation"
+                ],
+                "summary": "Исправления сверки",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор PR",
+                        "name": "pull_request_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "merge",
+                            "close",
+                            "reassign"
+                        ],
+                        "type": "string",
+                        "description": "Исправление",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода (RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода (RFC3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Период до текущего момента вместо from (например 24h, 30d)",
+                        "name": "window",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы (по умолчанию 50, не больше 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "next_cursor предыдущей страницы",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.ReconciliationCorrectionsOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/reconciliation/run": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Сверяет открытые PR организации, созданные webhook'ами GitHub/GitLab, с их состоянием у провайдера (нужен API токен, см. /vcs/setAPIToken) и исправляет расхождения из-за потерянных webhook'ов: смерженные у провайдера PR мержатся (merge), закрытые- закрываются (close), ревьюверы, снятые в PR GitHub после синхронизации, переназначаются (reassign, ревьюверы без сопоставления логина не сверяются). Все исправления, в том числе неудавшиеся (error), записываются и возвращаются в ответе; PR, которые не удалось получить у провайдера,- в failures. Та же сверка всех организаций выполняется по расписанию (reconciliation.interval)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reconciliation"
+                ],
+                "summary": "Сверить PR с провайдером",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.ReconciliationOutput"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Сверка уже выполняется",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Потоково выгружает отчет в CSV или NDJSON (по объекту на строку). Отчеты: pull_requests (созданные за период, user_id- автор), assignments (назначения ревьюверов за период, включая переназначенные; user_id- ревьювер), user_stats, team_stats, user_flow, team_flow (те же данные, что и в /stats, длительности в секундах). По умолчанию выгружаются все колонки отчета",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "Reports"
+                ],
+                "summary": "Выгрузка отчета",
+                "parameters": [
+                    {
+                        "enum": [
+                            "pull_requests",
+                            "assignments",
+                            "user_stats",
+                            "team_stats",
+                            "user_flow",
+                            "team_flow"
+                        ],
+                        "type": "string",
+                        "description": "Отчет",
+                        "name": "report",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "csv",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "description": "Формат (по умолчанию csv)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Колонки через запятую в нужном порядке",
+                        "name": "columns",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Идентификатор пользователя",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Имя команды",
+                        "name": "team_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Суммировать по дереву подкоманд (team_stats)",
+                        "name": "include_subteams",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода (RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода (RFC3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Период до текущего момента вместо from (например 24h, 30d)",
+                        "name": "window",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Отчет",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь/команда не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/repositories/add": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Создает репозиторий с командой-владельцем и настройками ревью. Настройки репозитория (null- не переопределять) имеют приоритет над настройками команды",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Repositories"
+                ],
+                "summary": "Зарегистрировать репозиторий",
+                "parameters": [
+                    {
+                        "description": "Repository payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.addRepositoryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.RepositoryOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Команда не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Репозиторий уже существует",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/repositories/get": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает команду-владельца и настройки ревью репозитория",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Repositories"
+                ],
+                "summary": "Получить репозиторий",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Имя репозитория",
+                        "name": "repository_name",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.RepositoryOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное имя репозитория",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Репозиторий не найден",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/repositories/setOwner": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Передает репозиторий другой команде. Пустое имя команды оставляет репозиторий без владельца",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Repositories"
+                ],
+                "summary": "Сменить команду-владельца репозитория",
+                "parameters": [
+                    {
+                        "description": "Owner payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.setRepositoryOwnerRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.RepositoryOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Репозиторий/команда не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/repositories/setSettings": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Задает количество ревьюверов и глубину поиска ревьюверов по иерархии для пулл реквестов репозитория. null- использовать настройки команды. Не переданные поля не меняются",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Repositories"
+                ],
+                "summary": "Изменить настройки ревью репозитория",
+                "parameters": [
+                    {
+                        "description": "Settings payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.setRepositorySettingsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.RepositoryOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Репозиторий не найден",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/snapshots/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Выгружает команды, пользователей, репозитории, PR, ревьюверов и настройки тенанта одним JSON-архивом с версией формата и контрольной суммой. Секреты интеграций и привязки Telegram не выгружаются",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Snapshots"
+                ],
+                "summary": "Выгрузка всех данных",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.SnapshotArchive"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/snapshots/restore": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Загружает архив /snapshots/export в тенант без команд, пользователей, репозиториев и PR. Архивы прошлых версий формата обновляются до текущей. Архив проверяется целиком (контрольная сумма, количество записей, ссылки между записями, ограничения схемы) и загружается одной транзакцией, события при этом не создаются",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Snapshots"
+                ],
+                "summary": "Восстановление из выгрузки",
+                "parameters": [
+                    {
+                        "description": "Архив",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.SnapshotArchive"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.SnapshotRestoreOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный или поврежденный архив",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "В тенанте уже есть данные",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stats/flow/teams": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает перцентили (p50/p90/p99, в секундах) времени от создания пулл реквеста до merge'а и до первого переназначения ревьювера за период",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Stats"
+                ],
+                "summary": "Скорость ревью по командам",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Имя команды",
+                        "name": "team_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода (RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода (RFC3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Период до текущего момента вместо from (например 24h, 30d)",
+                        "name": "window",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.StatsTeamFlowOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Команда не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stats/flow/users": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает перцентили (p50/p90/p99, в секундах) времени от назначения ревьювера до merge'а и до его переназначения на другого ревьювера за период",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Stats"
+                ],
+                "summary": "Скорость ревью по пользователям",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор пользователя",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Только пулл реквесты команды",
+                        "name": "team_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода (RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода (RFC3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Период до текущего момента вместо from (например 24h, 30d)",
+                        "name": "window",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.StatsUserFlowOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь/команда не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stats/teams": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает те же показатели по пулл реквестам, ревьюверы которых назначались из команды. С include_subteams показатели команды включают все ее подкоманды",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Stats"
+                ],
+                "summary": "Статистика ревью по командам",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Имя команды",
+                        "name": "team_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Суммировать по дереву подкоманд",
+                        "name": "include_subteams",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода (RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода (RFC3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Период до текущего момента вместо from (например 24h, 30d)",
+                        "name": "window",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.StatsTeamOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Команда не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stats/users": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает для каждого пользователя количество назначений, переназначений на другого ревьювера, завершенных (merged) ревью за период и текущую нагрузку (открытые PR). Границы периода- RFC3339, from включительно, to- нет",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Stats"
+                ],
+                "summary": "Статистика ревью по пользователям",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Идентификатор пользователя",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Только участники команды",
+                        "name": "team_name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода (RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода (RFC3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Период до текущего момента вместо from (например 24h, 30d)",
+                        "name": "window",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.StatsUserOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь/команда не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/team": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Приводит команду к переданному списку участников: добавляет, исключает, обновляет имена и активность в одной транзакции. Возвращает отчет об изменениях, при dry_run изменения не применяются",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Teams"
+                ],
+                "summary": "Декларативно синхронизировать состав команды",
+                "parameters": [
+                    {
+                        "description": "Desired team state",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.upsertTeamRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ожидаемая версия (ETag), при несовпадении- 412",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamUpsertOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия изменилась",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/team/add": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Создает или обновляет команду с указанными пользователями",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Teams"
+                ],
+                "summary": "Создать команду с участниками",
+                "parameters": [
+                    {
+                        "description": "Team payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.addTeamRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamAddOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Команда уже существует или неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/team/delete": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Удаляет команду без участников. Если передан reassign_to, участники предварительно переводятся в указанную команду, она должна существовать и не быть архивной",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Teams"
+                ],
+                "summary": "Удалить команду",
+                "parameters": [
+                    {
+                        "description": "Delete payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.deleteTeamRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ожидаемая версия (ETag), при несовпадении- 412",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamDeleteOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Команда или команда reassign_to не найдена (REASSIGN_TARGET_NOT_FOUND)",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "В команде остались участники или команда reassign_to архивная",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия изменилась",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/team/get": {
+            "get": {
+                "description": "Возвращает информацию о команде и ее пользователях, при include_subteams- вместе с вложенными подкомандами",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Teams"
+                ],
+                "summary": "Получить команду с участниками",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Имя команды",
+                        "name": "team_name",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Вернуть дерево подкоманд",
+                        "name": "include_subteams",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamGetOutput"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Версия команды для If-Match"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное имя команды",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Команда не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/team/import": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Принимает YAML или CSV с командами, их атрибутами (parent_team_name, reviewers_count, fallback_depth, review_sla, is_archived) и участниками. Файл проверяется целиком: при ошибках возвращается их список с номерами строк и ничего не применяется. Иначе все команды применяются в одной транзакции, при dry_run- возвращается отчет об изменениях без применения. Незаданные атрибуты и участники остаются прежними (parent_team_name \"-\" делает команду корневой), заданный список участников заменяет текущий, участник без is_active сохраняет текущую активность",
+                "consumes": [
+                    "application/yaml",
+                    "text/csv"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Teams"
+                ],
+                "summary": "Массовый импорт команд и пользователей",
+                "parameters": [
+                    {
+                        "enum": [
+                            "yaml",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "Формат файла (по умолчанию yaml)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Только отчет об изменениях",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Файл импорта",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamImportOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибки в файле импорта",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ImportErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Перемещение образует цикл",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/team/rename": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Меняет название команды, ссылки пользователей на команду обновляются автоматически",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Teams"
+                ],
+                "summary": "Переименовать команду",
+                "parameters": [
+                    {
+                        "description": "Rename payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.renameTeamRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ожидаемая версия (ETag), при несовпадении- 412",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamRenameOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Команда не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Команда с новым названием уже существует",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия изменилась",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/team/setIsArchived": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Участники архивной команды не назначаются ревьюверами, история пулл реквестов сохраняется",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Teams"
+                ],
+                "summary": "Архивировать или вернуть команду из архива",
+                "parameters": [
+                    {
+                        "description": "Archive payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.setIsArchivedTeamRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ожидаемая версия (ETag), при несовпадении- 412",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamSetIsArchivedOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Команда не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия изменилась",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/team/setParent": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Делает команду подкомандой указанной родительской команды. Пустой parent_team_name делает команду корневой",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Teams"
+                ],
+                "summary": "Переместить команду в иерархии",
+                "parameters": [
+                    {
+                        "description": "Parent payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.setParentTeamRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ожидаемая версия (ETag), при несовпадении- 412",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamUpdateOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Команда не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Перемещение образует цикл",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия изменилась",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/team/setReviewSLA": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Задает время, за которое ревьювер должен посмотреть пулл реквест команды (\"24h\", \"90m\", \"2d\"); null или пустая строка- не следить за сроком. Назначения, ожидающие дольше, один раз порождают событие pull_request.sla_breached, которое отправляется в webhook'и и чаты команды",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Teams"
+                ],
+                "summary": "Изменить SLA ревью команды",
+                "parameters": [
+                    {
+                        "description": "Review SLA payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.setTeamReviewSLARequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ожидаемая версия (ETag), при несовпадении- 412",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamReviewSLAOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Команда не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия изменилась",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/team/setSettings": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Задает количество ревьюверов на пулл реквест (null- 2) и глубину поиска ревьюверов по иерархии: при нехватке кандидатов в команде они берутся из подкоманд предков до указанного уровня. null- наследовать значение ближайшего предка. Не переданные поля не меняются",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Teams"
+                ],
+                "summary": "Изменить настройки команды",
+                "parameters": [
+                    {
+                        "description": "Settings payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.setTeamSettingsRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ожидаемая версия (ETag), при несовпадении- 412",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamUpdateOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Команда не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия изменилась",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/teams/deactivate": {
+            "post": {
+                "description": "Быстрый метод для массовой деактивации членов определенной команды",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Teams"
+                ],
+                "summary": "Деактивация всех членов команды",
+                "parameters": [
+                    {
+                        "description": "Team to deactivate name",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.deactivateTeamRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ожидаемая версия (ETag), при несовпадении- 412",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamSetIsActiveTeamOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Команда не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия изменилась",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tenants/add": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Создает изолированную организацию и выпускает для нее admin и user ключи. Ключи возвращаются только в этом ответе",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tenants"
+                ],
+                "summary": "Создать организацию",
+                "parameters": [
+                    {
+                        "description": "Tenant payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.addTenantRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TenantAddOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Организация уже существует",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tenants/addKey": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Выпускает новый ключ с ролью admin или user. Ключ возвращается только в этом ответе",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tenants"
+                ],
+                "summary": "Выпустить API ключ организации",
+                "parameters": [
+                    {
+                        "description": "API key payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.addAPIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TenantAddAPIKeyOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Организация не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tenants/revokeKey": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Отозванный ключ перестает проходить авторизацию. Повторный отзыв не является ошибкой",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tenants"
+                ],
+                "summary": "Отозвать API ключ организации",
+                "parameters": [
+                    {
+                        "description": "API key payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.revokeAPIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TenantRevokeAPIKeyOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Ключ не найден",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/get": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает пользователя с его версией: версия передается в If-Match запросов, меняющих пользователя",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Получить пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user_id пользователя",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.UserGetOutput"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Версия пользователя для If-Match"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный user_id",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/getReview": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает список пулл реквестов, назначенных пользователю, включая перенесенные в архив по сроку хранения",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Получить пулл реквесты, в которых пользователь является ревьювером",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user_id пользователя",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.UserGetReviewOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный user_id",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/notificationPreferences": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает каналы, типы событий и тихие часы пользователя, для пользователя без настроек- значения по умолчанию (все уведомления в любое время)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Получить настройки уведомлений пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user_id пользователя",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.UserNotificationPreferencesOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный user_id",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/setEmail": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Задает адрес email уведомлений пользователя, пустой email удаляет адрес. Какие письма отправляются (о каждом назначении и/или ежедневная сводка review_digest), задается настройками уведомлений",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Установить email пользователя",
+                "parameters": [
+                    {
+                        "description": "Email payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.setEmailRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ожидаемая версия (ETag), при несовпадении- 412",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.UserSetEmailOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия изменилась",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/setIsActive": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Позволяет активировать и деактивировать пользователя",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Установить is_active флаг пользователя",
+                "parameters": [
+                    {
+                        "description": "User payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.setIsActiveRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ожидаемая версия (ETag), при несовпадении- 412",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.UserSetIsActiveOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия изменилась",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/setNotificationPreferences": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Заменяет настройки уведомлений пользователя: каналы (пусто- все), типы событий (пусто- все события; ежедневная сводка review_digest отправляется, только если выбрана явно) и тихие часы в часовом поясе пользователя (IANA, по умолчанию UTC). Уведомления, выпавшие на тихие часы, копятся и отправляются после их окончания",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Установить настройки уведомлений пользователя",
+                "parameters": [
+                    {
+                        "description": "Notification preferences payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.setNotificationPreferencesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.UserNotificationPreferencesOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса, часовой пояс или тихие часы",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/setPrimaryTeam": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Делает одну из команд пользователя основной: из нее назначаются ревьюверы на его пулл реквесты по умолчанию",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Установить основную команду пользователя",
+                "parameters": [
+                    {
+                        "description": "Primary team payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.setPrimaryTeamRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ожидаемая версия (ETag), при несовпадении- 412",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.UserSetPrimaryTeamOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Пользователь не состоит в команде",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Версия изменилась",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/telegramLinkCode": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Выдает одноразовый код, которым пользователь привязывает чат с ботом (команда /link \u003ccode\u003e или ссылка на бота). Предыдущий код пользователя перестает действовать",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Выдать код привязки Telegram",
+                "parameters": [
+                    {
+                        "description": "User payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.telegramLinkCodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TelegramLinkCodeOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/vcs/identities/delete": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "После удаления PR автора с этим логином не создаются из webhook'ов провайдера",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "VCS"
+                ],
+                "summary": "Удалить сопоставление логина",
+                "parameters": [
+                    {
+                        "description": "Identity payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.deleteVCSIdentityRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Сопоставление удалено"
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Сопоставление не найдено",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/vcs/identities/list": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает сопоставления логинов систем контроля версий пользователям организации",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "VCS"
+                ],
+                "summary": "Список сопоставлений логинов",
+                "parameters": [
+                    {
+                        "enum": [
+                            "github",
+                            "gitlab"
+                        ],
+                        "type": "string",
+                        "description": "Провайдер",
+                        "name": "provider",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.VCSIdentitiesOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/vcs/identities/set": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Задает пользователя для логина провайдера (github, gitlab), заменяя прежнее сопоставление логина. account_id- числовой идентификатор аккаунта у провайдера, для GitLab обязателен, чтобы PR автора создавались из webhook'ов (в них автор передается только идентификатором). Идентификатор, сопоставленный другому логину, переносится на этот",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "VCS"
+                ],
+                "summary": "Сопоставить логин системы контроля версий пользователю",
+                "parameters": [
+                    {
+                        "description": "Identity payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.setVCSIdentityRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.VCSOutputIdentity"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/vcs/setAPIToken": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Задает токен, которым открытые PR сверяются с провайдером (см. /reconciliation/run), а в GitHub также запрашиваются ревьюверы, назначенные сервисом (request reviewers), и отзываются переназначенные. Пустой токен отключает и то, и другое. Токену GitHub нужен доступ на запись к pull requests, GitLab- read_api. Токен хранится зашифрованным ключом vcs.token_key, без ключа токены не принимаются",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "VCS"
+                ],
+                "summary": "Задать API токен системы контроля версий",
+                "parameters": [
+                    {
+                        "description": "Token payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.setVCSAPITokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.VCSAPITokenOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Ключ шифрования токенов не задан",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/vcs/setWebhookSecret": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Задает секрет, которым провайдер (github, gitlab) подписывает webhook'и организации. Если секрет не передан- генерируется. Секрет возвращается только в этом ответе",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "VCS"
+                ],
+                "summary": "Задать секрет webhook'ов системы контроля версий",
+                "parameters": [
+                    {
+                        "description": "Secret payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.setVCSWebhookSecretRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.VCSWebhookSecretOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/deliveries/list": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает доставки от новых к старым. status=DEAD- доставки, исчерпавшие попытки (dead-letter). Следующая страница- по next_cursor из ответа",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "История доставок webhook'ов",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор подписки",
+                        "name": "subscription_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "PENDING",
+                            "DELIVERED",
+                            "DEAD"
+                        ],
+                        "type": "string",
+                        "description": "Статус доставки",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы (по умолчанию 50, не больше 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "next_cursor предыдущей страницы",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.WebhookDeliveriesOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/deliveries/retry": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает доставку в статусе DEAD в очередь с новым набором попыток. Доставки в других статусах не меняются (requeued=false)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Повторить доставку webhook'а",
+                "parameters": [
+                    {
+                        "description": "Delivery payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.retryWebhookDeliveryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.WebhookRetryOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Доставка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/github": {
+            "post": {
+                "description": "Обрабатывает события pull_request GitHub. Подпись X-Hub-Signature-256 проверяется секретом, заданным через /vcs/setWebhookSecret. opened и ready_for_review создают PR (черновики пропускаются), closed мержит или закрывает его, reopened открывает снова. Идентификатор PR- \"\u003cowner\u003e/\u003crepo\u003e#\u003cномер\u003e\", автор определяется по сопоставлению логинов /vcs/identities, PR автора без сопоставления пропускается (result=unknown_author). Повторная доставка с тем же X-GitHub-Delivery не обрабатывается (duplicate=true). Организация- по параметру tenant, по умолчанию- организация по умолчанию",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "VCS"
+                ],
+                "summary": "Принять webhook GitHub",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Название организации",
+                        "name": "tenant",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Тип события",
+                        "name": "X-GitHub-Event",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Идентификатор доставки",
+                        "name": "X-GitHub-Delivery",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "HMAC-SHA256 подпись тела (sha256=\u003chex\u003e)",
+                        "name": "X-Hub-Signature-256",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Payload события GitHub",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.VCSWebhookOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса или заголовки",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверная подпись или организация не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/gitlab": {
+            "post": {
+                "description": "Обрабатывает события Merge Request Hook GitLab. X-Gitlab-Token сверяется с секретом, заданным через /vcs/setWebhookSecret. open и update, снимающий статус черновика, создают PR (черновики пропускаются), merge мержит его, close закрывает, reopen открывает снова. Идентификатор PR- \"\u003cgroup\u003e/\u003cproject\u003e!\u003ciid\u003e\". Автор определяется по author_id merge request'а через account_id сопоставлений /vcs/identities (provider gitlab), PR автора без сопоставления пропускается (result=unknown_author). Повторная доставка с тем же Idempotency-Key (X-Gitlab-Event-UUID в старых версиях GitLab) не обрабатывается (duplicate=true). Организация- по параметру tenant, по умолчанию- организация по умолчанию",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "VCS"
+                ],
+                "summary": "Принять webhook GitLab",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Название организации",
+                        "name": "tenant",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Тип события",
+                        "name": "X-Gitlab-Event",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Секрет webhook'а",
+                        "name": "X-Gitlab-Token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Идентификатор доставки, не меняется между повторами",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Идентификатор события, если нет Idempotency-Key",
+                        "name": "X-Gitlab-Event-UUID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Payload события GitLab",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.VCSWebhookOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса или заголовки",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверный токен или организация не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/slack": {
+            "post": {
+                "description": "Выполняет текстовую команду от имени пользователя, сопоставленного через /chatops/identities/set идентификатору вызвавшего в Slack (user_id); несопоставленному отвечается, что нужно обратиться к администратору. Подпись X-Slack-Signature проверяется секретом, заданным через /chatops/setSigningSecret, запросы старше 5 минут отклоняются. Команды: \"assign \u003cpr\u003e [название]\" создает PR вызвавшего и назначает ревьюверов (для существующего PR- показывает ревьюверов), \"reassign \u003cpr\u003e [@ревьювер]\" передает ревью вызвавшего другому ревьюверу (ревью упомянутого сопоставленного пользователя Slack- только ему самому или администратору), \"merge \u003cpr\u003e\" мержит PR (только автору PR или администратору), \"away until \u003cYYYY-MM-DD\u003e\" деактивирует вызвавшего до указанного дня, \"back\" активирует его снова. Ответ- эфемерное сообщение, ошибки выполнения команды описываются в его тексте. Организация- по параметру tenant, по умолчанию- организация по умолчанию",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ChatOps"
+                ],
+                "summary": "Принять slash-команду Slack",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Название организации",
+                        "name": "tenant",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Время отправки, unix",
+                        "name": "X-Slack-Request-Timestamp",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "HMAC-SHA256 подпись (v0=\u003chex\u003e)",
+                        "name": "X-Slack-Signature",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Идентификатор вызвавшего пользователя в Slack",
+                        "name": "user_id",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Текст команды",
+                        "name": "text",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.ChatOpsCommandOutput"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверная подпись или организация не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/subscriptions/add": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Регистрирует URL, на который POST-запросом отправляются события (pull_request.created, pull_request.merged, pull_request.closed, pull_request.reopened, pull_request.reviewer_reassigned, pull_request.sla_breached; пустой event_types- все события). Тело запроса подписывается HMAC-SHA256 секретом подписки, подпись- в заголовке X-Webhook-Signature-256 (sha256=\u003chex\u003e). Неуспешные доставки повторяются с экспоненциальной задержкой, после исчерпания попыток доставка получает статус DEAD. Секрет возвращается только в этом ответе, если он не передан- генерируется. URL должен вести на публичный адрес: адреса loopback, частных сетей и link-local (в том числе метаданные облака) отклоняются при регистрации и при каждой отправке, редиректы не выполняются",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Подписать webhook на события",
+                "parameters": [
+                    {
+                        "description": "Subscription payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.addWebhookSubscriptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.WebhookOutputSubscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса или непубличный URL",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Подписка на этот URL уже существует",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/subscriptions/delete": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Удаляет подписку вместе с историей ее доставок",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Удалить webhook подписку",
+                "parameters": [
+                    {
+                        "description": "Subscription payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.deleteWebhookSubscriptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Подписка удалена"
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/subscriptions/list": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает подписки организации (без секретов)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Список webhook подписок",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.WebhookSubscriptionsOutput"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/subscriptions/setIsActive": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Выключенная подписка не получает новые события, уже созданные доставки продолжают отправляться",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Включить/выключить webhook подписку",
+                "parameters": [
+                    {
+                        "description": "Subscription status payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.setWebhookSubscriptionIsActiveRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.WebhookOutputSubscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверное тело запроса",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Ошибка авторизации",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/internal_controller_http_v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.AuditOutput": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.AuditOutputEntry"
+                    }
+                },
+                "next_cursor": {
+                    "description": "pass as cursor to get the next page",
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.AuditOutputEntry": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actor": {
+                    "type": "string"
+                },
+                "after": {
+                    "type": "object"
+                },
+                "before": {
+                    "type": "object"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "entity_id": {
+                    "type": "string"
+                },
+                "entity_type": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "request_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.ChatChannelOutput": {
+            "type": "object",
+            "properties": {
+                "channel_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "is_active": {
+                    "type": "boolean"
+                },
+                "team_name": {
+                    "type": "string"
+                },
+                "templates": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "url": {
+                    "description": "path and query are masked",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.ChatChannelsOutput": {
+            "type": "object",
+            "properties": {
+                "channels": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.ChatChannelOutput"
+                    }
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.ChatOpsCommandOutput": {
+            "type": "object",
+            "properties": {
+                "response_type": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.ChatOpsIdentitiesOutput": {
+            "type": "object",
+            "properties": {
+                "identities": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.ChatOpsOutputIdentity"
+                    }
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.ChatOpsOutputIdentity": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "is_admin": {
+                    "type": "boolean"
+                },
+                "slack_user_id": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.ChatOpsSigningSecretOutput": {
+            "type": "object",
+            "properties": {
+                "secret": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.ImportLineError": {
+            "type": "object",
+            "properties": {
+                "line": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.NotificationOutput": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "channel": {
+                    "type": "string"
+                },
+                "chat_channel_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "digest_date": {
+                    "type": "string"
+                },
+                "event_id": {
+                    "description": "empty for digests",
+                    "type": "integer"
+                },
+                "event_type": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "description": "only for pending notifications",
+                    "type": "string"
+                },
+                "notification_id": {
+                    "type": "integer"
+                },
+                "recipient_id": {
+                    "type": "string"
+                },
+                "sent_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.NotificationPreferencesOutput": {
+            "type": "object",
+            "properties": {
+                "channels": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "quiet_hours": {
+                    "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.QuietHours"
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "description": "empty until the user sets preferences",
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.NotificationRetryOutput": {
+            "type": "object",
+            "properties": {
+                "notification": {
+                    "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.NotificationOutput"
+                },
+                "requeued": {
+                    "description": "false when the notification was not dead",
+                    "type": "boolean"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.NotificationsOutput": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "description": "pass as cursor to get the next page",
+                    "type": "integer"
+                },
+                "notifications": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.NotificationOutput"
+                    }
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.PullRequestCreateOutput": {
+            "type": "object",
+            "properties": {
+                "pr": {
+                    "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.PullRequestCreateOutputPR"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.PullRequestCreateOutputPR": {
+            "type": "object",
+            "properties": {
+                "assigned_reviewers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "author_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "pull_request_id": {
+                    "type": "string"
+                },
+                "pull_request_name": {
+                    "type": "string"
+                },
+                "repository_name": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "team_name": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.PullRequestGetOutput": {
+            "type": "object",
+            "properties": {
+                "pr": {
+                    "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.PullRequestGetOutputPR"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.PullRequestGetOutputPR": {
+            "type": "object",
+            "properties": {
+                "assigned_reviewers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "author_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "merged_at": {
+                    "type": "string"
+                },
+                "needs_more_reviewers": {
+                    "type": "boolean"
+                },
+                "pull_request_id": {
+                    "type": "string"
+                },
+                "pull_request_name": {
+                    "type": "string"
+                },
+                "repository_name": {
+                    "type": "string"
+                },
+                "reviewer_sync": {
+                    "description": "only for pull requests synced with GitHub",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.ReviewerSyncOutput"
+                        }
+                    ]
+                },
+                "status": {
+                    "type": "string"
+                },
+                "team_name": {
+                    "type": "string"
+                },
+                "vcs_provider": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.PullRequestMergeOutput": {
+            "type": "object",
+            "properties": {
+                "pr": {
+                    "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.PullRequestMergeOutputPR"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.PullRequestMergeOutputPR": {
+            "type": "object",
+            "properties": {
+                "assigned_reviewers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "author_id": {
+                    "type": "string"
+                },
+                "mergedAt": {
+                    "type": "string"
+                },
+                "pull_request_id": {
+                    "type": "string"
+                },
+                "pull_request_name": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.PullRequestReassignOutput": {
+            "type": "object",
+            "properties": {
+                "pr": {
+                    "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.PullRequestReassignOutputPR"
+                },
+                "replaced_by": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.PullRequestReassignOutputPR": {
+            "type": "object",
+            "properties": {
+                "assigned_reviewers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "author_id": {
+                    "type": "string"
+                },
+                "pull_request_id": {
+                    "type": "string"
+                },
+                "pull_request_name": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.PullRequestStatusOutput": {
+            "type": "object",
+            "properties": {
+                "pr": {
+                    "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.PullRequestStatusOutputPR"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.PullRequestStatusOutputPR": {
+            "type": "object",
+            "properties": {
                 "assigned_reviewers": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
-                "author_id": {
+                "author_id": {
+                    "type": "string"
+                },
+                "pull_request_id": {
+                    "type": "string"
+                },
+                "pull_request_name": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.QuietHours": {
+            "type": "object",
+            "required": [
+                "end",
+                "start"
+            ],
+            "properties": {
+                "end": {
+                    "type": "string"
+                },
+                "start": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.ReconciliationCorrectionsOutput": {
+            "type": "object",
+            "properties": {
+                "corrections": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.ReconciliationOutputCorrection"
+                    }
+                },
+                "next_cursor": {
+                    "description": "pass as cursor to get the next page",
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.ReconciliationOutput": {
+            "type": "object",
+            "properties": {
+                "checked": {
+                    "type": "integer"
+                },
+                "corrections": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.ReconciliationOutputCorrection"
+                    }
+                },
+                "failures": {
+                    "description": "pull requests the provider was not asked about",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.ReconciliationOutputFailure"
+                    }
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.ReconciliationOutputCorrection": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "attempts": {
+                    "type": "integer"
+                },
+                "correction_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "description": "the correction failed",
+                    "type": "string"
+                },
+                "last_attempt_at": {
+                    "description": "of a correction failed more than once",
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "pull_request_id": {
+                    "type": "string"
+                },
+                "replaced_by": {
+                    "type": "string"
+                },
+                "resolved_at": {
+                    "description": "the failed correction was applied later",
+                    "type": "string"
+                },
+                "reviewer_id": {
+                    "description": "reassigned away",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.ReconciliationOutputFailure": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "pull_request_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.RepositoryOutput": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "fallback_depth": {
+                    "type": "integer"
+                },
+                "repository_name": {
+                    "type": "string"
+                },
+                "reviewers_count": {
+                    "type": "integer"
+                },
+                "team_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.ReviewerSyncOutput": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "description": "only for pending syncs",
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "synced_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.ReviewerSyncResyncOutput": {
+            "type": "object",
+            "properties": {
+                "pull_request_id": {
+                    "type": "string"
+                },
+                "reviewer_sync": {
+                    "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.ReviewerSyncOutput"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.SnapshotArchive": {
+            "type": "object",
+            "properties": {
+                "checksum": {
+                    "description": "\"sha256:\u003chex\u003e\" of the compact data",
+                    "type": "string"
+                },
+                "counts": {
+                    "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.SnapshotCounts"
+                },
+                "data": {
+                    "type": "object"
+                },
+                "exported_at": {
+                    "type": "string"
+                },
+                "format_version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.SnapshotCounts": {
+            "type": "object",
+            "properties": {
+                "archived_pull_requests": {
+                    "type": "integer"
+                },
+                "archived_reviewers": {
+                    "type": "integer"
+                },
+                "notification_preferences": {
+                    "type": "integer"
+                },
+                "pull_requests": {
+                    "type": "integer"
+                },
+                "repositories": {
+                    "type": "integer"
+                },
+                "reviewers": {
+                    "type": "integer"
+                },
+                "slack_identities": {
+                    "type": "integer"
+                },
+                "team_members": {
+                    "type": "integer"
+                },
+                "teams": {
+                    "type": "integer"
+                },
+                "users": {
+                    "type": "integer"
+                },
+                "vcs_identities": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.SnapshotRestoreOutput": {
+            "type": "object",
+            "properties": {
+                "counts": {
+                    "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.SnapshotCounts"
+                },
+                "format_version": {
+                    "description": "of the restored archive",
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.StatsOutputPercentiles": {
+            "type": "object",
+            "properties": {
+                "p50": {
+                    "type": "number"
+                },
+                "p90": {
+                    "type": "number"
+                },
+                "p99": {
+                    "type": "number"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.StatsOutputTeam": {
+            "type": "object",
+            "properties": {
+                "assigned": {
+                    "type": "integer"
+                },
+                "completed": {
+                    "type": "integer"
+                },
+                "open_load": {
+                    "type": "integer"
+                },
+                "reassigned_away": {
+                    "type": "integer"
+                },
+                "team_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.StatsOutputTeamFlow": {
+            "type": "object",
+            "properties": {
+                "merged": {
+                    "type": "integer"
+                },
+                "reassigned": {
+                    "type": "integer"
+                },
+                "team_name": {
+                    "type": "string"
+                },
+                "time_to_first_reassign": {
+                    "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.StatsOutputPercentiles"
+                },
+                "time_to_merge": {
+                    "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.StatsOutputPercentiles"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.StatsOutputUser": {
+            "type": "object",
+            "properties": {
+                "assigned": {
+                    "type": "integer"
+                },
+                "completed": {
+                    "type": "integer"
+                },
+                "open_load": {
+                    "type": "integer"
+                },
+                "reassigned_away": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.StatsOutputUserFlow": {
+            "type": "object",
+            "properties": {
+                "merged": {
+                    "type": "integer"
+                },
+                "reassigned": {
+                    "type": "integer"
+                },
+                "time_to_first_reassign": {
+                    "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.StatsOutputPercentiles"
+                },
+                "time_to_merge": {
+                    "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.StatsOutputPercentiles"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.StatsTeamFlowOutput": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "teams": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.StatsOutputTeamFlow"
+                    }
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.StatsTeamOutput": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "teams": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.StatsOutputTeam"
+                    }
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.StatsUserFlowOutput": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.StatsOutputUserFlow"
+                    }
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.StatsUserOutput": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.StatsOutputUser"
+                    }
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamAddOutput": {
+            "type": "object",
+            "properties": {
+                "team": {
+                    "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamAddOutputTeam"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamAddOutputTeam": {
+            "type": "object",
+            "properties": {
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamOutputMember"
+                    }
+                },
+                "team_name": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamDeleteOutput": {
+            "type": "object",
+            "properties": {
+                "members_reassigned": {
+                    "type": "integer"
+                },
+                "reassigned_to": {
+                    "type": "string"
+                },
+                "team_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamGetOutput": {
+            "type": "object",
+            "properties": {
+                "fallback_depth": {
+                    "type": "integer"
+                },
+                "is_archived": {
+                    "type": "boolean"
+                },
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamOutputMember"
+                    }
+                },
+                "parent_team_name": {
+                    "type": "string"
+                },
+                "review_sla": {
+                    "description": "\"24h0m0s\", null when reviews are not timed",
+                    "type": "string"
+                },
+                "reviewers_count": {
+                    "type": "integer"
+                },
+                "subteams": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamGetOutput"
+                    }
+                },
+                "team_name": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamImportOutput": {
+            "type": "object",
+            "properties": {
+                "changed": {
+                    "type": "boolean"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "teams": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamImportOutputTeam"
+                    }
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamImportOutputTeam": {
+            "type": "object",
+            "properties": {
+                "activity_changed": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamUpsertOutputChange"
+                    }
+                },
+                "added": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamUpsertOutputChange"
+                    }
+                },
+                "changed": {
+                    "type": "boolean"
+                },
+                "removed": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamUpsertOutputChange"
+                    }
+                },
+                "team_created": {
+                    "type": "boolean"
+                },
+                "team_name": {
+                    "type": "string"
+                },
+                "updated_attributes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "username_changed": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamUpsertOutputChange"
+                    }
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamOutputMember": {
+            "type": "object",
+            "properties": {
+                "is_active": {
+                    "type": "boolean"
+                },
+                "is_primary": {
+                    "type": "boolean"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamRenameOutput": {
+            "type": "object",
+            "properties": {
+                "old_team_name": {
+                    "type": "string"
+                },
+                "team_name": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamReviewSLAOutput": {
+            "type": "object",
+            "properties": {
+                "review_sla": {
+                    "type": "string"
+                },
+                "team_name": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamSetIsActiveTeamOutput": {
+            "type": "object",
+            "properties": {
+                "users_updated": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamSetIsArchivedOutput": {
+            "type": "object",
+            "properties": {
+                "archived_at": {
+                    "type": "string"
+                },
+                "is_archived": {
+                    "type": "boolean"
+                },
+                "team_name": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamUpdateOutput": {
+            "type": "object",
+            "properties": {
+                "fallback_depth": {
+                    "type": "integer"
+                },
+                "parent_team_name": {
+                    "type": "string"
+                },
+                "reviewers_count": {
+                    "type": "integer"
+                },
+                "team_name": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamUpsertOutput": {
+            "type": "object",
+            "properties": {
+                "activity_changed": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamUpsertOutputChange"
+                    }
+                },
+                "added": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamUpsertOutputChange"
+                    }
+                },
+                "changed": {
+                    "type": "boolean"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "removed": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamUpsertOutputChange"
+                    }
+                },
+                "team_created": {
+                    "type": "boolean"
+                },
+                "team_name": {
+                    "type": "string"
+                },
+                "username_changed": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamUpsertOutputChange"
+                    }
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamUpsertOutputChange": {
+            "type": "object",
+            "properties": {
+                "is_active": {
+                    "type": "boolean"
+                },
+                "prev_is_active": {
+                    "type": "boolean"
+                },
+                "prev_team_name": {
+                    "description": "PrevTeamName is kept for clients written before users could be in several teams",
+                    "type": "string"
+                },
+                "prev_username": {
+                    "type": "string"
+                },
+                "primary_team_name": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.TelegramLinkCodeOutput": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "command": {
+                    "description": "to send to the bot",
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "url": {
+                    "description": "opens the bot with the code, when its username is configured",
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.TenantAddAPIKeyOutput": {
+            "type": "object",
+            "properties": {
+                "api_key": {
+                    "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TenantOutputAPIKey"
+                },
+                "tenant_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.TenantAddOutput": {
+            "type": "object",
+            "properties": {
+                "api_keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TenantOutputAPIKey"
+                    }
+                },
+                "tenant_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.TenantOutputAPIKey": {
+            "type": "object",
+            "properties": {
+                "api_key": {
+                    "type": "string"
+                },
+                "key_id": {
+                    "type": "integer"
+                },
+                "role": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.TenantRevokeAPIKeyOutput": {
+            "type": "object",
+            "properties": {
+                "key_id": {
+                    "type": "integer"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.UserGetOutput": {
+            "type": "object",
+            "properties": {
+                "user": {
+                    "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.UserGetOutputUser"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.UserGetOutputUser": {
+            "type": "object",
+            "properties": {
+                "away_until": {
+                    "description": "\"2006-01-02\", the day an away user is activated again",
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "is_active": {
+                    "type": "boolean"
+                },
+                "team_name": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.UserGetReviewOutput": {
+            "type": "object",
+            "properties": {
+                "pull_requests": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.UserReviewOutputPR"
+                    }
+                },
+                "teams": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.UserOutputTeam"
+                    }
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.UserNotificationPreferencesOutput": {
+            "type": "object",
+            "properties": {
+                "preferences": {
+                    "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.NotificationPreferencesOutput"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.UserOutputTeam": {
+            "type": "object",
+            "properties": {
+                "is_primary": {
+                    "type": "boolean"
+                },
+                "team_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.UserReviewOutputPR": {
+            "type": "object",
+            "properties": {
+                "author_id": {
+                    "type": "string"
+                },
+                "pull_request_id": {
+                    "type": "string"
+                },
+                "pull_request_name": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "team_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.UserSetEmailOutput": {
+            "type": "object",
+            "properties": {
+                "user": {
+                    "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.UserSetEmailOutputUser"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.UserSetEmailOutputUser": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.UserSetIsActiveOutput": {
+            "type": "object",
+            "properties": {
+                "user": {
+                    "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.UserSetIsActiveOutputUser"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.UserSetIsActiveOutputUser": {
+            "type": "object",
+            "properties": {
+                "away_until": {
+                    "description": "\"2006-01-02\", the day an away user is activated again",
+                    "type": "string"
+                },
+                "is_active": {
+                    "type": "boolean"
+                },
+                "team_name": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.UserSetPrimaryTeamOutput": {
+            "type": "object",
+            "properties": {
+                "user": {
+                    "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.UserSetIsActiveOutputUser"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.VCSAPITokenOutput": {
+            "type": "object",
+            "properties": {
+                "provider": {
+                    "type": "string"
+                },
+                "reconciliation": {
+                    "description": "open pull requests are checked against the provider",
+                    "type": "boolean"
+                },
+                "reviewer_sync": {
+                    "description": "reviewers are requested in the provider",
+                    "type": "boolean"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.VCSIdentitiesOutput": {
+            "type": "object",
+            "properties": {
+                "identities": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.VCSOutputIdentity"
+                    }
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.VCSOutputIdentity": {
+            "type": "object",
+            "properties": {
+                "account_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "login": {
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.VCSWebhookOutput": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "delivery_id": {
+                    "type": "string"
+                },
+                "duplicate": {
+                    "description": "the delivery was already received",
+                    "type": "boolean"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "pull_request_id": {
+                    "type": "string"
+                },
+                "result": {
+                    "description": "empty for a duplicate still being processed",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.VCSWebhookSecretOutput": {
+            "type": "object",
+            "properties": {
+                "provider": {
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.WebhookDeliveriesOutput": {
+            "type": "object",
+            "properties": {
+                "deliveries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.WebhookOutputDelivery"
+                    }
+                },
+                "next_cursor": {
+                    "description": "pass as cursor to get the next page",
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.WebhookOutputDelivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "delivered_at": {
+                    "type": "string"
+                },
+                "delivery_id": {
+                    "type": "integer"
+                },
+                "event_id": {
+                    "type": "integer"
+                },
+                "event_type": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_status_code": {
+                    "type": "integer"
+                },
+                "next_attempt_at": {
+                    "description": "only for pending deliveries",
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "subscription_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.WebhookOutputSubscription": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "is_active": {
+                    "type": "boolean"
+                },
+                "secret": {
+                    "description": "returned only on creation",
                     "type": "string"
                 },
-                "mergedAt": {
+                "subscription_id": {
+                    "type": "integer"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.WebhookRetryOutput": {
+            "type": "object",
+            "properties": {
+                "delivery": {
+                    "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.WebhookOutputDelivery"
+                },
+                "requeued": {
+                    "description": "false when the delivery was not dead",
+                    "type": "boolean"
+                }
+            }
+        },
+        "github_com_MatTwix_Pull-Request-Assigner_internal_service.WebhookSubscriptionsOutput": {
+            "type": "object",
+            "properties": {
+                "subscriptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.WebhookOutputSubscription"
+                    }
+                }
+            }
+        },
+        "internal_controller_http_v1.ErrorBody": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controller_http_v1.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/internal_controller_http_v1.ErrorBody"
+                }
+            }
+        },
+        "internal_controller_http_v1.ImportErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/internal_controller_http_v1.ErrorBody"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.ImportLineError"
+                    }
+                }
+            }
+        },
+        "internal_controller_http_v1.addAPIKeyRequest": {
+            "type": "object",
+            "required": [
+                "role",
+                "tenant_name"
+            ],
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "admin",
+                        "user"
+                    ]
+                },
+                "tenant_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controller_http_v1.addChatChannelRequest": {
+            "type": "object",
+            "required": [
+                "team_name",
+                "url"
+            ],
+            "properties": {
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "team_name": {
+                    "type": "string"
+                },
+                "templates": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controller_http_v1.addRepositoryRequest": {
+            "type": "object",
+            "required": [
+                "repository_name"
+            ],
+            "properties": {
+                "fallback_depth": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "repository_name": {
+                    "type": "string"
+                },
+                "reviewers_count": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "team_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controller_http_v1.addTeamRequest": {
+            "type": "object",
+            "required": [
+                "members",
+                "team_name"
+            ],
+            "properties": {
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_controller_http_v1.teamMember"
+                    }
+                },
+                "team_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controller_http_v1.addTenantRequest": {
+            "type": "object",
+            "required": [
+                "tenant_name"
+            ],
+            "properties": {
+                "tenant_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controller_http_v1.addWebhookSubscriptionRequest": {
+            "type": "object",
+            "required": [
+                "url"
+            ],
+            "properties": {
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "secret": {
+                    "type": "string",
+                    "minLength": 16
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controller_http_v1.createPRRequest": {
+            "type": "object",
+            "required": [
+                "author_id",
+                "pull_request_id",
+                "pull_request_name"
+            ],
+            "properties": {
+                "author_id": {
                     "type": "string"
                 },
                 "pull_request_id": {
@@ -566,259 +6392,451 @@ const docTemplate = `{
                 "pull_request_name": {
                     "type": "string"
                 },
-                "status": {
+                "repository": {
+                    "type": "string"
+                },
+                "team_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controller_http_v1.deactivateTeamRequest": {
+            "type": "object",
+            "required": [
+                "team_name"
+            ],
+            "properties": {
+                "team_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controller_http_v1.deleteChatChannelRequest": {
+            "type": "object",
+            "required": [
+                "channel_id"
+            ],
+            "properties": {
+                "channel_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_controller_http_v1.deleteChatOpsIdentityRequest": {
+            "type": "object",
+            "required": [
+                "slack_user_id"
+            ],
+            "properties": {
+                "slack_user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controller_http_v1.deleteTeamRequest": {
+            "type": "object",
+            "required": [
+                "team_name"
+            ],
+            "properties": {
+                "reassign_to": {
+                    "type": "string"
+                },
+                "team_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controller_http_v1.deleteVCSIdentityRequest": {
+            "type": "object",
+            "required": [
+                "login",
+                "provider"
+            ],
+            "properties": {
+                "login": {
                     "type": "string"
+                },
+                "provider": {
+                    "type": "string",
+                    "enum": [
+                        "github",
+                        "gitlab"
+                    ]
+                }
+            }
+        },
+        "internal_controller_http_v1.deleteWebhookSubscriptionRequest": {
+            "type": "object",
+            "required": [
+                "subscription_id"
+            ],
+            "properties": {
+                "subscription_id": {
+                    "type": "integer"
                 }
             }
         },
-        "github_com_MatTwix_Pull-Request-Assigner_internal_service.PullRequestReassignOutput": {
+        "internal_controller_http_v1.mergePRRequest": {
             "type": "object",
+            "required": [
+                "pull_request_id"
+            ],
             "properties": {
-                "pr": {
-                    "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.PullRequestReassignOutputPR"
-                },
-                "replaced_by": {
+                "pull_request_id": {
                     "type": "string"
                 }
             }
         },
-        "github_com_MatTwix_Pull-Request-Assigner_internal_service.PullRequestReassignOutputPR": {
+        "internal_controller_http_v1.reassignRequest": {
             "type": "object",
             "properties": {
-                "assigned_reviewers": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
-                },
-                "author_id": {
+                "old_user_id": {
                     "type": "string"
                 },
                 "pull_request_id": {
                     "type": "string"
-                },
-                "pull_request_name": {
+                }
+            }
+        },
+        "internal_controller_http_v1.renameTeamRequest": {
+            "type": "object",
+            "required": [
+                "new_team_name",
+                "team_name"
+            ],
+            "properties": {
+                "new_team_name": {
                     "type": "string"
                 },
-                "status": {
+                "team_name": {
                     "type": "string"
                 }
             }
         },
-        "github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamAddOutput": {
+        "internal_controller_http_v1.retryNotificationRequest": {
             "type": "object",
+            "required": [
+                "notification_id"
+            ],
             "properties": {
-                "team": {
-                    "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamAddOutputTeam"
+                "notification_id": {
+                    "type": "integer"
                 }
             }
         },
-        "github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamAddOutputTeam": {
+        "internal_controller_http_v1.retryWebhookDeliveryRequest": {
             "type": "object",
+            "required": [
+                "delivery_id"
+            ],
             "properties": {
-                "members": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamOutputMember"
-                    }
-                },
-                "team_name": {
-                    "type": "string"
+                "delivery_id": {
+                    "type": "integer"
                 }
             }
         },
-        "github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamGetOutput": {
+        "internal_controller_http_v1.revokeAPIKeyRequest": {
             "type": "object",
+            "required": [
+                "key_id"
+            ],
             "properties": {
-                "members": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamOutputMember"
-                    }
-                },
-                "team_name": {
-                    "type": "string"
+                "key_id": {
+                    "type": "integer"
                 }
             }
         },
-        "github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamOutputMember": {
+        "internal_controller_http_v1.setChatChannelIsActiveRequest": {
             "type": "object",
+            "required": [
+                "channel_id",
+                "is_active"
+            ],
             "properties": {
+                "channel_id": {
+                    "type": "integer"
+                },
                 "is_active": {
                     "type": "boolean"
+                }
+            }
+        },
+        "internal_controller_http_v1.setChatOpsIdentityRequest": {
+            "type": "object",
+            "required": [
+                "slack_user_id",
+                "user_id"
+            ],
+            "properties": {
+                "is_admin": {
+                    "type": "boolean"
                 },
-                "user_id": {
+                "slack_user_id": {
                     "type": "string"
                 },
-                "username": {
+                "user_id": {
                     "type": "string"
                 }
             }
         },
-        "github_com_MatTwix_Pull-Request-Assigner_internal_service.TeamSetIsActiveTeamOutput": {
+        "internal_controller_http_v1.setChatOpsSigningSecretRequest": {
             "type": "object",
             "properties": {
-                "users_updated": {
-                    "type": "integer"
+                "secret": {
+                    "type": "string",
+                    "minLength": 16
                 }
             }
         },
-        "github_com_MatTwix_Pull-Request-Assigner_internal_service.UserGetReviewOutput": {
+        "internal_controller_http_v1.setEmailRequest": {
             "type": "object",
+            "required": [
+                "user_id"
+            ],
             "properties": {
-                "pull_requests": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.UserReviewOutputPR"
-                    }
+                "email": {
+                    "type": "string"
                 },
                 "user_id": {
                     "type": "string"
                 }
             }
         },
-        "github_com_MatTwix_Pull-Request-Assigner_internal_service.UserReviewOutputPR": {
+        "internal_controller_http_v1.setIsActiveRequest": {
             "type": "object",
+            "required": [
+                "user_id"
+            ],
             "properties": {
-                "author_id": {
-                    "type": "string"
-                },
-                "pull_request_id": {
-                    "type": "string"
-                },
-                "pull_request_name": {
-                    "type": "string"
+                "is_active": {
+                    "type": "boolean"
                 },
-                "status": {
+                "user_id": {
                     "type": "string"
                 }
             }
         },
-        "github_com_MatTwix_Pull-Request-Assigner_internal_service.UserSetIsActiveOutput": {
+        "internal_controller_http_v1.setIsArchivedTeamRequest": {
             "type": "object",
+            "required": [
+                "team_name"
+            ],
             "properties": {
-                "user": {
-                    "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.UserSetIsActiveOutputUser"
+                "is_archived": {
+                    "type": "boolean"
+                },
+                "team_name": {
+                    "type": "string"
                 }
             }
         },
-        "github_com_MatTwix_Pull-Request-Assigner_internal_service.UserSetIsActiveOutputUser": {
+        "internal_controller_http_v1.setNotificationPreferencesRequest": {
             "type": "object",
+            "required": [
+                "user_id"
+            ],
             "properties": {
-                "is_active": {
-                    "type": "boolean"
+                "channels": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "team_name": {
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "quiet_hours": {
+                    "$ref": "#/definitions/github_com_MatTwix_Pull-Request-Assigner_internal_service.QuietHours"
+                },
+                "timezone": {
                     "type": "string"
                 },
                 "user_id": {
                     "type": "string"
-                },
-                "username": {
+                }
+            }
+        },
+        "internal_controller_http_v1.setPRStatusRequest": {
+            "type": "object",
+            "required": [
+                "pull_request_id"
+            ],
+            "properties": {
+                "pull_request_id": {
                     "type": "string"
                 }
             }
         },
-        "internal_controller_http_v1.ErrorBody": {
+        "internal_controller_http_v1.setParentTeamRequest": {
             "type": "object",
+            "required": [
+                "team_name"
+            ],
             "properties": {
-                "code": {
+                "parent_team_name": {
                     "type": "string"
                 },
-                "message": {
+                "team_name": {
                     "type": "string"
                 }
             }
         },
-        "internal_controller_http_v1.ErrorResponse": {
+        "internal_controller_http_v1.setPrimaryTeamRequest": {
             "type": "object",
+            "required": [
+                "team_name",
+                "user_id"
+            ],
             "properties": {
-                "error": {
-                    "$ref": "#/definitions/internal_controller_http_v1.ErrorBody"
+                "team_name": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
                 }
             }
         },
-        "internal_controller_http_v1.addTeamRequest": {
+        "internal_controller_http_v1.setRepositoryOwnerRequest": {
             "type": "object",
             "required": [
-                "members",
-                "team_name"
+                "repository_name"
             ],
             "properties": {
-                "members": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/internal_controller_http_v1.teamMember"
-                    }
+                "repository_name": {
+                    "type": "string"
                 },
                 "team_name": {
                     "type": "string"
                 }
             }
         },
-        "internal_controller_http_v1.createPRRequest": {
+        "internal_controller_http_v1.setRepositorySettingsRequest": {
             "type": "object",
             "required": [
-                "author_id",
-                "pull_request_id",
-                "pull_request_name"
+                "repository_name"
             ],
             "properties": {
-                "author_id": {
+                "fallback_depth": {
+                    "type": "integer"
+                },
+                "repository_name": {
                     "type": "string"
                 },
-                "pull_request_id": {
+                "reviewers_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_controller_http_v1.setTeamReviewSLARequest": {
+            "type": "object",
+            "required": [
+                "team_name"
+            ],
+            "properties": {
+                "review_sla": {
                     "type": "string"
                 },
-                "pull_request_name": {
+                "team_name": {
                     "type": "string"
                 }
             }
         },
-        "internal_controller_http_v1.deactivateTeamRequest": {
+        "internal_controller_http_v1.setTeamSettingsRequest": {
             "type": "object",
             "required": [
                 "team_name"
             ],
             "properties": {
+                "fallback_depth": {
+                    "type": "integer"
+                },
+                "reviewers_count": {
+                    "type": "integer"
+                },
                 "team_name": {
                     "type": "string"
                 }
             }
         },
-        "internal_controller_http_v1.mergePRRequest": {
+        "internal_controller_http_v1.setVCSAPITokenRequest": {
             "type": "object",
             "required": [
-                "pull_request_id"
+                "provider"
             ],
             "properties": {
-                "pull_request_id": {
+                "api_token": {
                     "type": "string"
+                },
+                "provider": {
+                    "type": "string",
+                    "enum": [
+                        "github",
+                        "gitlab"
+                    ]
                 }
             }
         },
-        "internal_controller_http_v1.reassignRequest": {
+        "internal_controller_http_v1.setVCSIdentityRequest": {
             "type": "object",
+            "required": [
+                "login",
+                "provider",
+                "user_id"
+            ],
             "properties": {
-                "old_user_id": {
+                "account_id": {
                     "type": "string"
                 },
-                "pull_request_id": {
+                "login": {
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string",
+                    "enum": [
+                        "github",
+                        "gitlab"
+                    ]
+                },
+                "user_id": {
                     "type": "string"
                 }
             }
         },
-        "internal_controller_http_v1.setIsActiveRequest": {
+        "internal_controller_http_v1.setVCSWebhookSecretRequest": {
             "type": "object",
             "required": [
-                "user_id"
+                "provider"
+            ],
+            "properties": {
+                "provider": {
+                    "type": "string",
+                    "enum": [
+                        "github",
+                        "gitlab"
+                    ]
+                },
+                "secret": {
+                    "type": "string",
+                    "minLength": 16
+                }
+            }
+        },
+        "internal_controller_http_v1.setWebhookSubscriptionIsActiveRequest": {
+            "type": "object",
+            "required": [
+                "is_active",
+                "subscription_id"
             ],
             "properties": {
                 "is_active": {
                     "type": "boolean"
                 },
-                "user_id": {
-                    "type": "string"
+                "subscription_id": {
+                    "type": "integer"
                 }
             }
         },
@@ -840,6 +6858,37 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "internal_controller_http_v1.telegramLinkCodeRequest": {
+            "type": "object",
+            "required": [
+                "user_id"
+            ],
+            "properties": {
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_controller_http_v1.upsertTeamRequest": {
+            "type": "object",
+            "required": [
+                "team_name"
+            ],
+            "properties": {
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_controller_http_v1.teamMember"
+                    }
+                },
+                "team_name": {
+                    "type": "string"
+                }
+            }
         }
     },
     "securityDefinitions": {
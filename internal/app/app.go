@@ -1,17 +1,26 @@
 package app
 
 import (
+	"context"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/MatTwix/Pull-Request-Assigner/internal/config"
 	v1 "github.com/MatTwix/Pull-Request-Assigner/internal/controller/http/v1"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
 	"github.com/MatTwix/Pull-Request-Assigner/internal/repo"
 	"github.com/MatTwix/Pull-Request-Assigner/internal/service"
 	"github.com/MatTwix/Pull-Request-Assigner/pkg/database/postgres"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/github"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/gitlab"
 	"github.com/MatTwix/Pull-Request-Assigner/pkg/httpserver"
 	"github.com/MatTwix/Pull-Request-Assigner/pkg/logger"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/mailer"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/secretbox"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/telegram"
 	"github.com/MatTwix/Pull-Request-Assigner/pkg/utils"
 	"github.com/go-chi/chi/v5"
 	"github.com/sirupsen/logrus"
@@ -59,15 +68,170 @@ func Run(configPath string) {
 	log.Info("initializing repositories...")
 	repositories := repo.NewRepositories(pg)
 
+	var vcsTokenBox *secretbox.Box
+	if cfg.VCS.TokenKey != "" {
+		if vcsTokenBox, err = secretbox.New(cfg.VCS.TokenKey); err != nil {
+			log.Fatal("invalid vcs token key", map[string]any{"error": err})
+		}
+	}
+
 	// Services dependencies
 	log.Info("initializing services...")
 	deps := service.ServicesDependencies{
 		Repos:       repositories,
 		AdminAPIKey: cfg.Auth.AdminAPIKey,
 		UserAPIKey:  cfg.Auth.UserAPIKey,
+		RootAPIKey:  cfg.Auth.RootAPIKey,
+
+		VCSClients: map[string]service.VCSClient{
+			models.VCSProviderGitHub: service.NewGitHubVCSClient(github.NewClient(cfg.GitHub.APIURL, cfg.Reconciliation.RequestTimeout)),
+			models.VCSProviderGitLab: service.NewGitLabVCSClient(gitlab.NewClient(cfg.GitLab.APIURL, cfg.Reconciliation.RequestTimeout)),
+		},
+		VCSTokenBox:            vcsTokenBox,
+		ReconciliationPageSize: cfg.Reconciliation.PageSize,
+
+		TelegramLinkCodeTTL: cfg.Telegram.LinkCodeTTL,
+		TelegramBotUsername: cfg.Telegram.BotUsername,
+
+		IdempotencyTTL:         cfg.Idempotency.TTL,
+		IdempotencyLockTimeout: cfg.Idempotency.LockTimeout,
 	}
 	services := service.NewServices(deps)
 
+	// Background workers
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+
+	log.Info("starting webhooks dispatcher...")
+	dispatcher := service.NewWebhookDispatcher(repositories.Webhook, service.WebhookDispatcherConfig{
+		DispatchInterval: cfg.Webhooks.DispatchInterval,
+		BatchSize:        cfg.Webhooks.BatchSize,
+		Workers:          cfg.Webhooks.Workers,
+		RequestTimeout:   cfg.Webhooks.RequestTimeout,
+		MaxAttempts:      cfg.Webhooks.MaxAttempts,
+		RetryBaseDelay:   cfg.Webhooks.RetryBaseDelay,
+		RetryMaxDelay:    cfg.Webhooks.RetryMaxDelay,
+	}, log)
+	workers.Go(func() { dispatcher.Run(workersCtx) })
+
+	log.Info("starting github reviewer sync...")
+	syncCfg := cfg.GitHub.ReviewerSync
+	reviewerSyncer := service.NewReviewerSyncer(
+		repositories.ReviewerSync,
+		github.NewClient(cfg.GitHub.APIURL, syncCfg.RequestTimeout),
+		vcsTokenBox,
+		service.ReviewerSyncerConfig{
+			Interval:       syncCfg.Interval,
+			BatchSize:      syncCfg.BatchSize,
+			RequestTimeout: syncCfg.RequestTimeout,
+			MaxAttempts:    syncCfg.MaxAttempts,
+			RetryBaseDelay: syncCfg.RetryBaseDelay,
+			RetryMaxDelay:  syncCfg.RetryMaxDelay,
+		},
+		log,
+	)
+	workers.Go(func() { reviewerSyncer.Run(workersCtx) })
+
+	if cfg.Reconciliation.Interval > 0 {
+		log.Info("starting reconciliation...")
+		reconciler := service.NewReconciler(services.Reconciliation, cfg.Reconciliation.Interval, log)
+		workers.Go(func() { reconciler.Run(workersCtx) })
+	}
+
+	log.Info("starting notifications dispatcher...")
+	notifyCfg := cfg.Notifications
+	senders := map[string]service.NotificationSender{
+		models.NotificationChannelChat: service.NewChatSender(notifyCfg.Chat.RequestTimeout),
+	}
+
+	if emailCfg := notifyCfg.Email; emailCfg.SMTPHost != "" {
+		emailTemplates, err := service.LoadEmailTemplates(emailCfg.TemplatesDir)
+		if err != nil {
+			log.Fatal("failed to load email templates", map[string]any{"error": err})
+		}
+
+		senders[models.NotificationChannelEmail] = service.NewEmailSender(
+			mailer.New(mailer.Config{
+				Host:     emailCfg.SMTPHost,
+				Port:     emailCfg.SMTPPort,
+				Username: emailCfg.Username,
+				Password: emailCfg.Password,
+				From:     emailCfg.From,
+				Timeout:  emailCfg.RequestTimeout,
+			}),
+			repositories.User,
+			emailTemplates,
+		)
+
+		log.Info("starting email digest scheduler...")
+		digestScheduler := service.NewDigestScheduler(repositories.Notification, emailCfg.DigestHour, time.Minute, log)
+		workers.Go(func() { digestScheduler.Run(workersCtx) })
+	}
+
+	if cfg.Telegram.BotToken != "" {
+		telegramClient := telegram.NewClient(cfg.Telegram.APIURL, cfg.Telegram.BotToken, cfg.Telegram.RequestTimeout)
+		senders[models.NotificationChannelTelegram] = service.NewTelegramSender(telegramClient)
+
+		log.Info("starting telegram bot...")
+		telegramBot := service.NewTelegramBot(
+			telegramClient,
+			repositories.Telegram,
+			services.User,
+			services.PullRequest,
+			service.TelegramBotConfig{PollTimeout: cfg.Telegram.PollTimeout},
+			log,
+		)
+		workers.Go(func() { telegramBot.Run(workersCtx) })
+	}
+
+	notificationDispatcher := service.NewNotificationDispatcher(
+		repositories.Notification,
+		senders,
+		service.NotificationDispatcherConfig{
+			DispatchInterval: notifyCfg.DispatchInterval,
+			BatchSize:        notifyCfg.BatchSize,
+			RequestTimeout:   max(notifyCfg.Chat.RequestTimeout, notifyCfg.Email.RequestTimeout, cfg.Telegram.RequestTimeout),
+			MaxAttempts:      notifyCfg.MaxAttempts,
+			RetryBaseDelay:   notifyCfg.RetryBaseDelay,
+			RetryMaxDelay:    notifyCfg.RetryMaxDelay,
+		},
+		log,
+	)
+	workers.Go(func() { notificationDispatcher.Run(workersCtx) })
+
+	if notifyCfg.ReviewSLA.CheckInterval > 0 {
+		log.Info("starting review sla monitor...")
+		slaMonitor := service.NewSLAMonitor(repositories.PullRequest, notifyCfg.ReviewSLA.CheckInterval, notifyCfg.ReviewSLA.BatchSize, log)
+		workers.Go(func() { slaMonitor.Run(workersCtx) })
+	}
+
+	if cfg.ChatOps.AwayCheckInterval > 0 {
+		log.Info("starting away users monitor...")
+		awayMonitor := service.NewAwayMonitor(repositories.User, cfg.ChatOps.AwayCheckInterval, cfg.ChatOps.AwayBatchSize, log)
+		workers.Go(func() { awayMonitor.Run(workersCtx) })
+	}
+
+	if cfg.Idempotency.CleanupInterval > 0 {
+		log.Info("starting idempotency keys cleaner...")
+		idempotencyCleaner := service.NewIdempotencyCleaner(repositories.Idempotency, cfg.Idempotency.CleanupInterval, cfg.Idempotency.CleanupBatch, log)
+		workers.Go(func() { idempotencyCleaner.Run(workersCtx) })
+	}
+
+	if cfg.Retention.Interval > 0 {
+		retentionJob, err := service.NewRetentionJob(repositories.Retention, service.RetentionJobConfig{
+			Interval:   cfg.Retention.Interval,
+			MergedDays: cfg.Retention.MergedDays,
+			Mode:       cfg.Retention.Mode,
+			BatchSize:  cfg.Retention.BatchSize,
+		}, log)
+		if err != nil {
+			log.Fatal("invalid retention config", map[string]any{"error": err})
+		}
+
+		log.Info("starting pull requests retention job...")
+		workers.Go(func() { retentionJob.Run(workersCtx) })
+	}
+
 	// Handlers and routes
 	log.Info("initializing handlers and routes...")
 	handler := chi.NewRouter()
@@ -97,4 +261,7 @@ func Run(configPath string) {
 	if err = httpServer.Shutdown(); err != nil {
 		log.Error("failed to shut down http server", map[string]any{"error": err})
 	}
+
+	stopWorkers()
+	workers.Wait()
 }
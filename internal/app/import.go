@@ -0,0 +1,131 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/config"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/service"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/tenant"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/database/postgres"
+)
+
+const importUsage = "usage: app import [-dry-run] [-tenant name] [-format yaml|csv] file"
+
+// Import applies a teams import file to the database of the service, the same
+// as POST /team/import. The diff is printed as JSON, problems of the file go to
+// stderr one per line. Returns the exit code of the process.
+func Import(configPath string, args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), importUsage)
+		flags.PrintDefaults()
+	}
+
+	dryRun := flags.Bool("dry-run", false, "only report the changes")
+	tenantName := flags.String("tenant", "", "tenant to import into, the default one when empty")
+	format := flags.String("format", "", "file format, taken from the file extension when empty")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	path := flags.Arg(0)
+
+	input := service.TeamImportInput{
+		Format: *format,
+		DryRun: *dryRun,
+	}
+
+	if input.Format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".csv":
+			input.Format = service.TeamImportFormatCSV
+		default:
+			input.Format = service.TeamImportFormatYAML
+		}
+	}
+
+	var err error
+	if path == "-" {
+		input.Data, err = io.ReadAll(os.Stdin)
+	} else {
+		input.Data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read import file: %v\n", err)
+		return 1
+	}
+
+	cfg, err := config.NewConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config error: %v\n", err)
+		return 1
+	}
+
+	pg, err := postgres.New(cfg.Postgres.Url)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to make new postgres connection: %v\n", err)
+		return 1
+	}
+	defer pg.Close()
+
+	repositories := repo.NewRepositories(pg)
+
+	ctx, err := tenantContext(context.Background(), repositories, *tenantName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	output, err := service.NewTeamService(repositories.Team).ImportTeams(ctx, input)
+	if err != nil {
+		var importErrs service.ImportErrors
+		if errors.As(err, &importErrs) {
+			for _, importErr := range importErrs {
+				if importErr.Line == 0 {
+					fmt.Fprintf(os.Stderr, "%s: %s\n", path, importErr.Message)
+					continue
+				}
+				fmt.Fprintf(os.Stderr, "%s:%d: %s\n", path, importErr.Line, importErr.Message)
+			}
+			return 1
+		}
+
+		fmt.Fprintf(os.Stderr, "failed to import teams: %v\n", err)
+		return 1
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(output)
+
+	return 0
+}
+
+// tenantContext scopes ctx to the tenant of the command, the default tenant
+// when the name is empty.
+func tenantContext(ctx context.Context, repositories *repo.Repositories, tenantName string) (context.Context, error) {
+	if tenantName == "" {
+		return ctx, nil
+	}
+
+	t, err := repositories.Tenant.GetTenantByName(ctx, tenantName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant %s: %w", tenantName, err)
+	}
+
+	return tenant.WithID(ctx, t.ID), nil
+}
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/config"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/service"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/database/postgres"
+)
+
+const snapshotUsage = "usage: app snapshot [-tenant name] export|restore file"
+
+// Snapshot exports the data of a tenant to an archive or restores one into an
+// empty tenant, the same as /snapshots/export and /snapshots/restore. The
+// file is "-" for stdout or stdin. Returns the exit code of the process.
+func Snapshot(configPath string, args []string) int {
+	flags := flag.NewFlagSet("snapshot", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), snapshotUsage)
+		flags.PrintDefaults()
+	}
+
+	tenantName := flags.String("tenant", "", "tenant to export or restore, the default one when empty")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() != 2 || (flags.Arg(0) != "export" && flags.Arg(0) != "restore") {
+		flags.Usage()
+		return 2
+	}
+	command, path := flags.Arg(0), flags.Arg(1)
+
+	var archive []byte
+	if command == "restore" {
+		var err error
+		if path == "-" {
+			archive, err = io.ReadAll(os.Stdin)
+		} else {
+			archive, err = os.ReadFile(path)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to read snapshot: %v\n", err)
+			return 1
+		}
+	}
+
+	cfg, err := config.NewConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config error: %v\n", err)
+		return 1
+	}
+
+	pg, err := postgres.New(cfg.Postgres.Url)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to make new postgres connection: %v\n", err)
+		return 1
+	}
+	defer pg.Close()
+
+	repositories := repo.NewRepositories(pg)
+
+	ctx, err := tenantContext(context.Background(), repositories, *tenantName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	snapshotService := service.NewSnapshotService(repositories.Snapshot)
+	encoder := json.NewEncoder(os.Stdout)
+
+	if command == "restore" {
+		output, err := snapshotService.Restore(ctx, archive)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to restore snapshot: %v\n", err)
+			return 1
+		}
+
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(output)
+
+		return 0
+	}
+
+	exported, err := snapshotService.Export(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to export snapshot: %v\n", err)
+		return 1
+	}
+
+	if path == "-" {
+		_ = encoder.Encode(exported)
+		return 0
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create snapshot file: %v\n", err)
+		return 1
+	}
+
+	if err := errors.Join(json.NewEncoder(file).Encode(exported), file.Close()); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write snapshot: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,36 @@
+// Package audit carries the identity of the caller and the request id through
+// request context down to the repositories, which record them in the audit
+// log together with every change.
+package audit
+
+import "context"
+
+// SystemActor is recorded for changes made outside of API requests.
+const SystemActor = "system"
+
+type (
+	actorKey     struct{}
+	requestIDKey struct{}
+)
+
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// Actor returns the caller of the request or SystemActor when none was set.
+func Actor(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+
+	return SystemActor
+}
+
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
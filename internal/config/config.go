@@ -14,6 +14,17 @@ type (
 		HttpServer HttpServerConfig `mapstructure:"http_server"`
 		Postgres   PGConfig         `mapstructure:"postgres"`
 		Auth       AuthConfig       `maptructure:"auth"`
+		Webhooks   WebhooksConfig   `mapstructure:"webhooks"`
+		GitHub     GitHubConfig     `mapstructure:"github"`
+		GitLab     GitLabConfig     `mapstructure:"gitlab"`
+		VCS        VCSConfig        `mapstructure:"vcs"`
+		Telegram   TelegramConfig   `mapstructure:"telegram"`
+
+		ChatOps        ChatOpsConfig        `mapstructure:"chatops"`
+		Idempotency    IdempotencyConfig    `mapstructure:"idempotency"`
+		Retention      RetentionConfig      `mapstructure:"retention"`
+		Reconciliation ReconciliationConfig `mapstructure:"reconciliation"`
+		Notifications  NotificationsConfig  `mapstructure:"notifications"`
 	}
 
 	HttpServerConfig struct {
@@ -30,6 +41,121 @@ type (
 	AuthConfig struct {
 		AdminAPIKey string `mapstructure:"admin_api_key"`
 		UserAPIKey  string `mapstructure:"user_api_key"`
+		RootAPIKey  string `mapstructure:"root_api_key"` // manages tenants, disabled when empty
+	}
+
+	WebhooksConfig struct {
+		DispatchInterval time.Duration `mapstructure:"dispatch_interval"`
+		BatchSize        uint64        `mapstructure:"batch_size"`
+		Workers          int           `mapstructure:"workers"`
+		RequestTimeout   time.Duration `mapstructure:"request_timeout"`
+		MaxAttempts      int           `mapstructure:"max_attempts"`     // delivery is dead after that many failures
+		RetryBaseDelay   time.Duration `mapstructure:"retry_base_delay"` // doubled after every failure
+		RetryMaxDelay    time.Duration `mapstructure:"retry_max_delay"`
+	}
+
+	GitHubConfig struct {
+		APIURL       string             `mapstructure:"api_url"` // a mock server may stand in for the API
+		ReviewerSync ReviewerSyncConfig `mapstructure:"reviewer_sync"`
+	}
+
+	// ReviewerSyncConfig is requesting assigned reviewers in GitHub, enabled
+	// per tenant by its api token.
+	ReviewerSyncConfig struct {
+		Interval       time.Duration `mapstructure:"interval"`
+		BatchSize      uint64        `mapstructure:"batch_size"`
+		RequestTimeout time.Duration `mapstructure:"request_timeout"`
+		MaxAttempts    int           `mapstructure:"max_attempts"` // sync fails after that many failures
+		RetryBaseDelay time.Duration `mapstructure:"retry_base_delay"`
+		RetryMaxDelay  time.Duration `mapstructure:"retry_max_delay"`
+	}
+
+	GitLabConfig struct {
+		APIURL string `mapstructure:"api_url"` // "https://<host>/api/v4" of self-managed instances
+	}
+
+	VCSConfig struct {
+		TokenKey string `mapstructure:"token_key"` // base64 of 32 bytes api tokens are encrypted with, api tokens are disabled when empty
+	}
+
+	// TelegramConfig is the bot reviewers run commands in and get assignments
+	// from.
+	TelegramConfig struct {
+		APIURL         string        `mapstructure:"api_url"`      // a local stub may stand in for the Bot API
+		BotToken       string        `mapstructure:"bot_token"`    // disabled when empty
+		BotUsername    string        `mapstructure:"bot_username"` // optional, for links opening the bot with a link code
+		PollTimeout    time.Duration `mapstructure:"poll_timeout"`
+		RequestTimeout time.Duration `mapstructure:"request_timeout"`
+		LinkCodeTTL    time.Duration `mapstructure:"link_code_ttl"`
+	}
+
+	// ChatOpsConfig is running commands from chat apps, enabled per tenant by
+	// its signing secret.
+	ChatOpsConfig struct {
+		AwayCheckInterval time.Duration `mapstructure:"away_check_interval"` // activating away users again, disabled when zero
+		AwayBatchSize     uint64        `mapstructure:"away_batch_size"`
+	}
+
+	// IdempotencyConfig is replaying responses of POST requests sent with an
+	// Idempotency-Key.
+	IdempotencyConfig struct {
+		TTL             time.Duration `mapstructure:"ttl"`              // how long responses are replayed
+		LockTimeout     time.Duration `mapstructure:"lock_timeout"`     // how long an unfinished request holds its key
+		CleanupInterval time.Duration `mapstructure:"cleanup_interval"` // deleting expired keys, disabled when zero
+		CleanupBatch    uint64        `mapstructure:"cleanup_batch"`
+	}
+
+	// RetentionConfig is moving merged pull requests out of the live tables.
+	RetentionConfig struct {
+		Interval   time.Duration `mapstructure:"interval"`    // disabled when zero
+		MergedDays int           `mapstructure:"merged_days"` // pull requests merged longer ago are retained
+		Mode       string        `mapstructure:"mode"`        // "archive" keeps them for stats and reports, "delete" drops them
+		BatchSize  uint64        `mapstructure:"batch_size"`  // pull requests moved per transaction
+	}
+
+	// ReconciliationConfig is checking open pull requests against their
+	// providers, enabled per tenant and provider by its api token.
+	ReconciliationConfig struct {
+		Interval       time.Duration `mapstructure:"interval"` // disabled when zero
+		PageSize       uint64        `mapstructure:"page_size"`
+		RequestTimeout time.Duration `mapstructure:"request_timeout"`
+	}
+
+	// NotificationsConfig is sending events to chats of teams.
+	NotificationsConfig struct {
+		DispatchInterval time.Duration `mapstructure:"dispatch_interval"`
+		BatchSize        uint64        `mapstructure:"batch_size"`
+		MaxAttempts      int           `mapstructure:"max_attempts"`     // notification is dead after that many failures
+		RetryBaseDelay   time.Duration `mapstructure:"retry_base_delay"` // doubled after every failure
+		RetryMaxDelay    time.Duration `mapstructure:"retry_max_delay"`
+
+		Chat      ChatConfig      `mapstructure:"chat"`
+		Email     EmailConfig     `mapstructure:"email"`
+		ReviewSLA ReviewSLAConfig `mapstructure:"review_sla"`
+	}
+
+	ChatConfig struct {
+		RequestTimeout time.Duration `mapstructure:"request_timeout"`
+	}
+
+	// EmailConfig is emailing reviewers over SMTP, a local catcher may stand in
+	// for the server.
+	EmailConfig struct {
+		SMTPHost       string        `mapstructure:"smtp_host"` // disabled when empty
+		SMTPPort       int           `mapstructure:"smtp_port"`
+		Username       string        `mapstructure:"username"` // no authentication when empty
+		Password       string        `mapstructure:"password"`
+		From           string        `mapstructure:"from"`
+		RequestTimeout time.Duration `mapstructure:"request_timeout"`
+		TemplatesDir   string        `mapstructure:"templates_dir"` // "<kind>.tmpl" files override the default templates
+		DigestHour     int           `mapstructure:"digest_hour"`   // UTC
+	}
+
+	// ReviewSLAConfig is checking reviews against the review SLA of their
+	// team, enabled per team.
+	ReviewSLAConfig struct {
+		CheckInterval time.Duration `mapstructure:"check_interval"` // disabled when zero
+		BatchSize     uint64        `mapstructure:"batch_size"`
 	}
 )
 
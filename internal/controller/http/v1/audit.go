@@ -0,0 +1,92 @@
+package v1
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/service"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/logger"
+)
+
+type auditRoutes struct {
+	auditService service.Audit
+	logger       logger.Logger
+}
+
+func newAuditRoutes(auditService service.Audit, logger logger.Logger) *auditRoutes {
+	ar := &auditRoutes{
+		auditService: auditService,
+		logger:       logger,
+	}
+
+	return ar
+}
+
+// @Summary Журнал изменений
+// @Description Возвращает записи журнала аудита от новых к старым: кто (actor- ключ API), что (action), над какой сущностью, состояние до и после изменения и идентификатор запроса (X-Request-Id). Следующая страница- по next_cursor из ответа
+// @Tags Audit
+// @Accept json
+// @Produce json
+// @Param actor query string false "Ключ API, например api_key:12 или config:admin"
+// @Param action query string false "Действие, например pull_request.merge"
+// @Param entity_type query string false "Тип сущности" Enums(user, team, pull_request, repository, tenant, api_key, webhook_subscription, vcs, chatops)
+// @Param entity_id query string false "Идентификатор сущности"
+// @Param request_id query string false "Идентификатор запроса"
+// @Param from query string false "Начало периода (RFC3339)"
+// @Param to query string false "Конец периода (RFC3339)"
+// @Param window query string false "Период до текущего момента вместо from (например 24h, 30d)"
+// @Param limit query int false "Размер страницы (по умолчанию 50, не больше 500)"
+// @Param cursor query int false "next_cursor предыдущей страницы"
+// @Success 200 {object} service.AuditOutput
+// @Failure 400 {object} ErrorResponse "Неверные параметры запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /audit [get]
+func (ar *auditRoutes) list(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	period, ok := parseStatsInput(w, query)
+	if !ok {
+		return
+	}
+
+	input := service.AuditInput{
+		Actor:      query.Get("actor"),
+		Action:     query.Get("action"),
+		EntityType: query.Get("entity_type"),
+		EntityID:   query.Get("entity_id"),
+		RequestID:  query.Get("request_id"),
+		From:       period.From,
+		To:         period.To,
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || limit == 0 || limit > service.MaxAuditLimit {
+			newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid limit")
+			return
+		}
+		input.Limit = limit
+	}
+
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || cursor <= 0 {
+			newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid cursor")
+			return
+		}
+		input.Cursor = cursor
+	}
+
+	output, err := ar.auditService.GetEntries(r.Context(), input)
+	if err != nil {
+		newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to get audit entries")
+		ar.logger.Error("failed to get audit entries", map[string]any{
+			"error": err,
+		})
+		return
+	}
+
+	newSuccessResponse(w, http.StatusOK, output)
+}
@@ -1,9 +1,16 @@
 package v1
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"strconv"
 
+	"github.com/MatTwix/Pull-Request-Assigner/internal/audit"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
 	"github.com/MatTwix/Pull-Request-Assigner/internal/service"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/tenant"
 	"github.com/MatTwix/Pull-Request-Assigner/pkg/logger"
 )
 
@@ -22,21 +29,83 @@ func (a *AuthMiddleware) APIKeyMiddleware(requireAdmin bool) func(next http.Hand
 				return
 			}
 
-			if requireAdmin {
-				if !a.authService.IsAdminKey(apiKey) {
-					a.log.Warn("invalid admin API key", map[string]any{"key": apiKey, "path": r.URL.Path})
-					newErrorResponse(w, http.StatusUnauthorized, CodeAdminAuthError, ErrAdminAuthError.Error())
-					return
-				}
-			} else {
-				if !a.authService.IsAdminKey(apiKey) && !a.authService.IsUserKey(apiKey) {
-					a.log.Warn("invalid API key", map[string]any{"key": apiKey, "path": r.URL.Path})
-					newErrorResponse(w, http.StatusUnauthorized, CodeUserAuthError, ErrUserAuthError.Error())
-					return
-				}
+			key, ok := a.authenticate(w, r, apiKey, requireAdmin)
+			if !ok {
+				return
 			}
 
-			next.ServeHTTP(w, r)
+			if requireAdmin && key.Role != models.RoleAdmin {
+				a.log.Warn("invalid admin API key", map[string]any{"key_id": key.ID, "path": r.URL.Path})
+				newErrorResponse(w, http.StatusUnauthorized, CodeAdminAuthError, ErrAdminAuthError.Error())
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(withKey(r.Context(), key)))
 		})
 	}
 }
+
+// TenantMiddleware scopes public endpoints to the tenant of the API key when
+// one is provided, requests without a key go to the default tenant.
+func (a *AuthMiddleware) TenantMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey := r.Header.Get("X-Api-Key")
+		if apiKey == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key, ok := a.authenticate(w, r, apiKey, false)
+		if !ok {
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(withKey(r.Context(), key)))
+	})
+}
+
+func (a *AuthMiddleware) RootKeyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !a.authService.IsRootKey(r.Header.Get("X-Api-Key")) {
+			a.log.Warn("invalid root API key", map[string]any{"path": r.URL.Path})
+			newErrorResponse(w, http.StatusUnauthorized, CodeRootAuthError, ErrRootAuthError.Error())
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withKey scopes the request to the tenant of the key and records the key as
+// the actor of changes made by the request.
+func withKey(ctx context.Context, key *models.APIKey) context.Context {
+	actor := "config:" + key.Role
+	if key.ID != 0 {
+		actor = "api_key:" + strconv.Itoa(key.ID)
+	}
+
+	return audit.WithActor(tenant.WithID(ctx, key.TenantID), actor)
+}
+
+func (a *AuthMiddleware) authenticate(w http.ResponseWriter, r *http.Request, apiKey string, requireAdmin bool) (*models.APIKey, bool) {
+	key, err := a.authService.Authenticate(r.Context(), apiKey)
+	if err == nil {
+		return key, true
+	}
+
+	if !errors.Is(err, repoerrs.ErrNotFound) {
+		newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to check API key")
+		a.log.Error("failed to check API key", map[string]any{"path": r.URL.Path, "error": err})
+		return nil, false
+	}
+
+	if requireAdmin {
+		a.log.Warn("invalid admin API key", map[string]any{"path": r.URL.Path})
+		newErrorResponse(w, http.StatusUnauthorized, CodeAdminAuthError, ErrAdminAuthError.Error())
+	} else {
+		a.log.Warn("invalid API key", map[string]any{"path": r.URL.Path})
+		newErrorResponse(w, http.StatusUnauthorized, CodeUserAuthError, ErrUserAuthError.Error())
+	}
+
+	return nil, false
+}
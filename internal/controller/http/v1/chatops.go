@@ -0,0 +1,241 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/service"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/logger"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/utils"
+)
+
+// maxSlashCommandBody is far above any slash command payload.
+const maxSlashCommandBody = 64 << 10
+
+type chatOpsRoutes struct {
+	chatOpsService service.ChatOps
+	logger         logger.Logger
+}
+
+func newChatOpsRoutes(chatOpsService service.ChatOps, logger logger.Logger) *chatOpsRoutes {
+	return &chatOpsRoutes{
+		chatOpsService: chatOpsService,
+		logger:         logger,
+	}
+}
+
+// @Summary Принять slash-команду Slack
+// @Description Выполняет текстовую команду от имени пользователя, сопоставленного через /chatops/identities/set идентификатору вызвавшего в Slack (user_id); несопоставленному отвечается, что нужно обратиться к администратору. Подпись X-Slack-Signature проверяется секретом, заданным через /chatops/setSigningSecret, запросы старше 5 минут отклоняются. Команды: "assign <pr> [название]" создает PR вызвавшего и назначает ревьюверов (для существующего PR- показывает ревьюверов), "reassign <pr> [@ревьювер]" передает ревью вызвавшего другому ревьюверу (ревью упомянутого сопоставленного пользователя Slack- только ему самому или администратору), "merge <pr>" мержит PR (только автору PR или администратору), "away until <YYYY-MM-DD>" деактивирует вызвавшего до указанного дня, "back" активирует его снова. Ответ- эфемерное сообщение, ошибки выполнения команды описываются в его тексте. Организация- по параметру tenant, по умолчанию- организация по умолчанию
+// @Tags ChatOps
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param tenant query string false "Название организации"
+// @Param X-Slack-Request-Timestamp header string true "Время отправки, unix"
+// @Param X-Slack-Signature header string true "HMAC-SHA256 подпись (v0=<hex>)"
+// @Param user_id formData string true "Идентификатор вызвавшего пользователя в Slack"
+// @Param text formData string false "Текст команды"
+// @Success 200 {object} service.ChatOpsCommandOutput
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса"
+// @Failure 401 {object} ErrorResponse "Неверная подпись или организация не найдена"
+// @Router /webhooks/slack [post]
+func (cr *chatOpsRoutes) slack(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSlashCommandBody))
+	if err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	input := service.ChatOpsSlackInput{
+		TenantName: r.URL.Query().Get("tenant"),
+		Timestamp:  r.Header.Get("X-Slack-Request-Timestamp"),
+		Signature:  r.Header.Get("X-Slack-Signature"),
+		Body:       body,
+	}
+
+	output, err := cr.chatOpsService.HandleSlackCommand(r.Context(), input)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidSignature):
+			newErrorResponse(w, http.StatusUnauthorized, CodeSignatureError, err.Error())
+		case errors.Is(err, service.ErrInvalidPayload):
+			newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, err.Error())
+		default:
+			// the chat shows the reply to the caller, a failed request only as an error code
+			newSuccessResponse(w, http.StatusOK, service.ChatOpsCommandOutput{
+				ResponseType: service.ChatOpsResponseEphemeral,
+				Text:         "Something went wrong, try again later.",
+			})
+			cr.logger.Error("failed to run slack command", map[string]any{
+				"tenant": input.TenantName,
+				"error":  err,
+			})
+		}
+		return
+	}
+
+	newSuccessResponse(w, http.StatusOK, output)
+}
+
+type setChatOpsSigningSecretRequest struct {
+	Secret string `json:"secret" validate:"omitempty,min=16"`
+}
+
+// @Summary Задать секрет подписи slash-команд
+// @Description Задает секрет, которым подписываются slash-команды организации (Signing Secret приложения Slack). Если секрет не передан- генерируется. Секрет возвращается только в этом ответе
+// @Tags ChatOps
+// @Accept json
+// @Produce json
+// @Param request body setChatOpsSigningSecretRequest true "Secret payload"
+// @Success 200 {object} service.ChatOpsSigningSecretOutput
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /chatops/setSigningSecret [post]
+func (cr *chatOpsRoutes) setSigningSecret(w http.ResponseWriter, r *http.Request) {
+	var req setChatOpsSigningSecretRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	output, err := cr.chatOpsService.SetSigningSecret(r.Context(), req.Secret)
+	if err != nil {
+		newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to set signing secret")
+		cr.logger.Error("failed to set chatops signing secret", map[string]any{"error": err})
+		return
+	}
+
+	newSuccessResponse(w, http.StatusOK, output)
+}
+
+type setChatOpsIdentityRequest struct {
+	SlackUserID string `json:"slack_user_id" validate:"required"`
+	UserID      string `json:"user_id" validate:"required"`
+	IsAdmin     bool   `json:"is_admin"`
+}
+
+// @Summary Сопоставить пользователя Slack пользователю
+// @Description Задает пользователя, от имени которого выполняются slash-команды пользователя Slack (user_id из Slack, например U012AB3CD), заменяя прежнее сопоставление. is_admin разрешает мержить чужие PR
+// @Tags ChatOps
+// @Accept json
+// @Produce json
+// @Param request body setChatOpsIdentityRequest true "Identity payload"
+// @Success 200 {object} service.ChatOpsOutputIdentity
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Пользователь не найден"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /chatops/identities/set [post]
+func (cr *chatOpsRoutes) setIdentity(w http.ResponseWriter, r *http.Request) {
+	var req setChatOpsIdentityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	identity, err := cr.chatOpsService.SetIdentity(r.Context(), service.ChatOpsIdentityInput{
+		SlackUserID: req.SlackUserID,
+		UserID:      req.UserID,
+		IsAdmin:     req.IsAdmin,
+	})
+	if err != nil {
+		switch err {
+		case repoerrs.ErrUserNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to set identity")
+			cr.logger.Error("failed to set slack identity", map[string]any{
+				"slack_user_id": req.SlackUserID,
+				"user_id":       req.UserID,
+				"error":         err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusOK, identity)
+}
+
+type deleteChatOpsIdentityRequest struct {
+	SlackUserID string `json:"slack_user_id" validate:"required"`
+}
+
+// @Summary Удалить сопоставление пользователя Slack
+// @Description После удаления slash-команды пользователя Slack не выполняются
+// @Tags ChatOps
+// @Accept json
+// @Produce json
+// @Param request body deleteChatOpsIdentityRequest true "Identity payload"
+// @Success 204 "Сопоставление удалено"
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Сопоставление не найдено"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /chatops/identities/delete [post]
+func (cr *chatOpsRoutes) deleteIdentity(w http.ResponseWriter, r *http.Request) {
+	var req deleteChatOpsIdentityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := cr.chatOpsService.DeleteIdentity(r.Context(), req.SlackUserID); err != nil {
+		switch err {
+		case repoerrs.ErrNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to delete identity")
+			cr.logger.Error("failed to delete slack identity", map[string]any{
+				"slack_user_id": req.SlackUserID,
+				"error":         err,
+			})
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Список сопоставлений пользователей Slack
+// @Description Возвращает сопоставления пользователей Slack пользователям организации
+// @Tags ChatOps
+// @Accept json
+// @Produce json
+// @Success 200 {object} service.ChatOpsIdentitiesOutput
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /chatops/identities/list [get]
+func (cr *chatOpsRoutes) listIdentities(w http.ResponseWriter, r *http.Request) {
+	identities, err := cr.chatOpsService.GetIdentities(r.Context())
+	if err != nil {
+		newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to get identities")
+		cr.logger.Error("failed to get slack identities", map[string]any{"error": err})
+		return
+	}
+
+	newSuccessResponse(w, http.StatusOK, identities)
+}
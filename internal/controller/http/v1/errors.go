@@ -3,9 +3,11 @@ package v1
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/MatTwix/Pull-Request-Assigner/internal/metrics"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/service"
 )
 
 const (
@@ -13,10 +15,35 @@ const (
 	CodeTeamExists  = "TEAM_EXISTS"
 	CodePRExists    = "PR_EXISTS"
 	CodePRMerged    = "PR_MERGED"
+	CodePRClosed    = "PR_CLOSED"
 	CodeNotAssigned = "NOT_ASSIGNED"
 	CodeNoCandidate = "NO_CANDIDATE"
 	CodeNotFound    = "NOT_FOUND"
 
+	CodeTeamNotEmpty = "TEAM_NOT_EMPTY"
+	CodeNotMember    = "NOT_MEMBER"
+	CodeTeamCycle    = "TEAM_CYCLE"
+	CodeTeamArchived = "TEAM_ARCHIVED"
+	CodeTenantExists = "TENANT_EXISTS"
+
+	CodeReassignTargetNotFound = "REASSIGN_TARGET_NOT_FOUND"
+
+	CodeRepositoryExists   = "REPOSITORY_EXISTS"
+	CodeSubscriptionExists = "SUBSCRIPTION_EXISTS"
+	CodeURLNotAllowed      = "URL_NOT_ALLOWED"
+	CodeSyncDisabled       = "SYNC_DISABLED"
+	CodeAPITokensDisabled  = "API_TOKENS_DISABLED"
+	CodeChannelExists      = "CHANNEL_EXISTS"
+
+	CodeReconciliationRunning = "RECONCILIATION_RUNNING"
+	CodeVersionMismatch       = "VERSION_MISMATCH"
+	CodeInvalidImport         = "INVALID_IMPORT"
+	CodeInvalidSnapshot       = "INVALID_SNAPSHOT"
+	CodeTenantNotEmpty        = "TENANT_NOT_EMPTY"
+
+	CodeIdempotencyKeyReused     = "IDEMPOTENCY_KEY_REUSED"
+	CodeIdempotencyKeyInProgress = "IDEMPOTENCY_KEY_IN_PROGRESS"
+
 	// Additional used error types codes
 	CodeBadRequest          = "BAD_REQUEST"
 	CodeInternalServerError = "INTERNAL_SERVER_ERROR"
@@ -24,11 +51,14 @@ const (
 	// Authorization errors codes
 	CodeAdminAuthError = "ADMIN_AUTH"
 	CodeUserAuthError  = "USER_AUTH"
+	CodeRootAuthError  = "ROOT_AUTH"
+	CodeSignatureError = "INVALID_SIGNATURE"
 )
 
 var (
 	ErrAdminAuthError = errors.New("incorrect admin key")
 	ErrUserAuthError  = errors.New("incorrect user key")
+	ErrRootAuthError  = errors.New("incorrect root key")
 )
 
 type ErrorResponse struct {
@@ -40,6 +70,12 @@ type ErrorBody struct {
 	Message string `json:"message"`
 }
 
+// ImportErrorResponse lists every problem of a rejected import file.
+type ImportErrorResponse struct {
+	ErrorResponse
+	Errors []service.ImportLineError `json:"errors"`
+}
+
 func newErrorResponse(w http.ResponseWriter, status int, code, msg string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -57,3 +93,22 @@ func newErrorResponse(w http.ResponseWriter, status int, code, msg string) {
 
 	_ = json.NewEncoder(w).Encode(resp)
 }
+
+func newImportErrorResponse(w http.ResponseWriter, errs service.ImportErrors) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+
+	resp := ImportErrorResponse{
+		ErrorResponse: ErrorResponse{
+			Error: ErrorBody{
+				Code:    CodeInvalidImport,
+				Message: fmt.Sprintf("import file has %d errors", len(errs)),
+			},
+		},
+		Errors: errs,
+	}
+
+	metrics.BusinessErrors.WithLabelValues(CodeInvalidImport).Inc()
+
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -1,15 +1,39 @@
 package v1
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/MatTwix/Pull-Request-Assigner/internal/audit"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/precondition"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/service"
 	"github.com/MatTwix/Pull-Request-Assigner/pkg/logger"
 	"github.com/go-chi/chi/v5/middleware"
 )
 
 const metricsRoutePath = "/metrics"
 
+const ifMatchHeader = "If-Match"
+
+const (
+	idempotencyKeyHeader    = "Idempotency-Key"
+	maxIdempotencyKeyLength = 255
+	// maxIdempotentRequestBody bounds bodies of recorded requests, larger ones
+	// (restored snapshots) are processed without recording
+	maxIdempotentRequestBody = 25 << 20
+)
+
+// replayedHeaders are the response headers recorded for repeats, the others
+// (X-Request-Id) describe the response to the first request only.
+var replayedHeaders = []string{"Content-Type", "Content-Disposition", "ETag", "Location"}
+
 func loggingMiddleware(log logger.Logger) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -30,7 +54,174 @@ func loggingMiddleware(log logger.Logger) func(next http.Handler) http.Handler {
 				"status":      ww.Status(),
 				"duration_ms": duration.Milliseconds(),
 				"remote_ip":   r.RemoteAddr,
+				"request_id":  middleware.GetReqID(r.Context()),
 			})
 		})
 	}
 }
+
+// requestIDMiddleware takes the request id from X-Request-Id or generates one,
+// returns it to the client and passes it to the audit log.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return middleware.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := middleware.GetReqID(r.Context())
+		w.Header().Set(middleware.RequestIDHeader, requestID)
+
+		next.ServeHTTP(w, r.WithContext(audit.WithRequestID(r.Context(), requestID)))
+	}))
+}
+
+// idempotencyMiddleware replays the recorded response for repeats of POST
+// requests with the same Idempotency-Key from the same API key. Requests
+// without an API key are not recorded: provider webhooks send their own
+// Idempotency-Key and are deduplicated by delivery. Neither are requests with
+// bodies over maxIdempotentRequestBody, which are passed on as they are.
+func idempotencyMiddleware(idempotency service.Idempotency, log logger.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(idempotencyKeyHeader)
+			apiKey := r.Header.Get("X-Api-Key")
+			if r.Method != http.MethodPost || key == "" || apiKey == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(key) > maxIdempotencyKeyLength {
+				newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "Idempotency-Key is too long")
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentRequestBody+1))
+			if err != nil {
+				newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+				return
+			}
+
+			if len(body) > maxIdempotentRequestBody {
+				r.Body = struct {
+					io.Reader
+					io.Closer
+				}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+
+				next.ServeHTTP(w, r)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			input := service.IdempotencyInput{
+				APIKey: apiKey,
+				Key:    key,
+				Method: r.Method,
+				Path:   r.URL.RequestURI(),
+				Body:   body,
+			}
+
+			recorded, leaseToken, err := idempotency.Begin(r.Context(), input)
+			if err != nil {
+				switch {
+				case errors.Is(err, service.ErrIdempotencyKeyReused):
+					newErrorResponse(w, http.StatusUnprocessableEntity, CodeIdempotencyKeyReused, err.Error())
+				case errors.Is(err, service.ErrIdempotencyKeyInProgress):
+					newErrorResponse(w, http.StatusConflict, CodeIdempotencyKeyInProgress, err.Error())
+				default:
+					newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to check idempotency key")
+					log.Error("failed to check idempotency key", map[string]any{"path": r.URL.Path, "error": err})
+				}
+				return
+			}
+
+			if recorded != nil {
+				for name, values := range recorded.Header {
+					w.Header()[name] = values
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(recorded.StatusCode)
+				_, _ = w.Write(recorded.Body)
+				return
+			}
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			var response bytes.Buffer
+			ww.Tee(&response)
+
+			next.ServeHTTP(ww, r)
+
+			// the client may be gone, the key is still to be completed
+			ctx := context.WithoutCancel(r.Context())
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			// failures of the server and of authentication are retried
+			if status >= http.StatusInternalServerError || status == http.StatusUnauthorized {
+				err = idempotency.Release(ctx, input, leaseToken)
+			} else {
+				header := make(map[string][]string, len(replayedHeaders))
+				for _, name := range replayedHeaders {
+					if values := ww.Header().Values(name); len(values) > 0 {
+						header[http.CanonicalHeaderKey(name)] = values
+					}
+				}
+
+				err = idempotency.Complete(ctx, input, leaseToken, service.IdempotentResponse{
+					StatusCode: status,
+					Header:     header,
+					Body:       response.Bytes(),
+				})
+			}
+
+			switch {
+			case err == nil:
+			case errors.Is(err, repoerrs.ErrLeaseLost):
+				log.Warn("idempotency key was claimed again before the response was recorded", map[string]any{"path": r.URL.Path})
+			default:
+				log.Error("failed to record idempotent response", map[string]any{"path": r.URL.Path, "error": err})
+			}
+		})
+	}
+}
+
+// preconditionMiddleware passes the version of If-Match, the ETag of the
+// entity returned by its GET, to the repositories, which change the entity
+// only while it has that version. "*" matches any version. It is used only on
+// routes changing a versioned entity: elsewhere the version would be checked
+// against whatever entity the request happens to touch.
+func preconditionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value := strings.TrimSpace(r.Header.Get(ifMatchHeader))
+		if value == "" || value == "*" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if strings.HasPrefix(value, "W/") {
+			// If-Match compares entity tags strongly, a weak one never matches
+			newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "weak entity tags are not allowed in If-Match header")
+			return
+		}
+
+		version, ok := parseETag(value)
+		if !ok {
+			newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid If-Match header")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(precondition.WithVersion(r.Context(), version)))
+	})
+}
+
+// parseETag returns the version of the strong `"<version>"` entity tag.
+func parseETag(quoted string) (int, bool) {
+	if len(quoted) < 2 || quoted[0] != '"' || quoted[len(quoted)-1] != '"' {
+		return 0, false
+	}
+
+	version, err := strconv.Atoi(quoted[1 : len(quoted)-1])
+	if err != nil || version < 1 {
+		return 0, false
+	}
+
+	return version, true
+}
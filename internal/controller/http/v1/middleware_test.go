@@ -0,0 +1,198 @@
+package v1
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/precondition"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/service"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...map[string]any) {}
+func (nopLogger) Info(string, ...map[string]any)  {}
+func (nopLogger) Warn(string, ...map[string]any)  {}
+func (nopLogger) Error(string, ...map[string]any) {}
+func (nopLogger) Fatal(string, ...map[string]any) {}
+
+// fakeIdempotency replays recorded when it is set and records how the claimed
+// request was finished otherwise.
+type fakeIdempotency struct {
+	recorded *service.IdempotentResponse
+
+	calls     []string
+	completed *service.IdempotentResponse
+}
+
+func (f *fakeIdempotency) Begin(context.Context, service.IdempotencyInput) (*service.IdempotentResponse, string, error) {
+	f.calls = append(f.calls, "begin")
+	if f.recorded != nil {
+		return f.recorded, "", nil
+	}
+	return nil, "lease", nil
+}
+
+func (f *fakeIdempotency) Complete(_ context.Context, _ service.IdempotencyInput, leaseToken string, response service.IdempotentResponse) error {
+	f.calls = append(f.calls, "complete "+leaseToken)
+	f.completed = &response
+	return nil
+}
+
+func (f *fakeIdempotency) Release(_ context.Context, _ service.IdempotencyInput, leaseToken string) error {
+	f.calls = append(f.calls, "release "+leaseToken)
+	return nil
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		recorded      *service.IdempotentResponse
+		status        int
+		wantCalls     []string
+		wantHandled   bool
+		wantStatus    int
+		wantHeader    http.Header
+		wantBody      string
+		wantCompleted *service.IdempotentResponse
+	}{
+		{
+			name:        "recorded with the replayed headers",
+			body:        `{"pull_request_id": "pr-1"}`,
+			status:      http.StatusOK,
+			wantCalls:   []string{"begin", "complete lease"},
+			wantHandled: true,
+			wantStatus:  http.StatusOK,
+			wantBody:    `{"ok":true}`,
+			wantCompleted: &service.IdempotentResponse{
+				StatusCode: http.StatusOK,
+				Header:     map[string][]string{"Content-Type": {"application/json"}, "Etag": {`"3"`}},
+				Body:       []byte(`{"ok":true}`),
+			},
+		},
+		{
+			name:        "released on failure",
+			body:        `{}`,
+			status:      http.StatusInternalServerError,
+			wantCalls:   []string{"begin", "release lease"},
+			wantHandled: true,
+			wantStatus:  http.StatusInternalServerError,
+			wantBody:    `{"ok":true}`,
+		},
+		{
+			name: "replayed",
+			body: `{}`,
+			recorded: &service.IdempotentResponse{
+				StatusCode: http.StatusCreated,
+				Header:     map[string][]string{"Content-Type": {"text/csv"}, "Etag": {`"3"`}},
+				Body:       []byte("a,b\n"),
+			},
+			wantCalls:  []string{"begin"},
+			wantStatus: http.StatusCreated,
+			wantHeader: http.Header{"Content-Type": {"text/csv"}, "Etag": {`"3"`}, "Idempotent-Replayed": {"true"}},
+			wantBody:   "a,b\n",
+		},
+		{
+			name:        "large body is not recorded",
+			body:        strings.Repeat("x", maxIdempotentRequestBody+1),
+			status:      http.StatusOK,
+			wantHandled: true,
+			wantStatus:  http.StatusOK,
+			wantBody:    `{"ok":true}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idempotency := &fakeIdempotency{recorded: tt.recorded}
+
+			handled := false
+			handler := idempotencyMiddleware(idempotency, nopLogger{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handled = true
+
+				body, err := io.ReadAll(r.Body)
+				if err != nil || string(body) != tt.body {
+					t.Errorf("handler got a body of %d bytes, want %d (error %v)", len(body), len(tt.body), err)
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("ETag", `"3"`)
+				w.Header().Set("X-Request-Id", "first")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(`{"ok":true}`))
+			}))
+
+			r := httptest.NewRequest(http.MethodPost, "/pullRequest/merge", strings.NewReader(tt.body))
+			r.Header.Set("X-Api-Key", "key")
+			r.Header.Set(idempotencyKeyHeader, "6f1c2a4e")
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, r)
+
+			if handled != tt.wantHandled {
+				t.Errorf("handled = %v, want %v", handled, tt.wantHandled)
+			}
+			if !reflect.DeepEqual(idempotency.calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", idempotency.calls, tt.wantCalls)
+			}
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if w.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.wantBody)
+			}
+			if tt.wantHeader != nil && !reflect.DeepEqual(w.Header(), tt.wantHeader) {
+				t.Errorf("header = %v, want %v", w.Header(), tt.wantHeader)
+			}
+			if tt.wantCompleted != nil && !reflect.DeepEqual(idempotency.completed, tt.wantCompleted) {
+				t.Errorf("completed = %+v, want %+v", idempotency.completed, tt.wantCompleted)
+			}
+		})
+	}
+}
+
+func TestPreconditionMiddleware(t *testing.T) {
+	tests := []struct {
+		ifMatch     string
+		wantStatus  int
+		wantVersion int // 0 when any version matches
+	}{
+		{ifMatch: "", wantStatus: http.StatusNoContent},
+		{ifMatch: "*", wantStatus: http.StatusNoContent},
+		{ifMatch: `"3"`, wantStatus: http.StatusNoContent, wantVersion: 3},
+		{ifMatch: ` "3" `, wantStatus: http.StatusNoContent, wantVersion: 3},
+		{ifMatch: `W/"3"`, wantStatus: http.StatusBadRequest},
+		{ifMatch: `3`, wantStatus: http.StatusBadRequest},
+		{ifMatch: `"0"`, wantStatus: http.StatusBadRequest},
+		{ifMatch: `"3", "4"`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ifMatch, func(t *testing.T) {
+			version := 0
+			handler := preconditionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				version, _ = precondition.Version(r.Context())
+				w.WriteHeader(http.StatusNoContent)
+			}))
+
+			r := httptest.NewRequest(http.MethodPost, "/team/rename", nil)
+			r.Header.Set(ifMatchHeader, tt.ifMatch)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if version != tt.wantVersion {
+				t.Errorf("version = %d, want %d", version, tt.wantVersion)
+			}
+		})
+	}
+}
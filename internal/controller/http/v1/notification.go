@@ -0,0 +1,339 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/service"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/logger"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/utils"
+)
+
+type notificationRoutes struct {
+	notificationService service.Notification
+	logger              logger.Logger
+}
+
+func newNotificationRoutes(notificationService service.Notification, logger logger.Logger) *notificationRoutes {
+	nr := &notificationRoutes{
+		notificationService: notificationService,
+		logger:              logger,
+	}
+
+	return nr
+}
+
+type addChatChannelRequest struct {
+	TeamName   string            `json:"team_name" validate:"required"`
+	URL        string            `json:"url" validate:"required,url"`
+	EventTypes []string          `json:"event_types" validate:"dive,oneof=pull_request.created pull_request.reviewer_reassigned pull_request.sla_breached pull_request.merged"`
+	Templates  map[string]string `json:"templates"`
+}
+
+// @Summary Подключить чат к команде
+// @Description Регистрирует входящий webhook Slack/Mattermost, в который отправляются уведомления команды: назначение ревьювера (pull_request.created), переназначение (pull_request.reviewer_reassigned), превышение SLA ревью (pull_request.sla_breached) и мердж (pull_request.merged); пустой event_types- все эти события. templates- text/template сообщения для типа события (поля: .EventType, .PullRequest, .Recipient.UserID, .Recipient.Username, .OldReviewerID, .NewReviewerID, .AssignedAt, .ReviewSLA), для остальных событий используются стандартные сообщения. Неуспешные отправки повторяются с экспоненциальной задержкой. URL должен указывать на публичный адрес, редиректы не выполняются; в ответах путь URL скрыт
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Param request body addChatChannelRequest true "Chat channel payload"
+// @Success 201 {object} service.ChatChannelOutput
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса, шаблон или непубличный URL"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Команда не найдена"
+// @Failure 409 {object} ErrorResponse "Этот URL уже подключен к команде"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /notifications/chatChannels/add [post]
+func (nr *notificationRoutes) addChatChannel(w http.ResponseWriter, r *http.Request) {
+	var req addChatChannelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if !strings.HasPrefix(req.URL, "http://") && !strings.HasPrefix(req.URL, "https://") {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "url must be http or https")
+		return
+	}
+
+	channel, err := nr.notificationService.AddChatChannel(r.Context(), service.ChatChannelInput{
+		TeamName:   req.TeamName,
+		URL:        req.URL,
+		EventTypes: req.EventTypes,
+		Templates:  req.Templates,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidTemplate):
+			newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, err.Error())
+			return
+		case errors.Is(err, service.ErrURLNotAllowed):
+			newErrorResponse(w, http.StatusBadRequest, CodeURLNotAllowed, err.Error())
+			return
+		case errors.Is(err, repoerrs.ErrTeamNotFound):
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		case errors.Is(err, repoerrs.ErrAlreadyExists):
+			newErrorResponse(w, http.StatusConflict, CodeChannelExists, "this url is already connected to the team")
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to add chat channel")
+			nr.logger.Error("failed to add chat channel", map[string]any{
+				"team_name": req.TeamName,
+				"error":     err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusCreated, channel)
+}
+
+// @Summary Список чатов
+// @Description Возвращает подключенные чаты команды, без team_name- всех команд организации
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Param team_name query string false "Название команды"
+// @Success 200 {object} service.ChatChannelsOutput
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /notifications/chatChannels/list [get]
+func (nr *notificationRoutes) listChatChannels(w http.ResponseWriter, r *http.Request) {
+	teamName := r.URL.Query().Get("team_name")
+
+	channels, err := nr.notificationService.GetChatChannels(r.Context(), teamName)
+	if err != nil {
+		newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to get chat channels")
+		nr.logger.Error("failed to get chat channels", map[string]any{
+			"team_name": teamName,
+			"error":     err,
+		})
+		return
+	}
+
+	newSuccessResponse(w, http.StatusOK, channels)
+}
+
+type setChatChannelIsActiveRequest struct {
+	ChannelID int   `json:"channel_id" validate:"required"`
+	IsActive  *bool `json:"is_active" validate:"required"`
+}
+
+// @Summary Включить/выключить чат
+// @Description Выключенный чат не получает новые уведомления, уже созданные продолжают отправляться
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Param request body setChatChannelIsActiveRequest true "Chat channel status payload"
+// @Success 200 {object} service.ChatChannelOutput
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Чат не найден"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /notifications/chatChannels/setIsActive [post]
+func (nr *notificationRoutes) setChatChannelIsActive(w http.ResponseWriter, r *http.Request) {
+	var req setChatChannelIsActiveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	channel, err := nr.notificationService.SetChatChannelIsActive(r.Context(), req.ChannelID, *req.IsActive)
+	if err != nil {
+		switch err {
+		case repoerrs.ErrNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to update chat channel")
+			nr.logger.Error("failed to update chat channel", map[string]any{
+				"channel_id": req.ChannelID,
+				"error":      err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusOK, channel)
+}
+
+type deleteChatChannelRequest struct {
+	ChannelID int `json:"channel_id" validate:"required"`
+}
+
+// @Summary Отключить чат
+// @Description Удаляет чат вместе с историей его уведомлений
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Param request body deleteChatChannelRequest true "Chat channel payload"
+// @Success 204 "Чат удален"
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Чат не найден"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /notifications/chatChannels/delete [post]
+func (nr *notificationRoutes) deleteChatChannel(w http.ResponseWriter, r *http.Request) {
+	var req deleteChatChannelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := nr.notificationService.DeleteChatChannel(r.Context(), req.ChannelID); err != nil {
+		switch err {
+		case repoerrs.ErrNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to delete chat channel")
+			nr.logger.Error("failed to delete chat channel", map[string]any{
+				"channel_id": req.ChannelID,
+				"error":      err,
+			})
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary История уведомлений
+// @Description Возвращает уведомления от новых к старым. status=DEAD- уведомления, исчерпавшие попытки, SKIPPED- уведомления, которым нечего отправлять (например, сводка, все ревью которой завершены). Следующая страница- по next_cursor из ответа
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Param channel query string false "Канал" Enums(chat, email, telegram)
+// @Param status query string false "Статус уведомления" Enums(PENDING, SENT, DEAD, SKIPPED)
+// @Param limit query int false "Размер страницы (по умолчанию 50, не больше 500)"
+// @Param cursor query int false "next_cursor предыдущей страницы"
+// @Success 200 {object} service.NotificationsOutput
+// @Failure 400 {object} ErrorResponse "Неверные параметры запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /notifications/list [get]
+func (nr *notificationRoutes) list(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	input := service.NotificationsInput{
+		Channel: query.Get("channel"),
+		Status:  query.Get("status"),
+	}
+
+	switch input.Channel {
+	case "", models.NotificationChannelChat, models.NotificationChannelEmail, models.NotificationChannelTelegram:
+	default:
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid channel")
+		return
+	}
+
+	switch input.Status {
+	case "", models.NotificationStatusPending, models.NotificationStatusSent, models.NotificationStatusDead, models.NotificationStatusSkipped:
+	default:
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid status")
+		return
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || limit == 0 || limit > service.MaxNotificationsLimit {
+			newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid limit")
+			return
+		}
+		input.Limit = limit
+	}
+
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || cursor <= 0 {
+			newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid cursor")
+			return
+		}
+		input.Cursor = cursor
+	}
+
+	notifications, err := nr.notificationService.GetNotifications(r.Context(), input)
+	if err != nil {
+		newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to get notifications")
+		nr.logger.Error("failed to get notifications", map[string]any{
+			"channel": input.Channel,
+			"error":   err,
+		})
+		return
+	}
+
+	newSuccessResponse(w, http.StatusOK, notifications)
+}
+
+type retryNotificationRequest struct {
+	NotificationID int64 `json:"notification_id" validate:"required"`
+}
+
+// @Summary Повторить уведомление
+// @Description Возвращает уведомление в статусе DEAD в очередь с новым набором попыток. Уведомления в других статусах не меняются (requeued=false)
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Param request body retryNotificationRequest true "Notification payload"
+// @Success 200 {object} service.NotificationRetryOutput
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Уведомление не найдено"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /notifications/retry [post]
+func (nr *notificationRoutes) retry(w http.ResponseWriter, r *http.Request) {
+	var req retryNotificationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	output, err := nr.notificationService.RetryNotification(r.Context(), req.NotificationID)
+	if err != nil {
+		switch err {
+		case repoerrs.ErrNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to retry notification")
+			nr.logger.Error("failed to retry notification", map[string]any{
+				"notification_id": req.NotificationID,
+				"error":           err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusOK, output)
+}
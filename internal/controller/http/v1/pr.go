@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 
@@ -11,14 +12,16 @@ import (
 )
 
 type pullRequestRoutes struct {
-	prService service.PullRequest
-	logger    logger.Logger
+	prService   service.PullRequest
+	syncService service.ReviewerSync
+	logger      logger.Logger
 }
 
-func newPullRequestRoutes(prService service.PullRequest, logger logger.Logger) *pullRequestRoutes {
+func newPullRequestRoutes(prService service.PullRequest, syncService service.ReviewerSync, logger logger.Logger) *pullRequestRoutes {
 	prr := &pullRequestRoutes{
-		prService: prService,
-		logger:    logger,
+		prService:   prService,
+		syncService: syncService,
+		logger:      logger,
 	}
 
 	return prr
@@ -28,10 +31,12 @@ type createPRRequest struct {
 	PullRequestID   string `json:"pull_request_id" validate:"required"`
 	PullRequestName string `json:"pull_request_name" validate:"required"`
 	AuthorID        string `json:"author_id" validate:"required"`
+	TeamName        string `json:"team_name"`
+	RepositoryName  string `json:"repository"`
 }
 
 // @Summary Создать пулл реквест
-// @Description Создает пулл реквест и автоматически назначает до 2 ревьюверов из команды автора
+// @Description Создает пулл реквест и автоматически назначает ревьюверов (по умолчанию до 2) из указанной команды автора. Если команда не указана, ревьюверы назначаются из команды-владельца репозитория, а при ее отсутствии- из основной команды автора. Настройки репозитория переопределяют настройки команды
 // @Tags PullRequests
 // @Accept json
 // @Produce json
@@ -39,8 +44,8 @@ type createPRRequest struct {
 // @Success 201 {object} service.PullRequestCreateOutput
 // @Failure 400 {object} ErrorResponse "Неверное тело запроса"
 // @Failure 401 {object} ErrorResponse "Ошибка авторизации"
-// @Failure 404 {object} ErrorResponse "Автор/команда не найдены"
-// @Failure 409 {object} ErrorResponse "PR уже существует"
+// @Failure 404 {object} ErrorResponse "Автор/команда/репозиторий не найдены"
+// @Failure 409 {object} ErrorResponse "PR уже существует или автор не состоит в команде"
 // @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
 // @Security ApiKeyAuth
 // @Router /pullRequest/create [post]
@@ -60,6 +65,8 @@ func (prr *pullRequestRoutes) create(w http.ResponseWriter, r *http.Request) {
 		PullRequestID:   req.PullRequestID,
 		PullRequestName: req.PullRequestName,
 		AuthorID:        req.AuthorID,
+		TeamName:        req.TeamName,
+		RepositoryName:  req.RepositoryName,
 	}
 
 	pullRequest, err := prr.prService.CreatePR(r.Context(), input)
@@ -71,13 +78,18 @@ func (prr *pullRequestRoutes) create(w http.ResponseWriter, r *http.Request) {
 		case repoerrs.ErrAlreadyExists:
 			newErrorResponse(w, http.StatusConflict, CodePRExists, "PR is already exists")
 			return
+		case repoerrs.ErrNotMember:
+			newErrorResponse(w, http.StatusConflict, CodeNotMember, err.Error())
+			return
 		default:
 			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to create pull request")
 			prr.logger.Error("failed to create pull request", map[string]any{
-				"pr_id":     req.PullRequestID,
-				"pr_name":   req.PullRequestName,
-				"author_id": req.AuthorID,
-				"error":     err,
+				"pr_id":      req.PullRequestID,
+				"pr_name":    req.PullRequestName,
+				"author_id":  req.AuthorID,
+				"team_name":  req.TeamName,
+				"repository": req.RepositoryName,
+				"error":      err,
 			})
 			return
 		}
@@ -96,10 +108,12 @@ type mergePRRequest struct {
 // @Accept json
 // @Produce json
 // @Param request body mergePRRequest true "Merge payload"
+// @Param If-Match header string false "Ожидаемая версия (ETag), при несовпадении- 412"
 // @Success 200 {object} service.PullRequestMergeOutput
 // @Failure 400 {object} ErrorResponse "Неверное тело запроса"
 // @Failure 401 {object} ErrorResponse "Ошибка авторизации"
 // @Failure 404 {object} ErrorResponse "PR не найден"
+// @Failure 412 {object} ErrorResponse "Версия изменилась"
 // @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
 // @Security ApiKeyAuth
 // @Router /pullRequest/merge [post]
@@ -122,6 +136,9 @@ func (prr *pullRequestRoutes) merge(w http.ResponseWriter, r *http.Request) {
 		case repoerrs.ErrNotFound:
 			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
 			return
+		case repoerrs.ErrVersionMismatch:
+			newErrorResponse(w, http.StatusPreconditionFailed, CodeVersionMismatch, err.Error())
+			return
 		default:
 			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to merge pull request")
 			prr.logger.Error("failed to merge pull request", map[string]any{
@@ -146,11 +163,13 @@ type reassignRequest struct {
 // @Accept json
 // @Produce json
 // @Param request body reassignRequest true "Reassign payload"
+// @Param If-Match header string false "Ожидаемая версия (ETag), при несовпадении- 412"
 // @Success 200 {object} service.PullRequestReassignOutput
 // @Failure 400 {object} ErrorResponse "Неверное тело запроса"
 // @Failure 401 {object} ErrorResponse "Ошибка авторизации"
 // @Failure 404 {object} ErrorResponse "PR или пользователь не найден"
 // @Failure 409 {object} ErrorResponse "Нарушение правил переназначения"
+// @Failure 412 {object} ErrorResponse "Версия изменилась"
 // @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
 // @Security ApiKeyAuth
 // @Router /pullRequest/reassign [post]
@@ -173,12 +192,18 @@ func (prr *pullRequestRoutes) reassign(w http.ResponseWriter, r *http.Request) {
 		case repoerrs.ErrNotFound:
 			newErrorResponse(w, http.StatusNotFound, CodeNotFound, "pull request not found")
 			return
+		case repoerrs.ErrVersionMismatch:
+			newErrorResponse(w, http.StatusPreconditionFailed, CodeVersionMismatch, err.Error())
+			return
 		case repoerrs.ErrUserNotFound:
 			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
 			return
 		case repoerrs.ErrReassignAfterMerge:
 			newErrorResponse(w, http.StatusConflict, CodePRMerged, err.Error())
 			return
+		case repoerrs.ErrReassignAfterClose:
+			newErrorResponse(w, http.StatusConflict, CodePRClosed, err.Error())
+			return
 		case repoerrs.ErrNotAssigned:
 			newErrorResponse(w, http.StatusConflict, CodeNotAssigned, err.Error())
 			return
@@ -198,3 +223,175 @@ func (prr *pullRequestRoutes) reassign(w http.ResponseWriter, r *http.Request) {
 
 	newSuccessResponse(w, http.StatusOK, response)
 }
+
+type setPRStatusRequest struct {
+	PullRequestID string `json:"pull_request_id" validate:"required"`
+}
+
+// @Summary Закрыть пулл реквест без мержа
+// @Description Идемпотентно устанавливает статус пулл реквеста "CLOSED", ревьюверы освобождаются как после мержа. Закрытый PR можно открыть снова
+// @Tags PullRequests
+// @Accept json
+// @Produce json
+// @Param request body setPRStatusRequest true "Close payload"
+// @Param If-Match header string false "Ожидаемая версия (ETag), при несовпадении- 412"
+// @Success 200 {object} service.PullRequestStatusOutput
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "PR не найден"
+// @Failure 409 {object} ErrorResponse "PR уже смержен"
+// @Failure 412 {object} ErrorResponse "Версия изменилась"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /pullRequest/close [post]
+func (prr *pullRequestRoutes) close(w http.ResponseWriter, r *http.Request) {
+	prr.setStatus(w, r, "close", prr.prService.ClosePR)
+}
+
+// @Summary Открыть закрытый пулл реквест снова
+// @Description Идемпотентно возвращает закрытому без мержа пулл реквесту статус "OPEN", его ревьюверы снова заняты
+// @Tags PullRequests
+// @Accept json
+// @Produce json
+// @Param request body setPRStatusRequest true "Reopen payload"
+// @Param If-Match header string false "Ожидаемая версия (ETag), при несовпадении- 412"
+// @Success 200 {object} service.PullRequestStatusOutput
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "PR не найден"
+// @Failure 409 {object} ErrorResponse "PR уже смержен"
+// @Failure 412 {object} ErrorResponse "Версия изменилась"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /pullRequest/reopen [post]
+func (prr *pullRequestRoutes) reopen(w http.ResponseWriter, r *http.Request) {
+	prr.setStatus(w, r, "reopen", prr.prService.ReopenPR)
+}
+
+func (prr *pullRequestRoutes) setStatus(w http.ResponseWriter, r *http.Request, operation string, set func(ctx context.Context, prID string) (*service.PullRequestStatusOutput, error)) {
+	var req setPRStatusRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	pullRequest, err := set(r.Context(), req.PullRequestID)
+	if err != nil {
+		switch err {
+		case repoerrs.ErrNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		case repoerrs.ErrVersionMismatch:
+			newErrorResponse(w, http.StatusPreconditionFailed, CodeVersionMismatch, err.Error())
+			return
+		case repoerrs.ErrPRMerged:
+			newErrorResponse(w, http.StatusConflict, CodePRMerged, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to "+operation+" pull request")
+			prr.logger.Error("failed to "+operation+" pull request", map[string]any{
+				"pr_id": req.PullRequestID,
+				"error": err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusOK, pullRequest)
+}
+
+// @Summary Получить пулл реквест
+// @Description Возвращает пулл реквест с текущими ревьюверами. Для PR из GitHub при заданном API токене (/vcs/setAPIToken) в reviewer_sync- состояние запроса ревьюверов в GitHub: PENDING (ожидает отправки или повтора), SYNCED, FAILED (попытки исчерпаны или GitHub отклонил запрос)
+// @Tags PullRequests
+// @Accept json
+// @Produce json
+// @Param pull_request_id query string true "Идентификатор PR"
+// @Success 200 {object} service.PullRequestGetOutput
+// @Header 200 {string} ETag "Версия PR для If-Match"
+// @Failure 400 {object} ErrorResponse "Неверные параметры запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "PR не найден"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /pullRequest/get [get]
+func (prr *pullRequestRoutes) get(w http.ResponseWriter, r *http.Request) {
+	prID := r.URL.Query().Get("pull_request_id")
+	if prID == "" {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "pull_request_id is required")
+		return
+	}
+
+	pullRequest, err := prr.prService.GetPR(r.Context(), prID)
+	if err != nil {
+		switch err {
+		case repoerrs.ErrNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to get pull request")
+			prr.logger.Error("failed to get pull request", map[string]any{
+				"pr_id": prID,
+				"error": err,
+			})
+			return
+		}
+	}
+
+	setETag(w, pullRequest.PullRequest.Version)
+	newSuccessResponse(w, http.StatusOK, pullRequest)
+}
+
+// @Summary Повторить запрос ревьюверов в GitHub
+// @Description Ставит в очередь запрос текущих ревьюверов PR в GitHub (и отзыв запросов у переназначенных) с новым набором попыток, например после статуса FAILED
+// @Tags PullRequests
+// @Accept json
+// @Produce json
+// @Param request body setPRStatusRequest true "Resync payload"
+// @Success 200 {object} service.ReviewerSyncResyncOutput
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "PR не найден"
+// @Failure 409 {object} ErrorResponse "PR не из GitHub или API токен не задан"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /pullRequest/resyncReviewers [post]
+func (prr *pullRequestRoutes) resyncReviewers(w http.ResponseWriter, r *http.Request) {
+	var req setPRStatusRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	output, err := prr.syncService.Resync(r.Context(), req.PullRequestID)
+	if err != nil {
+		switch err {
+		case repoerrs.ErrNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		case repoerrs.ErrReviewerSyncDisabled:
+			newErrorResponse(w, http.StatusConflict, CodeSyncDisabled, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to resync reviewers")
+			prr.logger.Error("failed to resync reviewers", map[string]any{
+				"pr_id": req.PullRequestID,
+				"error": err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusOK, output)
+}
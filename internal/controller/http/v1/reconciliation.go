@@ -0,0 +1,124 @@
+package v1
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/service"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/logger"
+)
+
+type reconciliationRoutes struct {
+	reconciliationService service.Reconciliation
+	logger                logger.Logger
+}
+
+func newReconciliationRoutes(reconciliationService service.Reconciliation, logger logger.Logger) *reconciliationRoutes {
+	rr := &reconciliationRoutes{
+		reconciliationService: reconciliationService,
+		logger:                logger,
+	}
+
+	return rr
+}
+
+// @Summary Сверить PR с провайдером
+// @Description Сверяет открытые PR организации, созданные webhook'ами GitHub/GitLab, с их состоянием у провайдера (нужен API токен, см. /vcs/setAPIToken) и исправляет расхождения из-за потерянных webhook'ов: смерженные у провайдера PR мержатся (merge), закрытые- закрываются (close), ревьюверы, снятые в PR GitHub после синхронизации, переназначаются (reassign, ревьюверы без сопоставления логина не сверяются). Все исправления, в том числе неудавшиеся (error), записываются и возвращаются в ответе; PR, которые не удалось получить у провайдера,- в failures. Та же сверка всех организаций выполняется по расписанию (reconciliation.interval)
+// @Tags Reconciliation
+// @Accept json
+// @Produce json
+// @Success 200 {object} service.ReconciliationOutput
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 409 {object} ErrorResponse "Сверка уже выполняется"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /reconciliation/run [post]
+func (rr *reconciliationRoutes) run(w http.ResponseWriter, r *http.Request) {
+	output, err := rr.reconciliationService.Reconcile(r.Context(), false)
+	if err != nil {
+		switch err {
+		case service.ErrReconciliationRunning:
+			newErrorResponse(w, http.StatusConflict, CodeReconciliationRunning, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to reconcile pull requests")
+			rr.logger.Error("failed to reconcile pull requests", map[string]any{
+				"error": err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusOK, output)
+}
+
+// @Summary Исправления сверки
+// @Description Возвращает исправления, внесенные сверкой с провайдером, от новых к старым. Неудавшееся исправление записывается один раз для PR и вида расхождения: повторные неудачи увеличивают attempts, успешное исправление позже заполняет resolved_at. Следующая страница- по next_cursor из ответа
+// @Tags Reconciliation
+// @Accept json
+// @Produce json
+// @Param pull_request_id query string false "Идентификатор PR"
+// @Param action query string false "Исправление" Enums(merge, close, reassign)
+// @Param from query string false "Начало периода (RFC3339)"
+// @Param to query string false "Конец периода (RFC3339)"
+// @Param window query string false "Период до текущего момента вместо from (например 24h, 30d)"
+// @Param limit query int false "Размер страницы (по умолчанию 50, не больше 500)"
+// @Param cursor query int false "next_cursor предыдущей страницы"
+// @Success 200 {object} service.ReconciliationCorrectionsOutput
+// @Failure 400 {object} ErrorResponse "Неверные параметры запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /reconciliation/corrections [get]
+func (rr *reconciliationRoutes) listCorrections(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	period, ok := parseStatsInput(w, query)
+	if !ok {
+		return
+	}
+
+	input := service.ReconciliationCorrectionsInput{
+		PullRequestID: query.Get("pull_request_id"),
+		Action:        query.Get("action"),
+		From:          period.From,
+		To:            period.To,
+	}
+
+	switch input.Action {
+	case "", models.ReconciliationMerge, models.ReconciliationClose, models.ReconciliationReassign:
+	default:
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid action")
+		return
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || limit == 0 || limit > service.MaxReconciliationLimit {
+			newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid limit")
+			return
+		}
+		input.Limit = limit
+	}
+
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || cursor <= 0 {
+			newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid cursor")
+			return
+		}
+		input.Cursor = cursor
+	}
+
+	output, err := rr.reconciliationService.GetCorrections(r.Context(), input)
+	if err != nil {
+		newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to get reconciliation corrections")
+		rr.logger.Error("failed to get reconciliation corrections", map[string]any{
+			"error": err,
+		})
+		return
+	}
+
+	newSuccessResponse(w, http.StatusOK, output)
+}
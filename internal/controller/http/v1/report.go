@@ -0,0 +1,313 @@
+package v1
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/service"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/logger"
+)
+
+const (
+	reportFormatCSV    = "csv"
+	reportFormatNDJSON = "ndjson"
+
+	// reportFlushRows is how often buffered rows are sent to the client.
+	reportFlushRows = 500
+	// reportWriteTimeout replaces the server write timeout for exports, it is
+	// extended on every flush, so only stalled streams are cut.
+	reportWriteTimeout = 30 * time.Second
+)
+
+type reportRoutes struct {
+	reportService service.Report
+	logger        logger.Logger
+}
+
+func newReportRoutes(reportService service.Report, logger logger.Logger) *reportRoutes {
+	rr := &reportRoutes{
+		reportService: reportService,
+		logger:        logger,
+	}
+
+	return rr
+}
+
+// @Summary Выгрузка отчета
+// @Description Потоково выгружает отчет в CSV или NDJSON (по объекту на строку). Отчеты: pull_requests (созданные за период, user_id- автор), assignments (назначения ревьюверов за период, включая переназначенные; user_id- ревьювер), user_stats, team_stats, user_flow, team_flow (те же данные, что и в /stats, длительности в секундах). По умолчанию выгружаются все колонки отчета
+// @Tags Reports
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param report query string true "Отчет" Enums(pull_requests, assignments, user_stats, team_stats, user_flow, team_flow)
+// @Param format query string false "Формат (по умолчанию csv)" Enums(csv, ndjson)
+// @Param columns query string false "Колонки через запятую в нужном порядке"
+// @Param user_id query string false "Идентификатор пользователя"
+// @Param team_name query string false "Имя команды"
+// @Param include_subteams query bool false "Суммировать по дереву подкоманд (team_stats)"
+// @Param from query string false "Начало периода (RFC3339)"
+// @Param to query string false "Конец периода (RFC3339)"
+// @Param window query string false "Период до текущего момента вместо from (например 24h, 30d)"
+// @Success 200 {string} string "Отчет"
+// @Failure 400 {object} ErrorResponse "Неверные параметры запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Пользователь/команда не найдены"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /reports/export [get]
+func (rr *reportRoutes) export(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	statsInput, ok := parseStatsInput(w, query)
+	if !ok {
+		return
+	}
+
+	input := service.ReportInput{
+		Report:     query.Get("report"),
+		StatsInput: statsInput,
+	}
+
+	if value := query.Get("columns"); value != "" {
+		for _, column := range strings.Split(value, ",") {
+			input.Columns = append(input.Columns, strings.TrimSpace(column))
+		}
+	}
+
+	var writer reportWriter
+	switch format := query.Get("format"); format {
+	case "", reportFormatCSV:
+		writer = newCSVReportWriter(w, input.Report)
+	case reportFormatNDJSON:
+		writer = newNDJSONReportWriter(w, input.Report)
+	default:
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid format")
+		return
+	}
+
+	err := rr.reportService.Export(r.Context(), input, writer)
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		return
+	}
+
+	if writer.Started() {
+		// Status is already sent, the client sees a truncated report.
+		rr.logger.Error("failed to stream report", map[string]any{
+			"report": input.Report,
+			"error":  err,
+		})
+		return
+	}
+
+	switch {
+	case errors.Is(err, service.ErrUnknownReport), errors.Is(err, service.ErrUnknownColumn):
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, err.Error())
+	case errors.Is(err, repoerrs.ErrNotFound):
+		newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+	default:
+		newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to export report")
+		rr.logger.Error("failed to export report", map[string]any{
+			"report":    input.Report,
+			"user_id":   input.UserID,
+			"team_name": input.TeamName,
+			"error":     err,
+		})
+	}
+}
+
+type reportWriter interface {
+	service.ReportWriter
+	Started() bool
+	Flush() error
+}
+
+// reportStream buffers the response and periodically flushes it to the
+// client, extending the write deadline.
+type reportStream struct {
+	w          http.ResponseWriter
+	controller *http.ResponseController
+	buf        *bufio.Writer
+	rows       int
+	started    bool
+}
+
+func newReportStream(w http.ResponseWriter) reportStream {
+	return reportStream{
+		w:          w,
+		controller: http.NewResponseController(w),
+		buf:        bufio.NewWriter(w),
+	}
+}
+
+func (s *reportStream) start(contentType, filename string) {
+	_ = s.controller.SetWriteDeadline(time.Now().Add(reportWriteTimeout))
+
+	s.w.Header().Set("Content-Type", contentType)
+	s.w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	s.w.WriteHeader(http.StatusOK)
+	s.started = true
+}
+
+func (s *reportStream) Started() bool {
+	return s.started
+}
+
+// flushDue counts the written row and reports whether it is time to flush.
+func (s *reportStream) flushDue() bool {
+	s.rows++
+
+	return s.rows%reportFlushRows == 0
+}
+
+func (s *reportStream) flush() error {
+	if err := s.buf.Flush(); err != nil {
+		return err
+	}
+
+	_ = s.controller.Flush()
+	_ = s.controller.SetWriteDeadline(time.Now().Add(reportWriteTimeout))
+
+	return nil
+}
+
+type csvReportWriter struct {
+	reportStream
+	csv    *csv.Writer
+	report string
+	record []string
+}
+
+func newCSVReportWriter(w http.ResponseWriter, report string) *csvReportWriter {
+	cw := &csvReportWriter{reportStream: newReportStream(w), report: report}
+	cw.csv = csv.NewWriter(cw.buf)
+
+	return cw
+}
+
+func (cw *csvReportWriter) WriteHeader(columns []string) error {
+	cw.start("text/csv; charset=utf-8", cw.report+".csv")
+	cw.record = make([]string, len(columns))
+
+	return cw.csv.Write(columns)
+}
+
+func (cw *csvReportWriter) WriteRow(values []any) error {
+	for i, value := range values {
+		cw.record[i] = formatCSVValue(value)
+	}
+
+	if err := cw.csv.Write(cw.record); err != nil {
+		return err
+	}
+
+	if cw.flushDue() {
+		return cw.Flush()
+	}
+
+	return nil
+}
+
+func (cw *csvReportWriter) Flush() error {
+	cw.csv.Flush()
+	if err := cw.csv.Error(); err != nil {
+		return err
+	}
+
+	return cw.flush()
+}
+
+func formatCSVValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return escapeCSVFormula(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case []string:
+		return escapeCSVFormula(strings.Join(v, ";"))
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// escapeCSVFormula keeps spreadsheets from running user supplied text (PR
+// names, usernames) as a formula by prefixing it with a quote.
+func escapeCSVFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+
+	return s
+}
+
+type ndjsonReportWriter struct {
+	reportStream
+	report  string
+	columns [][]byte // JSON encoded column names
+}
+
+func newNDJSONReportWriter(w http.ResponseWriter, report string) *ndjsonReportWriter {
+	return &ndjsonReportWriter{reportStream: newReportStream(w), report: report}
+}
+
+func (nw *ndjsonReportWriter) WriteHeader(columns []string) error {
+	nw.start("application/x-ndjson", nw.report+".ndjson")
+
+	nw.columns = make([][]byte, len(columns))
+	for i, column := range columns {
+		encoded, err := json.Marshal(column)
+		if err != nil {
+			return err
+		}
+		nw.columns[i] = encoded
+	}
+
+	return nil
+}
+
+// WriteRow writes the row as a JSON object keeping the order of columns.
+func (nw *ndjsonReportWriter) WriteRow(values []any) error {
+	_ = nw.buf.WriteByte('{')
+	for i, value := range values {
+		if i > 0 {
+			_ = nw.buf.WriteByte(',')
+		}
+
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+
+		_, _ = nw.buf.Write(nw.columns[i])
+		_ = nw.buf.WriteByte(':')
+		_, _ = nw.buf.Write(encoded)
+	}
+	if _, err := nw.buf.WriteString("}\n"); err != nil {
+		return err
+	}
+
+	if nw.flushDue() {
+		return nw.flush()
+	}
+
+	return nil
+}
+
+func (nw *ndjsonReportWriter) Flush() error { return nw.flush() }
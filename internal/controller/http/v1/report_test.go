@@ -0,0 +1,34 @@
+package v1
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatCSVValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{"nil", nil, ""},
+		{"plain string", "fix login", "fix login"},
+		{"empty string", "", ""},
+		{"formula", "=HYPERLINK(\"http://x\")", "'=HYPERLINK(\"http://x\")"},
+		{"plus", "+1", "'+1"},
+		{"minus", "-2+3", "'-2+3"},
+		{"at", "@SUM(A1)", "'@SUM(A1)"},
+		{"tab", "\t=1", "'\t=1"},
+		{"list", []string{"=u1", "u2"}, "'=u1;u2"},
+		{"negative number", float64(-1.5), "-1.5"},
+		{"time", time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), "2025-01-02T03:04:05Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatCSVValue(tt.value); got != tt.want {
+				t.Errorf("formatCSVValue(%v) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
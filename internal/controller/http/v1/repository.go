@@ -0,0 +1,235 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/service"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/logger"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/utils"
+)
+
+type repositoryRoutes struct {
+	repositoryService service.Repository
+	logger            logger.Logger
+}
+
+func newRepositoryRoutes(repositoryService service.Repository, logger logger.Logger) *repositoryRoutes {
+	rr := &repositoryRoutes{
+		repositoryService: repositoryService,
+		logger:            logger,
+	}
+
+	return rr
+}
+
+type addRepositoryRequest struct {
+	RepositoryName string `json:"repository_name" validate:"required"`
+	TeamName       string `json:"team_name"`
+	ReviewersCount *int   `json:"reviewers_count" validate:"omitempty,min=1"`
+	FallbackDepth  *int   `json:"fallback_depth" validate:"omitempty,min=0"`
+}
+
+// @Summary Зарегистрировать репозиторий
+// @Description Создает репозиторий с командой-владельцем и настройками ревью. Настройки репозитория (null- не переопределять) имеют приоритет над настройками команды
+// @Tags Repositories
+// @Accept json
+// @Produce json
+// @Param request body addRepositoryRequest true "Repository payload"
+// @Success 201 {object} service.RepositoryOutput
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Команда не найдена"
+// @Failure 409 {object} ErrorResponse "Репозиторий уже существует"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /repositories/add [post]
+func (rr *repositoryRoutes) add(w http.ResponseWriter, r *http.Request) {
+	var req addRepositoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	repository, err := rr.repositoryService.AddRepository(r.Context(), service.RepositoryInput{
+		RepositoryName: req.RepositoryName,
+		TeamName:       req.TeamName,
+		ReviewersCount: req.ReviewersCount,
+		FallbackDepth:  req.FallbackDepth,
+	})
+	if err != nil {
+		switch err {
+		case repoerrs.ErrTeamNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		case repoerrs.ErrAlreadyExists:
+			newErrorResponse(w, http.StatusConflict, CodeRepositoryExists, "repository_name already exists")
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to create repository")
+			rr.logger.Error("failed to create repository", map[string]any{
+				"repository_name": req.RepositoryName,
+				"team_name":       req.TeamName,
+				"error":           err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusCreated, repository)
+}
+
+// @Summary Получить репозиторий
+// @Description Возвращает команду-владельца и настройки ревью репозитория
+// @Tags Repositories
+// @Accept json
+// @Produce json
+// @Param repository_name query string true "Имя репозитория"
+// @Success 200 {object} service.RepositoryOutput
+// @Failure 400 {object} ErrorResponse "Неверное имя репозитория"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Репозиторий не найден"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /repositories/get [get]
+func (rr *repositoryRoutes) get(w http.ResponseWriter, r *http.Request) {
+	repositoryName := r.URL.Query().Get("repository_name")
+	if repositoryName == "" {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid repository_name")
+		return
+	}
+
+	repository, err := rr.repositoryService.GetRepository(r.Context(), repositoryName)
+	if err != nil {
+		switch err {
+		case repoerrs.ErrNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to get repository")
+			rr.logger.Error("failed to get repository", map[string]any{
+				"repository_name": repositoryName,
+				"error":           err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusOK, repository)
+}
+
+type setRepositoryOwnerRequest struct {
+	RepositoryName string `json:"repository_name" validate:"required"`
+	TeamName       string `json:"team_name"`
+}
+
+// @Summary Сменить команду-владельца репозитория
+// @Description Передает репозиторий другой команде. Пустое имя команды оставляет репозиторий без владельца
+// @Tags Repositories
+// @Accept json
+// @Produce json
+// @Param request body setRepositoryOwnerRequest true "Owner payload"
+// @Success 200 {object} service.RepositoryOutput
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Репозиторий/команда не найдены"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /repositories/setOwner [post]
+func (rr *repositoryRoutes) setOwner(w http.ResponseWriter, r *http.Request) {
+	var req setRepositoryOwnerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	repository, err := rr.repositoryService.SetRepositoryOwner(r.Context(), req.RepositoryName, req.TeamName)
+	if err != nil {
+		switch err {
+		case repoerrs.ErrNotFound, repoerrs.ErrTeamNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to set repository owner")
+			rr.logger.Error("failed to set repository owner", map[string]any{
+				"repository_name": req.RepositoryName,
+				"team_name":       req.TeamName,
+				"error":           err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusOK, repository)
+}
+
+type setRepositorySettingsRequest struct {
+	RepositoryName string         `json:"repository_name" validate:"required"`
+	ReviewersCount models.NullInt `json:"reviewers_count" swaggertype:"integer"`
+	FallbackDepth  models.NullInt `json:"fallback_depth" swaggertype:"integer"`
+}
+
+// @Summary Изменить настройки ревью репозитория
+// @Description Задает количество ревьюверов и глубину поиска ревьюверов по иерархии для пулл реквестов репозитория. null- использовать настройки команды. Не переданные поля не меняются
+// @Tags Repositories
+// @Accept json
+// @Produce json
+// @Param request body setRepositorySettingsRequest true "Settings payload"
+// @Success 200 {object} service.RepositoryOutput
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Репозиторий не найден"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /repositories/setSettings [post]
+func (rr *repositoryRoutes) setSettings(w http.ResponseWriter, r *http.Request) {
+	var req setRepositorySettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if !req.ReviewersCount.Set && !req.FallbackDepth.Set {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "reviewers_count or fallback_depth is required")
+		return
+	}
+	if req.ReviewersCount.Below(1) || req.FallbackDepth.Below(0) {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	repository, err := rr.repositoryService.SetRepositorySettings(r.Context(), req.RepositoryName, req.ReviewersCount, req.FallbackDepth)
+	if err != nil {
+		switch err {
+		case repoerrs.ErrNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to set repository settings")
+			rr.logger.Error("failed to set repository settings", map[string]any{
+				"repository_name": req.RepositoryName,
+				"error":           err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusOK, repository)
+}
@@ -3,6 +3,7 @@ package v1
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 )
 
 func newSuccessResponse(w http.ResponseWriter, status int, data any) {
@@ -11,3 +12,9 @@ func newSuccessResponse(w http.ResponseWriter, status int, data any) {
 
 	_ = json.NewEncoder(w).Encode(data)
 }
+
+// setETag returns the version of the entity in the response, requests
+// changing the entity send it back in If-Match.
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", strconv.Quote(strconv.Itoa(version)))
+}
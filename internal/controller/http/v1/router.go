@@ -17,7 +17,9 @@ func NewRouter(r *chi.Mux, services *service.Services, logger logger.Logger) {
 	authMiddleware := &AuthMiddleware{authService: services.Auth, log: logger}
 
 	r.Use(middleware.Recoverer)
+	r.Use(requestIDMiddleware)
 	r.Use(loggingMiddleware(logger))
+	r.Use(idempotencyMiddleware(services.Idempotency, logger))
 
 	r.Handle("/metrics", promhttp.Handler())
 
@@ -27,37 +29,224 @@ func NewRouter(r *chi.Mux, services *service.Services, logger logger.Logger) {
 
 	r.Get("/swagger/*", httpSwagger.WrapHandler)
 
+	r.Route("/tenants", func(rt chi.Router) {
+		tenant := newTenantRoutes(services.Tenant, logger)
+
+		rt.Use(authMiddleware.RootKeyMiddleware)
+
+		rt.Post("/add", tenant.add)
+		rt.Post("/addKey", tenant.addKey)
+		rt.Post("/revokeKey", tenant.revokeKey)
+	})
+
 	r.Route("/team", func(rt chi.Router) {
 		team := newTeamRoutes(services.Team, logger)
 
-		rt.Post("/add", team.add)
+		rt.With(authMiddleware.TenantMiddleware).
+			Post("/add", team.add)
+
+		rt.With(authMiddleware.APIKeyMiddleware(true), preconditionMiddleware).
+			Put("/", team.upsert)
 
 		rt.With(authMiddleware.APIKeyMiddleware(true)).
 			Get("/get", team.get)
 
-		rt.With(authMiddleware.APIKeyMiddleware(true)).
+		rt.With(authMiddleware.APIKeyMiddleware(true), preconditionMiddleware).
 			Post("/deactivate", team.deactivateTeam)
+
+		rt.With(authMiddleware.APIKeyMiddleware(true), preconditionMiddleware).
+			Post("/rename", team.rename)
+
+		rt.With(authMiddleware.APIKeyMiddleware(true), preconditionMiddleware).
+			Post("/setIsArchived", team.setIsArchived)
+
+		rt.With(authMiddleware.APIKeyMiddleware(true), preconditionMiddleware).
+			Post("/delete", team.delete)
+
+		rt.With(authMiddleware.APIKeyMiddleware(true), preconditionMiddleware).
+			Post("/setParent", team.setParent)
+
+		rt.With(authMiddleware.APIKeyMiddleware(true), preconditionMiddleware).
+			Post("/setSettings", team.setSettings)
+
+		rt.With(authMiddleware.APIKeyMiddleware(true), preconditionMiddleware).
+			Post("/setReviewSLA", team.setReviewSLA)
+
+		rt.With(authMiddleware.APIKeyMiddleware(true)).
+			Post("/import", team.importTeams)
 	})
 
 	r.Route("/users", func(rt chi.Router) {
 		user := newUserRoutes(services.User, logger)
 
-		rt.With(authMiddleware.APIKeyMiddleware(true)).
+		rt.With(authMiddleware.APIKeyMiddleware(true), preconditionMiddleware).
 			Post("/setIsActive", user.setIsActive)
 
+		rt.With(authMiddleware.APIKeyMiddleware(false)).
+			Get("/get", user.get)
+
 		rt.With(authMiddleware.APIKeyMiddleware(false)).
 			Get("/getReview", user.getReview)
+
+		rt.With(authMiddleware.APIKeyMiddleware(true), preconditionMiddleware).
+			Post("/setPrimaryTeam", user.setPrimaryTeam)
+
+		rt.With(authMiddleware.APIKeyMiddleware(true), preconditionMiddleware).
+			Post("/setEmail", user.setEmail)
+
+		rt.With(authMiddleware.APIKeyMiddleware(false)).
+			Get("/notificationPreferences", user.notificationPreferences)
+
+		rt.With(authMiddleware.APIKeyMiddleware(true)).
+			Post("/setNotificationPreferences", user.setNotificationPreferences)
+
+		telegram := newTelegramRoutes(services.Telegram, logger)
+
+		rt.With(authMiddleware.APIKeyMiddleware(true)).
+			Post("/telegramLinkCode", telegram.linkCode)
+	})
+
+	r.Route("/repositories", func(rt chi.Router) {
+		repository := newRepositoryRoutes(services.Repository, logger)
+
+		rt.With(authMiddleware.APIKeyMiddleware(true)).
+			Post("/add", repository.add)
+
+		rt.With(authMiddleware.APIKeyMiddleware(false)).
+			Get("/get", repository.get)
+
+		rt.With(authMiddleware.APIKeyMiddleware(true)).
+			Post("/setOwner", repository.setOwner)
+
+		rt.With(authMiddleware.APIKeyMiddleware(true)).
+			Post("/setSettings", repository.setSettings)
+	})
+
+	r.Route("/stats", func(rt chi.Router) {
+		stats := newStatsRoutes(services.Stats, logger)
+
+		rt.Use(authMiddleware.APIKeyMiddleware(false))
+
+		rt.Get("/users", stats.users)
+		rt.Get("/teams", stats.teams)
+		rt.Get("/flow/users", stats.usersFlow)
+		rt.Get("/flow/teams", stats.teamsFlow)
+	})
+
+	r.Route("/snapshots", func(rt chi.Router) {
+		snapshot := newSnapshotRoutes(services.Snapshot, logger)
+
+		rt.Use(authMiddleware.APIKeyMiddleware(true))
+
+		rt.Get("/export", snapshot.export)
+		rt.Post("/restore", snapshot.restore)
+	})
+
+	r.Route("/reports", func(rt chi.Router) {
+		report := newReportRoutes(services.Report, logger)
+
+		rt.With(authMiddleware.APIKeyMiddleware(false)).
+			Get("/export", report.export)
+	})
+
+	r.Route("/audit", func(rt chi.Router) {
+		audit := newAuditRoutes(services.Audit, logger)
+
+		rt.With(authMiddleware.APIKeyMiddleware(true)).
+			Get("/", audit.list)
+	})
+
+	r.Route("/webhooks", func(rt chi.Router) {
+		webhook := newWebhookRoutes(services.Webhook, logger)
+
+		rt.Group(func(rt chi.Router) {
+			rt.Use(authMiddleware.APIKeyMiddleware(true))
+
+			rt.Post("/subscriptions/add", webhook.addSubscription)
+			rt.Get("/subscriptions/list", webhook.listSubscriptions)
+			rt.Post("/subscriptions/setIsActive", webhook.setSubscriptionIsActive)
+			rt.Post("/subscriptions/delete", webhook.deleteSubscription)
+			rt.Get("/deliveries/list", webhook.listDeliveries)
+			rt.Post("/deliveries/retry", webhook.retryDelivery)
+		})
+
+		// signed by the provider, authorized by the signature instead of a key
+		vcs := newVCSRoutes(services.VCS, logger)
+
+		rt.Post("/github", vcs.github)
+		rt.Post("/gitlab", vcs.gitlab)
+
+		chatOps := newChatOpsRoutes(services.ChatOps, logger)
+
+		rt.Post("/slack", chatOps.slack)
+	})
+
+	r.Route("/chatops", func(rt chi.Router) {
+		chatOps := newChatOpsRoutes(services.ChatOps, logger)
+
+		rt.Use(authMiddleware.APIKeyMiddleware(true))
+
+		rt.Post("/setSigningSecret", chatOps.setSigningSecret)
+		rt.Post("/identities/set", chatOps.setIdentity)
+		rt.Post("/identities/delete", chatOps.deleteIdentity)
+		rt.Get("/identities/list", chatOps.listIdentities)
+	})
+
+	r.Route("/vcs", func(rt chi.Router) {
+		vcs := newVCSRoutes(services.VCS, logger)
+
+		rt.Use(authMiddleware.APIKeyMiddleware(true))
+
+		rt.Post("/setWebhookSecret", vcs.setWebhookSecret)
+		rt.Post("/setAPIToken", vcs.setAPIToken)
+		rt.Post("/identities/set", vcs.setIdentity)
+		rt.Post("/identities/delete", vcs.deleteIdentity)
+		rt.Get("/identities/list", vcs.listIdentities)
+	})
+
+	r.Route("/reconciliation", func(rt chi.Router) {
+		reconciliation := newReconciliationRoutes(services.Reconciliation, logger)
+
+		rt.Use(authMiddleware.APIKeyMiddleware(true))
+
+		rt.Post("/run", reconciliation.run)
+		rt.Get("/corrections", reconciliation.listCorrections)
+	})
+
+	r.Route("/notifications", func(rt chi.Router) {
+		notification := newNotificationRoutes(services.Notification, logger)
+
+		rt.Use(authMiddleware.APIKeyMiddleware(true))
+
+		rt.Post("/chatChannels/add", notification.addChatChannel)
+		rt.Get("/chatChannels/list", notification.listChatChannels)
+		rt.Post("/chatChannels/setIsActive", notification.setChatChannelIsActive)
+		rt.Post("/chatChannels/delete", notification.deleteChatChannel)
+		rt.Get("/list", notification.list)
+		rt.Post("/retry", notification.retry)
 	})
 
 	r.Route("/pullRequest", func(rt chi.Router) {
-		pr := newPullRequestRoutes(services.PullRequest, logger)
+		pr := newPullRequestRoutes(services.PullRequest, services.ReviewerSync, logger)
 		rt.With(authMiddleware.APIKeyMiddleware(true)).
 			Post("/create", pr.create)
 
-		rt.With(authMiddleware.APIKeyMiddleware(true)).
+		rt.With(authMiddleware.APIKeyMiddleware(true), preconditionMiddleware).
 			Post("/merge", pr.merge)
 
-		rt.With(authMiddleware.APIKeyMiddleware(true)).
+		rt.With(authMiddleware.APIKeyMiddleware(true), preconditionMiddleware).
 			Post("/reassign", pr.reassign)
+
+		rt.With(authMiddleware.APIKeyMiddleware(true), preconditionMiddleware).
+			Post("/close", pr.close)
+
+		rt.With(authMiddleware.APIKeyMiddleware(true), preconditionMiddleware).
+			Post("/reopen", pr.reopen)
+
+		rt.With(authMiddleware.APIKeyMiddleware(false)).
+			Get("/get", pr.get)
+
+		rt.With(authMiddleware.APIKeyMiddleware(true)).
+			Post("/resyncReviewers", pr.resyncReviewers)
 	})
 }
@@ -0,0 +1,109 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/service"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/logger"
+)
+
+const (
+	// maxSnapshotBody bounds restored archives.
+	maxSnapshotBody = 1 << 30
+	// snapshotTimeout replaces the server timeouts for transferring archives.
+	snapshotTimeout = 10 * time.Minute
+)
+
+type snapshotRoutes struct {
+	snapshotService service.Snapshot
+	logger          logger.Logger
+}
+
+func newSnapshotRoutes(snapshotService service.Snapshot, logger logger.Logger) *snapshotRoutes {
+	sr := &snapshotRoutes{
+		snapshotService: snapshotService,
+		logger:          logger,
+	}
+
+	return sr
+}
+
+// @Summary Выгрузка всех данных
+// @Description Выгружает команды, пользователей, репозитории, PR, ревьюверов и настройки тенанта одним JSON-архивом с версией формата и контрольной суммой. Секреты интеграций и привязки Telegram не выгружаются
+// @Tags Snapshots
+// @Produce json
+// @Success 200 {object} service.SnapshotArchive
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /snapshots/export [get]
+func (sr *snapshotRoutes) export(w http.ResponseWriter, r *http.Request) {
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(snapshotTimeout))
+
+	archive, err := sr.snapshotService.Export(r.Context())
+	if err != nil {
+		newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to export snapshot")
+		sr.logger.Error("failed to export snapshot", map[string]any{
+			"error": err,
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("snapshot-%s.json", archive.ExportedAt.Format("20060102-150405"))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	newSuccessResponse(w, http.StatusOK, archive)
+}
+
+// @Summary Восстановление из выгрузки
+// @Description Загружает архив /snapshots/export в тенант без команд, пользователей, репозиториев и PR. Архивы прошлых версий формата обновляются до текущей. Архив проверяется целиком (контрольная сумма, количество записей, ссылки между записями, ограничения схемы) и загружается одной транзакцией, события при этом не создаются
+// @Tags Snapshots
+// @Accept json
+// @Produce json
+// @Param request body service.SnapshotArchive true "Архив"
+// @Success 200 {object} service.SnapshotRestoreOutput
+// @Failure 400 {object} ErrorResponse "Неверный или поврежденный архив"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 409 {object} ErrorResponse "В тенанте уже есть данные"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /snapshots/restore [post]
+func (sr *snapshotRoutes) restore(w http.ResponseWriter, r *http.Request) {
+	controller := http.NewResponseController(w)
+	_ = controller.SetReadDeadline(time.Now().Add(snapshotTimeout))
+	_ = controller.SetWriteDeadline(time.Now().Add(snapshotTimeout))
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSnapshotBody))
+	if err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	output, err := sr.snapshotService.Restore(r.Context(), body)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidSnapshot),
+			errors.Is(err, service.ErrUnsupportedSnapshot),
+			errors.Is(err, service.ErrSnapshotChecksum):
+			newErrorResponse(w, http.StatusBadRequest, CodeInvalidSnapshot, err.Error())
+			return
+		case errors.Is(err, repoerrs.ErrTenantNotEmpty):
+			newErrorResponse(w, http.StatusConflict, CodeTenantNotEmpty, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to restore snapshot")
+			sr.logger.Error("failed to restore snapshot", map[string]any{
+				"size":  len(body),
+				"error": err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusOK, output)
+}
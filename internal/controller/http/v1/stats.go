@@ -0,0 +1,260 @@
+package v1
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/service"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/logger"
+)
+
+type statsRoutes struct {
+	statsService service.Stats
+	logger       logger.Logger
+}
+
+func newStatsRoutes(statsService service.Stats, logger logger.Logger) *statsRoutes {
+	sr := &statsRoutes{
+		statsService: statsService,
+		logger:       logger,
+	}
+
+	return sr
+}
+
+// @Summary Статистика ревью по пользователям
+// @Description Возвращает для каждого пользователя количество назначений, переназначений на другого ревьювера, завершенных (merged) ревью за период и текущую нагрузку (открытые PR). Границы периода- RFC3339, from включительно, to- нет
+// @Tags Stats
+// @Accept json
+// @Produce json
+// @Param user_id query string false "Идентификатор пользователя"
+// @Param team_name query string false "Только участники команды"
+// @Param from query string false "Начало периода (RFC3339)"
+// @Param to query string false "Конец периода (RFC3339)"
+// @Param window query string false "Период до текущего момента вместо from (например 24h, 30d)"
+// @Success 200 {object} service.StatsUserOutput
+// @Failure 400 {object} ErrorResponse "Неверные параметры запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Пользователь/команда не найдены"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /stats/users [get]
+func (sr *statsRoutes) users(w http.ResponseWriter, r *http.Request) {
+	input, ok := parseStatsInput(w, r.URL.Query())
+	if !ok {
+		return
+	}
+
+	stats, err := sr.statsService.GetUserStats(r.Context(), input)
+	if err != nil {
+		switch err {
+		case repoerrs.ErrNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to get user stats")
+			sr.logger.Error("failed to get user stats", map[string]any{
+				"user_id":   input.UserID,
+				"team_name": input.TeamName,
+				"error":     err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusOK, stats)
+}
+
+// @Summary Статистика ревью по командам
+// @Description Возвращает те же показатели по пулл реквестам, ревьюверы которых назначались из команды. С include_subteams показатели команды включают все ее подкоманды
+// @Tags Stats
+// @Accept json
+// @Produce json
+// @Param team_name query string false "Имя команды"
+// @Param include_subteams query bool false "Суммировать по дереву подкоманд"
+// @Param from query string false "Начало периода (RFC3339)"
+// @Param to query string false "Конец периода (RFC3339)"
+// @Param window query string false "Период до текущего момента вместо from (например 24h, 30d)"
+// @Success 200 {object} service.StatsTeamOutput
+// @Failure 400 {object} ErrorResponse "Неверные параметры запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Команда не найдена"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /stats/teams [get]
+func (sr *statsRoutes) teams(w http.ResponseWriter, r *http.Request) {
+	input, ok := parseStatsInput(w, r.URL.Query())
+	if !ok {
+		return
+	}
+
+	stats, err := sr.statsService.GetTeamStats(r.Context(), input)
+	if err != nil {
+		switch err {
+		case repoerrs.ErrNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to get team stats")
+			sr.logger.Error("failed to get team stats", map[string]any{
+				"team_name": input.TeamName,
+				"error":     err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusOK, stats)
+}
+
+// @Summary Скорость ревью по пользователям
+// @Description Возвращает перцентили (p50/p90/p99, в секундах) времени от назначения ревьювера до merge'а и до его переназначения на другого ревьювера за период
+// @Tags Stats
+// @Accept json
+// @Produce json
+// @Param user_id query string false "Идентификатор пользователя"
+// @Param team_name query string false "Только пулл реквесты команды"
+// @Param from query string false "Начало периода (RFC3339)"
+// @Param to query string false "Конец периода (RFC3339)"
+// @Param window query string false "Период до текущего момента вместо from (например 24h, 30d)"
+// @Success 200 {object} service.StatsUserFlowOutput
+// @Failure 400 {object} ErrorResponse "Неверные параметры запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Пользователь/команда не найдены"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /stats/flow/users [get]
+func (sr *statsRoutes) usersFlow(w http.ResponseWriter, r *http.Request) {
+	input, ok := parseStatsInput(w, r.URL.Query())
+	if !ok {
+		return
+	}
+
+	stats, err := sr.statsService.GetUserFlowStats(r.Context(), input)
+	if err != nil {
+		switch err {
+		case repoerrs.ErrNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to get user flow stats")
+			sr.logger.Error("failed to get user flow stats", map[string]any{
+				"user_id":   input.UserID,
+				"team_name": input.TeamName,
+				"error":     err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusOK, stats)
+}
+
+// @Summary Скорость ревью по командам
+// @Description Возвращает перцентили (p50/p90/p99, в секундах) времени от создания пулл реквеста до merge'а и до первого переназначения ревьювера за период
+// @Tags Stats
+// @Accept json
+// @Produce json
+// @Param team_name query string false "Имя команды"
+// @Param from query string false "Начало периода (RFC3339)"
+// @Param to query string false "Конец периода (RFC3339)"
+// @Param window query string false "Период до текущего момента вместо from (например 24h, 30d)"
+// @Success 200 {object} service.StatsTeamFlowOutput
+// @Failure 400 {object} ErrorResponse "Неверные параметры запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Команда не найдена"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /stats/flow/teams [get]
+func (sr *statsRoutes) teamsFlow(w http.ResponseWriter, r *http.Request) {
+	input, ok := parseStatsInput(w, r.URL.Query())
+	if !ok {
+		return
+	}
+
+	stats, err := sr.statsService.GetTeamFlowStats(r.Context(), input)
+	if err != nil {
+		switch err {
+		case repoerrs.ErrNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to get team flow stats")
+			sr.logger.Error("failed to get team flow stats", map[string]any{
+				"team_name": input.TeamName,
+				"error":     err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusOK, stats)
+}
+
+func parseStatsInput(w http.ResponseWriter, query url.Values) (service.StatsInput, bool) {
+	input := service.StatsInput{
+		UserID:   query.Get("user_id"),
+		TeamName: query.Get("team_name"),
+	}
+
+	if value := query.Get("include_subteams"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid include_subteams")
+			return input, false
+		}
+		input.IncludeSubteams = parsed
+	}
+
+	for name, bound := range map[string]**time.Time{"from": &input.From, "to": &input.To} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid "+name)
+			return input, false
+		}
+
+		parsed = parsed.UTC()
+		*bound = &parsed
+	}
+
+	if value := query.Get("window"); value != "" {
+		window, err := parseWindow(value)
+		if err != nil || window <= 0 || input.From != nil {
+			newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid window")
+			return input, false
+		}
+
+		from := time.Now().UTC().Add(-window)
+		input.From = &from
+	}
+
+	if input.From != nil && input.To != nil && !input.From.Before(*input.To) {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "from must be before to")
+		return input, false
+	}
+
+	return input, true
+}
+
+// parseWindow accepts Go durations and whole days, e.g. "36h" or "30d".
+func parseWindow(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	return time.ParseDuration(value)
+}
@@ -2,8 +2,13 @@ package v1
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
 	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
 	"github.com/MatTwix/Pull-Request-Assigner/internal/service"
 	"github.com/MatTwix/Pull-Request-Assigner/pkg/logger"
@@ -29,10 +34,11 @@ type addTeamRequest struct {
 	Members  []teamMember `json:"members" validate:"required,dive"`
 }
 
+// teamMember requires is_active to be sent, a pointer lets it be false.
 type teamMember struct {
 	UserID   string `json:"user_id" validate:"required"`
 	Username string `json:"username" validate:"required"`
-	IsActive bool   `json:"is_active" validate:"required"`
+	IsActive *bool  `json:"is_active" validate:"required"`
 }
 
 // @Summary Создать команду с участниками
@@ -65,7 +71,7 @@ func (tr *teamRoutes) add(w http.ResponseWriter, r *http.Request) {
 		input.Members = append(input.Members, service.TeamInputMember{
 			UserID:   member.UserID,
 			Username: member.Username,
-			IsActive: member.IsActive,
+			IsActive: *member.IsActive,
 		})
 	}
 
@@ -90,12 +96,14 @@ func (tr *teamRoutes) add(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Получить команду с участниками
-// @Description Возвращает информацию о команде и ее пользователях
+// @Description Возвращает информацию о команде и ее пользователях, при include_subteams- вместе с вложенными подкомандами
 // @Tags Teams
 // @Accept json
 // @Produce json
 // @Param team_name query string true "Имя команды"
+// @Param include_subteams query bool false "Вернуть дерево подкоманд"
 // @Success 200 {object} service.TeamGetOutput
+// @Header 200 {string} ETag "Версия команды для If-Match"
 // @Failure 400 {object} ErrorResponse "Неверное имя команды"
 // @Failure 401 {object} ErrorResponse "Ошибка авторизации"
 // @Failure 404 {object} ErrorResponse "Команда не найдена"
@@ -108,7 +116,17 @@ func (tr *teamRoutes) get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	team, err := tr.teamService.GetTeamByName(r.Context(), teamName)
+	includeSubteams := false
+	if value := r.URL.Query().Get("include_subteams"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid include_subteams")
+			return
+		}
+		includeSubteams = parsed
+	}
+
+	team, err := tr.teamService.GetTeamByName(r.Context(), teamName, includeSubteams)
 	if err != nil {
 		switch err {
 		case repoerrs.ErrNotFound:
@@ -124,6 +142,7 @@ func (tr *teamRoutes) get(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	setETag(w, team.Version)
 	newSuccessResponse(w, http.StatusOK, team)
 }
 
@@ -137,9 +156,11 @@ type deactivateTeamRequest struct {
 // @Accept json
 // @Produce json
 // @Param request body deactivateTeamRequest true "Team to deactivate name"
+// @Param If-Match header string false "Ожидаемая версия (ETag), при несовпадении- 412"
 // @Success 200 {object} service.TeamSetIsActiveTeamOutput
 // @Failure 400 {object} ErrorResponse "Неверное тело запроса"
 // @Failure 404 {object} ErrorResponse "Команда не найдена"
+// @Failure 412 {object} ErrorResponse "Версия изменилась"
 // @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
 // @Router /teams/deactivate [post]
 func (tr *teamRoutes) deactivateTeam(w http.ResponseWriter, r *http.Request) {
@@ -161,6 +182,9 @@ func (tr *teamRoutes) deactivateTeam(w http.ResponseWriter, r *http.Request) {
 		case repoerrs.ErrNotFound:
 			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
 			return
+		case repoerrs.ErrVersionMismatch:
+			newErrorResponse(w, http.StatusPreconditionFailed, CodeVersionMismatch, err.Error())
+			return
 		default:
 			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to deactivate users")
 			tr.logger.Error("failed to deactivate users", map[string]any{
@@ -173,3 +197,508 @@ func (tr *teamRoutes) deactivateTeam(w http.ResponseWriter, r *http.Request) {
 
 	newSuccessResponse(w, http.StatusOK, usersDeactivated)
 }
+
+type upsertTeamRequest struct {
+	TeamName string       `json:"team_name" validate:"required"`
+	Members  []teamMember `json:"members" validate:"dive"`
+	DryRun   bool         `json:"dry_run"`
+}
+
+// @Summary Декларативно синхронизировать состав команды
+// @Description Приводит команду к переданному списку участников: добавляет, исключает, обновляет имена и активность в одной транзакции. Возвращает отчет об изменениях, при dry_run изменения не применяются
+// @Tags Teams
+// @Accept json
+// @Produce json
+// @Param request body upsertTeamRequest true "Desired team state"
+// @Param If-Match header string false "Ожидаемая версия (ETag), при несовпадении- 412"
+// @Success 200 {object} service.TeamUpsertOutput
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 412 {object} ErrorResponse "Версия изменилась"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /team [put]
+func (tr *teamRoutes) upsert(w http.ResponseWriter, r *http.Request) {
+	var req upsertTeamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	input := service.TeamUpsertInput{
+		TeamName: req.TeamName,
+		DryRun:   req.DryRun,
+	}
+
+	for _, member := range req.Members {
+		input.Members = append(input.Members, service.TeamInputMember{
+			UserID:   member.UserID,
+			Username: member.Username,
+			IsActive: *member.IsActive,
+		})
+	}
+
+	diff, err := tr.teamService.UpsertTeam(r.Context(), input)
+	if err != nil {
+		switch err {
+		case repoerrs.ErrVersionMismatch:
+			newErrorResponse(w, http.StatusPreconditionFailed, CodeVersionMismatch, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to upsert team")
+			tr.logger.Error("failed to upsert team", map[string]any{
+				"team_name":      req.TeamName,
+				"members_amount": len(req.Members),
+				"dry_run":        req.DryRun,
+				"error":          err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusOK, diff)
+}
+
+type renameTeamRequest struct {
+	TeamName    string `json:"team_name" validate:"required"`
+	NewTeamName string `json:"new_team_name" validate:"required"`
+}
+
+// @Summary Переименовать команду
+// @Description Меняет название команды, ссылки пользователей на команду обновляются автоматически
+// @Tags Teams
+// @Accept json
+// @Produce json
+// @Param request body renameTeamRequest true "Rename payload"
+// @Param If-Match header string false "Ожидаемая версия (ETag), при несовпадении- 412"
+// @Success 200 {object} service.TeamRenameOutput
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Команда не найдена"
+// @Failure 409 {object} ErrorResponse "Команда с новым названием уже существует"
+// @Failure 412 {object} ErrorResponse "Версия изменилась"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /team/rename [post]
+func (tr *teamRoutes) rename(w http.ResponseWriter, r *http.Request) {
+	var req renameTeamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	team, err := tr.teamService.RenameTeam(r.Context(), req.TeamName, req.NewTeamName)
+	if err != nil {
+		switch err {
+		case repoerrs.ErrNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		case repoerrs.ErrVersionMismatch:
+			newErrorResponse(w, http.StatusPreconditionFailed, CodeVersionMismatch, err.Error())
+			return
+		case repoerrs.ErrAlreadyExists:
+			newErrorResponse(w, http.StatusConflict, CodeTeamExists, "new_team_name already exists")
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to rename team")
+			tr.logger.Error("failed to rename team", map[string]any{
+				"team_name":     req.TeamName,
+				"new_team_name": req.NewTeamName,
+				"error":         err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusOK, team)
+}
+
+type setIsArchivedTeamRequest struct {
+	TeamName   string `json:"team_name" validate:"required"`
+	IsArchived bool   `json:"is_archived"`
+}
+
+// @Summary Архивировать или вернуть команду из архива
+// @Description Участники архивной команды не назначаются ревьюверами, история пулл реквестов сохраняется
+// @Tags Teams
+// @Accept json
+// @Produce json
+// @Param request body setIsArchivedTeamRequest true "Archive payload"
+// @Param If-Match header string false "Ожидаемая версия (ETag), при несовпадении- 412"
+// @Success 200 {object} service.TeamSetIsArchivedOutput
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Команда не найдена"
+// @Failure 412 {object} ErrorResponse "Версия изменилась"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /team/setIsArchived [post]
+func (tr *teamRoutes) setIsArchived(w http.ResponseWriter, r *http.Request) {
+	var req setIsArchivedTeamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	team, err := tr.teamService.SetIsArchivedTeam(r.Context(), req.TeamName, req.IsArchived)
+	if err != nil {
+		switch err {
+		case repoerrs.ErrNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		case repoerrs.ErrVersionMismatch:
+			newErrorResponse(w, http.StatusPreconditionFailed, CodeVersionMismatch, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to set is_archived prop")
+			tr.logger.Error("failed to set is_archived prop", map[string]any{
+				"team_name":   req.TeamName,
+				"is_archived": req.IsArchived,
+				"error":       err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusOK, team)
+}
+
+type deleteTeamRequest struct {
+	TeamName   string `json:"team_name" validate:"required"`
+	ReassignTo string `json:"reassign_to"`
+}
+
+// @Summary Удалить команду
+// @Description Удаляет команду без участников. Если передан reassign_to, участники предварительно переводятся в указанную команду, она должна существовать и не быть архивной
+// @Tags Teams
+// @Accept json
+// @Produce json
+// @Param request body deleteTeamRequest true "Delete payload"
+// @Param If-Match header string false "Ожидаемая версия (ETag), при несовпадении- 412"
+// @Success 200 {object} service.TeamDeleteOutput
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Команда или команда reassign_to не найдена (REASSIGN_TARGET_NOT_FOUND)"
+// @Failure 409 {object} ErrorResponse "В команде остались участники или команда reassign_to архивная"
+// @Failure 412 {object} ErrorResponse "Версия изменилась"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /team/delete [post]
+func (tr *teamRoutes) delete(w http.ResponseWriter, r *http.Request) {
+	var req deleteTeamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil || req.ReassignTo == req.TeamName {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	result, err := tr.teamService.DeleteTeam(r.Context(), req.TeamName, req.ReassignTo)
+	if err != nil {
+		switch err {
+		case repoerrs.ErrNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		case repoerrs.ErrVersionMismatch:
+			newErrorResponse(w, http.StatusPreconditionFailed, CodeVersionMismatch, err.Error())
+			return
+		case repoerrs.ErrReassignTargetNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeReassignTargetNotFound, err.Error())
+			return
+		case repoerrs.ErrTeamNotEmpty:
+			newErrorResponse(w, http.StatusConflict, CodeTeamNotEmpty, err.Error())
+			return
+		case repoerrs.ErrTeamArchived:
+			newErrorResponse(w, http.StatusConflict, CodeTeamArchived, "team to reassign to is archived")
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to delete team")
+			tr.logger.Error("failed to delete team", map[string]any{
+				"team_name":   req.TeamName,
+				"reassign_to": req.ReassignTo,
+				"error":       err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusOK, result)
+}
+
+type setParentTeamRequest struct {
+	TeamName       string `json:"team_name" validate:"required"`
+	ParentTeamName string `json:"parent_team_name"`
+}
+
+// @Summary Переместить команду в иерархии
+// @Description Делает команду подкомандой указанной родительской команды. Пустой parent_team_name делает команду корневой
+// @Tags Teams
+// @Accept json
+// @Produce json
+// @Param request body setParentTeamRequest true "Parent payload"
+// @Param If-Match header string false "Ожидаемая версия (ETag), при несовпадении- 412"
+// @Success 200 {object} service.TeamUpdateOutput
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Команда не найдена"
+// @Failure 409 {object} ErrorResponse "Перемещение образует цикл"
+// @Failure 412 {object} ErrorResponse "Версия изменилась"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /team/setParent [post]
+func (tr *teamRoutes) setParent(w http.ResponseWriter, r *http.Request) {
+	var req setParentTeamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	team, err := tr.teamService.SetParentTeam(r.Context(), req.TeamName, req.ParentTeamName)
+	if err != nil {
+		switch err {
+		case repoerrs.ErrNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		case repoerrs.ErrVersionMismatch:
+			newErrorResponse(w, http.StatusPreconditionFailed, CodeVersionMismatch, err.Error())
+			return
+		case repoerrs.ErrTeamCycle:
+			newErrorResponse(w, http.StatusConflict, CodeTeamCycle, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to set parent team")
+			tr.logger.Error("failed to set parent team", map[string]any{
+				"team_name":        req.TeamName,
+				"parent_team_name": req.ParentTeamName,
+				"error":            err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusOK, team)
+}
+
+type setTeamSettingsRequest struct {
+	TeamName       string         `json:"team_name" validate:"required"`
+	ReviewersCount models.NullInt `json:"reviewers_count" swaggertype:"integer"`
+	FallbackDepth  models.NullInt `json:"fallback_depth" swaggertype:"integer"`
+}
+
+// @Summary Изменить настройки команды
+// @Description Задает количество ревьюверов на пулл реквест (null- 2) и глубину поиска ревьюверов по иерархии: при нехватке кандидатов в команде они берутся из подкоманд предков до указанного уровня. null- наследовать значение ближайшего предка. Не переданные поля не меняются
+// @Tags Teams
+// @Accept json
+// @Produce json
+// @Param request body setTeamSettingsRequest true "Settings payload"
+// @Param If-Match header string false "Ожидаемая версия (ETag), при несовпадении- 412"
+// @Success 200 {object} service.TeamUpdateOutput
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Команда не найдена"
+// @Failure 412 {object} ErrorResponse "Версия изменилась"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /team/setSettings [post]
+func (tr *teamRoutes) setSettings(w http.ResponseWriter, r *http.Request) {
+	var req setTeamSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if !req.ReviewersCount.Set && !req.FallbackDepth.Set {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "reviewers_count or fallback_depth is required")
+		return
+	}
+	if req.ReviewersCount.Below(1) || req.FallbackDepth.Below(0) {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	team, err := tr.teamService.SetTeamSettings(r.Context(), req.TeamName, req.ReviewersCount, req.FallbackDepth)
+	if err != nil {
+		switch err {
+		case repoerrs.ErrNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		case repoerrs.ErrVersionMismatch:
+			newErrorResponse(w, http.StatusPreconditionFailed, CodeVersionMismatch, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to set team settings")
+			tr.logger.Error("failed to set team settings", map[string]any{
+				"team_name": req.TeamName,
+				"error":     err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusOK, team)
+}
+
+type setTeamReviewSLARequest struct {
+	TeamName  string  `json:"team_name" validate:"required"`
+	ReviewSLA *string `json:"review_sla"`
+}
+
+// @Summary Изменить SLA ревью команды
+// @Description Задает время, за которое ревьювер должен посмотреть пулл реквест команды ("24h", "90m", "2d"); null или пустая строка- не следить за сроком. Назначения, ожидающие дольше, один раз порождают событие pull_request.sla_breached, которое отправляется в webhook'и и чаты команды
+// @Tags Teams
+// @Accept json
+// @Produce json
+// @Param request body setTeamReviewSLARequest true "Review SLA payload"
+// @Param If-Match header string false "Ожидаемая версия (ETag), при несовпадении- 412"
+// @Success 200 {object} service.TeamReviewSLAOutput
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Команда не найдена"
+// @Failure 412 {object} ErrorResponse "Версия изменилась"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /team/setReviewSLA [post]
+func (tr *teamRoutes) setReviewSLA(w http.ResponseWriter, r *http.Request) {
+	var req setTeamReviewSLARequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	var sla *time.Duration
+	if req.ReviewSLA != nil && *req.ReviewSLA != "" {
+		parsed, err := parseWindow(*req.ReviewSLA)
+		if err != nil || parsed < time.Minute {
+			newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid review_sla")
+			return
+		}
+		sla = &parsed
+	}
+
+	team, err := tr.teamService.SetReviewSLA(r.Context(), req.TeamName, sla)
+	if err != nil {
+		switch err {
+		case repoerrs.ErrNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		case repoerrs.ErrVersionMismatch:
+			newErrorResponse(w, http.StatusPreconditionFailed, CodeVersionMismatch, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to set team review sla")
+			tr.logger.Error("failed to set team review sla", map[string]any{
+				"team_name": req.TeamName,
+				"error":     err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusOK, team)
+}
+
+// maxImportBody bounds import files, thousands of teams fit far below it.
+const maxImportBody = 10 << 20
+
+// @Summary Массовый импорт команд и пользователей
+// @Description Принимает YAML или CSV с командами, их атрибутами (parent_team_name, reviewers_count, fallback_depth, review_sla, is_archived) и участниками. Файл проверяется целиком: при ошибках возвращается их список с номерами строк и ничего не применяется. Иначе все команды применяются в одной транзакции, при dry_run- возвращается отчет об изменениях без применения. Незаданные атрибуты и участники остаются прежними (parent_team_name "-" делает команду корневой), заданный список участников заменяет текущий, участник без is_active сохраняет текущую активность
+// @Tags Teams
+// @Accept application/yaml
+// @Accept text/csv
+// @Produce json
+// @Param format query string false "Формат файла (по умолчанию yaml)" Enums(yaml, csv)
+// @Param dry_run query bool false "Только отчет об изменениях"
+// @Param request body string true "Файл импорта"
+// @Success 200 {object} service.TeamImportOutput
+// @Failure 400 {object} ImportErrorResponse "Ошибки в файле импорта"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 409 {object} ErrorResponse "Перемещение образует цикл"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /team/import [post]
+func (tr *teamRoutes) importTeams(w http.ResponseWriter, r *http.Request) {
+	input := service.TeamImportInput{
+		Format: r.URL.Query().Get("format"),
+	}
+
+	if input.Format == "" {
+		input.Format = service.TeamImportFormatYAML
+	}
+	if input.Format != service.TeamImportFormatYAML && input.Format != service.TeamImportFormatCSV {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid format")
+		return
+	}
+
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid dry_run")
+			return
+		}
+		input.DryRun = parsed
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportBody))
+	if err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+	input.Data = body
+
+	output, err := tr.teamService.ImportTeams(r.Context(), input)
+	if err != nil {
+		var importErrs service.ImportErrors
+		switch {
+		case errors.As(err, &importErrs):
+			newImportErrorResponse(w, importErrs)
+			return
+		case errors.Is(err, repoerrs.ErrTeamCycle):
+			newErrorResponse(w, http.StatusConflict, CodeTeamCycle, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to import teams")
+			tr.logger.Error("failed to import teams", map[string]any{
+				"format":  input.Format,
+				"size":    len(input.Data),
+				"dry_run": input.DryRun,
+				"error":   err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusOK, output)
+}
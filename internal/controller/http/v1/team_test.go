@@ -0,0 +1,79 @@
+package v1
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/utils"
+)
+
+func TestAddTeamRequestIsActive(t *testing.T) {
+	utils.InitValidator()
+
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+	}{
+		{"active", `{"team_name": "t", "members": [{"user_id": "u1", "username": "A", "is_active": true}]}`, false},
+		{"inactive", `{"team_name": "t", "members": [{"user_id": "u1", "username": "A", "is_active": false}]}`, false},
+		{"omitted", `{"team_name": "t", "members": [{"user_id": "u1", "username": "A"}]}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req addTeamRequest
+			if err := json.Unmarshal([]byte(tt.body), &req); err != nil {
+				t.Fatal(err)
+			}
+
+			err := utils.ValidateStruct(req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateStruct() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSetTeamSettingsRequestPartial(t *testing.T) {
+	tests := []struct {
+		name               string
+		body               string
+		wantReviewersSet   bool
+		wantReviewers      *int
+		wantFallbackSet    bool
+		wantReviewersBelow bool
+	}{
+		{"omitted", `{"team_name": "t", "fallback_depth": 1}`, false, nil, true, false},
+		{"null", `{"team_name": "t", "reviewers_count": null}`, true, nil, false, false},
+		{"value", `{"team_name": "t", "reviewers_count": 3}`, true, intPtr(3), false, false},
+		{"below min", `{"team_name": "t", "reviewers_count": 0}`, true, intPtr(0), false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req setTeamSettingsRequest
+			if err := json.Unmarshal([]byte(tt.body), &req); err != nil {
+				t.Fatal(err)
+			}
+
+			if req.ReviewersCount.Set != tt.wantReviewersSet {
+				t.Errorf("ReviewersCount.Set = %v, want %v", req.ReviewersCount.Set, tt.wantReviewersSet)
+			}
+			if !reflect.DeepEqual(req.ReviewersCount.Value, tt.wantReviewers) {
+				t.Errorf("ReviewersCount.Value = %v, want %v", req.ReviewersCount.Value, tt.wantReviewers)
+			}
+			if req.FallbackDepth.Set != tt.wantFallbackSet {
+				t.Errorf("FallbackDepth.Set = %v, want %v", req.FallbackDepth.Set, tt.wantFallbackSet)
+			}
+			if got := req.ReviewersCount.Below(1); got != tt.wantReviewersBelow {
+				t.Errorf("ReviewersCount.Below(1) = %v, want %v", got, tt.wantReviewersBelow)
+			}
+		})
+	}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
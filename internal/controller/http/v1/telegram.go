@@ -0,0 +1,71 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/service"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/logger"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/utils"
+)
+
+type telegramRoutes struct {
+	telegramService service.Telegram
+	logger          logger.Logger
+}
+
+func newTelegramRoutes(telegramService service.Telegram, logger logger.Logger) *telegramRoutes {
+	return &telegramRoutes{
+		telegramService: telegramService,
+		logger:          logger,
+	}
+}
+
+type telegramLinkCodeRequest struct {
+	UserID string `json:"user_id" validate:"required"`
+}
+
+// @Summary Выдать код привязки Telegram
+// @Description Выдает одноразовый код, которым пользователь привязывает чат с ботом (команда /link <code> или ссылка на бота). Предыдущий код пользователя перестает действовать
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param request body telegramLinkCodeRequest true "User payload"
+// @Success 201 {object} service.TelegramLinkCodeOutput
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Пользователь не найден"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /users/telegramLinkCode [post]
+func (tr *telegramRoutes) linkCode(w http.ResponseWriter, r *http.Request) {
+	var req telegramLinkCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	code, err := tr.telegramService.IssueLinkCode(r.Context(), req.UserID)
+	if err != nil {
+		switch err {
+		case repoerrs.ErrNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to issue telegram link code")
+			tr.logger.Error("failed to issue telegram link code", map[string]any{
+				"user_id": req.UserID,
+				"error":   err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusCreated, code)
+}
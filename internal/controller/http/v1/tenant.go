@@ -0,0 +1,171 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/service"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/logger"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/utils"
+)
+
+type tenantRoutes struct {
+	tenantService service.Tenant
+	logger        logger.Logger
+}
+
+func newTenantRoutes(tenantService service.Tenant, logger logger.Logger) *tenantRoutes {
+	tr := &tenantRoutes{
+		tenantService: tenantService,
+		logger:        logger,
+	}
+
+	return tr
+}
+
+type addTenantRequest struct {
+	TenantName string `json:"tenant_name" validate:"required"`
+}
+
+// @Summary Создать организацию
+// @Description Создает изолированную организацию и выпускает для нее admin и user ключи. Ключи возвращаются только в этом ответе
+// @Tags Tenants
+// @Accept json
+// @Produce json
+// @Param request body addTenantRequest true "Tenant payload"
+// @Success 201 {object} service.TenantAddOutput
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 409 {object} ErrorResponse "Организация уже существует"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /tenants/add [post]
+func (tr *tenantRoutes) add(w http.ResponseWriter, r *http.Request) {
+	var req addTenantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	tenant, err := tr.tenantService.AddTenant(r.Context(), req.TenantName)
+	if err != nil {
+		switch err {
+		case repoerrs.ErrAlreadyExists:
+			newErrorResponse(w, http.StatusConflict, CodeTenantExists, "tenant_name already exists")
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to create tenant")
+			tr.logger.Error("failed to create tenant", map[string]any{
+				"tenant_name": req.TenantName,
+				"error":       err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusCreated, tenant)
+}
+
+type addAPIKeyRequest struct {
+	TenantName string `json:"tenant_name" validate:"required"`
+	Role       string `json:"role" validate:"required,oneof=admin user"`
+}
+
+// @Summary Выпустить API ключ организации
+// @Description Выпускает новый ключ с ролью admin или user. Ключ возвращается только в этом ответе
+// @Tags Tenants
+// @Accept json
+// @Produce json
+// @Param request body addAPIKeyRequest true "API key payload"
+// @Success 201 {object} service.TenantAddAPIKeyOutput
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Организация не найдена"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /tenants/addKey [post]
+func (tr *tenantRoutes) addKey(w http.ResponseWriter, r *http.Request) {
+	var req addAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	key, err := tr.tenantService.AddAPIKey(r.Context(), req.TenantName, req.Role)
+	if err != nil {
+		switch err {
+		case repoerrs.ErrNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to create api key")
+			tr.logger.Error("failed to create api key", map[string]any{
+				"tenant_name": req.TenantName,
+				"role":        req.Role,
+				"error":       err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusCreated, key)
+}
+
+type revokeAPIKeyRequest struct {
+	KeyID int `json:"key_id" validate:"required"`
+}
+
+// @Summary Отозвать API ключ организации
+// @Description Отозванный ключ перестает проходить авторизацию. Повторный отзыв не является ошибкой
+// @Tags Tenants
+// @Accept json
+// @Produce json
+// @Param request body revokeAPIKeyRequest true "API key payload"
+// @Success 200 {object} service.TenantRevokeAPIKeyOutput
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Ключ не найден"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /tenants/revokeKey [post]
+func (tr *tenantRoutes) revokeKey(w http.ResponseWriter, r *http.Request) {
+	var req revokeAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	key, err := tr.tenantService.RevokeAPIKey(r.Context(), req.KeyID)
+	if err != nil {
+		switch err {
+		case repoerrs.ErrNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to revoke api key")
+			tr.logger.Error("failed to revoke api key", map[string]any{
+				"key_id": req.KeyID,
+				"error":  err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusOK, key)
+}
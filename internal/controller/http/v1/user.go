@@ -2,6 +2,7 @@ package v1
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
@@ -35,10 +36,12 @@ type setIsActiveRequest struct {
 // @Accept json
 // @Produce json
 // @Param request body setIsActiveRequest true "User payload"
+// @Param If-Match header string false "Ожидаемая версия (ETag), при несовпадении- 412"
 // @Success 200 {object} service.UserSetIsActiveOutput
 // @Failure 400 {object} ErrorResponse "Неверное тело запроса"
 // @Failure 401 {object} ErrorResponse "Ошибка авторизации"
 // @Failure 404 {object} ErrorResponse "Пользователь не найден"
+// @Failure 412 {object} ErrorResponse "Версия изменилась"
 // @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
 // @Security ApiKeyAuth
 // @Router /users/setIsActive [post]
@@ -60,6 +63,9 @@ func (ur *userRoutes) setIsActive(w http.ResponseWriter, r *http.Request) {
 		case repoerrs.ErrNotFound:
 			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
 			return
+		case repoerrs.ErrVersionMismatch:
+			newErrorResponse(w, http.StatusPreconditionFailed, CodeVersionMismatch, err.Error())
+			return
 		default:
 			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to set is_active prop")
 			ur.logger.Error("failed to set is_active prop", map[string]any{
@@ -74,8 +80,49 @@ func (ur *userRoutes) setIsActive(w http.ResponseWriter, r *http.Request) {
 	newSuccessResponse(w, http.StatusOK, user)
 }
 
+// @Summary Получить пользователя
+// @Description Возвращает пользователя с его версией: версия передается в If-Match запросов, меняющих пользователя
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param user_id query string true "user_id пользователя"
+// @Success 200 {object} service.UserGetOutput
+// @Header 200 {string} ETag "Версия пользователя для If-Match"
+// @Failure 400 {object} ErrorResponse "Неверный user_id"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Пользователь не найден"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /users/get [get]
+func (ur *userRoutes) get(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid user_id")
+		return
+	}
+
+	user, err := ur.userService.GetUser(r.Context(), userID)
+	if err != nil {
+		switch err {
+		case repoerrs.ErrNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to get user")
+			ur.logger.Error("failed to get user", map[string]any{
+				"user_id": userID,
+				"error":   err,
+			})
+			return
+		}
+	}
+
+	setETag(w, user.User.Version)
+	newSuccessResponse(w, http.StatusOK, user)
+}
+
 // @Summary Получить пулл реквесты, в которых пользователь является ревьювером
-// @Description Возвращает список пулл реквестов, назначенных пользователю
+// @Description Возвращает список пулл реквестов, назначенных пользователю, включая перенесенные в архив по сроку хранения
 // @Tags Users
 // @Accept json
 // @Produce json
@@ -105,3 +152,216 @@ func (ur *userRoutes) getReview(w http.ResponseWriter, r *http.Request) {
 
 	newSuccessResponse(w, http.StatusOK, pullRequests)
 }
+
+type setPrimaryTeamRequest struct {
+	UserID   string `json:"user_id" validate:"required"`
+	TeamName string `json:"team_name" validate:"required"`
+}
+
+// @Summary Установить основную команду пользователя
+// @Description Делает одну из команд пользователя основной: из нее назначаются ревьюверы на его пулл реквесты по умолчанию
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param request body setPrimaryTeamRequest true "Primary team payload"
+// @Param If-Match header string false "Ожидаемая версия (ETag), при несовпадении- 412"
+// @Success 200 {object} service.UserSetPrimaryTeamOutput
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Пользователь не найден"
+// @Failure 409 {object} ErrorResponse "Пользователь не состоит в команде"
+// @Failure 412 {object} ErrorResponse "Версия изменилась"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /users/setPrimaryTeam [post]
+func (ur *userRoutes) setPrimaryTeam(w http.ResponseWriter, r *http.Request) {
+	var req setPrimaryTeamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	user, err := ur.userService.SetPrimaryTeam(r.Context(), req.UserID, req.TeamName)
+	if err != nil {
+		switch err {
+		case repoerrs.ErrNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		case repoerrs.ErrVersionMismatch:
+			newErrorResponse(w, http.StatusPreconditionFailed, CodeVersionMismatch, err.Error())
+			return
+		case repoerrs.ErrNotMember:
+			newErrorResponse(w, http.StatusConflict, CodeNotMember, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to set primary team")
+			ur.logger.Error("failed to set primary team", map[string]any{
+				"user_id":   req.UserID,
+				"team_name": req.TeamName,
+				"error":     err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusOK, user)
+}
+
+type setEmailRequest struct {
+	UserID string `json:"user_id" validate:"required"`
+	Email  string `json:"email" validate:"omitempty,email"`
+}
+
+// @Summary Установить email пользователя
+// @Description Задает адрес email уведомлений пользователя, пустой email удаляет адрес. Какие письма отправляются (о каждом назначении и/или ежедневная сводка review_digest), задается настройками уведомлений
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param request body setEmailRequest true "Email payload"
+// @Param If-Match header string false "Ожидаемая версия (ETag), при несовпадении- 412"
+// @Success 200 {object} service.UserSetEmailOutput
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Пользователь не найден"
+// @Failure 412 {object} ErrorResponse "Версия изменилась"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /users/setEmail [post]
+func (ur *userRoutes) setEmail(w http.ResponseWriter, r *http.Request) {
+	var req setEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	user, err := ur.userService.SetEmail(r.Context(), req.UserID, req.Email)
+	if err != nil {
+		switch err {
+		case repoerrs.ErrNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		case repoerrs.ErrVersionMismatch:
+			newErrorResponse(w, http.StatusPreconditionFailed, CodeVersionMismatch, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to set email")
+			ur.logger.Error("failed to set email", map[string]any{
+				"user_id": req.UserID,
+				"error":   err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusOK, user)
+}
+
+// @Summary Получить настройки уведомлений пользователя
+// @Description Возвращает каналы, типы событий и тихие часы пользователя, для пользователя без настроек- значения по умолчанию (все уведомления в любое время)
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param user_id query string true "user_id пользователя"
+// @Success 200 {object} service.UserNotificationPreferencesOutput
+// @Failure 400 {object} ErrorResponse "Неверный user_id"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Пользователь не найден"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /users/notificationPreferences [get]
+func (ur *userRoutes) notificationPreferences(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid user_id")
+		return
+	}
+
+	preferences, err := ur.userService.GetNotificationPreferences(r.Context(), userID)
+	if err != nil {
+		switch err {
+		case repoerrs.ErrNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to get notification preferences")
+			ur.logger.Error("failed to get notification preferences", map[string]any{
+				"user_id": userID,
+				"error":   err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusOK, preferences)
+}
+
+type setNotificationPreferencesRequest struct {
+	UserID     string              `json:"user_id" validate:"required"`
+	Channels   []string            `json:"channels" validate:"dive,oneof=chat email telegram"`
+	EventTypes []string            `json:"event_types" validate:"dive,oneof=pull_request.created pull_request.reviewer_reassigned pull_request.sla_breached pull_request.merged review_digest"`
+	Timezone   string              `json:"timezone"`
+	QuietHours *service.QuietHours `json:"quiet_hours"`
+}
+
+// @Summary Установить настройки уведомлений пользователя
+// @Description Заменяет настройки уведомлений пользователя: каналы (пусто- все), типы событий (пусто- все события; ежедневная сводка review_digest отправляется, только если выбрана явно) и тихие часы в часовом поясе пользователя (IANA, по умолчанию UTC). Уведомления, выпавшие на тихие часы, копятся и отправляются после их окончания
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param request body setNotificationPreferencesRequest true "Notification preferences payload"
+// @Success 200 {object} service.UserNotificationPreferencesOutput
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса, часовой пояс или тихие часы"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Пользователь не найден"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /users/setNotificationPreferences [post]
+func (ur *userRoutes) setNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	var req setNotificationPreferencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	preferences, err := ur.userService.SetNotificationPreferences(r.Context(), service.NotificationPreferencesInput{
+		UserID:     req.UserID,
+		Channels:   req.Channels,
+		EventTypes: req.EventTypes,
+		Timezone:   req.Timezone,
+		QuietHours: req.QuietHours,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidPreferences):
+			newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, err.Error())
+			return
+		case errors.Is(err, repoerrs.ErrNotFound):
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to set notification preferences")
+			ur.logger.Error("failed to set notification preferences", map[string]any{
+				"user_id": req.UserID,
+				"error":   err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusOK, preferences)
+}
@@ -0,0 +1,372 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/service"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/logger"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/utils"
+)
+
+// maxVCSWebhookBody is the largest payload GitHub and GitLab send.
+const maxVCSWebhookBody = 25 << 20
+
+type vcsRoutes struct {
+	vcsService service.VCS
+	logger     logger.Logger
+}
+
+func newVCSRoutes(vcsService service.VCS, logger logger.Logger) *vcsRoutes {
+	vr := &vcsRoutes{
+		vcsService: vcsService,
+		logger:     logger,
+	}
+
+	return vr
+}
+
+// @Summary Принять webhook GitHub
+// @Description Обрабатывает события pull_request GitHub. Подпись X-Hub-Signature-256 проверяется секретом, заданным через /vcs/setWebhookSecret. opened и ready_for_review создают PR (черновики пропускаются), closed мержит или закрывает его, reopened открывает снова. Идентификатор PR- "<owner>/<repo>#<номер>", автор определяется по сопоставлению логинов /vcs/identities, PR автора без сопоставления пропускается (result=unknown_author). Повторная доставка с тем же X-GitHub-Delivery не обрабатывается (duplicate=true). Организация- по параметру tenant, по умолчанию- организация по умолчанию
+// @Tags VCS
+// @Accept json
+// @Produce json
+// @Param tenant query string false "Название организации"
+// @Param X-GitHub-Event header string true "Тип события"
+// @Param X-GitHub-Delivery header string true "Идентификатор доставки"
+// @Param X-Hub-Signature-256 header string true "HMAC-SHA256 подпись тела (sha256=<hex>)"
+// @Param request body object true "Payload события GitHub"
+// @Success 200 {object} service.VCSWebhookOutput
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса или заголовки"
+// @Failure 401 {object} ErrorResponse "Неверная подпись или организация не найдена"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Router /webhooks/github [post]
+func (vr *vcsRoutes) github(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxVCSWebhookBody))
+	if err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	input := service.VCSWebhookInput{
+		TenantName: r.URL.Query().Get("tenant"),
+		DeliveryID: r.Header.Get("X-GitHub-Delivery"),
+		Event:      r.Header.Get("X-GitHub-Event"),
+		Signature:  r.Header.Get("X-Hub-Signature-256"),
+		Body:       body,
+	}
+
+	if input.DeliveryID == "" || input.Event == "" {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "missing X-GitHub-Delivery or X-GitHub-Event header")
+		return
+	}
+
+	output, err := vr.vcsService.HandleGitHubWebhook(r.Context(), input)
+	if err != nil {
+		vr.webhookError(w, input, err)
+		return
+	}
+
+	newSuccessResponse(w, http.StatusOK, output)
+}
+
+// @Summary Принять webhook GitLab
+// @Description Обрабатывает события Merge Request Hook GitLab. X-Gitlab-Token сверяется с секретом, заданным через /vcs/setWebhookSecret. open и update, снимающий статус черновика, создают PR (черновики пропускаются), merge мержит его, close закрывает, reopen открывает снова. Идентификатор PR- "<group>/<project>!<iid>". Автор определяется по author_id merge request'а через account_id сопоставлений /vcs/identities (provider gitlab), PR автора без сопоставления пропускается (result=unknown_author). Повторная доставка с тем же Idempotency-Key (X-Gitlab-Event-UUID в старых версиях GitLab) не обрабатывается (duplicate=true). Организация- по параметру tenant, по умолчанию- организация по умолчанию
+// @Tags VCS
+// @Accept json
+// @Produce json
+// @Param tenant query string false "Название организации"
+// @Param X-Gitlab-Event header string true "Тип события"
+// @Param X-Gitlab-Token header string true "Секрет webhook'а"
+// @Param Idempotency-Key header string false "Идентификатор доставки, не меняется между повторами"
+// @Param X-Gitlab-Event-UUID header string false "Идентификатор события, если нет Idempotency-Key"
+// @Param request body object true "Payload события GitLab"
+// @Success 200 {object} service.VCSWebhookOutput
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса или заголовки"
+// @Failure 401 {object} ErrorResponse "Неверный токен или организация не найдена"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Router /webhooks/gitlab [post]
+func (vr *vcsRoutes) gitlab(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxVCSWebhookBody))
+	if err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	deliveryID := r.Header.Get("Idempotency-Key")
+	if deliveryID == "" {
+		deliveryID = r.Header.Get("X-Gitlab-Event-UUID")
+	}
+
+	input := service.VCSWebhookInput{
+		TenantName: r.URL.Query().Get("tenant"),
+		DeliveryID: deliveryID,
+		Event:      r.Header.Get("X-Gitlab-Event"),
+		Signature:  r.Header.Get("X-Gitlab-Token"),
+		Body:       body,
+	}
+
+	if input.DeliveryID == "" || input.Event == "" {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "missing Idempotency-Key or X-Gitlab-Event header")
+		return
+	}
+
+	output, err := vr.vcsService.HandleGitLabWebhook(r.Context(), input)
+	if err != nil {
+		vr.webhookError(w, input, err)
+		return
+	}
+
+	newSuccessResponse(w, http.StatusOK, output)
+}
+
+func (vr *vcsRoutes) webhookError(w http.ResponseWriter, input service.VCSWebhookInput, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidSignature):
+		newErrorResponse(w, http.StatusUnauthorized, CodeSignatureError, err.Error())
+	case errors.Is(err, service.ErrInvalidPayload):
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, err.Error())
+	default:
+		newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to process webhook")
+		vr.logger.Error("failed to process vcs webhook", map[string]any{
+			"tenant":      input.TenantName,
+			"delivery_id": input.DeliveryID,
+			"event":       input.Event,
+			"error":       err,
+		})
+	}
+}
+
+type setVCSWebhookSecretRequest struct {
+	Provider string `json:"provider" validate:"required,oneof=github gitlab"`
+	Secret   string `json:"secret" validate:"omitempty,min=16"`
+}
+
+// @Summary Задать секрет webhook'ов системы контроля версий
+// @Description Задает секрет, которым провайдер (github, gitlab) подписывает webhook'и организации. Если секрет не передан- генерируется. Секрет возвращается только в этом ответе
+// @Tags VCS
+// @Accept json
+// @Produce json
+// @Param request body setVCSWebhookSecretRequest true "Secret payload"
+// @Success 200 {object} service.VCSWebhookSecretOutput
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /vcs/setWebhookSecret [post]
+func (vr *vcsRoutes) setWebhookSecret(w http.ResponseWriter, r *http.Request) {
+	var req setVCSWebhookSecretRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	output, err := vr.vcsService.SetWebhookSecret(r.Context(), req.Provider, req.Secret)
+	if err != nil {
+		newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to set webhook secret")
+		vr.logger.Error("failed to set vcs webhook secret", map[string]any{
+			"provider": req.Provider,
+			"error":    err,
+		})
+		return
+	}
+
+	newSuccessResponse(w, http.StatusOK, output)
+}
+
+type setVCSAPITokenRequest struct {
+	Provider string `json:"provider" validate:"required,oneof=github gitlab"`
+	APIToken string `json:"api_token"`
+}
+
+// @Summary Задать API токен системы контроля версий
+// @Description Задает токен, которым открытые PR сверяются с провайдером (см. /reconciliation/run), а в GitHub также запрашиваются ревьюверы, назначенные сервисом (request reviewers), и отзываются переназначенные. Пустой токен отключает и то, и другое. Токену GitHub нужен доступ на запись к pull requests, GitLab- read_api. Токен хранится зашифрованным ключом vcs.token_key, без ключа токены не принимаются
+// @Tags VCS
+// @Accept json
+// @Produce json
+// @Param request body setVCSAPITokenRequest true "Token payload"
+// @Success 200 {object} service.VCSAPITokenOutput
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 409 {object} ErrorResponse "Ключ шифрования токенов не задан"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /vcs/setAPIToken [post]
+func (vr *vcsRoutes) setAPIToken(w http.ResponseWriter, r *http.Request) {
+	var req setVCSAPITokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	output, err := vr.vcsService.SetAPIToken(r.Context(), req.Provider, req.APIToken)
+	if errors.Is(err, service.ErrAPITokensDisabled) {
+		newErrorResponse(w, http.StatusConflict, CodeAPITokensDisabled, err.Error())
+		return
+	} else if err != nil {
+		newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to set api token")
+		vr.logger.Error("failed to set vcs api token", map[string]any{
+			"provider": req.Provider,
+			"error":    err,
+		})
+		return
+	}
+
+	newSuccessResponse(w, http.StatusOK, output)
+}
+
+type setVCSIdentityRequest struct {
+	Provider  string `json:"provider" validate:"required,oneof=github gitlab"`
+	Login     string `json:"login" validate:"required"`
+	AccountID string `json:"account_id" validate:"omitempty,numeric"`
+	UserID    string `json:"user_id" validate:"required"`
+}
+
+// @Summary Сопоставить логин системы контроля версий пользователю
+// @Description Задает пользователя для логина провайдера (github, gitlab), заменяя прежнее сопоставление логина. account_id- числовой идентификатор аккаунта у провайдера, для GitLab обязателен, чтобы PR автора создавались из webhook'ов (в них автор передается только идентификатором). Идентификатор, сопоставленный другому логину, переносится на этот
+// @Tags VCS
+// @Accept json
+// @Produce json
+// @Param request body setVCSIdentityRequest true "Identity payload"
+// @Success 200 {object} service.VCSOutputIdentity
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Пользователь не найден"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /vcs/identities/set [post]
+func (vr *vcsRoutes) setIdentity(w http.ResponseWriter, r *http.Request) {
+	var req setVCSIdentityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	identity, err := vr.vcsService.SetIdentity(r.Context(), service.VCSIdentityInput{
+		Provider:  req.Provider,
+		Login:     req.Login,
+		AccountID: req.AccountID,
+		UserID:    req.UserID,
+	})
+	if err != nil {
+		switch err {
+		case repoerrs.ErrUserNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to set identity")
+			vr.logger.Error("failed to set vcs identity", map[string]any{
+				"provider": req.Provider,
+				"login":    req.Login,
+				"user_id":  req.UserID,
+				"error":    err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusOK, identity)
+}
+
+type deleteVCSIdentityRequest struct {
+	Provider string `json:"provider" validate:"required,oneof=github gitlab"`
+	Login    string `json:"login" validate:"required"`
+}
+
+// @Summary Удалить сопоставление логина
+// @Description После удаления PR автора с этим логином не создаются из webhook'ов провайдера
+// @Tags VCS
+// @Accept json
+// @Produce json
+// @Param request body deleteVCSIdentityRequest true "Identity payload"
+// @Success 204 "Сопоставление удалено"
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Сопоставление не найдено"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /vcs/identities/delete [post]
+func (vr *vcsRoutes) deleteIdentity(w http.ResponseWriter, r *http.Request) {
+	var req deleteVCSIdentityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := vr.vcsService.DeleteIdentity(r.Context(), req.Provider, req.Login); err != nil {
+		switch err {
+		case repoerrs.ErrNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to delete identity")
+			vr.logger.Error("failed to delete vcs identity", map[string]any{
+				"provider": req.Provider,
+				"login":    req.Login,
+				"error":    err,
+			})
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Список сопоставлений логинов
+// @Description Возвращает сопоставления логинов систем контроля версий пользователям организации
+// @Tags VCS
+// @Accept json
+// @Produce json
+// @Param provider query string false "Провайдер" Enums(github, gitlab)
+// @Success 200 {object} service.VCSIdentitiesOutput
+// @Failure 400 {object} ErrorResponse "Неверные параметры запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /vcs/identities/list [get]
+func (vr *vcsRoutes) listIdentities(w http.ResponseWriter, r *http.Request) {
+	provider := r.URL.Query().Get("provider")
+
+	switch provider {
+	case "", models.VCSProviderGitHub, models.VCSProviderGitLab:
+	default:
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid provider")
+		return
+	}
+
+	identities, err := vr.vcsService.GetIdentities(r.Context(), provider)
+	if err != nil {
+		newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to get identities")
+		vr.logger.Error("failed to get vcs identities", map[string]any{
+			"provider": provider,
+			"error":    err,
+		})
+		return
+	}
+
+	newSuccessResponse(w, http.StatusOK, identities)
+}
@@ -0,0 +1,327 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/service"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/logger"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/utils"
+)
+
+type webhookRoutes struct {
+	webhookService service.Webhook
+	logger         logger.Logger
+}
+
+func newWebhookRoutes(webhookService service.Webhook, logger logger.Logger) *webhookRoutes {
+	wr := &webhookRoutes{
+		webhookService: webhookService,
+		logger:         logger,
+	}
+
+	return wr
+}
+
+type addWebhookSubscriptionRequest struct {
+	URL        string   `json:"url" validate:"required,url"`
+	EventTypes []string `json:"event_types" validate:"dive,oneof=pull_request.created pull_request.merged pull_request.closed pull_request.reopened pull_request.reviewer_reassigned pull_request.sla_breached"`
+	Secret     string   `json:"secret" validate:"omitempty,min=16"`
+}
+
+// @Summary Подписать webhook на события
+// @Description Регистрирует URL, на который POST-запросом отправляются события (pull_request.created, pull_request.merged, pull_request.closed, pull_request.reopened, pull_request.reviewer_reassigned, pull_request.sla_breached; пустой event_types- все события). Тело запроса подписывается HMAC-SHA256 секретом подписки, подпись- в заголовке X-Webhook-Signature-256 (sha256=<hex>). Неуспешные доставки повторяются с экспоненциальной задержкой, после исчерпания попыток доставка получает статус DEAD. Секрет возвращается только в этом ответе, если он не передан- генерируется. URL должен вести на публичный адрес: адреса loopback, частных сетей и link-local (в том числе метаданные облака) отклоняются при регистрации и при каждой отправке, редиректы не выполняются
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param request body addWebhookSubscriptionRequest true "Subscription payload"
+// @Success 201 {object} service.WebhookOutputSubscription
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса или непубличный URL"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 409 {object} ErrorResponse "Подписка на этот URL уже существует"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /webhooks/subscriptions/add [post]
+func (wr *webhookRoutes) addSubscription(w http.ResponseWriter, r *http.Request) {
+	var req addWebhookSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if !strings.HasPrefix(req.URL, "http://") && !strings.HasPrefix(req.URL, "https://") {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "url must be http or https")
+		return
+	}
+
+	subscription, err := wr.webhookService.AddSubscription(r.Context(), service.WebhookSubscriptionInput{
+		URL:        req.URL,
+		EventTypes: req.EventTypes,
+		Secret:     req.Secret,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrURLNotAllowed):
+			newErrorResponse(w, http.StatusBadRequest, CodeURLNotAllowed, err.Error())
+			return
+		case errors.Is(err, repoerrs.ErrAlreadyExists):
+			newErrorResponse(w, http.StatusConflict, CodeSubscriptionExists, "subscription to this url already exists")
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to add webhook subscription")
+			wr.logger.Error("failed to add webhook subscription", map[string]any{
+				"url":   req.URL,
+				"error": err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusCreated, subscription)
+}
+
+// @Summary Список webhook подписок
+// @Description Возвращает подписки организации (без секретов)
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Success 200 {object} service.WebhookSubscriptionsOutput
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /webhooks/subscriptions/list [get]
+func (wr *webhookRoutes) listSubscriptions(w http.ResponseWriter, r *http.Request) {
+	subscriptions, err := wr.webhookService.GetSubscriptions(r.Context())
+	if err != nil {
+		newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to get webhook subscriptions")
+		wr.logger.Error("failed to get webhook subscriptions", map[string]any{
+			"error": err,
+		})
+		return
+	}
+
+	newSuccessResponse(w, http.StatusOK, subscriptions)
+}
+
+type setWebhookSubscriptionIsActiveRequest struct {
+	SubscriptionID int   `json:"subscription_id" validate:"required"`
+	IsActive       *bool `json:"is_active" validate:"required"`
+}
+
+// @Summary Включить/выключить webhook подписку
+// @Description Выключенная подписка не получает новые события, уже созданные доставки продолжают отправляться
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param request body setWebhookSubscriptionIsActiveRequest true "Subscription status payload"
+// @Success 200 {object} service.WebhookOutputSubscription
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Подписка не найдена"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /webhooks/subscriptions/setIsActive [post]
+func (wr *webhookRoutes) setSubscriptionIsActive(w http.ResponseWriter, r *http.Request) {
+	var req setWebhookSubscriptionIsActiveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	subscription, err := wr.webhookService.SetSubscriptionIsActive(r.Context(), req.SubscriptionID, *req.IsActive)
+	if err != nil {
+		switch err {
+		case repoerrs.ErrNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to update webhook subscription")
+			wr.logger.Error("failed to update webhook subscription", map[string]any{
+				"subscription_id": req.SubscriptionID,
+				"error":           err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusOK, subscription)
+}
+
+type deleteWebhookSubscriptionRequest struct {
+	SubscriptionID int `json:"subscription_id" validate:"required"`
+}
+
+// @Summary Удалить webhook подписку
+// @Description Удаляет подписку вместе с историей ее доставок
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param request body deleteWebhookSubscriptionRequest true "Subscription payload"
+// @Success 204 "Подписка удалена"
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Подписка не найдена"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /webhooks/subscriptions/delete [post]
+func (wr *webhookRoutes) deleteSubscription(w http.ResponseWriter, r *http.Request) {
+	var req deleteWebhookSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := wr.webhookService.DeleteSubscription(r.Context(), req.SubscriptionID); err != nil {
+		switch err {
+		case repoerrs.ErrNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to delete webhook subscription")
+			wr.logger.Error("failed to delete webhook subscription", map[string]any{
+				"subscription_id": req.SubscriptionID,
+				"error":           err,
+			})
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary История доставок webhook'ов
+// @Description Возвращает доставки от новых к старым. status=DEAD- доставки, исчерпавшие попытки (dead-letter). Следующая страница- по next_cursor из ответа
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param subscription_id query int false "Идентификатор подписки"
+// @Param status query string false "Статус доставки" Enums(PENDING, DELIVERED, DEAD)
+// @Param limit query int false "Размер страницы (по умолчанию 50, не больше 500)"
+// @Param cursor query int false "next_cursor предыдущей страницы"
+// @Success 200 {object} service.WebhookDeliveriesOutput
+// @Failure 400 {object} ErrorResponse "Неверные параметры запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /webhooks/deliveries/list [get]
+func (wr *webhookRoutes) listDeliveries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	input := service.WebhookDeliveriesInput{
+		Status: query.Get("status"),
+	}
+
+	switch input.Status {
+	case "", models.DeliveryStatusPending, models.DeliveryStatusDelivered, models.DeliveryStatusDead:
+	default:
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid status")
+		return
+	}
+
+	if value := query.Get("subscription_id"); value != "" {
+		subscriptionID, err := strconv.Atoi(value)
+		if err != nil || subscriptionID <= 0 {
+			newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid subscription_id")
+			return
+		}
+		input.SubscriptionID = subscriptionID
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || limit == 0 || limit > service.MaxWebhookDeliveriesLimit {
+			newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid limit")
+			return
+		}
+		input.Limit = limit
+	}
+
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || cursor <= 0 {
+			newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid cursor")
+			return
+		}
+		input.Cursor = cursor
+	}
+
+	deliveries, err := wr.webhookService.GetDeliveries(r.Context(), input)
+	if err != nil {
+		newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to get webhook deliveries")
+		wr.logger.Error("failed to get webhook deliveries", map[string]any{
+			"subscription_id": input.SubscriptionID,
+			"error":           err,
+		})
+		return
+	}
+
+	newSuccessResponse(w, http.StatusOK, deliveries)
+}
+
+type retryWebhookDeliveryRequest struct {
+	DeliveryID int64 `json:"delivery_id" validate:"required"`
+}
+
+// @Summary Повторить доставку webhook'а
+// @Description Возвращает доставку в статусе DEAD в очередь с новым набором попыток. Доставки в других статусах не меняются (requeued=false)
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param request body retryWebhookDeliveryRequest true "Delivery payload"
+// @Success 200 {object} service.WebhookRetryOutput
+// @Failure 400 {object} ErrorResponse "Неверное тело запроса"
+// @Failure 401 {object} ErrorResponse "Ошибка авторизации"
+// @Failure 404 {object} ErrorResponse "Доставка не найдена"
+// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /webhooks/deliveries/retry [post]
+func (wr *webhookRoutes) retryDelivery(w http.ResponseWriter, r *http.Request) {
+	var req retryWebhookDeliveryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		newErrorResponse(w, http.StatusBadRequest, CodeBadRequest, "invalid request body")
+		return
+	}
+
+	output, err := wr.webhookService.RetryDelivery(r.Context(), req.DeliveryID)
+	if err != nil {
+		switch err {
+		case repoerrs.ErrNotFound:
+			newErrorResponse(w, http.StatusNotFound, CodeNotFound, err.Error())
+			return
+		default:
+			newErrorResponse(w, http.StatusInternalServerError, CodeInternalServerError, "failed to retry webhook delivery")
+			wr.logger.Error("failed to retry webhook delivery", map[string]any{
+				"delivery_id": req.DeliveryID,
+				"error":       err,
+			})
+			return
+		}
+	}
+
+	newSuccessResponse(w, http.StatusOK, output)
+}
@@ -5,6 +5,9 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
+// durationBuckets cover review durations from a minute to about half a year.
+var durationBuckets = prometheus.ExponentialBuckets(60, 4, 10)
+
 var (
 	// PR metrics
 	PRCreated = promauto.NewCounter(
@@ -19,6 +22,29 @@ var (
 			Help: "Total number of merged PR's",
 		},
 	)
+	PRClosed = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "pr_closed_total",
+			Help: "Total number of PR's closed without merge",
+		},
+	)
+	// labeled with TeamLabels
+	PRTimeToMerge = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "pr_time_to_merge_seconds",
+			Help:    "Time from PR creation to merge by tenant and reviewers team",
+			Buckets: durationBuckets,
+		},
+		[]string{"tenant", "team"},
+	)
+	PRTimeToFirstReassign = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "pr_time_to_first_reassign_seconds",
+			Help:    "Time from PR creation to the first reviewer reassignment by tenant and reviewers team",
+			Buckets: durationBuckets,
+		},
+		[]string{"tenant", "team"},
+	)
 
 	// User metrics
 	UsersCreated = promauto.NewCounter(
@@ -42,6 +68,69 @@ var (
 			Help: "Total numbеr of created teams",
 		},
 	)
+	TeamStatusChanges = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "team_status_changes_total",
+			Help: "Team archivation and deletion operations",
+		},
+		[]string{"operation"},
+	)
+
+	// Webhook metrics
+	WebhookDeliveries = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "webhook_delivery_attempts_total",
+			Help: "Webhook delivery attempts by result: delivered, failed (will be retried) or dead",
+		},
+		[]string{"result"},
+	)
+
+	VCSWebhooks = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "vcs_webhooks_total",
+			Help: "Received version control system webhooks by provider and processing result",
+		},
+		[]string{"provider", "result"},
+	)
+
+	ReviewerSyncs = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "reviewer_sync_attempts_total",
+			Help: "Attempts to request assigned reviewers in GitHub by result: synced, failed (will be retried) or dead",
+		},
+		[]string{"result"},
+	)
+
+	ReconciliationCorrections = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "reconciliation_corrections_total",
+			Help: "Corrections of drift between pull requests and their providers by provider, action and result: applied or failed",
+		},
+		[]string{"provider", "action", "result"},
+	)
+
+	Notifications = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notification_attempts_total",
+			Help: "Notification attempts by channel and result: sent, skipped (nothing to send), failed (will be retried) or dead",
+		},
+		[]string{"channel", "result"},
+	)
+
+	ReviewSLABreaches = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "review_sla_breaches_total",
+			Help: "Assignments waiting for a review longer than the SLA of the team",
+		},
+	)
+
+	PRRetained = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "pr_retained_total",
+			Help: "Merged pull requests moved out of the live tables by the retention job by mode: archive or delete",
+		},
+		[]string{"mode"},
+	)
 
 	// Other metrics
 	BusinessErrors = promauto.NewCounterVec(
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"strconv"
+	"sync"
+)
+
+// OtherTeam labels pull requests of archived teams, of teams over
+// maxTeamsPerTenant and without a team.
+const OtherTeam = "other"
+
+// maxTeamsPerTenant bounds the team series of PR histograms of a tenant.
+const maxTeamsPerTenant = 100
+
+// labeledTeams are the teams of each tenant PR histograms have series of.
+var labeledTeams = struct {
+	sync.Mutex
+	byTenant map[int]map[string]bool
+}{byTenant: map[int]map[string]bool{}}
+
+// TeamLabels returns the tenant and team labels of PR histograms for a pull
+// request of the team.
+func TeamLabels(tenantID int, team string, archived bool) (string, string) {
+	tenantLabel := strconv.Itoa(tenantID)
+	if team == "" || archived {
+		return tenantLabel, OtherTeam
+	}
+
+	labeledTeams.Lock()
+	defer labeledTeams.Unlock()
+
+	teams := labeledTeams.byTenant[tenantID]
+	if teams == nil {
+		teams = map[string]bool{}
+		labeledTeams.byTenant[tenantID] = teams
+	}
+	if !teams[team] {
+		if len(teams) >= maxTeamsPerTenant {
+			return tenantLabel, OtherTeam
+		}
+		teams[team] = true
+	}
+
+	return tenantLabel, team
+}
+
+// ForgetTeam drops the PR histogram series of the archived, deleted or
+// renamed team, freeing its place among the teams of the tenant.
+func ForgetTeam(tenantID int, team string) {
+	labeledTeams.Lock()
+	defer labeledTeams.Unlock()
+
+	if !labeledTeams.byTenant[tenantID][team] {
+		return
+	}
+	delete(labeledTeams.byTenant[tenantID], team)
+
+	tenantLabel := strconv.Itoa(tenantID)
+	PRTimeToMerge.DeleteLabelValues(tenantLabel, team)
+	PRTimeToFirstReassign.DeleteLabelValues(tenantLabel, team)
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestTeamLabels(t *testing.T) {
+	const tenantID = 7
+
+	for i := range maxTeamsPerTenant - 1 {
+		TeamLabels(tenantID, "team-"+strconv.Itoa(i), false)
+	}
+
+	tests := []struct {
+		name     string
+		team     string
+		archived bool
+		want     string
+	}{
+		{name: "no team", team: "", want: OtherTeam},
+		{name: "archived", team: "backend", archived: true, want: OtherTeam},
+		{name: "last place", team: "backend", want: "backend"},
+		{name: "labeled", team: "backend", want: "backend"},
+		{name: "over the bound", team: "frontend", want: OtherTeam},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tenantLabel, teamLabel := TeamLabels(tenantID, tt.team, tt.archived)
+			if tenantLabel != "7" || teamLabel != tt.want {
+				t.Errorf("labels = %q, %q, want %q, %q", tenantLabel, teamLabel, "7", tt.want)
+			}
+		})
+	}
+
+	t.Run("forgotten team frees its place", func(t *testing.T) {
+		ForgetTeam(tenantID, "backend")
+
+		if _, teamLabel := TeamLabels(tenantID, "frontend", false); teamLabel != "frontend" {
+			t.Errorf("team label = %q, want %q", teamLabel, "frontend")
+		}
+	})
+
+	t.Run("tenants are bounded apart", func(t *testing.T) {
+		if _, teamLabel := TeamLabels(tenantID+1, "backend", false); teamLabel != "backend" {
+			t.Errorf("team label = %q, want %q", teamLabel, "backend")
+		}
+	})
+}
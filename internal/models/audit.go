@@ -0,0 +1,88 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditEntityUser                = "user"
+	AuditEntityTeam                = "team"
+	AuditEntityPullRequest         = "pull_request"
+	AuditEntityRepository          = "repository"
+	AuditEntityTenant              = "tenant"
+	AuditEntityAPIKey              = "api_key"
+	AuditEntityWebhookSubscription = "webhook_subscription"
+	AuditEntityVCS                 = "vcs"
+	AuditEntityChatOps             = "chatops"
+)
+
+const (
+	AuditActionUserSetIsActive    = "user.set_is_active"
+	AuditActionUserSetPrimaryTeam = "user.set_primary_team"
+	AuditActionUserSetEmail       = "user.set_email"
+
+	AuditActionTeamCreate        = "team.create"
+	AuditActionTeamSetIsActive   = "team.set_is_active"
+	AuditActionTeamUpsert        = "team.upsert"
+	AuditActionTeamImport        = "team.import"
+	AuditActionTeamRename        = "team.rename"
+	AuditActionTeamSetIsArchived = "team.set_is_archived"
+	AuditActionTeamDelete        = "team.delete"
+	AuditActionTeamSetParent     = "team.set_parent"
+	AuditActionTeamSetSettings   = "team.set_settings"
+	AuditActionTeamSetReviewSLA  = "team.set_review_sla"
+
+	AuditActionTenantCreate          = "tenant.create"
+	AuditActionTenantRestoreSnapshot = "tenant.restore_snapshot"
+	AuditActionAPIKeyCreate          = "api_key.create"
+	AuditActionAPIKeyRevoke          = "api_key.revoke"
+
+	AuditActionWebhookCreate      = "webhook_subscription.create"
+	AuditActionWebhookSetIsActive = "webhook_subscription.set_is_active"
+	AuditActionWebhookDelete      = "webhook_subscription.delete"
+
+	AuditActionVCSSetWebhookSecret     = "vcs.set_webhook_secret"
+	AuditActionVCSSetAPIToken          = "vcs.set_api_token"
+	AuditActionVCSSetIdentity          = "vcs.set_identity"
+	AuditActionVCSDeleteIdentity       = "vcs.delete_identity"
+	AuditActionChatOpsSetSigningSecret = "chatops.set_signing_secret"
+	AuditActionChatOpsSetIdentity      = "chatops.set_identity"
+	AuditActionChatOpsDeleteIdentity   = "chatops.delete_identity"
+
+	AuditActionRepositoryCreate      = "repository.create"
+	AuditActionRepositorySetOwner    = "repository.set_owner"
+	AuditActionRepositorySetSettings = "repository.set_settings"
+
+	AuditActionPullRequestCreate  = "pull_request.create"
+	AuditActionPullRequestMerge   = "pull_request.merge"
+	AuditActionPullRequestClose   = "pull_request.close"
+	AuditActionPullRequestReopen  = "pull_request.reopen"
+	AuditActionReviewerReassign   = "pull_request.reassign"
+	AuditActionPullRequestArchive = "pull_request.archive"
+	AuditActionPullRequestDelete  = "pull_request.delete"
+)
+
+type AuditEntry struct {
+	ID         int64           `db:"id"`
+	Actor      string          `db:"actor"` // api key identity, see audit.Actor
+	Action     string          `db:"action"`
+	EntityType string          `db:"entity_type"`
+	EntityID   string          `db:"entity_id"`
+	Before     json.RawMessage `db:"before"` // nullable
+	After      json.RawMessage `db:"after"`  // nullable
+	RequestID  string          `db:"request_id"`
+	CreatedAt  time.Time       `db:"created_at"`
+}
+
+type AuditFilter struct {
+	Actor      string
+	Action     string
+	EntityType string
+	EntityID   string
+	RequestID  string
+	From       *time.Time // nullable, inclusive
+	To         *time.Time // nullable, exclusive
+	Cursor     int64      // only entries older than the one with this id, 0 for the newest
+	Limit      uint64
+}
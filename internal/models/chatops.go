@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// SlackIdentity maps a Slack user to the user slash commands of the Slack
+// user are run on behalf of.
+type SlackIdentity struct {
+	SlackUserID string    `db:"slack_user_id"`
+	UserID      string    `db:"user_id"`
+	IsAdmin     bool      `db:"is_admin"` // may merge pull requests of others
+	CreatedAt   time.Time `db:"created_at"`
+}
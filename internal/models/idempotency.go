@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// IdempotencyKey is a request made with an Idempotency-Key and, once it is
+// completed, its response.
+type IdempotencyKey struct {
+	CallerHash      string              `db:"caller_hash"`
+	Key             string              `db:"idempotency_key"`
+	RequestHash     string              `db:"request_hash"`
+	StatusCode      *int                `db:"status_code"`      // nil while the request is in progress
+	ResponseHeaders map[string][]string `db:"response_headers"` // replayed ones only
+	ResponseBody    []byte              `db:"response_body"`
+	CreatedAt       time.Time           `db:"created_at"`
+	ExpiresAt       time.Time           `db:"expires_at"`
+	LeaseToken      string              `db:"lease_token"` // of the claiming request, while it is in progress
+}
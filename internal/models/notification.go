@@ -0,0 +1,94 @@
+package models
+
+import "time"
+
+const (
+	NotificationChannelChat     = "chat"
+	NotificationChannelEmail    = "email"
+	NotificationChannelTelegram = "telegram"
+)
+
+// NotificationReviewDigest is the kind of daily digests of open reviews, they
+// have no event.
+const NotificationReviewDigest = "review_digest"
+
+const (
+	NotificationStatusPending = "PENDING"
+	NotificationStatusSent    = "SENT"
+	NotificationStatusDead    = "DEAD"    // out of attempts, retried only manually
+	NotificationStatusSkipped = "SKIPPED" // nothing to send, e.g. reviews of a digest were finished
+)
+
+// ChatChannel is a Slack/Mattermost compatible incoming webhook of a team.
+type ChatChannel struct {
+	ID         int               `db:"id"`
+	TeamName   string            `db:"team_name"`
+	URL        string            `db:"url"`
+	EventTypes []string          `db:"event_types"` // empty means all notified events
+	Templates  map[string]string `db:"templates"`   // event type to text/template of the message
+	IsActive   bool              `db:"is_active"`
+	CreatedAt  time.Time         `db:"created_at"`
+}
+
+// Notification is an event sent to a user or a team over a channel.
+type Notification struct {
+	ID            int64      `db:"id"`
+	TenantID      int        `db:"tenant_id"`
+	EventID       *int64     `db:"event_id"` // nil for digests
+	Channel       string     `db:"channel"`
+	ChatChannelID *int       `db:"chat_channel_id"` // only for chat notifications
+	RecipientID   string     `db:"recipient_id"`    // empty for the whole team
+	DigestDate    *time.Time `db:"digest_date"`     // only for digests
+	Status        string     `db:"status"`
+	Attempts      int        `db:"attempts"`
+	NextAttemptAt time.Time  `db:"next_attempt_at"`
+	LastError     string     `db:"last_error"`
+	CreatedAt     time.Time  `db:"created_at"`
+	SentAt        *time.Time `db:"sent_at"` // nullable
+
+	// filled for notifications claimed to send
+	Event         OutboxEvent       `db:"-"` // empty for digests
+	RecipientName string            `db:"-"`
+	Email         string            `db:"-"` // of the recipient
+	TelegramChat  int64             `db:"-"` // of the recipient, zero when not linked
+	URL           string            `db:"-"` // of the chat channel
+	Templates     map[string]string `db:"-"` // of the chat channel
+	Timezone      string            `db:"-"` // of the recipient
+	QuietHours    *QuietHours       `db:"-"` // of the recipient
+	LeaseToken    string            `db:"-"` // of the claim, required to finish the notification
+	Wanted        bool              `db:"-"` // by the current preferences of the recipient
+}
+
+// NotificationPayload is the data of the notified events, fields of other
+// events are empty.
+type NotificationPayload struct {
+	PullRequestEvent
+	OldReviewerID    string    `json:"old_reviewer_id"`
+	NewReviewerID    string    `json:"new_reviewer_id"`
+	ReviewerID       string    `json:"reviewer_id"`
+	AssignedAt       time.Time `json:"assigned_at"`
+	ReviewSLASeconds int64     `json:"review_sla_seconds"`
+}
+
+type NotificationFilter struct {
+	Channel string // empty for any channel
+	Status  string // empty for any status
+	Cursor  int64  // only notifications older than the one with this id
+	Limit   uint64
+}
+
+// QuietHours is the daily time notifications of the user are held during.
+type QuietHours struct {
+	Start string // "15:04"
+	End   string // "15:04", before Start when quiet hours span midnight
+}
+
+// NotificationPreferences are what notifications the user gets and when.
+type NotificationPreferences struct {
+	UserID     string      `db:"user_id"`
+	Channels   []string    `db:"channels"`    // empty means all channels
+	EventTypes []string    `db:"event_types"` // empty means all notified events
+	Timezone   string      `db:"timezone"`    // IANA name quiet hours are in
+	QuietHours *QuietHours `db:"-"`           // nil when notifications are never held
+	UpdatedAt  *time.Time  `db:"updated_at"`  // nil for users without preferences
+}
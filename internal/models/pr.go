@@ -2,15 +2,71 @@ package models
 
 import "time"
 
+const (
+	PullRequestStatusOpen   = "OPEN"
+	PullRequestStatusMerged = "MERGED"
+	PullRequestStatusClosed = "CLOSED" // closed without merge, may be reopened
+)
+
+// DefaultReviewersCount is used when neither the repository nor the team set
+// their own reviewers count.
+const DefaultReviewersCount = 2
+
 type PullRequest struct {
 	ID                 int        `db:"id"`
 	PullRequestID      string     `db:"pull_request_id"`
 	PullRequestName    string     `db:"pull_request_name"`
 	AuthorID           string     `db:"author_id"`
+	TeamName           string     `db:"team_name"`       // team reviewers are assigned from
+	RepositoryName     string     `db:"repository_name"` // optional
+	VCSProvider        string     `db:"vcs_provider"`    // empty for pull requests created through the API
 	Status             string     `db:"status"`
 	NeedsMoreReviewers bool       `db:"needs_more_reviewers"`
 	CreatedAt          time.Time  `db:"created_at"`
 	MergedAt           *time.Time `db:"merged_at"` // nullable
+	Version            int        `db:"version"`
+
+	AssignedReviewers []string      `db:"-"` // reviewers uids
+	TeamIsArchived    bool          `db:"-"` // pull requests of archived teams are not labeled by team in metrics
+	ReviewerSync      *ReviewerSync `db:"-"` // nil when reviewers are not requested in the provider
+}
+
+const (
+	ReviewerSyncPending = "PENDING"
+	ReviewerSyncSynced  = "SYNCED"
+	ReviewerSyncFailed  = "FAILED" // out of attempts or rejected, retried only manually
+)
+
+// ReviewerSync is the state of requesting assigned reviewers of the pull
+// request in its version control system.
+type ReviewerSync struct {
+	ID            int        `db:"id"` // of the pull request
+	TenantID      int        `db:"tenant_id"`
+	PullRequestID string     `db:"pull_request_id"`
+	Provider      string     `db:"vcs_provider"`
+	Status        string     `db:"reviewer_sync_status"`
+	Version       int        `db:"reviewer_sync_version"`
+	Attempts      int        `db:"reviewer_sync_attempts"`
+	NextAttemptAt *time.Time `db:"reviewer_sync_next_attempt_at"` // nullable
+	LastError     string     `db:"reviewer_sync_error"`
+	SyncedAt      *time.Time `db:"reviewer_synced_at"` // nullable
+
+	// filled for syncs claimed to run, reviewers are logins in the provider
+	APIToken         string   `db:"-"`
+	RequestReviewers []string `db:"-"`
+	RemovedReviewers []string `db:"-"` // reassigned away
+}
 
-	AssignedReviewers []string `db:"-"` // reviewers uids
+// Assignment is a single reviewer assignment of a pull request, kept after the
+// reviewer is reassigned away.
+type Assignment struct {
+	PullRequestID  string     `db:"pull_request_id"`
+	ReviewerID     string     `db:"reviewer_id"`
+	TeamName       string     `db:"team_name"`
+	RepositoryName string     `db:"repository_name"`
+	Status         string     `db:"status"` // pull request status
+	AssignedAt     time.Time  `db:"assigned_at"`
+	ReassignedAt   *time.Time `db:"reassigned_at"` // nullable
+	ReplacedBy     string     `db:"replaced_by"`   // reviewer assigned instead
+	MergedAt       *time.Time `db:"merged_at"`     // nullable
 }
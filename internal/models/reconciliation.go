@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// Corrections of drift between a pull request and its provider.
+const (
+	ReconciliationMerge    = "merge"
+	ReconciliationClose    = "close"
+	ReconciliationReassign = "reassign" // the reviewer was removed in the provider
+)
+
+// ReconciledPullRequest is an open pull request checked against its provider.
+type ReconciledPullRequest struct {
+	ID            int    `db:"id"`
+	TenantID      int    `db:"tenant_id"`
+	PullRequestID string `db:"pull_request_id"`
+	Provider      string `db:"vcs_provider"`
+	APIToken      string `db:"api_token"`
+
+	// current reviewers and their logins in the provider, in the same order,
+	// empty for reviewers without a login there
+	ReviewerIDs    []string `db:"-"`
+	ReviewerLogins []string `db:"-"`
+	// reviewers were requested in the provider, so the ones missing there
+	// were removed by hand
+	ReviewersSynced bool `db:"-"`
+}
+
+type ReconciliationCorrection struct {
+	ID            int64     `db:"id"`
+	TenantID      int       `db:"tenant_id"`
+	PullRequestID string    `db:"pull_request_id"`
+	Provider      string    `db:"provider"`
+	Action        string    `db:"action"`
+	ReviewerID    string    `db:"reviewer_id"` // only for reassign
+	ReplacedBy    string    `db:"replaced_by"`
+	Error         string    `db:"error"` // empty when the correction was applied
+	CreatedAt     time.Time `db:"created_at"`
+
+	// a failed correction is recorded once for the pull request and drift,
+	// repeated failures count attempts until it is applied and resolves it
+	Attempts      int        `db:"attempts"`
+	LastAttemptAt *time.Time `db:"last_attempt_at"` // nullable, set after the first attempt
+	ResolvedAt    *time.Time `db:"resolved_at"`     // nullable
+}
+
+type ReconciliationCorrectionFilter struct {
+	PullRequestID string
+	Action        string
+	From          *time.Time // nullable, inclusive
+	To            *time.Time // nullable, exclusive
+	Cursor        int64      // only corrections older than the one with this id, 0 for the newest
+	Limit         uint64
+}
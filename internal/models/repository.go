@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+type Repository struct {
+	ID             int       `db:"id"`
+	RepositoryName string    `db:"repository_name"`
+	TeamName       string    `db:"team_name"`       // owner team, reviewers of outside authors come from it
+	ReviewersCount *int      `db:"reviewers_count"` // nullable, overrides team setting
+	FallbackDepth  *int      `db:"fallback_depth"`  // nullable, overrides team setting
+	CreatedAt      time.Time `db:"created_at"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "encoding/json"
+
+// NullInt is a nullable setting in a partial update: Set reports whether the
+// field was sent at all, Value is nil when it was sent as null.
+type NullInt struct {
+	Set   bool
+	Value *int
+}
+
+func (n *NullInt) UnmarshalJSON(data []byte) error {
+	n.Set = true
+	return json.Unmarshal(data, &n.Value)
+}
+
+// Below reports whether the sent value is less than min, null is never below.
+func (n NullInt) Below(min int) bool {
+	return n.Value != nil && *n.Value < min
+}
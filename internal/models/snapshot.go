@@ -0,0 +1,108 @@
+package models
+
+import "time"
+
+// Snapshot is the data of a tenant, exported to be restored into an empty
+// database. Secrets (webhook subscriptions, chat channels, whose webhook urls
+// carry their credentials, version control integrations, slash command
+// signing secrets) and telegram links are not part of it.
+//
+// The snapshot is stored as JSON, so fields are renamed only together with
+// SnapshotFormatVersion and an upgrade of older snapshots.
+type Snapshot struct {
+	Teams                   []SnapshotTeam                   `json:"teams"`
+	Users                   []SnapshotUser                   `json:"users"`
+	TeamMembers             []SnapshotTeamMember             `json:"team_members"`
+	Repositories            []SnapshotRepository             `json:"repositories"`
+	PullRequests            []SnapshotPullRequest            `json:"pull_requests"`
+	Reviewers               []SnapshotReviewer               `json:"reviewers"` // in the order of assignment
+	NotificationPreferences []SnapshotNotificationPreference `json:"notification_preferences"`
+	VCSIdentities           []SnapshotVCSIdentity            `json:"vcs_identities"`
+	SlackIdentities         []SnapshotSlackIdentity          `json:"slack_identities"`
+	ArchivedPullRequests    []SnapshotArchivedPullRequest    `json:"archived_pull_requests"` // moved out by the retention job
+	ArchivedReviewers       []SnapshotReviewer               `json:"archived_reviewers"`
+}
+
+// SnapshotFormatVersion is the version of snapshots written by the service.
+const SnapshotFormatVersion = 5
+
+type SnapshotTeam struct {
+	TeamName         string     `json:"team_name"`
+	ParentTeamName   string     `json:"parent_team_name,omitempty"`
+	ReviewersCount   *int       `json:"reviewers_count"`
+	FallbackDepth    *int       `json:"fallback_depth"`
+	ReviewSLASeconds *int64     `json:"review_sla_seconds"`
+	IsArchived       bool       `json:"is_archived"`
+	ArchivedAt       *time.Time `json:"archived_at"`
+}
+
+type SnapshotUser struct {
+	UserID    string     `json:"user_id"`
+	Username  string     `json:"username"`
+	IsActive  bool       `json:"is_active"`
+	Email     string     `json:"email,omitempty"`
+	AwayUntil *time.Time `json:"away_until"`
+}
+
+type SnapshotTeamMember struct {
+	UserID    string `json:"user_id"`
+	TeamName  string `json:"team_name"`
+	IsPrimary bool   `json:"is_primary"`
+}
+
+type SnapshotRepository struct {
+	RepositoryName string    `json:"repository_name"`
+	TeamName       string    `json:"team_name,omitempty"`
+	ReviewersCount *int      `json:"reviewers_count"`
+	FallbackDepth  *int      `json:"fallback_depth"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type SnapshotPullRequest struct {
+	PullRequestID      string     `json:"pull_request_id"`
+	PullRequestName    string     `json:"pull_request_name"`
+	AuthorID           string     `json:"author_id"`
+	TeamName           string     `json:"team_name,omitempty"`
+	RepositoryName     string     `json:"repository_name,omitempty"`
+	VCSProvider        string     `json:"vcs_provider,omitempty"`
+	Status             string     `json:"status"`
+	NeedsMoreReviewers bool       `json:"needs_more_reviewers"`
+	CreatedAt          time.Time  `json:"created_at"`
+	MergedAt           *time.Time `json:"merged_at"`
+}
+
+type SnapshotArchivedPullRequest struct {
+	SnapshotPullRequest
+	ArchivedAt time.Time `json:"archived_at"`
+}
+
+type SnapshotReviewer struct {
+	PullRequestID string     `json:"pull_request_id"`
+	ReviewerID    string     `json:"reviewer_id"`
+	AssignedAt    time.Time  `json:"assigned_at"`
+	ReassignedAt  *time.Time `json:"reassigned_at"`
+	ReplacedBy    string     `json:"replaced_by,omitempty"`
+	SLABreachedAt *time.Time `json:"sla_breached_at"`
+}
+
+type SnapshotNotificationPreference struct {
+	UserID          string   `json:"user_id"`
+	Channels        []string `json:"channels"`
+	EventTypes      []string `json:"event_types"`
+	Timezone        string   `json:"timezone"`
+	QuietHoursStart string   `json:"quiet_hours_start,omitempty"` // "15:04"
+	QuietHoursEnd   string   `json:"quiet_hours_end,omitempty"`
+}
+
+type SnapshotVCSIdentity struct {
+	Provider  string `json:"provider"`
+	Login     string `json:"login"`
+	AccountID string `json:"account_id,omitempty"`
+	UserID    string `json:"user_id"`
+}
+
+type SnapshotSlackIdentity struct {
+	SlackUserID string `json:"slack_user_id"`
+	UserID      string `json:"user_id"`
+	IsAdmin     bool   `json:"is_admin"`
+}
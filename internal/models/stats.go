@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+type StatsFilter struct {
+	UserID          string
+	TeamName        string
+	IncludeSubteams bool       // team stats include all subteams
+	From            *time.Time // nullable, inclusive
+	To              *time.Time // nullable, exclusive
+}
+
+type ReviewStats struct {
+	UserID   string `db:"user_id"`
+	Username string `db:"username"`
+	TeamName string `db:"team_name"`
+
+	Assigned       int64 `db:"assigned"`        // assignments made in the period
+	ReassignedAway int64 `db:"reassigned_away"` // assignments taken away in the period
+	Completed      int64 `db:"completed"`       // reviews of PRs merged in the period
+	OpenLoad       int64 `db:"open_load"`       // current reviews of open PRs, not bound to the period
+}
+
+type FlowStats struct {
+	UserID   string `db:"user_id"`
+	TeamName string `db:"team_name"`
+
+	Merged      int64       `db:"merged"` // PRs merged in the period
+	TimeToMerge Percentiles `db:"-"`
+
+	Reassigned          int64       `db:"reassigned"` // first reassignments made in the period
+	TimeToFirstReassign Percentiles `db:"-"`
+}
+
+// Percentiles of a duration in seconds, nil when there is no data.
+type Percentiles struct {
+	P50 *float64
+	P90 *float64
+	P99 *float64
+}
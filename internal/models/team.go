@@ -1,8 +1,85 @@
 package models
 
+import "time"
+
 type Team struct {
-	ID       int    `db:"id"`
-	TeamName string `db:"team_name"`
+	ID             int            `db:"id"`
+	TeamName       string         `db:"team_name"`
+	ParentID       *int           `db:"parent_id"`       // nullable
+	FallbackDepth  *int           `db:"fallback_depth"`  // nullable, inherited from ancestors
+	ReviewersCount *int           `db:"reviewers_count"` // nullable, DefaultReviewersCount when not set
+	ReviewSLA      *time.Duration `db:"review_sla"`      // nullable, reviews are not timed when not set
+	IsArchived     bool           `db:"is_archived"`
+	ArchivedAt     *time.Time     `db:"archived_at"` // nullable
+	Version        int            `db:"version"`
+
+	ParentName string `db:"-"`
+	Members    []User `db:"-"`
+	Subteams   []Team `db:"-"`
+}
+
+type TeamDiff struct {
+	TeamCreated bool
+
+	Added           []TeamMemberChange
+	Removed         []TeamMemberChange
+	UsernameChanged []TeamMemberChange
+	ActivityChanged []TeamMemberChange
+}
+
+type TeamMemberChange struct {
+	UserID string
+
+	PrimaryTeamName string
+	PrevUsername    string
+	PrevIsActive    bool
+
+	Username string
+	IsActive bool
+}
+
+func (d *TeamDiff) IsEmpty() bool {
+	return !d.TeamCreated &&
+		len(d.Added) == 0 &&
+		len(d.Removed) == 0 &&
+		len(d.UsernameChanged) == 0 &&
+		len(d.ActivityChanged) == 0
+}
+
+// Team attributes an import can change, named as in the import file.
+const (
+	TeamAttributeParent         = "parent_team_name"
+	TeamAttributeReviewersCount = "reviewers_count"
+	TeamAttributeFallbackDepth  = "fallback_depth"
+	TeamAttributeReviewSLA      = "review_sla"
+	TeamAttributeIsArchived     = "is_archived"
+)
+
+// TeamImport is the state of a team described by an import file, nil fields
+// keep the current values of the team.
+type TeamImport struct {
+	TeamName       string
+	ParentName     *string // empty moves the team to the root
+	ReviewersCount *int
+	FallbackDepth  *int
+	ReviewSLA      *time.Duration
+	IsArchived     *bool
+	Members        []TeamImportMember // nil keeps the current members
+}
+
+type TeamImportMember struct {
+	UserID   string
+	Username string
+	IsActive *bool // nil keeps the activity of an existing user, new users are active
+}
+
+type TeamImportDiff struct {
+	TeamName string
+	TeamDiff
+
+	UpdatedAttributes []string // TeamAttribute* constants
+}
 
-	Members []User `db:"-"`
+func (d *TeamImportDiff) IsEmpty() bool {
+	return d.TeamDiff.IsEmpty() && len(d.UpdatedAttributes) == 0
 }
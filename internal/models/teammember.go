@@ -0,0 +1,7 @@
+package models
+
+type TeamMember struct {
+	UserID    string `db:"user_id"`
+	TeamName  string `db:"team_name"`
+	IsPrimary bool   `db:"is_primary"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// TelegramLink is the telegram chat of the user with the bot.
+type TelegramLink struct {
+	TenantID  int       `db:"tenant_id"`
+	UserID    string    `db:"user_id"`
+	ChatID    int64     `db:"chat_id"`
+	CreatedAt time.Time `db:"created_at"`
+}
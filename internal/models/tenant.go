@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+type Tenant struct {
+	ID         int       `db:"id"`
+	TenantName string    `db:"tenant_name"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+type APIKey struct {
+	ID        int        `db:"id"`
+	TenantID  int        `db:"tenant_id"`
+	KeyHash   string     `db:"key_hash"`
+	Role      string     `db:"role"`
+	CreatedAt time.Time  `db:"created_at"`
+	RevokedAt *time.Time `db:"revoked_at"` // nullable
+}
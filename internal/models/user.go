@@ -1,11 +1,16 @@
 package models
 
+import "time"
+
 type User struct {
-	ID       int    `db:"id"`
-	UserID   string `db:"user_id"`
-	Username string `db:"username"`
-	TeamName string `db:"team_name"`
-	IsActive bool   `db:"is_active"`
+	ID        int        `db:"id"`
+	UserID    string     `db:"user_id"`
+	Username  string     `db:"username"`
+	TeamName  string     `db:"team_name"` // primary team
+	IsActive  bool       `db:"is_active"`
+	Email     string     `db:"email"`      // empty when not set
+	AwayUntil *time.Time `db:"away_until"` // nullable, the day the user is activated again
+	Version   int        `db:"version"`
 
 	AssignedPRs []PullRequest `db:"-"`
 }
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+const (
+	VCSProviderGitHub = "github"
+	VCSProviderGitLab = "gitlab"
+)
+
+// VCSIdentity maps a login in the version control system, and optionally the
+// numeric id of the account, to a user.
+type VCSIdentity struct {
+	Provider  string    `db:"provider"`
+	Login     string    `db:"login"`
+	AccountID string    `db:"account_id"` // empty when not known
+	UserID    string    `db:"user_id"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// VCSDelivery is a received webhook of the version control system, recorded
+// to process redeliveries only once.
+type VCSDelivery struct {
+	Provider      string    `db:"provider"`
+	DeliveryID    string    `db:"delivery_id"`
+	Event         string    `db:"event"`
+	Action        string    `db:"action"`
+	PullRequestID string    `db:"pull_request_id"`
+	Result        string    `db:"result"` // empty while processed
+	ReceivedAt    time.Time `db:"received_at"`
+}
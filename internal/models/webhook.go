@@ -0,0 +1,94 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	EventPullRequestCreated  = "pull_request.created"
+	EventPullRequestMerged   = "pull_request.merged"
+	EventPullRequestClosed   = "pull_request.closed"
+	EventPullRequestReopened = "pull_request.reopened"
+	EventReviewerReassigned  = "pull_request.reviewer_reassigned"
+	EventReviewSLABreached   = "pull_request.sla_breached"
+)
+
+const (
+	DeliveryStatusPending   = "PENDING"
+	DeliveryStatusDelivered = "DELIVERED"
+	DeliveryStatusDead      = "DEAD" // out of attempts, retried only manually
+)
+
+// PullRequestEvent is the data of pull request events sent to webhooks.
+type PullRequestEvent struct {
+	PullRequestID      string     `json:"pull_request_id"`
+	PullRequestName    string     `json:"pull_request_name"`
+	AuthorID           string     `json:"author_id"`
+	TeamName           string     `json:"team_name,omitempty"`
+	RepositoryName     string     `json:"repository_name,omitempty"`
+	Status             string     `json:"status"`
+	AssignedReviewers  []string   `json:"assigned_reviewers"`
+	NeedsMoreReviewers bool       `json:"needs_more_reviewers"`
+	CreatedAt          time.Time  `json:"created_at"`
+	MergedAt           *time.Time `json:"merged_at,omitempty"`
+}
+
+type ReviewerReassignedEvent struct {
+	PullRequestEvent
+	OldReviewerID string `json:"old_reviewer_id"`
+	NewReviewerID string `json:"new_reviewer_id"`
+}
+
+// ReviewSLABreachedEvent is raised once per assignment waiting longer than
+// the review SLA of the team.
+type ReviewSLABreachedEvent struct {
+	PullRequestEvent
+	ReviewerID       string    `json:"reviewer_id"`
+	AssignedAt       time.Time `json:"assigned_at"`
+	ReviewSLASeconds int64     `json:"review_sla_seconds"`
+}
+
+type OutboxEvent struct {
+	ID        int64           `db:"id"`
+	TenantID  int             `db:"tenant_id"`
+	EventType string          `db:"event_type"`
+	Payload   json.RawMessage `db:"payload"`
+	CreatedAt time.Time       `db:"created_at"`
+}
+
+type WebhookSubscription struct {
+	ID         int       `db:"id"`
+	URL        string    `db:"url"`
+	Secret     string    `db:"secret"`      // HMAC-SHA256 key of the signatures
+	EventTypes []string  `db:"event_types"` // empty means all events
+	IsActive   bool      `db:"is_active"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+type WebhookDelivery struct {
+	ID             int64      `db:"id"`
+	EventID        int64      `db:"event_id"`
+	SubscriptionID int        `db:"subscription_id"`
+	Status         string     `db:"status"`
+	Attempts       int        `db:"attempts"`
+	NextAttemptAt  time.Time  `db:"next_attempt_at"`
+	LastStatusCode *int       `db:"last_status_code"` // nullable
+	LastError      string     `db:"last_error"`
+	CreatedAt      time.Time  `db:"created_at"`
+	DeliveredAt    *time.Time `db:"delivered_at"` // nullable
+
+	// filled for deliveries claimed to send
+	Event  OutboxEvent `db:"-"`
+	URL    string      `db:"-"` // of the subscription
+	Secret string      `db:"-"` // of the subscription
+
+	LeaseToken string `db:"-"` // of the claim, required to finish the delivery
+}
+
+type WebhookDeliveryFilter struct {
+	SubscriptionID int    // 0 for all subscriptions
+	Status         string // empty for any status
+	Cursor         int64  // only deliveries older than the one with this id
+	Limit          uint64
+}
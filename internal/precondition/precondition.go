@@ -0,0 +1,19 @@
+// Package precondition carries the version of the entity a request expects to
+// change, taken from If-Match, through request context down to the
+// repositories, which change the entity only while it has that version.
+package precondition
+
+import "context"
+
+type versionKey struct{}
+
+func WithVersion(ctx context.Context, version int) context.Context {
+	return context.WithValue(ctx, versionKey{}, version)
+}
+
+// Version returns the version the request expects, ok is false when the
+// request changes the entity whatever its version is.
+func Version(ctx context.Context) (version int, ok bool) {
+	version, ok = ctx.Value(versionKey{}).(int)
+	return version, ok
+}
@@ -0,0 +1,157 @@
+package pgdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/audit"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/tenant"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/database/postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type AuditRepo struct {
+	*postgres.Postgres
+}
+
+func NewAuditRepo(pg *postgres.Postgres) *AuditRepo {
+	return &AuditRepo{pg}
+}
+
+// GetAuditEntries returns entries matching the filter, newest first.
+func (r *AuditRepo) GetAuditEntries(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	created, createdArgs := periodCondition("created_at", filter.From, filter.To)
+
+	query := r.Builder.
+		Select("id, actor, action, entity_type, entity_id, before, after, COALESCE(request_id, ''), created_at").
+		From("audit_log").
+		Where("tenant_id = ?", tenant.ID(ctx)).
+		Where(created, createdArgs...).
+		OrderBy("id DESC").
+		Limit(filter.Limit)
+
+	for column, value := range map[string]string{
+		"actor":       filter.Actor,
+		"action":      filter.Action,
+		"entity_type": filter.EntityType,
+		"entity_id":   filter.EntityID,
+		"request_id":  filter.RequestID,
+	} {
+		if value != "" {
+			query = query.Where(column+" = ?", value)
+		}
+	}
+	if filter.Cursor > 0 {
+		query = query.Where("id < ?", filter.Cursor)
+	}
+
+	return collect(func(fn func(models.AuditEntry) error) error {
+		return streamRows(ctx, r.Postgres, query, "audit entries", func(rows pgx.Rows, entry *models.AuditEntry) error {
+			return rows.Scan(
+				&entry.ID,
+				&entry.Actor,
+				&entry.Action,
+				&entry.EntityType,
+				&entry.EntityID,
+				&entry.Before,
+				&entry.After,
+				&entry.RequestID,
+				&entry.CreatedAt,
+			)
+		}, fn)
+	})
+}
+
+// writeAudit appends the change to the audit log within the transaction of
+// the change itself, so the log and the data never diverge. Before and after
+// are stored as JSON, nil is stored as NULL.
+func writeAudit(ctx context.Context, pg *postgres.Postgres, tx pgx.Tx, action, entityType, entityID string, before, after any) error {
+	beforeJSON, err := marshalAuditState(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := marshalAuditState(after)
+	if err != nil {
+		return err
+	}
+
+	sql, args, _ := pg.Builder.
+		Insert("audit_log").
+		Columns("tenant_id, actor, action, entity_type, entity_id, before, after, request_id").
+		Values(
+			tenant.ID(ctx),
+			audit.Actor(ctx),
+			action,
+			entityType,
+			entityID,
+			beforeJSON,
+			afterJSON,
+			nullIfEmpty(audit.RequestID(ctx)),
+		).
+		ToSql()
+
+	if _, err := tx.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+
+	return nil
+}
+
+// execAudited runs a single statement and its audit entry in one transaction,
+// for changes that need no other queries.
+func execAudited(ctx context.Context, pg *postgres.Postgres, sql string, args []any, action, entityType, entityID string, before, after any) (pgconn.CommandTag, error) {
+	tx, err := pg.Pool.Begin(ctx)
+	if err != nil {
+		return pgconn.CommandTag{}, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	tag, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return pgconn.CommandTag{}, err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return tag, nil
+	}
+
+	if err := writeAudit(ctx, pg, tx, action, entityType, entityID, before, after); err != nil {
+		return pgconn.CommandTag{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return pgconn.CommandTag{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return tag, nil
+}
+
+func marshalAuditState(state any) ([]byte, error) {
+	if state == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal audit state: %w", err)
+	}
+
+	return data, nil
+}
+
+// auditPullRequest is the state of the pull request recorded in the audit log.
+func auditPullRequest(pr models.PullRequest) map[string]any {
+	return map[string]any{
+		"pull_request_name":    pr.PullRequestName,
+		"author_id":            pr.AuthorID,
+		"team_name":            pr.TeamName,
+		"repository_name":      pr.RepositoryName,
+		"status":               pr.Status,
+		"needs_more_reviewers": pr.NeedsMoreReviewers,
+		"assigned_reviewers":   pr.AssignedReviewers,
+		"merged_at":            pr.MergedAt,
+	}
+}
@@ -0,0 +1,159 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/tenant"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/database/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+type ChatOpsRepo struct {
+	*postgres.Postgres
+}
+
+func NewChatOpsRepo(pg *postgres.Postgres) *ChatOpsRepo {
+	return &ChatOpsRepo{pg}
+}
+
+func (r *ChatOpsRepo) SetChatOpsSigningSecret(ctx context.Context, secret string) error {
+	sql, args, _ := r.Builder.
+		Insert("chatops_secrets").
+		Columns("tenant_id, signing_secret").
+		Values(tenant.ID(ctx), secret).
+		Suffix("ON CONFLICT (tenant_id) DO UPDATE SET signing_secret = EXCLUDED.signing_secret, updated_at = NOW()").
+		ToSql()
+
+	// the secret itself is not written to the audit log
+	if _, err := execAudited(ctx, r.Postgres, sql, args, models.AuditActionChatOpsSetSigningSecret, models.AuditEntityChatOps, "slack",
+		nil, map[string]any{"signing_secret_set": true},
+	); err != nil {
+		return fmt.Errorf("failed to set chatops signing secret: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ChatOpsRepo) GetChatOpsSigningSecret(ctx context.Context) (string, error) {
+	sql, args, _ := r.Builder.
+		Select("signing_secret").
+		From("chatops_secrets").
+		Where("tenant_id = ?", tenant.ID(ctx)).
+		ToSql()
+
+	var secret string
+	if err := r.Pool.QueryRow(ctx, sql, args...).Scan(&secret); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", repoerrs.ErrNotFound
+		}
+		return "", fmt.Errorf("failed to get chatops signing secret: %w", err)
+	}
+
+	return secret, nil
+}
+
+// SetSlackIdentity maps the Slack user to the user, replacing the previous
+// mapping of the Slack user.
+func (r *ChatOpsRepo) SetSlackIdentity(ctx context.Context, identity models.SlackIdentity) (*models.SlackIdentity, error) {
+	tenantID := tenant.ID(ctx)
+
+	sql, args, _ := r.Builder.
+		Select("1").
+		From("users").
+		Where("tenant_id = ? AND user_id = ?", tenantID, identity.UserID).
+		ToSql()
+
+	var exists int
+	if err := r.Pool.QueryRow(ctx, sql, args...).Scan(&exists); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to check user existence: %w", err)
+	}
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	sql, args, _ = r.Builder.
+		Insert("slack_identities").
+		Columns("tenant_id, slack_user_id, user_id, is_admin").
+		Values(tenantID, identity.SlackUserID, identity.UserID, identity.IsAdmin).
+		Suffix("ON CONFLICT (tenant_id, slack_user_id) DO UPDATE SET user_id = EXCLUDED.user_id, is_admin = EXCLUDED.is_admin RETURNING created_at").
+		ToSql()
+
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&identity.CreatedAt); err != nil {
+		return nil, fmt.Errorf("failed to set slack identity: %w", err)
+	}
+
+	if err := writeAudit(ctx, r.Postgres, tx, models.AuditActionChatOpsSetIdentity, models.AuditEntityChatOps, "slack",
+		nil, map[string]any{"slack_user_id": identity.SlackUserID, "user_id": identity.UserID, "is_admin": identity.IsAdmin},
+	); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &identity, nil
+}
+
+func (r *ChatOpsRepo) DeleteSlackIdentity(ctx context.Context, slackUserID string) error {
+	sql, args, _ := r.Builder.
+		Delete("slack_identities").
+		Where("tenant_id = ? AND slack_user_id = ?", tenant.ID(ctx), slackUserID).
+		ToSql()
+
+	tag, err := execAudited(ctx, r.Postgres, sql, args, models.AuditActionChatOpsDeleteIdentity, models.AuditEntityChatOps, "slack",
+		map[string]any{"slack_user_id": slackUserID}, nil,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete slack identity: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repoerrs.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *ChatOpsRepo) GetSlackIdentities(ctx context.Context) ([]models.SlackIdentity, error) {
+	query := r.Builder.
+		Select("slack_user_id, user_id, is_admin, created_at").
+		From("slack_identities").
+		Where("tenant_id = ?", tenant.ID(ctx)).
+		OrderBy("slack_user_id")
+
+	return collect(func(fn func(models.SlackIdentity) error) error {
+		return streamRows(ctx, r.Postgres, query, "slack identities", func(rows pgx.Rows, identity *models.SlackIdentity) error {
+			return rows.Scan(&identity.SlackUserID, &identity.UserID, &identity.IsAdmin, &identity.CreatedAt)
+		}, fn)
+	})
+}
+
+// GetSlackIdentity returns the mapping of the Slack user or ErrNotFound.
+func (r *ChatOpsRepo) GetSlackIdentity(ctx context.Context, slackUserID string) (*models.SlackIdentity, error) {
+	sql, args, _ := r.Builder.
+		Select("user_id, is_admin, created_at").
+		From("slack_identities").
+		Where("tenant_id = ? AND slack_user_id = ?", tenant.ID(ctx), slackUserID).
+		ToSql()
+
+	identity := models.SlackIdentity{SlackUserID: slackUserID}
+	if err := r.Pool.QueryRow(ctx, sql, args...).Scan(&identity.UserID, &identity.IsAdmin, &identity.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get slack identity: %w", err)
+	}
+
+	return &identity, nil
+}
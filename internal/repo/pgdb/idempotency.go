@@ -0,0 +1,144 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/database/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+type IdempotencyRepo struct {
+	*postgres.Postgres
+}
+
+func NewIdempotencyRepo(pg *postgres.Postgres) *IdempotencyRepo {
+	return &IdempotencyRepo{pg}
+}
+
+// ClaimIdempotencyKey records the request in progress for lockTimeout under a
+// new lease token unless the key of the caller is recorded and not expired,
+// then the recorded request is returned instead.
+func (r *IdempotencyRepo) ClaimIdempotencyKey(ctx context.Context, key models.IdempotencyKey, lockTimeout time.Duration) (recorded *models.IdempotencyKey, claimed bool, err error) {
+	sql, args, _ := r.Builder.
+		Insert("idempotency_keys").
+		Columns("caller_hash, idempotency_key, request_hash, expires_at, lease_token").
+		Values(
+			key.CallerHash,
+			key.Key,
+			key.RequestHash,
+			squirrel.Expr("NOW() + make_interval(secs => ?)", lockTimeout.Seconds()),
+			squirrel.Expr("gen_random_uuid()"),
+		).
+		Suffix(`ON CONFLICT (caller_hash, idempotency_key) DO UPDATE SET
+			request_hash = EXCLUDED.request_hash,
+			status_code = NULL,
+			response_headers = NULL,
+			response_body = NULL,
+			created_at = NOW(),
+			expires_at = EXCLUDED.expires_at,
+			lease_token = EXCLUDED.lease_token
+		WHERE idempotency_keys.expires_at <= NOW()
+		RETURNING created_at, expires_at, lease_token::text`).
+		ToSql()
+
+	if err := r.Pool.QueryRow(ctx, sql, args...).Scan(&key.CreatedAt, &key.ExpiresAt, &key.LeaseToken); err == nil {
+		return &key, true, nil
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+
+	sql, args, _ = r.Builder.
+		Select(`caller_hash, idempotency_key, request_hash, status_code, COALESCE(response_headers, '{}'), response_body,
+			created_at, expires_at`).
+		From("idempotency_keys").
+		Where("caller_hash = ? AND idempotency_key = ?", key.CallerHash, key.Key).
+		ToSql()
+
+	var existing models.IdempotencyKey
+	if err := r.Pool.QueryRow(ctx, sql, args...).Scan(
+		&existing.CallerHash,
+		&existing.Key,
+		&existing.RequestHash,
+		&existing.StatusCode,
+		&existing.ResponseHeaders,
+		&existing.ResponseBody,
+		&existing.CreatedAt,
+		&existing.ExpiresAt,
+	); err != nil {
+		// released by a failed request in between on pgx.ErrNoRows, the
+		// caller retries on the error
+		return nil, false, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	return &existing, false, nil
+}
+
+// CompleteIdempotencyKey records the response of the request holding the
+// lease, kept for ttl. ErrLeaseLost is returned when the key was claimed
+// again after the lock timeout.
+func (r *IdempotencyRepo) CompleteIdempotencyKey(ctx context.Context, callerHash, key, leaseToken string, statusCode int, headers map[string][]string, body []byte, ttl time.Duration) error {
+	sql, args, _ := r.Builder.
+		Update("idempotency_keys").
+		Set("status_code", statusCode).
+		Set("response_headers", headers).
+		Set("response_body", body).
+		Set("expires_at", squirrel.Expr("NOW() + make_interval(secs => ?)", ttl.Seconds())).
+		Set("lease_token", nil).
+		Where("caller_hash = ? AND idempotency_key = ? AND status_code IS NULL AND lease_token = ?", callerHash, key, leaseToken).
+		ToSql()
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repoerrs.ErrLeaseLost
+	}
+
+	return nil
+}
+
+// ReleaseIdempotencyKey forgets the failed request holding the lease, so its
+// retry is processed again.
+func (r *IdempotencyRepo) ReleaseIdempotencyKey(ctx context.Context, callerHash, key, leaseToken string) error {
+	sql, args, _ := r.Builder.
+		Delete("idempotency_keys").
+		Where("caller_hash = ? AND idempotency_key = ? AND status_code IS NULL AND lease_token = ?", callerHash, key, leaseToken).
+		ToSql()
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repoerrs.ErrLeaseLost
+	}
+
+	return nil
+}
+
+// DeleteExpiredIdempotencyKeys deletes up to limit expired keys.
+func (r *IdempotencyRepo) DeleteExpiredIdempotencyKeys(ctx context.Context, limit uint64) (int64, error) {
+	sql, args, _ := r.Builder.
+		Delete("idempotency_keys").
+		Where(`(caller_hash, idempotency_key) IN (
+			SELECT caller_hash, idempotency_key FROM idempotency_keys
+			WHERE expires_at <= NOW() LIMIT ? FOR UPDATE SKIP LOCKED
+		)`, limit).
+		ToSql()
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,569 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/tenant"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/database/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+const chatChannelColumns = "id, team_name, url, event_types, templates, is_active, created_at"
+
+const notificationColumns = `n.id, n.tenant_id, n.event_id, n.channel, n.chat_channel_id, n.recipient_id, n.digest_date,
+	n.status, n.attempts, n.next_attempt_at, COALESCE(n.last_error, ''), n.created_at, n.sent_at`
+
+// notificationRecipients are the users every notified event is about:
+// reviewers assigned on creation, the reviewer assigned on reassignment and
+// the reviewer breaching the SLA. Merges are notified to the whole team.
+const notificationRecipients = `CROSS JOIN LATERAL (
+	SELECT jsonb_array_elements_text(e.payload->'assigned_reviewers') AS recipient_id WHERE e.event_type = ?
+	UNION ALL SELECT e.payload->>'new_reviewer_id' WHERE e.event_type = ?
+	UNION ALL SELECT e.payload->>'reviewer_id' WHERE e.event_type = ?
+	UNION ALL SELECT '' WHERE e.event_type = ?
+) r`
+
+// wantedBy is the condition of the preferences p of the recipient to get
+// notifications of the event type over the channel. Users without preferences
+// and whole teams get all notifications.
+func wantedBy(channel, eventType any) squirrel.Sqlizer {
+	return squirrel.Expr(`(p.user_id IS NULL OR (
+		(cardinality(p.channels) = 0 OR ? = ANY(p.channels)) AND
+		(cardinality(p.event_types) = 0 OR ? = ANY(p.event_types))))`, channel, eventType)
+}
+
+var notificationRecipientsArgs = []any{
+	models.EventPullRequestCreated,
+	models.EventReviewerReassigned,
+	models.EventReviewSLABreached,
+	models.EventPullRequestMerged,
+}
+
+type NotificationRepo struct {
+	*postgres.Postgres
+}
+
+func NewNotificationRepo(pg *postgres.Postgres) *NotificationRepo {
+	return &NotificationRepo{pg}
+}
+
+func (r *NotificationRepo) CreateChatChannel(ctx context.Context, channel models.ChatChannel) (*models.ChatChannel, error) {
+	tenantID := tenant.ID(ctx)
+
+	if channel.EventTypes == nil {
+		channel.EventTypes = []string{}
+	}
+	if channel.Templates == nil {
+		channel.Templates = map[string]string{}
+	}
+
+	sql, args, _ := r.Builder.
+		Select("1").
+		From("teams").
+		Where("tenant_id = ? AND team_name = ?", tenantID, channel.TeamName).
+		ToSql()
+
+	var exists int
+	if err := r.Pool.QueryRow(ctx, sql, args...).Scan(&exists); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrTeamNotFound
+		}
+		return nil, fmt.Errorf("failed to check team existence: %w", err)
+	}
+
+	sql, args, _ = r.Builder.
+		Insert("chat_channels").
+		Columns("tenant_id, team_name, url, event_types, templates").
+		Values(tenantID, channel.TeamName, channel.URL, channel.EventTypes, channel.Templates).
+		Suffix("ON CONFLICT (tenant_id, team_name, url) DO NOTHING RETURNING " + chatChannelColumns).
+		ToSql()
+
+	created, err := scanChatChannel(r.Pool.QueryRow(ctx, sql, args...))
+	if errors.Is(err, repoerrs.ErrNotFound) {
+		return nil, repoerrs.ErrAlreadyExists
+	}
+
+	return created, err
+}
+
+// GetChatChannels returns chat channels of the team, of all teams when
+// teamName is empty.
+func (r *NotificationRepo) GetChatChannels(ctx context.Context, teamName string) ([]models.ChatChannel, error) {
+	query := r.Builder.
+		Select(chatChannelColumns).
+		From("chat_channels").
+		Where("tenant_id = ?", tenant.ID(ctx)).
+		OrderBy("id")
+
+	if teamName != "" {
+		query = query.Where("team_name = ?", teamName)
+	}
+
+	return collect(func(fn func(models.ChatChannel) error) error {
+		return streamRows(ctx, r.Postgres, query, "chat channels", func(rows pgx.Rows, channel *models.ChatChannel) error {
+			return scanChatChannelInto(rows, channel)
+		}, fn)
+	})
+}
+
+func (r *NotificationRepo) SetChatChannelIsActive(ctx context.Context, channelID int, isActive bool) (*models.ChatChannel, error) {
+	sql, args, _ := r.Builder.
+		Update("chat_channels").
+		Set("is_active", isActive).
+		Where("tenant_id = ? AND id = ?", tenant.ID(ctx), channelID).
+		Suffix("RETURNING " + chatChannelColumns).
+		ToSql()
+
+	return scanChatChannel(r.Pool.QueryRow(ctx, sql, args...))
+}
+
+// DeleteChatChannel removes the channel together with its notifications.
+func (r *NotificationRepo) DeleteChatChannel(ctx context.Context, channelID int) error {
+	sql, args, _ := r.Builder.
+		Delete("chat_channels").
+		Where("tenant_id = ? AND id = ?", tenant.ID(ctx), channelID).
+		ToSql()
+
+	cmd, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete chat channel: %w", err)
+	}
+
+	if cmd.RowsAffected() == 0 {
+		return repoerrs.ErrNotFound
+	}
+
+	return nil
+}
+
+// GetNotifications returns notifications matching the filter, newest first.
+func (r *NotificationRepo) GetNotifications(ctx context.Context, filter models.NotificationFilter) ([]models.Notification, error) {
+	query := r.Builder.
+		Select(notificationColumns).
+		Column("COALESCE(e.event_type, ?)", models.NotificationReviewDigest).
+		From("notifications n").
+		LeftJoin("outbox_events e ON e.id = n.event_id").
+		Where("n.tenant_id = ?", tenant.ID(ctx)).
+		OrderBy("n.id DESC").
+		Limit(filter.Limit)
+
+	if filter.Channel != "" {
+		query = query.Where("n.channel = ?", filter.Channel)
+	}
+	if filter.Status != "" {
+		query = query.Where("n.status = ?", filter.Status)
+	}
+	if filter.Cursor > 0 {
+		query = query.Where("n.id < ?", filter.Cursor)
+	}
+
+	return collect(func(fn func(models.Notification) error) error {
+		return streamRows(ctx, r.Postgres, query, "notifications", func(rows pgx.Rows, notification *models.Notification) error {
+			return rows.Scan(append(notificationDest(notification), &notification.Event.EventType)...)
+		}, fn)
+	})
+}
+
+// RetryNotification puts the dead notification back to the queue with a fresh
+// attempts budget. Notifications in other states are returned as is.
+func (r *NotificationRepo) RetryNotification(ctx context.Context, notificationID int64) (notificationRes *models.Notification, requeued bool, err error) {
+	tenantID := tenant.ID(ctx)
+
+	sql, args, _ := r.Builder.
+		Update("notifications n").
+		Set("status", models.NotificationStatusPending).
+		Set("attempts", 0).
+		Set("next_attempt_at", squirrel.Expr("NOW()")).
+		Where("n.tenant_id = ? AND n.id = ? AND n.status = ?", tenantID, notificationID, models.NotificationStatusDead).
+		Suffix("RETURNING " + notificationColumns).
+		ToSql()
+
+	var notification models.Notification
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(notificationDest(&notification)...)
+	if err == nil {
+		return &notification, true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, fmt.Errorf("failed to retry notification: %w", err)
+	}
+
+	sql, args, _ = r.Builder.
+		Select(notificationColumns).
+		From("notifications n").
+		Where("n.tenant_id = ? AND n.id = ?", tenantID, notificationID).
+		ToSql()
+
+	if err := r.Pool.QueryRow(ctx, sql, args...).Scan(notificationDest(&notification)...); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, repoerrs.ErrNotFound
+		}
+		return nil, false, fmt.Errorf("failed to get notification: %w", err)
+	}
+
+	return &notification, false, nil
+}
+
+// FanOutNotifications creates notifications of up to limit unnotified events
+// over the channels: for every active chat channel of the team of the event,
+// emails of assignments to reviewers getting them immediately and telegram
+// messages of assignments to reviewers with linked chats. Events are marked
+// notified. Runs for all tenants.
+func (r *NotificationRepo) FanOutNotifications(ctx context.Context, limit uint64, channels []string) (events int64, err error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	sql, args, _ := r.Builder.
+		Update("outbox_events").
+		Set("notified_at", squirrel.Expr("NOW()")).
+		Where(`id IN (
+			SELECT id FROM outbox_events WHERE notified_at IS NULL ORDER BY id LIMIT ? FOR UPDATE SKIP LOCKED
+		)`, limit).
+		Suffix("RETURNING id").
+		ToSql()
+
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark outbox events: %w", err)
+	}
+
+	eventIDs, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return 0, fmt.Errorf("failed to collect outbox events: %w", err)
+	}
+
+	if len(eventIDs) == 0 {
+		return 0, nil
+	}
+
+	if slices.Contains(channels, models.NotificationChannelChat) {
+		sql, args, _ = r.Builder.
+			Insert("notifications").
+			Columns("tenant_id, event_id, channel, chat_channel_id, recipient_id").
+			Select(squirrel.
+				Select("e.tenant_id, e.id").
+				Column("?", models.NotificationChannelChat).
+				Column("c.id, r.recipient_id").
+				From("outbox_events e").
+				Join("chat_channels c ON c.tenant_id = e.tenant_id AND c.team_name = e.payload->>'team_name'").
+				JoinClause(notificationRecipients, notificationRecipientsArgs...).
+				LeftJoin("notification_preferences p ON p.tenant_id = e.tenant_id AND p.user_id = r.recipient_id").
+				Where(squirrel.Eq{"e.id": eventIDs}).
+				Where("c.is_active AND (cardinality(c.event_types) = 0 OR e.event_type = ANY(c.event_types))").
+				Where(wantedBy(models.NotificationChannelChat, squirrel.Expr("e.event_type"))),
+			).
+			Suffix("ON CONFLICT DO NOTHING").
+			ToSql()
+
+		if _, err := tx.Exec(ctx, sql, args...); err != nil {
+			return 0, fmt.Errorf("failed to insert chat notifications: %w", err)
+		}
+	}
+
+	if slices.Contains(channels, models.NotificationChannelEmail) {
+		sql, args, _ = r.Builder.
+			Insert("notifications").
+			Columns("tenant_id, event_id, channel, recipient_id").
+			Select(squirrel.
+				Select("e.tenant_id, e.id").
+				Column("?", models.NotificationChannelEmail).
+				Column("r.recipient_id").
+				From("outbox_events e").
+				JoinClause(notificationRecipients, notificationRecipientsArgs...).
+				Join("users u ON u.tenant_id = e.tenant_id AND u.user_id = r.recipient_id").
+				LeftJoin("notification_preferences p ON p.tenant_id = u.tenant_id AND p.user_id = u.user_id").
+				Where(squirrel.Eq{
+					"e.id":         eventIDs,
+					"e.event_type": []string{models.EventPullRequestCreated, models.EventReviewerReassigned},
+				}).
+				Where("u.email IS NOT NULL").
+				Where(wantedBy(models.NotificationChannelEmail, squirrel.Expr("e.event_type"))),
+			).
+			Suffix("ON CONFLICT DO NOTHING").
+			ToSql()
+
+		if _, err := tx.Exec(ctx, sql, args...); err != nil {
+			return 0, fmt.Errorf("failed to insert email notifications: %w", err)
+		}
+	}
+
+	if slices.Contains(channels, models.NotificationChannelTelegram) {
+		sql, args, _ = r.Builder.
+			Insert("notifications").
+			Columns("tenant_id, event_id, channel, recipient_id").
+			Select(squirrel.
+				Select("e.tenant_id, e.id").
+				Column("?", models.NotificationChannelTelegram).
+				Column("r.recipient_id").
+				From("outbox_events e").
+				JoinClause(notificationRecipients, notificationRecipientsArgs...).
+				Join("telegram_links l ON l.tenant_id = e.tenant_id AND l.user_id = r.recipient_id").
+				LeftJoin("notification_preferences p ON p.tenant_id = l.tenant_id AND p.user_id = l.user_id").
+				Where(squirrel.Eq{
+					"e.id":         eventIDs,
+					"e.event_type": []string{models.EventPullRequestCreated, models.EventReviewerReassigned},
+				}).
+				Where(wantedBy(models.NotificationChannelTelegram, squirrel.Expr("e.event_type"))),
+			).
+			Suffix("ON CONFLICT DO NOTHING").
+			ToSql()
+
+		if _, err := tx.Exec(ctx, sql, args...); err != nil {
+			return 0, fmt.Errorf("failed to insert telegram notifications: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return int64(len(eventIDs)), nil
+}
+
+// CreateEmailDigests creates the digest of the date for every active user of
+// all tenants who has open reviews and chose the digest in the notification
+// preferences. Digests are created once per user and date.
+func (r *NotificationRepo) CreateEmailDigests(ctx context.Context, date time.Time) (int64, error) {
+	sql, args, _ := r.Builder.
+		Insert("notifications").
+		Columns("tenant_id, channel, recipient_id, digest_date").
+		Select(squirrel.
+			Select("u.tenant_id").
+			Column("?", models.NotificationChannelEmail).
+			Column("u.user_id").
+			Column("?::DATE", date.Format(time.DateOnly)).
+			From("users u").
+			Join("notification_preferences p ON p.tenant_id = u.tenant_id AND p.user_id = u.user_id").
+			Where("u.is_active AND u.email IS NOT NULL").
+			// unlike events, the digest is sent only to users who chose it
+			Where("? = ANY(p.event_types)", models.NotificationReviewDigest).
+			Where(wantedBy(models.NotificationChannelEmail, models.NotificationReviewDigest)).
+			Where(`EXISTS (
+				SELECT 1 FROM pull_request_reviewers prr
+				JOIN pull_requests pr ON pr.tenant_id = prr.tenant_id AND pr.pull_request_id = prr.pull_request_id
+				WHERE prr.tenant_id = u.tenant_id AND prr.reviewer_id = u.user_id AND prr.reassigned_at IS NULL AND pr.status = ?
+			)`, OpenStatus),
+		).
+		Suffix("ON CONFLICT DO NOTHING").
+		ToSql()
+
+	cmd, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create email digests: %w", err)
+	}
+
+	return cmd.RowsAffected(), nil
+}
+
+// ClaimNotifications takes up to limit due pending notifications of all
+// tenants and postpones them by lease, so other dispatchers skip them while
+// they are being sent. Unfinished notifications are retried after the lease.
+// Preferences and quiet hours of the recipient are the current ones, they may
+// have changed since the notification was created.
+func (r *NotificationRepo) ClaimNotifications(ctx context.Context, limit uint64, lease time.Duration) ([]models.Notification, error) {
+	// the nested update must keep "?" placeholders, the outer builder numbers them
+	claim, claimArgs, _ := squirrel.
+		Update("notifications").
+		Set("next_attempt_at", squirrel.Expr("NOW() + make_interval(secs => ?)", lease.Seconds())).
+		Set("lease_token", squirrel.Expr("gen_random_uuid()")).
+		Where(`id IN (
+			SELECT id FROM notifications
+			WHERE status = ? AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at LIMIT ? FOR UPDATE SKIP LOCKED
+		)`, models.NotificationStatusPending, limit).
+		Suffix("RETURNING *").
+		ToSql()
+
+	sql, args, _ := r.Builder.
+		Select(notificationColumns).
+		Column("COALESCE(e.event_type, ?)", models.NotificationReviewDigest).
+		Column(`e.payload, COALESCE(e.created_at, n.created_at), COALESCE(u.username, ''), COALESCE(u.email, ''),
+			COALESCE(l.chat_id, 0), COALESCE(c.url, ''), COALESCE(c.templates, '{}'), COALESCE(p.timezone, 'UTC'),
+			to_char(p.quiet_hours_start, 'HH24:MI'), to_char(p.quiet_hours_end, 'HH24:MI'), n.lease_token::text`).
+		Column(squirrel.And{
+			wantedBy(squirrel.Expr("n.channel"), squirrel.Expr("COALESCE(e.event_type, ?)", models.NotificationReviewDigest)),
+			squirrel.Expr("(n.digest_date IS NULL OR COALESCE(? = ANY(p.event_types), FALSE))", models.NotificationReviewDigest),
+		}).
+		Prefix("WITH n AS ("+claim+")", claimArgs...).
+		From("n").
+		LeftJoin("outbox_events e ON e.id = n.event_id").
+		LeftJoin("chat_channels c ON c.id = n.chat_channel_id").
+		LeftJoin("users u ON u.tenant_id = n.tenant_id AND u.user_id = n.recipient_id").
+		LeftJoin("notification_preferences p ON p.tenant_id = n.tenant_id AND p.user_id = n.recipient_id").
+		LeftJoin("telegram_links l ON l.tenant_id = n.tenant_id AND l.user_id = n.recipient_id").
+		ToSql()
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := []models.Notification{}
+	for rows.Next() {
+		var notification models.Notification
+		var quietStart, quietEnd *string
+		if err := rows.Scan(append(notificationDest(&notification),
+			&notification.Event.EventType,
+			&notification.Event.Payload,
+			&notification.Event.CreatedAt,
+			&notification.RecipientName,
+			&notification.Email,
+			&notification.TelegramChat,
+			&notification.URL,
+			&notification.Templates,
+			&notification.Timezone,
+			&quietStart,
+			&quietEnd,
+			&notification.LeaseToken,
+			&notification.Wanted,
+		)...); err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+
+		notification.QuietHours = quietHours(quietStart, quietEnd)
+		notification.Event.TenantID = notification.TenantID
+		if notification.EventID != nil {
+			notification.Event.ID = *notification.EventID
+		}
+		notifications = append(notifications, notification)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read notifications: %w", err)
+	}
+
+	return notifications, nil
+}
+
+// FinishNotification records an attempt of the claimed notification. Failed
+// attempts are retried after retryAfter, or the notification is dead when
+// retryAfter is nil. Returns repoerrs.ErrLeaseLost if the lease expired and
+// the notification was claimed again.
+func (r *NotificationRepo) FinishNotification(ctx context.Context, notificationID int64, leaseToken string, sent bool, lastError string, retryAfter *time.Duration) error {
+	update := r.Builder.
+		Update("notifications").
+		Set("attempts", squirrel.Expr("attempts + 1")).
+		Set("last_error", nullIfEmpty(lastError)).
+		Set("lease_token", nil).
+		Where("id = ? AND status = ? AND lease_token = ?", notificationID, models.NotificationStatusPending, leaseToken)
+
+	switch {
+	case sent:
+		update = update.
+			Set("status", models.NotificationStatusSent).
+			Set("sent_at", squirrel.Expr("NOW()"))
+	case retryAfter != nil:
+		update = update.Set("next_attempt_at", squirrel.Expr("NOW() + make_interval(secs => ?)", retryAfter.Seconds()))
+	default:
+		update = update.Set("status", models.NotificationStatusDead)
+	}
+
+	sql, args, _ := update.ToSql()
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update notification: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repoerrs.ErrLeaseLost
+	}
+
+	return nil
+}
+
+// SkipNotification closes the claimed notification which turned out to have
+// nothing to send. Returns repoerrs.ErrLeaseLost if the lease expired and the
+// notification was claimed again.
+func (r *NotificationRepo) SkipNotification(ctx context.Context, notificationID int64, leaseToken string) error {
+	sql, args, _ := r.Builder.
+		Update("notifications").
+		Set("status", models.NotificationStatusSkipped).
+		Set("lease_token", nil).
+		Where("id = ? AND status = ? AND lease_token = ?", notificationID, models.NotificationStatusPending, leaseToken).
+		ToSql()
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to skip notification: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repoerrs.ErrLeaseLost
+	}
+
+	return nil
+}
+
+// PostponeNotification releases the claimed notification till delay passes
+// without spending an attempt. Returns repoerrs.ErrLeaseLost if the lease
+// expired and the notification was claimed again.
+func (r *NotificationRepo) PostponeNotification(ctx context.Context, notificationID int64, leaseToken string, delay time.Duration) error {
+	sql, args, _ := r.Builder.
+		Update("notifications").
+		Set("next_attempt_at", squirrel.Expr("NOW() + make_interval(secs => ?)", delay.Seconds())).
+		Set("lease_token", nil).
+		Where("id = ? AND status = ? AND lease_token = ?", notificationID, models.NotificationStatusPending, leaseToken).
+		ToSql()
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to postpone notification: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repoerrs.ErrLeaseLost
+	}
+
+	return nil
+}
+
+func scanChatChannel(row pgx.Row) (*models.ChatChannel, error) {
+	var channel models.ChatChannel
+	if err := scanChatChannelInto(row, &channel); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to scan chat channel: %w", err)
+	}
+
+	return &channel, nil
+}
+
+func scanChatChannelInto(row pgx.Row, channel *models.ChatChannel) error {
+	return row.Scan(
+		&channel.ID,
+		&channel.TeamName,
+		&channel.URL,
+		&channel.EventTypes,
+		&channel.Templates,
+		&channel.IsActive,
+		&channel.CreatedAt,
+	)
+}
+
+func notificationDest(notification *models.Notification) []any {
+	return []any{
+		&notification.ID,
+		&notification.TenantID,
+		&notification.EventID,
+		&notification.Channel,
+		&notification.ChatChannelID,
+		&notification.RecipientID,
+		&notification.DigestDate,
+		&notification.Status,
+		&notification.Attempts,
+		&notification.NextAttemptAt,
+		&notification.LastError,
+		&notification.CreatedAt,
+		&notification.SentAt,
+	}
+}
@@ -4,15 +4,21 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
 	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/tenant"
 	"github.com/MatTwix/Pull-Request-Assigner/pkg/database/postgres"
 	"github.com/jackc/pgx/v5"
 )
 
-const MergedStatus = "MERGED"
+const (
+	OpenStatus   = models.PullRequestStatusOpen
+	MergedStatus = models.PullRequestStatusMerged
+	ClosedStatus = models.PullRequestStatusClosed
+)
 
 type PullRequestRepo struct {
 	*postgres.Postgres
@@ -23,53 +29,91 @@ func NewPullrequestRepo(pg *postgres.Postgres) *PullRequestRepo {
 }
 
 func (r *PullRequestRepo) CreatePR(ctx context.Context, pr models.PullRequest) (*models.PullRequest, error) {
+	tenantID := tenant.ID(ctx)
+
+	var repository models.Repository
+	if pr.RepositoryName != "" {
+		sql, args, _ := r.Builder.
+			Select("COALESCE(team_name, ''), reviewers_count, fallback_depth").
+			From("repositories").
+			Where("tenant_id = ? AND repository_name = ?", tenantID, pr.RepositoryName).
+			ToSql()
+
+		if err := r.Pool.QueryRow(ctx, sql, args...).Scan(
+			&repository.TeamName,
+			&repository.ReviewersCount,
+			&repository.FallbackDepth,
+		); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, repoerrs.ErrNotFound
+			}
+			return nil, fmt.Errorf("failed to get repository: %w", err)
+		}
+	}
+
 	sql, args, _ := r.Builder.
-		Select("team_name").
-		From("users").
-		Where("user_id = ?", pr.AuthorID).
+		Select(primaryTeamColumn).
+		Column("EXISTS (SELECT 1 FROM team_members m WHERE m.tenant_id = u.tenant_id AND m.user_id = u.user_id AND m.team_name = ?)", pr.TeamName).
+		From("users u").
+		Where("u.tenant_id = ? AND u.user_id = ?", tenantID, pr.AuthorID).
 		ToSql()
 
-	var teamName string
+	var (
+		primaryTeam string
+		isMember    bool
+	)
 
-	if err := r.Pool.QueryRow(ctx, sql, args...).Scan(&teamName); err != nil {
+	if err := r.Pool.QueryRow(ctx, sql, args...).Scan(&primaryTeam, &isMember); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, repoerrs.ErrNotFound
 		}
 		return nil, fmt.Errorf("failed to get author team name: %w", err)
 	}
 
-	sql, args, _ = r.Builder.
-		Select("user_id").
-		From("users").
-		Where("team_name = ? AND is_active = TRUE AND user_id != ?", teamName, pr.AuthorID).
-		OrderBy("RANDOM()").
-		Limit(2).
-		ToSql()
-
-	rows, err := r.Pool.Query(ctx, sql, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query row: %w", err)
+	switch {
+	case pr.TeamName != "":
+		if !isMember {
+			return nil, repoerrs.ErrNotMember
+		}
+	case repository.TeamName != "":
+		// owners review their repository, whatever team the author is from
+		pr.TeamName = repository.TeamName
+	default:
+		pr.TeamName = primaryTeam
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var reviewerID string
-		err := rows.Scan(&reviewerID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan reviewer: %w", err)
+	reviewersCount := models.DefaultReviewersCount
+	if repository.ReviewersCount != nil {
+		reviewersCount = *repository.ReviewersCount
+	} else if pr.TeamName != "" {
+		sql, args, _ = r.Builder.
+			Select().
+			Column("COALESCE(reviewers_count, ?)", models.DefaultReviewersCount).
+			From("teams").
+			Where("tenant_id = ? AND team_name = ?", tenantID, pr.TeamName).
+			ToSql()
+
+		if err := r.Pool.QueryRow(ctx, sql, args...).Scan(&reviewersCount); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("failed to get team reviewers count: %w", err)
 		}
+	}
 
-		pr.AssignedReviewers = append(pr.AssignedReviewers, reviewerID)
+	reviewers, err := r.pickReviewers(ctx, r.Pool, tenantID, pr.TeamName, repository.FallbackDepth, []string{pr.AuthorID}, uint64(reviewersCount))
+	if err != nil {
+		return nil, err
 	}
 
-	if len(pr.AssignedReviewers) < 2 {
+	pr.AssignedReviewers = reviewers
+
+	if len(pr.AssignedReviewers) < reviewersCount {
 		pr.NeedsMoreReviewers = true
 	}
 
+	// ids of archived pull requests are taken too
 	checkSQL, checkArgs, _ := r.Builder.
 		Select("1").
-		From("pull_requests").
-		Where("pull_request_id = ?", pr.PullRequestID).
+		From("all_pull_requests").
+		Where("tenant_id = ? AND pull_request_id = ?", tenantID, pr.PullRequestID).
 		ToSql()
 
 	var exists int
@@ -87,32 +131,37 @@ func (r *PullRequestRepo) CreatePR(ctx context.Context, pr models.PullRequest) (
 
 	sql, args, _ = r.Builder.
 		Insert("pull_requests").
-		Columns("pull_request_id, pull_request_name, author_id, needs_more_reviewers").
+		Columns("tenant_id, pull_request_id, pull_request_name, author_id, team_name, repository_name, vcs_provider, needs_more_reviewers").
 		Values(
+			tenantID,
 			pr.PullRequestID,
 			pr.PullRequestName,
 			pr.AuthorID,
+			nullIfEmpty(pr.TeamName),
+			nullIfEmpty(pr.RepositoryName),
+			nullIfEmpty(pr.VCSProvider),
 			pr.NeedsMoreReviewers,
 		).
-		Suffix("RETURNING id, status, created_at").
+		Suffix("RETURNING id, status, created_at, version").
 		ToSql()
 
-	if err = tx.QueryRow(ctx, sql, args...).Scan(&pr.ID, &pr.Status, &pr.CreatedAt); err != nil {
+	if err = tx.QueryRow(ctx, sql, args...).Scan(&pr.ID, &pr.Status, &pr.CreatedAt, &pr.Version); err != nil {
 		return nil, fmt.Errorf("failed to insert pr: %w", err)
 	}
 
 	insert := r.Builder.
 		Insert("pull_request_reviewers").
-		Columns("pull_request_id, reviewer_id")
+		Columns("tenant_id, pull_request_id, reviewer_id")
 
 	for _, reviewerID := range pr.AssignedReviewers {
-		insert = insert.Values(pr.PullRequestID, reviewerID)
+		insert = insert.Values(tenantID, pr.PullRequestID, reviewerID)
 	}
 
 	sql, args, _ = r.Builder.
 		Update("users").
 		Set("is_active", false).
-		Where(squirrel.Eq{"user_id": pr.AssignedReviewers}).
+		Set("version", bumpVersion).
+		Where(squirrel.Eq{"tenant_id": tenantID, "user_id": pr.AssignedReviewers}).
 		ToSql()
 
 	if _, err := tx.Exec(ctx, sql, args...); err != nil {
@@ -124,6 +173,20 @@ func (r *PullRequestRepo) CreatePR(ctx context.Context, pr models.PullRequest) (
 		return nil, fmt.Errorf("failed to insert reviewers: %w", err)
 	}
 
+	if err := writeAudit(ctx, r.Postgres, tx, models.AuditActionPullRequestCreate, models.AuditEntityPullRequest, pr.PullRequestID,
+		nil, auditPullRequest(pr),
+	); err != nil {
+		return nil, err
+	}
+
+	if err := writeOutbox(ctx, r.Postgres, tx, models.EventPullRequestCreated, pullRequestEvent(pr)); err != nil {
+		return nil, err
+	}
+
+	if _, err := requestReviewerSync(ctx, r.Postgres, tx, pr.PullRequestID); err != nil {
+		return nil, err
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
@@ -132,20 +195,26 @@ func (r *PullRequestRepo) CreatePR(ctx context.Context, pr models.PullRequest) (
 }
 
 func (r *PullRequestRepo) MergePR(ctx context.Context, prID string) (prRes *models.PullRequest, alreadyMerged bool, err error) {
+	tenantID := tenant.ID(ctx)
+
 	tx, err := r.Pool.Begin(ctx)
 	if err != nil {
 		return nil, false, fmt.Errorf("failed to start transaction: %w", err)
 	}
 	defer func() { _ = tx.Rollback(ctx) }()
 
-	var prevStatus string
+	var (
+		prevStatus string
+		version    int
+	)
 	sql, args, _ := r.Builder.
-		Select("status").
+		Select("status, version").
 		From("pull_requests").
-		Where(squirrel.Eq{"pull_request_id": prID}).
+		Where(squirrel.Eq{"tenant_id": tenantID, "pull_request_id": prID}).
+		Suffix("FOR UPDATE").
 		ToSql()
 
-	if err := tx.QueryRow(ctx, sql, args...).Scan(&prevStatus); err != nil {
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&prevStatus, &version); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, false, repoerrs.ErrNotFound
 		}
@@ -154,26 +223,38 @@ func (r *PullRequestRepo) MergePR(ctx context.Context, prID string) (prRes *mode
 
 	alreadyMerged = prevStatus == MergedStatus
 
-	if !alreadyMerged {
+	if alreadyMerged {
+		if err := checkVersion(ctx, version); err != nil {
+			return nil, false, err
+		}
+	} else {
 		sql, args, _ = r.Builder.
 			Update("pull_requests").
-			Set("status", "MERGED").
+			Set("status", MergedStatus).
 			Set("merged_at", squirrel.Expr("NOW()")).
 			Set("needs_more_reviewers", false).
-			Where(squirrel.Eq{"pull_request_id": prID}).
+			Set("version", bumpVersion).
+			Where(squirrel.Eq{"tenant_id": tenantID, "pull_request_id": prID}).
+			Where(expectedVersion(ctx, "version")).
 			ToSql()
 
-		_, err = tx.Exec(ctx, sql, args...)
+		tag, err := tx.Exec(ctx, sql, args...)
 		if err != nil {
 			return nil, false, fmt.Errorf("failed to exec row: %w", err)
 		}
+
+		// the pull request is locked, so only another version leaves nothing to update
+		if tag.RowsAffected() == 0 {
+			return nil, false, repoerrs.ErrVersionMismatch
+		}
 	}
 
 	var reviewerIDs []string
 	sql, args, _ = r.Builder.
 		Select("reviewer_id").
 		From("pull_request_reviewers").
-		Where("pull_request_id = ?", prID).
+		Where("tenant_id = ? AND pull_request_id = ? AND reassigned_at IS NULL", tenantID, prID).
+		OrderBy("assigned_at", "id").
 		ToSql()
 
 	rows, err := tx.Query(ctx, sql, args...)
@@ -197,18 +278,23 @@ func (r *PullRequestRepo) MergePR(ctx context.Context, prID string) (prRes *mode
 		AssignedReviewers: reviewerIDs,
 	}
 	sql, args, _ = r.Builder.
-		Select("id", "pull_request_name", "author_id", "status", "merged_at", "created_at").
-		From("pull_requests").
-		Where("pull_request_id = ?", prID).
+		Select("id", "pull_request_name", "author_id", "COALESCE(team_name, '')", "COALESCE(repository_name, '')", "status", "needs_more_reviewers", "merged_at", "created_at", "version", teamIsArchivedColumn).
+		From("pull_requests pr").
+		Where("tenant_id = ? AND pull_request_id = ?", tenantID, prID).
 		ToSql()
 
 	err = tx.QueryRow(ctx, sql, args...).Scan(
 		&pr.ID,
 		&pr.PullRequestName,
 		&pr.AuthorID,
+		&pr.TeamName,
+		&pr.RepositoryName,
 		&pr.Status,
+		&pr.NeedsMoreReviewers,
 		&pr.MergedAt,
 		&pr.CreatedAt,
+		&pr.Version,
+		&pr.TeamIsArchived,
 	)
 
 	if err != nil {
@@ -219,7 +305,8 @@ func (r *PullRequestRepo) MergePR(ctx context.Context, prID string) (prRes *mode
 		sql, args, _ := r.Builder.
 			Update("users").
 			Set("is_active", true).
-			Where(squirrel.Eq{"user_id": reviewerIDs}).
+			Set("version", bumpVersion).
+			Where(squirrel.Eq{"tenant_id": tenantID, "user_id": reviewerIDs}).
 			ToSql()
 
 		if _, err := tx.Exec(ctx, sql, args...); err != nil {
@@ -227,6 +314,19 @@ func (r *PullRequestRepo) MergePR(ctx context.Context, prID string) (prRes *mode
 		}
 	}
 
+	if !alreadyMerged {
+		if err := writeAudit(ctx, r.Postgres, tx, models.AuditActionPullRequestMerge, models.AuditEntityPullRequest, prID,
+			map[string]any{"status": prevStatus},
+			map[string]any{"status": pr.Status, "merged_at": pr.MergedAt, "assigned_reviewers": reviewerIDs},
+		); err != nil {
+			return nil, false, err
+		}
+
+		if err := writeOutbox(ctx, r.Postgres, tx, models.EventPullRequestMerged, pullRequestEvent(pr)); err != nil {
+			return nil, false, err
+		}
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		return nil, false, fmt.Errorf("failed to commit transaction: %w", err)
 	}
@@ -234,94 +334,169 @@ func (r *PullRequestRepo) MergePR(ctx context.Context, prID string) (prRes *mode
 	return &pr, alreadyMerged, nil
 }
 
-func (r *PullRequestRepo) ReassignReviewer(ctx context.Context, prID, oldUserID string) (pullRequest *models.PullRequest, replacedBy string, err error) {
+func (r *PullRequestRepo) ReassignReviewer(ctx context.Context, prID, oldUserID string) (pullRequest *models.PullRequest, replacedBy string, firstReassignAfter *time.Duration, err error) {
+	tenantID := tenant.ID(ctx)
+
 	tx, err := r.Pool.Begin(ctx)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to start transaction: %w", err)
+		return nil, "", nil, fmt.Errorf("failed to start transaction: %w", err)
 	}
 	defer func() { _ = tx.Rollback(ctx) }()
 
-	var authorID, status string
+	var (
+		authorID, teamName, status string
+		fallbackDepth              *int
+	)
 	sql, args, _ := r.Builder.
-		Select("author_id, status").
-		From("pull_requests").
-		Where("pull_request_id = ?", prID).
+		Select("pr.author_id, COALESCE(pr.team_name, ''), pr.status, rp.fallback_depth").
+		From("pull_requests pr").
+		LeftJoin("repositories rp ON rp.tenant_id = pr.tenant_id AND rp.repository_name = pr.repository_name").
+		Where("pr.tenant_id = ? AND pr.pull_request_id = ?", tenantID, prID).
 		ToSql()
 
-	if err := tx.QueryRow(ctx, sql, args...).Scan(&authorID, &status); err != nil {
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&authorID, &teamName, &status, &fallbackDepth); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, "", repoerrs.ErrNotFound
+			return nil, "", nil, repoerrs.ErrNotFound
 		}
-		return nil, "", fmt.Errorf("failed to get pr: %w", err)
+		return nil, "", nil, fmt.Errorf("failed to get pr: %w", err)
+	}
+
+	switch status {
+	case MergedStatus:
+		return nil, "", nil, repoerrs.ErrReassignAfterMerge
+	case ClosedStatus:
+		return nil, "", nil, repoerrs.ErrReassignAfterClose
+	}
+
+	// locks the pull request against concurrent changes of its reviewers
+	sql, args, _ = r.Builder.
+		Update("pull_requests").
+		Set("version", bumpVersion).
+		Where(squirrel.Eq{"tenant_id": tenantID, "pull_request_id": prID}).
+		Where(expectedVersion(ctx, "version")).
+		ToSql()
+
+	tag, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to update pr version: %w", err)
 	}
 
-	if status == MergedStatus {
-		return nil, "", repoerrs.ErrReassignAfterMerge
+	if tag.RowsAffected() == 0 {
+		return nil, "", nil, repoerrs.ErrVersionMismatch
 	}
 
 	sql, args, _ = r.Builder.
 		Select("1").
 		From("users").
-		Where("user_id = ?", oldUserID).
+		Where("tenant_id = ? AND user_id = ?", tenantID, oldUserID).
 		ToSql()
 
 	var exists int
 	if err := tx.QueryRow(ctx, sql, args...).Scan(&exists); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, "", repoerrs.ErrUserNotFound
+			return nil, "", nil, repoerrs.ErrUserNotFound
 		}
-		return nil, "", fmt.Errorf("failed to check old use existence: %w", err)
+		return nil, "", nil, fmt.Errorf("failed to check old use existence: %w", err)
 	}
 
 	sql, args, _ = r.Builder.
-		Select("1").
+		Select("reviewer_id").
 		From("pull_request_reviewers").
-		Where("pull_request_id = ? AND reviewer_id = ?", prID, oldUserID).
+		Where("tenant_id = ? AND pull_request_id = ? AND reassigned_at IS NULL", tenantID, prID).
+		OrderBy("assigned_at", "id").
+		Suffix("FOR UPDATE").
 		ToSql()
 
-	if err := tx.QueryRow(ctx, sql, args...).Scan(&exists); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, "", repoerrs.ErrNotAssigned
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to get reviewers list: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		currentReviewers []string
+		isAssigned       bool
+	)
+	for rows.Next() {
+		var reviewerID string
+		if err := rows.Scan(&reviewerID); err != nil {
+			return nil, "", nil, fmt.Errorf("failed to scan reviewer id: %w", err)
 		}
-		return nil, "", fmt.Errorf("failed to check reviewer: %w", err)
+
+		isAssigned = isAssigned || reviewerID == oldUserID
+		currentReviewers = append(currentReviewers, reviewerID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", nil, fmt.Errorf("failed to get reviewers list: %w", err)
+	}
+
+	if !isAssigned {
+		return nil, "", nil, repoerrs.ErrNotAssigned
+	}
+
+	// PRs whose team was deleted fall back to the old reviewer's primary team
+	if teamName == "" {
+		sql, args, _ = r.Builder.
+			Select(primaryTeamColumn).
+			From("users u").
+			Where("u.tenant_id = ? AND u.user_id = ?", tenantID, oldUserID).
+			ToSql()
+
+		if err := tx.QueryRow(ctx, sql, args...).Scan(&teamName); err != nil {
+			return nil, "", nil, fmt.Errorf("failed to get team name: %w", err)
+		}
+	}
+
+	candidates, err := r.pickReviewers(ctx, tx, tenantID, teamName, fallbackDepth, append([]string{authorID}, currentReviewers...), 1)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	if len(candidates) == 0 {
+		return nil, "", nil, repoerrs.ErrNoCandidate
 	}
+	newReviewerID := candidates[0]
 
-	var teamName string
+	// the age of the PR is taken from the database clock, the same one created_at comes from
 	sql, args, _ = r.Builder.
-		Select("team_name").
-		From("users").
-		Where("user_id = ?", oldUserID).
+		Select("EXTRACT(EPOCH FROM LOCALTIMESTAMP - created_at)::float8").
+		Column("NOT EXISTS (SELECT 1 FROM pull_request_reviewers WHERE tenant_id = ? AND pull_request_id = ? AND reassigned_at IS NOT NULL)", tenantID, prID).
+		From("pull_requests").
+		Where("tenant_id = ? AND pull_request_id = ?", tenantID, prID).
 		ToSql()
 
-	if err := tx.QueryRow(ctx, sql, args...).Scan(&teamName); err != nil {
-		return nil, "", fmt.Errorf("failed to get team name: %w", err)
+	var (
+		ageSeconds    float64
+		firstReassign bool
+	)
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&ageSeconds, &firstReassign); err != nil {
+		return nil, "", nil, fmt.Errorf("failed to check previous reassignments: %w", err)
+	}
+	if firstReassign {
+		age := time.Duration(ageSeconds * float64(time.Second))
+		firstReassignAfter = &age
 	}
 
+	// the old assignment is kept for statistics
 	sql, args, _ = r.Builder.
-		Select("user_id").
-		From("users").
-		Where(squirrel.Eq{"team_name": teamName, "is_active": true}).
-		Where(squirrel.NotEq{"user_id": []string{authorID, oldUserID}}).
-		OrderBy("RANDOM()").
-		Limit(1).
+		Update("pull_request_reviewers").
+		Set("reassigned_at", squirrel.Expr("NOW()")).
+		Set("replaced_by", newReviewerID).
+		Where("tenant_id = ? AND pull_request_id = ? AND reviewer_id = ? AND reassigned_at IS NULL", tenantID, prID, oldUserID).
 		ToSql()
 
-	var newReviewerID string
-	if err := tx.QueryRow(ctx, sql, args...).Scan(&newReviewerID); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, "", repoerrs.ErrNoCandidate
-		}
-		return nil, "", fmt.Errorf("failed to find new reviewer: %w", err)
+	if _, err := tx.Exec(ctx, sql, args...); err != nil {
+		return nil, "", nil, fmt.Errorf("failed to update reviewer: %w", err)
 	}
 
 	sql, args, _ = r.Builder.
-		Update("pull_request_reviewers").
-		Set("reviewer_id", newReviewerID).
-		Where("pull_request_id = ? AND reviewer_id = ?", prID, oldUserID).
+		Insert("pull_request_reviewers").
+		Columns("tenant_id, pull_request_id, reviewer_id").
+		Values(tenantID, prID, newReviewerID).
 		ToSql()
 
 	if _, err := tx.Exec(ctx, sql, args...); err != nil {
-		return nil, "", fmt.Errorf("failed to update reviewer: %w", err)
+		return nil, "", nil, fmt.Errorf("failed to insert reviewer: %w", err)
 	}
 
 	pr := models.PullRequest{
@@ -329,65 +504,420 @@ func (r *PullRequestRepo) ReassignReviewer(ctx context.Context, prID, oldUserID
 	}
 
 	sql, args, _ = r.Builder.
-		Select("id", "pull_request_name", "author_id", "status", "merged_at", "created_at").
-		From("pull_requests").
-		Where("pull_request_id = ?", prID).
+		Select("id", "pull_request_name", "author_id", "COALESCE(team_name, '')", "COALESCE(repository_name, '')", "status", "needs_more_reviewers", "merged_at", "created_at", "version", teamIsArchivedColumn).
+		From("pull_requests pr").
+		Where("tenant_id = ? AND pull_request_id = ?", tenantID, prID).
 		ToSql()
 
 	err = tx.QueryRow(ctx, sql, args...).Scan(
 		&pr.ID,
 		&pr.PullRequestName,
 		&pr.AuthorID,
+		&pr.TeamName,
+		&pr.RepositoryName,
 		&pr.Status,
+		&pr.NeedsMoreReviewers,
 		&pr.MergedAt,
 		&pr.CreatedAt,
+		&pr.Version,
+		&pr.TeamIsArchived,
 	)
 
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to get updated pr: %w", err)
-	}
-
-	var otherReviewerID string
-	sql, args, _ = r.Builder.
-		Select("reviewer_id").
-		From("pull_request_reviewers").
-		Where("pull_request_id = ? AND reviewer_id != ?", prID, newReviewerID).
-		Limit(1).
-		ToSql()
-
-	err = tx.QueryRow(ctx, sql, args...).Scan(&otherReviewerID)
-	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
-		return nil, "", fmt.Errorf("failed to get reviewers list: %w", err)
+		return nil, "", nil, fmt.Errorf("failed to get updated pr: %w", err)
 	}
 
 	pr.AssignedReviewers = append(pr.AssignedReviewers, newReviewerID)
-	if otherReviewerID != "" {
-		pr.AssignedReviewers = append(pr.AssignedReviewers, otherReviewerID)
+	for _, reviewerID := range currentReviewers {
+		if reviewerID != oldUserID {
+			pr.AssignedReviewers = append(pr.AssignedReviewers, reviewerID)
+		}
 	}
 
 	sql, args, _ = r.Builder.
 		Update("users").
 		Set("is_active", true).
-		Where("user_id = ?", oldUserID).
+		Set("version", bumpVersion).
+		Where("tenant_id = ? AND user_id = ?", tenantID, oldUserID).
 		ToSql()
 
 	if _, err := tx.Exec(ctx, sql, args...); err != nil {
-		return nil, "", fmt.Errorf("failed to make old reviewer active: %w", err)
+		return nil, "", nil, fmt.Errorf("failed to make old reviewer active: %w", err)
 	}
 
 	sql, args, _ = r.Builder.
 		Update("users").
 		Set("is_active", false).
-		Where("user_id = ?", newReviewerID).
+		Set("version", bumpVersion).
+		Where("tenant_id = ? AND user_id = ?", tenantID, newReviewerID).
 		ToSql()
 
 	if _, err := tx.Exec(ctx, sql, args...); err != nil {
-		return nil, "", fmt.Errorf("failed to make new reviewer inactive: %w", err)
+		return nil, "", nil, fmt.Errorf("failed to make new reviewer inactive: %w", err)
+	}
+
+	if err := writeAudit(ctx, r.Postgres, tx, models.AuditActionReviewerReassign, models.AuditEntityPullRequest, prID,
+		map[string]any{"assigned_reviewers": currentReviewers},
+		map[string]any{"assigned_reviewers": pr.AssignedReviewers, "old_reviewer_id": oldUserID, "new_reviewer_id": newReviewerID},
+	); err != nil {
+		return nil, "", nil, err
+	}
+
+	if err := writeOutbox(ctx, r.Postgres, tx, models.EventReviewerReassigned, models.ReviewerReassignedEvent{
+		PullRequestEvent: pullRequestEvent(pr),
+		OldReviewerID:    oldUserID,
+		NewReviewerID:    newReviewerID,
+	}); err != nil {
+		return nil, "", nil, err
+	}
+
+	if _, err := requestReviewerSync(ctx, r.Postgres, tx, prID); err != nil {
+		return nil, "", nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, "", nil, fmt.Errorf("failed to commit reassignment: %w", err)
+	}
+
+	return &pr, newReviewerID, firstReassignAfter, nil
+}
+
+// ClosePR closes the pull request without merge and releases its reviewers
+// as merge does. Closing a closed pull request changes nothing.
+func (r *PullRequestRepo) ClosePR(ctx context.Context, prID string) (pr *models.PullRequest, alreadyClosed bool, err error) {
+	return r.setOpenStatus(ctx, prID, ClosedStatus, models.AuditActionPullRequestClose, models.EventPullRequestClosed)
+}
+
+// ReopenPR opens the closed pull request again, its reviewers are busy with it
+// again. Reopening an open pull request changes nothing.
+func (r *PullRequestRepo) ReopenPR(ctx context.Context, prID string) (pr *models.PullRequest, alreadyOpen bool, err error) {
+	return r.setOpenStatus(ctx, prID, OpenStatus, models.AuditActionPullRequestReopen, models.EventPullRequestReopened)
+}
+
+// setOpenStatus moves the not merged pull request between OPEN and CLOSED.
+// Reviewers are active while the pull request is closed, like after merge.
+func (r *PullRequestRepo) setOpenStatus(ctx context.Context, prID, status, auditAction, eventType string) (prRes *models.PullRequest, alreadySet bool, err error) {
+	tenantID := tenant.ID(ctx)
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var (
+		prevStatus string
+		version    int
+	)
+	sql, args, _ := r.Builder.
+		Select("status, version").
+		From("pull_requests").
+		Where(squirrel.Eq{"tenant_id": tenantID, "pull_request_id": prID}).
+		Suffix("FOR UPDATE").
+		ToSql()
+
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&prevStatus, &version); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, repoerrs.ErrNotFound
+		}
+		return nil, false, fmt.Errorf("failed to check pr status: %w", err)
+	}
+
+	if prevStatus == MergedStatus {
+		return nil, false, repoerrs.ErrPRMerged
+	}
+
+	alreadySet = prevStatus == status
+
+	if alreadySet {
+		if err := checkVersion(ctx, version); err != nil {
+			return nil, false, err
+		}
+	} else {
+		sql, args, _ = r.Builder.
+			Update("pull_requests").
+			Set("status", status).
+			Set("version", bumpVersion).
+			Where(squirrel.Eq{"tenant_id": tenantID, "pull_request_id": prID}).
+			Where(expectedVersion(ctx, "version")).
+			ToSql()
+
+		tag, err := tx.Exec(ctx, sql, args...)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to update pr status: %w", err)
+		}
+
+		// the pull request is locked, so only another version leaves nothing to update
+		if tag.RowsAffected() == 0 {
+			return nil, false, repoerrs.ErrVersionMismatch
+		}
+	}
+
+	pr, err := r.getPullRequest(ctx, tx, tenantID, prID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if alreadySet {
+		if err := tx.Commit(ctx); err != nil {
+			return nil, false, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+
+		return pr, true, nil
+	}
+
+	if len(pr.AssignedReviewers) > 0 {
+		sql, args, _ := r.Builder.
+			Update("users").
+			Set("is_active", status == ClosedStatus).
+			Set("version", bumpVersion).
+			Where(squirrel.Eq{"tenant_id": tenantID, "user_id": pr.AssignedReviewers}).
+			ToSql()
+
+		if _, err := tx.Exec(ctx, sql, args...); err != nil {
+			return nil, false, fmt.Errorf("failed to update reviewers activity: %w", err)
+		}
+	}
+
+	if err := writeAudit(ctx, r.Postgres, tx, auditAction, models.AuditEntityPullRequest, prID,
+		map[string]any{"status": prevStatus},
+		map[string]any{"status": pr.Status, "assigned_reviewers": pr.AssignedReviewers},
+	); err != nil {
+		return nil, false, err
+	}
+
+	if err := writeOutbox(ctx, r.Postgres, tx, eventType, pullRequestEvent(*pr)); err != nil {
+		return nil, false, err
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		return nil, "", fmt.Errorf("failed to commit reassignment: %w", err)
+		return nil, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return pr, false, nil
+}
+
+// GetPR returns the pull request with its current reviewers and the state of
+// requesting them in the provider.
+func (r *PullRequestRepo) GetPR(ctx context.Context, prID string) (*models.PullRequest, error) {
+	tenantID := tenant.ID(ctx)
+
+	pr, err := r.getPullRequest(ctx, r.Pool, tenantID, prID)
+	if err != nil {
+		return nil, err
+	}
+
+	pr.ReviewerSync, err = getReviewerSync(ctx, r.Postgres, r.Pool, tenantID, prID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pr, nil
+}
+
+// MarkReviewSLABreaches marks up to limit current assignments of open pull
+// requests waiting longer than the review SLA of the team of the pull request
+// and raises a breach event for each of them. Runs for all tenants.
+func (r *PullRequestRepo) MarkReviewSLABreaches(ctx context.Context, limit uint64) (int64, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	sql, args, _ := r.Builder.
+		Update("pull_request_reviewers prr").
+		Set("sla_breached_at", squirrel.Expr("NOW()")).
+		From("pull_requests pr JOIN teams t ON t.tenant_id = pr.tenant_id AND t.team_name = pr.team_name").
+		Where("pr.tenant_id = prr.tenant_id AND pr.pull_request_id = prr.pull_request_id").
+		Where(`prr.id IN (
+			SELECT prr.id FROM pull_request_reviewers prr
+			JOIN pull_requests pr ON pr.tenant_id = prr.tenant_id AND pr.pull_request_id = prr.pull_request_id
+			JOIN teams t ON t.tenant_id = pr.tenant_id AND t.team_name = pr.team_name
+			WHERE prr.reassigned_at IS NULL AND prr.sla_breached_at IS NULL AND pr.status = ?
+				AND prr.assigned_at + t.review_sla <= NOW()
+			ORDER BY prr.assigned_at LIMIT ? FOR UPDATE OF prr SKIP LOCKED
+		)`, OpenStatus, limit).
+		Suffix("RETURNING prr.tenant_id, prr.pull_request_id, prr.reviewer_id, prr.assigned_at, " + reviewSLASeconds("t.review_sla")).
+		ToSql()
+
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark review sla breaches: %w", err)
+	}
+
+	type breach struct {
+		tenantID      int
+		prID          string
+		reviewerID    string
+		assignedAt    time.Time
+		reviewSLASecs int64
+	}
+
+	breaches, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (breach, error) {
+		var b breach
+		err := row.Scan(&b.tenantID, &b.prID, &b.reviewerID, &b.assignedAt, &b.reviewSLASecs)
+		return b, err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to scan review sla breaches: %w", err)
+	}
+
+	for _, b := range breaches {
+		pr, err := r.getPullRequest(ctx, tx, b.tenantID, b.prID)
+		if err != nil {
+			return 0, err
+		}
+
+		if err := writeOutbox(tenant.WithID(ctx, b.tenantID), r.Postgres, tx, models.EventReviewSLABreached, models.ReviewSLABreachedEvent{
+			PullRequestEvent: pullRequestEvent(*pr),
+			ReviewerID:       b.reviewerID,
+			AssignedAt:       b.assignedAt,
+			ReviewSLASeconds: b.reviewSLASecs,
+		}); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return int64(len(breaches)), nil
+}
+
+// getPullRequest returns the pull request with its current reviewers.
+// teamIsArchivedColumn selects whether the team of the pull request "pr" is
+// archived, false for pull requests without a team.
+const teamIsArchivedColumn = `COALESCE((SELECT t.is_archived FROM teams t
+	WHERE t.tenant_id = pr.tenant_id AND t.team_name = pr.team_name), FALSE)`
+
+func (r *PullRequestRepo) getPullRequest(ctx context.Context, q dbtx, tenantID int, prID string) (*models.PullRequest, error) {
+	pr := models.PullRequest{PullRequestID: prID}
+
+	sql, args, _ := r.Builder.
+		Select("id", "pull_request_name", "author_id", "COALESCE(team_name, '')", "COALESCE(repository_name, '')", "COALESCE(vcs_provider, '')", "status", "needs_more_reviewers", "merged_at", "created_at", "version", teamIsArchivedColumn).
+		From("pull_requests pr").
+		Where("tenant_id = ? AND pull_request_id = ?", tenantID, prID).
+		ToSql()
+
+	if err := q.QueryRow(ctx, sql, args...).Scan(
+		&pr.ID,
+		&pr.PullRequestName,
+		&pr.AuthorID,
+		&pr.TeamName,
+		&pr.RepositoryName,
+		&pr.VCSProvider,
+		&pr.Status,
+		&pr.NeedsMoreReviewers,
+		&pr.MergedAt,
+		&pr.CreatedAt,
+		&pr.Version,
+		&pr.TeamIsArchived,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get pr: %w", err)
+	}
+
+	sql, args, _ = r.Builder.
+		Select("reviewer_id").
+		From("pull_request_reviewers").
+		Where("tenant_id = ? AND pull_request_id = ? AND reassigned_at IS NULL", tenantID, prID).
+		OrderBy("assigned_at", "id").
+		ToSql()
+
+	rows, err := q.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select reviewers: %w", err)
+	}
+
+	pr.AssignedReviewers, err = pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan reviewers: %w", err)
+	}
+
+	return &pr, nil
+}
+
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// dbtx is the pool or a transaction.
+type dbtx interface {
+	querier
+	queryRower
+}
+
+// pickReviewers selects up to limit random active reviewers from the team.
+// When the team has a fallback depth (repository override, own or inherited
+// from the nearest ancestor), reviewers are also drawn from subtrees of the
+// ancestors up to that depth, closer teams first.
+func (r *PullRequestRepo) pickReviewers(ctx context.Context, q querier, tenantID int, teamName string, fallbackDepth *int, exclude []string, limit uint64) ([]string, error) {
+	sql, args, _ := r.Builder.
+		Select("u.user_id").
+		Prefix(`WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id, fallback_depth, 0 AS depth FROM teams WHERE tenant_id = ? AND team_name = ?
+			UNION ALL
+			SELECT t.id, t.parent_id, t.fallback_depth, a.depth + 1
+			FROM teams t
+			JOIN ancestors a ON t.id = a.parent_id
+			WHERE a.depth < ?
+		),
+		policy AS (
+			SELECT COALESCE(
+				?::int,
+				(SELECT fallback_depth FROM ancestors WHERE fallback_depth IS NOT NULL ORDER BY depth LIMIT 1),
+				0
+			) AS fallback_depth
+		),
+		pool AS (
+			SELECT a.id, a.depth FROM ancestors a, policy p WHERE a.depth <= p.fallback_depth
+			UNION ALL
+			SELECT t.id, pool.depth FROM teams t JOIN pool ON t.parent_id = pool.id WHERE pool.depth > 0
+		)`, tenantID, teamName, maxHierarchyDepth, fallbackDepth).
+		From("pool").
+		Join("teams t ON t.id = pool.id").
+		Join("team_members tm ON tm.tenant_id = t.tenant_id AND tm.team_name = t.team_name").
+		Join("users u ON u.tenant_id = tm.tenant_id AND u.user_id = tm.user_id").
+		Where("t.is_archived = FALSE AND u.is_active = TRUE").
+		// away users activated with their team are still away until the day
+		Where("(u.away_until IS NULL OR u.away_until <= CURRENT_DATE)").
+		Where(squirrel.NotEq{"u.user_id": exclude}).
+		GroupBy("u.user_id").
+		OrderBy("MIN(pool.depth)", "RANDOM()").
+		Limit(limit).
+		ToSql()
+
+	rows, err := q.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query row: %w", err)
+	}
+	defer rows.Close()
+
+	var reviewers []string
+	for rows.Next() {
+		var reviewerID string
+		err := rows.Scan(&reviewerID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reviewer: %w", err)
+		}
+
+		reviewers = append(reviewers, reviewerID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to find reviewers: %w", err)
+	}
+
+	return reviewers, nil
+}
+
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
 	}
 
-	return &pr, newReviewerID, nil
+	return s
 }
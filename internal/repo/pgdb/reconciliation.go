@@ -0,0 +1,208 @@
+package pgdb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/tenant"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/database/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+// reconciliationLockKey is the advisory lock held by the running
+// reconciliation.
+const reconciliationLockKey = 7_340_201
+
+type ReconciliationRepo struct {
+	*postgres.Postgres
+}
+
+func NewReconciliationRepo(pg *postgres.Postgres) *ReconciliationRepo {
+	return &ReconciliationRepo{pg}
+}
+
+// LockReconciliation takes the lock of the reconciliation on a connection of
+// its own, so only one instance reconciles at a time. The lock is held until
+// unlock is called, ok is false when another run holds it.
+func (r *ReconciliationRepo) LockReconciliation(ctx context.Context) (unlock func(), ok bool, err error) {
+	return tryAdvisoryLock(ctx, r.Postgres, reconciliationLockKey)
+}
+
+// tryAdvisoryLock takes the session advisory lock of the key on a connection
+// of its own, the lock is held until unlock is called or the connection is
+// lost. ok is false when another session holds it.
+func tryAdvisoryLock(ctx context.Context, pg *postgres.Postgres, key int64) (unlock func(), ok bool, err error) {
+	conn, err := pg.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&ok); err != nil {
+		conn.Release()
+		return nil, false, fmt.Errorf("failed to take advisory lock %d: %w", key, err)
+	}
+
+	if !ok {
+		conn.Release()
+		return nil, false, nil
+	}
+
+	unlock = func() {
+		// the session lock is freed with the connection when unlocking fails
+		if _, err := conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", key); err != nil {
+			_ = conn.Conn().Close(context.Background())
+		}
+		conn.Release()
+	}
+
+	return unlock, true, nil
+}
+
+// GetReconciledPullRequests returns a page of open pull requests created from
+// webhooks of providers the tenant has an api token of, ordered by id. Tenant
+// of the context limits the page unless allTenants is set.
+func (r *ReconciliationRepo) GetReconciledPullRequests(ctx context.Context, allTenants bool, afterID int, limit uint64) ([]models.ReconciledPullRequest, error) {
+	reviewers := squirrel.
+		Select().
+		From("pull_request_reviewers prr").
+		Where("prr.tenant_id = pr.tenant_id AND prr.pull_request_id = pr.pull_request_id AND prr.reassigned_at IS NULL").
+		OrderBy("prr.assigned_at", "prr.id")
+
+	// nested selects must keep "?" placeholders, the outer builder numbers them
+	reviewerIDs, reviewerIDsArgs, _ := reviewers.Column("prr.reviewer_id").ToSql()
+	reviewerLogins, reviewerLoginsArgs, _ := reviewers.Column("COALESCE(" + reviewerLogin + ", '')").ToSql()
+
+	query := r.Builder.
+		Select("pr.id, pr.tenant_id, pr.pull_request_id, pr.vcs_provider, i.api_token").
+		Column("ARRAY("+reviewerIDs+")", reviewerIDsArgs...).
+		Column("ARRAY("+reviewerLogins+")", reviewerLoginsArgs...).
+		Column("COALESCE(pr.reviewer_sync_status = ?, FALSE)", models.ReviewerSyncSynced).
+		From("pull_requests pr").
+		Join("vcs_integrations i ON i.tenant_id = pr.tenant_id AND i.provider = pr.vcs_provider").
+		Where("pr.status = ? AND pr.vcs_provider IS NOT NULL AND i.api_token IS NOT NULL AND pr.id > ?", OpenStatus, afterID).
+		OrderBy("pr.id").
+		Limit(limit)
+
+	if !allTenants {
+		query = query.Where("pr.tenant_id = ?", tenant.ID(ctx))
+	}
+
+	return collect(func(fn func(models.ReconciledPullRequest) error) error {
+		return streamRows(ctx, r.Postgres, query, "reconciled pull requests", func(rows pgx.Rows, pr *models.ReconciledPullRequest) error {
+			return rows.Scan(
+				&pr.ID,
+				&pr.TenantID,
+				&pr.PullRequestID,
+				&pr.Provider,
+				&pr.APIToken,
+				&pr.ReviewerIDs,
+				&pr.ReviewerLogins,
+				&pr.ReviewersSynced,
+			)
+		}, fn)
+	})
+}
+
+// AddReconciliationCorrection records the correction for the tenant of the
+// context. A failed correction of the drift already recorded as failed counts
+// one more attempt of it instead, an applied one resolves the failed one.
+func (r *ReconciliationRepo) AddReconciliationCorrection(ctx context.Context, correction models.ReconciliationCorrection) (*models.ReconciliationCorrection, error) {
+	correction.TenantID = tenant.ID(ctx)
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	suffix := "RETURNING id, created_at, attempts, last_attempt_at"
+	if correction.Error != "" {
+		suffix = `ON CONFLICT (tenant_id, pull_request_id, action, (COALESCE(reviewer_id, '')))
+			WHERE error IS NOT NULL AND resolved_at IS NULL
+			DO UPDATE SET error = EXCLUDED.error, attempts = reconciliation_corrections.attempts + 1, last_attempt_at = NOW()
+			` + suffix
+	} else {
+		sql, args, _ := r.Builder.
+			Update("reconciliation_corrections").
+			Set("resolved_at", squirrel.Expr("NOW()")).
+			Where("tenant_id = ? AND pull_request_id = ? AND action = ? AND COALESCE(reviewer_id, '') = ?",
+				correction.TenantID, correction.PullRequestID, correction.Action, correction.ReviewerID).
+			Where("error IS NOT NULL AND resolved_at IS NULL").
+			ToSql()
+
+		if _, err := tx.Exec(ctx, sql, args...); err != nil {
+			return nil, fmt.Errorf("failed to resolve failed reconciliation correction: %w", err)
+		}
+	}
+
+	sql, args, _ := r.Builder.
+		Insert("reconciliation_corrections").
+		Columns("tenant_id, pull_request_id, provider, action, reviewer_id, replaced_by, error").
+		Values(
+			correction.TenantID,
+			correction.PullRequestID,
+			correction.Provider,
+			correction.Action,
+			nullIfEmpty(correction.ReviewerID),
+			nullIfEmpty(correction.ReplacedBy),
+			nullIfEmpty(correction.Error),
+		).
+		Suffix(suffix).
+		ToSql()
+
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&correction.ID, &correction.CreatedAt, &correction.Attempts, &correction.LastAttemptAt); err != nil {
+		return nil, fmt.Errorf("failed to insert reconciliation correction: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &correction, nil
+}
+
+// GetReconciliationCorrections returns corrections matching the filter, newest
+// first.
+func (r *ReconciliationRepo) GetReconciliationCorrections(ctx context.Context, filter models.ReconciliationCorrectionFilter) ([]models.ReconciliationCorrection, error) {
+	created, createdArgs := periodCondition("created_at", filter.From, filter.To)
+
+	query := r.Builder.
+		Select(`id, tenant_id, pull_request_id, provider, action, COALESCE(reviewer_id, ''),
+			COALESCE(replaced_by, ''), COALESCE(error, ''), attempts, last_attempt_at, resolved_at, created_at`).
+		From("reconciliation_corrections").
+		Where("tenant_id = ?", tenant.ID(ctx)).
+		Where(created, createdArgs...).
+		OrderBy("id DESC").
+		Limit(filter.Limit)
+
+	if filter.PullRequestID != "" {
+		query = query.Where("pull_request_id = ?", filter.PullRequestID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.Cursor > 0 {
+		query = query.Where("id < ?", filter.Cursor)
+	}
+
+	return collect(func(fn func(models.ReconciliationCorrection) error) error {
+		return streamRows(ctx, r.Postgres, query, "reconciliation corrections", func(rows pgx.Rows, correction *models.ReconciliationCorrection) error {
+			return rows.Scan(
+				&correction.ID,
+				&correction.TenantID,
+				&correction.PullRequestID,
+				&correction.Provider,
+				&correction.Action,
+				&correction.ReviewerID,
+				&correction.ReplacedBy,
+				&correction.Error,
+				&correction.Attempts,
+				&correction.LastAttemptAt,
+				&correction.ResolvedAt,
+				&correction.CreatedAt,
+			)
+		}, fn)
+	})
+}
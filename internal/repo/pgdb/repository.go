@@ -0,0 +1,223 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/tenant"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/database/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+type RepositoryRepo struct {
+	*postgres.Postgres
+}
+
+func NewRepositoryRepo(pg *postgres.Postgres) *RepositoryRepo {
+	return &RepositoryRepo{pg}
+}
+
+func (r *RepositoryRepo) CreateRepository(ctx context.Context, repository models.Repository) (*models.Repository, error) {
+	tenantID := tenant.ID(ctx)
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := r.checkTeam(ctx, tx, tenantID, repository.TeamName); err != nil {
+		return nil, err
+	}
+
+	sql, args, _ := r.Builder.
+		Insert("repositories").
+		Columns("tenant_id, repository_name, team_name, reviewers_count, fallback_depth").
+		Values(
+			tenantID,
+			repository.RepositoryName,
+			nullIfEmpty(repository.TeamName),
+			repository.ReviewersCount,
+			repository.FallbackDepth,
+		).
+		Suffix("ON CONFLICT (tenant_id, repository_name) DO NOTHING RETURNING id, created_at").
+		ToSql()
+
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&repository.ID, &repository.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrAlreadyExists
+		}
+		return nil, fmt.Errorf("failed to insert repository: %w", err)
+	}
+
+	if err := writeAudit(ctx, r.Postgres, tx, models.AuditActionRepositoryCreate, models.AuditEntityRepository, repository.RepositoryName,
+		nil, repositoryAudit(repository),
+	); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &repository, nil
+}
+
+func (r *RepositoryRepo) GetRepositoryByName(ctx context.Context, repositoryName string) (*models.Repository, error) {
+	return r.getRepository(ctx, r.Pool, repositoryName, "")
+}
+
+func (r *RepositoryRepo) getRepository(ctx context.Context, q queryRower, repositoryName, lock string) (*models.Repository, error) {
+	sql, args, _ := r.Builder.
+		Select("id, repository_name, COALESCE(team_name, ''), reviewers_count, fallback_depth, created_at").
+		From("repositories").
+		Where("tenant_id = ? AND repository_name = ?", tenant.ID(ctx), repositoryName).
+		Suffix(lock).
+		ToSql()
+
+	return scanRepository(q.QueryRow(ctx, sql, args...))
+}
+
+// SetRepositoryOwner moves the repository to the team, empty teamName leaves
+// the repository without an owner.
+func (r *RepositoryRepo) SetRepositoryOwner(ctx context.Context, repositoryName, teamName string) (*models.Repository, error) {
+	tenantID := tenant.ID(ctx)
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := r.checkTeam(ctx, tx, tenantID, teamName); err != nil {
+		return nil, err
+	}
+
+	prev, err := r.getRepository(ctx, tx, repositoryName, "FOR UPDATE")
+	if err != nil {
+		return nil, err
+	}
+
+	sql, args, _ := r.Builder.
+		Update("repositories").
+		Set("team_name", nullIfEmpty(teamName)).
+		Where("id = ?", prev.ID).
+		Suffix("RETURNING id, repository_name, COALESCE(team_name, ''), reviewers_count, fallback_depth, created_at").
+		ToSql()
+
+	repository, err := scanRepository(tx.QueryRow(ctx, sql, args...))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeAudit(ctx, r.Postgres, tx, models.AuditActionRepositorySetOwner, models.AuditEntityRepository, repositoryName,
+		map[string]any{"team_name": nullIfEmpty(prev.TeamName)},
+		map[string]any{"team_name": nullIfEmpty(teamName)},
+	); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return repository, nil
+}
+
+func (r *RepositoryRepo) SetRepositorySettings(ctx context.Context, repositoryName string, reviewersCount, fallbackDepth models.NullInt) (*models.Repository, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	prev, err := r.getRepository(ctx, tx, repositoryName, "FOR UPDATE")
+	if err != nil {
+		return nil, err
+	}
+
+	update := r.Builder.Update("repositories")
+	if reviewersCount.Set {
+		update = update.Set("reviewers_count", reviewersCount.Value)
+	}
+	if fallbackDepth.Set {
+		update = update.Set("fallback_depth", fallbackDepth.Value)
+	}
+
+	sql, args, _ := update.
+		Where("id = ?", prev.ID).
+		Suffix("RETURNING id, repository_name, COALESCE(team_name, ''), reviewers_count, fallback_depth, created_at").
+		ToSql()
+
+	repository, err := scanRepository(tx.QueryRow(ctx, sql, args...))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeAudit(ctx, r.Postgres, tx, models.AuditActionRepositorySetSettings, models.AuditEntityRepository, repositoryName,
+		map[string]any{"reviewers_count": prev.ReviewersCount, "fallback_depth": prev.FallbackDepth},
+		map[string]any{"reviewers_count": repository.ReviewersCount, "fallback_depth": repository.FallbackDepth},
+	); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return repository, nil
+}
+
+// repositoryAudit is the state of the repository kept in the audit log.
+func repositoryAudit(repository models.Repository) map[string]any {
+	return map[string]any{
+		"team_name":       nullIfEmpty(repository.TeamName),
+		"reviewers_count": repository.ReviewersCount,
+		"fallback_depth":  repository.FallbackDepth,
+	}
+}
+
+func (r *RepositoryRepo) checkTeam(ctx context.Context, tx pgx.Tx, tenantID int, teamName string) error {
+	if teamName == "" {
+		return nil
+	}
+
+	sql, args, _ := r.Builder.
+		Select("1").
+		From("teams").
+		Where("tenant_id = ? AND team_name = ?", tenantID, teamName).
+		Suffix("FOR SHARE").
+		ToSql()
+
+	var exists int
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&exists); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repoerrs.ErrTeamNotFound
+		}
+		return fmt.Errorf("failed to check team existence: %w", err)
+	}
+
+	return nil
+}
+
+func scanRepository(row pgx.Row) (*models.Repository, error) {
+	var repository models.Repository
+	if err := row.Scan(
+		&repository.ID,
+		&repository.RepositoryName,
+		&repository.TeamName,
+		&repository.ReviewersCount,
+		&repository.FallbackDepth,
+		&repository.CreatedAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to scan repository: %w", err)
+	}
+
+	return &repository, nil
+}
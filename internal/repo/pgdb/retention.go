@@ -0,0 +1,148 @@
+package pgdb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/tenant"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/database/postgres"
+)
+
+// Columns of pull requests and reviewers kept in the archive tables.
+const (
+	archivedPullRequestColumns = `id, tenant_id, pull_request_id, pull_request_name, author_id, team_name, repository_name,
+		vcs_provider, status, needs_more_reviewers, created_at, merged_at`
+	archivedReviewerColumns = "id, tenant_id, pull_request_id, reviewer_id, assigned_at, reassigned_at, replaced_by, sla_breached_at"
+)
+
+type retainedPullRequest struct {
+	tenantID      int
+	pullRequestID string
+	mergedAt      time.Time
+}
+
+type RetentionRepo struct {
+	*postgres.Postgres
+}
+
+func NewRetentionRepo(pg *postgres.Postgres) *RetentionRepo {
+	return &RetentionRepo{pg}
+}
+
+// RetainMergedPullRequests moves up to limit pull requests of all tenants
+// merged more than mergedDays ago with their reviewers out of the live tables,
+// into the archive tables or, without archive, nowhere. The cutoff is taken
+// by the database clock, as merged_at is set. Returns the number of pull
+// requests moved.
+func (r *RetentionRepo) RetainMergedPullRequests(ctx context.Context, mergedDays int, archive bool, limit uint64) (int64, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	sql, args, _ := r.Builder.
+		Select("id, tenant_id, pull_request_id, merged_at").
+		From("pull_requests").
+		Where("status = ? AND merged_at < NOW() - make_interval(days => ?)", MergedStatus, mergedDays).
+		OrderBy("merged_at").
+		Limit(limit).
+		Suffix("FOR UPDATE SKIP LOCKED").
+		ToSql()
+
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to select retained pull requests: %w", err)
+	}
+
+	var (
+		ids      []int
+		retained []retainedPullRequest
+	)
+	for rows.Next() {
+		var (
+			id int
+			pr retainedPullRequest
+		)
+		if err := rows.Scan(&id, &pr.tenantID, &pr.pullRequestID, &pr.mergedAt); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan retained pull request: %w", err)
+		}
+
+		ids = append(ids, id)
+		retained = append(retained, pr)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to scan retained pull requests: %w", err)
+	}
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	if archive {
+		// nested selects must keep "?" placeholders, the outer builder numbers them
+		sql, args, _ = r.Builder.
+			Insert("pull_requests_archive").
+			Columns(archivedPullRequestColumns).
+			Select(squirrel.
+				Select(archivedPullRequestColumns).
+				From("pull_requests").
+				Where("id = ANY(?)", ids),
+			).
+			ToSql()
+
+		if _, err := tx.Exec(ctx, sql, args...); err != nil {
+			return 0, fmt.Errorf("failed to archive pull requests: %w", err)
+		}
+
+		sql, args, _ = r.Builder.
+			Insert("pull_request_reviewers_archive").
+			Columns(archivedReviewerColumns).
+			Select(squirrel.
+				Select(archivedReviewerColumns).
+				From("pull_request_reviewers").
+				Where(`(tenant_id, pull_request_id) IN (
+					SELECT tenant_id, pull_request_id FROM pull_requests WHERE id = ANY(?)
+				)`, ids),
+			).
+			ToSql()
+
+		if _, err := tx.Exec(ctx, sql, args...); err != nil {
+			return 0, fmt.Errorf("failed to archive pull request reviewers: %w", err)
+		}
+	}
+
+	// reviewers are deleted by the cascade
+	sql, args, _ = r.Builder.
+		Delete("pull_requests").
+		Where("id = ANY(?)", ids).
+		ToSql()
+
+	tag, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete retained pull requests: %w", err)
+	}
+
+	action, after := models.AuditActionPullRequestDelete, any(nil)
+	if archive {
+		action, after = models.AuditActionPullRequestArchive, map[string]any{"archived": true}
+	}
+
+	for _, pr := range retained {
+		if err := writeAudit(tenant.WithID(ctx, pr.tenantID), r.Postgres, tx, action, models.AuditEntityPullRequest, pr.pullRequestID,
+			map[string]any{"status": MergedStatus, "merged_at": pr.mergedAt}, after,
+		); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
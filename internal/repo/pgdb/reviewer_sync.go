@@ -0,0 +1,242 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/tenant"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/database/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+// reviewerLogin is the login of the reviewer "prr.reviewer_id" in the provider
+// of the pull request "pr", NULL when the user has no login there. Such
+// reviewers are not synced: a user id may be the login of someone else.
+const reviewerLogin = `(SELECT MIN(vi.login) FROM vcs_identities vi
+	WHERE vi.tenant_id = prr.tenant_id AND vi.provider = pr.vcs_provider AND vi.user_id = prr.reviewer_id)`
+
+type ReviewerSyncRepo struct {
+	*postgres.Postgres
+}
+
+func NewReviewerSyncRepo(pg *postgres.Postgres) *ReviewerSyncRepo {
+	return &ReviewerSyncRepo{pg}
+}
+
+// RequestReviewerSync queues requesting current reviewers of the pull request
+// in its provider again, e.g. after the sync failed.
+func (r *ReviewerSyncRepo) RequestReviewerSync(ctx context.Context, prID string) (*models.ReviewerSync, error) {
+	tenantID := tenant.ID(ctx)
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	sql, args, _ := r.Builder.
+		Select("1").
+		From("pull_requests").
+		Where("tenant_id = ? AND pull_request_id = ?", tenantID, prID).
+		ToSql()
+
+	var exists int
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&exists); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to check pr existence: %w", err)
+	}
+
+	queued, err := requestReviewerSync(ctx, r.Postgres, tx, prID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !queued {
+		return nil, repoerrs.ErrReviewerSyncDisabled
+	}
+
+	sync, err := getReviewerSync(ctx, r.Postgres, tx, tenantID, prID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return sync, nil
+}
+
+// ClaimReviewerSyncs leases due syncs for the time of running them, so other
+// instances skip them. Current reviewers are to be requested and reviewers
+// reassigned away are to be removed.
+func (r *ReviewerSyncRepo) ClaimReviewerSyncs(ctx context.Context, limit uint64, lease time.Duration) ([]models.ReviewerSync, error) {
+	// nested selects must keep "?" placeholders, the outer builder numbers them
+	requested, requestedArgs, _ := squirrel.
+		Select(reviewerLogin).
+		From("pull_request_reviewers prr").
+		Where("prr.tenant_id = pr.tenant_id AND prr.pull_request_id = pr.pull_request_id AND prr.reassigned_at IS NULL").
+		Where(reviewerLogin+" IS NOT NULL").
+		OrderBy("prr.assigned_at", "prr.id").
+		ToSql()
+
+	removed, removedArgs, _ := squirrel.
+		Select("DISTINCT " + reviewerLogin).
+		From("pull_request_reviewers prr").
+		Where(`prr.tenant_id = pr.tenant_id AND prr.pull_request_id = pr.pull_request_id AND prr.reassigned_at IS NOT NULL
+			AND NOT EXISTS (
+				SELECT 1 FROM pull_request_reviewers cur
+				WHERE cur.tenant_id = prr.tenant_id AND cur.pull_request_id = prr.pull_request_id
+					AND cur.reviewer_id = prr.reviewer_id AND cur.reassigned_at IS NULL
+			)`).
+		Where(reviewerLogin + " IS NOT NULL").
+		ToSql()
+
+	sql, args, _ := r.Builder.
+		Update("pull_requests pr").
+		Set("reviewer_sync_next_attempt_at", squirrel.Expr("NOW() + make_interval(secs => ?)", lease.Seconds())).
+		From("vcs_integrations i").
+		Where(`pr.id IN (
+			SELECT id FROM pull_requests
+			WHERE reviewer_sync_status = ? AND reviewer_sync_next_attempt_at <= NOW()
+			ORDER BY reviewer_sync_next_attempt_at LIMIT ? FOR UPDATE SKIP LOCKED
+		)`, models.ReviewerSyncPending, limit).
+		Where("i.tenant_id = pr.tenant_id AND i.provider = pr.vcs_provider").
+		Suffix(`RETURNING pr.id, pr.tenant_id, pr.pull_request_id, pr.vcs_provider, pr.reviewer_sync_status,
+			pr.reviewer_sync_version, pr.reviewer_sync_attempts, COALESCE(i.api_token, ''),
+			ARRAY(`+requested+`), ARRAY(`+removed+`)`, append(requestedArgs, removedArgs...)...).
+		ToSql()
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim reviewer syncs: %w", err)
+	}
+	defer rows.Close()
+
+	syncs := []models.ReviewerSync{}
+	for rows.Next() {
+		var sync models.ReviewerSync
+		if err := rows.Scan(
+			&sync.ID,
+			&sync.TenantID,
+			&sync.PullRequestID,
+			&sync.Provider,
+			&sync.Status,
+			&sync.Version,
+			&sync.Attempts,
+			&sync.APIToken,
+			&sync.RequestReviewers,
+			&sync.RemovedReviewers,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan reviewer sync: %w", err)
+		}
+
+		syncs = append(syncs, sync)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read reviewer syncs: %w", err)
+	}
+
+	return syncs, nil
+}
+
+// FinishReviewerSync records the attempt. Nil retryAfter of a failed attempt
+// fails the sync. Attempts of an outdated version are not recorded, reviewers
+// changed meanwhile and the new version is due already.
+func (r *ReviewerSyncRepo) FinishReviewerSync(ctx context.Context, id, version int, synced bool, lastError string, retryAfter *time.Duration) error {
+	update := r.Builder.
+		Update("pull_requests").
+		Set("reviewer_sync_attempts", squirrel.Expr("reviewer_sync_attempts + 1")).
+		Set("reviewer_sync_error", nullIfEmpty(lastError)).
+		Where("id = ? AND reviewer_sync_version = ? AND reviewer_sync_status = ?", id, version, models.ReviewerSyncPending)
+
+	switch {
+	case synced:
+		update = update.
+			Set("reviewer_sync_status", models.ReviewerSyncSynced).
+			Set("reviewer_sync_next_attempt_at", nil).
+			Set("reviewer_synced_at", squirrel.Expr("NOW()"))
+	case retryAfter != nil:
+		update = update.Set("reviewer_sync_next_attempt_at", squirrel.Expr("NOW() + make_interval(secs => ?)", retryAfter.Seconds()))
+	default:
+		update = update.
+			Set("reviewer_sync_status", models.ReviewerSyncFailed).
+			Set("reviewer_sync_next_attempt_at", nil)
+	}
+
+	sql, args, _ := update.ToSql()
+	if _, err := r.Pool.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("failed to update reviewer sync: %w", err)
+	}
+
+	return nil
+}
+
+// requestReviewerSync queues requesting reviewers of the pull request within
+// the transaction that changed them. Only GitHub pull requests of tenants with
+// an api token are synced, it reports whether the sync was queued.
+func requestReviewerSync(ctx context.Context, pg *postgres.Postgres, tx pgx.Tx, prID string) (bool, error) {
+	tenantID := tenant.ID(ctx)
+
+	sql, args, _ := pg.Builder.
+		Update("pull_requests").
+		Set("reviewer_sync_status", models.ReviewerSyncPending).
+		Set("reviewer_sync_version", squirrel.Expr("reviewer_sync_version + 1")).
+		Set("reviewer_sync_attempts", 0).
+		Set("reviewer_sync_next_attempt_at", squirrel.Expr("NOW()")).
+		Set("reviewer_sync_error", nil).
+		Where("tenant_id = ? AND pull_request_id = ? AND vcs_provider = ?", tenantID, prID, models.VCSProviderGitHub).
+		Where("EXISTS (SELECT 1 FROM vcs_integrations WHERE tenant_id = ? AND provider = ? AND api_token IS NOT NULL)", tenantID, models.VCSProviderGitHub).
+		ToSql()
+
+	tag, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return false, fmt.Errorf("failed to queue reviewer sync: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// getReviewerSync returns the sync state of the pull request, nil when its
+// reviewers were never synced.
+func getReviewerSync(ctx context.Context, pg *postgres.Postgres, q queryRower, tenantID int, prID string) (*models.ReviewerSync, error) {
+	sql, args, _ := pg.Builder.
+		Select(`id, tenant_id, pull_request_id, COALESCE(vcs_provider, ''), COALESCE(reviewer_sync_status, ''),
+			reviewer_sync_version, reviewer_sync_attempts, reviewer_sync_next_attempt_at,
+			COALESCE(reviewer_sync_error, ''), reviewer_synced_at`).
+		From("pull_requests").
+		Where("tenant_id = ? AND pull_request_id = ?", tenantID, prID).
+		ToSql()
+
+	var sync models.ReviewerSync
+	if err := q.QueryRow(ctx, sql, args...).Scan(
+		&sync.ID,
+		&sync.TenantID,
+		&sync.PullRequestID,
+		&sync.Provider,
+		&sync.Status,
+		&sync.Version,
+		&sync.Attempts,
+		&sync.NextAttemptAt,
+		&sync.LastError,
+		&sync.SyncedAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get reviewer sync: %w", err)
+	}
+
+	if sync.Status == "" {
+		return nil, nil
+	}
+
+	return &sync, nil
+}
@@ -0,0 +1,502 @@
+package pgdb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/tenant"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/database/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+// maxInsertArgs keeps multi-row inserts of a restore below the limit of
+// query parameters.
+const maxInsertArgs = 60000
+
+type SnapshotRepo struct {
+	*postgres.Postgres
+}
+
+func NewSnapshotRepo(pg *postgres.Postgres) *SnapshotRepo {
+	return &SnapshotRepo{pg}
+}
+
+// ExportSnapshot reads the data of the tenant in one repeatable read
+// transaction, so the snapshot is consistent under concurrent changes.
+func (r *SnapshotRepo) ExportSnapshot(ctx context.Context) (*models.Snapshot, error) {
+	tenantID := tenant.ID(ctx)
+
+	tx, err := r.Pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var snapshot models.Snapshot
+
+	snapshot.Teams, err = selectAll(ctx, tx, r.Builder.
+		Select("t.team_name, COALESCE(p.team_name, ''), t.reviewers_count, t.fallback_depth").
+		Column(reviewSLASeconds("t.review_sla")).
+		Columns("t.is_archived, t.archived_at").
+		From("teams t").
+		LeftJoin("teams p ON p.id = t.parent_id").
+		Where("t.tenant_id = ?", tenantID).
+		OrderBy("t.id"),
+		"teams",
+		func(row pgx.CollectableRow, t *models.SnapshotTeam) error {
+			return row.Scan(&t.TeamName, &t.ParentTeamName, &t.ReviewersCount, &t.FallbackDepth, &t.ReviewSLASeconds, &t.IsArchived, &t.ArchivedAt)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.Users, err = selectAll(ctx, tx, r.Builder.
+		Select("user_id, username, is_active, COALESCE(email, ''), away_until").
+		From("users").
+		Where("tenant_id = ?", tenantID).
+		OrderBy("id"),
+		"users",
+		func(row pgx.CollectableRow, u *models.SnapshotUser) error {
+			return row.Scan(&u.UserID, &u.Username, &u.IsActive, &u.Email, &u.AwayUntil)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.TeamMembers, err = selectAll(ctx, tx, r.Builder.
+		Select("user_id, team_name, is_primary").
+		From("team_members").
+		Where("tenant_id = ?", tenantID).
+		OrderBy("team_name, user_id"),
+		"team members",
+		func(row pgx.CollectableRow, m *models.SnapshotTeamMember) error {
+			return row.Scan(&m.UserID, &m.TeamName, &m.IsPrimary)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.Repositories, err = selectAll(ctx, tx, r.Builder.
+		Select("repository_name, COALESCE(team_name, ''), reviewers_count, fallback_depth, created_at").
+		From("repositories").
+		Where("tenant_id = ?", tenantID).
+		OrderBy("id"),
+		"repositories",
+		func(row pgx.CollectableRow, repo *models.SnapshotRepository) error {
+			return row.Scan(&repo.RepositoryName, &repo.TeamName, &repo.ReviewersCount, &repo.FallbackDepth, &repo.CreatedAt)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.PullRequests, err = selectAll(ctx, tx, r.Builder.
+		Select(`pull_request_id, pull_request_name, author_id, COALESCE(team_name, ''), COALESCE(repository_name, ''),
+			COALESCE(vcs_provider, ''), status, needs_more_reviewers, created_at, merged_at`).
+		From("pull_requests").
+		Where("tenant_id = ?", tenantID).
+		OrderBy("id"),
+		"pull requests",
+		func(row pgx.CollectableRow, pr *models.SnapshotPullRequest) error {
+			return row.Scan(
+				&pr.PullRequestID,
+				&pr.PullRequestName,
+				&pr.AuthorID,
+				&pr.TeamName,
+				&pr.RepositoryName,
+				&pr.VCSProvider,
+				&pr.Status,
+				&pr.NeedsMoreReviewers,
+				&pr.CreatedAt,
+				&pr.MergedAt,
+			)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.Reviewers, err = selectAll(ctx, tx, r.Builder.
+		Select("pull_request_id, reviewer_id, assigned_at, reassigned_at, COALESCE(replaced_by, ''), sla_breached_at").
+		From("pull_request_reviewers").
+		Where("tenant_id = ?", tenantID).
+		OrderBy("id"),
+		"reviewers",
+		func(row pgx.CollectableRow, rv *models.SnapshotReviewer) error {
+			return row.Scan(&rv.PullRequestID, &rv.ReviewerID, &rv.AssignedAt, &rv.ReassignedAt, &rv.ReplacedBy, &rv.SLABreachedAt)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.NotificationPreferences, err = selectAll(ctx, tx, r.Builder.
+		Select(`user_id, channels, event_types, timezone,
+			COALESCE(to_char(quiet_hours_start, 'HH24:MI'), ''), COALESCE(to_char(quiet_hours_end, 'HH24:MI'), '')`).
+		From("notification_preferences").
+		Where("tenant_id = ?", tenantID).
+		OrderBy("user_id"),
+		"notification preferences",
+		func(row pgx.CollectableRow, p *models.SnapshotNotificationPreference) error {
+			return row.Scan(&p.UserID, &p.Channels, &p.EventTypes, &p.Timezone, &p.QuietHoursStart, &p.QuietHoursEnd)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.VCSIdentities, err = selectAll(ctx, tx, r.Builder.
+		Select("provider, login, COALESCE(account_id, ''), user_id").
+		From("vcs_identities").
+		Where("tenant_id = ?", tenantID).
+		OrderBy("provider, login"),
+		"vcs identities",
+		func(row pgx.CollectableRow, i *models.SnapshotVCSIdentity) error {
+			return row.Scan(&i.Provider, &i.Login, &i.AccountID, &i.UserID)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.SlackIdentities, err = selectAll(ctx, tx, r.Builder.
+		Select("slack_user_id, user_id, is_admin").
+		From("slack_identities").
+		Where("tenant_id = ?", tenantID).
+		OrderBy("slack_user_id"),
+		"slack identities",
+		func(row pgx.CollectableRow, i *models.SnapshotSlackIdentity) error {
+			return row.Scan(&i.SlackUserID, &i.UserID, &i.IsAdmin)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.ArchivedPullRequests, err = selectAll(ctx, tx, r.Builder.
+		Select(`pull_request_id, pull_request_name, author_id, COALESCE(team_name, ''), COALESCE(repository_name, ''),
+			COALESCE(vcs_provider, ''), status, needs_more_reviewers, created_at, merged_at, archived_at`).
+		From("pull_requests_archive").
+		Where("tenant_id = ?", tenantID).
+		OrderBy("id"),
+		"archived pull requests",
+		func(row pgx.CollectableRow, pr *models.SnapshotArchivedPullRequest) error {
+			return row.Scan(
+				&pr.PullRequestID,
+				&pr.PullRequestName,
+				&pr.AuthorID,
+				&pr.TeamName,
+				&pr.RepositoryName,
+				&pr.VCSProvider,
+				&pr.Status,
+				&pr.NeedsMoreReviewers,
+				&pr.CreatedAt,
+				&pr.MergedAt,
+				&pr.ArchivedAt,
+			)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.ArchivedReviewers, err = selectAll(ctx, tx, r.Builder.
+		Select("pull_request_id, reviewer_id, assigned_at, reassigned_at, COALESCE(replaced_by, ''), sla_breached_at").
+		From("pull_request_reviewers_archive").
+		Where("tenant_id = ?", tenantID).
+		OrderBy("id"),
+		"archived reviewers",
+		func(row pgx.CollectableRow, rv *models.SnapshotReviewer) error {
+			return row.Scan(&rv.PullRequestID, &rv.ReviewerID, &rv.AssignedAt, &rv.ReassignedAt, &rv.ReplacedBy, &rv.SLABreachedAt)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &snapshot, nil
+}
+
+// RestoreSnapshot loads a checked snapshot into the tenant in one transaction.
+// The tenant must have no teams, users, repositories and pull requests yet.
+// No events are raised for the restored data.
+func (r *SnapshotRepo) RestoreSnapshot(ctx context.Context, snapshot models.Snapshot) error {
+	tenantID := tenant.ID(ctx)
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// serializes restores of the tenant, so two of them cannot both see it empty
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext('snapshot_restore'), $1)", tenantID); err != nil {
+		return fmt.Errorf("failed to lock tenant restore: %w", err)
+	}
+
+	sql, args, _ := r.Builder.
+		Select().
+		Column("EXISTS (SELECT 1 FROM teams WHERE tenant_id = ?)", tenantID).
+		Column("EXISTS (SELECT 1 FROM users WHERE tenant_id = ?)", tenantID).
+		Column("EXISTS (SELECT 1 FROM repositories WHERE tenant_id = ?)", tenantID).
+		Column("EXISTS (SELECT 1 FROM all_pull_requests WHERE tenant_id = ?)", tenantID).
+		ToSql()
+
+	var teams, users, repositories, pullRequests bool
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&teams, &users, &repositories, &pullRequests); err != nil {
+		return fmt.Errorf("failed to check tenant data: %w", err)
+	}
+
+	if teams || users || repositories || pullRequests {
+		return repoerrs.ErrTenantNotEmpty
+	}
+
+	rows := make([][]any, 0, len(snapshot.Teams))
+	var teamNames, parentNames []string
+	for _, t := range snapshot.Teams {
+		var sla any
+		if t.ReviewSLASeconds != nil {
+			sla = squirrel.Expr("make_interval(secs => ?)", *t.ReviewSLASeconds)
+		}
+
+		rows = append(rows, []any{tenantID, t.TeamName, t.ReviewersCount, t.FallbackDepth, sla, t.IsArchived, t.ArchivedAt})
+
+		if t.ParentTeamName != "" {
+			teamNames = append(teamNames, t.TeamName)
+			parentNames = append(parentNames, t.ParentTeamName)
+		}
+	}
+	if err := r.insertAll(ctx, tx, "teams", "tenant_id, team_name, reviewers_count, fallback_depth, review_sla, is_archived, archived_at", rows); err != nil {
+		return err
+	}
+
+	if len(teamNames) > 0 {
+		sql, args, _ = r.Builder.
+			Update("teams t").
+			Set("parent_id", squirrel.Expr(`(
+				SELECT p.id FROM teams p
+				JOIN unnest(?::TEXT[], ?::TEXT[]) AS h(team_name, parent_name) ON p.team_name = h.parent_name
+				WHERE p.tenant_id = t.tenant_id AND h.team_name = t.team_name
+			)`, teamNames, parentNames)).
+			Where("t.tenant_id = ? AND t.team_name = ANY(?)", tenantID, teamNames).
+			ToSql()
+
+		if _, err := tx.Exec(ctx, sql, args...); err != nil {
+			return fmt.Errorf("failed to restore teams hierarchy: %w", err)
+		}
+	}
+
+	rows = rows[:0]
+	for _, u := range snapshot.Users {
+		rows = append(rows, []any{tenantID, u.UserID, u.Username, u.IsActive, nullIfEmpty(u.Email), u.AwayUntil})
+	}
+	if err := r.insertAll(ctx, tx, "users", "tenant_id, user_id, username, is_active, email, away_until", rows); err != nil {
+		return err
+	}
+
+	rows = rows[:0]
+	for _, m := range snapshot.TeamMembers {
+		rows = append(rows, []any{tenantID, m.UserID, m.TeamName, m.IsPrimary})
+	}
+	if err := r.insertAll(ctx, tx, "team_members", "tenant_id, user_id, team_name, is_primary", rows); err != nil {
+		return err
+	}
+
+	rows = rows[:0]
+	for _, repo := range snapshot.Repositories {
+		rows = append(rows, []any{tenantID, repo.RepositoryName, nullIfEmpty(repo.TeamName), repo.ReviewersCount, repo.FallbackDepth, repo.CreatedAt})
+	}
+	if err := r.insertAll(ctx, tx, "repositories", "tenant_id, repository_name, team_name, reviewers_count, fallback_depth, created_at", rows); err != nil {
+		return err
+	}
+
+	rows = rows[:0]
+	for _, pr := range snapshot.PullRequests {
+		rows = append(rows, []any{
+			tenantID,
+			pr.PullRequestID,
+			pr.PullRequestName,
+			pr.AuthorID,
+			nullIfEmpty(pr.TeamName),
+			nullIfEmpty(pr.RepositoryName),
+			nullIfEmpty(pr.VCSProvider),
+			pr.Status,
+			pr.NeedsMoreReviewers,
+			pr.CreatedAt,
+			pr.MergedAt,
+		})
+	}
+	if err := r.insertAll(ctx, tx, "pull_requests",
+		"tenant_id, pull_request_id, pull_request_name, author_id, team_name, repository_name, vcs_provider, status, needs_more_reviewers, created_at, merged_at",
+		rows,
+	); err != nil {
+		return err
+	}
+
+	rows = rows[:0]
+	for _, rv := range snapshot.Reviewers {
+		rows = append(rows, []any{tenantID, rv.PullRequestID, rv.ReviewerID, rv.AssignedAt, rv.ReassignedAt, nullIfEmpty(rv.ReplacedBy), rv.SLABreachedAt})
+	}
+	if err := r.insertAll(ctx, tx, "pull_request_reviewers",
+		"tenant_id, pull_request_id, reviewer_id, assigned_at, reassigned_at, replaced_by, sla_breached_at",
+		rows,
+	); err != nil {
+		return err
+	}
+
+	rows = rows[:0]
+	for _, p := range snapshot.NotificationPreferences {
+		rows = append(rows, []any{
+			tenantID,
+			p.UserID,
+			p.Channels,
+			p.EventTypes,
+			p.Timezone,
+			squirrel.Expr("?::TIME", nullIfEmpty(p.QuietHoursStart)),
+			squirrel.Expr("?::TIME", nullIfEmpty(p.QuietHoursEnd)),
+		})
+	}
+	if err := r.insertAll(ctx, tx, "notification_preferences",
+		"tenant_id, user_id, channels, event_types, timezone, quiet_hours_start, quiet_hours_end",
+		rows,
+	); err != nil {
+		return err
+	}
+
+	rows = rows[:0]
+	for _, i := range snapshot.VCSIdentities {
+		rows = append(rows, []any{tenantID, i.Provider, i.Login, nullIfEmpty(i.AccountID), i.UserID})
+	}
+	if err := r.insertAll(ctx, tx, "vcs_identities", "tenant_id, provider, login, account_id, user_id", rows); err != nil {
+		return err
+	}
+
+	rows = rows[:0]
+	for _, i := range snapshot.SlackIdentities {
+		rows = append(rows, []any{tenantID, i.SlackUserID, i.UserID, i.IsAdmin})
+	}
+	if err := r.insertAll(ctx, tx, "slack_identities", "tenant_id, slack_user_id, user_id, is_admin", rows); err != nil {
+		return err
+	}
+
+	// archived rows take ids from the sequences of the live tables, as if
+	// archived after the restore
+	rows = rows[:0]
+	for _, pr := range snapshot.ArchivedPullRequests {
+		rows = append(rows, []any{
+			squirrel.Expr("nextval(pg_get_serial_sequence('pull_requests', 'id'))"),
+			tenantID,
+			pr.PullRequestID,
+			pr.PullRequestName,
+			pr.AuthorID,
+			nullIfEmpty(pr.TeamName),
+			nullIfEmpty(pr.RepositoryName),
+			nullIfEmpty(pr.VCSProvider),
+			pr.Status,
+			pr.NeedsMoreReviewers,
+			pr.CreatedAt,
+			pr.MergedAt,
+			pr.ArchivedAt,
+		})
+	}
+	if err := r.insertAll(ctx, tx, "pull_requests_archive", archivedPullRequestColumns+", archived_at", rows); err != nil {
+		return err
+	}
+
+	rows = rows[:0]
+	for _, rv := range snapshot.ArchivedReviewers {
+		rows = append(rows, []any{
+			squirrel.Expr("nextval(pg_get_serial_sequence('pull_request_reviewers', 'id'))"),
+			tenantID,
+			rv.PullRequestID,
+			rv.ReviewerID,
+			rv.AssignedAt,
+			rv.ReassignedAt,
+			nullIfEmpty(rv.ReplacedBy),
+			rv.SLABreachedAt,
+		})
+	}
+	if err := r.insertAll(ctx, tx, "pull_request_reviewers_archive", archivedReviewerColumns, rows); err != nil {
+		return err
+	}
+
+	var tenantName string
+	if err := tx.QueryRow(ctx, "SELECT tenant_name FROM tenants WHERE id = $1", tenantID).Scan(&tenantName); err != nil {
+		return fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	if err := writeAudit(ctx, r.Postgres, tx, models.AuditActionTenantRestoreSnapshot, models.AuditEntityTenant, tenantName,
+		nil, map[string]any{
+			"teams":                  len(snapshot.Teams),
+			"users":                  len(snapshot.Users),
+			"repositories":           len(snapshot.Repositories),
+			"pull_requests":          len(snapshot.PullRequests),
+			"archived_pull_requests": len(snapshot.ArchivedPullRequests),
+		},
+	); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// insertAll inserts rows in as few statements as the limit of query
+// parameters allows.
+func (r *SnapshotRepo) insertAll(ctx context.Context, tx pgx.Tx, table, columns string, rows [][]any) error {
+	batch := max(1, maxInsertArgs/len(strings.Split(columns, ",")))
+
+	for start := 0; start < len(rows); start += batch {
+		insert := r.Builder.
+			Insert(table).
+			Columns(columns)
+
+		for _, row := range rows[start:min(start+batch, len(rows))] {
+			insert = insert.Values(row...)
+		}
+
+		sql, args, _ := insert.ToSql()
+		if _, err := tx.Exec(ctx, sql, args...); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", table, err)
+		}
+	}
+
+	return nil
+}
+
+// selectAll runs the query in the transaction and scans all of its rows.
+func selectAll[T any](
+	ctx context.Context,
+	tx pgx.Tx,
+	query squirrel.SelectBuilder,
+	name string,
+	scan func(row pgx.CollectableRow, dest *T) error,
+) ([]T, error) {
+	sql, args, _ := query.ToSql()
+
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", name, err)
+	}
+
+	items, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (T, error) {
+		var dest T
+		err := scan(row, &dest)
+		return dest, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", name, err)
+	}
+
+	return items, nil
+}
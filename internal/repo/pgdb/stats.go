@@ -0,0 +1,455 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/tenant"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/database/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+// StatsRepo reads live and archived pull requests together, through the
+// all_pull_requests and all_pull_request_reviewers views.
+type StatsRepo struct {
+	*postgres.Postgres
+}
+
+func NewStatsRepo(pg *postgres.Postgres) *StatsRepo {
+	return &StatsRepo{pg}
+}
+
+func (r *StatsRepo) GetUserStats(ctx context.Context, filter models.StatsFilter) ([]models.ReviewStats, error) {
+	return collect(func(fn func(models.ReviewStats) error) error {
+		return r.StreamUserStats(ctx, filter, fn)
+	})
+}
+
+// StreamUserStats calls fn for every row of the user stats without loading
+// them all into memory.
+func (r *StatsRepo) StreamUserStats(ctx context.Context, filter models.StatsFilter, fn func(models.ReviewStats) error) error {
+	tenantID := tenant.ID(ctx)
+
+	if err := r.checkFilter(ctx, filter); err != nil {
+		return err
+	}
+
+	query := r.Builder.
+		Select("u.user_id, u.username").
+		From("users u").
+		LeftJoin("all_pull_request_reviewers prr ON prr.tenant_id = u.tenant_id AND prr.reviewer_id = u.user_id").
+		LeftJoin("all_pull_requests pr ON pr.tenant_id = prr.tenant_id AND pr.pull_request_id = prr.pull_request_id").
+		Where("u.tenant_id = ?", tenantID).
+		GroupBy("u.id").
+		OrderBy("u.user_id")
+
+	query = statsColumns(query, filter)
+
+	if filter.UserID != "" {
+		query = query.Where("u.user_id = ?", filter.UserID)
+	}
+	if filter.TeamName != "" {
+		query = query.Where("u.user_id IN (SELECT user_id FROM team_members WHERE tenant_id = ? AND team_name = ?)", tenantID, filter.TeamName)
+	}
+
+	return streamRows(ctx, r.Postgres, query, "user stats", func(rows pgx.Rows, s *models.ReviewStats) error {
+		return rows.Scan(
+			&s.UserID,
+			&s.Username,
+			&s.Assigned,
+			&s.ReassignedAway,
+			&s.Completed,
+			&s.OpenLoad,
+		)
+	}, fn)
+}
+
+// GetTeamStats counts reviews of pull requests by the team they were assigned
+// from. With IncludeSubteams numbers of every team include its subtree.
+func (r *StatsRepo) GetTeamStats(ctx context.Context, filter models.StatsFilter) ([]models.ReviewStats, error) {
+	return collect(func(fn func(models.ReviewStats) error) error {
+		return r.StreamTeamStats(ctx, filter, fn)
+	})
+}
+
+func (r *StatsRepo) StreamTeamStats(ctx context.Context, filter models.StatsFilter, fn func(models.ReviewStats) error) error {
+	tenantID := tenant.ID(ctx)
+
+	filter.UserID = ""
+	if err := r.checkFilter(ctx, filter); err != nil {
+		return err
+	}
+
+	depth := 0
+	if filter.IncludeSubteams {
+		depth = maxHierarchyDepth
+	}
+
+	query := r.Builder.
+		Select("root.team_name").
+		Prefix(`WITH RECURSIVE closure AS (
+			SELECT id AS root_id, id, 0 AS depth FROM teams WHERE tenant_id = ?
+			UNION ALL
+			SELECT c.root_id, t.id, c.depth + 1
+			FROM teams t
+			JOIN closure c ON t.parent_id = c.id
+			WHERE c.depth < ?
+		)`, tenantID, depth).
+		From("closure c").
+		Join("teams root ON root.id = c.root_id").
+		Join("teams t ON t.id = c.id").
+		LeftJoin("all_pull_requests pr ON pr.tenant_id = t.tenant_id AND pr.team_name = t.team_name").
+		LeftJoin("all_pull_request_reviewers prr ON prr.tenant_id = pr.tenant_id AND prr.pull_request_id = pr.pull_request_id").
+		GroupBy("root.team_name").
+		OrderBy("root.team_name")
+
+	query = statsColumns(query, filter)
+
+	if filter.TeamName != "" {
+		query = query.Where("root.team_name = ?", filter.TeamName)
+	}
+
+	return streamRows(ctx, r.Postgres, query, "team stats", func(rows pgx.Rows, s *models.ReviewStats) error {
+		return rows.Scan(
+			&s.TeamName,
+			&s.Assigned,
+			&s.ReassignedAway,
+			&s.Completed,
+			&s.OpenLoad,
+		)
+	}, fn)
+}
+
+// GetTeamFlowStats measures pull requests of every team: time from creation to
+// merge and to the first reviewer reassignment.
+func (r *StatsRepo) GetTeamFlowStats(ctx context.Context, filter models.StatsFilter) ([]models.FlowStats, error) {
+	return collect(func(fn func(models.FlowStats) error) error {
+		return r.StreamTeamFlowStats(ctx, filter, fn)
+	})
+}
+
+func (r *StatsRepo) StreamTeamFlowStats(ctx context.Context, filter models.StatsFilter, fn func(models.FlowStats) error) error {
+	tenantID := tenant.ID(ctx)
+
+	filter.UserID = ""
+	if err := r.checkFilter(ctx, filter); err != nil {
+		return err
+	}
+
+	merged, mergedArgs := periodCondition("pr.merged_at", filter.From, filter.To)
+	reassigned, reassignedArgs := periodCondition("fr.reassigned_at", filter.From, filter.To)
+
+	query := r.Builder.
+		Select("pr.team_name").
+		Prefix(`WITH first_reassign AS (
+			SELECT pull_request_id, MIN(reassigned_at) AS reassigned_at
+			FROM all_pull_request_reviewers
+			WHERE tenant_id = ? AND reassigned_at IS NOT NULL
+			GROUP BY pull_request_id
+		)`, tenantID).
+		From("all_pull_requests pr").
+		LeftJoin("first_reassign fr ON fr.pull_request_id = pr.pull_request_id").
+		Where("pr.tenant_id = ? AND pr.team_name IS NOT NULL", tenantID).
+		GroupBy("pr.team_name").
+		OrderBy("pr.team_name")
+
+	query = flowColumns(query,
+		"pr.merged_at - pr.created_at", "pr.merged_at IS NOT NULL AND "+merged, mergedArgs,
+		"fr.reassigned_at - pr.created_at", "fr.reassigned_at IS NOT NULL AND "+reassigned, reassignedArgs,
+	)
+
+	if filter.TeamName != "" {
+		query = query.Where("pr.team_name = ?", filter.TeamName)
+	}
+
+	return streamRows(ctx, r.Postgres, query, "team flow stats", func(rows pgx.Rows, s *models.FlowStats) error {
+		return rows.Scan(append([]any{&s.TeamName}, flowStatsDest(s)...)...)
+	}, fn)
+}
+
+// GetUserFlowStats measures assignments of every reviewer: time from the
+// assignment to merge and to the reassignment away from the reviewer.
+func (r *StatsRepo) GetUserFlowStats(ctx context.Context, filter models.StatsFilter) ([]models.FlowStats, error) {
+	return collect(func(fn func(models.FlowStats) error) error {
+		return r.StreamUserFlowStats(ctx, filter, fn)
+	})
+}
+
+func (r *StatsRepo) StreamUserFlowStats(ctx context.Context, filter models.StatsFilter, fn func(models.FlowStats) error) error {
+	tenantID := tenant.ID(ctx)
+
+	if err := r.checkFilter(ctx, filter); err != nil {
+		return err
+	}
+
+	merged, mergedArgs := periodCondition("pr.merged_at", filter.From, filter.To)
+	reassigned, reassignedArgs := periodCondition("prr.reassigned_at", filter.From, filter.To)
+
+	query := r.Builder.
+		Select("prr.reviewer_id").
+		From("all_pull_request_reviewers prr").
+		Join("all_pull_requests pr ON pr.tenant_id = prr.tenant_id AND pr.pull_request_id = prr.pull_request_id").
+		Where("prr.tenant_id = ?", tenantID).
+		GroupBy("prr.reviewer_id").
+		OrderBy("prr.reviewer_id")
+
+	query = flowColumns(query,
+		"pr.merged_at - prr.assigned_at", "prr.reassigned_at IS NULL AND pr.merged_at IS NOT NULL AND "+merged, mergedArgs,
+		"prr.reassigned_at - prr.assigned_at", "prr.reassigned_at IS NOT NULL AND "+reassigned, reassignedArgs,
+	)
+
+	if filter.UserID != "" {
+		query = query.Where("prr.reviewer_id = ?", filter.UserID)
+	}
+	if filter.TeamName != "" {
+		query = query.Where("pr.team_name = ?", filter.TeamName)
+	}
+
+	return streamRows(ctx, r.Postgres, query, "user flow stats", func(rows pgx.Rows, s *models.FlowStats) error {
+		return rows.Scan(append([]any{&s.UserID}, flowStatsDest(s)...)...)
+	}, fn)
+}
+
+var flowPercentiles = []float64{0.5, 0.9, 0.99}
+
+// flowColumns adds count and percentiles in seconds of both durations, each
+// aggregated over rows matching its condition.
+func flowColumns(
+	query squirrel.SelectBuilder,
+	mergeDuration, mergeCondition string, mergeArgs []any,
+	reassignDuration, reassignCondition string, reassignArgs []any,
+) squirrel.SelectBuilder {
+	for _, metric := range []struct {
+		duration, condition string
+		args                []any
+	}{
+		{mergeDuration, mergeCondition, mergeArgs},
+		{reassignDuration, reassignCondition, reassignArgs},
+	} {
+		query = query.Column("COUNT(*) FILTER (WHERE "+metric.condition+")", metric.args...)
+
+		for _, p := range flowPercentiles {
+			query = query.Column(
+				fmt.Sprintf("percentile_cont(%g) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM %s)) FILTER (WHERE %s)", p, metric.duration, metric.condition),
+				metric.args...,
+			)
+		}
+	}
+
+	return query
+}
+
+func flowStatsDest(s *models.FlowStats) []any {
+	return []any{
+		&s.Merged,
+		&s.TimeToMerge.P50,
+		&s.TimeToMerge.P90,
+		&s.TimeToMerge.P99,
+		&s.Reassigned,
+		&s.TimeToFirstReassign.P50,
+		&s.TimeToFirstReassign.P90,
+		&s.TimeToFirstReassign.P99,
+	}
+}
+
+// statsColumns adds counters over pull_request_reviewers "prr" joined with
+// pull_requests "pr". Reviewer id is counted, so rows of the outer joins
+// without assignments are skipped.
+func statsColumns(query squirrel.SelectBuilder, filter models.StatsFilter) squirrel.SelectBuilder {
+	assigned, assignedArgs := periodCondition("prr.assigned_at", filter.From, filter.To)
+	reassigned, reassignedArgs := periodCondition("prr.reassigned_at", filter.From, filter.To)
+	merged, mergedArgs := periodCondition("pr.merged_at", filter.From, filter.To)
+
+	return query.
+		Column("COUNT(prr.reviewer_id) FILTER (WHERE "+assigned+")", assignedArgs...).
+		Column("COUNT(prr.reviewer_id) FILTER (WHERE prr.reassigned_at IS NOT NULL AND "+reassigned+")", reassignedArgs...).
+		Column("COUNT(prr.reviewer_id) FILTER (WHERE prr.reassigned_at IS NULL AND pr.status = ? AND "+merged+")", append([]any{MergedStatus}, mergedArgs...)...).
+		Column("COUNT(prr.reviewer_id) FILTER (WHERE prr.reassigned_at IS NULL AND pr.status = ?)", OpenStatus)
+}
+
+func periodCondition(column string, from, to *time.Time) (string, []any) {
+	conditions := []string{"TRUE"}
+	var args []any
+
+	if from != nil {
+		conditions = append(conditions, column+" >= ?")
+		args = append(args, *from)
+	}
+	if to != nil {
+		conditions = append(conditions, column+" < ?")
+		args = append(args, *to)
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// StreamPullRequests calls fn for every pull request created in the period.
+// UserID filters by author.
+func (r *StatsRepo) StreamPullRequests(ctx context.Context, filter models.StatsFilter, fn func(models.PullRequest) error) error {
+	if err := r.checkFilter(ctx, filter); err != nil {
+		return err
+	}
+
+	created, createdArgs := periodCondition("pr.created_at", filter.From, filter.To)
+
+	query := r.Builder.
+		Select(`pr.pull_request_id, pr.pull_request_name, pr.author_id, COALESCE(pr.team_name, ''),
+			COALESCE(pr.repository_name, ''), pr.status, pr.needs_more_reviewers, pr.created_at, pr.merged_at`).
+		Column(`ARRAY(
+			SELECT reviewer_id FROM all_pull_request_reviewers prr
+			WHERE prr.tenant_id = pr.tenant_id AND prr.pull_request_id = pr.pull_request_id AND prr.reassigned_at IS NULL
+			ORDER BY prr.id
+		)`).
+		From("all_pull_requests pr").
+		Where("pr.tenant_id = ?", tenant.ID(ctx)).
+		Where(created, createdArgs...).
+		OrderBy("pr.created_at", "pr.id")
+
+	if filter.UserID != "" {
+		query = query.Where("pr.author_id = ?", filter.UserID)
+	}
+	if filter.TeamName != "" {
+		query = query.Where("pr.team_name = ?", filter.TeamName)
+	}
+
+	return streamRows(ctx, r.Postgres, query, "pull requests", func(rows pgx.Rows, pr *models.PullRequest) error {
+		return rows.Scan(
+			&pr.PullRequestID,
+			&pr.PullRequestName,
+			&pr.AuthorID,
+			&pr.TeamName,
+			&pr.RepositoryName,
+			&pr.Status,
+			&pr.NeedsMoreReviewers,
+			&pr.CreatedAt,
+			&pr.MergedAt,
+			&pr.AssignedReviewers,
+		)
+	}, fn)
+}
+
+// StreamAssignments calls fn for every reviewer assignment made in the period,
+// including the ones later reassigned. UserID filters by reviewer.
+func (r *StatsRepo) StreamAssignments(ctx context.Context, filter models.StatsFilter, fn func(models.Assignment) error) error {
+	if err := r.checkFilter(ctx, filter); err != nil {
+		return err
+	}
+
+	assigned, assignedArgs := periodCondition("prr.assigned_at", filter.From, filter.To)
+
+	query := r.Builder.
+		Select(`prr.pull_request_id, prr.reviewer_id, COALESCE(pr.team_name, ''), COALESCE(pr.repository_name, ''),
+			pr.status, prr.assigned_at, prr.reassigned_at, COALESCE(prr.replaced_by, ''), pr.merged_at`).
+		From("all_pull_request_reviewers prr").
+		Join("all_pull_requests pr ON pr.tenant_id = prr.tenant_id AND pr.pull_request_id = prr.pull_request_id").
+		Where("prr.tenant_id = ?", tenant.ID(ctx)).
+		Where(assigned, assignedArgs...).
+		OrderBy("prr.assigned_at", "prr.id")
+
+	if filter.UserID != "" {
+		query = query.Where("prr.reviewer_id = ?", filter.UserID)
+	}
+	if filter.TeamName != "" {
+		query = query.Where("pr.team_name = ?", filter.TeamName)
+	}
+
+	return streamRows(ctx, r.Postgres, query, "assignments", func(rows pgx.Rows, a *models.Assignment) error {
+		return rows.Scan(
+			&a.PullRequestID,
+			&a.ReviewerID,
+			&a.TeamName,
+			&a.RepositoryName,
+			&a.Status,
+			&a.AssignedAt,
+			&a.ReassignedAt,
+			&a.ReplacedBy,
+			&a.MergedAt,
+		)
+	}, fn)
+}
+
+// streamRows runs the query and passes rows to fn one by one, stopping at the
+// first error returned by fn.
+func streamRows[T any](
+	ctx context.Context,
+	pg *postgres.Postgres,
+	query squirrel.SelectBuilder,
+	name string,
+	scan func(rows pgx.Rows, dest *T) error,
+	fn func(T) error,
+) error {
+	sql, args, _ := query.ToSql()
+
+	rows, err := pg.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query %s: %w", name, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var dest T
+		if err := scan(rows, &dest); err != nil {
+			return fmt.Errorf("failed to scan %s: %w", name, err)
+		}
+
+		if err := fn(dest); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	return nil
+}
+
+func collect[T any](stream func(fn func(T) error) error) ([]T, error) {
+	items := []T{}
+	if err := stream(func(item T) error {
+		items = append(items, item)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (r *StatsRepo) checkFilter(ctx context.Context, filter models.StatsFilter) error {
+	if filter.UserID != "" {
+		if err := r.checkExists(ctx, "users", "user_id", filter.UserID); err != nil {
+			return err
+		}
+	}
+	if filter.TeamName != "" {
+		if err := r.checkExists(ctx, "teams", "team_name", filter.TeamName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *StatsRepo) checkExists(ctx context.Context, table, column, value string) error {
+	sql, args, _ := r.Builder.
+		Select("1").
+		From(table).
+		Where("tenant_id = ? AND "+column+" = ?", tenant.ID(ctx), value).
+		ToSql()
+
+	var exists int
+	if err := r.Pool.QueryRow(ctx, sql, args...).Scan(&exists); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repoerrs.ErrNotFound
+		}
+		return fmt.Errorf("failed to check %s existence: %w", table, err)
+	}
+
+	return nil
+}
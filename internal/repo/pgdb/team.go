@@ -4,14 +4,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
 	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/tenant"
 	"github.com/MatTwix/Pull-Request-Assigner/pkg/database/postgres"
 	"github.com/jackc/pgx/v5"
 )
 
+// maxHierarchyDepth bounds recursive walks over the teams tree.
+const maxHierarchyDepth = 32
+
 type TeamRepo struct {
 	*postgres.Postgres
 }
@@ -21,10 +26,12 @@ func NewTeamRepo(pg *postgres.Postgres) *TeamRepo {
 }
 
 func (r *TeamRepo) CreateTeam(ctx context.Context, team models.Team) (*models.Team, error) {
+	tenantID := tenant.ID(ctx)
+
 	checkSQL, checkArgs, _ := r.Builder.
 		Select("1").
 		From("teams").
-		Where("team_name = ?", team.TeamName).
+		Where("tenant_id = ? AND team_name = ?", tenantID, team.TeamName).
 		ToSql()
 
 	var exists int
@@ -42,43 +49,34 @@ func (r *TeamRepo) CreateTeam(ctx context.Context, team models.Team) (*models.Te
 
 	sql, args, _ := r.Builder.
 		Insert("teams").
-		Columns("team_name").
-		Values(team.TeamName).
-		Suffix("RETURNING id").
+		Columns("tenant_id, team_name").
+		Values(tenantID, team.TeamName).
+		Suffix("RETURNING id, version").
 		ToSql()
 
-	if err := tx.QueryRow(ctx, sql, args...).Scan(&team.ID); err != nil {
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&team.ID, &team.Version); err != nil {
 		return nil, fmt.Errorf("failed to insert team: %w", err)
 	}
 
-	seen := map[string]struct{}{}
-	uniqueMembers := []models.User{}
-	for _, m := range team.Members {
-		if _, ok := seen[m.UserID]; !ok {
-			uniqueMembers = append(uniqueMembers, m)
-			seen[m.UserID] = struct{}{}
-		}
-	}
-	team.Members = uniqueMembers
-
-	insert := r.Builder.
-		Insert("users").
-		Columns("user_id, username, team_name, is_active")
+	team.Members = uniqueMembers(team.Members)
 
-	for _, teamMember := range team.Members {
-		insert = insert.Values(teamMember.UserID, teamMember.Username, team.TeamName, teamMember.IsActive)
+	primaryIDs, err := r.upsertMembers(ctx, tx, tenantID, team.TeamName, team.Members)
+	if err != nil {
+		return nil, err
 	}
 
-	sql, args, _ = insert.Suffix(`
-		ON CONFLICT (user_id)
-		DO UPDATE SET
-			username = EXCLUDED.username,
-			team_name = EXCLUDED.team_name,
-			is_active = EXCLUDED.is_active
-	`).ToSql()
+	memberIDs := make([]string, 0, len(team.Members))
+	for i := range team.Members {
+		if _, ok := primaryIDs[team.Members[i].UserID]; ok {
+			team.Members[i].TeamName = team.TeamName
+		}
+		memberIDs = append(memberIDs, team.Members[i].UserID)
+	}
 
-	if _, err := tx.Exec(ctx, sql, args...); err != nil {
-		return nil, fmt.Errorf("failed to insert team member: %w", err)
+	if err := writeAudit(ctx, r.Postgres, tx, models.AuditActionTeamCreate, models.AuditEntityTeam, team.TeamName,
+		nil, map[string]any{"user_ids": memberIDs},
+	); err != nil {
+		return nil, err
 	}
 
 	if err := tx.Commit(ctx); err != nil {
@@ -89,85 +87,1427 @@ func (r *TeamRepo) CreateTeam(ctx context.Context, team models.Team) (*models.Te
 }
 
 func (r *TeamRepo) GetTeamByName(ctx context.Context, name string) (*models.Team, error) {
-	var teamID int
+	team := models.Team{TeamName: name}
+	var slaSeconds *int64
 	sql, args, _ := r.Builder.
-		Select("id").
-		From("teams").
-		Where("team_name = ?", name).
+		Select("t.id, t.parent_id, COALESCE(p.team_name, ''), t.fallback_depth, t.reviewers_count, t.is_archived, t.archived_at, t.version").
+		Column(reviewSLASeconds("t.review_sla")).
+		From("teams t").
+		LeftJoin("teams p ON p.id = t.parent_id").
+		Where("t.tenant_id = ? AND t.team_name = ?", tenant.ID(ctx), name).
 		Limit(1).
 		ToSql()
 
-	if err := r.Pool.QueryRow(ctx, sql, args...).Scan(&teamID); err != nil {
+	if err := r.Pool.QueryRow(ctx, sql, args...).Scan(
+		&team.ID,
+		&team.ParentID,
+		&team.ParentName,
+		&team.FallbackDepth,
+		&team.ReviewersCount,
+		&team.IsArchived,
+		&team.ArchivedAt,
+		&team.Version,
+		&slaSeconds,
+	); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, repoerrs.ErrNotFound
 		}
 		return nil, fmt.Errorf("failed to check team: %w", err)
 	}
 
-	sql, args, _ = r.Builder.
-		Select("id, user_id, username, is_active").
-		From("users").
-		Where("team_name = ?", name).
-		OrderBy("id").
+	team.ReviewSLA = durationFromSeconds(slaSeconds)
+
+	members, err := r.getMembers(ctx, []string{name})
+	if err != nil {
+		return nil, err
+	}
+
+	team.Members = members[name]
+
+	return &team, nil
+}
+
+// GetTeamTree returns the team with all its subteams and their members.
+func (r *TeamRepo) GetTeamTree(ctx context.Context, name string) (*models.Team, error) {
+	root, err := r.GetTeamByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	sql, args, _ := r.Builder.
+		Select("tree.id, tree.parent_id, tree.parent_name, tree.team_name, tree.fallback_depth, tree.reviewers_count, tree.is_archived, tree.archived_at, tree.version").
+		Prefix(`WITH RECURSIVE tree AS (
+			SELECT t.id, t.parent_id, p.team_name AS parent_name, t.team_name, t.fallback_depth, t.reviewers_count, t.is_archived, t.archived_at, t.version, 1 AS depth
+			FROM teams t
+			JOIN teams p ON p.id = t.parent_id
+			WHERE t.parent_id = ?
+			UNION ALL
+			SELECT t.id, t.parent_id, tree.team_name, t.team_name, t.fallback_depth, t.reviewers_count, t.is_archived, t.archived_at, t.version, tree.depth + 1
+			FROM teams t
+			JOIN tree ON t.parent_id = tree.id
+			WHERE tree.depth < ?
+		)`, root.ID, maxHierarchyDepth).
+		From("tree").
+		OrderBy("tree.depth", "tree.team_name").
 		ToSql()
 
 	rows, err := r.Pool.Query(ctx, sql, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query row: %w", err)
+		return nil, fmt.Errorf("failed to query subteams: %w", err)
 	}
 	defer rows.Close()
 
-	var teamMembers []models.User
+	var subteams []models.Team
+	teamNames := []string{}
+	for rows.Next() {
+		var team models.Team
+		if err := rows.Scan(
+			&team.ID,
+			&team.ParentID,
+			&team.ParentName,
+			&team.TeamName,
+			&team.FallbackDepth,
+			&team.ReviewersCount,
+			&team.IsArchived,
+			&team.ArchivedAt,
+			&team.Version,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan subteam: %w", err)
+		}
+
+		subteams = append(subteams, team)
+		teamNames = append(teamNames, team.TeamName)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read subteams: %w", err)
+	}
+
+	members, err := r.getMembers(ctx, teamNames)
+	if err != nil {
+		return nil, err
+	}
+
+	// subteams are ordered by depth, so the deepest ones are attached first
+	children := map[int][]models.Team{}
+	for i := len(subteams) - 1; i >= 0; i-- {
+		team := subteams[i]
+		team.Members = members[team.TeamName]
+		team.Subteams = children[team.ID]
+
+		children[*team.ParentID] = append([]models.Team{team}, children[*team.ParentID]...)
+	}
+
+	root.Subteams = children[root.ID]
+
+	return root, nil
+}
+
+func (r *TeamRepo) getMembers(ctx context.Context, teamNames []string) (map[string][]models.User, error) {
+	members := map[string][]models.User{}
+	if len(teamNames) == 0 {
+		return members, nil
+	}
+
+	sql, args, _ := r.Builder.
+		Select("tm.team_name, u.id, u.user_id, u.username, u.is_active").
+		Column(primaryTeamColumn).
+		From("users u").
+		Join("team_members tm ON tm.tenant_id = u.tenant_id AND tm.user_id = u.user_id").
+		Where(squirrel.Eq{"u.tenant_id": tenant.ID(ctx), "tm.team_name": teamNames}).
+		OrderBy("u.id").
+		ToSql()
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query row: %w", err)
+	}
+	defer rows.Close()
 
 	for rows.Next() {
-		var teamMebmer models.User
+		var (
+			teamName   string
+			teamMebmer models.User
+		)
 		if err := rows.Scan(
+			&teamName,
 			&teamMebmer.ID,
 			&teamMebmer.UserID,
 			&teamMebmer.Username,
 			&teamMebmer.IsActive,
+			&teamMebmer.TeamName,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan team member: %w", err)
 		}
 
-		teamMembers = append(teamMembers, teamMebmer)
+		members[teamName] = append(members[teamName], teamMebmer)
 	}
 
-	team := models.Team{
-		ID:       teamID,
-		TeamName: name,
-		Members:  teamMembers,
-	}
-
-	return &team, nil
+	return members, nil
 }
 
 func (r *TeamRepo) SetIsActiveTeam(ctx context.Context, teamName string, active bool) (int64, error) {
-	checkSQL, checkArgs, _ := r.Builder.
-		Select("1").
+	tenantID := tenant.ID(ctx)
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	lockSQL, lockArgs, _ := r.Builder.
+		Select("id, version").
 		From("teams").
-		Where("team_name = ?", teamName).
+		Where("tenant_id = ? AND team_name = ?", tenantID, teamName).
+		Suffix("FOR UPDATE").
 		ToSql()
 
-	var exists int
-	if err := r.Pool.QueryRow(ctx, checkSQL, checkArgs...).Scan(&exists); err != nil {
+	var teamID, version int
+	if err := tx.QueryRow(ctx, lockSQL, lockArgs...).Scan(&teamID, &version); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return 0, repoerrs.ErrNotFound
 		}
 		return 0, fmt.Errorf("failed to check team existence: %w", err)
 	}
 
+	if err := checkVersion(ctx, version); err != nil {
+		return 0, err
+	}
+
 	sql, args, _ := r.Builder.
 		Update("users").
 		Set("is_active", active).
-		Where(squirrel.Eq{"team_name": teamName}).
+		Set("version", bumpVersion).
+		Where("tenant_id = ?", tenantID).
+		Where("user_id IN (SELECT user_id FROM team_members WHERE tenant_id = ? AND team_name = ?)", tenantID, teamName).
 		Where("is_active != ?", active).
+		Suffix("RETURNING user_id").
 		ToSql()
 
-	cmd, err := r.Pool.Exec(ctx, sql, args...)
+	rows, err := tx.Query(ctx, sql, args...)
 	if err != nil {
 		return 0, fmt.Errorf("failed to send batch: %w", err)
 	}
 
-	return cmd.RowsAffected(), nil
+	userIDs, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return 0, fmt.Errorf("failed to collect updated users: %w", err)
+	}
+
+	if len(userIDs) > 0 {
+		if err := r.bumpTeamVersion(ctx, tx, teamID); err != nil {
+			return 0, err
+		}
+
+		if err := writeAudit(ctx, r.Postgres, tx, models.AuditActionTeamSetIsActive, models.AuditEntityTeam, teamName,
+			map[string]any{"is_active": !active, "user_ids": userIDs},
+			map[string]any{"is_active": active, "user_ids": userIDs},
+		); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return int64(len(userIDs)), nil
+}
+
+func (r *TeamRepo) UpsertTeam(ctx context.Context, team models.Team, dryRun bool) (*models.TeamDiff, error) {
+	tenantID := tenant.ID(ctx)
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	diff := models.TeamDiff{}
+
+	if diff.TeamCreated, err = r.ensureTeam(ctx, tx, tenantID, &team); err != nil {
+		return nil, err
+	}
+
+	// a team that did not exist has no version to match
+	if err := checkVersion(ctx, team.Version); err != nil {
+		return nil, err
+	}
+
+	if err := r.syncMembers(ctx, tx, tenantID, team, &diff, dryRun); err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		return &diff, nil
+	}
+
+	if !diff.TeamCreated && !diff.IsEmpty() {
+		if err := r.bumpTeamVersion(ctx, tx, team.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if !diff.IsEmpty() {
+		if err := writeAudit(ctx, r.Postgres, tx, models.AuditActionTeamUpsert, models.AuditEntityTeam, team.TeamName,
+			nil, teamDiffAudit(diff),
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &diff, nil
+}
+
+// teamDiffAudit lists the users whose membership the change touched.
+func teamDiffAudit(diff models.TeamDiff) map[string]any {
+	return map[string]any{
+		"team_created":     diff.TeamCreated,
+		"added":            memberChangeIDs(diff.Added),
+		"removed":          memberChangeIDs(diff.Removed),
+		"username_changed": memberChangeIDs(diff.UsernameChanged),
+		"activity_changed": memberChangeIDs(diff.ActivityChanged),
+	}
+}
+
+func memberChangeIDs(changes []models.TeamMemberChange) []string {
+	userIDs := make([]string, 0, len(changes))
+	for _, change := range changes {
+		userIDs = append(userIDs, change.UserID)
+	}
+
+	return userIDs
+}
+
+// ensureTeam creates the team when it does not exist and locks it otherwise,
+// filling its id and version. Version stays 0 for a created team.
+func (r *TeamRepo) ensureTeam(ctx context.Context, tx pgx.Tx, tenantID int, team *models.Team) (created bool, err error) {
+	sql, args, _ := r.Builder.
+		Insert("teams").
+		Columns("tenant_id, team_name").
+		Values(tenantID, team.TeamName).
+		Suffix("ON CONFLICT (tenant_id, team_name) DO NOTHING RETURNING id").
+		ToSql()
+
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&team.ID); err == nil {
+		return true, nil
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return false, fmt.Errorf("failed to insert team: %w", err)
+	}
+
+	sql, args, _ = r.Builder.
+		Select("id, version").
+		From("teams").
+		Where("tenant_id = ? AND team_name = ?", tenantID, team.TeamName).
+		Suffix("FOR UPDATE").
+		ToSql()
+
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&team.ID, &team.Version); err != nil {
+		return false, fmt.Errorf("failed to lock team: %w", err)
+	}
+
+	return false, nil
+}
+
+// syncMembers brings members of the locked team to team.Members and reports
+// the changes in diff, with dryRun nothing is changed.
+func (r *TeamRepo) syncMembers(ctx context.Context, tx pgx.Tx, tenantID int, team models.Team, diff *models.TeamDiff, dryRun bool) error {
+	team.Members = uniqueMembers(team.Members)
+
+	desiredIDs := make([]string, 0, len(team.Members))
+	for _, member := range team.Members {
+		desiredIDs = append(desiredIDs, member.UserID)
+	}
+
+	sql, args, _ := r.Builder.
+		Select("u.user_id, u.username, u.is_active").
+		Column(primaryTeamColumn).
+		Column("EXISTS (SELECT 1 FROM team_members m WHERE m.tenant_id = u.tenant_id AND m.user_id = u.user_id AND m.team_name = ?)", team.TeamName).
+		From("users u").
+		Where("u.tenant_id = ?", tenantID).
+		Where(squirrel.Or{
+			squirrel.Expr("u.user_id IN (SELECT user_id FROM team_members WHERE tenant_id = ? AND team_name = ?)", tenantID, team.TeamName),
+			squirrel.Eq{"u.user_id": desiredIDs},
+		}).
+		OrderBy("u.id").
+		Suffix("FOR UPDATE OF u").
+		ToSql()
+
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query current members: %w", err)
+	}
+	defer rows.Close()
+
+	type currentMember struct {
+		models.User
+		isMember bool
+	}
+
+	current := map[string]currentMember{}
+	var currentOrder []string
+	for rows.Next() {
+		var member currentMember
+		if err := rows.Scan(
+			&member.UserID,
+			&member.Username,
+			&member.IsActive,
+			&member.TeamName,
+			&member.isMember,
+		); err != nil {
+			return fmt.Errorf("failed to scan current member: %w", err)
+		}
+
+		current[member.UserID] = member
+		currentOrder = append(currentOrder, member.UserID)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read current members: %w", err)
+	}
+
+	desired := map[string]struct{}{}
+	for _, member := range team.Members {
+		desired[member.UserID] = struct{}{}
+
+		change := models.TeamMemberChange{
+			UserID:   member.UserID,
+			Username: member.Username,
+			IsActive: member.IsActive,
+		}
+
+		existing, ok := current[member.UserID]
+		if ok {
+			change.PrimaryTeamName = existing.TeamName
+			change.PrevUsername = existing.Username
+			change.PrevIsActive = existing.IsActive
+		}
+
+		if !existing.isMember {
+			diff.Added = append(diff.Added, change)
+			continue
+		}
+
+		if existing.Username != member.Username {
+			diff.UsernameChanged = append(diff.UsernameChanged, change)
+		}
+		if existing.IsActive != member.IsActive {
+			diff.ActivityChanged = append(diff.ActivityChanged, change)
+		}
+	}
+
+	var removedIDs []string
+	for _, userID := range currentOrder {
+		existing := current[userID]
+		if _, ok := desired[userID]; ok || !existing.isMember {
+			continue
+		}
+
+		diff.Removed = append(diff.Removed, models.TeamMemberChange{
+			UserID:          existing.UserID,
+			PrimaryTeamName: existing.TeamName,
+			PrevUsername:    existing.Username,
+			PrevIsActive:    existing.IsActive,
+			Username:        existing.Username,
+			IsActive:        existing.IsActive,
+		})
+		removedIDs = append(removedIDs, userID)
+	}
+
+	if dryRun {
+		return nil
+	}
+
+	if len(removedIDs) > 0 {
+		sql, args, _ = r.Builder.
+			Delete("team_members").
+			Where(squirrel.Eq{"tenant_id": tenantID, "team_name": team.TeamName, "user_id": removedIDs}).
+			ToSql()
+
+		if _, err := tx.Exec(ctx, sql, args...); err != nil {
+			return fmt.Errorf("failed to remove team members: %w", err)
+		}
+
+		if err := r.promotePrimaryTeams(ctx, tx, tenantID, removedIDs); err != nil {
+			return err
+		}
+	}
+
+	if _, err := r.upsertMembers(ctx, tx, tenantID, team.TeamName, team.Members); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// upsertMembers creates or updates users and adds them to the team. The team
+// becomes the primary one for users that have no primary team yet. Returns ids
+// of users whose primary team is teamName after the call.
+func (r *TeamRepo) upsertMembers(ctx context.Context, tx pgx.Tx, tenantID int, teamName string, members []models.User) (map[string]struct{}, error) {
+	primaryIDs := map[string]struct{}{}
+	if len(members) == 0 {
+		return primaryIDs, nil
+	}
+
+	insert := r.Builder.
+		Insert("users").
+		Columns("tenant_id, user_id, username, is_active")
+
+	for _, teamMember := range members {
+		insert = insert.Values(tenantID, teamMember.UserID, teamMember.Username, teamMember.IsActive)
+	}
+
+	sql, args, _ := insert.Suffix(`
+		ON CONFLICT (tenant_id, user_id)
+		DO UPDATE SET
+			username = EXCLUDED.username,
+			is_active = EXCLUDED.is_active,
+			version = users.version + 1
+		WHERE (users.username, users.is_active) IS DISTINCT FROM (EXCLUDED.username, EXCLUDED.is_active)
+	`).ToSql()
+
+	if _, err := tx.Exec(ctx, sql, args...); err != nil {
+		return nil, fmt.Errorf("failed to insert team member: %w", err)
+	}
+
+	membership := r.Builder.
+		Insert("team_members").
+		Columns("tenant_id, user_id, team_name, is_primary")
+
+	for _, teamMember := range members {
+		membership = membership.Values(
+			tenantID,
+			teamMember.UserID,
+			teamName,
+			squirrel.Expr("NOT EXISTS (SELECT 1 FROM team_members WHERE tenant_id = ? AND user_id = ? AND is_primary)", tenantID, teamMember.UserID),
+		)
+	}
+
+	sql, args, _ = membership.
+		Suffix("ON CONFLICT (tenant_id, user_id, team_name) DO NOTHING RETURNING user_id, is_primary").
+		ToSql()
+
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert team membership: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			userID    string
+			isPrimary bool
+		)
+		if err := rows.Scan(&userID, &isPrimary); err != nil {
+			return nil, fmt.Errorf("failed to scan team membership: %w", err)
+		}
+
+		if isPrimary {
+			primaryIDs[userID] = struct{}{}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to insert team membership: %w", err)
+	}
+
+	return primaryIDs, nil
+}
+
+// bumpTeamVersion marks the locked team changed by a change of its members.
+func (r *TeamRepo) bumpTeamVersion(ctx context.Context, tx pgx.Tx, teamID int) error {
+	sql, args, _ := r.Builder.
+		Update("teams").
+		Set("version", bumpVersion).
+		Where("id = ?", teamID).
+		ToSql()
+
+	if _, err := tx.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("failed to update team version: %w", err)
+	}
+
+	return nil
+}
+
+// promotePrimaryTeams picks a new primary team for users left without one.
+func (r *TeamRepo) promotePrimaryTeams(ctx context.Context, tx pgx.Tx, tenantID int, userIDs []string) error {
+	sql, args, _ := r.Builder.
+		Update("team_members").
+		Set("is_primary", true).
+		Where("tenant_id = ?", tenantID).
+		Where(`(user_id, team_name) IN (
+			SELECT DISTINCT ON (m.user_id) m.user_id, m.team_name
+			FROM team_members m
+			WHERE m.tenant_id = ? AND m.user_id = ANY(?)
+				AND NOT EXISTS (SELECT 1 FROM team_members p WHERE p.tenant_id = m.tenant_id AND p.user_id = m.user_id AND p.is_primary)
+			ORDER BY m.user_id, m.team_name
+		)`, tenantID, userIDs).
+		ToSql()
+
+	if _, err := tx.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("failed to promote primary teams: %w", err)
+	}
+
+	return nil
+}
+
+func uniqueMembers(members []models.User) []models.User {
+	seen := map[string]struct{}{}
+	unique := []models.User{}
+	for _, m := range members {
+		if _, ok := seen[m.UserID]; !ok {
+			unique = append(unique, m)
+			seen[m.UserID] = struct{}{}
+		}
+	}
+
+	return unique
+}
+
+func (r *TeamRepo) RenameTeam(ctx context.Context, oldName, newName string) (*models.Team, error) {
+	tenantID := tenant.ID(ctx)
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	checkSQL, checkArgs, _ := r.Builder.
+		Select("1").
+		From("teams").
+		Where("tenant_id = ? AND team_name = ?", tenantID, newName).
+		ToSql()
+
+	var exists int
+	if err := tx.QueryRow(ctx, checkSQL, checkArgs...).Scan(&exists); err == nil {
+		return nil, repoerrs.ErrAlreadyExists
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to check team existence: %w", err)
+	}
+
+	// team_members, pull_requests, pull_requests_archive, repositories and
+	// chat_channels follow the new name by ON UPDATE CASCADE, child teams
+	// reference the parent by id
+	sql, args, _ := r.Builder.
+		Update("teams").
+		Set("team_name", newName).
+		Set("version", bumpVersion).
+		Where("tenant_id = ? AND team_name = ?", tenantID, oldName).
+		Where(expectedVersion(ctx, "version")).
+		Suffix("RETURNING id, team_name, is_archived, archived_at, version").
+		ToSql()
+
+	var team models.Team
+	if err := tx.QueryRow(ctx, sql, args...).Scan(
+		&team.ID,
+		&team.TeamName,
+		&team.IsArchived,
+		&team.ArchivedAt,
+		&team.Version,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, notUpdated(ctx, r.Postgres, tx, "teams", squirrel.Eq{"tenant_id": tenantID, "team_name": oldName})
+		}
+		return nil, fmt.Errorf("failed to rename team: %w", err)
+	}
+
+	if err := writeAudit(ctx, r.Postgres, tx, models.AuditActionTeamRename, models.AuditEntityTeam, newName,
+		map[string]any{"team_name": oldName},
+		map[string]any{"team_name": newName},
+	); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &team, nil
+}
+
+func (r *TeamRepo) SetIsArchivedTeam(ctx context.Context, teamName string, isArchived bool) (teamRes *models.Team, alreadyUpdated bool, err error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	team := models.Team{TeamName: teamName}
+	sql, args, _ := r.Builder.
+		Select("id, is_archived, archived_at, version").
+		From("teams").
+		Where("tenant_id = ? AND team_name = ?", tenant.ID(ctx), teamName).
+		Suffix("FOR UPDATE").
+		ToSql()
+
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&team.ID, &team.IsArchived, &team.ArchivedAt, &team.Version); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, repoerrs.ErrNotFound
+		}
+		return nil, false, fmt.Errorf("failed to check team: %w", err)
+	}
+
+	alreadyUpdated = team.IsArchived == isArchived
+	if alreadyUpdated {
+		if err := checkVersion(ctx, team.Version); err != nil {
+			return nil, false, err
+		}
+		return &team, true, nil
+	}
+
+	archivedAt := squirrel.Expr("NULL")
+	if isArchived {
+		archivedAt = squirrel.Expr("NOW()")
+	}
+
+	sql, args, _ = r.Builder.
+		Update("teams").
+		Set("is_archived", isArchived).
+		Set("archived_at", archivedAt).
+		Set("version", bumpVersion).
+		Where("id = ?", team.ID).
+		Where(expectedVersion(ctx, "version")).
+		Suffix("RETURNING is_archived, archived_at, version").
+		ToSql()
+
+	// the team is locked, so only another version leaves nothing to update
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&team.IsArchived, &team.ArchivedAt, &team.Version); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, repoerrs.ErrVersionMismatch
+		}
+		return nil, false, fmt.Errorf("failed to update team: %w", err)
+	}
+
+	if err := writeAudit(ctx, r.Postgres, tx, models.AuditActionTeamSetIsArchived, models.AuditEntityTeam, teamName,
+		map[string]any{"is_archived": !isArchived},
+		map[string]any{"is_archived": isArchived},
+	); err != nil {
+		return nil, false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &team, false, nil
+}
+
+func (r *TeamRepo) DeleteTeam(ctx context.Context, teamName, reassignTo string) (int64, error) {
+	tenantID := tenant.ID(ctx)
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	lockSQL, lockArgs, _ := r.Builder.
+		Select("id, version").
+		From("teams").
+		Where("tenant_id = ? AND team_name = ?", tenantID, teamName).
+		Suffix("FOR UPDATE").
+		ToSql()
+
+	var teamID, version int
+	if err := tx.QueryRow(ctx, lockSQL, lockArgs...).Scan(&teamID, &version); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, repoerrs.ErrNotFound
+		}
+		return 0, fmt.Errorf("failed to lock team: %w", err)
+	}
+
+	if err := checkVersion(ctx, version); err != nil {
+		return 0, err
+	}
+
+	if reassignTo == "" {
+		checkSQL, checkArgs, _ := r.Builder.
+			Select().
+			Column(`EXISTS (SELECT 1 FROM team_members WHERE tenant_id = ? AND team_name = ?)
+				OR EXISTS (SELECT 1 FROM pull_requests WHERE tenant_id = ? AND team_name = ? AND status = ?)`,
+				tenantID, teamName, tenantID, teamName, OpenStatus,
+			).
+			ToSql()
+
+		var hasLiveData bool
+		if err := tx.QueryRow(ctx, checkSQL, checkArgs...).Scan(&hasLiveData); err != nil {
+			return 0, fmt.Errorf("failed to check team members: %w", err)
+		}
+
+		if hasLiveData {
+			return 0, repoerrs.ErrTeamNotEmpty
+		}
+	}
+
+	var membersMoved int64
+	if reassignTo != "" {
+		// the lock keeps the target from being archived till the members are moved
+		checkSQL, checkArgs, _ := r.Builder.
+			Select("is_archived").
+			From("teams").
+			Where("tenant_id = ? AND team_name = ?", tenantID, reassignTo).
+			Suffix("FOR SHARE").
+			ToSql()
+
+		var targetArchived bool
+		if err := tx.QueryRow(ctx, checkSQL, checkArgs...).Scan(&targetArchived); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return 0, repoerrs.ErrReassignTargetNotFound
+			}
+			return 0, fmt.Errorf("failed to check target team: %w", err)
+		}
+
+		if targetArchived {
+			return 0, repoerrs.ErrTeamArchived
+		}
+
+		// nested select must keep "?" placeholders, the outer builder numbers them
+		members := squirrel.
+			Select("tenant_id, user_id").
+			Column("?::text", reassignTo).
+			Column("FALSE").
+			From("team_members").
+			Where("tenant_id = ? AND team_name = ?", tenantID, teamName)
+
+		sql, args, _ := r.Builder.
+			Insert("team_members").
+			Columns("tenant_id, user_id, team_name, is_primary").
+			Select(members).
+			Suffix("ON CONFLICT (tenant_id, user_id, team_name) DO NOTHING").
+			ToSql()
+
+		if _, err := tx.Exec(ctx, sql, args...); err != nil {
+			return 0, fmt.Errorf("failed to reassign team members: %w", err)
+		}
+
+		sql, args, _ = r.Builder.
+			Update("pull_requests").
+			Set("team_name", reassignTo).
+			Set("version", bumpVersion).
+			Where("tenant_id = ? AND team_name = ?", tenantID, teamName).
+			ToSql()
+
+		if _, err := tx.Exec(ctx, sql, args...); err != nil {
+			return 0, fmt.Errorf("failed to reassign team pull requests: %w", err)
+		}
+
+		sql, args, _ = r.Builder.
+			Update("pull_requests_archive").
+			Set("team_name", reassignTo).
+			Where("tenant_id = ? AND team_name = ?", tenantID, teamName).
+			ToSql()
+
+		if _, err := tx.Exec(ctx, sql, args...); err != nil {
+			return 0, fmt.Errorf("failed to reassign team archived pull requests: %w", err)
+		}
+
+		sql, args, _ = r.Builder.
+			Update("repositories").
+			Set("team_name", reassignTo).
+			Where("tenant_id = ? AND team_name = ?", tenantID, teamName).
+			ToSql()
+
+		if _, err := tx.Exec(ctx, sql, args...); err != nil {
+			return 0, fmt.Errorf("failed to reassign team repositories: %w", err)
+		}
+	} else {
+		// merged and closed pull requests outlive the team
+		sql, args, _ := r.Builder.
+			Update("pull_requests").
+			Set("team_name", nil).
+			Set("version", bumpVersion).
+			Where("tenant_id = ? AND team_name = ?", tenantID, teamName).
+			ToSql()
+
+		if _, err := tx.Exec(ctx, sql, args...); err != nil {
+			return 0, fmt.Errorf("failed to detach team pull requests: %w", err)
+		}
+
+		sql, args, _ = r.Builder.
+			Update("pull_requests_archive").
+			Set("team_name", nil).
+			Where("tenant_id = ? AND team_name = ?", tenantID, teamName).
+			ToSql()
+
+		if _, err := tx.Exec(ctx, sql, args...); err != nil {
+			return 0, fmt.Errorf("failed to detach team archived pull requests: %w", err)
+		}
+
+		sql, args, _ = r.Builder.
+			Update("repositories").
+			Set("team_name", nil).
+			Where("tenant_id = ? AND team_name = ?", tenantID, teamName).
+			ToSql()
+
+		if _, err := tx.Exec(ctx, sql, args...); err != nil {
+			return 0, fmt.Errorf("failed to detach team repositories: %w", err)
+		}
+	}
+
+	sql, args, _ := r.Builder.
+		Delete("team_members").
+		Where("tenant_id = ? AND team_name = ?", tenantID, teamName).
+		Suffix("RETURNING user_id, is_primary").
+		ToSql()
+
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete team members: %w", err)
+	}
+	defer rows.Close()
+
+	memberIDs := []string{}
+	var primaryIDs []string
+	for rows.Next() {
+		var (
+			userID    string
+			isPrimary bool
+		)
+		if err := rows.Scan(&userID, &isPrimary); err != nil {
+			return 0, fmt.Errorf("failed to scan team member: %w", err)
+		}
+
+		if isPrimary {
+			primaryIDs = append(primaryIDs, userID)
+		}
+		memberIDs = append(memberIDs, userID)
+		membersMoved++
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to delete team members: %w", err)
+	}
+
+	if len(primaryIDs) > 0 {
+		if reassignTo != "" {
+			sql, args, _ = r.Builder.
+				Update("team_members").
+				Set("is_primary", true).
+				Where(squirrel.Eq{"tenant_id": tenantID, "team_name": reassignTo, "user_id": primaryIDs}).
+				Where("NOT EXISTS (SELECT 1 FROM team_members p WHERE p.tenant_id = team_members.tenant_id AND p.user_id = team_members.user_id AND p.is_primary)").
+				ToSql()
+
+			if _, err := tx.Exec(ctx, sql, args...); err != nil {
+				return 0, fmt.Errorf("failed to move primary team: %w", err)
+			}
+		}
+
+		if err := r.promotePrimaryTeams(ctx, tx, tenantID, primaryIDs); err != nil {
+			return 0, err
+		}
+	}
+
+	// subteams move one level up instead of being orphaned
+	sql, args, _ = r.Builder.
+		Update("teams").
+		Set("parent_id", squirrel.Expr("(SELECT parent_id FROM teams WHERE id = ?)", teamID)).
+		Set("version", bumpVersion).
+		Where("parent_id = ?", teamID).
+		ToSql()
+
+	if _, err := tx.Exec(ctx, sql, args...); err != nil {
+		return 0, fmt.Errorf("failed to move subteams: %w", err)
+	}
+
+	sql, args, _ = r.Builder.
+		Delete("teams").
+		Where("id = ?", teamID).
+		ToSql()
+
+	if _, err := tx.Exec(ctx, sql, args...); err != nil {
+		return 0, fmt.Errorf("failed to delete team: %w", err)
+	}
+
+	var after any
+	if reassignTo != "" {
+		after = map[string]any{"reassign_to": reassignTo}
+	}
+
+	if err := writeAudit(ctx, r.Postgres, tx, models.AuditActionTeamDelete, models.AuditEntityTeam, teamName,
+		map[string]any{"version": version, "user_ids": memberIDs},
+		after,
+	); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return membersMoved, nil
+}
+
+func (r *TeamRepo) SetParentTeam(ctx context.Context, teamName, parentName string) (*models.Team, error) {
+	tenantID := tenant.ID(ctx)
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// serializes hierarchy changes, so concurrent moves cannot build a cycle
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext('teams_hierarchy'))"); err != nil {
+		return nil, fmt.Errorf("failed to lock teams hierarchy: %w", err)
+	}
+
+	team := models.Team{TeamName: teamName, ParentName: parentName}
+	var prevParentName string
+	sql, args, _ := r.Builder.
+		Select("t.id, COALESCE(p.team_name, '')").
+		From("teams t").
+		LeftJoin("teams p ON p.id = t.parent_id").
+		Where("t.tenant_id = ? AND t.team_name = ?", tenantID, teamName).
+		ToSql()
+
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&team.ID, &prevParentName); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get team: %w", err)
+	}
+
+	if parentName != "" {
+		parentID, err := r.parentTeamID(ctx, tx, tenantID, team.ID, parentName)
+		if err != nil {
+			return nil, err
+		}
+
+		team.ParentID = &parentID
+	}
+
+	sql, args, _ = r.Builder.
+		Update("teams").
+		Set("parent_id", team.ParentID).
+		Set("version", bumpVersion).
+		Where("id = ?", team.ID).
+		Where(expectedVersion(ctx, "version")).
+		Suffix("RETURNING fallback_depth, reviewers_count, is_archived, archived_at, version").
+		ToSql()
+
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&team.FallbackDepth, &team.ReviewersCount, &team.IsArchived, &team.ArchivedAt, &team.Version); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, notUpdated(ctx, r.Postgres, tx, "teams", squirrel.Eq{"id": team.ID})
+		}
+		return nil, fmt.Errorf("failed to update parent team: %w", err)
+	}
+
+	if err := writeAudit(ctx, r.Postgres, tx, models.AuditActionTeamSetParent, models.AuditEntityTeam, teamName,
+		map[string]any{"parent_team_name": nullIfEmpty(prevParentName)},
+		map[string]any{"parent_team_name": nullIfEmpty(parentName)},
+	); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &team, nil
+}
+
+// parentTeamID returns the id of the team teamID is moved under, refusing
+// parents that are the team itself or its subteams.
+func (r *TeamRepo) parentTeamID(ctx context.Context, tx pgx.Tx, tenantID, teamID int, parentName string) (int, error) {
+	var (
+		parentID int
+		isCycle  bool
+	)
+
+	sql, args, _ := r.Builder.
+		Select("a.id").
+		Column("EXISTS (SELECT 1 FROM ancestors WHERE id = ?)", teamID).
+		Prefix(`WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id FROM teams WHERE tenant_id = ? AND team_name = ?
+			UNION
+			SELECT t.id, t.parent_id FROM teams t JOIN ancestors a ON t.id = a.parent_id
+		)`, tenantID, parentName).
+		From("teams a").
+		Where("a.tenant_id = ? AND a.team_name = ?", tenantID, parentName).
+		ToSql()
+
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&parentID, &isCycle); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, repoerrs.ErrNotFound
+		}
+		return 0, fmt.Errorf("failed to check parent team: %w", err)
+	}
+
+	if isCycle {
+		return 0, repoerrs.ErrTeamCycle
+	}
+
+	return parentID, nil
+}
+
+func (r *TeamRepo) SetTeamSettings(ctx context.Context, teamName string, reviewersCount, fallbackDepth models.NullInt) (*models.Team, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var prevReviewersCount, prevFallbackDepth *int
+	sql, args, _ := r.Builder.
+		Select("reviewers_count, fallback_depth").
+		From("teams").
+		Where("tenant_id = ? AND team_name = ?", tenant.ID(ctx), teamName).
+		Suffix("FOR UPDATE").
+		ToSql()
+
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&prevReviewersCount, &prevFallbackDepth); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get team settings: %w", err)
+	}
+
+	team := models.Team{TeamName: teamName}
+	update := r.Builder.Update("teams")
+	if reviewersCount.Set {
+		update = update.Set("reviewers_count", reviewersCount.Value)
+	}
+	if fallbackDepth.Set {
+		update = update.Set("fallback_depth", fallbackDepth.Value)
+	}
+
+	sql, args, _ = update.
+		Set("version", bumpVersion).
+		Where("tenant_id = ? AND team_name = ?", tenant.ID(ctx), teamName).
+		Where(expectedVersion(ctx, "version")).
+		Suffix("RETURNING id, parent_id, fallback_depth, reviewers_count, is_archived, archived_at, version").
+		ToSql()
+
+	// the team is locked, so only another version leaves nothing to update
+	if err := tx.QueryRow(ctx, sql, args...).Scan(
+		&team.ID,
+		&team.ParentID,
+		&team.FallbackDepth,
+		&team.ReviewersCount,
+		&team.IsArchived,
+		&team.ArchivedAt,
+		&team.Version,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrVersionMismatch
+		}
+		return nil, fmt.Errorf("failed to update team settings: %w", err)
+	}
+
+	if err := writeAudit(ctx, r.Postgres, tx, models.AuditActionTeamSetSettings, models.AuditEntityTeam, teamName,
+		map[string]any{"reviewers_count": prevReviewersCount, "fallback_depth": prevFallbackDepth},
+		map[string]any{"reviewers_count": team.ReviewersCount, "fallback_depth": team.FallbackDepth},
+	); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &team, nil
+}
+
+// SetTeamReviewSLA sets the time reviewers of the team have for a review, nil
+// turns the check off.
+func (r *TeamRepo) SetTeamReviewSLA(ctx context.Context, teamName string, sla *time.Duration) (*models.Team, error) {
+	var interval any
+	if sla != nil {
+		interval = squirrel.Expr("make_interval(secs => ?)", sla.Seconds())
+	}
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var prevSLASeconds *int64
+	sql, args, _ := r.Builder.
+		Select(reviewSLASeconds("review_sla")).
+		From("teams").
+		Where("tenant_id = ? AND team_name = ?", tenant.ID(ctx), teamName).
+		Suffix("FOR UPDATE").
+		ToSql()
+
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&prevSLASeconds); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get team review sla: %w", err)
+	}
+
+	team := models.Team{TeamName: teamName}
+	var slaSeconds *int64
+	sql, args, _ = r.Builder.
+		Update("teams").
+		Set("review_sla", interval).
+		Set("version", bumpVersion).
+		Where("tenant_id = ? AND team_name = ?", tenant.ID(ctx), teamName).
+		Where(expectedVersion(ctx, "version")).
+		Suffix("RETURNING id, parent_id, fallback_depth, reviewers_count, is_archived, archived_at, version, " + reviewSLASeconds("review_sla")).
+		ToSql()
+
+	// the team is locked, so only another version leaves nothing to update
+	if err := tx.QueryRow(ctx, sql, args...).Scan(
+		&team.ID,
+		&team.ParentID,
+		&team.FallbackDepth,
+		&team.ReviewersCount,
+		&team.IsArchived,
+		&team.ArchivedAt,
+		&team.Version,
+		&slaSeconds,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrVersionMismatch
+		}
+		return nil, fmt.Errorf("failed to update team review sla: %w", err)
+	}
+
+	if err := writeAudit(ctx, r.Postgres, tx, models.AuditActionTeamSetReviewSLA, models.AuditEntityTeam, teamName,
+		map[string]any{"review_sla_seconds": prevSLASeconds},
+		map[string]any{"review_sla_seconds": slaSeconds},
+	); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	team.ReviewSLA = durationFromSeconds(slaSeconds)
+
+	return &team, nil
+}
+
+// reviewSLASeconds selects the interval column as whole seconds.
+func reviewSLASeconds(column string) string {
+	return "EXTRACT(EPOCH FROM " + column + ")::BIGINT"
+}
+
+func durationFromSeconds(seconds *int64) *time.Duration {
+	if seconds == nil {
+		return nil
+	}
+
+	d := time.Duration(*seconds) * time.Second
+	return &d
+}
+
+// ImportTeams brings every team of the import to the described state in one
+// transaction. Teams are created and synced first, so parents may refer to
+// teams declared later in the import. With dryRun the whole import is applied
+// and rolled back, so the diff of a team accounts for the teams before it.
+func (r *TeamRepo) ImportTeams(ctx context.Context, teams []models.TeamImport, dryRun bool) ([]models.TeamImportDiff, error) {
+	tenantID := tenant.ID(ctx)
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// serializes hierarchy changes, so concurrent moves cannot build a cycle
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext('teams_hierarchy'))"); err != nil {
+		return nil, fmt.Errorf("failed to lock teams hierarchy: %w", err)
+	}
+
+	diffs := make([]models.TeamImportDiff, len(teams))
+	teamIDs := make([]int, len(teams))
+	for i, imported := range teams {
+		team := models.Team{TeamName: imported.TeamName}
+		diffs[i].TeamName = imported.TeamName
+
+		if diffs[i].TeamCreated, err = r.ensureTeam(ctx, tx, tenantID, &team); err != nil {
+			return nil, err
+		}
+		teamIDs[i] = team.ID
+
+		if imported.Members == nil {
+			continue
+		}
+
+		if team.Members, err = r.importedMembers(ctx, tx, tenantID, imported.Members); err != nil {
+			return nil, err
+		}
+
+		if err := r.syncMembers(ctx, tx, tenantID, team, &diffs[i].TeamDiff, false); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, imported := range teams {
+		if err := r.importAttributes(ctx, tx, tenantID, teamIDs[i], imported, &diffs[i]); err != nil {
+			return nil, err
+		}
+
+		if !diffs[i].TeamCreated && !diffs[i].IsEmpty() {
+			if err := r.bumpTeamVersion(ctx, tx, teamIDs[i]); err != nil {
+				return nil, err
+			}
+		}
+
+		if !diffs[i].IsEmpty() {
+			after := teamDiffAudit(diffs[i].TeamDiff)
+			after["updated_attributes"] = diffs[i].UpdatedAttributes
+
+			if err := writeAudit(ctx, r.Postgres, tx, models.AuditActionTeamImport, models.AuditEntityTeam, imported.TeamName,
+				nil, after,
+			); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if dryRun {
+		return diffs, nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return diffs, nil
+}
+
+// importedMembers resolves the activity of members imported without one:
+// existing users keep theirs, new users are active.
+func (r *TeamRepo) importedMembers(ctx context.Context, tx pgx.Tx, tenantID int, imported []models.TeamImportMember) ([]models.User, error) {
+	var keepIDs []string
+	for _, member := range imported {
+		if member.IsActive == nil {
+			keepIDs = append(keepIDs, member.UserID)
+		}
+	}
+
+	current := map[string]bool{}
+	if len(keepIDs) > 0 {
+		sql, args, _ := r.Builder.
+			Select("user_id, is_active").
+			From("users").
+			Where(squirrel.Eq{"tenant_id": tenantID, "user_id": keepIDs}).
+			ToSql()
+
+		rows, err := tx.Query(ctx, sql, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query member activity: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				userID   string
+				isActive bool
+			)
+			if err := rows.Scan(&userID, &isActive); err != nil {
+				return nil, fmt.Errorf("failed to scan member activity: %w", err)
+			}
+			current[userID] = isActive
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read member activity: %w", err)
+		}
+	}
+
+	members := make([]models.User, 0, len(imported))
+	for _, member := range imported {
+		isActive, ok := current[member.UserID]
+		switch {
+		case member.IsActive != nil:
+			isActive = *member.IsActive
+		case !ok:
+			isActive = true
+		}
+
+		members = append(members, models.User{
+			UserID:   member.UserID,
+			Username: member.Username,
+			IsActive: isActive,
+		})
+	}
+
+	return members, nil
+}
+
+// importAttributes sets attributes of the locked team given in the import and
+// records the changed ones in diff.
+func (r *TeamRepo) importAttributes(ctx context.Context, tx pgx.Tx, tenantID, teamID int, imported models.TeamImport, diff *models.TeamImportDiff) error {
+	current := models.Team{ID: teamID}
+	var slaSeconds *int64
+	sql, args, _ := r.Builder.
+		Select("COALESCE(p.team_name, ''), t.reviewers_count, t.fallback_depth, t.is_archived").
+		Column(reviewSLASeconds("t.review_sla")).
+		From("teams t").
+		LeftJoin("teams p ON p.id = t.parent_id").
+		Where("t.id = ?", teamID).
+		ToSql()
+
+	if err := tx.QueryRow(ctx, sql, args...).Scan(
+		&current.ParentName,
+		&current.ReviewersCount,
+		&current.FallbackDepth,
+		&current.IsArchived,
+		&slaSeconds,
+	); err != nil {
+		return fmt.Errorf("failed to get team: %w", err)
+	}
+	current.ReviewSLA = durationFromSeconds(slaSeconds)
+
+	update := r.Builder.
+		Update("teams").
+		Where("id = ?", teamID)
+
+	if imported.ParentName != nil && *imported.ParentName != current.ParentName {
+		var parentID *int
+		if *imported.ParentName != "" {
+			id, err := r.parentTeamID(ctx, tx, tenantID, teamID, *imported.ParentName)
+			if err != nil {
+				return fmt.Errorf("team %s: %w", imported.TeamName, err)
+			}
+			parentID = &id
+		}
+
+		update = update.Set("parent_id", parentID)
+		diff.UpdatedAttributes = append(diff.UpdatedAttributes, models.TeamAttributeParent)
+	}
+
+	if imported.ReviewersCount != nil && (current.ReviewersCount == nil || *current.ReviewersCount != *imported.ReviewersCount) {
+		update = update.Set("reviewers_count", *imported.ReviewersCount)
+		diff.UpdatedAttributes = append(diff.UpdatedAttributes, models.TeamAttributeReviewersCount)
+	}
+
+	if imported.FallbackDepth != nil && (current.FallbackDepth == nil || *current.FallbackDepth != *imported.FallbackDepth) {
+		update = update.Set("fallback_depth", *imported.FallbackDepth)
+		diff.UpdatedAttributes = append(diff.UpdatedAttributes, models.TeamAttributeFallbackDepth)
+	}
+
+	if imported.ReviewSLA != nil && (current.ReviewSLA == nil || *current.ReviewSLA != *imported.ReviewSLA) {
+		update = update.Set("review_sla", squirrel.Expr("make_interval(secs => ?)", imported.ReviewSLA.Seconds()))
+		diff.UpdatedAttributes = append(diff.UpdatedAttributes, models.TeamAttributeReviewSLA)
+	}
+
+	if imported.IsArchived != nil && *imported.IsArchived != current.IsArchived {
+		archivedAt := squirrel.Expr("NULL")
+		if *imported.IsArchived {
+			archivedAt = squirrel.Expr("NOW()")
+		}
+
+		update = update.
+			Set("is_archived", *imported.IsArchived).
+			Set("archived_at", archivedAt)
+		diff.UpdatedAttributes = append(diff.UpdatedAttributes, models.TeamAttributeIsArchived)
+	}
+
+	if len(diff.UpdatedAttributes) == 0 {
+		return nil
+	}
+
+	sql, args, _ = update.ToSql()
+	if _, err := tx.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("failed to update team: %w", err)
+	}
+
+	return nil
 }
@@ -0,0 +1,160 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/tenant"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/database/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+// telegramPollingLockKey is the advisory lock held by the instance polling
+// the bot.
+const telegramPollingLockKey = 7_340_202
+
+type TelegramRepo struct {
+	*postgres.Postgres
+}
+
+func NewTelegramRepo(pg *postgres.Postgres) *TelegramRepo {
+	return &TelegramRepo{pg}
+}
+
+// SetTelegramLinkCode replaces the link code of the user with the one of the
+// hash, valid for ttl.
+func (r *TelegramRepo) SetTelegramLinkCode(ctx context.Context, userID, codeHash string, ttl time.Duration) (expiresAt time.Time, err error) {
+	tenantID := tenant.ID(ctx)
+
+	sql, args, _ := r.Builder.
+		Select("1").
+		From("users").
+		Where("tenant_id = ? AND user_id = ?", tenantID, userID).
+		ToSql()
+
+	var exists int
+	if err := r.Pool.QueryRow(ctx, sql, args...).Scan(&exists); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, repoerrs.ErrNotFound
+		}
+		return time.Time{}, fmt.Errorf("failed to check user existence: %w", err)
+	}
+
+	sql, args, _ = r.Builder.
+		Insert("telegram_link_codes").
+		Columns("tenant_id, user_id, code_hash, expires_at").
+		Values(tenantID, userID, codeHash, squirrel.Expr("NOW() + make_interval(secs => ?)", ttl.Seconds())).
+		Suffix(`ON CONFLICT (tenant_id, user_id) DO UPDATE SET
+			code_hash = EXCLUDED.code_hash,
+			expires_at = EXCLUDED.expires_at,
+			created_at = NOW()
+		RETURNING expires_at`).
+		ToSql()
+
+	if err := r.Pool.QueryRow(ctx, sql, args...).Scan(&expiresAt); err != nil {
+		return time.Time{}, fmt.Errorf("failed to set telegram link code: %w", err)
+	}
+
+	return expiresAt, nil
+}
+
+// RedeemTelegramLinkCode links the chat to the user of the unexpired code of
+// the hash, the code is spent. The chat is unlinked from any other user.
+// Codes of all tenants are looked up.
+func (r *TelegramRepo) RedeemTelegramLinkCode(ctx context.Context, codeHash string, chatID int64) (*models.TelegramLink, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	sql, args, _ := r.Builder.
+		Delete("telegram_link_codes").
+		Where("code_hash = ? AND expires_at > NOW()", codeHash).
+		Suffix("RETURNING tenant_id, user_id").
+		ToSql()
+
+	link := models.TelegramLink{ChatID: chatID}
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&link.TenantID, &link.UserID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to spend telegram link code: %w", err)
+	}
+
+	sql, args, _ = r.Builder.
+		Delete("telegram_links").
+		Where("chat_id = ?", chatID).
+		ToSql()
+
+	if _, err := tx.Exec(ctx, sql, args...); err != nil {
+		return nil, fmt.Errorf("failed to unlink telegram chat: %w", err)
+	}
+
+	sql, args, _ = r.Builder.
+		Insert("telegram_links").
+		Columns("tenant_id, user_id, chat_id").
+		Values(link.TenantID, link.UserID, chatID).
+		Suffix("ON CONFLICT (tenant_id, user_id) DO UPDATE SET chat_id = EXCLUDED.chat_id, created_at = NOW() RETURNING created_at").
+		ToSql()
+
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&link.CreatedAt); err != nil {
+		return nil, fmt.Errorf("failed to link telegram chat: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &link, nil
+}
+
+// GetTelegramLink returns the link of the chat of any tenant.
+func (r *TelegramRepo) GetTelegramLink(ctx context.Context, chatID int64) (*models.TelegramLink, error) {
+	sql, args, _ := r.Builder.
+		Select("tenant_id, user_id, chat_id, created_at").
+		From("telegram_links").
+		Where("chat_id = ?", chatID).
+		ToSql()
+
+	var link models.TelegramLink
+	if err := r.Pool.QueryRow(ctx, sql, args...).Scan(&link.TenantID, &link.UserID, &link.ChatID, &link.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get telegram link: %w", err)
+	}
+
+	return &link, nil
+}
+
+// DeleteTelegramLink unlinks the chat of any tenant.
+func (r *TelegramRepo) DeleteTelegramLink(ctx context.Context, chatID int64) error {
+	sql, args, _ := r.Builder.
+		Delete("telegram_links").
+		Where("chat_id = ?", chatID).
+		ToSql()
+
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete telegram link: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repoerrs.ErrNotFound
+	}
+
+	return nil
+}
+
+// LockTelegramPolling takes the lock of polling the bot, so only one instance
+// polls it at a time. The lock is held until unlock is called, ok is false
+// when another instance holds it.
+func (r *TelegramRepo) LockTelegramPolling(ctx context.Context) (unlock func(), ok bool, err error) {
+	return tryAdvisoryLock(ctx, r.Postgres, telegramPollingLockKey)
+}
@@ -0,0 +1,227 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/tenant"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/database/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+type TenantRepo struct {
+	*postgres.Postgres
+}
+
+func NewTenantRepo(pg *postgres.Postgres) *TenantRepo {
+	return &TenantRepo{pg}
+}
+
+// CreateTenant creates the tenant together with its initial API keys.
+func (r *TenantRepo) CreateTenant(ctx context.Context, tenantName string, keys []models.APIKey) (*models.Tenant, []models.APIKey, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	sql, args, _ := r.Builder.
+		Insert("tenants").
+		Columns("tenant_name").
+		Values(tenantName).
+		Suffix("ON CONFLICT (tenant_name) DO NOTHING RETURNING id, tenant_name, created_at").
+		ToSql()
+
+	var newTenant models.Tenant
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&newTenant.ID, &newTenant.TenantName, &newTenant.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, repoerrs.ErrAlreadyExists
+		}
+		return nil, nil, fmt.Errorf("failed to insert tenant: %w", err)
+	}
+
+	created := make([]models.APIKey, 0, len(keys))
+	for _, key := range keys {
+		key.TenantID = newTenant.ID
+
+		apiKey, err := r.insertAPIKey(ctx, tx, key)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		created = append(created, *apiKey)
+	}
+
+	if err := writeAudit(tenant.WithID(ctx, newTenant.ID), r.Postgres, tx, models.AuditActionTenantCreate, models.AuditEntityTenant, tenantName,
+		nil, map[string]any{"api_keys": apiKeysAudit(created)},
+	); err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &newTenant, created, nil
+}
+
+func (r *TenantRepo) GetTenantByName(ctx context.Context, tenantName string) (*models.Tenant, error) {
+	sql, args, _ := r.Builder.
+		Select("id, tenant_name, created_at").
+		From("tenants").
+		Where("tenant_name = ?", tenantName).
+		ToSql()
+
+	var tenant models.Tenant
+	if err := r.Pool.QueryRow(ctx, sql, args...).Scan(&tenant.ID, &tenant.TenantName, &tenant.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	return &tenant, nil
+}
+
+func (r *TenantRepo) CreateAPIKey(ctx context.Context, key models.APIKey) (*models.APIKey, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	apiKey, err := r.insertAPIKey(ctx, tx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeAudit(tenant.WithID(ctx, apiKey.TenantID), r.Postgres, tx, models.AuditActionAPIKeyCreate, models.AuditEntityAPIKey, strconv.Itoa(apiKey.ID),
+		nil, map[string]any{"role": apiKey.Role},
+	); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return apiKey, nil
+}
+
+type queryRower interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func (r *TenantRepo) insertAPIKey(ctx context.Context, q queryRower, key models.APIKey) (*models.APIKey, error) {
+	sql, args, _ := r.Builder.
+		Insert("api_keys").
+		Columns("tenant_id, key_hash, role").
+		Values(key.TenantID, key.KeyHash, key.Role).
+		Suffix("RETURNING id, created_at").
+		ToSql()
+
+	if err := q.QueryRow(ctx, sql, args...).Scan(&key.ID, &key.CreatedAt); err != nil {
+		return nil, fmt.Errorf("failed to insert api key: %w", err)
+	}
+
+	return &key, nil
+}
+
+// GetAPIKeyByHash returns the non-revoked key with the given hash.
+func (r *TenantRepo) GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	sql, args, _ := r.Builder.
+		Select("id, tenant_id, key_hash, role, created_at, revoked_at").
+		From("api_keys").
+		Where("key_hash = ? AND revoked_at IS NULL", keyHash).
+		ToSql()
+
+	var key models.APIKey
+	if err := r.Pool.QueryRow(ctx, sql, args...).Scan(
+		&key.ID,
+		&key.TenantID,
+		&key.KeyHash,
+		&key.Role,
+		&key.CreatedAt,
+		&key.RevokedAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	return &key, nil
+}
+
+func (r *TenantRepo) RevokeAPIKey(ctx context.Context, keyID int) (keyRes *models.APIKey, alreadyRevoked bool, err error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	sql, args, _ := r.Builder.
+		Select("id, tenant_id, key_hash, role, created_at, revoked_at").
+		From("api_keys").
+		Where("id = ?", keyID).
+		Suffix("FOR UPDATE").
+		ToSql()
+
+	var key models.APIKey
+	if err := tx.QueryRow(ctx, sql, args...).Scan(
+		&key.ID,
+		&key.TenantID,
+		&key.KeyHash,
+		&key.Role,
+		&key.CreatedAt,
+		&key.RevokedAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, repoerrs.ErrNotFound
+		}
+		return nil, false, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	if key.RevokedAt != nil {
+		return &key, true, nil
+	}
+
+	sql, args, _ = r.Builder.
+		Update("api_keys").
+		Set("revoked_at", squirrel.Expr("NOW()")).
+		Where("id = ?", keyID).
+		Suffix("RETURNING revoked_at").
+		ToSql()
+
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&key.RevokedAt); err != nil {
+		return nil, false, fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	if err := writeAudit(tenant.WithID(ctx, key.TenantID), r.Postgres, tx, models.AuditActionAPIKeyRevoke, models.AuditEntityAPIKey, strconv.Itoa(key.ID),
+		map[string]any{"role": key.Role, "revoked_at": nil},
+		map[string]any{"role": key.Role, "revoked_at": key.RevokedAt},
+	); err != nil {
+		return nil, false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &key, false, nil
+}
+
+// apiKeysAudit lists the keys without their hashes.
+func apiKeysAudit(keys []models.APIKey) []map[string]any {
+	audited := make([]map[string]any, 0, len(keys))
+	for _, key := range keys {
+		audited = append(audited, map[string]any{"id": key.ID, "role": key.Role})
+	}
+
+	return audited
+}
@@ -4,13 +4,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
+	"github.com/Masterminds/squirrel"
 	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
 	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/tenant"
 	"github.com/MatTwix/Pull-Request-Assigner/pkg/database/postgres"
 	"github.com/jackc/pgx/v5"
 )
 
+// primaryTeamColumn selects primary team name of the user aliased as "u".
+const primaryTeamColumn = "COALESCE((SELECT p.team_name FROM team_members p WHERE p.tenant_id = u.tenant_id AND p.user_id = u.user_id AND p.is_primary), '')"
+
 type UserRepo struct {
 	*postgres.Postgres
 }
@@ -20,45 +26,192 @@ func NewUserRepo(pg *postgres.Postgres) *UserRepo {
 }
 
 func (r *UserRepo) SetIsActive(ctx context.Context, userID string, isActive bool) (userRes *models.User, alreadyUpdated bool, err error) {
-	user, err := r.GetUserByID(ctx, userID)
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	user, err := r.getUser(ctx, tx, userID, "FOR UPDATE OF u")
 	if err != nil {
 		return nil, false, err
 	}
 
 	alreadyUpdated = user.IsActive == isActive
 
-	if !alreadyUpdated {
+	if alreadyUpdated {
+		if err := checkVersion(ctx, user.Version); err != nil {
+			return nil, false, err
+		}
+	} else {
 		sql, args, _ := r.Builder.
 			Update("users").
 			Set("is_active", isActive).
-			Where("user_id = ?", userID).
+			Set("away_until", nil).
+			Set("version", bumpVersion).
+			Where("tenant_id = ? AND user_id = ?", tenant.ID(ctx), userID).
+			Where(expectedVersion(ctx, "version")).
+			Suffix("RETURNING version").
 			ToSql()
 
-		if _, err = r.Pool.Exec(ctx, sql, args...); err != nil {
+		// the user is locked, so only another version leaves nothing to update
+		if err = tx.QueryRow(ctx, sql, args...).Scan(&user.Version); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, false, repoerrs.ErrVersionMismatch
+			}
 			return nil, false, fmt.Errorf("failed to execute sql request: %w", err)
 		}
 
+		if err := writeAudit(ctx, r.Postgres, tx, models.AuditActionUserSetIsActive, models.AuditEntityUser, userID,
+			map[string]any{"is_active": user.IsActive},
+			map[string]any{"is_active": isActive},
+		); err != nil {
+			return nil, false, err
+		}
+
 		user.IsActive = isActive
+		user.AwayUntil = nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
 	return user, alreadyUpdated, nil
 }
 
+// SetAwayUntil deactivates the user until the day, ReturnAwayUsers activates
+// the user again once it has come.
+func (r *UserRepo) SetAwayUntil(ctx context.Context, userID string, until time.Time) (*models.User, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	user, err := r.getUser(ctx, tx, userID, "FOR UPDATE OF u")
+	if err != nil {
+		return nil, err
+	}
+
+	sql, args, _ := r.Builder.
+		Update("users").
+		Set("is_active", false).
+		Set("away_until", until).
+		Set("version", bumpVersion).
+		Where("tenant_id = ? AND user_id = ?", tenant.ID(ctx), userID).
+		Where(expectedVersion(ctx, "version")).
+		Suffix("RETURNING version").
+		ToSql()
+
+	var version int
+	if err = tx.QueryRow(ctx, sql, args...).Scan(&version); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrVersionMismatch
+		}
+		return nil, fmt.Errorf("failed to execute sql request: %w", err)
+	}
+
+	if err := writeAudit(ctx, r.Postgres, tx, models.AuditActionUserSetIsActive, models.AuditEntityUser, userID,
+		map[string]any{"is_active": user.IsActive, "away_until": user.AwayUntil},
+		map[string]any{"is_active": false, "away_until": until},
+	); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	user.IsActive = false
+	user.AwayUntil = &until
+	user.Version = version
+
+	return user, nil
+}
+
+// ReturnAwayUsers activates up to limit users whose away day has come, as of
+// the database date. Runs for all tenants.
+func (r *UserRepo) ReturnAwayUsers(ctx context.Context, limit uint64) (int64, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	sql, args, _ := r.Builder.
+		Update("users").
+		Set("is_active", true).
+		Set("away_until", nil).
+		Set("version", bumpVersion).
+		Where(`id IN (
+			SELECT id FROM users WHERE away_until <= CURRENT_DATE
+			ORDER BY away_until LIMIT ? FOR UPDATE SKIP LOCKED
+		)`, limit).
+		Suffix("RETURNING tenant_id, user_id").
+		ToSql()
+
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to return away users: %w", err)
+	}
+
+	type returned struct {
+		tenantID int
+		userID   string
+	}
+
+	users, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (returned, error) {
+		var u returned
+		err := row.Scan(&u.tenantID, &u.userID)
+		return u, err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to scan returned users: %w", err)
+	}
+
+	for _, u := range users {
+		if err := writeAudit(tenant.WithID(ctx, u.tenantID), r.Postgres, tx, models.AuditActionUserSetIsActive, models.AuditEntityUser, u.userID,
+			map[string]any{"is_active": false},
+			map[string]any{"is_active": true},
+		); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return int64(len(users)), nil
+}
+
 func (r *UserRepo) GetUserByID(ctx context.Context, userID string) (*models.User, error) {
+	return r.getUser(ctx, r.Pool, userID, "")
+}
+
+// getUser selects the user, lock is an optional locking clause.
+func (r *UserRepo) getUser(ctx context.Context, q queryRower, userID, lock string) (*models.User, error) {
 	sql, args, _ := r.Builder.
-		Select("id, username, team_name, is_active").
-		From("users").
-		Where("user_id = ?", userID).
+		Select("u.id, u.username").
+		Column(primaryTeamColumn).
+		Column("u.is_active, COALESCE(u.email, ''), u.away_until, u.version").
+		From("users u").
+		Where("u.tenant_id = ? AND u.user_id = ?", tenant.ID(ctx), userID).
+		Suffix(lock).
 		ToSql()
 
 	user := models.User{
 		UserID: userID,
 	}
-	err := r.Pool.QueryRow(ctx, sql, args...).Scan(
+	err := q.QueryRow(ctx, sql, args...).Scan(
 		&user.ID,
 		&user.Username,
 		&user.TeamName,
 		&user.IsActive,
+		&user.Email,
+		&user.AwayUntil,
+		&user.Version,
 	)
 
 	if err != nil {
@@ -73,8 +226,10 @@ func (r *UserRepo) GetUserByID(ctx context.Context, userID string) (*models.User
 
 func (r *UserRepo) GetActiveUsersByTeam(ctx context.Context, teamName string) ([]models.User, error) {
 	sql, args, _ := r.Builder.
-		Select("id, user_id, username, is_active").
-		From("users").Where("team_name = ? AND is_active = TRUE", teamName).
+		Select("u.id, u.user_id, u.username, u.is_active").
+		From("users u").
+		Join("team_members tm ON tm.tenant_id = u.tenant_id AND tm.user_id = u.user_id").
+		Where("u.tenant_id = ? AND tm.team_name = ? AND u.is_active = TRUE", tenant.ID(ctx), teamName).
 		ToSql()
 
 	rows, err := r.Pool.Query(ctx, sql, args...)
@@ -107,12 +262,14 @@ func (r *UserRepo) GetActiveUsersByTeam(ctx context.Context, teamName string) ([
 	return users, nil
 }
 
+// GetReviewPRsByUserID returns pull requests the user reviews, archived ones
+// included.
 func (r *UserRepo) GetReviewPRsByUserID(ctx context.Context, userID string) ([]models.PullRequest, error) {
 	sql, args, _ := r.Builder.
-		Select("pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status").
-		From("pull_requests pr").
-		Join("pull_request_reviewers prr ON pr.pull_request_id = prr.pull_request_id").
-		Where("prr.reviewer_id = ?", userID).
+		Select("pr.pull_request_id, pr.pull_request_name, pr.author_id, COALESCE(pr.team_name, ''), pr.status").
+		From("all_pull_requests pr").
+		Join("all_pull_request_reviewers prr ON prr.tenant_id = pr.tenant_id AND prr.pull_request_id = pr.pull_request_id").
+		Where("pr.tenant_id = ? AND prr.reviewer_id = ? AND prr.reassigned_at IS NULL", tenant.ID(ctx), userID).
 		ToSql()
 
 	rows, err := r.Pool.Query(ctx, sql, args...)
@@ -129,6 +286,7 @@ func (r *UserRepo) GetReviewPRsByUserID(ctx context.Context, userID string) ([]m
 			&pullRequest.PullRequestID,
 			&pullRequest.PullRequestName,
 			&pullRequest.AuthorID,
+			&pullRequest.TeamName,
 			&pullRequest.Status,
 		)
 
@@ -141,3 +299,288 @@ func (r *UserRepo) GetReviewPRsByUserID(ctx context.Context, userID string) ([]m
 
 	return pullRequests, nil
 }
+
+func (r *UserRepo) GetUserTeams(ctx context.Context, userID string) ([]models.TeamMember, error) {
+	sql, args, _ := r.Builder.
+		Select("team_name, is_primary").
+		From("team_members").
+		Where("tenant_id = ? AND user_id = ?", tenant.ID(ctx), userID).
+		OrderBy("is_primary DESC", "team_name").
+		ToSql()
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rows: %w", err)
+	}
+	defer rows.Close()
+
+	var teams []models.TeamMember
+	for rows.Next() {
+		team := models.TeamMember{UserID: userID}
+
+		if err := rows.Scan(&team.TeamName, &team.IsPrimary); err != nil {
+			return nil, fmt.Errorf("failed to scan rows: %w", err)
+		}
+
+		teams = append(teams, team)
+	}
+
+	return teams, nil
+}
+
+func (r *UserRepo) SetPrimaryTeam(ctx context.Context, userID, teamName string) (*models.User, error) {
+	tenantID := tenant.ID(ctx)
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	user := models.User{
+		UserID:   userID,
+		TeamName: teamName,
+	}
+
+	var prevTeamName string
+	sql, args, _ := r.Builder.
+		Select("u.id, u.username, u.is_active, u.away_until, "+primaryTeamColumn).
+		From("users u").
+		Where("u.tenant_id = ? AND u.user_id = ?", tenantID, userID).
+		Suffix("FOR UPDATE").
+		ToSql()
+
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&user.ID, &user.Username, &user.IsActive, &user.AwayUntil, &prevTeamName); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	sql, args, _ = r.Builder.
+		Select("1").
+		From("team_members").
+		Where("tenant_id = ? AND user_id = ? AND team_name = ?", tenantID, userID, teamName).
+		ToSql()
+
+	var exists int
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&exists); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrNotMember
+		}
+		return nil, fmt.Errorf("failed to check membership: %w", err)
+	}
+
+	sql, args, _ = r.Builder.
+		Update("users").
+		Set("version", bumpVersion).
+		Where("tenant_id = ? AND user_id = ?", tenantID, userID).
+		Where(expectedVersion(ctx, "version")).
+		Suffix("RETURNING version").
+		ToSql()
+
+	// the user is locked, so only another version leaves nothing to update
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&user.Version); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrVersionMismatch
+		}
+		return nil, fmt.Errorf("failed to update user version: %w", err)
+	}
+
+	// primary flag is unique per user, so the old one has to be cleared first
+	sql, args, _ = r.Builder.
+		Update("team_members").
+		Set("is_primary", false).
+		Where("tenant_id = ? AND user_id = ? AND is_primary AND team_name != ?", tenantID, userID, teamName).
+		ToSql()
+
+	if _, err := tx.Exec(ctx, sql, args...); err != nil {
+		return nil, fmt.Errorf("failed to reset primary team: %w", err)
+	}
+
+	sql, args, _ = r.Builder.
+		Update("team_members").
+		Set("is_primary", true).
+		Where("tenant_id = ? AND user_id = ? AND team_name = ?", tenantID, userID, teamName).
+		ToSql()
+
+	if _, err := tx.Exec(ctx, sql, args...); err != nil {
+		return nil, fmt.Errorf("failed to set primary team: %w", err)
+	}
+
+	if err := writeAudit(ctx, r.Postgres, tx, models.AuditActionUserSetPrimaryTeam, models.AuditEntityUser, userID,
+		map[string]any{"primary_team_name": nullIfEmpty(prevTeamName)},
+		map[string]any{"primary_team_name": teamName},
+	); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &user, nil
+}
+
+// SetEmail sets the email address of the user, empty clears it.
+func (r *UserRepo) SetEmail(ctx context.Context, userID, email string) (*models.User, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var prevEmail string
+	sql, args, _ := r.Builder.
+		Select("COALESCE(email, '')").
+		From("users").
+		Where("tenant_id = ? AND user_id = ?", tenant.ID(ctx), userID).
+		Suffix("FOR UPDATE").
+		ToSql()
+
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&prevEmail); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get user email: %w", err)
+	}
+
+	sql, args, _ = r.Builder.
+		Update("users u").
+		Set("email", nullIfEmpty(email)).
+		Set("version", bumpVersion).
+		Where("u.tenant_id = ? AND u.user_id = ?", tenant.ID(ctx), userID).
+		Where(expectedVersion(ctx, "u.version")).
+		Suffix("RETURNING u.id, u.username, " + primaryTeamColumn + ", u.is_active, COALESCE(u.email, ''), u.version").
+		ToSql()
+
+	user := models.User{UserID: userID}
+	// the user is locked, so only another version leaves nothing to update
+	if err := tx.QueryRow(ctx, sql, args...).Scan(
+		&user.ID,
+		&user.Username,
+		&user.TeamName,
+		&user.IsActive,
+		&user.Email,
+		&user.Version,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrVersionMismatch
+		}
+		return nil, fmt.Errorf("failed to update user email: %w", err)
+	}
+
+	if err := writeAudit(ctx, r.Postgres, tx, models.AuditActionUserSetEmail, models.AuditEntityUser, userID,
+		map[string]any{"email": nullIfEmpty(prevEmail)},
+		map[string]any{"email": nullIfEmpty(email)},
+	); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &user, nil
+}
+
+// GetNotificationPreferences returns the notification preferences of the
+// user, the defaults when the user has not set them.
+func (r *UserRepo) GetNotificationPreferences(ctx context.Context, userID string) (*models.NotificationPreferences, error) {
+	sql, args, _ := r.Builder.
+		Select(`COALESCE(p.channels, '{}'), COALESCE(p.event_types, '{}'), COALESCE(p.timezone, 'UTC'),
+			to_char(p.quiet_hours_start, 'HH24:MI'), to_char(p.quiet_hours_end, 'HH24:MI'), p.updated_at`).
+		From("users u").
+		LeftJoin("notification_preferences p ON p.tenant_id = u.tenant_id AND p.user_id = u.user_id").
+		Where("u.tenant_id = ? AND u.user_id = ?", tenant.ID(ctx), userID).
+		ToSql()
+
+	preferences := models.NotificationPreferences{UserID: userID}
+	var quietStart, quietEnd *string
+	if err := r.Pool.QueryRow(ctx, sql, args...).Scan(
+		&preferences.Channels,
+		&preferences.EventTypes,
+		&preferences.Timezone,
+		&quietStart,
+		&quietEnd,
+		&preferences.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+
+	preferences.QuietHours = quietHours(quietStart, quietEnd)
+
+	return &preferences, nil
+}
+
+// SetNotificationPreferences replaces the notification preferences of the
+// user.
+func (r *UserRepo) SetNotificationPreferences(ctx context.Context, preferences models.NotificationPreferences) (*models.NotificationPreferences, error) {
+	tenantID := tenant.ID(ctx)
+
+	if preferences.Channels == nil {
+		preferences.Channels = []string{}
+	}
+	if preferences.EventTypes == nil {
+		preferences.EventTypes = []string{}
+	}
+
+	sql, args, _ := r.Builder.
+		Select("1").
+		From("users").
+		Where("tenant_id = ? AND user_id = ?", tenantID, preferences.UserID).
+		ToSql()
+
+	var exists int
+	if err := r.Pool.QueryRow(ctx, sql, args...).Scan(&exists); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to check user existence: %w", err)
+	}
+
+	var quietStart, quietEnd any
+	if preferences.QuietHours != nil {
+		quietStart, quietEnd = preferences.QuietHours.Start, preferences.QuietHours.End
+	}
+
+	sql, args, _ = r.Builder.
+		Insert("notification_preferences").
+		Columns("tenant_id, user_id, channels, event_types, timezone, quiet_hours_start, quiet_hours_end").
+		Values(
+			tenantID,
+			preferences.UserID,
+			preferences.Channels,
+			preferences.EventTypes,
+			preferences.Timezone,
+			squirrel.Expr("?::TIME", quietStart),
+			squirrel.Expr("?::TIME", quietEnd),
+		).
+		Suffix(`ON CONFLICT (tenant_id, user_id) DO UPDATE SET
+			channels = EXCLUDED.channels,
+			event_types = EXCLUDED.event_types,
+			timezone = EXCLUDED.timezone,
+			quiet_hours_start = EXCLUDED.quiet_hours_start,
+			quiet_hours_end = EXCLUDED.quiet_hours_end,
+			updated_at = NOW()
+		RETURNING updated_at`).
+		ToSql()
+
+	if err := r.Pool.QueryRow(ctx, sql, args...).Scan(&preferences.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("failed to set notification preferences: %w", err)
+	}
+
+	return &preferences, nil
+}
+
+// quietHours makes quiet hours of nullable "HH24:MI" columns.
+func quietHours(start, end *string) *models.QuietHours {
+	if start == nil || end == nil {
+		return nil
+	}
+
+	return &models.QuietHours{Start: *start, End: *end}
+}
@@ -0,0 +1,284 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/tenant"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/database/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+type VCSRepo struct {
+	*postgres.Postgres
+}
+
+func NewVCSRepo(pg *postgres.Postgres) *VCSRepo {
+	return &VCSRepo{pg}
+}
+
+func (r *VCSRepo) SetVCSWebhookSecret(ctx context.Context, provider, secret string) error {
+	sql, args, _ := r.Builder.
+		Insert("vcs_integrations").
+		Columns("tenant_id, provider, webhook_secret").
+		Values(tenant.ID(ctx), provider, secret).
+		Suffix("ON CONFLICT (tenant_id, provider) DO UPDATE SET webhook_secret = EXCLUDED.webhook_secret, updated_at = NOW()").
+		ToSql()
+
+	// the secret itself is not written to the audit log
+	if _, err := execAudited(ctx, r.Postgres, sql, args, models.AuditActionVCSSetWebhookSecret, models.AuditEntityVCS, provider,
+		nil, map[string]any{"webhook_secret_set": true},
+	); err != nil {
+		return fmt.Errorf("failed to set webhook secret: %w", err)
+	}
+
+	return nil
+}
+
+func (r *VCSRepo) GetVCSWebhookSecret(ctx context.Context, provider string) (string, error) {
+	sql, args, _ := r.Builder.
+		Select("webhook_secret").
+		From("vcs_integrations").
+		Where("tenant_id = ? AND provider = ? AND webhook_secret IS NOT NULL", tenant.ID(ctx), provider).
+		ToSql()
+
+	var secret string
+	if err := r.Pool.QueryRow(ctx, sql, args...).Scan(&secret); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", repoerrs.ErrNotFound
+		}
+		return "", fmt.Errorf("failed to get webhook secret: %w", err)
+	}
+
+	return secret, nil
+}
+
+// SetVCSAPIToken sets the token reviewers are requested in the provider with,
+// an empty token disables requesting reviewers.
+func (r *VCSRepo) SetVCSAPIToken(ctx context.Context, provider, token string) error {
+	sql, args, _ := r.Builder.
+		Insert("vcs_integrations").
+		Columns("tenant_id, provider, api_token").
+		Values(tenant.ID(ctx), provider, nullIfEmpty(token)).
+		Suffix("ON CONFLICT (tenant_id, provider) DO UPDATE SET api_token = EXCLUDED.api_token, updated_at = NOW()").
+		ToSql()
+
+	if _, err := execAudited(ctx, r.Postgres, sql, args, models.AuditActionVCSSetAPIToken, models.AuditEntityVCS, provider,
+		nil, map[string]any{"api_token_set": token != ""},
+	); err != nil {
+		return fmt.Errorf("failed to set api token: %w", err)
+	}
+
+	return nil
+}
+
+// SetVCSIdentity maps the login to the user, replacing the previous mapping
+// of the login. The account id is moved from the login it was mapped with.
+func (r *VCSRepo) SetVCSIdentity(ctx context.Context, identity models.VCSIdentity) (*models.VCSIdentity, error) {
+	tenantID := tenant.ID(ctx)
+
+	sql, args, _ := r.Builder.
+		Select("1").
+		From("users").
+		Where("tenant_id = ? AND user_id = ?", tenantID, identity.UserID).
+		ToSql()
+
+	var exists int
+	if err := r.Pool.QueryRow(ctx, sql, args...).Scan(&exists); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to check user existence: %w", err)
+	}
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if identity.AccountID != "" {
+		sql, args, _ = r.Builder.
+			Update("vcs_identities").
+			Set("account_id", nil).
+			Where("tenant_id = ? AND provider = ? AND account_id = ? AND login <> ?", tenantID, identity.Provider, identity.AccountID, identity.Login).
+			ToSql()
+
+		if _, err := tx.Exec(ctx, sql, args...); err != nil {
+			return nil, fmt.Errorf("failed to release vcs account id: %w", err)
+		}
+	}
+
+	sql, args, _ = r.Builder.
+		Insert("vcs_identities").
+		Columns("tenant_id, provider, login, account_id, user_id").
+		Values(tenantID, identity.Provider, identity.Login, nullIfEmpty(identity.AccountID), identity.UserID).
+		Suffix("ON CONFLICT (tenant_id, provider, login) DO UPDATE SET account_id = EXCLUDED.account_id, user_id = EXCLUDED.user_id RETURNING created_at").
+		ToSql()
+
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&identity.CreatedAt); err != nil {
+		return nil, fmt.Errorf("failed to set vcs identity: %w", err)
+	}
+
+	if err := writeAudit(ctx, r.Postgres, tx, models.AuditActionVCSSetIdentity, models.AuditEntityVCS, identity.Provider,
+		nil, map[string]any{"login": identity.Login, "account_id": identity.AccountID, "user_id": identity.UserID},
+	); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &identity, nil
+}
+
+func (r *VCSRepo) DeleteVCSIdentity(ctx context.Context, provider, login string) error {
+	sql, args, _ := r.Builder.
+		Delete("vcs_identities").
+		Where("tenant_id = ? AND provider = ? AND login = ?", tenant.ID(ctx), provider, login).
+		ToSql()
+
+	tag, err := execAudited(ctx, r.Postgres, sql, args, models.AuditActionVCSDeleteIdentity, models.AuditEntityVCS, provider,
+		map[string]any{"login": login}, nil,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete vcs identity: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repoerrs.ErrNotFound
+	}
+
+	return nil
+}
+
+// GetVCSIdentities returns the mappings of the provider, of all providers when
+// provider is empty.
+func (r *VCSRepo) GetVCSIdentities(ctx context.Context, provider string) ([]models.VCSIdentity, error) {
+	query := r.Builder.
+		Select("provider, login, COALESCE(account_id, ''), user_id, created_at").
+		From("vcs_identities").
+		Where("tenant_id = ?", tenant.ID(ctx)).
+		OrderBy("provider", "login")
+
+	if provider != "" {
+		query = query.Where("provider = ?", provider)
+	}
+
+	return collect(func(fn func(models.VCSIdentity) error) error {
+		return streamRows(ctx, r.Postgres, query, "vcs identities", func(rows pgx.Rows, identity *models.VCSIdentity) error {
+			return rows.Scan(&identity.Provider, &identity.Login, &identity.AccountID, &identity.UserID, &identity.CreatedAt)
+		}, fn)
+	})
+}
+
+// GetVCSUserID returns the user mapped to the login or ErrNotFound.
+func (r *VCSRepo) GetVCSUserID(ctx context.Context, provider, login string) (string, error) {
+	return r.getVCSUserID(ctx, squirrel.Eq{"tenant_id": tenant.ID(ctx), "provider": provider, "login": login})
+}
+
+// GetVCSUserIDByAccount returns the user mapped to the account id or
+// ErrNotFound.
+func (r *VCSRepo) GetVCSUserIDByAccount(ctx context.Context, provider, accountID string) (string, error) {
+	return r.getVCSUserID(ctx, squirrel.Eq{"tenant_id": tenant.ID(ctx), "provider": provider, "account_id": accountID})
+}
+
+func (r *VCSRepo) getVCSUserID(ctx context.Context, identity squirrel.Eq) (string, error) {
+	sql, args, _ := r.Builder.
+		Select("user_id").
+		From("vcs_identities").
+		Where(identity).
+		ToSql()
+
+	var userID string
+	if err := r.Pool.QueryRow(ctx, sql, args...).Scan(&userID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", repoerrs.ErrNotFound
+		}
+		return "", fmt.Errorf("failed to get vcs identity: %w", err)
+	}
+
+	return userID, nil
+}
+
+// ClaimVCSDelivery records the delivery before it is processed, for the lease.
+// When the delivery was already received, it returns the recorded one with
+// claimed false; its result is empty while it is still processed. A delivery
+// without result whose lease has expired is claimed again.
+func (r *VCSRepo) ClaimVCSDelivery(ctx context.Context, delivery models.VCSDelivery, lease time.Duration) (recorded *models.VCSDelivery, claimed bool, err error) {
+	tenantID := tenant.ID(ctx)
+
+	sql, args, _ := r.Builder.
+		Insert("vcs_webhook_deliveries").
+		Columns("tenant_id, provider, delivery_id, event, action, pull_request_id, locked_until").
+		Values(tenantID, delivery.Provider, delivery.DeliveryID, delivery.Event, delivery.Action, nullIfEmpty(delivery.PullRequestID),
+			squirrel.Expr("NOW() + make_interval(secs => ?)", lease.Seconds())).
+		Suffix(`ON CONFLICT (tenant_id, provider, delivery_id) DO UPDATE SET locked_until = EXCLUDED.locked_until
+			WHERE vcs_webhook_deliveries.result IS NULL AND vcs_webhook_deliveries.locked_until <= NOW()
+			RETURNING received_at`).
+		ToSql()
+
+	if err := r.Pool.QueryRow(ctx, sql, args...).Scan(&delivery.ReceivedAt); err == nil {
+		return &delivery, true, nil
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, fmt.Errorf("failed to claim vcs delivery: %w", err)
+	}
+
+	sql, args, _ = r.Builder.
+		Select("provider, delivery_id, event, action, COALESCE(pull_request_id, ''), COALESCE(result, ''), received_at").
+		From("vcs_webhook_deliveries").
+		Where("tenant_id = ? AND provider = ? AND delivery_id = ?", tenantID, delivery.Provider, delivery.DeliveryID).
+		ToSql()
+
+	var existing models.VCSDelivery
+	if err := r.Pool.QueryRow(ctx, sql, args...).Scan(
+		&existing.Provider,
+		&existing.DeliveryID,
+		&existing.Event,
+		&existing.Action,
+		&existing.PullRequestID,
+		&existing.Result,
+		&existing.ReceivedAt,
+	); err != nil {
+		// released by a failed attempt in between on pgx.ErrNoRows, the
+		// sender retries the delivery on the error
+		return nil, false, fmt.Errorf("failed to get vcs delivery: %w", err)
+	}
+
+	return &existing, false, nil
+}
+
+func (r *VCSRepo) FinishVCSDelivery(ctx context.Context, provider, deliveryID, result string) error {
+	sql, args, _ := r.Builder.
+		Update("vcs_webhook_deliveries").
+		Set("result", result).
+		Where("tenant_id = ? AND provider = ? AND delivery_id = ?", tenant.ID(ctx), provider, deliveryID).
+		ToSql()
+
+	if _, err := r.Pool.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("failed to finish vcs delivery: %w", err)
+	}
+
+	return nil
+}
+
+// ReleaseVCSDelivery forgets the delivery that failed to process, so its
+// redelivery is processed again.
+func (r *VCSRepo) ReleaseVCSDelivery(ctx context.Context, provider, deliveryID string) error {
+	sql, args, _ := r.Builder.
+		Delete("vcs_webhook_deliveries").
+		Where("tenant_id = ? AND provider = ? AND delivery_id = ? AND result IS NULL", tenant.ID(ctx), provider, deliveryID).
+		ToSql()
+
+	if _, err := r.Pool.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("failed to release vcs delivery: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/precondition"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/database/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+// bumpVersion is set to the version column by every update of teams, users
+// and pull requests.
+var bumpVersion = squirrel.Expr("version + 1")
+
+// expectedVersion narrows the update to the version the request expects the
+// entity to have, if any. The update of a changed entity matches no rows.
+func expectedVersion(ctx context.Context, column string) squirrel.Sqlizer {
+	if version, ok := precondition.Version(ctx); ok {
+		return squirrel.Eq{column: version}
+	}
+
+	return squirrel.And{}
+}
+
+// checkVersion compares the current version of the entity, read under lock,
+// with the one the request expects.
+func checkVersion(ctx context.Context, current int) error {
+	if version, ok := precondition.Version(ctx); ok && version != current {
+		return repoerrs.ErrVersionMismatch
+	}
+
+	return nil
+}
+
+// notUpdated tells why the update narrowed by expectedVersion matched no rows:
+// the entity selected by where is missing or has another version.
+func notUpdated(ctx context.Context, pg *postgres.Postgres, q queryRower, table string, where squirrel.Sqlizer) error {
+	if _, ok := precondition.Version(ctx); !ok {
+		return repoerrs.ErrNotFound
+	}
+
+	sql, args, _ := pg.Builder.
+		Select("1").
+		From(table).
+		Where(where).
+		ToSql()
+
+	var exists int
+	if err := q.QueryRow(ctx, sql, args...).Scan(&exists); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repoerrs.ErrNotFound
+		}
+		return fmt.Errorf("failed to check %s existence: %w", table, err)
+	}
+
+	return repoerrs.ErrVersionMismatch
+}
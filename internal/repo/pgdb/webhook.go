@@ -0,0 +1,476 @@
+package pgdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/tenant"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/database/postgres"
+	"github.com/jackc/pgx/v5"
+)
+
+const webhookSubscriptionColumns = "id, url, secret, event_types, is_active, created_at"
+
+const webhookDeliveryColumns = `d.id, d.event_id, d.subscription_id, d.status, d.attempts, d.next_attempt_at,
+	d.last_status_code, COALESCE(d.last_error, ''), d.created_at, d.delivered_at`
+
+type WebhookRepo struct {
+	*postgres.Postgres
+}
+
+func NewWebhookRepo(pg *postgres.Postgres) *WebhookRepo {
+	return &WebhookRepo{pg}
+}
+
+func (r *WebhookRepo) CreateWebhookSubscription(ctx context.Context, subscription models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	if subscription.EventTypes == nil {
+		subscription.EventTypes = []string{}
+	}
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	sql, args, _ := r.Builder.
+		Insert("webhook_subscriptions").
+		Columns("tenant_id, url, secret, event_types").
+		Values(tenant.ID(ctx), subscription.URL, subscription.Secret, subscription.EventTypes).
+		Suffix("ON CONFLICT (tenant_id, url) DO NOTHING RETURNING " + webhookSubscriptionColumns).
+		ToSql()
+
+	created, err := scanWebhookSubscription(tx.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, repoerrs.ErrNotFound) {
+			return nil, repoerrs.ErrAlreadyExists
+		}
+		return nil, err
+	}
+
+	if err := writeAudit(ctx, r.Postgres, tx, models.AuditActionWebhookCreate, models.AuditEntityWebhookSubscription, strconv.Itoa(created.ID),
+		nil, webhookSubscriptionAudit(*created),
+	); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return created, nil
+}
+
+func (r *WebhookRepo) GetWebhookSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	query := r.Builder.
+		Select(webhookSubscriptionColumns).
+		From("webhook_subscriptions").
+		Where("tenant_id = ?", tenant.ID(ctx)).
+		OrderBy("id")
+
+	return collect(func(fn func(models.WebhookSubscription) error) error {
+		return streamRows(ctx, r.Postgres, query, "webhook subscriptions", func(rows pgx.Rows, subscription *models.WebhookSubscription) error {
+			return scanWebhookSubscriptionInto(rows, subscription)
+		}, fn)
+	})
+}
+
+func (r *WebhookRepo) SetWebhookSubscriptionIsActive(ctx context.Context, subscriptionID int, isActive bool) (*models.WebhookSubscription, error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	prev, err := r.lockWebhookSubscription(ctx, tx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	sql, args, _ := r.Builder.
+		Update("webhook_subscriptions").
+		Set("is_active", isActive).
+		Where("id = ?", subscriptionID).
+		Suffix("RETURNING " + webhookSubscriptionColumns).
+		ToSql()
+
+	subscription, err := scanWebhookSubscription(tx.QueryRow(ctx, sql, args...))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeAudit(ctx, r.Postgres, tx, models.AuditActionWebhookSetIsActive, models.AuditEntityWebhookSubscription, strconv.Itoa(subscriptionID),
+		map[string]any{"is_active": prev.IsActive},
+		map[string]any{"is_active": isActive},
+	); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return subscription, nil
+}
+
+// DeleteWebhookSubscription removes the subscription together with its
+// deliveries.
+func (r *WebhookRepo) DeleteWebhookSubscription(ctx context.Context, subscriptionID int) error {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	prev, err := r.lockWebhookSubscription(ctx, tx, subscriptionID)
+	if err != nil {
+		return err
+	}
+
+	sql, args, _ := r.Builder.
+		Delete("webhook_subscriptions").
+		Where("id = ?", subscriptionID).
+		ToSql()
+
+	if _, err := tx.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+
+	if err := writeAudit(ctx, r.Postgres, tx, models.AuditActionWebhookDelete, models.AuditEntityWebhookSubscription, strconv.Itoa(subscriptionID),
+		webhookSubscriptionAudit(*prev), nil,
+	); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *WebhookRepo) lockWebhookSubscription(ctx context.Context, tx pgx.Tx, subscriptionID int) (*models.WebhookSubscription, error) {
+	sql, args, _ := r.Builder.
+		Select(webhookSubscriptionColumns).
+		From("webhook_subscriptions").
+		Where("tenant_id = ? AND id = ?", tenant.ID(ctx), subscriptionID).
+		Suffix("FOR UPDATE").
+		ToSql()
+
+	return scanWebhookSubscription(tx.QueryRow(ctx, sql, args...))
+}
+
+// webhookSubscriptionAudit is the state of the subscription kept in the audit
+// log, the signing secret is left out.
+func webhookSubscriptionAudit(subscription models.WebhookSubscription) map[string]any {
+	return map[string]any{
+		"url":         subscription.URL,
+		"event_types": subscription.EventTypes,
+		"is_active":   subscription.IsActive,
+	}
+}
+
+// GetWebhookDeliveries returns deliveries matching the filter, newest first.
+func (r *WebhookRepo) GetWebhookDeliveries(ctx context.Context, filter models.WebhookDeliveryFilter) ([]models.WebhookDelivery, error) {
+	query := r.Builder.
+		Select(webhookDeliveryColumns).
+		Column("e.event_type").
+		From("webhook_deliveries d").
+		Join("outbox_events e ON e.id = d.event_id").
+		Where("d.tenant_id = ?", tenant.ID(ctx)).
+		OrderBy("d.id DESC").
+		Limit(filter.Limit)
+
+	if filter.SubscriptionID != 0 {
+		query = query.Where("d.subscription_id = ?", filter.SubscriptionID)
+	}
+	if filter.Status != "" {
+		query = query.Where("d.status = ?", filter.Status)
+	}
+	if filter.Cursor > 0 {
+		query = query.Where("d.id < ?", filter.Cursor)
+	}
+
+	return collect(func(fn func(models.WebhookDelivery) error) error {
+		return streamRows(ctx, r.Postgres, query, "webhook deliveries", func(rows pgx.Rows, delivery *models.WebhookDelivery) error {
+			return rows.Scan(append(webhookDeliveryDest(delivery), &delivery.Event.EventType)...)
+		}, fn)
+	})
+}
+
+// RetryWebhookDelivery puts the dead delivery back to the queue with a fresh
+// attempts budget. Deliveries in other states are returned as is.
+func (r *WebhookRepo) RetryWebhookDelivery(ctx context.Context, deliveryID int64) (deliveryRes *models.WebhookDelivery, requeued bool, err error) {
+	tenantID := tenant.ID(ctx)
+
+	sql, args, _ := r.Builder.
+		Update("webhook_deliveries d").
+		Set("status", models.DeliveryStatusPending).
+		Set("attempts", 0).
+		Set("next_attempt_at", squirrel.Expr("NOW()")).
+		Where("d.tenant_id = ? AND d.id = ? AND d.status = ?", tenantID, deliveryID, models.DeliveryStatusDead).
+		Suffix("RETURNING " + webhookDeliveryColumns).
+		ToSql()
+
+	var delivery models.WebhookDelivery
+	err = r.Pool.QueryRow(ctx, sql, args...).Scan(webhookDeliveryDest(&delivery)...)
+	if err == nil {
+		return &delivery, true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, fmt.Errorf("failed to retry webhook delivery: %w", err)
+	}
+
+	sql, args, _ = r.Builder.
+		Select(webhookDeliveryColumns).
+		From("webhook_deliveries d").
+		Where("d.tenant_id = ? AND d.id = ?", tenantID, deliveryID).
+		ToSql()
+
+	if err := r.Pool.QueryRow(ctx, sql, args...).Scan(webhookDeliveryDest(&delivery)...); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, repoerrs.ErrNotFound
+		}
+		return nil, false, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+
+	return &delivery, false, nil
+}
+
+// FanOutOutboxEvents creates deliveries of up to limit undispatched events for
+// every active subscription of their tenants and marks events dispatched.
+// Events without subscribers are only marked. Runs for all tenants.
+func (r *WebhookRepo) FanOutOutboxEvents(ctx context.Context, limit uint64) (events int64, err error) {
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	sql, args, _ := r.Builder.
+		Update("outbox_events").
+		Set("dispatched_at", squirrel.Expr("NOW()")).
+		Where(`id IN (
+			SELECT id FROM outbox_events WHERE dispatched_at IS NULL ORDER BY id LIMIT ? FOR UPDATE SKIP LOCKED
+		)`, limit).
+		Suffix("RETURNING id").
+		ToSql()
+
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark outbox events: %w", err)
+	}
+
+	eventIDs, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return 0, fmt.Errorf("failed to collect outbox events: %w", err)
+	}
+
+	if len(eventIDs) == 0 {
+		return 0, nil
+	}
+
+	sql, args, _ = r.Builder.
+		Insert("webhook_deliveries").
+		Columns("tenant_id, event_id, subscription_id").
+		Select(squirrel.
+			Select("e.tenant_id, e.id, s.id").
+			From("outbox_events e").
+			Join("webhook_subscriptions s ON s.tenant_id = e.tenant_id").
+			Where(squirrel.Eq{"e.id": eventIDs}).
+			Where("s.is_active AND (cardinality(s.event_types) = 0 OR e.event_type = ANY(s.event_types))"),
+		).
+		Suffix("ON CONFLICT (event_id, subscription_id) DO NOTHING").
+		ToSql()
+
+	if _, err := tx.Exec(ctx, sql, args...); err != nil {
+		return 0, fmt.Errorf("failed to insert webhook deliveries: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return int64(len(eventIDs)), nil
+}
+
+// ClaimWebhookDeliveries takes up to limit due pending deliveries of all
+// tenants and postpones them by lease, so other dispatchers skip them while
+// they are being sent. Unfinished deliveries are retried after the lease by
+// whoever claims them next, with a new lease token.
+func (r *WebhookRepo) ClaimWebhookDeliveries(ctx context.Context, limit uint64, lease time.Duration) ([]models.WebhookDelivery, error) {
+	sql, args, _ := r.Builder.
+		Update("webhook_deliveries d").
+		Set("next_attempt_at", squirrel.Expr("NOW() + make_interval(secs => ?)", lease.Seconds())).
+		Set("lease_token", squirrel.Expr("gen_random_uuid()")).
+		From("outbox_events e, webhook_subscriptions s").
+		Where(`d.id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = ? AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at LIMIT ? FOR UPDATE SKIP LOCKED
+		)`, models.DeliveryStatusPending, limit).
+		Where("e.id = d.event_id AND s.id = d.subscription_id").
+		Suffix("RETURNING " + webhookDeliveryColumns + ", e.tenant_id, e.event_type, e.payload, e.created_at, s.url, s.secret, d.lease_token::text").
+		ToSql()
+
+	rows, err := r.Pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+		if err := rows.Scan(append(webhookDeliveryDest(&delivery),
+			&delivery.Event.TenantID,
+			&delivery.Event.EventType,
+			&delivery.Event.Payload,
+			&delivery.Event.CreatedAt,
+			&delivery.URL,
+			&delivery.Secret,
+			&delivery.LeaseToken,
+		)...); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+
+		delivery.Event.ID = delivery.EventID
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// FinishWebhookDelivery records an attempt of the claimed delivery. Successful
+// attempts deliver it, failed ones are retried after retryAfter, or the
+// delivery is dead when retryAfter is nil. Returns repoerrs.ErrLeaseLost if
+// the delivery was claimed again after its lease expired.
+func (r *WebhookRepo) FinishWebhookDelivery(ctx context.Context, deliveryID int64, leaseToken string, delivered bool, statusCode int, lastError string, retryAfter *time.Duration) error {
+	update := r.Builder.
+		Update("webhook_deliveries").
+		Set("attempts", squirrel.Expr("attempts + 1")).
+		Set("last_status_code", nullIfZero(statusCode)).
+		Set("last_error", nullIfEmpty(lastError)).
+		Set("lease_token", nil).
+		Where("id = ? AND status = ? AND lease_token = ?", deliveryID, models.DeliveryStatusPending, leaseToken)
+
+	switch {
+	case delivered:
+		update = update.
+			Set("status", models.DeliveryStatusDelivered).
+			Set("delivered_at", squirrel.Expr("NOW()"))
+	case retryAfter != nil:
+		update = update.Set("next_attempt_at", squirrel.Expr("NOW() + make_interval(secs => ?)", retryAfter.Seconds()))
+	default:
+		update = update.Set("status", models.DeliveryStatusDead)
+	}
+
+	sql, args, _ := update.ToSql()
+	tag, err := r.Pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repoerrs.ErrLeaseLost
+	}
+
+	return nil
+}
+
+// writeOutbox adds the event to the outbox within the transaction of the
+// change, it is delivered to webhooks only if the change is committed.
+func writeOutbox(ctx context.Context, pg *postgres.Postgres, tx pgx.Tx, eventType string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %w", eventType, err)
+	}
+
+	sql, args, _ := pg.Builder.
+		Insert("outbox_events").
+		Columns("tenant_id, event_type, payload").
+		Values(tenant.ID(ctx), eventType, data).
+		ToSql()
+
+	if _, err := tx.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("failed to write %s event: %w", eventType, err)
+	}
+
+	return nil
+}
+
+func pullRequestEvent(pr models.PullRequest) models.PullRequestEvent {
+	reviewers := pr.AssignedReviewers
+	if reviewers == nil {
+		reviewers = []string{}
+	}
+
+	return models.PullRequestEvent{
+		PullRequestID:      pr.PullRequestID,
+		PullRequestName:    pr.PullRequestName,
+		AuthorID:           pr.AuthorID,
+		TeamName:           pr.TeamName,
+		RepositoryName:     pr.RepositoryName,
+		Status:             pr.Status,
+		AssignedReviewers:  reviewers,
+		NeedsMoreReviewers: pr.NeedsMoreReviewers,
+		CreatedAt:          pr.CreatedAt,
+		MergedAt:           pr.MergedAt,
+	}
+}
+
+func scanWebhookSubscription(row pgx.Row) (*models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	if err := scanWebhookSubscriptionInto(row, &subscription); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to scan webhook subscription: %w", err)
+	}
+
+	return &subscription, nil
+}
+
+func scanWebhookSubscriptionInto(row pgx.Row, subscription *models.WebhookSubscription) error {
+	return row.Scan(
+		&subscription.ID,
+		&subscription.URL,
+		&subscription.Secret,
+		&subscription.EventTypes,
+		&subscription.IsActive,
+		&subscription.CreatedAt,
+	)
+}
+
+func webhookDeliveryDest(delivery *models.WebhookDelivery) []any {
+	return []any{
+		&delivery.ID,
+		&delivery.EventID,
+		&delivery.SubscriptionID,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&delivery.LastStatusCode,
+		&delivery.LastError,
+		&delivery.CreatedAt,
+		&delivery.DeliveredAt,
+	}
+}
+
+func nullIfZero(n int) any {
+	if n == 0 {
+		return nil
+	}
+
+	return n
+}
@@ -2,6 +2,7 @@ package repo
 
 import (
 	"context"
+	"time"
 
 	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
 	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/pgdb"
@@ -10,33 +11,206 @@ import (
 
 type User interface {
 	SetIsActive(ctx context.Context, userID string, isActive bool) (userRes *models.User, alreadyUpdated bool, err error)
+	SetAwayUntil(ctx context.Context, userID string, until time.Time) (*models.User, error)
+	ReturnAwayUsers(ctx context.Context, limit uint64) (int64, error)
 	GetUserByID(ctx context.Context, userID string) (*models.User, error)
 	GetActiveUsersByTeam(ctx context.Context, teamName string) ([]models.User, error)
 	GetReviewPRsByUserID(ctx context.Context, userID string) ([]models.PullRequest, error)
+	GetUserTeams(ctx context.Context, userID string) ([]models.TeamMember, error)
+	SetPrimaryTeam(ctx context.Context, userID, teamName string) (*models.User, error)
+	SetEmail(ctx context.Context, userID, email string) (*models.User, error)
+	GetNotificationPreferences(ctx context.Context, userID string) (*models.NotificationPreferences, error)
+	SetNotificationPreferences(ctx context.Context, preferences models.NotificationPreferences) (*models.NotificationPreferences, error)
 }
 
 type PullRequest interface {
 	CreatePR(ctx context.Context, pr models.PullRequest) (*models.PullRequest, error)
 	MergePR(ctx context.Context, prID string) (pr *models.PullRequest, alreadyMerged bool, err error)
-	ReassignReviewer(ctx context.Context, prID, oldUserID string) (pullRequest *models.PullRequest, replacedBy string, err error)
+	ReassignReviewer(ctx context.Context, prID, oldUserID string) (pullRequest *models.PullRequest, replacedBy string, firstReassignAfter *time.Duration, err error)
+	ClosePR(ctx context.Context, prID string) (pr *models.PullRequest, alreadyClosed bool, err error)
+	ReopenPR(ctx context.Context, prID string) (pr *models.PullRequest, alreadyOpen bool, err error)
+	GetPR(ctx context.Context, prID string) (*models.PullRequest, error)
+
+	MarkReviewSLABreaches(ctx context.Context, limit uint64) (int64, error)
+}
+
+type ReviewerSync interface {
+	RequestReviewerSync(ctx context.Context, prID string) (*models.ReviewerSync, error)
+
+	ClaimReviewerSyncs(ctx context.Context, limit uint64, lease time.Duration) ([]models.ReviewerSync, error)
+	FinishReviewerSync(ctx context.Context, id, version int, synced bool, lastError string, retryAfter *time.Duration) error
 }
 
 type Team interface {
 	CreateTeam(ctx context.Context, team models.Team) (*models.Team, error)
 	GetTeamByName(ctx context.Context, name string) (*models.Team, error)
+	GetTeamTree(ctx context.Context, name string) (*models.Team, error)
 	SetIsActiveTeam(ctx context.Context, teamName string, isActive bool) (int64, error)
+	UpsertTeam(ctx context.Context, team models.Team, dryRun bool) (*models.TeamDiff, error)
+	RenameTeam(ctx context.Context, oldName, newName string) (*models.Team, error)
+	SetIsArchivedTeam(ctx context.Context, teamName string, isArchived bool) (team *models.Team, alreadyUpdated bool, err error)
+	DeleteTeam(ctx context.Context, teamName, reassignTo string) (membersMoved int64, err error)
+	SetParentTeam(ctx context.Context, teamName, parentName string) (*models.Team, error)
+	SetTeamSettings(ctx context.Context, teamName string, reviewersCount, fallbackDepth models.NullInt) (*models.Team, error)
+	SetTeamReviewSLA(ctx context.Context, teamName string, sla *time.Duration) (*models.Team, error)
+	ImportTeams(ctx context.Context, teams []models.TeamImport, dryRun bool) ([]models.TeamImportDiff, error)
+}
+
+type Repository interface {
+	CreateRepository(ctx context.Context, repository models.Repository) (*models.Repository, error)
+	GetRepositoryByName(ctx context.Context, repositoryName string) (*models.Repository, error)
+	SetRepositoryOwner(ctx context.Context, repositoryName, teamName string) (*models.Repository, error)
+	SetRepositorySettings(ctx context.Context, repositoryName string, reviewersCount, fallbackDepth models.NullInt) (*models.Repository, error)
+}
+
+type Stats interface {
+	GetUserStats(ctx context.Context, filter models.StatsFilter) ([]models.ReviewStats, error)
+	GetTeamStats(ctx context.Context, filter models.StatsFilter) ([]models.ReviewStats, error)
+	GetUserFlowStats(ctx context.Context, filter models.StatsFilter) ([]models.FlowStats, error)
+	GetTeamFlowStats(ctx context.Context, filter models.StatsFilter) ([]models.FlowStats, error)
+
+	StreamUserStats(ctx context.Context, filter models.StatsFilter, fn func(models.ReviewStats) error) error
+	StreamTeamStats(ctx context.Context, filter models.StatsFilter, fn func(models.ReviewStats) error) error
+	StreamUserFlowStats(ctx context.Context, filter models.StatsFilter, fn func(models.FlowStats) error) error
+	StreamTeamFlowStats(ctx context.Context, filter models.StatsFilter, fn func(models.FlowStats) error) error
+	StreamPullRequests(ctx context.Context, filter models.StatsFilter, fn func(models.PullRequest) error) error
+	StreamAssignments(ctx context.Context, filter models.StatsFilter, fn func(models.Assignment) error) error
+}
+
+type Tenant interface {
+	CreateTenant(ctx context.Context, tenantName string, keys []models.APIKey) (*models.Tenant, []models.APIKey, error)
+	GetTenantByName(ctx context.Context, tenantName string) (*models.Tenant, error)
+	CreateAPIKey(ctx context.Context, key models.APIKey) (*models.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, keyID int) (key *models.APIKey, alreadyRevoked bool, err error)
+}
+
+type Audit interface {
+	GetAuditEntries(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error)
+}
+
+type Webhook interface {
+	CreateWebhookSubscription(ctx context.Context, subscription models.WebhookSubscription) (*models.WebhookSubscription, error)
+	GetWebhookSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	SetWebhookSubscriptionIsActive(ctx context.Context, subscriptionID int, isActive bool) (*models.WebhookSubscription, error)
+	DeleteWebhookSubscription(ctx context.Context, subscriptionID int) error
+	GetWebhookDeliveries(ctx context.Context, filter models.WebhookDeliveryFilter) ([]models.WebhookDelivery, error)
+	RetryWebhookDelivery(ctx context.Context, deliveryID int64) (delivery *models.WebhookDelivery, requeued bool, err error)
+
+	FanOutOutboxEvents(ctx context.Context, limit uint64) (events int64, err error)
+	ClaimWebhookDeliveries(ctx context.Context, limit uint64, lease time.Duration) ([]models.WebhookDelivery, error)
+	FinishWebhookDelivery(ctx context.Context, deliveryID int64, leaseToken string, delivered bool, statusCode int, lastError string, retryAfter *time.Duration) error
+}
+
+type VCS interface {
+	SetVCSWebhookSecret(ctx context.Context, provider, secret string) error
+	SetVCSAPIToken(ctx context.Context, provider, token string) error
+	GetVCSWebhookSecret(ctx context.Context, provider string) (string, error)
+	SetVCSIdentity(ctx context.Context, identity models.VCSIdentity) (*models.VCSIdentity, error)
+	DeleteVCSIdentity(ctx context.Context, provider, login string) error
+	GetVCSIdentities(ctx context.Context, provider string) ([]models.VCSIdentity, error)
+	GetVCSUserID(ctx context.Context, provider, login string) (string, error)
+	GetVCSUserIDByAccount(ctx context.Context, provider, accountID string) (string, error)
+
+	ClaimVCSDelivery(ctx context.Context, delivery models.VCSDelivery, lease time.Duration) (recorded *models.VCSDelivery, claimed bool, err error)
+	FinishVCSDelivery(ctx context.Context, provider, deliveryID, result string) error
+	ReleaseVCSDelivery(ctx context.Context, provider, deliveryID string) error
+}
+
+type Reconciliation interface {
+	LockReconciliation(ctx context.Context) (unlock func(), ok bool, err error)
+	GetReconciledPullRequests(ctx context.Context, allTenants bool, afterID int, limit uint64) ([]models.ReconciledPullRequest, error)
+	AddReconciliationCorrection(ctx context.Context, correction models.ReconciliationCorrection) (*models.ReconciliationCorrection, error)
+	GetReconciliationCorrections(ctx context.Context, filter models.ReconciliationCorrectionFilter) ([]models.ReconciliationCorrection, error)
+}
+
+type Notification interface {
+	CreateChatChannel(ctx context.Context, channel models.ChatChannel) (*models.ChatChannel, error)
+	GetChatChannels(ctx context.Context, teamName string) ([]models.ChatChannel, error)
+	SetChatChannelIsActive(ctx context.Context, channelID int, isActive bool) (*models.ChatChannel, error)
+	DeleteChatChannel(ctx context.Context, channelID int) error
+	GetNotifications(ctx context.Context, filter models.NotificationFilter) ([]models.Notification, error)
+	RetryNotification(ctx context.Context, notificationID int64) (notification *models.Notification, requeued bool, err error)
+
+	FanOutNotifications(ctx context.Context, limit uint64, channels []string) (events int64, err error)
+	CreateEmailDigests(ctx context.Context, date time.Time) (int64, error)
+	ClaimNotifications(ctx context.Context, limit uint64, lease time.Duration) ([]models.Notification, error)
+	FinishNotification(ctx context.Context, notificationID int64, leaseToken string, sent bool, lastError string, retryAfter *time.Duration) error
+	PostponeNotification(ctx context.Context, notificationID int64, leaseToken string, delay time.Duration) error
+	SkipNotification(ctx context.Context, notificationID int64, leaseToken string) error
+}
+
+type Telegram interface {
+	SetTelegramLinkCode(ctx context.Context, userID, codeHash string, ttl time.Duration) (expiresAt time.Time, err error)
+	RedeemTelegramLinkCode(ctx context.Context, codeHash string, chatID int64) (*models.TelegramLink, error)
+	GetTelegramLink(ctx context.Context, chatID int64) (*models.TelegramLink, error)
+	DeleteTelegramLink(ctx context.Context, chatID int64) error
+	LockTelegramPolling(ctx context.Context) (unlock func(), ok bool, err error)
+}
+
+type ChatOps interface {
+	SetChatOpsSigningSecret(ctx context.Context, secret string) error
+	GetChatOpsSigningSecret(ctx context.Context) (string, error)
+	SetSlackIdentity(ctx context.Context, identity models.SlackIdentity) (*models.SlackIdentity, error)
+	DeleteSlackIdentity(ctx context.Context, slackUserID string) error
+	GetSlackIdentities(ctx context.Context) ([]models.SlackIdentity, error)
+	GetSlackIdentity(ctx context.Context, slackUserID string) (*models.SlackIdentity, error)
+}
+
+type Idempotency interface {
+	ClaimIdempotencyKey(ctx context.Context, key models.IdempotencyKey, lockTimeout time.Duration) (recorded *models.IdempotencyKey, claimed bool, err error)
+	CompleteIdempotencyKey(ctx context.Context, callerHash, key, leaseToken string, statusCode int, headers map[string][]string, body []byte, ttl time.Duration) error
+	ReleaseIdempotencyKey(ctx context.Context, callerHash, key, leaseToken string) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context, limit uint64) (int64, error)
+}
+
+type Retention interface {
+	RetainMergedPullRequests(ctx context.Context, mergedDays int, archive bool, limit uint64) (int64, error)
+}
+
+type Snapshot interface {
+	ExportSnapshot(ctx context.Context) (*models.Snapshot, error)
+	RestoreSnapshot(ctx context.Context, snapshot models.Snapshot) error
 }
 
 type Repositories struct {
 	User
 	PullRequest
 	Team
+	Repository
+	Stats
+	Tenant
+	Audit
+	Webhook
+	VCS
+	ReviewerSync
+	Reconciliation
+	Notification
+	Telegram
+	ChatOps
+	Idempotency
+	Retention
+	Snapshot
 }
 
 func NewRepositories(pg *postgres.Postgres) *Repositories {
 	return &Repositories{
-		User:        pgdb.NewUserRepo(pg),
-		PullRequest: pgdb.NewPullrequestRepo(pg),
-		Team:        pgdb.NewTeamRepo(pg),
+		User:           pgdb.NewUserRepo(pg),
+		PullRequest:    pgdb.NewPullrequestRepo(pg),
+		Team:           pgdb.NewTeamRepo(pg),
+		Repository:     pgdb.NewRepositoryRepo(pg),
+		Stats:          pgdb.NewStatsRepo(pg),
+		Tenant:         pgdb.NewTenantRepo(pg),
+		Audit:          pgdb.NewAuditRepo(pg),
+		Webhook:        pgdb.NewWebhookRepo(pg),
+		VCS:            pgdb.NewVCSRepo(pg),
+		ReviewerSync:   pgdb.NewReviewerSyncRepo(pg),
+		Reconciliation: pgdb.NewReconciliationRepo(pg),
+		Notification:   pgdb.NewNotificationRepo(pg),
+		Telegram:       pgdb.NewTelegramRepo(pg),
+		ChatOps:        pgdb.NewChatOpsRepo(pg),
+		Idempotency:    pgdb.NewIdempotencyRepo(pg),
+		Retention:      pgdb.NewRetentionRepo(pg),
+		Snapshot:       pgdb.NewSnapshotRepo(pg),
 	}
 }
@@ -8,6 +8,24 @@ var (
 
 	ErrUserNotFound       = errors.New("user not found")
 	ErrReassignAfterMerge = errors.New("cannot reassign on merged PR")
-	ErrNotAssigned        = errors.New("reviewer is not assigned to this PR")
-	ErrNoCandidate        = errors.New("no active replacement candidate in team")
+	ErrReassignAfterClose = errors.New("cannot reassign on closed PR")
+	ErrPRMerged           = errors.New("pull request is already merged")
+
+	ErrReviewerSyncDisabled = errors.New("reviewers of the pull request are not requested in its provider")
+	ErrNotAssigned          = errors.New("reviewer is not assigned to this PR")
+	ErrNoCandidate          = errors.New("no active replacement candidate in team")
+
+	ErrTeamNotEmpty = errors.New("team still has members or open pull requests")
+	ErrNotMember    = errors.New("user is not a member of the team")
+	ErrTeamCycle    = errors.New("team cannot be nested under itself or its subteams")
+	ErrTeamNotFound = errors.New("team not found")
+	ErrTeamArchived = errors.New("team is archived")
+
+	ErrReassignTargetNotFound = errors.New("team to reassign to not found")
+
+	ErrVersionMismatch = errors.New("entity was changed since the expected version")
+
+	ErrLeaseLost = errors.New("lease expired and the entity was claimed again")
+
+	ErrTenantNotEmpty = errors.New("tenant already has teams, users or pull requests")
 )
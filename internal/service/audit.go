@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo"
+)
+
+const (
+	DefaultAuditLimit = 50
+	MaxAuditLimit     = 500
+)
+
+type AuditService struct {
+	auditRepo repo.Audit
+}
+
+func NewAuditService(auditRepo repo.Audit) *AuditService {
+	return &AuditService{auditRepo: auditRepo}
+}
+
+func (s *AuditService) GetEntries(ctx context.Context, input AuditInput) (*AuditOutput, error) {
+	limit := input.Limit
+	if limit == 0 {
+		limit = DefaultAuditLimit
+	}
+
+	entries, err := s.auditRepo.GetAuditEntries(ctx, models.AuditFilter{
+		Actor:      input.Actor,
+		Action:     input.Action,
+		EntityType: input.EntityType,
+		EntityID:   input.EntityID,
+		RequestID:  input.RequestID,
+		From:       input.From,
+		To:         input.To,
+		Cursor:     input.Cursor,
+		Limit:      limit + 1, // one more to know whether there is a next page
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	output := AuditOutput{
+		Entries: []AuditOutputEntry{},
+	}
+
+	if uint64(len(entries)) > limit {
+		entries = entries[:limit]
+		output.NextCursor = entries[len(entries)-1].ID
+	}
+
+	for _, entry := range entries {
+		output.Entries = append(output.Entries, AuditOutputEntry{
+			ID:         entry.ID,
+			Actor:      entry.Actor,
+			Action:     entry.Action,
+			EntityType: entry.EntityType,
+			EntityID:   entry.EntityID,
+			Before:     entry.Before,
+			After:      entry.After,
+			RequestID:  entry.RequestID,
+			CreatedAt:  entry.CreatedAt,
+		})
+	}
+
+	return &output, nil
+}
@@ -1,21 +1,71 @@
 package service
 
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/tenant"
+)
+
 type AuthService struct {
+	tenantRepo repo.Tenant
+
 	UserAPIKey  string
 	AdminAPIKey string
+	RootAPIKey  string
 }
 
-func NewAuthService(userAPIKey, adminAPIKey string) *AuthService {
+func NewAuthService(tenantRepo repo.Tenant, userAPIKey, adminAPIKey, rootAPIKey string) *AuthService {
 	return &AuthService{
+		tenantRepo:  tenantRepo,
 		UserAPIKey:  userAPIKey,
 		AdminAPIKey: adminAPIKey,
+		RootAPIKey:  rootAPIKey,
 	}
 }
 
-func (s *AuthService) IsUserKey(key string) bool {
-	return key == s.UserAPIKey
+// Authenticate resolves the tenant and role of the key. Keys from the service
+// configuration belong to the default tenant, others are looked up by hash.
+func (s *AuthService) Authenticate(ctx context.Context, key string) (*models.APIKey, error) {
+	if equalKeys(key, s.AdminAPIKey) {
+		return &models.APIKey{TenantID: tenant.DefaultID, Role: models.RoleAdmin}, nil
+	}
+
+	if equalKeys(key, s.UserAPIKey) {
+		return &models.APIKey{TenantID: tenant.DefaultID, Role: models.RoleUser}, nil
+	}
+
+	return s.tenantRepo.GetAPIKeyByHash(ctx, hashAPIKey(key))
 }
 
-func (s *AuthService) IsAdminKey(key string) bool {
-	return key == s.AdminAPIKey
+func (s *AuthService) IsRootKey(key string) bool {
+	return equalKeys(key, s.RootAPIKey)
+}
+
+// equalKeys reports whether key matches the configured one, empty configured
+// keys never match.
+func equalKeys(key, configured string) bool {
+	return configured != "" && subtle.ConstantTimeCompare([]byte(key), []byte(configured)) == 1
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateSecret returns a random hex token used for API keys and webhook
+// secrets.
+func generateSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+
+	return hex.EncodeToString(buf), nil
 }
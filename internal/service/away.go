@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/metrics"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/logger"
+)
+
+// AwayMonitor activates away users again once their day has come.
+type AwayMonitor struct {
+	userRepo  repo.User
+	interval  time.Duration
+	batchSize uint64
+	log       logger.Logger
+}
+
+func NewAwayMonitor(userRepo repo.User, interval time.Duration, batchSize uint64, log logger.Logger) *AwayMonitor {
+	return &AwayMonitor{
+		userRepo:  userRepo,
+		interval:  interval,
+		batchSize: max(batchSize, 1),
+		log:       log,
+	}
+}
+
+// Run checks away users every interval until ctx is done.
+func (m *AwayMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.check(ctx)
+		}
+	}
+}
+
+func (m *AwayMonitor) check(ctx context.Context) {
+	for ctx.Err() == nil {
+		returned, err := m.userRepo.ReturnAwayUsers(ctx, m.batchSize)
+		if err != nil {
+			m.log.Error("failed to return away users", map[string]any{"error": err})
+			return
+		}
+
+		metrics.UserStatusChanges.WithLabelValues("returnAway").Add(float64(returned))
+
+		if uint64(returned) < m.batchSize {
+			return
+		}
+	}
+}
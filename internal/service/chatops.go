@@ -0,0 +1,406 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/audit"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/tenant"
+)
+
+// chatOpsMaxSkew is how old a signed slash command may be, older ones are
+// taken for replays.
+const chatOpsMaxSkew = 5 * time.Minute
+
+const ChatOpsResponseEphemeral = "ephemeral" // seen by the caller only
+
+const chatOpsHelp = `Commands:
+assign <pr> [title] - open the pull request authored by you and assign reviewers
+reassign <pr> [@reviewer] - pass your review, or as an admin the reviewer's, to a teammate
+merge <pr> - merge the pull request authored by you
+away until <YYYY-MM-DD> - get no new reviews until the day
+back - get new reviews again`
+
+type ChatOpsService struct {
+	chatOpsRepo repo.ChatOps
+	tenantRepo  repo.Tenant
+	userService User
+	prService   PullRequest
+}
+
+func NewChatOpsService(chatOpsRepo repo.ChatOps, tenantRepo repo.Tenant, userService User, prService PullRequest) *ChatOpsService {
+	return &ChatOpsService{
+		chatOpsRepo: chatOpsRepo,
+		tenantRepo:  tenantRepo,
+		userService: userService,
+		prService:   prService,
+	}
+}
+
+// SetSigningSecret sets the secret slash commands of the tenant are signed
+// with. The secret is returned only here and generated when empty.
+func (s *ChatOpsService) SetSigningSecret(ctx context.Context, secret string) (*ChatOpsSigningSecretOutput, error) {
+	if secret == "" {
+		generated, err := generateSecret()
+		if err != nil {
+			return nil, err
+		}
+		secret = generated
+	}
+
+	if err := s.chatOpsRepo.SetChatOpsSigningSecret(ctx, secret); err != nil {
+		return nil, err
+	}
+
+	return &ChatOpsSigningSecretOutput{Secret: secret}, nil
+}
+
+func (s *ChatOpsService) SetIdentity(ctx context.Context, input ChatOpsIdentityInput) (*ChatOpsOutputIdentity, error) {
+	identity, err := s.chatOpsRepo.SetSlackIdentity(ctx, models.SlackIdentity{
+		SlackUserID: input.SlackUserID,
+		UserID:      input.UserID,
+		IsAdmin:     input.IsAdmin,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	output := toChatOpsOutputIdentity(*identity)
+	return &output, nil
+}
+
+func (s *ChatOpsService) DeleteIdentity(ctx context.Context, slackUserID string) error {
+	return s.chatOpsRepo.DeleteSlackIdentity(ctx, slackUserID)
+}
+
+func (s *ChatOpsService) GetIdentities(ctx context.Context) (*ChatOpsIdentitiesOutput, error) {
+	identities, err := s.chatOpsRepo.GetSlackIdentities(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	output := ChatOpsIdentitiesOutput{Identities: []ChatOpsOutputIdentity{}}
+	for _, identity := range identities {
+		output.Identities = append(output.Identities, toChatOpsOutputIdentity(identity))
+	}
+
+	return &output, nil
+}
+
+// HandleSlackCommand verifies the X-Slack-Signature signature of the slash
+// command and runs its text on behalf of the user the calling Slack user is
+// mapped to by SetIdentity. Failures of the command itself are described in
+// the reply, errors are returned for requests that cannot be answered. An
+// unknown tenant has no secret to verify the command with, so it is reported
+// as an invalid signature and does not reveal which tenants exist.
+func (s *ChatOpsService) HandleSlackCommand(ctx context.Context, input ChatOpsSlackInput) (*ChatOpsCommandOutput, error) {
+	if input.TenantName != "" {
+		t, err := s.tenantRepo.GetTenantByName(ctx, input.TenantName)
+		if errors.Is(err, repoerrs.ErrNotFound) {
+			return nil, ErrInvalidSignature
+		} else if err != nil {
+			return nil, err
+		}
+		ctx = tenant.WithID(ctx, t.ID)
+	}
+
+	timestamp, err := strconv.ParseInt(input.Timestamp, 10, 64)
+	if err != nil || time.Since(time.Unix(timestamp, 0)).Abs() > chatOpsMaxSkew {
+		return nil, ErrInvalidSignature
+	}
+
+	secret, err := s.chatOpsRepo.GetChatOpsSigningSecret(ctx)
+	if errors.Is(err, repoerrs.ErrNotFound) {
+		return nil, ErrInvalidSignature
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !hmac.Equal([]byte(SignSlackRequest(secret, input.Timestamp, input.Body)), []byte(input.Signature)) {
+		return nil, ErrInvalidSignature
+	}
+
+	form, err := url.ParseQuery(string(input.Body))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPayload, err)
+	}
+
+	slackUserID := form.Get("user_id")
+	if slackUserID == "" {
+		return nil, fmt.Errorf("%w: user_id is missing", ErrInvalidPayload)
+	}
+
+	identity, err := s.chatOpsRepo.GetSlackIdentity(ctx, slackUserID)
+	if errors.Is(err, repoerrs.ErrNotFound) {
+		return &ChatOpsCommandOutput{
+			ResponseType: ChatOpsResponseEphemeral,
+			Text:         fmt.Sprintf("Your Slack user %s is not linked to a user, ask an admin to link it.", slackUserID),
+		}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	text, err := s.run(audit.WithActor(ctx, "slack:"+slackUserID), *identity, form.Get("text"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ChatOpsCommandOutput{ResponseType: ChatOpsResponseEphemeral, Text: text}, nil
+}
+
+// SignSlackRequest returns the value of the X-Slack-Signature header for the
+// body sent at the unix timestamp.
+func SignSlackRequest(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// run runs the command text of the linked user and returns the reply.
+func (s *ChatOpsService) run(ctx context.Context, identity models.SlackIdentity, text string) (string, error) {
+	userID := identity.UserID
+
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return chatOpsHelp, nil
+	}
+
+	command, args := strings.ToLower(fields[0]), fields[1:]
+
+	switch command {
+	case "assign":
+		if len(args) == 0 {
+			return "Usage: assign <pr> [title]", nil
+		}
+		return s.assign(ctx, userID, args[0], strings.Join(args[1:], " "))
+	case "reassign":
+		switch len(args) {
+		case 1:
+			return s.reassign(ctx, args[0], userID)
+		case 2:
+			return s.reassignOf(ctx, identity, args[0], args[1])
+		default:
+			return "Usage: reassign <pr> [@reviewer]", nil
+		}
+	case "merge":
+		if len(args) != 1 {
+			return "Usage: merge <pr>", nil
+		}
+		return s.merge(ctx, identity, args[0])
+	case "away":
+		if len(args) != 2 || strings.ToLower(args[0]) != "until" {
+			return "Usage: away until <YYYY-MM-DD>", nil
+		}
+		return s.away(ctx, userID, args[1])
+	case "back":
+		if len(args) != 0 {
+			return "Usage: back", nil
+		}
+		return s.back(ctx, userID)
+	default:
+		return chatOpsHelp, nil
+	}
+}
+
+func (s *ChatOpsService) assign(ctx context.Context, userID, prID, name string) (string, error) {
+	if name == "" {
+		name = prID
+	}
+
+	output, err := s.prService.CreatePR(ctx, PullRequestCreateInput{
+		PullRequestID:   prID,
+		PullRequestName: name,
+		AuthorID:        userID,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repoerrs.ErrAlreadyExists):
+			return s.describe(ctx, prID)
+		case errors.Is(err, repoerrs.ErrNotFound):
+			return fmt.Sprintf("User %s or their team is not found.", userID), nil
+		case errors.Is(err, repoerrs.ErrNotMember):
+			return fmt.Sprintf("Cannot open %s: %s.", prID, err), nil
+		default:
+			return "", fmt.Errorf("failed to run assign command: %w", err)
+		}
+	}
+
+	return fmt.Sprintf("%s is open in team %s, %s.", prID, output.PullRequest.TeamName, describeReviewers(output.PullRequest.AssignedReviewers)), nil
+}
+
+// describe replies with the state of the existing pull request.
+func (s *ChatOpsService) describe(ctx context.Context, prID string) (string, error) {
+	output, err := s.prService.GetPR(ctx, prID)
+	if err != nil {
+		return "", fmt.Errorf("failed to run assign command: %w", err)
+	}
+
+	pr := output.PullRequest
+	return fmt.Sprintf("%s already exists and is %s, %s.", prID, strings.ToLower(pr.Status), describeReviewers(pr.AssignedReviewers)), nil
+}
+
+func (s *ChatOpsService) reassign(ctx context.Context, prID, reviewerID string) (string, error) {
+	output, err := s.prService.ReassignReviewer(ctx, prID, reviewerID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repoerrs.ErrNotFound):
+			return fmt.Sprintf("Pull request %s is not found.", prID), nil
+		case errors.Is(err, repoerrs.ErrNotAssigned),
+			errors.Is(err, repoerrs.ErrNoCandidate),
+			errors.Is(err, repoerrs.ErrReassignAfterMerge),
+			errors.Is(err, repoerrs.ErrReassignAfterClose),
+			errors.Is(err, repoerrs.ErrUserNotFound):
+			return fmt.Sprintf("Cannot pass your review of %s: %s.", prID, err), nil
+		default:
+			return "", fmt.Errorf("failed to run reassign command: %w", err)
+		}
+	}
+
+	return fmt.Sprintf("Your review of %s is passed to %s.", prID, output.ReplacedBy), nil
+}
+
+// reassignOf passes the review of the mentioned reviewer, resolved through
+// the slack identities. Only the reviewer themselves or an admin may do it.
+func (s *ChatOpsService) reassignOf(ctx context.Context, identity models.SlackIdentity, prID, mention string) (string, error) {
+	slackUserID := slackMention(mention)
+	if slackUserID == "" {
+		return "Usage: reassign <pr> [@reviewer]", nil
+	}
+
+	reviewer, err := s.chatOpsRepo.GetSlackIdentity(ctx, slackUserID)
+	if errors.Is(err, repoerrs.ErrNotFound) {
+		return fmt.Sprintf("Slack user %s is not linked to a user.", slackUserID), nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to run reassign command: %w", err)
+	}
+
+	if reviewer.UserID == identity.UserID {
+		return s.reassign(ctx, prID, identity.UserID)
+	}
+	if !identity.IsAdmin {
+		return fmt.Sprintf("Cannot reassign %s from %s: only the reviewer or an admin may pass the review.", prID, reviewer.UserID), nil
+	}
+
+	output, err := s.prService.ReassignReviewer(ctx, prID, reviewer.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repoerrs.ErrNotFound):
+			return fmt.Sprintf("Pull request %s is not found.", prID), nil
+		case errors.Is(err, repoerrs.ErrNotAssigned),
+			errors.Is(err, repoerrs.ErrNoCandidate),
+			errors.Is(err, repoerrs.ErrReassignAfterMerge),
+			errors.Is(err, repoerrs.ErrReassignAfterClose),
+			errors.Is(err, repoerrs.ErrUserNotFound):
+			return fmt.Sprintf("Cannot reassign %s from %s: %s.", prID, reviewer.UserID, err), nil
+		default:
+			return "", fmt.Errorf("failed to run reassign command: %w", err)
+		}
+	}
+
+	return fmt.Sprintf("Review of %s is passed from %s to %s.", prID, reviewer.UserID, output.ReplacedBy), nil
+}
+
+// merge merges the pull request authored by the user, admins may merge any.
+func (s *ChatOpsService) merge(ctx context.Context, identity models.SlackIdentity, prID string) (string, error) {
+	if !identity.IsAdmin {
+		output, err := s.prService.GetPR(ctx, prID)
+		if errors.Is(err, repoerrs.ErrNotFound) {
+			return fmt.Sprintf("Pull request %s is not found.", prID), nil
+		} else if err != nil {
+			return "", fmt.Errorf("failed to run merge command: %w", err)
+		}
+
+		if output.PullRequest.AuthorID != identity.UserID {
+			return fmt.Sprintf("Cannot merge %s: only its author or an admin may merge it.", prID), nil
+		}
+	}
+
+	if _, err := s.prService.MergePR(ctx, prID); err != nil {
+		if errors.Is(err, repoerrs.ErrNotFound) {
+			return fmt.Sprintf("Pull request %s is not found.", prID), nil
+		}
+		return "", fmt.Errorf("failed to run merge command: %w", err)
+	}
+
+	return fmt.Sprintf("%s is merged.", prID), nil
+}
+
+func (s *ChatOpsService) away(ctx context.Context, userID, day string) (string, error) {
+	until, err := time.Parse(time.DateOnly, day)
+	if err != nil {
+		return "Usage: away until <YYYY-MM-DD>", nil
+	}
+
+	if _, err := s.userService.SetAwayUntil(ctx, userID, until); err != nil {
+		switch {
+		case errors.Is(err, repoerrs.ErrNotFound):
+			return fmt.Sprintf("User %s is not found.", userID), nil
+		case errors.Is(err, ErrAwayDayPassed):
+			return fmt.Sprintf("Cannot be away until %s: %s.", day, err), nil
+		default:
+			return "", fmt.Errorf("failed to run away command: %w", err)
+		}
+	}
+
+	return fmt.Sprintf("You are away until %s: you get no new reviews until then.", until.Format(time.DateOnly)), nil
+}
+
+func (s *ChatOpsService) back(ctx context.Context, userID string) (string, error) {
+	if _, err := s.userService.SetIsActive(ctx, userID, true); err != nil {
+		if errors.Is(err, repoerrs.ErrNotFound) {
+			return fmt.Sprintf("User %s is not found.", userID), nil
+		}
+		return "", fmt.Errorf("failed to run back command: %w", err)
+	}
+
+	return "Welcome back: you get new reviews again.", nil
+}
+
+func toChatOpsOutputIdentity(identity models.SlackIdentity) ChatOpsOutputIdentity {
+	return ChatOpsOutputIdentity{
+		SlackUserID: identity.SlackUserID,
+		UserID:      identity.UserID,
+		IsAdmin:     identity.IsAdmin,
+		CreatedAt:   identity.CreatedAt,
+	}
+}
+
+func describeReviewers(reviewers []string) string {
+	if len(reviewers) == 0 {
+		return "no reviewers are assigned"
+	}
+
+	return "reviewers: " + strings.Join(reviewers, ", ")
+}
+
+// slackMention returns the Slack user id of escaped "<@U012AB3CD|name>" and
+// plain "@U012AB3CD" mentions, empty for other values.
+func slackMention(mention string) string {
+	if escaped, ok := strings.CutPrefix(mention, "<@"); ok {
+		escaped, ok = strings.CutSuffix(escaped, ">")
+		if !ok {
+			return ""
+		}
+		slackUserID, _, _ := strings.Cut(escaped, "|")
+		return slackUserID
+	}
+
+	slackUserID, _ := strings.CutPrefix(mention, "@")
+	if slackUserID == mention {
+		return ""
+	}
+	return slackUserID
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+)
+
+const testSigningSecret = "slack-signing-secret"
+
+type fakeChatOpsRepo struct {
+	repo.ChatOps // not called by slash commands
+}
+
+func (r fakeChatOpsRepo) GetChatOpsSigningSecret(context.Context) (string, error) {
+	return testSigningSecret, nil
+}
+
+func (r fakeChatOpsRepo) GetSlackIdentity(_ context.Context, slackUserID string) (*models.SlackIdentity, error) {
+	switch slackUserID {
+	case "U1":
+		return &models.SlackIdentity{SlackUserID: slackUserID, UserID: "u1"}, nil
+	case "U2":
+		return &models.SlackIdentity{SlackUserID: slackUserID, UserID: "u2"}, nil
+	case "UADMIN":
+		return &models.SlackIdentity{SlackUserID: slackUserID, UserID: "u3", IsAdmin: true}, nil
+	default:
+		return nil, repoerrs.ErrNotFound
+	}
+}
+
+type fakeTenantRepo struct {
+	repo.Tenant // not called by slash commands
+}
+
+func (r fakeTenantRepo) GetTenantByName(_ context.Context, tenantName string) (*models.Tenant, error) {
+	if tenantName != "acme" {
+		return nil, repoerrs.ErrNotFound
+	}
+	return &models.Tenant{ID: 2}, nil
+}
+
+// GetPR returns pr-1 authored by u1.
+func (s *fakePullRequestService) GetPR(_ context.Context, prID string) (*PullRequestGetOutput, error) {
+	if prID != "pr-1" {
+		return nil, repoerrs.ErrNotFound
+	}
+	return &PullRequestGetOutput{PullRequest: PullRequestGetOutputPR{PullRequestID: prID, AuthorID: "u1"}}, nil
+}
+
+func TestHandleSlackCommand(t *testing.T) {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-10*time.Minute).Unix(), 10)
+
+	tests := []struct {
+		name        string
+		tenantName  string
+		timestamp   string
+		secret      string
+		slackUserID string
+		text        string
+		wantErr     error
+		wantText    string
+		wantCalls   []string
+	}{
+		{
+			name:        "unknown tenant",
+			tenantName:  "unknown",
+			slackUserID: "U1",
+			text:        "merge pr-1",
+			wantErr:     ErrInvalidSignature,
+		},
+		{
+			name:        "wrong signature",
+			secret:      "another-secret",
+			slackUserID: "U1",
+			text:        "merge pr-1",
+			wantErr:     ErrInvalidSignature,
+		},
+		{
+			name:        "replayed",
+			timestamp:   stale,
+			slackUserID: "U1",
+			text:        "merge pr-1",
+			wantErr:     ErrInvalidSignature,
+		},
+		{
+			name:    "no user id",
+			text:    "merge pr-1",
+			wantErr: ErrInvalidPayload,
+		},
+		{
+			name:        "unlinked user",
+			slackUserID: "U9",
+			text:        "merge pr-1",
+			wantText:    "Your Slack user U9 is not linked to a user, ask an admin to link it.",
+		},
+		{
+			name:        "help",
+			tenantName:  "acme",
+			slackUserID: "U1",
+			wantText:    chatOpsHelp,
+		},
+		{
+			name:        "merge by the author",
+			slackUserID: "U1",
+			text:        "merge pr-1",
+			wantText:    "pr-1 is merged.",
+			wantCalls:   []string{"merge pr-1"},
+		},
+		{
+			name:        "merge by another user",
+			slackUserID: "U2",
+			text:        "merge pr-1",
+			wantText:    "Cannot merge pr-1: only its author or an admin may merge it.",
+		},
+		{
+			name:        "merge by an admin",
+			slackUserID: "UADMIN",
+			text:        "MERGE pr-1",
+			wantText:    "pr-1 is merged.",
+			wantCalls:   []string{"merge pr-1"},
+		},
+		{
+			name:        "merge of an unknown pull request",
+			slackUserID: "U2",
+			text:        "merge pr-9",
+			wantText:    "Pull request pr-9 is not found.",
+		},
+		{
+			name:        "merge usage",
+			slackUserID: "U1",
+			text:        "merge",
+			wantText:    "Usage: merge <pr>",
+		},
+		{
+			name:        "reassign own review",
+			slackUserID: "U2",
+			text:        "reassign pr-1",
+			wantText:    "Your review of pr-1 is passed to u9.",
+			wantCalls:   []string{"reassign pr-1 u2"},
+		},
+		{
+			name:        "reassign of a mapped reviewer by an admin",
+			slackUserID: "UADMIN",
+			text:        "reassign pr-1 <@U2|bob>",
+			wantText:    "Review of pr-1 is passed from u2 to u9.",
+			wantCalls:   []string{"reassign pr-1 u2"},
+		},
+		{
+			name:        "reassign of own review by mention",
+			slackUserID: "U2",
+			text:        "reassign pr-1 @U2",
+			wantText:    "Your review of pr-1 is passed to u9.",
+			wantCalls:   []string{"reassign pr-1 u2"},
+		},
+		{
+			name:        "reassign of an unmapped reviewer",
+			slackUserID: "UADMIN",
+			text:        "reassign pr-1 <@U9>",
+			wantText:    "Slack user U9 is not linked to a user.",
+		},
+		{
+			name:        "reassign of another reviewer by a non-admin",
+			slackUserID: "U2",
+			text:        "reassign pr-1 @U1",
+			wantText:    "Cannot reassign pr-1 from u1: only the reviewer or an admin may pass the review.",
+		},
+		{
+			name:        "reassign usage",
+			slackUserID: "U2",
+			text:        "reassign pr-1 alice",
+			wantText:    "Usage: reassign <pr> [@reviewer]",
+		},
+		{
+			name:        "away usage",
+			slackUserID: "U1",
+			text:        "away until tomorrow",
+			wantText:    "Usage: away until <YYYY-MM-DD>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prService := &fakePullRequestService{}
+			s := NewChatOpsService(fakeChatOpsRepo{}, fakeTenantRepo{}, &fakeUserService{}, prService)
+
+			timestamp := tt.timestamp
+			if timestamp == "" {
+				timestamp = now
+			}
+			secret := tt.secret
+			if secret == "" {
+				secret = testSigningSecret
+			}
+
+			form := url.Values{"text": {tt.text}}
+			if tt.slackUserID != "" {
+				form.Set("user_id", tt.slackUserID)
+			}
+			body := []byte(form.Encode())
+
+			output, err := s.HandleSlackCommand(context.Background(), ChatOpsSlackInput{
+				TenantName: tt.tenantName,
+				Timestamp:  timestamp,
+				Signature:  SignSlackRequest(secret, timestamp, body),
+				Body:       body,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("HandleSlackCommand() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr == nil && output.Text != tt.wantText {
+				t.Errorf("text = %q, want %q", output.Text, tt.wantText)
+			}
+			if !reflect.DeepEqual(prService.calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", prService.calls, tt.wantCalls)
+			}
+		})
+	}
+}
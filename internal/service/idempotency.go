@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/logger"
+)
+
+var (
+	ErrIdempotencyKeyReused     = errors.New("idempotency key is already used for another request")
+	ErrIdempotencyKeyInProgress = errors.New("request with the idempotency key is still in progress")
+)
+
+type IdempotencyService struct {
+	idempotencyRepo repo.Idempotency
+	ttl             time.Duration
+	lockTimeout     time.Duration
+}
+
+// NewIdempotencyService keeps responses for ttl. A request in progress holds
+// its key for lockTimeout at most, so keys of requests that never completed
+// are freed for retries.
+func NewIdempotencyService(idempotencyRepo repo.Idempotency, ttl, lockTimeout time.Duration) *IdempotencyService {
+	return &IdempotencyService{
+		idempotencyRepo: idempotencyRepo,
+		ttl:             ttl,
+		lockTimeout:     lockTimeout,
+	}
+}
+
+// Begin claims the key of the caller for the request. The recorded response
+// is returned for repeats of a completed request, nil and the lease token to
+// complete or release the key with when the request is claimed and is to be
+// processed.
+func (s *IdempotencyService) Begin(ctx context.Context, input IdempotencyInput) (*IdempotentResponse, string, error) {
+	key := models.IdempotencyKey{
+		CallerHash:  hashAPIKey(input.APIKey),
+		Key:         input.Key,
+		RequestHash: hashIdempotentRequest(input),
+	}
+
+	recorded, claimed, err := s.idempotencyRepo.ClaimIdempotencyKey(ctx, key, s.lockTimeout)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if claimed {
+		return nil, recorded.LeaseToken, nil
+	}
+
+	if recorded.RequestHash != key.RequestHash {
+		return nil, "", ErrIdempotencyKeyReused
+	}
+
+	if recorded.StatusCode == nil {
+		return nil, "", ErrIdempotencyKeyInProgress
+	}
+
+	return &IdempotentResponse{
+		StatusCode: *recorded.StatusCode,
+		Header:     recorded.ResponseHeaders,
+		Body:       recorded.ResponseBody,
+	}, "", nil
+}
+
+// Complete records the response of the claimed request for repeats.
+// repoerrs.ErrLeaseLost is returned when the request outlived the lock
+// timeout and the key was claimed again.
+func (s *IdempotencyService) Complete(ctx context.Context, input IdempotencyInput, leaseToken string, response IdempotentResponse) error {
+	return s.idempotencyRepo.CompleteIdempotencyKey(ctx, hashAPIKey(input.APIKey), input.Key, leaseToken,
+		response.StatusCode, response.Header, response.Body, s.ttl)
+}
+
+// Release frees the key of the claimed request that is not to be replayed.
+func (s *IdempotencyService) Release(ctx context.Context, input IdempotencyInput, leaseToken string) error {
+	return s.idempotencyRepo.ReleaseIdempotencyKey(ctx, hashAPIKey(input.APIKey), input.Key, leaseToken)
+}
+
+// hashIdempotentRequest identifies the request the key was first used for.
+func hashIdempotentRequest(input IdempotencyInput) string {
+	hash := sha256.New()
+	hash.Write([]byte(input.Method + " " + input.Path + "\n"))
+	hash.Write(input.Body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// IdempotencyCleaner deletes expired idempotency keys.
+type IdempotencyCleaner struct {
+	idempotencyRepo repo.Idempotency
+	interval        time.Duration
+	batchSize       uint64
+	log             logger.Logger
+}
+
+func NewIdempotencyCleaner(idempotencyRepo repo.Idempotency, interval time.Duration, batchSize uint64, log logger.Logger) *IdempotencyCleaner {
+	return &IdempotencyCleaner{
+		idempotencyRepo: idempotencyRepo,
+		interval:        interval,
+		batchSize:       max(batchSize, 1),
+		log:             log,
+	}
+}
+
+// Run cleans every interval until ctx is done.
+func (c *IdempotencyCleaner) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.clean(ctx)
+		}
+	}
+}
+
+func (c *IdempotencyCleaner) clean(ctx context.Context) {
+	for ctx.Err() == nil {
+		deleted, err := c.idempotencyRepo.DeleteExpiredIdempotencyKeys(ctx, c.batchSize)
+		if err != nil {
+			c.log.Error("failed to delete expired idempotency keys", map[string]any{"error": err})
+			return
+		}
+
+		if uint64(deleted) < c.batchSize {
+			return
+		}
+	}
+}
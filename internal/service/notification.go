@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/safehttp"
+)
+
+const (
+	DefaultNotificationsLimit = 50
+	MaxNotificationsLimit     = 500
+)
+
+var ErrInvalidTemplate = errors.New("invalid notification template")
+
+// defaultChatTemplates are the messages of chat channels without a template
+// of their own for the event.
+var defaultChatTemplates = map[string]string{
+	models.EventPullRequestCreated: `{{.Recipient.Username}}, you are assigned to review *{{.PullRequest.PullRequestName}}* ` +
+		`({{.PullRequest.PullRequestID}}) by {{.PullRequest.AuthorID}}`,
+	models.EventReviewerReassigned: `{{.Recipient.Username}}, you are assigned to review *{{.PullRequest.PullRequestName}}* ` +
+		`({{.PullRequest.PullRequestID}}) instead of {{.OldReviewerID}}`,
+	models.EventReviewSLABreached: `{{.Recipient.Username}}, review of *{{.PullRequest.PullRequestName}}* ` +
+		`({{.PullRequest.PullRequestID}}) is overdue: assigned {{.AssignedAt.Format "2006-01-02 15:04"}}, the team SLA is {{.ReviewSLA}}`,
+	models.EventPullRequestMerged: `*{{.PullRequest.PullRequestName}}* ({{.PullRequest.PullRequestID}}) ` +
+		`by {{.PullRequest.AuthorID}} is merged`,
+}
+
+// NotificationRecipient is the user a notification is about, empty for
+// notifications of the whole team.
+type NotificationRecipient struct {
+	UserID   string
+	Username string // the user id when the user is not known
+}
+
+// NotificationData is what notification templates are executed with.
+type NotificationData struct {
+	EventType   string
+	PullRequest models.PullRequestEvent
+	Recipient   NotificationRecipient
+
+	OldReviewerID string        // reviewer_reassigned only
+	NewReviewerID string        // reviewer_reassigned only
+	AssignedAt    time.Time     // sla_breached only
+	ReviewSLA     time.Duration // sla_breached only
+
+	OpenReviews []models.PullRequest // review_digest only
+}
+
+// newNotificationData decodes the event of the claimed notification.
+func newNotificationData(notification models.Notification) (NotificationData, error) {
+	// digests are not of an event
+	var payload models.NotificationPayload
+	if notification.EventID != nil {
+		if err := json.Unmarshal(notification.Event.Payload, &payload); err != nil {
+			return NotificationData{}, fmt.Errorf("failed to decode %s event: %w", notification.Event.EventType, err)
+		}
+	}
+
+	recipient := NotificationRecipient{
+		UserID:   notification.RecipientID,
+		Username: notification.RecipientName,
+	}
+	if recipient.Username == "" {
+		recipient.Username = recipient.UserID
+	}
+
+	return NotificationData{
+		EventType:     notification.Event.EventType,
+		PullRequest:   payload.PullRequestEvent,
+		Recipient:     recipient,
+		OldReviewerID: payload.OldReviewerID,
+		NewReviewerID: payload.NewReviewerID,
+		AssignedAt:    payload.AssignedAt,
+		ReviewSLA:     time.Duration(payload.ReviewSLASeconds) * time.Second,
+	}, nil
+}
+
+// sampleNotificationData is used to check templates before they are saved.
+var sampleNotificationData = NotificationData{
+	EventType: models.EventReviewSLABreached,
+	PullRequest: models.PullRequestEvent{
+		PullRequestID:     "pr-1001",
+		PullRequestName:   "Add search",
+		AuthorID:          "u1",
+		TeamName:          "backend",
+		Status:            models.PullRequestStatusOpen,
+		AssignedReviewers: []string{"u2", "u3"},
+	},
+	Recipient:     NotificationRecipient{UserID: "u2", Username: "Bob"},
+	OldReviewerID: "u4",
+	NewReviewerID: "u2",
+	ReviewSLA:     24 * time.Hour,
+	OpenReviews: []models.PullRequest{
+		{PullRequestID: "pr-1001", PullRequestName: "Add search", AuthorID: "u1", TeamName: "backend", Status: models.PullRequestStatusOpen},
+	},
+}
+
+func renderTemplate(w io.Writer, name, text string, data NotificationData) error {
+	tmpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return err
+	}
+
+	return tmpl.Execute(w, data)
+}
+
+// validateTemplates checks that the templates are of notified events, parse
+// and execute.
+func validateTemplates(templates map[string]string) error {
+	for eventType, text := range templates {
+		if _, ok := defaultChatTemplates[eventType]; !ok {
+			return fmt.Errorf("%w: %s is not a notified event", ErrInvalidTemplate, eventType)
+		}
+
+		if err := renderTemplate(io.Discard, eventType, text, sampleNotificationData); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidTemplate, eventType, err)
+		}
+	}
+
+	return nil
+}
+
+type NotificationService struct {
+	notificationRepo repo.Notification
+}
+
+func NewNotificationService(notificationRepo repo.Notification) *NotificationService {
+	return &NotificationService{notificationRepo: notificationRepo}
+}
+
+func (s *NotificationService) AddChatChannel(ctx context.Context, input ChatChannelInput) (*ChatChannelOutput, error) {
+	if err := validateTemplates(input.Templates); err != nil {
+		return nil, err
+	}
+
+	if err := safehttp.CheckURL(ctx, input.URL); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrURLNotAllowed, err)
+	}
+
+	channel, err := s.notificationRepo.CreateChatChannel(ctx, models.ChatChannel{
+		TeamName:   input.TeamName,
+		URL:        input.URL,
+		EventTypes: input.EventTypes,
+		Templates:  input.Templates,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	output := toChatChannelOutput(*channel)
+
+	return &output, nil
+}
+
+func (s *NotificationService) GetChatChannels(ctx context.Context, teamName string) (*ChatChannelsOutput, error) {
+	channels, err := s.notificationRepo.GetChatChannels(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+
+	output := ChatChannelsOutput{
+		Channels: []ChatChannelOutput{},
+	}
+	for _, channel := range channels {
+		output.Channels = append(output.Channels, toChatChannelOutput(channel))
+	}
+
+	return &output, nil
+}
+
+func (s *NotificationService) SetChatChannelIsActive(ctx context.Context, channelID int, isActive bool) (*ChatChannelOutput, error) {
+	channel, err := s.notificationRepo.SetChatChannelIsActive(ctx, channelID, isActive)
+	if err != nil {
+		return nil, err
+	}
+
+	output := toChatChannelOutput(*channel)
+
+	return &output, nil
+}
+
+func (s *NotificationService) DeleteChatChannel(ctx context.Context, channelID int) error {
+	return s.notificationRepo.DeleteChatChannel(ctx, channelID)
+}
+
+func (s *NotificationService) GetNotifications(ctx context.Context, input NotificationsInput) (*NotificationsOutput, error) {
+	limit := input.Limit
+	if limit == 0 {
+		limit = DefaultNotificationsLimit
+	}
+
+	notifications, err := s.notificationRepo.GetNotifications(ctx, models.NotificationFilter{
+		Channel: input.Channel,
+		Status:  input.Status,
+		Cursor:  input.Cursor,
+		Limit:   limit + 1, // one more to know whether there is a next page
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	output := NotificationsOutput{
+		Notifications: []NotificationOutput{},
+	}
+
+	if uint64(len(notifications)) > limit {
+		notifications = notifications[:limit]
+		output.NextCursor = notifications[len(notifications)-1].ID
+	}
+
+	for _, notification := range notifications {
+		output.Notifications = append(output.Notifications, toNotificationOutput(notification))
+	}
+
+	return &output, nil
+}
+
+// RetryNotification requeues the dead notification with a fresh attempts
+// budget.
+func (s *NotificationService) RetryNotification(ctx context.Context, notificationID int64) (*NotificationRetryOutput, error) {
+	notification, requeued, err := s.notificationRepo.RetryNotification(ctx, notificationID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NotificationRetryOutput{
+		Notification: toNotificationOutput(*notification),
+		Requeued:     requeued,
+	}, nil
+}
+
+func toChatChannelOutput(channel models.ChatChannel) ChatChannelOutput {
+	return ChatChannelOutput{
+		ChannelID:  channel.ID,
+		TeamName:   channel.TeamName,
+		URL:        maskURL(channel.URL),
+		EventTypes: channel.EventTypes,
+		Templates:  channel.Templates,
+		IsActive:   channel.IsActive,
+		CreatedAt:  channel.CreatedAt,
+	}
+}
+
+// maskURL hides the path and query of the incoming webhook URL, they carry
+// the secret of the webhook.
+func maskURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "***"
+	}
+
+	if u.Path == "" && u.RawQuery == "" {
+		return u.Scheme + "://" + u.Host
+	}
+	return u.Scheme + "://" + u.Host + "/***"
+}
+
+func toNotificationOutput(notification models.Notification) NotificationOutput {
+	output := NotificationOutput{
+		NotificationID: notification.ID,
+		EventID:        notification.EventID,
+		EventType:      notification.Event.EventType,
+		Channel:        notification.Channel,
+		ChatChannelID:  notification.ChatChannelID,
+		RecipientID:    notification.RecipientID,
+		DigestDate:     notification.DigestDate,
+		Status:         notification.Status,
+		Attempts:       notification.Attempts,
+		LastError:      notification.LastError,
+		CreatedAt:      notification.CreatedAt,
+		SentAt:         notification.SentAt,
+	}
+
+	if notification.Status == models.NotificationStatusPending {
+		output.NextAttemptAt = &notification.NextAttemptAt
+	}
+
+	return output
+}
+
+// chatText renders the message of the chat notification with the template of
+// its channel, the default one when the channel has none.
+func chatText(notification models.Notification, data NotificationData) (string, error) {
+	text, ok := notification.Templates[data.EventType]
+	if !ok {
+		text, ok = defaultChatTemplates[data.EventType]
+	}
+	if !ok {
+		return "", fmt.Errorf("%s is not a notified event", data.EventType)
+	}
+
+	var b strings.Builder
+	if err := renderTemplate(&b, data.EventType, text, data); err != nil {
+		return "", fmt.Errorf("failed to render message: %w", err)
+	}
+
+	return b.String(), nil
+}
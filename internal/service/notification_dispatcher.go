@@ -0,0 +1,308 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/metrics"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/logger"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/safehttp"
+)
+
+// ErrNothingToSend is returned by senders for notifications which have nothing
+// to send anymore, they are skipped without spending an attempt.
+var ErrNothingToSend = errors.New("nothing to send")
+
+// NotificationSender sends claimed notifications of a channel.
+type NotificationSender interface {
+	Send(ctx context.Context, notification models.Notification, data NotificationData) error
+}
+
+// ChatSender posts notifications to Slack/Mattermost compatible incoming
+// webhooks, internal addresses and redirects are refused.
+type ChatSender struct {
+	client *http.Client
+}
+
+func NewChatSender(timeout time.Duration) *ChatSender {
+	return &ChatSender{client: safehttp.NewClient(timeout)}
+}
+
+type chatMessage struct {
+	Text string `json:"text"`
+}
+
+func (s *ChatSender) Send(ctx context.Context, notification models.Notification, data NotificationData) error {
+	text, err := chatText(notification, data)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(chatMessage{Text: text})
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, notification.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+type NotificationDispatcherConfig struct {
+	DispatchInterval time.Duration
+	BatchSize        uint64
+	RequestTimeout   time.Duration
+	MaxAttempts      int
+	RetryBaseDelay   time.Duration
+	RetryMaxDelay    time.Duration
+}
+
+// NotificationDispatcher creates notifications of outbox events and sends
+// them with retries through the sender of their channel. Several instances may
+// run against the same database, notifications are claimed with row locks.
+type NotificationDispatcher struct {
+	notificationRepo repo.Notification
+	senders          map[string]NotificationSender // by channel
+	channels         []string                      // configured ones, only they are fanned out
+	cfg              NotificationDispatcherConfig
+	log              logger.Logger
+}
+
+func NewNotificationDispatcher(notificationRepo repo.Notification, senders map[string]NotificationSender, cfg NotificationDispatcherConfig, log logger.Logger) *NotificationDispatcher {
+	cfg.BatchSize = max(cfg.BatchSize, 1)
+	cfg.MaxAttempts = max(cfg.MaxAttempts, 1)
+	if cfg.DispatchInterval <= 0 {
+		cfg.DispatchInterval = time.Second
+	}
+
+	return &NotificationDispatcher{
+		notificationRepo: notificationRepo,
+		senders:          senders,
+		channels:         slices.Sorted(maps.Keys(senders)),
+		cfg:              cfg,
+		log:              log,
+	}
+}
+
+// Run dispatches notifications every DispatchInterval until ctx is done.
+func (d *NotificationDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.DispatchInterval)
+	defer ticker.Stop()
+
+	for {
+		d.dispatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *NotificationDispatcher) dispatch(ctx context.Context) {
+	for ctx.Err() == nil {
+		events, err := d.notificationRepo.FanOutNotifications(ctx, d.cfg.BatchSize, d.channels)
+		if err != nil {
+			d.log.Error("failed to fan out notifications", map[string]any{"error": err})
+			break
+		}
+
+		if uint64(events) < d.cfg.BatchSize {
+			break
+		}
+	}
+
+	for ctx.Err() == nil {
+		if sent := d.sendBatch(ctx); uint64(sent) < d.cfg.BatchSize {
+			return
+		}
+	}
+}
+
+// sendBatch claims due notifications and sends them one by one, returning the
+// number of claimed notifications.
+func (d *NotificationDispatcher) sendBatch(ctx context.Context) int {
+	// enough to send the whole batch even if every request times out
+	lease := d.cfg.RequestTimeout*time.Duration(d.cfg.BatchSize) + time.Minute
+
+	notifications, err := d.notificationRepo.ClaimNotifications(ctx, d.cfg.BatchSize, lease)
+	if err != nil {
+		d.log.Error("failed to claim notifications", map[string]any{"error": err})
+		return 0
+	}
+
+	for _, notification := range notifications {
+		d.send(ctx, notification)
+	}
+
+	return len(notifications)
+}
+
+func (d *NotificationDispatcher) send(ctx context.Context, notification models.Notification) {
+	// the recipient turned the notification off since it was created
+	if !notification.Wanted {
+		metrics.Notifications.WithLabelValues(notification.Channel, "skipped").Inc()
+		d.skip(context.WithoutCancel(ctx), notification)
+		return
+	}
+
+	if delay := quietHoursLeft(time.Now(), notification.Timezone, notification.QuietHours); delay > 0 {
+		err := d.notificationRepo.PostponeNotification(context.WithoutCancel(ctx), notification.ID, notification.LeaseToken, delay)
+		switch {
+		case err == nil:
+		case errors.Is(err, repoerrs.ErrLeaseLost):
+			d.log.Warn("notification lease expired before it was postponed", map[string]any{"notification_id": notification.ID})
+		default:
+			d.log.Error("failed to postpone notification", map[string]any{"notification_id": notification.ID, "error": err})
+		}
+		return
+	}
+
+	err := d.deliver(ctx, notification)
+
+	// the attempt is recorded even when dispatching is being stopped
+	ctx = context.WithoutCancel(ctx)
+
+	if err == nil {
+		metrics.Notifications.WithLabelValues(notification.Channel, "sent").Inc()
+
+		d.finish(ctx, notification, true, "", nil)
+		return
+	}
+
+	if errors.Is(err, ErrNothingToSend) {
+		metrics.Notifications.WithLabelValues(notification.Channel, "skipped").Inc()
+		d.skip(ctx, notification)
+		return
+	}
+
+	fields := map[string]any{
+		"notification_id": notification.ID,
+		"channel":         notification.Channel,
+		"event_type":      notification.Event.EventType,
+		"recipient_id":    notification.RecipientID,
+		"attempt":         notification.Attempts + 1,
+		"error":           err,
+	}
+
+	var retryAfter *time.Duration
+	if notification.Attempts+1 < d.cfg.MaxAttempts {
+		delay := backoffDelay(d.cfg.RetryBaseDelay, d.cfg.RetryMaxDelay, notification.Attempts+1)
+		retryAfter = &delay
+
+		metrics.Notifications.WithLabelValues(notification.Channel, "failed").Inc()
+		d.log.Warn("notification failed, will retry", fields)
+	} else {
+		metrics.Notifications.WithLabelValues(notification.Channel, "dead").Inc()
+		d.log.Error("notification is dead", fields)
+	}
+
+	d.finish(ctx, notification, false, err.Error(), retryAfter)
+}
+
+// finish records the attempt unless another dispatcher claimed the
+// notification after its lease expired, then the attempt is left to that
+// dispatcher.
+func (d *NotificationDispatcher) finish(ctx context.Context, notification models.Notification, sent bool, lastError string, retryAfter *time.Duration) {
+	err := d.notificationRepo.FinishNotification(ctx, notification.ID, notification.LeaseToken, sent, lastError, retryAfter)
+	switch {
+	case err == nil:
+	case errors.Is(err, repoerrs.ErrLeaseLost):
+		d.log.Warn("notification lease expired before the attempt was recorded", map[string]any{"notification_id": notification.ID})
+	default:
+		d.log.Error("failed to record notification attempt", map[string]any{"notification_id": notification.ID, "error": err})
+	}
+}
+
+// skip closes the notification which has nothing to send.
+func (d *NotificationDispatcher) skip(ctx context.Context, notification models.Notification) {
+	err := d.notificationRepo.SkipNotification(ctx, notification.ID, notification.LeaseToken)
+	switch {
+	case err == nil:
+	case errors.Is(err, repoerrs.ErrLeaseLost):
+		d.log.Warn("notification lease expired before it was skipped", map[string]any{"notification_id": notification.ID})
+	default:
+		d.log.Error("failed to skip notification", map[string]any{"notification_id": notification.ID, "error": err})
+	}
+}
+
+func (d *NotificationDispatcher) deliver(ctx context.Context, notification models.Notification) error {
+	sender, ok := d.senders[notification.Channel]
+	if !ok {
+		return fmt.Errorf("channel %s is not configured", notification.Channel)
+	}
+
+	data, err := newNotificationData(notification)
+	if err != nil {
+		return err
+	}
+
+	return sender.Send(ctx, notification, data)
+}
+
+// quietHoursLeft is how long the quiet hours of the recipient last after now,
+// zero outside of them.
+func quietHoursLeft(now time.Time, timezone string, quietHours *models.QuietHours) time.Duration {
+	if quietHours == nil {
+		return 0
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	start, errStart := time.Parse(quietHoursLayout, quietHours.Start)
+	end, errEnd := time.Parse(quietHoursLayout, quietHours.End)
+	if errStart != nil || errEnd != nil {
+		return 0
+	}
+
+	local := now.In(loc)
+	at := func(clock time.Time, days int) time.Time {
+		return time.Date(local.Year(), local.Month(), local.Day()+days, clock.Hour(), clock.Minute(), 0, 0, loc)
+	}
+
+	// the quiet hours started today or, when they span midnight, yesterday
+	for _, days := range []int{0, -1} {
+		from, till := at(start, days), at(end, days)
+		if !till.After(from) {
+			till = at(end, days+1)
+		}
+
+		if !local.Before(from) && local.Before(till) {
+			return till.Sub(now)
+		}
+	}
+
+	return 0
+}
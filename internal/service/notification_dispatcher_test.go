@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+)
+
+// fakeNotificationRepo records how claimed notifications were finished.
+type fakeNotificationRepo struct {
+	repo.Notification // not called by send
+
+	calls    []string
+	leaseErr bool
+}
+
+func (r *fakeNotificationRepo) result(call, leaseToken string) error {
+	r.calls = append(r.calls, call+" "+leaseToken)
+	if r.leaseErr {
+		return repoerrs.ErrLeaseLost
+	}
+	return nil
+}
+
+func (r *fakeNotificationRepo) FinishNotification(_ context.Context, _ int64, leaseToken string, sent bool, _ string, retryAfter *time.Duration) error {
+	switch {
+	case sent:
+		return r.result("sent", leaseToken)
+	case retryAfter != nil:
+		return r.result("retry", leaseToken)
+	default:
+		return r.result("dead", leaseToken)
+	}
+}
+
+func (r *fakeNotificationRepo) PostponeNotification(_ context.Context, _ int64, leaseToken string, _ time.Duration) error {
+	return r.result("postpone", leaseToken)
+}
+
+func (r *fakeNotificationRepo) SkipNotification(_ context.Context, _ int64, leaseToken string) error {
+	return r.result("skip", leaseToken)
+}
+
+type fakeSender struct {
+	err error
+}
+
+func (s fakeSender) Send(context.Context, models.Notification, NotificationData) error {
+	return s.err
+}
+
+func TestNotificationDispatcherSend(t *testing.T) {
+	now := time.Now().UTC()
+	quietNow := &models.QuietHours{
+		Start: now.Add(-time.Hour).Format(quietHoursLayout),
+		End:   now.Add(time.Hour).Format(quietHoursLayout),
+	}
+
+	tests := []struct {
+		name       string
+		unwanted   bool
+		quietHours *models.QuietHours
+		sendErr    error
+		attempts   int
+		leaseLost  bool
+		wantCalls  []string
+	}{
+		{
+			name:      "sent",
+			wantCalls: []string{"sent lease"},
+		},
+		{
+			name:      "nothing to send",
+			sendErr:   ErrNothingToSend,
+			wantCalls: []string{"skip lease"},
+		},
+		{
+			name:      "failed",
+			sendErr:   errors.New("smtp: 451"),
+			wantCalls: []string{"retry lease"},
+		},
+		{
+			name:      "out of attempts",
+			sendErr:   errors.New("smtp: 451"),
+			attempts:  2,
+			wantCalls: []string{"dead lease"},
+		},
+		{
+			name:      "turned off by the recipient",
+			unwanted:  true,
+			wantCalls: []string{"skip lease"},
+		},
+		{
+			name:       "quiet hours",
+			quietHours: quietNow,
+			wantCalls:  []string{"postpone lease"},
+		},
+		{
+			name:      "lease lost",
+			leaseLost: true,
+			wantCalls: []string{"sent lease"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notificationRepo := &fakeNotificationRepo{leaseErr: tt.leaseLost}
+
+			d := NewNotificationDispatcher(notificationRepo, map[string]NotificationSender{
+				models.NotificationChannelEmail: fakeSender{err: tt.sendErr},
+			}, NotificationDispatcherConfig{
+				MaxAttempts:    3,
+				RetryBaseDelay: time.Second,
+				RetryMaxDelay:  time.Minute,
+			}, nopLogger{})
+
+			digestDate := time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+			d.send(context.Background(), models.Notification{
+				ID:          1,
+				Channel:     models.NotificationChannelEmail,
+				RecipientID: "u2",
+				DigestDate:  &digestDate,
+				Status:      models.NotificationStatusPending,
+				Attempts:    tt.attempts,
+				Event:       models.OutboxEvent{EventType: models.NotificationReviewDigest},
+				LeaseToken:  "lease",
+				Wanted:      !tt.unwanted,
+				Timezone:    "UTC",
+				QuietHours:  tt.quietHours,
+			})
+
+			if !reflect.DeepEqual(notificationRepo.calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", notificationRepo.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestQuietHoursLeft(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+
+	day := &models.QuietHours{Start: "13:00", End: "14:30"}
+	night := &models.QuietHours{Start: "22:00", End: "08:00"}
+
+	tests := []struct {
+		name       string
+		now        time.Time
+		timezone   string
+		quietHours *models.QuietHours
+		want       time.Duration
+	}{
+		{"no quiet hours", time.Date(2025, 12, 31, 23, 0, 0, 0, time.UTC), "UTC", nil, 0},
+		{"before", time.Date(2025, 12, 31, 12, 59, 0, 0, time.UTC), "UTC", day, 0},
+		{"at start", time.Date(2025, 12, 31, 13, 0, 0, 0, time.UTC), "UTC", day, 90 * time.Minute},
+		{"within", time.Date(2025, 12, 31, 14, 0, 0, 0, time.UTC), "UTC", day, 30 * time.Minute},
+		{"at end", time.Date(2025, 12, 31, 14, 30, 0, 0, time.UTC), "UTC", day, 0},
+		{"across midnight, before it", time.Date(2025, 12, 31, 23, 0, 0, 0, time.UTC), "UTC", night, 9 * time.Hour},
+		{"across midnight, after it", time.Date(2026, 1, 1, 7, 30, 0, 0, time.UTC), "UTC", night, 30 * time.Minute},
+		{"across midnight, at end", time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC), "UTC", night, 0},
+		{"across midnight, outside", time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC), "UTC", night, 0},
+		{"in the timezone of the recipient", time.Date(2025, 12, 31, 20, 0, 0, 0, time.UTC), "Europe/Moscow", night, 9 * time.Hour},
+		{"outside in the timezone of the recipient", time.Date(2025, 12, 31, 22, 0, 0, 0, moscow), "UTC", night, 0},
+		{"unknown timezone is utc", time.Date(2025, 12, 31, 23, 0, 0, 0, time.UTC), "Mars/Olympus", night, 9 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := quietHoursLeft(tt.now, tt.timezone, tt.quietHours); got != tt.want {
+				t.Errorf("quietHoursLeft() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
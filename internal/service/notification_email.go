@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/tenant"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/logger"
+)
+
+// defaultEmailTemplates are the emails by notification kind. The "subject"
+// template is the subject, the rest is the plain text body.
+var defaultEmailTemplates = map[string]string{
+	models.EventPullRequestCreated: `{{define "subject"}}Review requested: {{.PullRequest.PullRequestName}}{{end -}}
+Hi {{.Recipient.Username}},
+
+you are assigned to review "{{.PullRequest.PullRequestName}}" ({{.PullRequest.PullRequestID}}) by {{.PullRequest.AuthorID}}.
+`,
+	models.EventReviewerReassigned: `{{define "subject"}}Review requested: {{.PullRequest.PullRequestName}}{{end -}}
+Hi {{.Recipient.Username}},
+
+you are assigned to review "{{.PullRequest.PullRequestName}}" ({{.PullRequest.PullRequestID}}) by {{.PullRequest.AuthorID}} instead of {{.OldReviewerID}}.
+`,
+	models.NotificationReviewDigest: `{{define "subject"}}Open reviews: {{len .OpenReviews}}{{end -}}
+Hi {{.Recipient.Username}},
+
+pull requests waiting for your review:
+{{range .OpenReviews}}- "{{.PullRequestName}}" ({{.PullRequestID}}) by {{.AuthorID}}
+{{end}}`,
+}
+
+// EmailTemplates are parsed email templates by notification kind.
+type EmailTemplates map[string]*template.Template
+
+// LoadEmailTemplates parses the default templates, overridden by
+// "<kind>.tmpl" files of dir when it is not empty, and checks them against
+// sample data.
+func LoadEmailTemplates(dir string) (EmailTemplates, error) {
+	templates := EmailTemplates{}
+
+	for kind, text := range defaultEmailTemplates {
+		if dir != "" {
+			data, err := os.ReadFile(filepath.Join(dir, kind+".tmpl"))
+			switch {
+			case err == nil:
+				text = string(data)
+			case !errors.Is(err, os.ErrNotExist):
+				return nil, fmt.Errorf("failed to read %s email template: %w", kind, err)
+			}
+		}
+
+		tmpl, err := template.New(kind).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s email: %v", ErrInvalidTemplate, kind, err)
+		}
+		if tmpl.Lookup("subject") == nil {
+			return nil, fmt.Errorf("%w: %s email has no subject template", ErrInvalidTemplate, kind)
+		}
+
+		if err := executeEmail(io.Discard, io.Discard, tmpl, sampleNotificationData); err != nil {
+			return nil, fmt.Errorf("%w: %s email: %v", ErrInvalidTemplate, kind, err)
+		}
+
+		templates[kind] = tmpl
+	}
+
+	return templates, nil
+}
+
+func executeEmail(subject, body io.Writer, tmpl *template.Template, data NotificationData) error {
+	if err := tmpl.ExecuteTemplate(subject, "subject", data); err != nil {
+		return err
+	}
+
+	return tmpl.Execute(body, data)
+}
+
+// Mailer sends plain text emails.
+type Mailer interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// EmailSender emails notifications to their recipients.
+type EmailSender struct {
+	mailer    Mailer
+	userRepo  repo.User
+	templates EmailTemplates
+}
+
+func NewEmailSender(mailer Mailer, userRepo repo.User, templates EmailTemplates) *EmailSender {
+	return &EmailSender{
+		mailer:    mailer,
+		userRepo:  userRepo,
+		templates: templates,
+	}
+}
+
+func (s *EmailSender) Send(ctx context.Context, notification models.Notification, data NotificationData) error {
+	if notification.Email == "" {
+		return errors.New("recipient has no email")
+	}
+
+	tmpl, ok := s.templates[data.EventType]
+	if !ok {
+		return fmt.Errorf("%s is not emailed", data.EventType)
+	}
+
+	if data.EventType == models.NotificationReviewDigest {
+		reviews, err := s.userRepo.GetReviewPRsByUserID(tenant.WithID(ctx, notification.TenantID), notification.RecipientID)
+		if err != nil {
+			return err
+		}
+
+		for _, pr := range reviews {
+			if pr.Status == models.PullRequestStatusOpen {
+				data.OpenReviews = append(data.OpenReviews, pr)
+			}
+		}
+
+		// reviews were finished since the digest was created
+		if len(data.OpenReviews) == 0 {
+			return ErrNothingToSend
+		}
+	}
+
+	var subject, body strings.Builder
+	if err := executeEmail(&subject, &body, tmpl, data); err != nil {
+		return fmt.Errorf("failed to render email: %w", err)
+	}
+
+	return s.mailer.Send(ctx, notification.Email, strings.TrimSpace(subject.String()), body.String())
+}
+
+// DigestScheduler creates daily digests of open reviews once the hour of the
+// digest has come.
+type DigestScheduler struct {
+	notificationRepo repo.Notification
+	hour             int // UTC
+	interval         time.Duration
+	log              logger.Logger
+}
+
+func NewDigestScheduler(notificationRepo repo.Notification, hour int, interval time.Duration, log logger.Logger) *DigestScheduler {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	return &DigestScheduler{
+		notificationRepo: notificationRepo,
+		hour:             hour,
+		interval:         interval,
+		log:              log,
+	}
+}
+
+// Run checks every interval until ctx is done. Digests are created once per
+// user and day, so instances may run it concurrently.
+func (s *DigestScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.schedule(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *DigestScheduler) schedule(ctx context.Context) {
+	now := time.Now().UTC()
+	if now.Hour() < s.hour {
+		return
+	}
+
+	digests, err := s.notificationRepo.CreateEmailDigests(ctx, now)
+	if err != nil {
+		s.log.Error("failed to create email digests", map[string]any{"error": err})
+		return
+	}
+
+	if digests > 0 {
+		s.log.Info("email digests created", map[string]any{"digests": digests})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/MatTwix/Pull-Request-Assigner/internal/metrics"
 	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
 	"github.com/MatTwix/Pull-Request-Assigner/internal/repo"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/tenant"
 )
 
 type PullRequestService struct {
@@ -21,6 +22,9 @@ func (s *PullRequestService) CreatePR(ctx context.Context, input PullRequestCrea
 		PullRequestID:   input.PullRequestID,
 		PullRequestName: input.PullRequestName,
 		AuthorID:        input.AuthorID,
+		TeamName:        input.TeamName,
+		RepositoryName:  input.RepositoryName,
+		VCSProvider:     input.VCSProvider,
 	}
 
 	createdPullRequest, err := s.pullRequestRepo.CreatePR(ctx, pullRequest)
@@ -32,9 +36,12 @@ func (s *PullRequestService) CreatePR(ctx context.Context, input PullRequestCrea
 		PullRequestID:     createdPullRequest.PullRequestID,
 		PullRequestName:   createdPullRequest.PullRequestName,
 		AuthorID:          createdPullRequest.AuthorID,
+		TeamName:          createdPullRequest.TeamName,
+		RepositoryName:    createdPullRequest.RepositoryName,
 		Status:            createdPullRequest.Status,
 		AssignedReviewers: createdPullRequest.AssignedReviewers,
 		CreatedAt:         createdPullRequest.CreatedAt,
+		Version:           createdPullRequest.Version,
 	}
 
 	output := PullRequestCreateOutput{PullRequest: outputPR}
@@ -56,22 +63,30 @@ func (s *PullRequestService) MergePR(ctx context.Context, prID string) (*PullReq
 		Status:            pullRequest.Status,
 		AssignedReviewers: pullRequest.AssignedReviewers,
 		MergedAt:          *pullRequest.MergedAt,
+		Version:           pullRequest.Version,
 	}
 
 	output := PullRequestMergeOutput{PullRequest: outputPR}
 
 	if !alreadyMerged {
 		metrics.PRMerged.Inc()
+		metrics.PRTimeToMerge.WithLabelValues(metrics.TeamLabels(tenant.ID(ctx), pullRequest.TeamName, pullRequest.TeamIsArchived)).
+			Observe(pullRequest.MergedAt.Sub(pullRequest.CreatedAt).Seconds())
 	}
 	return &output, nil
 }
 
 func (s *PullRequestService) ReassignReviewer(ctx context.Context, prID, oldUserID string) (*PullRequestReassignOutput, error) {
-	pullRequest, replacedBy, err := s.pullRequestRepo.ReassignReviewer(ctx, prID, oldUserID)
+	pullRequest, replacedBy, firstReassignAfter, err := s.pullRequestRepo.ReassignReviewer(ctx, prID, oldUserID)
 	if err != nil {
 		return nil, err
 	}
 
+	if firstReassignAfter != nil {
+		metrics.PRTimeToFirstReassign.WithLabelValues(metrics.TeamLabels(tenant.ID(ctx), pullRequest.TeamName, pullRequest.TeamIsArchived)).
+			Observe(firstReassignAfter.Seconds())
+	}
+
 	output := PullRequestReassignOutput{
 		ReplacedBy: replacedBy,
 		PullRequest: PullRequestReassignOutputPR{
@@ -80,8 +95,70 @@ func (s *PullRequestService) ReassignReviewer(ctx context.Context, prID, oldUser
 			AuthorID:          pullRequest.AuthorID,
 			Status:            pullRequest.Status,
 			AssignedReviewers: pullRequest.AssignedReviewers,
+			Version:           pullRequest.Version,
+		},
+	}
+
+	return &output, nil
+}
+
+func (s *PullRequestService) ClosePR(ctx context.Context, prID string) (*PullRequestStatusOutput, error) {
+	pullRequest, alreadyClosed, err := s.pullRequestRepo.ClosePR(ctx, prID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !alreadyClosed {
+		metrics.PRClosed.Inc()
+	}
+	return toPullRequestStatusOutput(*pullRequest), nil
+}
+
+func (s *PullRequestService) ReopenPR(ctx context.Context, prID string) (*PullRequestStatusOutput, error) {
+	pullRequest, _, err := s.pullRequestRepo.ReopenPR(ctx, prID)
+	if err != nil {
+		return nil, err
+	}
+
+	return toPullRequestStatusOutput(*pullRequest), nil
+}
+
+func (s *PullRequestService) GetPR(ctx context.Context, prID string) (*PullRequestGetOutput, error) {
+	pullRequest, err := s.pullRequestRepo.GetPR(ctx, prID)
+	if err != nil {
+		return nil, err
+	}
+
+	output := PullRequestGetOutput{
+		PullRequest: PullRequestGetOutputPR{
+			PullRequestID:      pullRequest.PullRequestID,
+			PullRequestName:    pullRequest.PullRequestName,
+			AuthorID:           pullRequest.AuthorID,
+			TeamName:           pullRequest.TeamName,
+			RepositoryName:     pullRequest.RepositoryName,
+			VCSProvider:        pullRequest.VCSProvider,
+			Status:             pullRequest.Status,
+			AssignedReviewers:  pullRequest.AssignedReviewers,
+			NeedsMoreReviewers: pullRequest.NeedsMoreReviewers,
+			CreatedAt:          pullRequest.CreatedAt,
+			MergedAt:           pullRequest.MergedAt,
+			Version:            pullRequest.Version,
+			ReviewerSync:       toReviewerSyncOutput(pullRequest.ReviewerSync),
 		},
 	}
 
 	return &output, nil
 }
+
+func toPullRequestStatusOutput(pullRequest models.PullRequest) *PullRequestStatusOutput {
+	return &PullRequestStatusOutput{
+		PullRequest: PullRequestStatusOutputPR{
+			PullRequestID:     pullRequest.PullRequestID,
+			PullRequestName:   pullRequest.PullRequestName,
+			AuthorID:          pullRequest.AuthorID,
+			Status:            pullRequest.Status,
+			AssignedReviewers: pullRequest.AssignedReviewers,
+			Version:           pullRequest.Version,
+		},
+	}
+}
@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/audit"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/metrics"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/tenant"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/logger"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/secretbox"
+)
+
+const (
+	DefaultReconciliationLimit = 50
+	MaxReconciliationLimit     = 500
+
+	// ReconciliationActor is recorded in the audit log for corrections of the
+	// scheduled reconciliation.
+	ReconciliationActor = "reconciliation"
+)
+
+var (
+	ErrReconciliationRunning  = errors.New("reconciliation is already running")
+	ErrVCSPullRequestNotFound = errors.New("pull request is not found in the provider")
+)
+
+// VCSPullRequestState is a pull request as its provider reports it.
+type VCSPullRequestState struct {
+	Merged    bool
+	Closed    bool     // closed without merge
+	Reviewers []string // logins of requested reviewers and of those who reviewed
+}
+
+// VCSClient reads pull requests of a provider by the ids its webhooks create
+// them with.
+type VCSClient interface {
+	GetPullRequest(ctx context.Context, token, prID string) (*VCSPullRequestState, error)
+}
+
+type ReconciliationService struct {
+	reconciliationRepo repo.Reconciliation
+	prService          PullRequest
+	clients            map[string]VCSClient // by provider
+	tokenBox           *secretbox.Box
+	pageSize           uint64
+}
+
+func NewReconciliationService(reconciliationRepo repo.Reconciliation, prService PullRequest, clients map[string]VCSClient, tokenBox *secretbox.Box, pageSize uint64) *ReconciliationService {
+	return &ReconciliationService{
+		reconciliationRepo: reconciliationRepo,
+		prService:          prService,
+		clients:            clients,
+		tokenBox:           tokenBox,
+		pageSize:           max(pageSize, 1),
+	}
+}
+
+// Reconcile checks open pull requests of the tenant, or of all tenants, against
+// their providers and fixes drift left by lost webhooks: pull requests merged
+// or closed there are merged or closed here, reviewers removed there are
+// reassigned. Reviewers are compared only when they were requested in the
+// provider. Every correction is recorded, failed ones with the error.
+func (s *ReconciliationService) Reconcile(ctx context.Context, allTenants bool) (*ReconciliationOutput, error) {
+	unlock, ok, err := s.reconciliationRepo.LockReconciliation(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrReconciliationRunning
+	}
+	defer unlock()
+
+	output := ReconciliationOutput{
+		Corrections: []ReconciliationOutputCorrection{},
+		Failures:    []ReconciliationOutputFailure{},
+	}
+
+	afterID := 0
+	for {
+		prs, err := s.reconciliationRepo.GetReconciledPullRequests(ctx, allTenants, afterID, s.pageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, pr := range prs {
+			corrections, err := s.reconcilePR(tenant.WithID(ctx, pr.TenantID), pr)
+			for _, correction := range corrections {
+				output.Corrections = append(output.Corrections, toReconciliationOutputCorrection(correction))
+			}
+
+			output.Checked++
+			if err != nil {
+				output.Failures = append(output.Failures, ReconciliationOutputFailure{
+					TenantID:      pr.TenantID,
+					PullRequestID: pr.PullRequestID,
+					Provider:      pr.Provider,
+					Error:         err.Error(),
+				})
+			}
+		}
+
+		if uint64(len(prs)) < s.pageSize {
+			return &output, nil
+		}
+		afterID = prs[len(prs)-1].ID
+	}
+}
+
+func (s *ReconciliationService) reconcilePR(ctx context.Context, pr models.ReconciledPullRequest) ([]models.ReconciliationCorrection, error) {
+	client, ok := s.clients[pr.Provider]
+	if !ok {
+		return nil, fmt.Errorf("provider %s is not supported", pr.Provider)
+	}
+
+	token, err := openAPIToken(s.tokenBox, pr.APIToken)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := client.GetPullRequest(ctx, token, pr.PullRequestID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case state.Merged:
+		_, err := s.prService.MergePR(ctx, pr.PullRequestID)
+		return s.record(ctx, pr, models.ReconciliationMerge, "", "", err)
+
+	case state.Closed:
+		_, err := s.prService.ClosePR(ctx, pr.PullRequestID)
+		return s.record(ctx, pr, models.ReconciliationClose, "", "", err)
+
+	case !pr.ReviewersSynced:
+		return nil, nil
+	}
+
+	present := make(map[string]bool, len(state.Reviewers))
+	for _, login := range state.Reviewers {
+		present[strings.ToLower(login)] = true
+	}
+
+	var corrections []models.ReconciliationCorrection
+	for i, reviewerID := range pr.ReviewerIDs {
+		// reviewers without a login were never requested in the provider
+		login := pr.ReviewerLogins[i]
+		if login == "" || present[strings.ToLower(login)] {
+			continue
+		}
+
+		var replacedBy string
+		output, err := s.prService.ReassignReviewer(ctx, pr.PullRequestID, reviewerID)
+		if err == nil {
+			replacedBy = output.ReplacedBy
+		}
+
+		recorded, err := s.record(ctx, pr, models.ReconciliationReassign, reviewerID, replacedBy, err)
+		corrections = append(corrections, recorded...)
+		if err != nil {
+			return corrections, err
+		}
+	}
+
+	return corrections, nil
+}
+
+// record saves the correction, failed with err when it is not nil. Pull
+// requests changed since they were paged are not corrected.
+func (s *ReconciliationService) record(ctx context.Context, pr models.ReconciledPullRequest, action, reviewerID, replacedBy string, err error) ([]models.ReconciliationCorrection, error) {
+	switch {
+	case errors.Is(err, repoerrs.ErrNotFound),
+		errors.Is(err, repoerrs.ErrPRMerged),
+		errors.Is(err, repoerrs.ErrNotAssigned),
+		errors.Is(err, repoerrs.ErrReassignAfterMerge),
+		errors.Is(err, repoerrs.ErrReassignAfterClose):
+		return nil, nil
+	}
+
+	correction := models.ReconciliationCorrection{
+		PullRequestID: pr.PullRequestID,
+		Provider:      pr.Provider,
+		Action:        action,
+		ReviewerID:    reviewerID,
+		ReplacedBy:    replacedBy,
+	}
+
+	result := "applied"
+	if err != nil {
+		correction.Error = err.Error()
+		result = "failed"
+	}
+	metrics.ReconciliationCorrections.WithLabelValues(pr.Provider, action, result).Inc()
+
+	recorded, recordErr := s.reconciliationRepo.AddReconciliationCorrection(ctx, correction)
+	if recordErr != nil {
+		return nil, recordErr
+	}
+
+	return []models.ReconciliationCorrection{*recorded}, nil
+}
+
+func (s *ReconciliationService) GetCorrections(ctx context.Context, input ReconciliationCorrectionsInput) (*ReconciliationCorrectionsOutput, error) {
+	limit := input.Limit
+	if limit == 0 {
+		limit = DefaultReconciliationLimit
+	}
+
+	corrections, err := s.reconciliationRepo.GetReconciliationCorrections(ctx, models.ReconciliationCorrectionFilter{
+		PullRequestID: input.PullRequestID,
+		Action:        input.Action,
+		From:          input.From,
+		To:            input.To,
+		Cursor:        input.Cursor,
+		Limit:         limit + 1, // one more to know whether there is a next page
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	output := ReconciliationCorrectionsOutput{
+		Corrections: []ReconciliationOutputCorrection{},
+	}
+
+	if uint64(len(corrections)) > limit {
+		corrections = corrections[:limit]
+		output.NextCursor = corrections[len(corrections)-1].ID
+	}
+
+	for _, correction := range corrections {
+		output.Corrections = append(output.Corrections, toReconciliationOutputCorrection(correction))
+	}
+
+	return &output, nil
+}
+
+func toReconciliationOutputCorrection(correction models.ReconciliationCorrection) ReconciliationOutputCorrection {
+	return ReconciliationOutputCorrection{
+		CorrectionID:  correction.ID,
+		TenantID:      correction.TenantID,
+		PullRequestID: correction.PullRequestID,
+		Provider:      correction.Provider,
+		Action:        correction.Action,
+		ReviewerID:    correction.ReviewerID,
+		ReplacedBy:    correction.ReplacedBy,
+		Error:         correction.Error,
+		Attempts:      correction.Attempts,
+		LastAttemptAt: correction.LastAttemptAt,
+		ResolvedAt:    correction.ResolvedAt,
+		CreatedAt:     correction.CreatedAt,
+	}
+}
+
+// Reconciler runs the reconciliation of all tenants every interval and logs
+// its report.
+type Reconciler struct {
+	reconciliation Reconciliation
+	interval       time.Duration
+	log            logger.Logger
+}
+
+func NewReconciler(reconciliation Reconciliation, interval time.Duration, log logger.Logger) *Reconciler {
+	return &Reconciler{
+		reconciliation: reconciliation,
+		interval:       interval,
+		log:            log,
+	}
+}
+
+// Run reconciles every interval until ctx is done.
+func (r *Reconciler) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.reconcile(ctx)
+		}
+	}
+}
+
+func (r *Reconciler) reconcile(ctx context.Context) {
+	output, err := r.reconciliation.Reconcile(audit.WithActor(ctx, ReconciliationActor), true)
+	switch {
+	case errors.Is(err, ErrReconciliationRunning):
+		r.log.Info("reconciliation is running on another instance, skipped")
+		return
+	case err != nil:
+		r.log.Error("failed to reconcile pull requests", map[string]any{"error": err})
+		return
+	}
+
+	for _, correction := range output.Corrections {
+		fields := map[string]any{
+			"tenant_id":   correction.TenantID,
+			"pr_id":       correction.PullRequestID,
+			"provider":    correction.Provider,
+			"action":      correction.Action,
+			"reviewer_id": correction.ReviewerID,
+			"replaced_by": correction.ReplacedBy,
+		}
+
+		if correction.Error != "" {
+			fields["error"] = correction.Error
+			r.log.Warn("reconciliation correction failed", fields)
+		} else {
+			r.log.Info("reconciliation corrected pull request", fields)
+		}
+	}
+
+	for _, failure := range output.Failures {
+		r.log.Warn("failed to reconcile pull request", map[string]any{
+			"tenant_id": failure.TenantID,
+			"pr_id":     failure.PullRequestID,
+			"provider":  failure.Provider,
+			"error":     failure.Error,
+		})
+	}
+
+	r.log.Info("reconciliation finished", map[string]any{
+		"checked":     output.Checked,
+		"corrections": len(output.Corrections),
+		"failures":    len(output.Failures),
+	})
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/secretbox"
+)
+
+type fakeVCSClient struct {
+	states map[string]VCSPullRequestState // by pull request id
+	tokens []string
+}
+
+func (c *fakeVCSClient) GetPullRequest(_ context.Context, token, prID string) (*VCSPullRequestState, error) {
+	c.tokens = append(c.tokens, token)
+
+	state, ok := c.states[prID]
+	if !ok {
+		return nil, ErrVCSPullRequestNotFound
+	}
+	return &state, nil
+}
+
+type fakeReconciliationRepo struct {
+	prs         []models.ReconciledPullRequest
+	corrections []models.ReconciliationCorrection
+}
+
+func (r *fakeReconciliationRepo) LockReconciliation(context.Context) (func(), bool, error) {
+	return func() {}, true, nil
+}
+
+func (r *fakeReconciliationRepo) GetReconciledPullRequests(_ context.Context, _ bool, afterID int, limit uint64) ([]models.ReconciledPullRequest, error) {
+	page := []models.ReconciledPullRequest{}
+	for _, pr := range r.prs {
+		if pr.ID > afterID && uint64(len(page)) < limit {
+			page = append(page, pr)
+		}
+	}
+	return page, nil
+}
+
+func (r *fakeReconciliationRepo) AddReconciliationCorrection(_ context.Context, correction models.ReconciliationCorrection) (*models.ReconciliationCorrection, error) {
+	r.corrections = append(r.corrections, correction)
+	return &correction, nil
+}
+
+func (r *fakeReconciliationRepo) GetReconciliationCorrections(context.Context, models.ReconciliationCorrectionFilter) ([]models.ReconciliationCorrection, error) {
+	return r.corrections, nil
+}
+
+// fakePullRequestService records the changes reconciliation makes.
+type fakePullRequestService struct {
+	PullRequest // not called by reconciliation
+
+	calls       []string
+	reassignErr error
+}
+
+func (s *fakePullRequestService) MergePR(_ context.Context, prID string) (*PullRequestMergeOutput, error) {
+	s.calls = append(s.calls, "merge "+prID)
+	return &PullRequestMergeOutput{}, nil
+}
+
+func (s *fakePullRequestService) ClosePR(_ context.Context, prID string) (*PullRequestStatusOutput, error) {
+	s.calls = append(s.calls, "close "+prID)
+	return &PullRequestStatusOutput{}, nil
+}
+
+func (s *fakePullRequestService) ReassignReviewer(_ context.Context, prID, oldUserID string) (*PullRequestReassignOutput, error) {
+	s.calls = append(s.calls, "reassign "+prID+" "+oldUserID)
+	if s.reassignErr != nil {
+		return nil, s.reassignErr
+	}
+	return &PullRequestReassignOutput{ReplacedBy: "u9"}, nil
+}
+
+func TestReconcile(t *testing.T) {
+	box, err := secretbox.New("MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := box.Seal("ghp_token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pr := func(id int, prID string, reviewerIDs, logins []string, synced bool) models.ReconciledPullRequest {
+		return models.ReconciledPullRequest{
+			ID:              id,
+			TenantID:        1,
+			PullRequestID:   prID,
+			Provider:        models.VCSProviderGitHub,
+			APIToken:        token,
+			ReviewerIDs:     reviewerIDs,
+			ReviewerLogins:  logins,
+			ReviewersSynced: synced,
+		}
+	}
+
+	tests := []struct {
+		name            string
+		pr              models.ReconciledPullRequest
+		state           VCSPullRequestState
+		reassignErr     error
+		wantCalls       []string
+		wantCorrections []models.ReconciliationCorrection
+	}{
+		{
+			name:      "merged upstream",
+			pr:        pr(1, "acme/api#1", []string{"u1"}, []string{"alice"}, true),
+			state:     VCSPullRequestState{Merged: true},
+			wantCalls: []string{"merge acme/api#1"},
+			wantCorrections: []models.ReconciliationCorrection{
+				{PullRequestID: "acme/api#1", Provider: models.VCSProviderGitHub, Action: models.ReconciliationMerge},
+			},
+		},
+		{
+			name:      "closed upstream",
+			pr:        pr(2, "acme/api#2", []string{"u1"}, []string{"alice"}, true),
+			state:     VCSPullRequestState{Closed: true},
+			wantCalls: []string{"close acme/api#2"},
+			wantCorrections: []models.ReconciliationCorrection{
+				{PullRequestID: "acme/api#2", Provider: models.VCSProviderGitHub, Action: models.ReconciliationClose},
+			},
+		},
+		{
+			name:      "reviewer removed upstream",
+			pr:        pr(3, "acme/api#3", []string{"u1", "u2"}, []string{"alice", "bob"}, true),
+			state:     VCSPullRequestState{Reviewers: []string{"Alice"}},
+			wantCalls: []string{"reassign acme/api#3 u2"},
+			wantCorrections: []models.ReconciliationCorrection{
+				{PullRequestID: "acme/api#3", Provider: models.VCSProviderGitHub, Action: models.ReconciliationReassign, ReviewerID: "u2", ReplacedBy: "u9"},
+			},
+		},
+		{
+			name:            "unmapped reviewer is not drift",
+			pr:              pr(4, "acme/api#4", []string{"u1", "u2"}, []string{"alice", ""}, true),
+			state:           VCSPullRequestState{Reviewers: []string{"alice"}},
+			wantCalls:       nil,
+			wantCorrections: nil,
+		},
+		{
+			name:            "reviewers never requested",
+			pr:              pr(5, "acme/api#5", []string{"u1"}, []string{"alice"}, false),
+			state:           VCSPullRequestState{},
+			wantCalls:       nil,
+			wantCorrections: nil,
+		},
+		{
+			name:        "failed reassign is recorded",
+			pr:          pr(6, "acme/api#6", []string{"u1"}, []string{"alice"}, true),
+			state:       VCSPullRequestState{},
+			reassignErr: repoerrs.ErrNoCandidate,
+			wantCalls:   []string{"reassign acme/api#6 u1"},
+			wantCorrections: []models.ReconciliationCorrection{
+				{PullRequestID: "acme/api#6", Provider: models.VCSProviderGitHub, Action: models.ReconciliationReassign, ReviewerID: "u1", Error: repoerrs.ErrNoCandidate.Error()},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeVCSClient{states: map[string]VCSPullRequestState{tt.pr.PullRequestID: tt.state}}
+			reconciliationRepo := &fakeReconciliationRepo{prs: []models.ReconciledPullRequest{tt.pr}}
+			prService := &fakePullRequestService{reassignErr: tt.reassignErr}
+
+			s := NewReconciliationService(reconciliationRepo, prService, map[string]VCSClient{models.VCSProviderGitHub: client}, box, 10)
+
+			output, err := s.Reconcile(context.Background(), true)
+			if err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+
+			if output.Checked != 1 || len(output.Failures) != 0 {
+				t.Errorf("Reconcile() checked %d, failures %+v", output.Checked, output.Failures)
+			}
+
+			if !reflect.DeepEqual(client.tokens, []string{"ghp_token"}) {
+				t.Errorf("tokens = %v, want the decrypted token", client.tokens)
+			}
+
+			if !reflect.DeepEqual(prService.calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", prService.calls, tt.wantCalls)
+			}
+
+			if !reflect.DeepEqual(reconciliationRepo.corrections, tt.wantCorrections) {
+				t.Errorf("corrections = %+v, want %+v", reconciliationRepo.corrections, tt.wantCorrections)
+			}
+		})
+	}
+}
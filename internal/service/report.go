@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo"
+)
+
+const (
+	ReportPullRequests = "pull_requests"
+	ReportAssignments  = "assignments"
+	ReportUserStats    = "user_stats"
+	ReportTeamStats    = "team_stats"
+	ReportUserFlow     = "user_flow"
+	ReportTeamFlow     = "team_flow"
+)
+
+var (
+	ErrUnknownReport = errors.New("unknown report")
+	ErrUnknownColumn = errors.New("unknown column")
+)
+
+type reportColumn[T any] struct {
+	name  string
+	value func(T) any
+}
+
+var pullRequestReportColumns = []reportColumn[models.PullRequest]{
+	{"pull_request_id", func(pr models.PullRequest) any { return pr.PullRequestID }},
+	{"pull_request_name", func(pr models.PullRequest) any { return pr.PullRequestName }},
+	{"author_id", func(pr models.PullRequest) any { return pr.AuthorID }},
+	{"team_name", func(pr models.PullRequest) any { return pr.TeamName }},
+	{"repository_name", func(pr models.PullRequest) any { return pr.RepositoryName }},
+	{"status", func(pr models.PullRequest) any { return pr.Status }},
+	{"needs_more_reviewers", func(pr models.PullRequest) any { return pr.NeedsMoreReviewers }},
+	{"assigned_reviewers", func(pr models.PullRequest) any { return pr.AssignedReviewers }},
+	{"created_at", func(pr models.PullRequest) any { return pr.CreatedAt }},
+	{"merged_at", func(pr models.PullRequest) any { return optional(pr.MergedAt) }},
+}
+
+var assignmentReportColumns = []reportColumn[models.Assignment]{
+	{"pull_request_id", func(a models.Assignment) any { return a.PullRequestID }},
+	{"reviewer_id", func(a models.Assignment) any { return a.ReviewerID }},
+	{"team_name", func(a models.Assignment) any { return a.TeamName }},
+	{"repository_name", func(a models.Assignment) any { return a.RepositoryName }},
+	{"status", func(a models.Assignment) any { return a.Status }},
+	{"assigned_at", func(a models.Assignment) any { return a.AssignedAt }},
+	{"reassigned_at", func(a models.Assignment) any { return optional(a.ReassignedAt) }},
+	{"replaced_by", func(a models.Assignment) any { return a.ReplacedBy }},
+	{"merged_at", func(a models.Assignment) any { return optional(a.MergedAt) }},
+}
+
+var reviewStatsReportColumns = []reportColumn[models.ReviewStats]{
+	{"assigned", func(s models.ReviewStats) any { return s.Assigned }},
+	{"reassigned_away", func(s models.ReviewStats) any { return s.ReassignedAway }},
+	{"completed", func(s models.ReviewStats) any { return s.Completed }},
+	{"open_load", func(s models.ReviewStats) any { return s.OpenLoad }},
+}
+
+var userStatsReportColumns = append([]reportColumn[models.ReviewStats]{
+	{"user_id", func(s models.ReviewStats) any { return s.UserID }},
+	{"username", func(s models.ReviewStats) any { return s.Username }},
+}, reviewStatsReportColumns...)
+
+var teamStatsReportColumns = append([]reportColumn[models.ReviewStats]{
+	{"team_name", func(s models.ReviewStats) any { return s.TeamName }},
+}, reviewStatsReportColumns...)
+
+// Durations are in seconds, as in the flow stats API.
+var flowStatsReportColumns = []reportColumn[models.FlowStats]{
+	{"merged", func(s models.FlowStats) any { return s.Merged }},
+	{"time_to_merge_p50", func(s models.FlowStats) any { return optional(s.TimeToMerge.P50) }},
+	{"time_to_merge_p90", func(s models.FlowStats) any { return optional(s.TimeToMerge.P90) }},
+	{"time_to_merge_p99", func(s models.FlowStats) any { return optional(s.TimeToMerge.P99) }},
+	{"reassigned", func(s models.FlowStats) any { return s.Reassigned }},
+	{"time_to_first_reassign_p50", func(s models.FlowStats) any { return optional(s.TimeToFirstReassign.P50) }},
+	{"time_to_first_reassign_p90", func(s models.FlowStats) any { return optional(s.TimeToFirstReassign.P90) }},
+	{"time_to_first_reassign_p99", func(s models.FlowStats) any { return optional(s.TimeToFirstReassign.P99) }},
+}
+
+var userFlowReportColumns = append([]reportColumn[models.FlowStats]{
+	{"user_id", func(s models.FlowStats) any { return s.UserID }},
+}, flowStatsReportColumns...)
+
+var teamFlowReportColumns = append([]reportColumn[models.FlowStats]{
+	{"team_name", func(s models.FlowStats) any { return s.TeamName }},
+}, flowStatsReportColumns...)
+
+type ReportService struct {
+	statsRepo repo.Stats
+}
+
+func NewReportService(statsRepo repo.Stats) *ReportService {
+	return &ReportService{statsRepo: statsRepo}
+}
+
+// Export streams the report row by row into w. Errors returned before the
+// first row can still be reported to the client, since the header is written
+// together with the first row.
+func (s *ReportService) Export(ctx context.Context, input ReportInput, w ReportWriter) error {
+	filter := toStatsFilter(input.StatsInput)
+
+	switch input.Report {
+	case ReportPullRequests:
+		return exportReport(pullRequestReportColumns, input.Columns, w, func(fn func(models.PullRequest) error) error {
+			return s.statsRepo.StreamPullRequests(ctx, filter, fn)
+		})
+	case ReportAssignments:
+		return exportReport(assignmentReportColumns, input.Columns, w, func(fn func(models.Assignment) error) error {
+			return s.statsRepo.StreamAssignments(ctx, filter, fn)
+		})
+	case ReportUserStats:
+		return exportReport(userStatsReportColumns, input.Columns, w, func(fn func(models.ReviewStats) error) error {
+			return s.statsRepo.StreamUserStats(ctx, filter, fn)
+		})
+	case ReportTeamStats:
+		return exportReport(teamStatsReportColumns, input.Columns, w, func(fn func(models.ReviewStats) error) error {
+			return s.statsRepo.StreamTeamStats(ctx, filter, fn)
+		})
+	case ReportUserFlow:
+		return exportReport(userFlowReportColumns, input.Columns, w, func(fn func(models.FlowStats) error) error {
+			return s.statsRepo.StreamUserFlowStats(ctx, filter, fn)
+		})
+	case ReportTeamFlow:
+		return exportReport(teamFlowReportColumns, input.Columns, w, func(fn func(models.FlowStats) error) error {
+			return s.statsRepo.StreamTeamFlowStats(ctx, filter, fn)
+		})
+	default:
+		return ErrUnknownReport
+	}
+}
+
+func exportReport[T any](
+	columns []reportColumn[T],
+	selected []string,
+	w ReportWriter,
+	stream func(fn func(T) error) error,
+) error {
+	picked, err := pickReportColumns(columns, selected)
+	if err != nil {
+		return err
+	}
+
+	header := make([]string, len(picked))
+	for i, column := range picked {
+		header[i] = column.name
+	}
+
+	headerWritten := false
+	writeHeader := func() error {
+		if headerWritten {
+			return nil
+		}
+		headerWritten = true
+
+		return w.WriteHeader(header)
+	}
+
+	values := make([]any, len(picked))
+	if err := stream(func(item T) error {
+		if err := writeHeader(); err != nil {
+			return err
+		}
+
+		for i, column := range picked {
+			values[i] = column.value(item)
+		}
+
+		return w.WriteRow(values)
+	}); err != nil {
+		return err
+	}
+
+	return writeHeader()
+}
+
+func pickReportColumns[T any](columns []reportColumn[T], selected []string) ([]reportColumn[T], error) {
+	if len(selected) == 0 {
+		return columns, nil
+	}
+
+	picked := make([]reportColumn[T], 0, len(selected))
+	for _, name := range selected {
+		found := false
+		for _, column := range columns {
+			if column.name == name {
+				picked = append(picked, column)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownColumn, name)
+		}
+	}
+
+	return picked, nil
+}
+
+// optional unwraps a nullable value so writers see untyped nil for NULL.
+func optional[T any](value *T) any {
+	if value == nil {
+		return nil
+	}
+
+	return *value
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo"
+)
+
+type RepositoryService struct {
+	repositoryRepo repo.Repository
+}
+
+func NewRepositoryService(repositoryRepo repo.Repository) *RepositoryService {
+	return &RepositoryService{repositoryRepo: repositoryRepo}
+}
+
+func (s *RepositoryService) AddRepository(ctx context.Context, input RepositoryInput) (*RepositoryOutput, error) {
+	repository, err := s.repositoryRepo.CreateRepository(ctx, models.Repository{
+		RepositoryName: input.RepositoryName,
+		TeamName:       input.TeamName,
+		ReviewersCount: input.ReviewersCount,
+		FallbackDepth:  input.FallbackDepth,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	output := toRepositoryOutput(*repository)
+
+	return &output, nil
+}
+
+func (s *RepositoryService) GetRepository(ctx context.Context, repositoryName string) (*RepositoryOutput, error) {
+	repository, err := s.repositoryRepo.GetRepositoryByName(ctx, repositoryName)
+	if err != nil {
+		return nil, err
+	}
+
+	output := toRepositoryOutput(*repository)
+
+	return &output, nil
+}
+
+func (s *RepositoryService) SetRepositoryOwner(ctx context.Context, repositoryName, teamName string) (*RepositoryOutput, error) {
+	repository, err := s.repositoryRepo.SetRepositoryOwner(ctx, repositoryName, teamName)
+	if err != nil {
+		return nil, err
+	}
+
+	output := toRepositoryOutput(*repository)
+
+	return &output, nil
+}
+
+func (s *RepositoryService) SetRepositorySettings(ctx context.Context, repositoryName string, reviewersCount, fallbackDepth models.NullInt) (*RepositoryOutput, error) {
+	repository, err := s.repositoryRepo.SetRepositorySettings(ctx, repositoryName, reviewersCount, fallbackDepth)
+	if err != nil {
+		return nil, err
+	}
+
+	output := toRepositoryOutput(*repository)
+
+	return &output, nil
+}
+
+func toRepositoryOutput(repository models.Repository) RepositoryOutput {
+	return RepositoryOutput{
+		RepositoryName: repository.RepositoryName,
+		TeamName:       repository.TeamName,
+		ReviewersCount: repository.ReviewersCount,
+		FallbackDepth:  repository.FallbackDepth,
+		CreatedAt:      repository.CreatedAt,
+	}
+}
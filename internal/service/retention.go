@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/metrics"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/logger"
+)
+
+const (
+	RetentionModeArchive = "archive" // into the archive tables, still counted by stats and reports
+	RetentionModeDelete  = "delete"
+)
+
+type RetentionJobConfig struct {
+	Interval   time.Duration
+	MergedDays int // pull requests merged longer ago are retained
+	Mode       string
+	BatchSize  uint64 // pull requests moved per transaction
+}
+
+// RetentionJob moves merged pull requests out of the live tables in batches,
+// each in its own short transaction.
+type RetentionJob struct {
+	retentionRepo repo.Retention
+	cfg           RetentionJobConfig
+	log           logger.Logger
+}
+
+func NewRetentionJob(retentionRepo repo.Retention, cfg RetentionJobConfig, log logger.Logger) (*RetentionJob, error) {
+	if cfg.Mode != RetentionModeArchive && cfg.Mode != RetentionModeDelete {
+		return nil, fmt.Errorf("unknown retention mode %q", cfg.Mode)
+	}
+	if cfg.MergedDays <= 0 {
+		return nil, fmt.Errorf("retention merged days must be positive, got %d", cfg.MergedDays)
+	}
+
+	cfg.BatchSize = max(cfg.BatchSize, 1)
+
+	return &RetentionJob{
+		retentionRepo: retentionRepo,
+		cfg:           cfg,
+		log:           log,
+	}, nil
+}
+
+// Run retains pull requests every interval until ctx is done.
+func (j *RetentionJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			j.retain(ctx)
+		}
+	}
+}
+
+func (j *RetentionJob) retain(ctx context.Context) {
+	var total int64
+	for ctx.Err() == nil {
+		moved, err := j.retentionRepo.RetainMergedPullRequests(ctx, j.cfg.MergedDays, j.cfg.Mode == RetentionModeArchive, j.cfg.BatchSize)
+		if err != nil {
+			j.log.Error("failed to retain merged pull requests", map[string]any{"mode": j.cfg.Mode, "error": err})
+			break
+		}
+
+		metrics.PRRetained.WithLabelValues(j.cfg.Mode).Add(float64(moved))
+		total += moved
+
+		if uint64(moved) < j.cfg.BatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		j.log.Info("retained merged pull requests", map[string]any{
+			"mode":        j.cfg.Mode,
+			"merged_days": j.cfg.MergedDays,
+			"count":       total,
+		})
+	}
+}
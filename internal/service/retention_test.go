@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// fakeRetentionRepo moves the next of moved per call and records the calls.
+type fakeRetentionRepo struct {
+	moved []int64
+	err   error
+
+	calls []string
+}
+
+func (r *fakeRetentionRepo) RetainMergedPullRequests(_ context.Context, mergedDays int, archive bool, limit uint64) (int64, error) {
+	r.calls = append(r.calls, fmt.Sprintf("retain %d %v %d", mergedDays, archive, limit))
+	if len(r.moved) == 0 {
+		return 0, r.err
+	}
+
+	moved := r.moved[0]
+	r.moved = r.moved[1:]
+	return moved, nil
+}
+
+func TestRetentionJob(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      string
+		moved     []int64
+		err       error
+		wantCalls []string
+	}{
+		{
+			name:      "nothing to retain",
+			mode:      RetentionModeArchive,
+			wantCalls: []string{"retain 90 true 2"},
+		},
+		{
+			name:      "batches until a partial one",
+			mode:      RetentionModeDelete,
+			moved:     []int64{2, 2, 1},
+			wantCalls: []string{"retain 90 false 2", "retain 90 false 2", "retain 90 false 2"},
+		},
+		{
+			name:      "stops on error",
+			mode:      RetentionModeArchive,
+			moved:     []int64{2},
+			err:       errors.New("connection refused"),
+			wantCalls: []string{"retain 90 true 2", "retain 90 true 2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retentionRepo := &fakeRetentionRepo{moved: tt.moved, err: tt.err}
+			job, err := NewRetentionJob(retentionRepo, RetentionJobConfig{MergedDays: 90, Mode: tt.mode, BatchSize: 2}, nopLogger{})
+			if err != nil {
+				t.Fatalf("NewRetentionJob() error = %v", err)
+			}
+
+			job.retain(context.Background())
+
+			if !reflect.DeepEqual(retentionRepo.calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", retentionRepo.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestNewRetentionJob(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     RetentionJobConfig
+		wantErr bool
+	}{
+		{name: "archive", cfg: RetentionJobConfig{MergedDays: 1, Mode: RetentionModeArchive}},
+		{name: "unknown mode", cfg: RetentionJobConfig{MergedDays: 1, Mode: "move"}, wantErr: true},
+		{name: "no merged days", cfg: RetentionJobConfig{Mode: RetentionModeDelete}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRetentionJob(&fakeRetentionRepo{}, tt.cfg, nopLogger{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewRetentionJob() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/metrics"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/logger"
+)
+
+// SLAMonitor raises breach events of reviews waiting longer than the review
+// SLA of their team, once per assignment.
+type SLAMonitor struct {
+	prRepo    repo.PullRequest
+	interval  time.Duration
+	batchSize uint64
+	log       logger.Logger
+}
+
+func NewSLAMonitor(prRepo repo.PullRequest, interval time.Duration, batchSize uint64, log logger.Logger) *SLAMonitor {
+	return &SLAMonitor{
+		prRepo:    prRepo,
+		interval:  interval,
+		batchSize: max(batchSize, 1),
+		log:       log,
+	}
+}
+
+// Run checks reviews every interval until ctx is done.
+func (m *SLAMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.check(ctx)
+		}
+	}
+}
+
+func (m *SLAMonitor) check(ctx context.Context) {
+	for ctx.Err() == nil {
+		breaches, err := m.prRepo.MarkReviewSLABreaches(ctx, m.batchSize)
+		if err != nil {
+			m.log.Error("failed to check review sla", map[string]any{"error": err})
+			return
+		}
+
+		metrics.ReviewSLABreaches.Add(float64(breaches))
+
+		if uint64(breaches) < m.batchSize {
+			return
+		}
+	}
+}
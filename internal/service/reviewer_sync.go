@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/metrics"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/github"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/logger"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/secretbox"
+)
+
+var errNoAPIToken = errors.New("api token of the provider is not set")
+
+// GitHubClient is the part of the GitHub API reviewers are requested with.
+type GitHubClient interface {
+	RequestReviewers(ctx context.Context, token, repository string, number int, logins []string) error
+	RemoveRequestedReviewers(ctx context.Context, token, repository string, number int, logins []string) error
+}
+
+type ReviewerSyncService struct {
+	syncRepo repo.ReviewerSync
+}
+
+func NewReviewerSyncService(syncRepo repo.ReviewerSync) *ReviewerSyncService {
+	return &ReviewerSyncService{syncRepo: syncRepo}
+}
+
+// Resync queues requesting current reviewers of the pull request in GitHub
+// again with a new set of attempts.
+func (s *ReviewerSyncService) Resync(ctx context.Context, prID string) (*ReviewerSyncResyncOutput, error) {
+	sync, err := s.syncRepo.RequestReviewerSync(ctx, prID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReviewerSyncResyncOutput{
+		PullRequestID: sync.PullRequestID,
+		ReviewerSync:  *toReviewerSyncOutput(sync),
+	}, nil
+}
+
+func toReviewerSyncOutput(sync *models.ReviewerSync) *ReviewerSyncOutput {
+	if sync == nil {
+		return nil
+	}
+
+	output := ReviewerSyncOutput{
+		Status:    sync.Status,
+		Attempts:  sync.Attempts,
+		LastError: sync.LastError,
+		SyncedAt:  sync.SyncedAt,
+	}
+	if sync.Status == models.ReviewerSyncPending {
+		output.NextAttemptAt = sync.NextAttemptAt
+	}
+
+	return &output
+}
+
+type ReviewerSyncerConfig struct {
+	Interval       time.Duration
+	BatchSize      uint64
+	RequestTimeout time.Duration
+	MaxAttempts    int
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+}
+
+// ReviewerSyncer requests reviewers assigned here in GitHub and withdraws
+// requests of reviewers reassigned away. Syncs are queued in the transactions
+// changing reviewers and claimed with row locks, so several instances may run.
+type ReviewerSyncer struct {
+	syncRepo repo.ReviewerSync
+	client   GitHubClient
+	tokenBox *secretbox.Box
+	cfg      ReviewerSyncerConfig
+	log      logger.Logger
+}
+
+func NewReviewerSyncer(syncRepo repo.ReviewerSync, client GitHubClient, tokenBox *secretbox.Box, cfg ReviewerSyncerConfig, log logger.Logger) *ReviewerSyncer {
+	cfg.BatchSize = max(cfg.BatchSize, 1)
+	cfg.MaxAttempts = max(cfg.MaxAttempts, 1)
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+
+	return &ReviewerSyncer{
+		syncRepo: syncRepo,
+		client:   client,
+		tokenBox: tokenBox,
+		cfg:      cfg,
+		log:      log,
+	}
+}
+
+// Run syncs due pull requests every Interval until ctx is done.
+func (s *ReviewerSyncer) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			if synced := s.syncBatch(ctx); uint64(synced) < s.cfg.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// syncBatch claims due syncs and runs them one by one, returning the number of
+// claimed syncs.
+func (s *ReviewerSyncer) syncBatch(ctx context.Context) int {
+	// two requests per pull request
+	lease := 2*s.cfg.RequestTimeout*time.Duration(s.cfg.BatchSize) + time.Minute
+
+	syncs, err := s.syncRepo.ClaimReviewerSyncs(ctx, s.cfg.BatchSize, lease)
+	if err != nil {
+		s.log.Error("failed to claim reviewer syncs", map[string]any{"error": err})
+		return 0
+	}
+
+	for _, sync := range syncs {
+		s.sync(ctx, sync)
+	}
+
+	return len(syncs)
+}
+
+func (s *ReviewerSyncer) sync(ctx context.Context, sync models.ReviewerSync) {
+	err := s.push(ctx, sync)
+
+	// the attempt is recorded even when syncing is being stopped
+	ctx = context.WithoutCancel(ctx)
+
+	if err == nil {
+		metrics.ReviewerSyncs.WithLabelValues("synced").Inc()
+
+		if err := s.syncRepo.FinishReviewerSync(ctx, sync.ID, sync.Version, true, "", nil); err != nil {
+			s.log.Error("failed to mark reviewers synced", map[string]any{"pr_id": sync.PullRequestID, "error": err})
+		}
+		return
+	}
+
+	fields := map[string]any{
+		"tenant_id": sync.TenantID,
+		"pr_id":     sync.PullRequestID,
+		"attempt":   sync.Attempts + 1,
+		"error":     err,
+	}
+
+	var retryAfter *time.Duration
+	if sync.Attempts+1 < s.cfg.MaxAttempts && isTemporary(err) {
+		delay := backoffDelay(s.cfg.RetryBaseDelay, s.cfg.RetryMaxDelay, sync.Attempts+1)
+		retryAfter = &delay
+
+		metrics.ReviewerSyncs.WithLabelValues("failed").Inc()
+		s.log.Warn("reviewer sync failed, will retry", fields)
+	} else {
+		metrics.ReviewerSyncs.WithLabelValues("dead").Inc()
+		s.log.Error("reviewer sync failed", fields)
+	}
+
+	if err := s.syncRepo.FinishReviewerSync(ctx, sync.ID, sync.Version, false, err.Error(), retryAfter); err != nil {
+		s.log.Error("failed to record reviewer sync failure", map[string]any{"pr_id": sync.PullRequestID, "error": err})
+	}
+}
+
+func (s *ReviewerSyncer) push(ctx context.Context, sync models.ReviewerSync) error {
+	if sync.APIToken == "" {
+		return errNoAPIToken
+	}
+
+	token, err := openAPIToken(s.tokenBox, sync.APIToken)
+	if err != nil {
+		return err
+	}
+
+	repository, number, err := parseGitHubPullRequestID(sync.PullRequestID)
+	if err != nil {
+		return err
+	}
+
+	if len(sync.RequestReviewers) > 0 {
+		if err := s.client.RequestReviewers(ctx, token, repository, number, sync.RequestReviewers); err != nil {
+			return fmt.Errorf("failed to request reviewers: %w", err)
+		}
+	}
+
+	if len(sync.RemovedReviewers) > 0 {
+		if err := s.client.RemoveRequestedReviewers(ctx, token, repository, number, sync.RemovedReviewers); err != nil {
+			return fmt.Errorf("failed to remove requested reviewers: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// isTemporary reports whether the failed request is worth repeating: network
+// errors and temporary API errors are, rejected requests are not.
+func isTemporary(err error) bool {
+	var apiErr *github.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+
+	return !errors.Is(err, errNoAPIToken) && !errors.Is(err, ErrAPITokensDisabled) &&
+		!errors.Is(err, secretbox.ErrInvalidSealed) && !errors.Is(err, ErrInvalidPullRequestID)
+}
+
+// parseGitHubPullRequestID splits "<owner>/<repo>#<number>" the GitHub
+// webhooks create pull requests with.
+func parseGitHubPullRequestID(prID string) (repository string, number int, err error) {
+	repository, numberStr, ok := strings.Cut(prID, "#")
+	if ok {
+		number, err = strconv.Atoi(numberStr)
+	}
+	if !ok || err != nil || number <= 0 || !strings.Contains(repository, "/") {
+		return "", 0, fmt.Errorf("%w: %s", ErrInvalidPullRequestID, prID)
+	}
+
+	return repository, number, nil
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/github"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/secretbox"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...map[string]any) {}
+func (nopLogger) Info(string, ...map[string]any)  {}
+func (nopLogger) Warn(string, ...map[string]any)  {}
+func (nopLogger) Error(string, ...map[string]any) {}
+func (nopLogger) Fatal(string, ...map[string]any) {}
+
+type finishedSync struct {
+	synced bool
+	retry  bool
+}
+
+type fakeReviewerSyncRepo struct {
+	syncs    []models.ReviewerSync
+	finished []finishedSync
+}
+
+func (r *fakeReviewerSyncRepo) RequestReviewerSync(context.Context, string) (*models.ReviewerSync, error) {
+	return nil, nil
+}
+
+func (r *fakeReviewerSyncRepo) ClaimReviewerSyncs(context.Context, uint64, time.Duration) ([]models.ReviewerSync, error) {
+	syncs := r.syncs
+	r.syncs = nil
+	return syncs, nil
+}
+
+func (r *fakeReviewerSyncRepo) FinishReviewerSync(_ context.Context, _, _ int, synced bool, _ string, retryAfter *time.Duration) error {
+	r.finished = append(r.finished, finishedSync{synced: synced, retry: retryAfter != nil})
+	return nil
+}
+
+type gitHubRequest struct {
+	Method        string
+	Path          string
+	Authorization string
+	Reviewers     []string
+}
+
+// newGitHubMock answers requests to the requested reviewers api with the
+// status, recording them.
+func newGitHubMock(t *testing.T, status int) (*httptest.Server, *[]gitHubRequest) {
+	t.Helper()
+
+	var mu sync.Mutex
+	requests := []gitHubRequest{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Reviewers []string `json:"reviewers"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		mu.Lock()
+		requests = append(requests, gitHubRequest{
+			Method:        r.Method,
+			Path:          r.URL.Path,
+			Authorization: r.Header.Get("Authorization"),
+			Reviewers:     body.Reviewers,
+		})
+		mu.Unlock()
+
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"message": "mock"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &requests
+}
+
+func TestReviewerSyncerPush(t *testing.T) {
+	box, err := secretbox.New("MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sealed, err := box.Seal("ghp_token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const path = "/repos/acme/api/pulls/7/requested_reviewers"
+
+	tests := []struct {
+		name         string
+		status       int
+		token        string
+		request      []string
+		removed      []string
+		wantRequests []gitHubRequest
+		wantFinished finishedSync
+	}{
+		{
+			name:    "requested and removed",
+			status:  http.StatusCreated,
+			token:   sealed,
+			request: []string{"alice", "bob"},
+			removed: []string{"carol"},
+			wantRequests: []gitHubRequest{
+				{http.MethodPost, path, "Bearer ghp_token", []string{"alice", "bob"}},
+				{http.MethodDelete, path, "Bearer ghp_token", []string{"carol"}},
+			},
+			wantFinished: finishedSync{synced: true},
+		},
+		{
+			name:    "nothing to remove",
+			status:  http.StatusCreated,
+			token:   sealed,
+			request: []string{"alice"},
+			wantRequests: []gitHubRequest{
+				{http.MethodPost, path, "Bearer ghp_token", []string{"alice"}},
+			},
+			wantFinished: finishedSync{synced: true},
+		},
+		{
+			name:    "rejected",
+			status:  http.StatusUnprocessableEntity,
+			token:   sealed,
+			request: []string{"alice"},
+			wantRequests: []gitHubRequest{
+				{http.MethodPost, path, "Bearer ghp_token", []string{"alice"}},
+			},
+			wantFinished: finishedSync{},
+		},
+		{
+			name:    "temporary failure",
+			status:  http.StatusBadGateway,
+			token:   sealed,
+			request: []string{"alice"},
+			wantRequests: []gitHubRequest{
+				{http.MethodPost, path, "Bearer ghp_token", []string{"alice"}},
+			},
+			wantFinished: finishedSync{retry: true},
+		},
+		{
+			name:         "token stored unencrypted",
+			status:       http.StatusCreated,
+			token:        "ghp_token",
+			request:      []string{"alice"},
+			wantRequests: []gitHubRequest{},
+			wantFinished: finishedSync{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := newGitHubMock(t, tt.status)
+
+			syncRepo := &fakeReviewerSyncRepo{syncs: []models.ReviewerSync{{
+				ID:               1,
+				PullRequestID:    "acme/api#7",
+				Provider:         models.VCSProviderGitHub,
+				Status:           models.ReviewerSyncPending,
+				Version:          1,
+				APIToken:         tt.token,
+				RequestReviewers: tt.request,
+				RemovedReviewers: tt.removed,
+			}}}
+
+			syncer := NewReviewerSyncer(syncRepo, github.NewClient(server.URL, time.Second), box, ReviewerSyncerConfig{
+				BatchSize:      10,
+				RequestTimeout: time.Second,
+				MaxAttempts:    3,
+				RetryBaseDelay: time.Second,
+				RetryMaxDelay:  time.Minute,
+			}, nopLogger{})
+
+			if claimed := syncer.syncBatch(context.Background()); claimed != 1 {
+				t.Fatalf("syncBatch() = %d, want 1", claimed)
+			}
+
+			if !reflect.DeepEqual(*requests, tt.wantRequests) {
+				t.Errorf("requests = %+v, want %+v", *requests, tt.wantRequests)
+			}
+
+			if want := []finishedSync{tt.wantFinished}; !reflect.DeepEqual(syncRepo.finished, want) {
+				t.Errorf("finished = %+v, want %+v", syncRepo.finished, want)
+			}
+		})
+	}
+}
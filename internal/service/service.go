@@ -2,14 +2,17 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
 	"github.com/MatTwix/Pull-Request-Assigner/internal/repo"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/secretbox"
 )
 
 type Auth interface {
-	IsUserKey(key string) bool
-	IsAdminKey(key string) bool
+	Authenticate(ctx context.Context, key string) (*models.APIKey, error)
+	IsRootKey(key string) bool
 }
 
 type TeamAddInput struct {
@@ -29,28 +32,129 @@ type TeamAddOutput struct {
 
 type TeamAddOutputTeam struct {
 	TeamName string             `json:"team_name"`
+	Version  int                `json:"version"`
 	Members  []TeamOutputMember `json:"members"`
 }
 
 type TeamGetOutput struct {
-	TeamName string             `json:"team_name"`
-	Members  []TeamOutputMember `json:"members"`
+	TeamName       string             `json:"team_name"`
+	ParentTeamName string             `json:"parent_team_name,omitempty"`
+	FallbackDepth  *int               `json:"fallback_depth"`
+	ReviewersCount *int               `json:"reviewers_count"`
+	ReviewSLA      *string            `json:"review_sla"` // "24h0m0s", null when reviews are not timed
+	IsArchived     bool               `json:"is_archived"`
+	Version        int                `json:"version"`
+	Members        []TeamOutputMember `json:"members"`
+	Subteams       []TeamGetOutput    `json:"subteams,omitempty"`
 }
 
 type TeamOutputMember struct {
-	UserID   string `json:"user_id"`
-	UserName string `json:"username"`
-	IsActive bool   `json:"is_active"`
+	UserID    string `json:"user_id"`
+	UserName  string `json:"username"`
+	IsActive  bool   `json:"is_active"`
+	IsPrimary bool   `json:"is_primary"`
 }
 
 type TeamSetIsActiveTeamOutput struct {
 	UsersUpdated int64 `json:"users_updated"`
 }
 
+type TeamUpsertInput struct {
+	TeamName string
+	Members  []TeamInputMember
+	DryRun   bool
+}
+
+type TeamUpsertOutput struct {
+	TeamName        string                   `json:"team_name"`
+	DryRun          bool                     `json:"dry_run"`
+	Changed         bool                     `json:"changed"`
+	TeamCreated     bool                     `json:"team_created"`
+	Added           []TeamUpsertOutputChange `json:"added"`
+	Removed         []TeamUpsertOutputChange `json:"removed"`
+	UsernameChanged []TeamUpsertOutputChange `json:"username_changed"`
+	ActivityChanged []TeamUpsertOutputChange `json:"activity_changed"`
+}
+
+type TeamUpsertOutputChange struct {
+	UserID          string `json:"user_id"`
+	PrimaryTeamName string `json:"primary_team_name,omitempty"`
+	// PrevTeamName is kept for clients written before users could be in several teams
+	PrevTeamName string `json:"prev_team_name,omitempty"`
+	PrevUsername string `json:"prev_username,omitempty"`
+	PrevIsActive *bool  `json:"prev_is_active,omitempty"`
+	Username     string `json:"username"`
+	IsActive     bool   `json:"is_active"`
+}
+
+type TeamRenameOutput struct {
+	OldTeamName string `json:"old_team_name"`
+	TeamName    string `json:"team_name"`
+	Version     int    `json:"version"`
+}
+
+type TeamSetIsArchivedOutput struct {
+	TeamName   string     `json:"team_name"`
+	IsArchived bool       `json:"is_archived"`
+	ArchivedAt *time.Time `json:"archived_at"`
+	Version    int        `json:"version"`
+}
+
+type TeamUpdateOutput struct {
+	TeamName       string `json:"team_name"`
+	ParentTeamName string `json:"parent_team_name,omitempty"`
+	FallbackDepth  *int   `json:"fallback_depth"`
+	ReviewersCount *int   `json:"reviewers_count"`
+	Version        int    `json:"version"`
+}
+
+type TeamReviewSLAOutput struct {
+	TeamName  string  `json:"team_name"`
+	ReviewSLA *string `json:"review_sla"`
+	Version   int     `json:"version"`
+}
+
+type TeamDeleteOutput struct {
+	TeamName          string `json:"team_name"`
+	ReassignedTo      string `json:"reassigned_to,omitempty"`
+	MembersReassigned int64  `json:"members_reassigned"`
+}
+
+type TeamImportInput struct {
+	Format string // TeamImportFormat* constants
+	Data   []byte
+	DryRun bool
+}
+
+type TeamImportOutput struct {
+	DryRun  bool                   `json:"dry_run"`
+	Changed bool                   `json:"changed"`
+	Teams   []TeamImportOutputTeam `json:"teams"`
+}
+
+type TeamImportOutputTeam struct {
+	TeamName          string                   `json:"team_name"`
+	Changed           bool                     `json:"changed"`
+	TeamCreated       bool                     `json:"team_created"`
+	UpdatedAttributes []string                 `json:"updated_attributes"`
+	Added             []TeamUpsertOutputChange `json:"added"`
+	Removed           []TeamUpsertOutputChange `json:"removed"`
+	UsernameChanged   []TeamUpsertOutputChange `json:"username_changed"`
+	ActivityChanged   []TeamUpsertOutputChange `json:"activity_changed"`
+}
+
 type Team interface {
 	AddTeam(ctx context.Context, input TeamAddInput) (*TeamAddOutput, error)
-	GetTeamByName(ctx context.Context, name string) (*TeamGetOutput, error)
+	GetTeamByName(ctx context.Context, name string, includeSubteams bool) (*TeamGetOutput, error)
 	SetIsActiveTeam(ctx context.Context, teamName string, isActive bool) (*TeamSetIsActiveTeamOutput, error)
+	SetParentTeam(ctx context.Context, teamName, parentName string) (*TeamUpdateOutput, error)
+	SetTeamSettings(ctx context.Context, teamName string, reviewersCount, fallbackDepth models.NullInt) (*TeamUpdateOutput, error)
+	SetReviewSLA(ctx context.Context, teamName string, sla *time.Duration) (*TeamReviewSLAOutput, error)
+	UpsertTeam(ctx context.Context, input TeamUpsertInput) (*TeamUpsertOutput, error)
+	RenameTeam(ctx context.Context, oldName, newName string) (*TeamRenameOutput, error)
+	SetIsArchivedTeam(ctx context.Context, teamName string, isArchived bool) (*TeamSetIsArchivedOutput, error)
+	DeleteTeam(ctx context.Context, teamName, reassignTo string) (*TeamDeleteOutput, error)
+	ImportTeams(ctx context.Context, input TeamImportInput) (*TeamImportOutput, error)
 }
 
 type UserSetIsActiveOutput struct {
@@ -58,33 +162,103 @@ type UserSetIsActiveOutput struct {
 }
 
 type UserSetIsActiveOutputUser struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	TeamName string `json:"team_name"`
-	IsActive bool   `json:"is_active"`
+	UserID    string `json:"user_id"`
+	Username  string `json:"username"`
+	TeamName  string `json:"team_name"`
+	IsActive  bool   `json:"is_active"`
+	AwayUntil string `json:"away_until,omitempty"` // "2006-01-02", the day an away user is activated again
+	Version   int    `json:"version"`
+}
+
+type UserGetOutput struct {
+	User UserGetOutputUser `json:"user"`
+}
+
+type UserGetOutputUser struct {
+	UserSetIsActiveOutputUser
+	Email string `json:"email,omitempty"`
 }
 
 type UserGetReviewOutput struct {
 	UserID       string               `json:"user_id"`
+	Teams        []UserOutputTeam     `json:"teams"`
 	PullRequests []UserReviewOutputPR `json:"pull_requests"`
 }
 
+type UserOutputTeam struct {
+	TeamName  string `json:"team_name"`
+	IsPrimary bool   `json:"is_primary"`
+}
+
 type UserReviewOutputPR struct {
 	PullRequestID   string `json:"pull_request_id"`
 	PullRequestName string `json:"pull_request_name"`
 	AuthorID        string `json:"author_id"`
+	TeamName        string `json:"team_name"`
 	Status          string `json:"status"`
 }
 
+type UserSetPrimaryTeamOutput struct {
+	User UserSetIsActiveOutputUser `json:"user"`
+}
+
+type UserSetEmailOutput struct {
+	User UserSetEmailOutputUser `json:"user"`
+}
+
+type UserSetEmailOutputUser struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	Email    string `json:"email,omitempty"`
+	Version  int    `json:"version"`
+}
+
+type NotificationPreferencesInput struct {
+	UserID     string
+	Channels   []string    // empty means all channels
+	EventTypes []string    // empty means all notified events
+	Timezone   string      // UTC when empty
+	QuietHours *QuietHours // optional
+}
+
+// QuietHours is the daily time notifications are held during, "15:04" in the
+// timezone of the user. End before start spans midnight.
+type QuietHours struct {
+	Start string `json:"start" validate:"required"`
+	End   string `json:"end" validate:"required"`
+}
+
+type UserNotificationPreferencesOutput struct {
+	Preferences NotificationPreferencesOutput `json:"preferences"`
+}
+
+type NotificationPreferencesOutput struct {
+	UserID     string      `json:"user_id"`
+	Channels   []string    `json:"channels"`
+	EventTypes []string    `json:"event_types"`
+	Timezone   string      `json:"timezone"`
+	QuietHours *QuietHours `json:"quiet_hours,omitempty"`
+	UpdatedAt  *time.Time  `json:"updated_at,omitempty"` // empty until the user sets preferences
+}
+
 type User interface {
 	SetIsActive(ctx context.Context, userID string, isActive bool) (*UserSetIsActiveOutput, error)
+	SetAwayUntil(ctx context.Context, userID string, until time.Time) (*UserSetIsActiveOutput, error)
+	GetUser(ctx context.Context, userID string) (*UserGetOutput, error)
 	GetReview(ctx context.Context, userID string) (*UserGetReviewOutput, error)
+	SetPrimaryTeam(ctx context.Context, userID, teamName string) (*UserSetPrimaryTeamOutput, error)
+	SetEmail(ctx context.Context, userID, email string) (*UserSetEmailOutput, error)
+	GetNotificationPreferences(ctx context.Context, userID string) (*UserNotificationPreferencesOutput, error)
+	SetNotificationPreferences(ctx context.Context, input NotificationPreferencesInput) (*UserNotificationPreferencesOutput, error)
 }
 
 type PullRequestCreateInput struct {
 	PullRequestID   string
 	PullRequestName string
 	AuthorID        string
+	TeamName        string // optional, repository owner team or author's primary team by default
+	RepositoryName  string // optional
+	VCSProvider     string // optional, the version control system the pull request lives in
 }
 
 type PullRequestCreateOutput struct {
@@ -95,9 +269,12 @@ type PullRequestCreateOutputPR struct {
 	PullRequestID     string    `json:"pull_request_id"`
 	PullRequestName   string    `json:"pull_request_name"`
 	AuthorID          string    `json:"author_id"`
+	TeamName          string    `json:"team_name"`
+	RepositoryName    string    `json:"repository_name,omitempty"`
 	Status            string    `json:"status"`
 	AssignedReviewers []string  `json:"assigned_reviewers"`
 	CreatedAt         time.Time `json:"created_at"`
+	Version           int       `json:"version"`
 }
 
 type PullRequestMergeOutput struct {
@@ -111,6 +288,7 @@ type PullRequestMergeOutputPR struct {
 	Status            string    `json:"status"`
 	AssignedReviewers []string  `json:"assigned_reviewers"`
 	MergedAt          time.Time `json:"mergedAt"`
+	Version           int       `json:"version"`
 }
 
 type PullRequestReassignOutput struct {
@@ -124,19 +302,601 @@ type PullRequestReassignOutputPR struct {
 	AuthorID          string   `json:"author_id"`
 	Status            string   `json:"status"`
 	AssignedReviewers []string `json:"assigned_reviewers"`
+	Version           int      `json:"version"`
+}
+
+type PullRequestStatusOutput struct {
+	PullRequest PullRequestStatusOutputPR `json:"pr"`
+}
+
+type PullRequestStatusOutputPR struct {
+	PullRequestID     string   `json:"pull_request_id"`
+	PullRequestName   string   `json:"pull_request_name"`
+	AuthorID          string   `json:"author_id"`
+	Status            string   `json:"status"`
+	AssignedReviewers []string `json:"assigned_reviewers"`
+	Version           int      `json:"version"`
+}
+
+type PullRequestGetOutput struct {
+	PullRequest PullRequestGetOutputPR `json:"pr"`
+}
+
+type PullRequestGetOutputPR struct {
+	PullRequestID      string              `json:"pull_request_id"`
+	PullRequestName    string              `json:"pull_request_name"`
+	AuthorID           string              `json:"author_id"`
+	TeamName           string              `json:"team_name"`
+	RepositoryName     string              `json:"repository_name,omitempty"`
+	VCSProvider        string              `json:"vcs_provider,omitempty"`
+	Status             string              `json:"status"`
+	AssignedReviewers  []string            `json:"assigned_reviewers"`
+	NeedsMoreReviewers bool                `json:"needs_more_reviewers"`
+	CreatedAt          time.Time           `json:"created_at"`
+	MergedAt           *time.Time          `json:"merged_at,omitempty"`
+	Version            int                 `json:"version"`
+	ReviewerSync       *ReviewerSyncOutput `json:"reviewer_sync,omitempty"` // only for pull requests synced with GitHub
 }
 
 type PullRequest interface {
 	CreatePR(ctx context.Context, input PullRequestCreateInput) (*PullRequestCreateOutput, error)
 	MergePR(ctx context.Context, prID string) (*PullRequestMergeOutput, error)
 	ReassignReviewer(ctx context.Context, prID, oldUserID string) (*PullRequestReassignOutput, error)
+	ClosePR(ctx context.Context, prID string) (*PullRequestStatusOutput, error)
+	ReopenPR(ctx context.Context, prID string) (*PullRequestStatusOutput, error)
+	GetPR(ctx context.Context, prID string) (*PullRequestGetOutput, error)
+}
+
+type ReviewerSyncOutput struct {
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"` // only for pending syncs
+	SyncedAt      *time.Time `json:"synced_at,omitempty"`
+}
+
+type ReviewerSyncResyncOutput struct {
+	PullRequestID string             `json:"pull_request_id"`
+	ReviewerSync  ReviewerSyncOutput `json:"reviewer_sync"`
+}
+
+type ReviewerSync interface {
+	Resync(ctx context.Context, prID string) (*ReviewerSyncResyncOutput, error)
+}
+
+type RepositoryInput struct {
+	RepositoryName string
+	TeamName       string
+	ReviewersCount *int
+	FallbackDepth  *int
+}
+
+type RepositoryOutput struct {
+	RepositoryName string    `json:"repository_name"`
+	TeamName       string    `json:"team_name,omitempty"`
+	ReviewersCount *int      `json:"reviewers_count"`
+	FallbackDepth  *int      `json:"fallback_depth"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type Repository interface {
+	AddRepository(ctx context.Context, input RepositoryInput) (*RepositoryOutput, error)
+	GetRepository(ctx context.Context, repositoryName string) (*RepositoryOutput, error)
+	SetRepositoryOwner(ctx context.Context, repositoryName, teamName string) (*RepositoryOutput, error)
+	SetRepositorySettings(ctx context.Context, repositoryName string, reviewersCount, fallbackDepth models.NullInt) (*RepositoryOutput, error)
+}
+
+type StatsInput struct {
+	UserID          string
+	TeamName        string
+	IncludeSubteams bool
+	From            *time.Time
+	To              *time.Time
+}
+
+type StatsUserOutput struct {
+	From  *time.Time        `json:"from,omitempty"`
+	To    *time.Time        `json:"to,omitempty"`
+	Users []StatsOutputUser `json:"users"`
+}
+
+type StatsOutputUser struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	StatsOutputCounters
+}
+
+type StatsTeamOutput struct {
+	From  *time.Time        `json:"from,omitempty"`
+	To    *time.Time        `json:"to,omitempty"`
+	Teams []StatsOutputTeam `json:"teams"`
+}
+
+type StatsOutputTeam struct {
+	TeamName string `json:"team_name"`
+	StatsOutputCounters
+}
+
+type StatsOutputCounters struct {
+	Assigned       int64 `json:"assigned"`
+	ReassignedAway int64 `json:"reassigned_away"`
+	Completed      int64 `json:"completed"`
+	OpenLoad       int64 `json:"open_load"`
+}
+
+type StatsUserFlowOutput struct {
+	From  *time.Time            `json:"from,omitempty"`
+	To    *time.Time            `json:"to,omitempty"`
+	Users []StatsOutputUserFlow `json:"users"`
+}
+
+type StatsTeamFlowOutput struct {
+	From  *time.Time            `json:"from,omitempty"`
+	To    *time.Time            `json:"to,omitempty"`
+	Teams []StatsOutputTeamFlow `json:"teams"`
+}
+
+type StatsOutputUserFlow struct {
+	UserID string `json:"user_id"`
+	StatsOutputFlow
+}
+
+type StatsOutputTeamFlow struct {
+	TeamName string `json:"team_name"`
+	StatsOutputFlow
+}
+
+type StatsOutputFlow struct {
+	Merged              int64                  `json:"merged"`
+	TimeToMerge         StatsOutputPercentiles `json:"time_to_merge"`
+	Reassigned          int64                  `json:"reassigned"`
+	TimeToFirstReassign StatsOutputPercentiles `json:"time_to_first_reassign"`
+}
+
+// StatsOutputPercentiles are durations in seconds, null when there is no data.
+type StatsOutputPercentiles struct {
+	P50 *float64 `json:"p50"`
+	P90 *float64 `json:"p90"`
+	P99 *float64 `json:"p99"`
+}
+
+type Stats interface {
+	GetUserStats(ctx context.Context, input StatsInput) (*StatsUserOutput, error)
+	GetTeamStats(ctx context.Context, input StatsInput) (*StatsTeamOutput, error)
+	GetUserFlowStats(ctx context.Context, input StatsInput) (*StatsUserFlowOutput, error)
+	GetTeamFlowStats(ctx context.Context, input StatsInput) (*StatsTeamFlowOutput, error)
+}
+
+type ReportInput struct {
+	Report  string
+	Columns []string // all columns of the report when empty
+	StatsInput
+}
+
+// ReportWriter receives the header of the report once and then its rows, with
+// values in the order of the header.
+type ReportWriter interface {
+	WriteHeader(columns []string) error
+	WriteRow(values []any) error
+}
+
+type Report interface {
+	Export(ctx context.Context, input ReportInput, w ReportWriter) error
+}
+
+type AuditInput struct {
+	Actor      string
+	Action     string
+	EntityType string
+	EntityID   string
+	RequestID  string
+	From       *time.Time
+	To         *time.Time
+	Cursor     int64
+	Limit      uint64
+}
+
+type AuditOutput struct {
+	Entries    []AuditOutputEntry `json:"entries"`
+	NextCursor int64              `json:"next_cursor,omitempty"` // pass as cursor to get the next page
+}
+
+type AuditOutputEntry struct {
+	ID         int64           `json:"id"`
+	Actor      string          `json:"actor"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   string          `json:"entity_id"`
+	Before     json.RawMessage `json:"before" swaggertype:"object"`
+	After      json.RawMessage `json:"after" swaggertype:"object"`
+	RequestID  string          `json:"request_id,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+type Audit interface {
+	GetEntries(ctx context.Context, input AuditInput) (*AuditOutput, error)
+}
+
+type WebhookSubscriptionInput struct {
+	URL        string
+	EventTypes []string // all events when empty
+	Secret     string   // generated when empty
+}
+
+type WebhookSubscriptionsOutput struct {
+	Subscriptions []WebhookOutputSubscription `json:"subscriptions"`
+}
+
+type WebhookOutputSubscription struct {
+	SubscriptionID int       `json:"subscription_id"`
+	URL            string    `json:"url"`
+	EventTypes     []string  `json:"event_types"`
+	IsActive       bool      `json:"is_active"`
+	CreatedAt      time.Time `json:"created_at"`
+	Secret         string    `json:"secret,omitempty"` // returned only on creation
+}
+
+type WebhookDeliveriesInput struct {
+	SubscriptionID int
+	Status         string
+	Cursor         int64
+	Limit          uint64
+}
+
+type WebhookDeliveriesOutput struct {
+	Deliveries []WebhookOutputDelivery `json:"deliveries"`
+	NextCursor int64                   `json:"next_cursor,omitempty"` // pass as cursor to get the next page
+}
+
+type WebhookOutputDelivery struct {
+	DeliveryID     int64      `json:"delivery_id"`
+	EventID        int64      `json:"event_id"`
+	EventType      string     `json:"event_type,omitempty"`
+	SubscriptionID int        `json:"subscription_id"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"` // only for pending deliveries
+	LastStatusCode *int       `json:"last_status_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
+
+type WebhookRetryOutput struct {
+	Delivery WebhookOutputDelivery `json:"delivery"`
+	Requeued bool                  `json:"requeued"` // false when the delivery was not dead
+}
+
+type Webhook interface {
+	AddSubscription(ctx context.Context, input WebhookSubscriptionInput) (*WebhookOutputSubscription, error)
+	GetSubscriptions(ctx context.Context) (*WebhookSubscriptionsOutput, error)
+	SetSubscriptionIsActive(ctx context.Context, subscriptionID int, isActive bool) (*WebhookOutputSubscription, error)
+	DeleteSubscription(ctx context.Context, subscriptionID int) error
+	GetDeliveries(ctx context.Context, input WebhookDeliveriesInput) (*WebhookDeliveriesOutput, error)
+	RetryDelivery(ctx context.Context, deliveryID int64) (*WebhookRetryOutput, error)
+}
+
+type ChatChannelInput struct {
+	TeamName   string
+	URL        string
+	EventTypes []string          // all notified events when empty
+	Templates  map[string]string // event type to text/template of the message
+}
+
+type ChatChannelsOutput struct {
+	Channels []ChatChannelOutput `json:"channels"`
+}
+
+type ChatChannelOutput struct {
+	ChannelID  int               `json:"channel_id"`
+	TeamName   string            `json:"team_name"`
+	URL        string            `json:"url"` // path and query are masked
+	EventTypes []string          `json:"event_types"`
+	Templates  map[string]string `json:"templates"`
+	IsActive   bool              `json:"is_active"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+type NotificationsInput struct {
+	Channel string
+	Status  string
+	Cursor  int64
+	Limit   uint64
+}
+
+type NotificationsOutput struct {
+	Notifications []NotificationOutput `json:"notifications"`
+	NextCursor    int64                `json:"next_cursor,omitempty"` // pass as cursor to get the next page
+}
+
+type NotificationOutput struct {
+	NotificationID int64      `json:"notification_id"`
+	EventID        *int64     `json:"event_id,omitempty"` // empty for digests
+	EventType      string     `json:"event_type,omitempty"`
+	Channel        string     `json:"channel"`
+	ChatChannelID  *int       `json:"chat_channel_id,omitempty"`
+	RecipientID    string     `json:"recipient_id,omitempty"`
+	DigestDate     *time.Time `json:"digest_date,omitempty"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"` // only for pending notifications
+	LastError      string     `json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	SentAt         *time.Time `json:"sent_at,omitempty"`
+}
+
+type NotificationRetryOutput struct {
+	Notification NotificationOutput `json:"notification"`
+	Requeued     bool               `json:"requeued"` // false when the notification was not dead
+}
+
+type Notification interface {
+	AddChatChannel(ctx context.Context, input ChatChannelInput) (*ChatChannelOutput, error)
+	GetChatChannels(ctx context.Context, teamName string) (*ChatChannelsOutput, error)
+	SetChatChannelIsActive(ctx context.Context, channelID int, isActive bool) (*ChatChannelOutput, error)
+	DeleteChatChannel(ctx context.Context, channelID int) error
+	GetNotifications(ctx context.Context, input NotificationsInput) (*NotificationsOutput, error)
+	RetryNotification(ctx context.Context, notificationID int64) (*NotificationRetryOutput, error)
+}
+
+type VCSWebhookInput struct {
+	TenantName string // default tenant when empty
+	DeliveryID string
+	Event      string
+	Signature  string // signature of the body or the secret token itself
+	Body       []byte
+}
+
+type VCSWebhookOutput struct {
+	DeliveryID    string `json:"delivery_id"`
+	Event         string `json:"event"`
+	Action        string `json:"action,omitempty"`
+	PullRequestID string `json:"pull_request_id,omitempty"`
+	Result        string `json:"result"`    // empty for a duplicate still being processed
+	Duplicate     bool   `json:"duplicate"` // the delivery was already received
+}
+
+type VCSWebhookSecretOutput struct {
+	Provider string `json:"provider"`
+	Secret   string `json:"secret"`
+}
+
+type VCSAPITokenOutput struct {
+	Provider       string `json:"provider"`
+	ReviewerSync   bool   `json:"reviewer_sync"`  // reviewers are requested in the provider
+	Reconciliation bool   `json:"reconciliation"` // open pull requests are checked against the provider
+}
+
+type VCSIdentityInput struct {
+	Provider  string
+	Login     string
+	AccountID string
+	UserID    string
+}
+
+type VCSIdentitiesOutput struct {
+	Identities []VCSOutputIdentity `json:"identities"`
+}
+
+type VCSOutputIdentity struct {
+	Provider  string    `json:"provider"`
+	Login     string    `json:"login"`
+	AccountID string    `json:"account_id,omitempty"`
+	UserID    string    `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type VCS interface {
+	HandleGitHubWebhook(ctx context.Context, input VCSWebhookInput) (*VCSWebhookOutput, error)
+	HandleGitLabWebhook(ctx context.Context, input VCSWebhookInput) (*VCSWebhookOutput, error)
+	SetWebhookSecret(ctx context.Context, provider, secret string) (*VCSWebhookSecretOutput, error)
+	SetAPIToken(ctx context.Context, provider, token string) (*VCSAPITokenOutput, error)
+	SetIdentity(ctx context.Context, input VCSIdentityInput) (*VCSOutputIdentity, error)
+	DeleteIdentity(ctx context.Context, provider, login string) error
+	GetIdentities(ctx context.Context, provider string) (*VCSIdentitiesOutput, error)
+}
+
+type ReconciliationOutput struct {
+	Checked     int                              `json:"checked"`
+	Corrections []ReconciliationOutputCorrection `json:"corrections"`
+	Failures    []ReconciliationOutputFailure    `json:"failures"` // pull requests the provider was not asked about
+}
+
+type ReconciliationOutputCorrection struct {
+	CorrectionID  int64      `json:"correction_id"`
+	TenantID      int        `json:"-"`
+	PullRequestID string     `json:"pull_request_id"`
+	Provider      string     `json:"provider"`
+	Action        string     `json:"action"`
+	ReviewerID    string     `json:"reviewer_id,omitempty"` // reassigned away
+	ReplacedBy    string     `json:"replaced_by,omitempty"`
+	Error         string     `json:"error,omitempty"` // the correction failed
+	Attempts      int        `json:"attempts"`
+	LastAttemptAt *time.Time `json:"last_attempt_at,omitempty"` // of a correction failed more than once
+	ResolvedAt    *time.Time `json:"resolved_at,omitempty"`     // the failed correction was applied later
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type ReconciliationOutputFailure struct {
+	TenantID      int    `json:"-"`
+	PullRequestID string `json:"pull_request_id"`
+	Provider      string `json:"provider"`
+	Error         string `json:"error"`
+}
+
+type ReconciliationCorrectionsInput struct {
+	PullRequestID string
+	Action        string
+	From          *time.Time
+	To            *time.Time
+	Cursor        int64
+	Limit         uint64
+}
+
+type ReconciliationCorrectionsOutput struct {
+	Corrections []ReconciliationOutputCorrection `json:"corrections"`
+	NextCursor  int64                            `json:"next_cursor,omitempty"` // pass as cursor to get the next page
+}
+
+type Reconciliation interface {
+	Reconcile(ctx context.Context, allTenants bool) (*ReconciliationOutput, error)
+	GetCorrections(ctx context.Context, input ReconciliationCorrectionsInput) (*ReconciliationCorrectionsOutput, error)
+}
+
+type TenantAddOutput struct {
+	TenantName string               `json:"tenant_name"`
+	APIKeys    []TenantOutputAPIKey `json:"api_keys"`
+}
+
+type TenantOutputAPIKey struct {
+	KeyID  int    `json:"key_id"`
+	Role   string `json:"role"`
+	APIKey string `json:"api_key"`
+}
+
+type TenantAddAPIKeyOutput struct {
+	TenantName string             `json:"tenant_name"`
+	APIKey     TenantOutputAPIKey `json:"api_key"`
+}
+
+type TenantRevokeAPIKeyOutput struct {
+	KeyID     int       `json:"key_id"`
+	Role      string    `json:"role"`
+	RevokedAt time.Time `json:"revoked_at"`
+}
+
+type Tenant interface {
+	AddTenant(ctx context.Context, tenantName string) (*TenantAddOutput, error)
+	AddAPIKey(ctx context.Context, tenantName, role string) (*TenantAddAPIKeyOutput, error)
+	RevokeAPIKey(ctx context.Context, keyID int) (*TenantRevokeAPIKeyOutput, error)
+}
+
+type TelegramLinkCodeOutput struct {
+	UserID    string    `json:"user_id"`
+	Code      string    `json:"code"`
+	Command   string    `json:"command"`       // to send to the bot
+	URL       string    `json:"url,omitempty"` // opens the bot with the code, when its username is configured
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type Telegram interface {
+	IssueLinkCode(ctx context.Context, userID string) (*TelegramLinkCodeOutput, error)
+}
+
+type ChatOpsSigningSecretOutput struct {
+	Secret string `json:"secret"`
+}
+
+type ChatOpsSlackInput struct {
+	TenantName string // default tenant when empty
+	Timestamp  string // X-Slack-Request-Timestamp
+	Signature  string // X-Slack-Signature
+	Body       []byte // form encoded slash command
+}
+
+type ChatOpsCommandOutput struct {
+	ResponseType string `json:"response_type"`
+	Text         string `json:"text"`
+}
+
+type ChatOpsIdentityInput struct {
+	SlackUserID string
+	UserID      string
+	IsAdmin     bool
+}
+
+type ChatOpsIdentitiesOutput struct {
+	Identities []ChatOpsOutputIdentity `json:"identities"`
+}
+
+type ChatOpsOutputIdentity struct {
+	SlackUserID string    `json:"slack_user_id"`
+	UserID      string    `json:"user_id"`
+	IsAdmin     bool      `json:"is_admin"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type ChatOps interface {
+	SetSigningSecret(ctx context.Context, secret string) (*ChatOpsSigningSecretOutput, error)
+	HandleSlackCommand(ctx context.Context, input ChatOpsSlackInput) (*ChatOpsCommandOutput, error)
+	SetIdentity(ctx context.Context, input ChatOpsIdentityInput) (*ChatOpsOutputIdentity, error)
+	DeleteIdentity(ctx context.Context, slackUserID string) error
+	GetIdentities(ctx context.Context) (*ChatOpsIdentitiesOutput, error)
+}
+
+type IdempotencyInput struct {
+	APIKey string // the key is scoped to the caller
+	Key    string
+	Method string
+	Path   string // with the query
+	Body   []byte
+}
+
+type IdempotentResponse struct {
+	StatusCode int
+	Header     map[string][]string // replayed headers, such as Content-Type and ETag
+	Body       []byte
+}
+
+type Idempotency interface {
+	Begin(ctx context.Context, input IdempotencyInput) (recorded *IdempotentResponse, leaseToken string, err error)
+	Complete(ctx context.Context, input IdempotencyInput, leaseToken string, response IdempotentResponse) error
+	Release(ctx context.Context, input IdempotencyInput, leaseToken string) error
+}
+
+// SnapshotArchive is a snapshot of the tenant with what is needed to check
+// it on restore. Data is models.Snapshot in the format of FormatVersion.
+type SnapshotArchive struct {
+	FormatVersion int             `json:"format_version"`
+	ExportedAt    time.Time       `json:"exported_at"`
+	Checksum      string          `json:"checksum"` // "sha256:<hex>" of the compact data
+	Counts        SnapshotCounts  `json:"counts"`
+	Data          json.RawMessage `json:"data" swaggertype:"object"`
+}
+
+type SnapshotCounts struct {
+	Teams                   int `json:"teams"`
+	Users                   int `json:"users"`
+	TeamMembers             int `json:"team_members"`
+	Repositories            int `json:"repositories"`
+	PullRequests            int `json:"pull_requests"`
+	Reviewers               int `json:"reviewers"`
+	NotificationPreferences int `json:"notification_preferences"`
+	VCSIdentities           int `json:"vcs_identities"`
+	SlackIdentities         int `json:"slack_identities"`
+	ArchivedPullRequests    int `json:"archived_pull_requests"`
+	ArchivedReviewers       int `json:"archived_reviewers"`
+}
+
+type SnapshotRestoreOutput struct {
+	FormatVersion int            `json:"format_version"` // of the restored archive
+	Counts        SnapshotCounts `json:"counts"`
+}
+
+type Snapshot interface {
+	Export(ctx context.Context) (*SnapshotArchive, error)
+	Restore(ctx context.Context, archive []byte) (*SnapshotRestoreOutput, error)
 }
 
 type Services struct {
-	Auth        Auth
-	Team        Team
-	User        User
-	PullRequest PullRequest
+	Auth           Auth
+	Tenant         Tenant
+	Team           Team
+	User           User
+	PullRequest    PullRequest
+	Repository     Repository
+	Stats          Stats
+	Report         Report
+	Audit          Audit
+	Webhook        Webhook
+	VCS            VCS
+	ReviewerSync   ReviewerSync
+	Reconciliation Reconciliation
+	Notification   Notification
+	Telegram       Telegram
+	ChatOps        ChatOps
+	Idempotency    Idempotency
+	Snapshot       Snapshot
 }
 
 type ServicesDependencies struct {
@@ -144,13 +904,41 @@ type ServicesDependencies struct {
 
 	AdminAPIKey string
 	UserAPIKey  string
+	RootAPIKey  string
+
+	VCSClients             map[string]VCSClient // by provider, pull requests of other providers are not reconciled
+	VCSTokenBox            *secretbox.Box       // encrypts api tokens, nil disables them
+	ReconciliationPageSize uint64
+
+	TelegramLinkCodeTTL time.Duration
+	TelegramBotUsername string // optional
+
+	IdempotencyTTL         time.Duration
+	IdempotencyLockTimeout time.Duration
 }
 
 func NewServices(deps ServicesDependencies) *Services {
+	pullRequest := NewPullRequestService(deps.Repos.PullRequest)
+	user := NewUserService(deps.Repos.User)
+
 	return &Services{
-		Auth:        NewAuthService(deps.UserAPIKey, deps.AdminAPIKey),
-		User:        NewUserService(deps.Repos.User),
-		Team:        NewTeamService(deps.Repos.Team),
-		PullRequest: NewPullRequestService(deps.Repos.PullRequest),
+		Auth:           NewAuthService(deps.Repos.Tenant, deps.UserAPIKey, deps.AdminAPIKey, deps.RootAPIKey),
+		Tenant:         NewTenantService(deps.Repos.Tenant),
+		User:           user,
+		Team:           NewTeamService(deps.Repos.Team),
+		PullRequest:    pullRequest,
+		Repository:     NewRepositoryService(deps.Repos.Repository),
+		Stats:          NewStatsService(deps.Repos.Stats),
+		Report:         NewReportService(deps.Repos.Stats),
+		Audit:          NewAuditService(deps.Repos.Audit),
+		Webhook:        NewWebhookService(deps.Repos.Webhook),
+		VCS:            NewVCSService(deps.Repos.VCS, deps.Repos.Tenant, deps.Repos.Repository, pullRequest, deps.VCSTokenBox),
+		ReviewerSync:   NewReviewerSyncService(deps.Repos.ReviewerSync),
+		Reconciliation: NewReconciliationService(deps.Repos.Reconciliation, pullRequest, deps.VCSClients, deps.VCSTokenBox, deps.ReconciliationPageSize),
+		Notification:   NewNotificationService(deps.Repos.Notification),
+		Telegram:       NewTelegramService(deps.Repos.Telegram, deps.TelegramLinkCodeTTL, deps.TelegramBotUsername),
+		ChatOps:        NewChatOpsService(deps.Repos.ChatOps, deps.Repos.Tenant, user, pullRequest),
+		Idempotency:    NewIdempotencyService(deps.Repos.Idempotency, deps.IdempotencyTTL, deps.IdempotencyLockTimeout),
+		Snapshot:       NewSnapshotService(deps.Repos.Snapshot),
 	}
 }
@@ -0,0 +1,516 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo"
+)
+
+var (
+	ErrUnsupportedSnapshot = errors.New("unsupported snapshot format version")
+	ErrSnapshotChecksum    = errors.New("snapshot checksum mismatch")
+	ErrInvalidSnapshot     = errors.New("invalid snapshot")
+)
+
+const snapshotChecksumPrefix = "sha256:"
+
+// maxSnapshotProblems bounds the problems listed in the error of a restore.
+const maxSnapshotProblems = 20
+
+// snapshotUpgrades upgrade the data of a snapshot from the format version of
+// the key to the next one. A migration changing what is exported bumps
+// models.SnapshotFormatVersion and adds the upgrade from the previous
+// version here, so snapshots taken before it are still restored.
+var snapshotUpgrades = map[int]func(data json.RawMessage) (json.RawMessage, error){
+	// version 2 added archived pull requests, there were none before
+	1: func(data json.RawMessage) (json.RawMessage, error) { return data, nil },
+	// version 3 replaced email modes of users with notification preferences
+	2: upgradeEmailModes,
+	// version 4 added slack identities, there were none before
+	3: func(data json.RawMessage) (json.RawMessage, error) { return data, nil },
+	// version 5 left out chat channels, their webhook urls are secrets
+	4: upgradeDropChatChannels,
+}
+
+type SnapshotService struct {
+	snapshotRepo repo.Snapshot
+}
+
+func NewSnapshotService(snapshotRepo repo.Snapshot) *SnapshotService {
+	return &SnapshotService{snapshotRepo: snapshotRepo}
+}
+
+func (s *SnapshotService) Export(ctx context.Context) (*SnapshotArchive, error) {
+	snapshot, err := s.snapshotRepo.ExportSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	return &SnapshotArchive{
+		FormatVersion: models.SnapshotFormatVersion,
+		ExportedAt:    time.Now().UTC(),
+		Checksum:      snapshotChecksum(data),
+		Counts:        snapshotCounts(*snapshot),
+		Data:          data,
+	}, nil
+}
+
+// Restore loads an archive made by Export into the tenant, which must have
+// no data yet. Archives of older format versions are upgraded first. The
+// whole snapshot is checked before anything is written.
+func (s *SnapshotService) Restore(ctx context.Context, archiveData []byte) (*SnapshotRestoreOutput, error) {
+	var archive SnapshotArchive
+	if err := json.Unmarshal(archiveData, &archive); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSnapshot, err)
+	}
+
+	if archive.FormatVersion < 1 || archive.FormatVersion > models.SnapshotFormatVersion {
+		return nil, fmt.Errorf("%w: %d, supported up to %d", ErrUnsupportedSnapshot, archive.FormatVersion, models.SnapshotFormatVersion)
+	}
+
+	if len(archive.Data) == 0 {
+		return nil, fmt.Errorf("%w: no data", ErrInvalidSnapshot)
+	}
+
+	// the archive may be reformatted, the checksum is of the compact data
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, archive.Data); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSnapshot, err)
+	}
+	if snapshotChecksum(compact.Bytes()) != archive.Checksum {
+		return nil, ErrSnapshotChecksum
+	}
+
+	snapshot, err := decodeSnapshot(archive.FormatVersion, compact.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	counts := snapshotCounts(snapshot)
+	if archive.FormatVersion == models.SnapshotFormatVersion && counts != archive.Counts {
+		return nil, fmt.Errorf("%w: counts do not match the data", ErrInvalidSnapshot)
+	}
+
+	if problems := checkSnapshot(&snapshot); len(problems) > 0 {
+		if len(problems) > maxSnapshotProblems {
+			problems = append(problems[:maxSnapshotProblems], fmt.Sprintf("and %d more", len(problems)-maxSnapshotProblems))
+		}
+
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSnapshot, strings.Join(problems, "; "))
+	}
+
+	if err := s.snapshotRepo.RestoreSnapshot(ctx, snapshot); err != nil {
+		return nil, err
+	}
+
+	return &SnapshotRestoreOutput{
+		FormatVersion: archive.FormatVersion,
+		Counts:        counts,
+	}, nil
+}
+
+// decodeSnapshot upgrades the data of the format version to the current one
+// and decodes it, fields unknown to the current version are rejected.
+func decodeSnapshot(formatVersion int, data json.RawMessage) (models.Snapshot, error) {
+	for version := formatVersion; version < models.SnapshotFormatVersion; version++ {
+		upgrade, ok := snapshotUpgrades[version]
+		if !ok {
+			return models.Snapshot{}, fmt.Errorf("%w: no upgrade from version %d", ErrUnsupportedSnapshot, version)
+		}
+
+		upgraded, err := upgrade(data)
+		if err != nil {
+			return models.Snapshot{}, fmt.Errorf("%w: failed to upgrade from version %d: %s", ErrInvalidSnapshot, version, err)
+		}
+		data = upgraded
+	}
+
+	var snapshot models.Snapshot
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&snapshot); err != nil {
+		return models.Snapshot{}, fmt.Errorf("%w: %s", ErrInvalidSnapshot, err)
+	}
+	return snapshot, nil
+}
+
+// upgradeEmailModes removes email_mode of users the way the migration does:
+// the address of users without emails is dropped and users of the digest
+// choose review_digest in their notification preferences.
+func upgradeEmailModes(data json.RawMessage) (json.RawMessage, error) {
+	var snapshot map[string]json.RawMessage
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, err
+	}
+
+	var users, preferences []map[string]any
+	if err := unmarshalSnapshotField(snapshot, "users", &users); err != nil {
+		return nil, err
+	}
+	if err := unmarshalSnapshotField(snapshot, "notification_preferences", &preferences); err != nil {
+		return nil, err
+	}
+
+	preferencesOf := make(map[any]map[string]any, len(preferences))
+	for _, p := range preferences {
+		preferencesOf[p["user_id"]] = p
+	}
+
+	for _, u := range users {
+		mode := u["email_mode"]
+		delete(u, "email_mode")
+
+		switch mode {
+		case "off":
+			delete(u, "email")
+		case "digest":
+			p, ok := preferencesOf[u["user_id"]]
+			if !ok {
+				p = map[string]any{"user_id": u["user_id"], "channels": []any{}, "event_types": []any{}, "timezone": "UTC"}
+				preferences = append(preferences, p)
+				preferencesOf[u["user_id"]] = p
+			}
+
+			// empty event types meant all events, the digest is chosen explicitly
+			eventTypes, _ := p["event_types"].([]any)
+			if len(eventTypes) == 0 {
+				eventTypes = []any{
+					models.EventPullRequestCreated,
+					models.EventReviewerReassigned,
+					models.EventReviewSLABreached,
+					models.EventPullRequestMerged,
+				}
+			}
+			if !slices.Contains(eventTypes, any(models.NotificationReviewDigest)) {
+				eventTypes = append(eventTypes, models.NotificationReviewDigest)
+			}
+			p["event_types"] = eventTypes
+		}
+	}
+
+	for field, value := range map[string]any{"users": users, "notification_preferences": preferences} {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		snapshot[field] = encoded
+	}
+
+	return json.Marshal(snapshot)
+}
+
+// upgradeDropChatChannels removes the chat channels, they are added again
+// after the restore.
+func upgradeDropChatChannels(data json.RawMessage) (json.RawMessage, error) {
+	var snapshot map[string]json.RawMessage
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, err
+	}
+
+	delete(snapshot, "chat_channels")
+
+	return json.Marshal(snapshot)
+}
+
+// unmarshalSnapshotField decodes the field of the snapshot, a missing or null
+// field leaves v unchanged.
+func unmarshalSnapshotField(snapshot map[string]json.RawMessage, field string, v any) error {
+	raw, ok := snapshot[field]
+	if !ok {
+		return nil
+	}
+
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("%s: %w", field, err)
+	}
+	return nil
+}
+
+func snapshotChecksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return snapshotChecksumPrefix + hex.EncodeToString(sum[:])
+}
+
+func snapshotCounts(snapshot models.Snapshot) SnapshotCounts {
+	return SnapshotCounts{
+		Teams:                   len(snapshot.Teams),
+		Users:                   len(snapshot.Users),
+		TeamMembers:             len(snapshot.TeamMembers),
+		Repositories:            len(snapshot.Repositories),
+		PullRequests:            len(snapshot.PullRequests),
+		Reviewers:               len(snapshot.Reviewers),
+		NotificationPreferences: len(snapshot.NotificationPreferences),
+		VCSIdentities:           len(snapshot.VCSIdentities),
+		SlackIdentities:         len(snapshot.SlackIdentities),
+		ArchivedPullRequests:    len(snapshot.ArchivedPullRequests),
+		ArchivedReviewers:       len(snapshot.ArchivedReviewers),
+	}
+}
+
+// checkSnapshot fills in the defaults of the snapshot and lists what would
+// break the constraints of the database or leave dangling references.
+func checkSnapshot(snapshot *models.Snapshot) []string {
+	var problems []string
+	addProblem := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	teams := make(map[string]string, len(snapshot.Teams)) // to the parent team
+	for i, t := range snapshot.Teams {
+		switch {
+		case t.TeamName == "":
+			addProblem("teams[%d]: empty team_name", i)
+			continue
+		case t.ReviewersCount != nil && *t.ReviewersCount <= 0:
+			addProblem("team %q: reviewers_count must be positive", t.TeamName)
+		case t.FallbackDepth != nil && *t.FallbackDepth < 0:
+			addProblem("team %q: fallback_depth must not be negative", t.TeamName)
+		case t.ReviewSLASeconds != nil && *t.ReviewSLASeconds <= 0:
+			addProblem("team %q: review_sla_seconds must be positive", t.TeamName)
+		}
+
+		if _, ok := teams[t.TeamName]; ok {
+			addProblem("team %q: duplicate", t.TeamName)
+		}
+		teams[t.TeamName] = t.ParentTeamName
+	}
+
+	for _, t := range snapshot.Teams {
+		name, parent := t.TeamName, t.ParentTeamName
+		if name == "" || parent == "" {
+			continue
+		}
+		if _, ok := teams[parent]; !ok {
+			addProblem("team %q: unknown parent team %q", name, parent)
+			continue
+		}
+
+		// a chain longer than the number of teams has a cycle
+		for steps := 0; parent != ""; steps++ {
+			if parent == name || steps > len(teams) {
+				addProblem("team %q: parent teams form a cycle", name)
+				break
+			}
+			parent = teams[parent]
+		}
+	}
+
+	users := make(map[string]bool, len(snapshot.Users))
+	for i := range snapshot.Users {
+		u := &snapshot.Users[i]
+		if u.UserID == "" || u.Username == "" {
+			addProblem("users[%d]: empty user_id or username", i)
+			continue
+		}
+		if users[u.UserID] {
+			addProblem("user %q: duplicate", u.UserID)
+		}
+		users[u.UserID] = true
+	}
+
+	type membership struct{ userID, teamName string }
+	members := make(map[membership]bool, len(snapshot.TeamMembers))
+	primaryTeams := make(map[string]bool)
+	for _, m := range snapshot.TeamMembers {
+		if !users[m.UserID] {
+			addProblem("member %q of team %q: unknown user", m.UserID, m.TeamName)
+		}
+		if _, ok := teams[m.TeamName]; !ok {
+			addProblem("member %q of team %q: unknown team", m.UserID, m.TeamName)
+		}
+
+		if members[membership{m.UserID, m.TeamName}] {
+			addProblem("member %q of team %q: duplicate", m.UserID, m.TeamName)
+		}
+		members[membership{m.UserID, m.TeamName}] = true
+
+		if m.IsPrimary {
+			if primaryTeams[m.UserID] {
+				addProblem("user %q: more than one primary team", m.UserID)
+			}
+			primaryTeams[m.UserID] = true
+		}
+	}
+
+	repositories := make(map[string]bool, len(snapshot.Repositories))
+	for i, r := range snapshot.Repositories {
+		if r.RepositoryName == "" {
+			addProblem("repositories[%d]: empty repository_name", i)
+			continue
+		}
+		if repositories[r.RepositoryName] {
+			addProblem("repository %q: duplicate", r.RepositoryName)
+		}
+		repositories[r.RepositoryName] = true
+
+		if _, ok := teams[r.TeamName]; r.TeamName != "" && !ok {
+			addProblem("repository %q: unknown team %q", r.RepositoryName, r.TeamName)
+		}
+		if (r.ReviewersCount != nil && *r.ReviewersCount <= 0) || (r.FallbackDepth != nil && *r.FallbackDepth < 0) {
+			addProblem("repository %q: invalid settings", r.RepositoryName)
+		}
+	}
+
+	pullRequests := make(map[string]bool, len(snapshot.PullRequests)+len(snapshot.ArchivedPullRequests)) // to being archived
+	checkPullRequest := func(section string, i int, pr models.SnapshotPullRequest, archived bool) {
+		if pr.PullRequestID == "" {
+			addProblem("%s[%d]: empty pull_request_id", section, i)
+			return
+		}
+		if _, ok := pullRequests[pr.PullRequestID]; ok {
+			addProblem("pull request %q: duplicate", pr.PullRequestID)
+		}
+		pullRequests[pr.PullRequestID] = archived
+
+		if !users[pr.AuthorID] {
+			addProblem("pull request %q: unknown author %q", pr.PullRequestID, pr.AuthorID)
+		}
+		if _, ok := teams[pr.TeamName]; pr.TeamName != "" && !ok {
+			addProblem("pull request %q: unknown team %q", pr.PullRequestID, pr.TeamName)
+		}
+		if pr.RepositoryName != "" && !repositories[pr.RepositoryName] {
+			addProblem("pull request %q: unknown repository %q", pr.PullRequestID, pr.RepositoryName)
+		}
+		if pr.VCSProvider != "" && !validVCSProvider(pr.VCSProvider) {
+			addProblem("pull request %q: unknown vcs_provider %q", pr.PullRequestID, pr.VCSProvider)
+		}
+
+		switch pr.Status {
+		case models.PullRequestStatusOpen, models.PullRequestStatusClosed:
+			if pr.MergedAt != nil {
+				addProblem("pull request %q: merged_at of a pull request that is not merged", pr.PullRequestID)
+			}
+		case models.PullRequestStatusMerged:
+		default:
+			addProblem("pull request %q: invalid status %q", pr.PullRequestID, pr.Status)
+		}
+	}
+
+	for i, pr := range snapshot.PullRequests {
+		checkPullRequest("pull_requests", i, pr, false)
+	}
+	for i, pr := range snapshot.ArchivedPullRequests {
+		checkPullRequest("archived_pull_requests", i, pr.SnapshotPullRequest, true)
+	}
+
+	type assignment struct{ pullRequestID, reviewerID string }
+	currentReviewers := make(map[assignment]bool)
+	for _, rv := range snapshot.Reviewers {
+		if archived, ok := pullRequests[rv.PullRequestID]; !ok || archived {
+			addProblem("reviewer %q: unknown pull request %q", rv.ReviewerID, rv.PullRequestID)
+		}
+		if !users[rv.ReviewerID] {
+			addProblem("reviewer %q of pull request %q: unknown user", rv.ReviewerID, rv.PullRequestID)
+		}
+
+		if rv.ReassignedAt == nil {
+			if currentReviewers[assignment{rv.PullRequestID, rv.ReviewerID}] {
+				addProblem("reviewer %q of pull request %q: assigned twice", rv.ReviewerID, rv.PullRequestID)
+			}
+			currentReviewers[assignment{rv.PullRequestID, rv.ReviewerID}] = true
+		}
+	}
+
+	for _, rv := range snapshot.ArchivedReviewers {
+		if archived := pullRequests[rv.PullRequestID]; !archived {
+			addProblem("archived reviewer %q: unknown archived pull request %q", rv.ReviewerID, rv.PullRequestID)
+		}
+		if !users[rv.ReviewerID] {
+			addProblem("archived reviewer %q of pull request %q: unknown user", rv.ReviewerID, rv.PullRequestID)
+		}
+	}
+
+	preferences := make(map[string]bool, len(snapshot.NotificationPreferences))
+	for i := range snapshot.NotificationPreferences {
+		p := &snapshot.NotificationPreferences[i]
+		if !users[p.UserID] {
+			addProblem("notification preferences of %q: unknown user", p.UserID)
+		}
+		if preferences[p.UserID] {
+			addProblem("notification preferences of %q: duplicate", p.UserID)
+		}
+		preferences[p.UserID] = true
+
+		if p.Channels == nil {
+			p.Channels = []string{}
+		}
+		if p.EventTypes == nil {
+			p.EventTypes = []string{}
+		}
+		if p.Timezone == "" {
+			p.Timezone = "UTC"
+		}
+
+		if _, err := time.LoadLocation(p.Timezone); err != nil {
+			addProblem("notification preferences of %q: unknown timezone %q", p.UserID, p.Timezone)
+		}
+		if (p.QuietHoursStart == "") != (p.QuietHoursEnd == "") {
+			addProblem("notification preferences of %q: quiet hours need both start and end", p.UserID)
+		}
+		for _, clock := range []string{p.QuietHoursStart, p.QuietHoursEnd} {
+			if _, err := time.Parse("15:04", clock); clock != "" && err != nil {
+				addProblem("notification preferences of %q: invalid quiet hours %q", p.UserID, clock)
+			}
+		}
+	}
+
+	type identity struct{ provider, login string }
+	identities := make(map[identity]bool, len(snapshot.VCSIdentities))
+	accounts := make(map[identity]bool, len(snapshot.VCSIdentities))
+	for _, i := range snapshot.VCSIdentities {
+		if !validVCSProvider(i.Provider) || i.Login == "" {
+			addProblem("vcs identity %q of %q: invalid provider or login", i.Login, i.Provider)
+		}
+		if !users[i.UserID] {
+			addProblem("vcs identity %q of %q: unknown user %q", i.Login, i.Provider, i.UserID)
+		}
+
+		if identities[identity{i.Provider, i.Login}] {
+			addProblem("vcs identity %q of %q: duplicate", i.Login, i.Provider)
+		}
+		identities[identity{i.Provider, i.Login}] = true
+
+		if i.AccountID != "" {
+			if accounts[identity{i.Provider, i.AccountID}] {
+				addProblem("vcs identity %q of %q: duplicate account id %q", i.Login, i.Provider, i.AccountID)
+			}
+			accounts[identity{i.Provider, i.AccountID}] = true
+		}
+	}
+
+	slackUsers := make(map[string]bool, len(snapshot.SlackIdentities))
+	for _, i := range snapshot.SlackIdentities {
+		if i.SlackUserID == "" {
+			addProblem("slack identity of %q: empty slack user id", i.UserID)
+		}
+		if !users[i.UserID] {
+			addProblem("slack identity %q: unknown user %q", i.SlackUserID, i.UserID)
+		}
+
+		if slackUsers[i.SlackUserID] {
+			addProblem("slack identity %q: duplicate", i.SlackUserID)
+		}
+		slackUsers[i.SlackUserID] = true
+	}
+
+	return problems
+}
+
+func validVCSProvider(provider string) bool {
+	return provider == models.VCSProviderGitHub || provider == models.VCSProviderGitLab
+}
@@ -0,0 +1,318 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+)
+
+func TestUpgradeEmailModes(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{
+			name: "immediate",
+			data: `{"users":[{"user_id":"u1","username":"Alice","email":"alice@example.com","email_mode":"immediate"}],"notification_preferences":[]}`,
+			want: `{"users":[{"user_id":"u1","username":"Alice","email":"alice@example.com"}],"notification_preferences":[]}`,
+		},
+		{
+			name: "off drops the address",
+			data: `{"users":[{"user_id":"u1","username":"Alice","email":"alice@example.com","email_mode":"off"}],"notification_preferences":[]}`,
+			want: `{"users":[{"user_id":"u1","username":"Alice"}],"notification_preferences":[]}`,
+		},
+		{
+			name: "digest without preferences",
+			data: `{"users":[{"user_id":"u1","username":"Alice","email":"alice@example.com","email_mode":"digest"}],"notification_preferences":null}`,
+			want: `{"users":[{"user_id":"u1","username":"Alice","email":"alice@example.com"}],"notification_preferences":[` +
+				`{"user_id":"u1","channels":[],"event_types":["pull_request.created","pull_request.reviewer_reassigned","pull_request.sla_breached","pull_request.merged","review_digest"],"timezone":"UTC"}]}`,
+		},
+		{
+			name: "digest with preferences",
+			data: `{"users":[{"user_id":"u1","username":"Alice","email_mode":"digest"}],"notification_preferences":[` +
+				`{"user_id":"u1","channels":["email"],"event_types":["pull_request.created"],"timezone":"Europe/Moscow","quiet_hours_start":"22:00","quiet_hours_end":"08:00"}]}`,
+			want: `{"users":[{"user_id":"u1","username":"Alice"}],"notification_preferences":[` +
+				`{"user_id":"u1","channels":["email"],"event_types":["pull_request.created","review_digest"],"timezone":"Europe/Moscow","quiet_hours_start":"22:00","quiet_hours_end":"08:00"}]}`,
+		},
+		{
+			name: "digest already chosen",
+			data: `{"users":[{"user_id":"u1","username":"Alice","email_mode":"digest"}],"notification_preferences":[` +
+				`{"user_id":"u1","channels":[],"event_types":["review_digest"],"timezone":"UTC"}]}`,
+			want: `{"users":[{"user_id":"u1","username":"Alice"}],"notification_preferences":[` +
+				`{"user_id":"u1","channels":[],"event_types":["review_digest"],"timezone":"UTC"}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := upgradeEmailModes(json.RawMessage(tt.data))
+			if err != nil {
+				t.Fatalf("upgradeEmailModes() error = %v", err)
+			}
+
+			assertJSONEqual(t, got, tt.want)
+		})
+	}
+}
+
+func TestDecodeSnapshot(t *testing.T) {
+	tests := []struct {
+		name          string
+		formatVersion int
+		data          string
+		want          string
+		wantErr       error
+	}{
+		{
+			name:          "version 1",
+			formatVersion: 1,
+			data: `{"teams":[],"users":[{"user_id":"u1","username":"Alice","is_active":true,"email":"alice@example.com","email_mode":"off"}],` +
+				`"team_members":[],"repositories":[],"pull_requests":[],"reviewers":[],` +
+				`"chat_channels":[{"team_name":"backend","url":"https://hooks.slack.com/services/T0/B0/secret","event_types":[],"templates":{},"is_active":true}],` +
+				`"notification_preferences":[],"vcs_identities":[]}`,
+			want: `{"teams":[],"users":[{"user_id":"u1","username":"Alice","is_active":true,"away_until":null}],` +
+				`"team_members":[],"repositories":[],"pull_requests":[],"reviewers":[],"notification_preferences":[],"vcs_identities":[],` +
+				`"slack_identities":null,"archived_pull_requests":null,"archived_reviewers":null}`,
+		},
+		{
+			name:          "version 4 drops chat channels",
+			formatVersion: 4,
+			data: `{"users":[{"user_id":"u1","username":"Alice","is_active":true}],` +
+				`"chat_channels":[{"team_name":"backend","url":"https://hooks.slack.com/services/T0/B0/secret","event_types":[],"templates":{},"is_active":true}],` +
+				`"slack_identities":[{"slack_user_id":"U1","user_id":"u1","is_admin":true}]}`,
+			want: `{"teams":null,"users":[{"user_id":"u1","username":"Alice","is_active":true,"away_until":null}],` +
+				`"team_members":null,"repositories":null,"pull_requests":null,"reviewers":null,"notification_preferences":null,"vcs_identities":null,` +
+				`"slack_identities":[{"slack_user_id":"U1","user_id":"u1","is_admin":true}],"archived_pull_requests":null,"archived_reviewers":null}`,
+		},
+		{
+			name:          "current version rejects chat channels",
+			formatVersion: models.SnapshotFormatVersion,
+			data:          `{"chat_channels":[]}`,
+			wantErr:       ErrInvalidSnapshot,
+		},
+		{
+			name:          "email modes are gone after version 2",
+			formatVersion: 3,
+			data:          `{"users":[{"user_id":"u1","username":"Alice","email_mode":"off"}]}`,
+			wantErr:       ErrInvalidSnapshot,
+		},
+		{
+			name:          "no upgrade",
+			formatVersion: 0,
+			data:          `{}`,
+			wantErr:       ErrUnsupportedSnapshot,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snapshot, err := decodeSnapshot(tt.formatVersion, json.RawMessage(tt.data))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("decodeSnapshot() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			got, err := json.Marshal(snapshot)
+			if err != nil {
+				t.Fatalf("failed to encode snapshot: %v", err)
+			}
+			assertJSONEqual(t, got, tt.want)
+		})
+	}
+}
+
+func TestCheckSnapshot(t *testing.T) {
+	zero, two := 0, 2
+	mergedAt := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	// valid returns a snapshot without problems, the cases break one part of it
+	valid := func() *models.Snapshot {
+		return &models.Snapshot{
+			Teams: []models.SnapshotTeam{
+				{TeamName: "engineering"},
+				{TeamName: "backend", ParentTeamName: "engineering", ReviewersCount: &two},
+			},
+			Users: []models.SnapshotUser{
+				{UserID: "u1", Username: "Alice", IsActive: true},
+				{UserID: "u2", Username: "Bob", IsActive: true},
+			},
+			TeamMembers: []models.SnapshotTeamMember{
+				{UserID: "u1", TeamName: "backend", IsPrimary: true},
+				{UserID: "u2", TeamName: "backend", IsPrimary: true},
+			},
+			PullRequests: []models.SnapshotPullRequest{
+				{PullRequestID: "pr-1", PullRequestName: "Fix", AuthorID: "u1", TeamName: "backend", Status: models.PullRequestStatusOpen},
+			},
+			Reviewers: []models.SnapshotReviewer{
+				{PullRequestID: "pr-1", ReviewerID: "u2"},
+			},
+			NotificationPreferences: []models.SnapshotNotificationPreference{
+				{UserID: "u1"},
+			},
+			VCSIdentities: []models.SnapshotVCSIdentity{
+				{Provider: models.VCSProviderGitHub, Login: "alice", AccountID: "1", UserID: "u1"},
+			},
+			SlackIdentities: []models.SnapshotSlackIdentity{
+				{SlackUserID: "U1", UserID: "u1"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		change       func(s *models.Snapshot)
+		wantProblems []string
+	}{
+		{
+			name:   "valid",
+			change: func(*models.Snapshot) {},
+		},
+		{
+			name: "teams",
+			change: func(s *models.Snapshot) {
+				s.Teams = append(s.Teams,
+					models.SnapshotTeam{TeamName: "backend"},
+					models.SnapshotTeam{TeamName: "qa", ReviewersCount: &zero},
+					models.SnapshotTeam{TeamName: "ops", ParentTeamName: "platform"},
+				)
+			},
+			wantProblems: []string{
+				`team "backend": duplicate`,
+				`team "qa": reviewers_count must be positive`,
+				`team "ops": unknown parent team "platform"`,
+			},
+		},
+		{
+			name: "cycle",
+			change: func(s *models.Snapshot) {
+				s.Teams[0].ParentTeamName = "backend"
+			},
+			wantProblems: []string{
+				`team "engineering": parent teams form a cycle`,
+				`team "backend": parent teams form a cycle`,
+			},
+		},
+		{
+			name: "users and members",
+			change: func(s *models.Snapshot) {
+				s.Users = append(s.Users, models.SnapshotUser{UserID: "u1", Username: "Alice"}, models.SnapshotUser{UserID: "u3"})
+				s.TeamMembers = append(s.TeamMembers,
+					models.SnapshotTeamMember{UserID: "u1", TeamName: "engineering", IsPrimary: true},
+					models.SnapshotTeamMember{UserID: "u9", TeamName: "frontend"},
+				)
+			},
+			wantProblems: []string{
+				`user "u1": duplicate`,
+				`users[3]: empty user_id or username`,
+				`user "u1": more than one primary team`,
+				`member "u9" of team "frontend": unknown user`,
+				`member "u9" of team "frontend": unknown team`,
+			},
+		},
+		{
+			name: "pull requests and reviewers",
+			change: func(s *models.Snapshot) {
+				s.PullRequests = append(s.PullRequests,
+					models.SnapshotPullRequest{PullRequestID: "pr-1", AuthorID: "u1", Status: models.PullRequestStatusOpen},
+					models.SnapshotPullRequest{PullRequestID: "pr-2", AuthorID: "u9", Status: models.PullRequestStatusClosed, MergedAt: &mergedAt},
+				)
+				s.Reviewers = append(s.Reviewers,
+					models.SnapshotReviewer{PullRequestID: "pr-1", ReviewerID: "u2"},
+					models.SnapshotReviewer{PullRequestID: "pr-9", ReviewerID: "u1"},
+				)
+				s.ArchivedReviewers = []models.SnapshotReviewer{{PullRequestID: "pr-1", ReviewerID: "u2"}}
+			},
+			wantProblems: []string{
+				`pull request "pr-1": duplicate`,
+				`pull request "pr-2": unknown author "u9"`,
+				`pull request "pr-2": merged_at of a pull request that is not merged`,
+				`reviewer "u2" of pull request "pr-1": assigned twice`,
+				`reviewer "u1": unknown pull request "pr-9"`,
+				`archived reviewer "u2": unknown archived pull request "pr-1"`,
+			},
+		},
+		{
+			name: "notification preferences",
+			change: func(s *models.Snapshot) {
+				s.NotificationPreferences = append(s.NotificationPreferences,
+					models.SnapshotNotificationPreference{UserID: "u1"},
+					models.SnapshotNotificationPreference{UserID: "u2", Timezone: "Mars/Olympus", QuietHoursStart: "25:00"},
+				)
+			},
+			wantProblems: []string{
+				`notification preferences of "u1": duplicate`,
+				`notification preferences of "u2": unknown timezone "Mars/Olympus"`,
+				`notification preferences of "u2": quiet hours need both start and end`,
+				`notification preferences of "u2": invalid quiet hours "25:00"`,
+			},
+		},
+		{
+			name: "identities",
+			change: func(s *models.Snapshot) {
+				s.VCSIdentities = append(s.VCSIdentities,
+					models.SnapshotVCSIdentity{Provider: models.VCSProviderGitHub, Login: "alice2", AccountID: "1", UserID: "u2"},
+					models.SnapshotVCSIdentity{Provider: "bitbucket", Login: "bob", UserID: "u9"},
+				)
+				s.SlackIdentities = append(s.SlackIdentities,
+					models.SnapshotSlackIdentity{SlackUserID: "U1", UserID: "u2"},
+					models.SnapshotSlackIdentity{UserID: "u9"},
+				)
+			},
+			wantProblems: []string{
+				`vcs identity "alice2" of "github": duplicate account id "1"`,
+				`vcs identity "bob" of "bitbucket": invalid provider or login`,
+				`vcs identity "bob" of "bitbucket": unknown user "u9"`,
+				`slack identity "U1": duplicate`,
+				`slack identity of "u9": empty slack user id`,
+				`slack identity "": unknown user "u9"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snapshot := valid()
+			tt.change(snapshot)
+
+			problems := checkSnapshot(snapshot)
+			if !reflect.DeepEqual(problems, tt.wantProblems) {
+				t.Fatalf("problems = %q, want %q", problems, tt.wantProblems)
+			}
+		})
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		snapshot := valid()
+		checkSnapshot(snapshot)
+
+		want := models.SnapshotNotificationPreference{UserID: "u1", Channels: []string{}, EventTypes: []string{}, Timezone: "UTC"}
+		if !reflect.DeepEqual(snapshot.NotificationPreferences[0], want) {
+			t.Errorf("preferences = %+v, want %+v", snapshot.NotificationPreferences[0], want)
+		}
+	})
+}
+
+func assertJSONEqual(t *testing.T, got json.RawMessage, want string) {
+	t.Helper()
+
+	var gotValue, wantValue any
+	if err := json.Unmarshal(got, &gotValue); err != nil {
+		t.Fatalf("invalid json %s: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &wantValue); err != nil {
+		t.Fatalf("invalid want json %s: %v", want, err)
+	}
+
+	gotNormalized, _ := json.Marshal(gotValue)
+	wantNormalized, _ := json.Marshal(wantValue)
+	if string(gotNormalized) != string(wantNormalized) {
+		t.Errorf("got %s, want %s", gotNormalized, wantNormalized)
+	}
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo"
+)
+
+type StatsService struct {
+	statsRepo repo.Stats
+}
+
+func NewStatsService(statsRepo repo.Stats) *StatsService {
+	return &StatsService{statsRepo: statsRepo}
+}
+
+func (s *StatsService) GetUserStats(ctx context.Context, input StatsInput) (*StatsUserOutput, error) {
+	stats, err := s.statsRepo.GetUserStats(ctx, toStatsFilter(input))
+	if err != nil {
+		return nil, err
+	}
+
+	output := StatsUserOutput{
+		From:  input.From,
+		To:    input.To,
+		Users: []StatsOutputUser{},
+	}
+
+	for _, userStats := range stats {
+		output.Users = append(output.Users, StatsOutputUser{
+			UserID:              userStats.UserID,
+			Username:            userStats.Username,
+			StatsOutputCounters: toStatsOutputCounters(userStats),
+		})
+	}
+
+	return &output, nil
+}
+
+func (s *StatsService) GetTeamStats(ctx context.Context, input StatsInput) (*StatsTeamOutput, error) {
+	stats, err := s.statsRepo.GetTeamStats(ctx, toStatsFilter(input))
+	if err != nil {
+		return nil, err
+	}
+
+	output := StatsTeamOutput{
+		From:  input.From,
+		To:    input.To,
+		Teams: []StatsOutputTeam{},
+	}
+
+	for _, teamStats := range stats {
+		output.Teams = append(output.Teams, StatsOutputTeam{
+			TeamName:            teamStats.TeamName,
+			StatsOutputCounters: toStatsOutputCounters(teamStats),
+		})
+	}
+
+	return &output, nil
+}
+
+func (s *StatsService) GetUserFlowStats(ctx context.Context, input StatsInput) (*StatsUserFlowOutput, error) {
+	stats, err := s.statsRepo.GetUserFlowStats(ctx, toStatsFilter(input))
+	if err != nil {
+		return nil, err
+	}
+
+	output := StatsUserFlowOutput{
+		From:  input.From,
+		To:    input.To,
+		Users: []StatsOutputUserFlow{},
+	}
+
+	for _, userStats := range stats {
+		output.Users = append(output.Users, StatsOutputUserFlow{
+			UserID:          userStats.UserID,
+			StatsOutputFlow: toStatsOutputFlow(userStats),
+		})
+	}
+
+	return &output, nil
+}
+
+func (s *StatsService) GetTeamFlowStats(ctx context.Context, input StatsInput) (*StatsTeamFlowOutput, error) {
+	stats, err := s.statsRepo.GetTeamFlowStats(ctx, toStatsFilter(input))
+	if err != nil {
+		return nil, err
+	}
+
+	output := StatsTeamFlowOutput{
+		From:  input.From,
+		To:    input.To,
+		Teams: []StatsOutputTeamFlow{},
+	}
+
+	for _, teamStats := range stats {
+		output.Teams = append(output.Teams, StatsOutputTeamFlow{
+			TeamName:        teamStats.TeamName,
+			StatsOutputFlow: toStatsOutputFlow(teamStats),
+		})
+	}
+
+	return &output, nil
+}
+
+func toStatsFilter(input StatsInput) models.StatsFilter {
+	return models.StatsFilter{
+		UserID:          input.UserID,
+		TeamName:        input.TeamName,
+		IncludeSubteams: input.IncludeSubteams,
+		From:            input.From,
+		To:              input.To,
+	}
+}
+
+func toStatsOutputCounters(stats models.ReviewStats) StatsOutputCounters {
+	return StatsOutputCounters{
+		Assigned:       stats.Assigned,
+		ReassignedAway: stats.ReassignedAway,
+		Completed:      stats.Completed,
+		OpenLoad:       stats.OpenLoad,
+	}
+}
+
+func toStatsOutputFlow(stats models.FlowStats) StatsOutputFlow {
+	return StatsOutputFlow{
+		Merged:              stats.Merged,
+		TimeToMerge:         StatsOutputPercentiles(stats.TimeToMerge),
+		Reassigned:          stats.Reassigned,
+		TimeToFirstReassign: StatsOutputPercentiles(stats.TimeToFirstReassign),
+	}
+}
@@ -2,10 +2,12 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/MatTwix/Pull-Request-Assigner/internal/metrics"
 	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
 	"github.com/MatTwix/Pull-Request-Assigner/internal/repo"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/tenant"
 )
 
 type TeamService struct {
@@ -34,13 +36,17 @@ func (s *TeamService) AddTeam(ctx context.Context, input TeamAddInput) (*TeamAdd
 		return nil, err
 	}
 
-	outputTeam := TeamAddOutputTeam{TeamName: createdTeam.TeamName}
+	outputTeam := TeamAddOutputTeam{
+		TeamName: createdTeam.TeamName,
+		Version:  createdTeam.Version,
+	}
 
 	for _, member := range createdTeam.Members {
 		outputTeam.Members = append(outputTeam.Members, TeamOutputMember{
-			UserID:   member.UserID,
-			UserName: member.Username,
-			IsActive: member.IsActive,
+			UserID:    member.UserID,
+			UserName:  member.Username,
+			IsActive:  member.IsActive,
+			IsPrimary: member.TeamName == createdTeam.TeamName,
 		})
 	}
 
@@ -52,23 +58,51 @@ func (s *TeamService) AddTeam(ctx context.Context, input TeamAddInput) (*TeamAdd
 	return &output, nil
 }
 
-func (s *TeamService) GetTeamByName(ctx context.Context, name string) (*TeamGetOutput, error) {
-	team, err := s.teamRepo.GetTeamByName(ctx, name)
+func (s *TeamService) GetTeamByName(ctx context.Context, name string, includeSubteams bool) (*TeamGetOutput, error) {
+	var (
+		team *models.Team
+		err  error
+	)
+
+	if includeSubteams {
+		team, err = s.teamRepo.GetTeamTree(ctx, name)
+	} else {
+		team, err = s.teamRepo.GetTeamByName(ctx, name)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	output := TeamGetOutput{TeamName: team.TeamName}
+	output := toTeamGetOutput(*team)
+
+	return &output, nil
+}
+
+func toTeamGetOutput(team models.Team) TeamGetOutput {
+	output := TeamGetOutput{
+		TeamName:       team.TeamName,
+		ParentTeamName: team.ParentName,
+		FallbackDepth:  team.FallbackDepth,
+		ReviewersCount: team.ReviewersCount,
+		ReviewSLA:      formatReviewSLA(team.ReviewSLA),
+		IsArchived:     team.IsArchived,
+		Version:        team.Version,
+	}
 
 	for _, member := range team.Members {
 		output.Members = append(output.Members, TeamOutputMember{
-			UserID:   member.UserID,
-			UserName: member.Username,
-			IsActive: member.IsActive,
+			UserID:    member.UserID,
+			UserName:  member.Username,
+			IsActive:  member.IsActive,
+			IsPrimary: member.TeamName == team.TeamName,
 		})
 	}
 
-	return &output, nil
+	for _, subteam := range team.Subteams {
+		output.Subteams = append(output.Subteams, toTeamGetOutput(subteam))
+	}
+
+	return output
 }
 
 func (s *TeamService) SetIsActiveTeam(ctx context.Context, teamName string, isActive bool) (*TeamSetIsActiveTeamOutput, error) {
@@ -82,3 +116,183 @@ func (s *TeamService) SetIsActiveTeam(ctx context.Context, teamName string, isAc
 	metrics.UserStatusChanges.WithLabelValues("setIsActiveTeam").Add(float64(usersUpdated))
 	return &output, nil
 }
+
+func (s *TeamService) UpsertTeam(ctx context.Context, input TeamUpsertInput) (*TeamUpsertOutput, error) {
+	team := models.Team{
+		TeamName: input.TeamName,
+	}
+
+	for _, member := range input.Members {
+		team.Members = append(team.Members, models.User{
+			UserID:   member.UserID,
+			Username: member.Username,
+			IsActive: member.IsActive,
+		})
+	}
+
+	diff, err := s.teamRepo.UpsertTeam(ctx, team, input.DryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	output := TeamUpsertOutput{
+		TeamName:        input.TeamName,
+		DryRun:          input.DryRun,
+		Changed:         !diff.IsEmpty(),
+		TeamCreated:     diff.TeamCreated,
+		Added:           toUpsertOutputChanges(diff.Added),
+		Removed:         toUpsertOutputChanges(diff.Removed),
+		UsernameChanged: toUpsertOutputChanges(diff.UsernameChanged),
+		ActivityChanged: toUpsertOutputChanges(diff.ActivityChanged),
+	}
+
+	if !input.DryRun {
+		if diff.TeamCreated {
+			metrics.TeamsCreated.Inc()
+		}
+		metrics.UserStatusChanges.WithLabelValues("upsertTeam").Add(float64(len(diff.ActivityChanged)))
+	}
+
+	return &output, nil
+}
+
+func toUpsertOutputChanges(changes []models.TeamMemberChange) []TeamUpsertOutputChange {
+	output := []TeamUpsertOutputChange{}
+
+	for _, change := range changes {
+		outputChange := TeamUpsertOutputChange{
+			UserID:          change.UserID,
+			PrimaryTeamName: change.PrimaryTeamName,
+			PrevTeamName:    change.PrimaryTeamName,
+			PrevUsername:    change.PrevUsername,
+			Username:        change.Username,
+			IsActive:        change.IsActive,
+		}
+
+		// previous state is known only for users that already existed
+		if change.PrevUsername != "" {
+			prevIsActive := change.PrevIsActive
+			outputChange.PrevIsActive = &prevIsActive
+		}
+
+		output = append(output, outputChange)
+	}
+
+	return output
+}
+
+func (s *TeamService) RenameTeam(ctx context.Context, oldName, newName string) (*TeamRenameOutput, error) {
+	team, err := s.teamRepo.RenameTeam(ctx, oldName, newName)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.ForgetTeam(tenant.ID(ctx), oldName)
+
+	output := TeamRenameOutput{
+		OldTeamName: oldName,
+		TeamName:    team.TeamName,
+		Version:     team.Version,
+	}
+
+	return &output, nil
+}
+
+func (s *TeamService) SetIsArchivedTeam(ctx context.Context, teamName string, isArchived bool) (*TeamSetIsArchivedOutput, error) {
+	team, alreadyUpdated, err := s.teamRepo.SetIsArchivedTeam(ctx, teamName, isArchived)
+	if err != nil {
+		return nil, err
+	}
+
+	output := TeamSetIsArchivedOutput{
+		TeamName:   team.TeamName,
+		IsArchived: team.IsArchived,
+		ArchivedAt: team.ArchivedAt,
+		Version:    team.Version,
+	}
+
+	if !alreadyUpdated {
+		metrics.TeamStatusChanges.WithLabelValues("setIsArchived").Inc()
+		if isArchived {
+			metrics.ForgetTeam(tenant.ID(ctx), teamName)
+		}
+	}
+
+	return &output, nil
+}
+
+func (s *TeamService) DeleteTeam(ctx context.Context, teamName, reassignTo string) (*TeamDeleteOutput, error) {
+	membersMoved, err := s.teamRepo.DeleteTeam(ctx, teamName, reassignTo)
+	if err != nil {
+		return nil, err
+	}
+
+	output := TeamDeleteOutput{
+		TeamName:          teamName,
+		ReassignedTo:      reassignTo,
+		MembersReassigned: membersMoved,
+	}
+
+	metrics.TeamStatusChanges.WithLabelValues("delete").Inc()
+	metrics.ForgetTeam(tenant.ID(ctx), teamName)
+	return &output, nil
+}
+
+func (s *TeamService) SetParentTeam(ctx context.Context, teamName, parentName string) (*TeamUpdateOutput, error) {
+	team, err := s.teamRepo.SetParentTeam(ctx, teamName, parentName)
+	if err != nil {
+		return nil, err
+	}
+
+	output := TeamUpdateOutput{
+		TeamName:       team.TeamName,
+		ParentTeamName: team.ParentName,
+		FallbackDepth:  team.FallbackDepth,
+		ReviewersCount: team.ReviewersCount,
+		Version:        team.Version,
+	}
+
+	return &output, nil
+}
+
+func (s *TeamService) SetTeamSettings(ctx context.Context, teamName string, reviewersCount, fallbackDepth models.NullInt) (*TeamUpdateOutput, error) {
+	team, err := s.teamRepo.SetTeamSettings(ctx, teamName, reviewersCount, fallbackDepth)
+	if err != nil {
+		return nil, err
+	}
+
+	output := TeamUpdateOutput{
+		TeamName:       team.TeamName,
+		FallbackDepth:  team.FallbackDepth,
+		ReviewersCount: team.ReviewersCount,
+		Version:        team.Version,
+	}
+
+	return &output, nil
+}
+
+// SetReviewSLA sets the time reviewers of the team have for a review, nil
+// turns the check off.
+func (s *TeamService) SetReviewSLA(ctx context.Context, teamName string, sla *time.Duration) (*TeamReviewSLAOutput, error) {
+	team, err := s.teamRepo.SetTeamReviewSLA(ctx, teamName, sla)
+	if err != nil {
+		return nil, err
+	}
+
+	output := TeamReviewSLAOutput{
+		TeamName:  team.TeamName,
+		ReviewSLA: formatReviewSLA(team.ReviewSLA),
+		Version:   team.Version,
+	}
+
+	return &output, nil
+}
+
+func formatReviewSLA(sla *time.Duration) *string {
+	if sla == nil {
+		return nil
+	}
+
+	formatted := sla.String()
+	return &formatted
+}
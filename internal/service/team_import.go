@@ -0,0 +1,635 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/metrics"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	TeamImportFormatYAML = "yaml"
+	TeamImportFormatCSV  = "csv"
+)
+
+var ErrInvalidImport = errors.New("invalid import file")
+
+// ImportLineError is a problem of the import file, Line is 0 for problems of
+// the file as a whole.
+type ImportLineError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+func (e ImportLineError) String() string {
+	if e.Line == 0 {
+		return e.Message
+	}
+
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// ImportErrors lists every problem found in the import file, nothing is
+// applied when there is any.
+type ImportErrors []ImportLineError
+
+func (e ImportErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, lineErr := range e {
+		messages = append(messages, lineErr.String())
+	}
+
+	return fmt.Sprintf("%s: %s", ErrInvalidImport, strings.Join(messages, "; "))
+}
+
+func (e ImportErrors) Unwrap() error {
+	return ErrInvalidImport
+}
+
+// teamImportAttributes are the team attributes an import file may set.
+var teamImportAttributes = []string{
+	models.TeamAttributeParent,
+	models.TeamAttributeReviewersCount,
+	models.TeamAttributeFallbackDepth,
+	models.TeamAttributeReviewSLA,
+	models.TeamAttributeIsArchived,
+}
+
+// teamImportNoParent as parent_team_name moves the team to the root, empty
+// values keep the current parent.
+const teamImportNoParent = "-"
+
+// teamImportColumns are the columns of a CSV import, a row describes a team
+// and optionally one of its members.
+var teamImportColumns = slices.Concat(
+	[]string{"team_name"},
+	teamImportAttributes,
+	[]string{"user_id", "username", "is_active"},
+)
+
+// ImportTeams validates the whole import file and applies it in one
+// transaction, or only reports the changes with DryRun.
+func (s *TeamService) ImportTeams(ctx context.Context, input TeamImportInput) (*TeamImportOutput, error) {
+	p := &importParser{
+		byName: map[string]*importedTeam{},
+		users:  map[string]importedUser{},
+	}
+
+	data := bytes.TrimPrefix(input.Data, []byte("\xef\xbb\xbf"))
+	switch input.Format {
+	case TeamImportFormatYAML:
+		p.parseYAML(data)
+	case TeamImportFormatCSV:
+		p.parseCSV(data)
+	default:
+		return nil, fmt.Errorf("%w: unknown format %s", ErrInvalidImport, input.Format)
+	}
+
+	if len(p.teams) == 0 && len(p.errs) == 0 {
+		p.errorf(0, "file has no teams")
+	}
+
+	p.checkHierarchy()
+	if err := s.checkParents(ctx, p); err != nil {
+		return nil, err
+	}
+
+	if len(p.errs) > 0 {
+		slices.SortStableFunc(p.errs, func(a, b ImportLineError) int { return a.Line - b.Line })
+		return nil, p.errs
+	}
+
+	teams := make([]models.TeamImport, 0, len(p.teams))
+	for _, team := range p.teams {
+		teams = append(teams, team.TeamImport)
+	}
+
+	diffs, err := s.teamRepo.ImportTeams(ctx, teams, input.DryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	output := TeamImportOutput{
+		DryRun: input.DryRun,
+		Teams:  []TeamImportOutputTeam{},
+	}
+
+	var teamsCreated, activityChanged int
+	for _, diff := range diffs {
+		output.Changed = output.Changed || !diff.IsEmpty()
+		output.Teams = append(output.Teams, TeamImportOutputTeam{
+			TeamName:          diff.TeamName,
+			Changed:           !diff.IsEmpty(),
+			TeamCreated:       diff.TeamCreated,
+			UpdatedAttributes: append([]string{}, diff.UpdatedAttributes...),
+			Added:             toUpsertOutputChanges(diff.Added),
+			Removed:           toUpsertOutputChanges(diff.Removed),
+			UsernameChanged:   toUpsertOutputChanges(diff.UsernameChanged),
+			ActivityChanged:   toUpsertOutputChanges(diff.ActivityChanged),
+		})
+
+		if diff.TeamCreated {
+			teamsCreated++
+		}
+		activityChanged += len(diff.ActivityChanged)
+	}
+
+	if !input.DryRun {
+		metrics.TeamsCreated.Add(float64(teamsCreated))
+		metrics.UserStatusChanges.WithLabelValues("importTeams").Add(float64(activityChanged))
+	}
+
+	return &output, nil
+}
+
+// checkParents reports parents that are neither declared in the file nor
+// exist already.
+func (s *TeamService) checkParents(ctx context.Context, p *importParser) error {
+	checked := map[string]bool{}
+	for _, team := range p.teams {
+		if team.ParentName == nil || *team.ParentName == "" {
+			continue
+		}
+
+		parentName := *team.ParentName
+		if _, ok := p.byName[parentName]; ok {
+			continue
+		}
+
+		exists, ok := checked[parentName]
+		if !ok {
+			_, err := s.teamRepo.GetTeamByName(ctx, parentName)
+			switch {
+			case err == nil:
+				exists = true
+			case errors.Is(err, repoerrs.ErrNotFound):
+				exists = false
+			default:
+				return err
+			}
+			checked[parentName] = exists
+		}
+
+		if !exists {
+			p.errorf(team.parentLine, "parent team %s is neither in the file nor exists", parentName)
+		}
+	}
+
+	return nil
+}
+
+// importParser collects teams of the import file together with the lines
+// they come from, so every problem is reported against the file.
+type importParser struct {
+	teams  []*importedTeam
+	byName map[string]*importedTeam
+	users  map[string]importedUser
+	errs   ImportErrors
+}
+
+type importedTeam struct {
+	models.TeamImport
+
+	line        int
+	parentLine  int
+	fields      map[string]importedField
+	memberLines map[string]int
+}
+
+type importedField struct {
+	value string
+	line  int
+}
+
+type importedUser struct {
+	username string
+	isActive *bool
+	line     int
+}
+
+func (p *importParser) errorf(line int, format string, args ...any) {
+	p.errs = append(p.errs, ImportLineError{Line: line, Message: fmt.Sprintf(format, args...)})
+}
+
+// team returns the team declared at line. Rows of a CSV file merge into one
+// team, while a YAML file declares every team once.
+func (p *importParser) team(name string, line int, merge bool) *importedTeam {
+	if team, ok := p.byName[name]; ok {
+		if merge {
+			return team
+		}
+
+		p.errorf(line, "team %s is already declared at line %d", name, team.line)
+	}
+
+	team := &importedTeam{
+		TeamImport:  models.TeamImport{TeamName: name},
+		line:        line,
+		fields:      map[string]importedField{},
+		memberLines: map[string]int{},
+	}
+
+	if _, ok := p.byName[name]; !ok {
+		p.byName[name] = team
+		p.teams = append(p.teams, team)
+	}
+
+	return team
+}
+
+// setAttribute parses a team attribute, empty values keep the current one.
+func (p *importParser) setAttribute(team *importedTeam, field, value string, line int) {
+	if value == "" {
+		return
+	}
+
+	if prev, ok := team.fields[field]; ok {
+		if prev.value != value {
+			p.errorf(line, "%s of team %s differs from the one at line %d", field, team.TeamName, prev.line)
+		}
+		return
+	}
+	team.fields[field] = importedField{value: value, line: line}
+
+	switch field {
+	case models.TeamAttributeParent:
+		if value == teamImportNoParent {
+			value = ""
+		}
+		if value == team.TeamName {
+			p.errorf(line, "team %s cannot be its own parent", team.TeamName)
+			return
+		}
+		team.ParentName = &value
+		team.parentLine = line
+	case models.TeamAttributeReviewersCount:
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			p.errorf(line, "%s must be a positive integer", field)
+			return
+		}
+		team.ReviewersCount = &n
+	case models.TeamAttributeFallbackDepth:
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			p.errorf(line, "%s must be a non-negative integer", field)
+			return
+		}
+		team.FallbackDepth = &n
+	case models.TeamAttributeReviewSLA:
+		sla, err := parseImportSLA(value)
+		if err != nil || sla < time.Minute {
+			p.errorf(line, "%s must be a duration of at least a minute, e.g. 24h or 2d", field)
+			return
+		}
+		team.ReviewSLA = &sla
+	case models.TeamAttributeIsArchived:
+		isArchived, err := strconv.ParseBool(value)
+		if err != nil {
+			p.errorf(line, "%s must be true or false", field)
+			return
+		}
+		team.IsArchived = &isArchived
+	}
+}
+
+// addMember adds a member to the team, an omitted is_active keeps the activity
+// of an existing user. A user listed in several teams must have the same
+// username and activity in all.
+func (p *importParser) addMember(team *importedTeam, userID, username, isActive string, line int) {
+	if userID == "" {
+		p.errorf(line, "user_id is required")
+		return
+	}
+	if username == "" {
+		p.errorf(line, "username of user %s is required", userID)
+		return
+	}
+
+	var active *bool
+	if isActive != "" {
+		value, err := strconv.ParseBool(isActive)
+		if err != nil {
+			p.errorf(line, "is_active must be true or false")
+			return
+		}
+		active = &value
+	}
+
+	if prevLine, ok := team.memberLines[userID]; ok {
+		p.errorf(line, "user %s is already listed in team %s at line %d", userID, team.TeamName, prevLine)
+		return
+	}
+	team.memberLines[userID] = line
+
+	if prev, ok := p.users[userID]; ok {
+		if prev.username != username || !equalPtr(prev.isActive, active) {
+			p.errorf(line, "user %s differs from the one at line %d", userID, prev.line)
+			return
+		}
+	} else {
+		p.users[userID] = importedUser{username: username, isActive: active, line: line}
+	}
+
+	team.Members = append(team.Members, models.TeamImportMember{
+		UserID:   userID,
+		Username: username,
+		IsActive: active,
+	})
+}
+
+func equalPtr[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
+
+// checkHierarchy reports parents of the file forming a cycle, cycles through
+// existing teams are refused when the file is applied.
+func (p *importParser) checkHierarchy() {
+	for _, team := range p.teams {
+		seen := map[string]struct{}{team.TeamName: {}}
+		for next := team; next.ParentName != nil; {
+			parent, ok := p.byName[*next.ParentName]
+			if !ok {
+				break
+			}
+
+			if _, ok := seen[parent.TeamName]; ok {
+				if parent == team {
+					p.errorf(team.parentLine, "parent of team %s makes a cycle", team.TeamName)
+				}
+				break
+			}
+
+			seen[parent.TeamName] = struct{}{}
+			next = parent
+		}
+	}
+}
+
+// parseYAML reads a file of the form
+//
+//	teams:
+//	  - team_name: backend
+//	    parent_team_name: engineering
+//	    reviewers_count: 2
+//	    members:
+//	      - user_id: u1
+//	        username: Alice
+//
+// Members left out keep the current members of the team, an empty list
+// removes them all. parent_team_name "-" moves the team to the root.
+func (p *importParser) parseYAML(data []byte) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		p.errs = append(p.errs, yamlError(err))
+		return
+	}
+
+	if len(doc.Content) == 0 {
+		return
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		p.errorf(root.Line, "expected a mapping with teams")
+		return
+	}
+
+	for i := 0; i < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if key.Value != "teams" {
+			p.errorf(key.Line, "unknown field %s", key.Value)
+			continue
+		}
+
+		if value.Kind != yaml.SequenceNode {
+			p.errorf(value.Line, "teams must be a list")
+			continue
+		}
+
+		for _, teamNode := range value.Content {
+			p.parseYAMLTeam(teamNode)
+		}
+	}
+}
+
+func (p *importParser) parseYAMLTeam(node *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		p.errorf(node.Line, "team must be a mapping")
+		return
+	}
+
+	var (
+		name    string
+		members *yaml.Node
+	)
+	for i := 0; i < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		switch key.Value {
+		case "team_name":
+			name = p.yamlScalar(key.Value, value)
+		case "members":
+			members = value
+		default:
+			if !slices.Contains(teamImportAttributes, key.Value) {
+				p.errorf(key.Line, "unknown field %s", key.Value)
+			}
+		}
+	}
+
+	if name == "" {
+		p.errorf(node.Line, "team_name is required")
+		return
+	}
+
+	team := p.team(name, node.Line, false)
+	for i := 0; i < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if slices.Contains(teamImportAttributes, key.Value) {
+			p.setAttribute(team, key.Value, p.yamlScalar(key.Value, value), value.Line)
+		}
+	}
+
+	if members == nil || members.Tag == "!!null" {
+		return
+	}
+
+	if members.Kind != yaml.SequenceNode {
+		p.errorf(members.Line, "members must be a list")
+		return
+	}
+
+	team.Members = []models.TeamImportMember{}
+	for _, memberNode := range members.Content {
+		if memberNode.Kind != yaml.MappingNode {
+			p.errorf(memberNode.Line, "member must be a mapping")
+			continue
+		}
+
+		var userID, username, isActive string
+		for i := 0; i < len(memberNode.Content); i += 2 {
+			key, value := memberNode.Content[i], memberNode.Content[i+1]
+			switch key.Value {
+			case "user_id":
+				userID = p.yamlScalar(key.Value, value)
+			case "username":
+				username = p.yamlScalar(key.Value, value)
+			case "is_active":
+				isActive = p.yamlScalar(key.Value, value)
+			default:
+				p.errorf(key.Line, "unknown field %s", key.Value)
+			}
+		}
+
+		p.addMember(team, userID, username, isActive, memberNode.Line)
+	}
+}
+
+// yamlScalar returns the value of a scalar field, null is the same as a
+// missing field.
+func (p *importParser) yamlScalar(field string, node *yaml.Node) string {
+	if node.Kind != yaml.ScalarNode {
+		p.errorf(node.Line, "%s must be a scalar", field)
+		return ""
+	}
+
+	if node.Tag == "!!null" {
+		return ""
+	}
+
+	return strings.TrimSpace(node.Value)
+}
+
+// yamlError keeps the line of a syntax error apart from its message.
+func yamlError(err error) ImportLineError {
+	message := strings.TrimPrefix(err.Error(), "yaml: ")
+
+	if rest, ok := strings.CutPrefix(message, "line "); ok {
+		if number, text, ok := strings.Cut(rest, ": "); ok {
+			if line, err := strconv.Atoi(number); err == nil {
+				return ImportLineError{Line: line, Message: text}
+			}
+		}
+	}
+
+	return ImportLineError{Message: message}
+}
+
+// parseCSV reads a file with a header row of teamImportColumns in any order,
+// only team_name is required. Rows of a team merge, rows with a user_id list
+// its members, and a team without them keeps the current members.
+func (p *importParser) parseCSV(data []byte) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return
+	}
+	if err != nil {
+		p.errs = append(p.errs, csvError(err))
+		return
+	}
+
+	columns := map[string]int{}
+	for i, column := range header {
+		column = strings.TrimSpace(column)
+		if !slices.Contains(teamImportColumns, column) {
+			p.errorf(1, "unknown column %s", column)
+			continue
+		}
+		if _, ok := columns[column]; ok {
+			p.errorf(1, "column %s is repeated", column)
+			continue
+		}
+		columns[column] = i
+	}
+
+	if _, ok := columns["team_name"]; !ok {
+		p.errorf(1, "column team_name is required")
+		return
+	}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			p.errs = append(p.errs, csvError(err))
+			return
+		}
+
+		line, _ := reader.FieldPos(0)
+		if len(record) != len(header) {
+			p.errorf(line, "expected %d fields, got %d", len(header), len(record))
+			continue
+		}
+
+		value := func(column string) string {
+			if i, ok := columns[column]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		name := value("team_name")
+		if name == "" {
+			p.errorf(line, "team_name is required")
+			continue
+		}
+
+		team := p.team(name, line, true)
+		for _, column := range teamImportAttributes {
+			p.setAttribute(team, column, value(column), line)
+		}
+
+		userID, username, isActive := value("user_id"), value("username"), value("is_active")
+		if userID == "" && username == "" && isActive == "" {
+			continue
+		}
+
+		if team.Members == nil {
+			team.Members = []models.TeamImportMember{}
+		}
+		p.addMember(team, userID, username, isActive, line)
+	}
+}
+
+func csvError(err error) ImportLineError {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return ImportLineError{Line: parseErr.Line, Message: parseErr.Err.Error()}
+	}
+
+	return ImportLineError{Message: err.Error()}
+}
+
+// parseImportSLA accepts Go durations and whole days, e.g. "36h" or "2d".
+func parseImportSLA(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	return time.ParseDuration(value)
+}
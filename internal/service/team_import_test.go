@@ -0,0 +1,160 @@
+package service
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+)
+
+func TestImportParser(t *testing.T) {
+	active, inactive := true, false
+	root, engineering := "", "engineering"
+
+	tests := []struct {
+		name      string
+		format    string
+		data      string
+		wantTeams []models.TeamImport
+		wantErrs  ImportErrors
+	}{
+		{
+			name:   "yaml",
+			format: TeamImportFormatYAML,
+			data: `teams:
+  - team_name: backend
+    parent_team_name: engineering
+    members:
+      - user_id: u1
+        username: Alice
+      - user_id: u2
+        username: Bob
+        is_active: false
+  - team_name: engineering
+    parent_team_name: "-"
+`,
+			wantTeams: []models.TeamImport{
+				{TeamName: "backend", ParentName: &engineering, Members: []models.TeamImportMember{
+					{UserID: "u1", Username: "Alice"},
+					{UserID: "u2", Username: "Bob", IsActive: &inactive},
+				}},
+				{TeamName: "engineering", ParentName: &root},
+			},
+		},
+		{
+			name:   "yaml errors",
+			format: TeamImportFormatYAML,
+			data: `teams:
+  - team_name: backend
+    reviewers_count: 0
+    owner: Alice
+    members:
+      - user_id: u1
+      - user_id: u2
+        username: Bob
+        is_active: maybe
+  - team_name: backend
+`,
+			wantErrs: ImportErrors{
+				{Line: 3, Message: "reviewers_count must be a positive integer"},
+				{Line: 4, Message: "unknown field owner"},
+				{Line: 6, Message: "username of user u1 is required"},
+				{Line: 7, Message: "is_active must be true or false"},
+				{Line: 10, Message: "team backend is already declared at line 2"},
+			},
+		},
+		{
+			name:     "yaml syntax",
+			format:   TeamImportFormatYAML,
+			data:     "teams:\n  - team_name: backend\n    members: u1: Alice\n",
+			wantErrs: ImportErrors{{Line: 3, Message: "mapping values are not allowed in this context"}},
+		},
+		{
+			name:   "csv",
+			format: TeamImportFormatCSV,
+			data: `team_name,parent_team_name,user_id,username,is_active
+backend,engineering,u1,Alice,
+backend,,u2,Bob,true
+engineering,-,,,
+`,
+			wantTeams: []models.TeamImport{
+				{TeamName: "backend", ParentName: &engineering, Members: []models.TeamImportMember{
+					{UserID: "u1", Username: "Alice"},
+					{UserID: "u2", Username: "Bob", IsActive: &active},
+				}},
+				{TeamName: "engineering", ParentName: &root},
+			},
+		},
+		{
+			name:   "csv errors",
+			format: TeamImportFormatCSV,
+			data: `team_name,parent_team_name,user_id,username,is_active
+backend,engineering,u1,Alice,
+backend,platform,u1,Alice,
+frontend,,u1,Alice,false
+,,u3,Carol,
+qa,qa,,,
+ops,,u4
+`,
+			wantErrs: ImportErrors{
+				{Line: 3, Message: "parent_team_name of team backend differs from the one at line 2"},
+				{Line: 3, Message: "user u1 is already listed in team backend at line 2"},
+				{Line: 4, Message: "user u1 differs from the one at line 2"},
+				{Line: 5, Message: "team_name is required"},
+				{Line: 6, Message: "team qa cannot be its own parent"},
+				{Line: 7, Message: "expected 5 fields, got 3"},
+			},
+		},
+		{
+			name:     "csv unknown column",
+			format:   TeamImportFormatCSV,
+			data:     "team_name,owner\nbackend,Alice\n",
+			wantErrs: ImportErrors{{Line: 1, Message: "unknown column owner"}},
+		},
+		{
+			name:   "cycle",
+			format: TeamImportFormatCSV,
+			data: `team_name,parent_team_name
+a,b
+b,a
+`,
+			wantErrs: ImportErrors{
+				{Line: 2, Message: "parent of team a makes a cycle"},
+				{Line: 3, Message: "parent of team b makes a cycle"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &importParser{
+				byName: map[string]*importedTeam{},
+				users:  map[string]importedUser{},
+			}
+
+			if tt.format == TeamImportFormatYAML {
+				p.parseYAML([]byte(tt.data))
+			} else {
+				p.parseCSV([]byte(tt.data))
+			}
+			p.checkHierarchy()
+			slices.SortStableFunc(p.errs, func(a, b ImportLineError) int { return a.Line - b.Line })
+
+			if !reflect.DeepEqual(p.errs, tt.wantErrs) {
+				t.Fatalf("errors = %v, want %v", p.errs, tt.wantErrs)
+			}
+			if tt.wantErrs != nil {
+				return
+			}
+
+			var teams []models.TeamImport
+			for _, team := range p.teams {
+				teams = append(teams, team.TeamImport)
+			}
+			if !reflect.DeepEqual(teams, tt.wantTeams) {
+				t.Errorf("teams = %+v, want %+v", teams, tt.wantTeams)
+			}
+		})
+	}
+}
@@ -0,0 +1,375 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/audit"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/tenant"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/logger"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/telegram"
+)
+
+// linkCodeAlphabet has no look-alike characters, codes are typed by hand.
+const (
+	linkCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	linkCodeLength   = 8
+)
+
+// TelegramClient is the part of the Bot API the bot works with.
+type TelegramClient interface {
+	GetUpdates(ctx context.Context, offset int64, pollTimeout time.Duration) ([]telegram.Update, error)
+	SendMessage(ctx context.Context, chatID int64, text string) error
+}
+
+type TelegramService struct {
+	telegramRepo repo.Telegram
+	codeTTL      time.Duration
+	botUsername  string // optional
+}
+
+func NewTelegramService(telegramRepo repo.Telegram, codeTTL time.Duration, botUsername string) *TelegramService {
+	return &TelegramService{
+		telegramRepo: telegramRepo,
+		codeTTL:      codeTTL,
+		botUsername:  botUsername,
+	}
+}
+
+// IssueLinkCode issues a one-time code the user links a telegram chat with,
+// previous codes of the user are no longer valid.
+func (s *TelegramService) IssueLinkCode(ctx context.Context, userID string) (*TelegramLinkCodeOutput, error) {
+	code, err := generateLinkCode()
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt, err := s.telegramRepo.SetTelegramLinkCode(ctx, userID, hashAPIKey(code), s.codeTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	output := TelegramLinkCodeOutput{
+		UserID:    userID,
+		Code:      code,
+		Command:   "/link " + code,
+		ExpiresAt: expiresAt,
+	}
+
+	if s.botUsername != "" {
+		output.URL = fmt.Sprintf("https://t.me/%s?start=%s", s.botUsername, code)
+	}
+
+	return &output, nil
+}
+
+func generateLinkCode() (string, error) {
+	buf := make([]byte, linkCodeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate link code: %w", err)
+	}
+
+	for i, b := range buf {
+		buf[i] = linkCodeAlphabet[int(b)%len(linkCodeAlphabet)]
+	}
+
+	return string(buf), nil
+}
+
+// defaultTelegramTemplates are the assignment messages of the bot.
+var defaultTelegramTemplates = map[string]string{
+	models.EventPullRequestCreated: `You are assigned to review "{{.PullRequest.PullRequestName}}" ` +
+		`({{.PullRequest.PullRequestID}}) by {{.PullRequest.AuthorID}}.`,
+	models.EventReviewerReassigned: `You are assigned to review "{{.PullRequest.PullRequestName}}" ` +
+		`({{.PullRequest.PullRequestID}}) by {{.PullRequest.AuthorID}} instead of {{.OldReviewerID}}.`,
+}
+
+// TelegramSender sends notifications to linked telegram chats of their
+// recipients.
+type TelegramSender struct {
+	client TelegramClient
+}
+
+func NewTelegramSender(client TelegramClient) *TelegramSender {
+	return &TelegramSender{client: client}
+}
+
+func (s *TelegramSender) Send(ctx context.Context, notification models.Notification, data NotificationData) error {
+	if notification.TelegramChat == 0 {
+		return errors.New("recipient has no linked telegram chat")
+	}
+
+	text, ok := defaultTelegramTemplates[data.EventType]
+	if !ok {
+		return fmt.Errorf("%s is not sent to telegram", data.EventType)
+	}
+
+	var message strings.Builder
+	if err := renderTemplate(&message, data.EventType, text, data); err != nil {
+		return fmt.Errorf("failed to render message: %w", err)
+	}
+
+	return s.client.SendMessage(ctx, notification.TelegramChat, message.String())
+}
+
+const telegramHelp = `Commands:
+/link <code> - link this chat to your account with a code issued by the API
+/myreviews - open pull requests you review
+/vacation <YYYY-MM-DD>|off - get no new reviews until the day, or again now
+/reassign <pr> - pass your review of the pull request to a teammate
+/unlink - unlink this chat`
+
+type TelegramBotConfig struct {
+	PollTimeout    time.Duration
+	RetryDelay     time.Duration // after failed polling
+	LockRetryDelay time.Duration // while another instance polls the bot
+}
+
+// TelegramBot long polls messages of the bot and runs commands of linked
+// users on their behalf. Only the instance holding the polling lock polls the
+// bot, others wait to take over once it stops.
+type TelegramBot struct {
+	client       TelegramClient
+	telegramRepo repo.Telegram
+	userService  User
+	prService    PullRequest
+	cfg          TelegramBotConfig
+	log          logger.Logger
+}
+
+func NewTelegramBot(client TelegramClient, telegramRepo repo.Telegram, userService User, prService PullRequest, cfg TelegramBotConfig, log logger.Logger) *TelegramBot {
+	if cfg.RetryDelay <= 0 {
+		cfg.RetryDelay = 5 * time.Second
+	}
+	if cfg.LockRetryDelay <= 0 {
+		cfg.LockRetryDelay = 30 * time.Second
+	}
+
+	return &TelegramBot{
+		client:       client,
+		telegramRepo: telegramRepo,
+		userService:  userService,
+		prService:    prService,
+		cfg:          cfg,
+		log:          log,
+	}
+}
+
+// Run polls the bot while holding the polling lock until ctx is done.
+func (b *TelegramBot) Run(ctx context.Context) {
+	waiting := false
+	for ctx.Err() == nil {
+		unlock, ok, err := b.telegramRepo.LockTelegramPolling(ctx)
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				return
+			}
+			b.log.Warn("failed to take telegram polling lock", map[string]any{"error": err})
+		case !ok:
+			if !waiting {
+				b.log.Info("telegram bot is polled by another instance, waiting for it to stop")
+				waiting = true
+			}
+		default:
+			b.poll(ctx)
+			unlock()
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(b.cfg.LockRetryDelay):
+		}
+	}
+}
+
+// poll gets updates of the bot and runs their commands until ctx is done.
+func (b *TelegramBot) poll(ctx context.Context) {
+	var offset int64
+
+	for ctx.Err() == nil {
+		updates, err := b.client.GetUpdates(ctx, offset, b.cfg.PollTimeout)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			b.log.Warn("failed to get telegram updates", map[string]any{"error": err})
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(b.cfg.RetryDelay):
+			}
+			continue
+		}
+
+		for _, update := range updates {
+			offset = update.UpdateID + 1
+
+			message := update.Message
+			if message == nil || message.Chat.Type != "private" || !strings.HasPrefix(message.Text, "/") {
+				continue
+			}
+
+			reply := b.handle(ctx, message.Chat.ID, message.Text)
+			if err := b.client.SendMessage(ctx, message.Chat.ID, reply); err != nil {
+				b.log.Warn("failed to reply in telegram", map[string]any{"chat_id": message.Chat.ID, "error": err})
+			}
+		}
+	}
+}
+
+// handle runs the command and returns the reply.
+func (b *TelegramBot) handle(ctx context.Context, chatID int64, text string) string {
+	fields := strings.Fields(text)
+	command, _, _ := strings.Cut(strings.ToLower(fields[0]), "@") // "/cmd@bot" form
+	args := fields[1:]
+
+	switch command {
+	case "/start", "/link":
+		if len(args) != 1 {
+			return telegramHelp
+		}
+		return b.link(ctx, chatID, args[0])
+	case "/myreviews", "/vacation", "/reassign", "/unlink":
+	default:
+		return telegramHelp
+	}
+
+	link, err := b.telegramRepo.GetTelegramLink(ctx, chatID)
+	if err != nil {
+		if errors.Is(err, repoerrs.ErrNotFound) {
+			return "This chat is not linked. Get a link code from the API and send /link <code>."
+		}
+		return b.failed(command, err)
+	}
+
+	ctx = audit.WithActor(tenant.WithID(ctx, link.TenantID), "telegram:"+link.UserID)
+
+	switch command {
+	case "/myreviews":
+		return b.myReviews(ctx, link.UserID)
+	case "/vacation":
+		if len(args) != 1 {
+			return "Usage: /vacation <YYYY-MM-DD>|off"
+		}
+		return b.vacation(ctx, link.UserID, args[0])
+	case "/reassign":
+		if len(args) != 1 {
+			return "Usage: /reassign <pr>"
+		}
+		return b.reassign(ctx, link.UserID, args[0])
+	case "/unlink":
+		if err := b.telegramRepo.DeleteTelegramLink(ctx, chatID); err != nil && !errors.Is(err, repoerrs.ErrNotFound) {
+			return b.failed(command, err)
+		}
+		return "This chat is unlinked."
+	}
+
+	return telegramHelp
+}
+
+func (b *TelegramBot) link(ctx context.Context, chatID int64, code string) string {
+	link, err := b.telegramRepo.RedeemTelegramLinkCode(ctx, hashAPIKey(strings.ToUpper(code)), chatID)
+	if err != nil {
+		if errors.Is(err, repoerrs.ErrNotFound) {
+			return "The code is invalid or expired, get a new one from the API."
+		}
+		return b.failed("/link", err)
+	}
+
+	return fmt.Sprintf("This chat is linked to %s. Send /help to see commands.", link.UserID)
+}
+
+func (b *TelegramBot) myReviews(ctx context.Context, userID string) string {
+	reviews, err := b.userService.GetReview(ctx, userID)
+	if err != nil {
+		return b.failed("/myreviews", err)
+	}
+
+	var reply strings.Builder
+	for _, pr := range reviews.PullRequests {
+		if pr.Status != models.PullRequestStatusOpen {
+			continue
+		}
+		fmt.Fprintf(&reply, "\n- %s (%s) by %s", pr.PullRequestName, pr.PullRequestID, pr.AuthorID)
+	}
+
+	if reply.Len() == 0 {
+		return "You have no open reviews."
+	}
+
+	return "Your open reviews:" + reply.String()
+}
+
+// vacation makes the user away until the day, so the user is activated again
+// once it comes, or ends the vacation with "off".
+func (b *TelegramBot) vacation(ctx context.Context, userID, day string) string {
+	if day == "off" {
+		if _, err := b.userService.SetIsActive(ctx, userID, true); err != nil {
+			if errors.Is(err, repoerrs.ErrNotFound) {
+				return "Your user no longer exists."
+			}
+			return b.failed("/vacation", err)
+		}
+
+		return "Vacation is off: you get new reviews again."
+	}
+
+	until, err := time.Parse(time.DateOnly, day)
+	if err != nil {
+		return "Usage: /vacation <YYYY-MM-DD>|off"
+	}
+
+	if _, err := b.userService.SetAwayUntil(ctx, userID, until); err != nil {
+		switch {
+		case errors.Is(err, repoerrs.ErrNotFound):
+			return "Your user no longer exists."
+		case errors.Is(err, ErrAwayDayPassed):
+			return fmt.Sprintf("Cannot be on vacation until %s: %s.", day, err)
+		default:
+			return b.failed("/vacation", err)
+		}
+	}
+
+	return fmt.Sprintf("Vacation is on until %s: you get no new reviews until then.", until.Format(time.DateOnly))
+}
+
+func (b *TelegramBot) reassign(ctx context.Context, userID, prID string) string {
+	output, err := b.prService.ReassignReviewer(ctx, prID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repoerrs.ErrNotFound):
+			return fmt.Sprintf("Pull request %s is not found.", prID)
+		case errors.Is(err, repoerrs.ErrNotAssigned),
+			errors.Is(err, repoerrs.ErrNoCandidate),
+			errors.Is(err, repoerrs.ErrReassignAfterMerge),
+			errors.Is(err, repoerrs.ErrReassignAfterClose),
+			errors.Is(err, repoerrs.ErrUserNotFound):
+			return fmt.Sprintf("Cannot reassign %s: %s.", prID, err)
+		default:
+			return b.failed("/reassign", err)
+		}
+	}
+
+	return fmt.Sprintf("%s is reassigned to %s.", prID, output.ReplacedBy)
+}
+
+func (b *TelegramBot) failed(command string, err error) string {
+	b.log.Error("failed to run telegram command", map[string]any{
+		"command": command,
+		"error":   err,
+	})
+
+	return "Something went wrong, try again later."
+}
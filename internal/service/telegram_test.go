@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/telegram"
+)
+
+type fakeTelegramRepo struct {
+	repo.Telegram // not called by the tests
+
+	locked   bool // by another instance
+	unlocked bool
+}
+
+func (r *fakeTelegramRepo) GetTelegramLink(_ context.Context, chatID int64) (*models.TelegramLink, error) {
+	return &models.TelegramLink{TenantID: 1, UserID: "u2", ChatID: chatID}, nil
+}
+
+func (r *fakeTelegramRepo) LockTelegramPolling(context.Context) (func(), bool, error) {
+	if r.locked {
+		return nil, false, nil
+	}
+	return func() { r.unlocked = true }, true, nil
+}
+
+type fakeTelegramClient struct {
+	polls int
+}
+
+func (c *fakeTelegramClient) GetUpdates(ctx context.Context, _ int64, _ time.Duration) ([]telegram.Update, error) {
+	c.polls++
+
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (c *fakeTelegramClient) SendMessage(context.Context, int64, string) error {
+	return nil
+}
+
+// fakeUserService records changes of the activity of users.
+type fakeUserService struct {
+	User // not called by the tests
+
+	calls []string
+}
+
+func (s *fakeUserService) SetIsActive(_ context.Context, userID string, isActive bool) (*UserSetIsActiveOutput, error) {
+	if isActive {
+		s.calls = append(s.calls, "activate "+userID)
+	} else {
+		s.calls = append(s.calls, "deactivate "+userID)
+	}
+	return &UserSetIsActiveOutput{}, nil
+}
+
+func (s *fakeUserService) SetAwayUntil(_ context.Context, userID string, until time.Time) (*UserSetIsActiveOutput, error) {
+	s.calls = append(s.calls, "away "+userID+" "+until.Format(time.DateOnly))
+	return &UserSetIsActiveOutput{}, nil
+}
+
+func TestTelegramBotVacation(t *testing.T) {
+	tests := []struct {
+		text      string
+		wantReply string
+		wantCalls []string
+	}{
+		{
+			text:      "/vacation 2099-01-15",
+			wantReply: "Vacation is on until 2099-01-15: you get no new reviews until then.",
+			wantCalls: []string{"away u2 2099-01-15"},
+		},
+		{
+			text:      "/vacation off",
+			wantReply: "Vacation is off: you get new reviews again.",
+			wantCalls: []string{"activate u2"},
+		},
+		{
+			text:      "/vacation on",
+			wantReply: "Usage: /vacation <YYYY-MM-DD>|off",
+		},
+		{
+			text:      "/vacation",
+			wantReply: "Usage: /vacation <YYYY-MM-DD>|off",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			userService := &fakeUserService{}
+			bot := NewTelegramBot(&fakeTelegramClient{}, &fakeTelegramRepo{}, userService, nil, TelegramBotConfig{}, nopLogger{})
+
+			if reply := bot.handle(context.Background(), 42, tt.text); reply != tt.wantReply {
+				t.Errorf("reply = %q, want %q", reply, tt.wantReply)
+			}
+
+			if !reflect.DeepEqual(userService.calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", userService.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestTelegramBotPollingLock(t *testing.T) {
+	tests := []struct {
+		name         string
+		locked       bool
+		wantPolled   bool
+		wantUnlocked bool
+	}{
+		{name: "holds the lock", wantPolled: true, wantUnlocked: true},
+		{name: "polled by another instance", locked: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			telegramRepo := &fakeTelegramRepo{locked: tt.locked}
+			client := &fakeTelegramClient{}
+			bot := NewTelegramBot(client, telegramRepo, &fakeUserService{}, nil, TelegramBotConfig{LockRetryDelay: time.Millisecond}, nopLogger{})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			bot.Run(ctx)
+
+			if polled := client.polls > 0; polled != tt.wantPolled {
+				t.Errorf("polled = %v, want %v", polled, tt.wantPolled)
+			}
+			if telegramRepo.unlocked != tt.wantUnlocked {
+				t.Errorf("unlocked = %v, want %v", telegramRepo.unlocked, tt.wantUnlocked)
+			}
+		})
+	}
+}
+
+func TestTelegramBotCommands(t *testing.T) {
+	tests := []struct {
+		text        string
+		reassignErr error
+		wantReply   string
+		wantCalls   []string
+	}{
+		{
+			text:      "/reassign pr-1",
+			wantReply: "pr-1 is reassigned to u9.",
+			wantCalls: []string{"reassign pr-1 u2"},
+		},
+		{
+			text:      "/REASSIGN@pr_bot pr-1",
+			wantReply: "pr-1 is reassigned to u9.",
+			wantCalls: []string{"reassign pr-1 u2"},
+		},
+		{
+			text:        "/reassign pr-1",
+			reassignErr: repoerrs.ErrNotAssigned,
+			wantReply:   "Cannot reassign pr-1: " + repoerrs.ErrNotAssigned.Error() + ".",
+			wantCalls:   []string{"reassign pr-1 u2"},
+		},
+		{
+			text:      "/reassign pr-1 u5",
+			wantReply: "Usage: /reassign <pr>",
+		},
+		{
+			text:      "/link",
+			wantReply: telegramHelp,
+		},
+		{
+			text:      "/merge pr-1",
+			wantReply: telegramHelp,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			prService := &fakePullRequestService{reassignErr: tt.reassignErr}
+			bot := NewTelegramBot(&fakeTelegramClient{}, &fakeTelegramRepo{}, &fakeUserService{}, prService, TelegramBotConfig{}, nopLogger{})
+
+			if reply := bot.handle(context.Background(), 42, tt.text); reply != tt.wantReply {
+				t.Errorf("reply = %q, want %q", reply, tt.wantReply)
+			}
+
+			if !reflect.DeepEqual(prService.calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", prService.calls, tt.wantCalls)
+			}
+		})
+	}
+}
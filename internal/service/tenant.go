@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo"
+)
+
+type TenantService struct {
+	tenantRepo repo.Tenant
+}
+
+func NewTenantService(tenantRepo repo.Tenant) *TenantService {
+	return &TenantService{tenantRepo: tenantRepo}
+}
+
+// AddTenant creates the tenant with one admin and one user key. Plain keys are
+// returned only here, the database keeps their hashes.
+func (s *TenantService) AddTenant(ctx context.Context, tenantName string) (*TenantAddOutput, error) {
+	roles := []string{models.RoleAdmin, models.RoleUser}
+
+	plainKeys := make([]string, 0, len(roles))
+	keys := make([]models.APIKey, 0, len(roles))
+	for _, role := range roles {
+		key, err := generateSecret()
+		if err != nil {
+			return nil, err
+		}
+
+		plainKeys = append(plainKeys, key)
+		keys = append(keys, models.APIKey{
+			KeyHash: hashAPIKey(key),
+			Role:    role,
+		})
+	}
+
+	tenant, createdKeys, err := s.tenantRepo.CreateTenant(ctx, tenantName, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	output := TenantAddOutput{TenantName: tenant.TenantName}
+	for i, key := range createdKeys {
+		output.APIKeys = append(output.APIKeys, TenantOutputAPIKey{
+			KeyID:  key.ID,
+			Role:   key.Role,
+			APIKey: plainKeys[i],
+		})
+	}
+
+	return &output, nil
+}
+
+func (s *TenantService) AddAPIKey(ctx context.Context, tenantName, role string) (*TenantAddAPIKeyOutput, error) {
+	tenant, err := s.tenantRepo.GetTenantByName(ctx, tenantName)
+	if err != nil {
+		return nil, err
+	}
+
+	plainKey, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := s.tenantRepo.CreateAPIKey(ctx, models.APIKey{
+		TenantID: tenant.ID,
+		KeyHash:  hashAPIKey(plainKey),
+		Role:     role,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	output := TenantAddAPIKeyOutput{
+		TenantName: tenant.TenantName,
+		APIKey: TenantOutputAPIKey{
+			KeyID:  key.ID,
+			Role:   key.Role,
+			APIKey: plainKey,
+		},
+	}
+
+	return &output, nil
+}
+
+func (s *TenantService) RevokeAPIKey(ctx context.Context, keyID int) (*TenantRevokeAPIKeyOutput, error) {
+	key, _, err := s.tenantRepo.RevokeAPIKey(ctx, keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	output := TenantRevokeAPIKeyOutput{
+		KeyID:     key.ID,
+		Role:      key.Role,
+		RevokedAt: *key.RevokedAt,
+	}
+
+	return &output, nil
+}
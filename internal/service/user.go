@@ -2,11 +2,22 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/MatTwix/Pull-Request-Assigner/internal/metrics"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
 	"github.com/MatTwix/Pull-Request-Assigner/internal/repo"
 )
 
+const quietHoursLayout = "15:04"
+
+var (
+	ErrInvalidPreferences = errors.New("invalid notification preferences")
+	ErrAwayDayPassed      = errors.New("away day must be in the future")
+)
+
 type UserService struct {
 	userRepo repo.User
 }
@@ -21,19 +32,60 @@ func (s *UserService) SetIsActive(ctx context.Context, userID string, isActive b
 		return nil, err
 	}
 
-	outputUser := UserSetIsActiveOutputUser{
+	output := UserSetIsActiveOutput{User: toUserSetIsActiveOutputUser(*user)}
+
+	if !alreadyUpdated {
+		metrics.UserStatusChanges.WithLabelValues("setIsActive").Inc()
+	}
+
+	return &output, nil
+}
+
+// SetAwayUntil deactivates the user until the day, UTC, the user is activated
+// again once it comes. Activating the user earlier cancels it.
+func (s *UserService) SetAwayUntil(ctx context.Context, userID string, until time.Time) (*UserSetIsActiveOutput, error) {
+	until = time.Date(until.Year(), until.Month(), until.Day(), 0, 0, 0, 0, time.UTC)
+	if !until.After(time.Now().UTC()) {
+		return nil, ErrAwayDayPassed
+	}
+
+	user, err := s.userRepo.SetAwayUntil(ctx, userID, until)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.UserStatusChanges.WithLabelValues("setAwayUntil").Inc()
+
+	return &UserSetIsActiveOutput{User: toUserSetIsActiveOutputUser(*user)}, nil
+}
+
+func toUserSetIsActiveOutputUser(user models.User) UserSetIsActiveOutputUser {
+	output := UserSetIsActiveOutputUser{
 		UserID:   user.UserID,
 		Username: user.Username,
 		TeamName: user.TeamName,
 		IsActive: user.IsActive,
+		Version:  user.Version,
 	}
 
-	output := UserSetIsActiveOutput{User: outputUser}
+	if user.AwayUntil != nil {
+		output.AwayUntil = user.AwayUntil.Format(time.DateOnly)
+	}
 
-	if !alreadyUpdated {
-		metrics.UserStatusChanges.WithLabelValues("setIsActive").Inc()
+	return output
+}
+
+func (s *UserService) GetUser(ctx context.Context, userID string) (*UserGetOutput, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
 	}
 
+	output := UserGetOutput{User: UserGetOutputUser{
+		UserSetIsActiveOutputUser: toUserSetIsActiveOutputUser(*user),
+		Email:                     user.Email,
+	}}
+
 	return &output, nil
 }
 
@@ -43,15 +95,129 @@ func (s *UserService) GetReview(ctx context.Context, userID string) (*UserGetRev
 		return nil, err
 	}
 
+	teams, err := s.userRepo.GetUserTeams(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
 	output := UserGetReviewOutput{UserID: userID}
+	for _, team := range teams {
+		output.Teams = append(output.Teams, UserOutputTeam{
+			TeamName:  team.TeamName,
+			IsPrimary: team.IsPrimary,
+		})
+	}
+
 	for _, repository := range repositories {
 		output.PullRequests = append(output.PullRequests, UserReviewOutputPR{
 			PullRequestID:   repository.PullRequestID,
 			PullRequestName: repository.PullRequestName,
 			AuthorID:        repository.AuthorID,
+			TeamName:        repository.TeamName,
 			Status:          repository.Status,
 		})
 	}
 
 	return &output, nil
 }
+
+func (s *UserService) SetPrimaryTeam(ctx context.Context, userID, teamName string) (*UserSetPrimaryTeamOutput, error) {
+	user, err := s.userRepo.SetPrimaryTeam(ctx, userID, teamName)
+	if err != nil {
+		return nil, err
+	}
+
+	output := UserSetPrimaryTeamOutput{User: toUserSetIsActiveOutputUser(*user)}
+
+	return &output, nil
+}
+
+func (s *UserService) SetEmail(ctx context.Context, userID, email string) (*UserSetEmailOutput, error) {
+	user, err := s.userRepo.SetEmail(ctx, userID, email)
+	if err != nil {
+		return nil, err
+	}
+
+	output := UserSetEmailOutput{User: UserSetEmailOutputUser{
+		UserID:   user.UserID,
+		Username: user.Username,
+		Email:    user.Email,
+		Version:  user.Version,
+	}}
+
+	return &output, nil
+}
+
+func (s *UserService) GetNotificationPreferences(ctx context.Context, userID string) (*UserNotificationPreferencesOutput, error) {
+	preferences, err := s.userRepo.GetNotificationPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return toNotificationPreferencesOutput(*preferences), nil
+}
+
+// SetNotificationPreferences replaces the notification preferences of the
+// user, they apply to notifications not sent yet.
+func (s *UserService) SetNotificationPreferences(ctx context.Context, input NotificationPreferencesInput) (*UserNotificationPreferencesOutput, error) {
+	preferences := models.NotificationPreferences{
+		UserID:     input.UserID,
+		Channels:   input.Channels,
+		EventTypes: input.EventTypes,
+		Timezone:   input.Timezone,
+	}
+
+	if preferences.Timezone == "" {
+		preferences.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(preferences.Timezone); err != nil {
+		return nil, fmt.Errorf("%w: unknown timezone %s", ErrInvalidPreferences, preferences.Timezone)
+	}
+
+	if input.QuietHours != nil {
+		start, err := time.Parse(quietHoursLayout, input.QuietHours.Start)
+		if err != nil {
+			return nil, fmt.Errorf("%w: quiet hours start must be HH:MM", ErrInvalidPreferences)
+		}
+
+		end, err := time.Parse(quietHoursLayout, input.QuietHours.End)
+		if err != nil {
+			return nil, fmt.Errorf("%w: quiet hours end must be HH:MM", ErrInvalidPreferences)
+		}
+
+		if start.Equal(end) {
+			return nil, fmt.Errorf("%w: quiet hours are empty", ErrInvalidPreferences)
+		}
+
+		preferences.QuietHours = &models.QuietHours{
+			Start: start.Format(quietHoursLayout),
+			End:   end.Format(quietHoursLayout),
+		}
+	}
+
+	saved, err := s.userRepo.SetNotificationPreferences(ctx, preferences)
+	if err != nil {
+		return nil, err
+	}
+
+	return toNotificationPreferencesOutput(*saved), nil
+}
+
+func toNotificationPreferencesOutput(preferences models.NotificationPreferences) *UserNotificationPreferencesOutput {
+	output := NotificationPreferencesOutput{
+		UserID:     preferences.UserID,
+		Channels:   preferences.Channels,
+		EventTypes: preferences.EventTypes,
+		Timezone:   preferences.Timezone,
+		UpdatedAt:  preferences.UpdatedAt,
+	}
+
+	if preferences.QuietHours != nil {
+		output.QuietHours = &QuietHours{
+			Start: preferences.QuietHours.Start,
+			End:   preferences.QuietHours.End,
+		}
+	}
+
+	return &UserNotificationPreferencesOutput{Preferences: output}
+}
@@ -0,0 +1,332 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/audit"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/metrics"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/tenant"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/secretbox"
+)
+
+// Results of processed version control system webhooks.
+const (
+	VCSResultCreated            = "created"
+	VCSResultExists             = "exists" // opened pull request is already known
+	VCSResultMerged             = "merged"
+	VCSResultClosed             = "closed"
+	VCSResultReopened           = "reopened"
+	VCSResultAlreadyMerged      = "already_merged"
+	VCSResultUnknownPullRequest = "unknown_pull_request"
+	VCSResultUnknownAuthor      = "unknown_author" // login is not mapped to a user, the pull request is skipped
+	VCSResultIgnored            = "ignored"        // event or action without effect
+)
+
+// vcsDeliveryLease is how long a received delivery is processed by one
+// instance. A delivery left unfinished, e.g. by a crash between the change and
+// recording its result, is processed again by a redelivery after the lease.
+const vcsDeliveryLease = 5 * time.Minute
+
+var (
+	ErrInvalidSignature  = errors.New("invalid webhook signature")
+	ErrInvalidPayload    = errors.New("invalid webhook payload")
+	ErrAPITokensDisabled = errors.New("api tokens are disabled, vcs.token_key is not configured")
+
+	ErrInvalidPullRequestID = errors.New("pull request id is not of the provider format")
+)
+
+// vcsChange is what a webhook of any provider asks to do with a pull request.
+type vcsChange int
+
+const (
+	vcsChangeNone vcsChange = iota
+	vcsChangeOpen
+	vcsChangeMerge
+	vcsChangeClose
+	vcsChangeReopen
+)
+
+// vcsPullRequest is a pull request as described by the provider.
+type vcsPullRequest struct {
+	ID             string // "<repository>#<number>" on GitHub, "<repository>!<iid>" on GitLab
+	Name           string
+	AuthorLogin    string
+	AuthorID       string // id of the author's account, resolved instead of the login when set
+	RepositoryName string // full path of the repository in the provider
+}
+
+type VCSService struct {
+	vcsRepo        repo.VCS
+	tenantRepo     repo.Tenant
+	repositoryRepo repo.Repository
+	prService      PullRequest
+	tokenBox       *secretbox.Box
+}
+
+func NewVCSService(vcsRepo repo.VCS, tenantRepo repo.Tenant, repositoryRepo repo.Repository, prService PullRequest, tokenBox *secretbox.Box) *VCSService {
+	return &VCSService{
+		vcsRepo:        vcsRepo,
+		tenantRepo:     tenantRepo,
+		repositoryRepo: repositoryRepo,
+		prService:      prService,
+		tokenBox:       tokenBox,
+	}
+}
+
+// SetWebhookSecret sets the secret the provider signs webhooks of the tenant
+// with. The secret is returned only here and generated when empty.
+func (s *VCSService) SetWebhookSecret(ctx context.Context, provider, secret string) (*VCSWebhookSecretOutput, error) {
+	if secret == "" {
+		generated, err := generateSecret()
+		if err != nil {
+			return nil, err
+		}
+		secret = generated
+	}
+
+	if err := s.vcsRepo.SetVCSWebhookSecret(ctx, provider, secret); err != nil {
+		return nil, err
+	}
+
+	return &VCSWebhookSecretOutput{Provider: provider, Secret: secret}, nil
+}
+
+// SetAPIToken sets the token open pull requests are reconciled with and, on
+// GitHub, reviewers assigned here are requested with. An empty token stops
+// both. The token is stored encrypted.
+func (s *VCSService) SetAPIToken(ctx context.Context, provider, token string) (*VCSAPITokenOutput, error) {
+	sealed := ""
+	if token != "" {
+		if s.tokenBox == nil {
+			return nil, ErrAPITokensDisabled
+		}
+
+		var err error
+		if sealed, err = s.tokenBox.Seal(token); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.vcsRepo.SetVCSAPIToken(ctx, provider, sealed); err != nil {
+		return nil, err
+	}
+
+	return &VCSAPITokenOutput{
+		Provider:       provider,
+		ReviewerSync:   token != "" && provider == models.VCSProviderGitHub,
+		Reconciliation: token != "",
+	}, nil
+}
+
+func (s *VCSService) SetIdentity(ctx context.Context, input VCSIdentityInput) (*VCSOutputIdentity, error) {
+	identity, err := s.vcsRepo.SetVCSIdentity(ctx, models.VCSIdentity{
+		Provider:  input.Provider,
+		Login:     input.Login,
+		AccountID: input.AccountID,
+		UserID:    input.UserID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	output := toVCSOutputIdentity(*identity)
+	return &output, nil
+}
+
+func (s *VCSService) DeleteIdentity(ctx context.Context, provider, login string) error {
+	return s.vcsRepo.DeleteVCSIdentity(ctx, provider, login)
+}
+
+func (s *VCSService) GetIdentities(ctx context.Context, provider string) (*VCSIdentitiesOutput, error) {
+	identities, err := s.vcsRepo.GetVCSIdentities(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	output := VCSIdentitiesOutput{Identities: []VCSOutputIdentity{}}
+	for _, identity := range identities {
+		output.Identities = append(output.Identities, toVCSOutputIdentity(identity))
+	}
+
+	return &output, nil
+}
+
+// openAPIToken decrypts the api token stored by SetAPIToken.
+func openAPIToken(tokenBox *secretbox.Box, sealed string) (string, error) {
+	if tokenBox == nil {
+		return "", ErrAPITokensDisabled
+	}
+
+	token, err := tokenBox.Open(sealed)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt api token, it must be set again: %w", err)
+	}
+
+	return token, nil
+}
+
+func toVCSOutputIdentity(identity models.VCSIdentity) VCSOutputIdentity {
+	return VCSOutputIdentity{
+		Provider:  identity.Provider,
+		Login:     identity.Login,
+		AccountID: identity.AccountID,
+		UserID:    identity.UserID,
+		CreatedAt: identity.CreatedAt,
+	}
+}
+
+// webhookContext resolves the tenant of the webhook, changes made by it are
+// recorded in the audit log on behalf of the provider. An unknown tenant has
+// no secret to verify the webhook with, so it is reported as an invalid
+// signature and does not reveal which tenants exist.
+func (s *VCSService) webhookContext(ctx context.Context, provider, tenantName string) (context.Context, error) {
+	if tenantName != "" {
+		t, err := s.tenantRepo.GetTenantByName(ctx, tenantName)
+		if errors.Is(err, repoerrs.ErrNotFound) {
+			return nil, ErrInvalidSignature
+		} else if err != nil {
+			return nil, err
+		}
+		ctx = tenant.WithID(ctx, t.ID)
+	}
+
+	return audit.WithActor(ctx, "vcs:"+provider), nil
+}
+
+// processDelivery applies the change once per delivery id. A failed delivery
+// is forgotten, so the provider's redelivery is processed again, as is one
+// left unfinished for longer than vcsDeliveryLease.
+func (s *VCSService) processDelivery(ctx context.Context, delivery models.VCSDelivery, change vcsChange, pr vcsPullRequest) (*VCSWebhookOutput, error) {
+	output := VCSWebhookOutput{
+		DeliveryID:    delivery.DeliveryID,
+		Event:         delivery.Event,
+		Action:        delivery.Action,
+		PullRequestID: delivery.PullRequestID,
+	}
+
+	recorded, claimed, err := s.vcsRepo.ClaimVCSDelivery(ctx, delivery, vcsDeliveryLease)
+	if err != nil {
+		return nil, err
+	}
+
+	if !claimed {
+		output.Result = recorded.Result
+		output.Duplicate = true
+
+		metrics.VCSWebhooks.WithLabelValues(delivery.Provider, "duplicate").Inc()
+		return &output, nil
+	}
+
+	result, err := s.applyChange(ctx, delivery.Provider, change, pr)
+	if err != nil {
+		if releaseErr := s.vcsRepo.ReleaseVCSDelivery(context.WithoutCancel(ctx), delivery.Provider, delivery.DeliveryID); releaseErr != nil {
+			return nil, errors.Join(err, releaseErr)
+		}
+
+		return nil, err
+	}
+
+	if err := s.vcsRepo.FinishVCSDelivery(context.WithoutCancel(ctx), delivery.Provider, delivery.DeliveryID, result); err != nil {
+		return nil, err
+	}
+
+	output.Result = result
+
+	metrics.VCSWebhooks.WithLabelValues(delivery.Provider, result).Inc()
+	return &output, nil
+}
+
+func (s *VCSService) applyChange(ctx context.Context, provider string, change vcsChange, pr vcsPullRequest) (string, error) {
+	switch change {
+	case vcsChangeOpen:
+		return s.openPullRequest(ctx, provider, pr)
+
+	case vcsChangeMerge:
+		_, err := s.prService.MergePR(ctx, pr.ID)
+		switch {
+		case errors.Is(err, repoerrs.ErrNotFound):
+			return VCSResultUnknownPullRequest, nil
+		case err != nil:
+			return "", fmt.Errorf("failed to merge pull request: %w", err)
+		}
+		return VCSResultMerged, nil
+
+	case vcsChangeClose:
+		_, err := s.prService.ClosePR(ctx, pr.ID)
+		switch {
+		case errors.Is(err, repoerrs.ErrNotFound):
+			return VCSResultUnknownPullRequest, nil
+		case errors.Is(err, repoerrs.ErrPRMerged):
+			return VCSResultAlreadyMerged, nil
+		case err != nil:
+			return "", fmt.Errorf("failed to close pull request: %w", err)
+		}
+		return VCSResultClosed, nil
+
+	case vcsChangeReopen:
+		_, err := s.prService.ReopenPR(ctx, pr.ID)
+		switch {
+		case errors.Is(err, repoerrs.ErrNotFound):
+			// opened before the integration was set up
+			return s.openPullRequest(ctx, provider, pr)
+		case errors.Is(err, repoerrs.ErrPRMerged):
+			return VCSResultAlreadyMerged, nil
+		case err != nil:
+			return "", fmt.Errorf("failed to reopen pull request: %w", err)
+		}
+		return VCSResultReopened, nil
+	}
+
+	return VCSResultIgnored, nil
+}
+
+// openPullRequest creates the pull request of the mapped author. Pull
+// requests of authors not mapped to a user are skipped: a login is chosen by
+// whoever owns the provider account and must not be trusted as a user id. The
+// repository is passed only when it is registered, otherwise the author's
+// primary team reviews it.
+func (s *VCSService) openPullRequest(ctx context.Context, provider string, pr vcsPullRequest) (string, error) {
+	var authorID string
+	var err error
+	if pr.AuthorID != "" {
+		authorID, err = s.vcsRepo.GetVCSUserIDByAccount(ctx, provider, pr.AuthorID)
+	} else {
+		authorID, err = s.vcsRepo.GetVCSUserID(ctx, provider, pr.AuthorLogin)
+	}
+	if errors.Is(err, repoerrs.ErrNotFound) {
+		return VCSResultUnknownAuthor, nil
+	} else if err != nil {
+		return "", err
+	}
+
+	repositoryName := pr.RepositoryName
+	if _, err := s.repositoryRepo.GetRepositoryByName(ctx, repositoryName); errors.Is(err, repoerrs.ErrNotFound) {
+		repositoryName = ""
+	} else if err != nil {
+		return "", err
+	}
+
+	_, err = s.prService.CreatePR(ctx, PullRequestCreateInput{
+		PullRequestID:   pr.ID,
+		PullRequestName: pr.Name,
+		AuthorID:        authorID,
+		RepositoryName:  repositoryName,
+		VCSProvider:     provider,
+	})
+	switch {
+	case errors.Is(err, repoerrs.ErrAlreadyExists):
+		return VCSResultExists, nil
+	case errors.Is(err, repoerrs.ErrNotFound):
+		return VCSResultUnknownAuthor, nil
+	case err != nil:
+		return "", fmt.Errorf("failed to create pull request: %w", err)
+	}
+
+	return VCSResultCreated, nil
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/github"
+)
+
+const (
+	gitHubEventPing        = "ping"
+	gitHubEventPullRequest = "pull_request"
+)
+
+type gitHubPullRequestPayload struct {
+	Action      string `json:"action"`
+	PullRequest struct {
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+		Draft  bool   `json:"draft"`
+		Merged bool   `json:"merged"`
+		User   struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	} `json:"pull_request"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+// HandleGitHubWebhook verifies the X-Hub-Signature-256 signature of the
+// delivery and applies pull_request events: opened and ready_for_review create
+// the pull request, closed merges or closes it, reopened opens it again.
+// Drafts are skipped until they are ready for review.
+func (s *VCSService) HandleGitHubWebhook(ctx context.Context, input VCSWebhookInput) (*VCSWebhookOutput, error) {
+	ctx, err := s.webhookContext(ctx, models.VCSProviderGitHub, input.TenantName)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := s.vcsRepo.GetVCSWebhookSecret(ctx, models.VCSProviderGitHub)
+	if errors.Is(err, repoerrs.ErrNotFound) {
+		return nil, ErrInvalidSignature
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !hmac.Equal([]byte(SignWebhookPayload(secret, input.Body)), []byte(input.Signature)) {
+		return nil, ErrInvalidSignature
+	}
+
+	if input.Event != gitHubEventPullRequest {
+		// ping and events of other kinds are acknowledged only
+		return &VCSWebhookOutput{DeliveryID: input.DeliveryID, Event: input.Event, Result: VCSResultIgnored}, nil
+	}
+
+	var payload gitHubPullRequestPayload
+	if err := json.Unmarshal(input.Body, &payload); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPayload, err)
+	}
+
+	if payload.Repository.FullName == "" || payload.PullRequest.Number == 0 || payload.PullRequest.User.Login == "" {
+		return nil, fmt.Errorf("%w: pull request is not described", ErrInvalidPayload)
+	}
+
+	pr := vcsPullRequest{
+		ID:             fmt.Sprintf("%s#%d", payload.Repository.FullName, payload.PullRequest.Number),
+		Name:           payload.PullRequest.Title,
+		AuthorLogin:    payload.PullRequest.User.Login,
+		RepositoryName: payload.Repository.FullName,
+	}
+
+	change := vcsChangeNone
+	switch payload.Action {
+	case "opened":
+		if !payload.PullRequest.Draft {
+			change = vcsChangeOpen
+		}
+	case "ready_for_review":
+		change = vcsChangeOpen
+	case "closed":
+		change = vcsChangeClose
+		if payload.PullRequest.Merged {
+			change = vcsChangeMerge
+		}
+	case "reopened":
+		if !payload.PullRequest.Draft {
+			change = vcsChangeReopen
+		}
+	}
+
+	return s.processDelivery(ctx, models.VCSDelivery{
+		Provider:      models.VCSProviderGitHub,
+		DeliveryID:    input.DeliveryID,
+		Event:         input.Event,
+		Action:        payload.Action,
+		PullRequestID: pr.ID,
+	}, change, pr)
+}
+
+type gitHubVCSClient struct {
+	client *github.Client
+}
+
+// NewGitHubVCSClient reads pull requests "<owner>/<repo>#<number>" from
+// GitHub.
+func NewGitHubVCSClient(client *github.Client) VCSClient {
+	return &gitHubVCSClient{client: client}
+}
+
+// GetPullRequest reports requested reviewers together with the ones who
+// reviewed, GitHub stops listing reviewers as requested once they review.
+func (c *gitHubVCSClient) GetPullRequest(ctx context.Context, token, prID string) (*VCSPullRequestState, error) {
+	repository, number, err := parseGitHubPullRequestID(prID)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, err := c.client.GetPullRequest(ctx, token, repository, number)
+	if err != nil {
+		var apiErr *github.Error
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%w: %s", ErrVCSPullRequestNotFound, prID)
+		}
+		return nil, fmt.Errorf("failed to get pull request: %w", err)
+	}
+
+	state := VCSPullRequestState{
+		Merged: pr.Merged,
+		Closed: pr.State == "closed" && !pr.Merged,
+	}
+	if state.Merged || state.Closed {
+		return &state, nil
+	}
+
+	for _, reviewer := range pr.RequestedReviewers {
+		state.Reviewers = append(state.Reviewers, reviewer.Login)
+	}
+
+	reviews, err := c.client.ListReviews(ctx, token, repository, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reviews: %w", err)
+	}
+
+	for _, review := range reviews {
+		state.Reviewers = append(state.Reviewers, review.User.Login)
+	}
+
+	return &state, nil
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/gitlab"
+)
+
+const gitLabEventMergeRequest = "Merge Request Hook"
+
+type gitLabMergeRequestPayload struct {
+	Project struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"project"`
+	ObjectAttributes struct {
+		IID      int    `json:"iid"`
+		AuthorID int    `json:"author_id"`
+		Title    string `json:"title"`
+		Action   string `json:"action"`
+		Draft    bool   `json:"draft"`
+	} `json:"object_attributes"`
+	Changes struct {
+		Draft *struct {
+			Previous bool `json:"previous"`
+			Current  bool `json:"current"`
+		} `json:"draft"`
+	} `json:"changes"`
+}
+
+// HandleGitLabWebhook checks the X-Gitlab-Token of the delivery and applies
+// Merge Request Hook events: open and update marking the draft ready create
+// the pull request, merge and close finish it, reopen opens it again. Merge
+// request payloads carry only the id of the author, it is resolved by the
+// account id of the identity.
+func (s *VCSService) HandleGitLabWebhook(ctx context.Context, input VCSWebhookInput) (*VCSWebhookOutput, error) {
+	ctx, err := s.webhookContext(ctx, models.VCSProviderGitLab, input.TenantName)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := s.vcsRepo.GetVCSWebhookSecret(ctx, models.VCSProviderGitLab)
+	if errors.Is(err, repoerrs.ErrNotFound) {
+		return nil, ErrInvalidSignature
+	} else if err != nil {
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(secret), []byte(input.Signature)) != 1 {
+		return nil, ErrInvalidSignature
+	}
+
+	if input.Event != gitLabEventMergeRequest {
+		return &VCSWebhookOutput{DeliveryID: input.DeliveryID, Event: input.Event, Result: VCSResultIgnored}, nil
+	}
+
+	var payload gitLabMergeRequestPayload
+	if err := json.Unmarshal(input.Body, &payload); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPayload, err)
+	}
+
+	attributes := payload.ObjectAttributes
+	if payload.Project.PathWithNamespace == "" || attributes.IID == 0 || attributes.AuthorID == 0 {
+		return nil, fmt.Errorf("%w: merge request is not described", ErrInvalidPayload)
+	}
+
+	pr := vcsPullRequest{
+		ID:             fmt.Sprintf("%s!%d", payload.Project.PathWithNamespace, attributes.IID),
+		Name:           attributes.Title,
+		AuthorID:       strconv.Itoa(attributes.AuthorID),
+		RepositoryName: payload.Project.PathWithNamespace,
+	}
+
+	change := vcsChangeNone
+	switch attributes.Action {
+	case "open":
+		if !attributes.Draft {
+			change = vcsChangeOpen
+		}
+	case "update":
+		if draft := payload.Changes.Draft; draft != nil && draft.Previous && !draft.Current {
+			change = vcsChangeOpen
+		}
+	case "merge":
+		change = vcsChangeMerge
+	case "close":
+		change = vcsChangeClose
+	case "reopen":
+		if !attributes.Draft {
+			change = vcsChangeReopen
+		}
+	}
+
+	return s.processDelivery(ctx, models.VCSDelivery{
+		Provider:      models.VCSProviderGitLab,
+		DeliveryID:    input.DeliveryID,
+		Event:         input.Event,
+		Action:        attributes.Action,
+		PullRequestID: pr.ID,
+	}, change, pr)
+}
+
+type gitLabVCSClient struct {
+	client *gitlab.Client
+}
+
+// NewGitLabVCSClient reads merge requests "<group>/<project>!<iid>" from
+// GitLab.
+func NewGitLabVCSClient(client *gitlab.Client) VCSClient {
+	return &gitLabVCSClient{client: client}
+}
+
+func (c *gitLabVCSClient) GetPullRequest(ctx context.Context, token, prID string) (*VCSPullRequestState, error) {
+	project, iid, err := parseGitLabPullRequestID(prID)
+	if err != nil {
+		return nil, err
+	}
+
+	mr, err := c.client.GetMergeRequest(ctx, token, project, iid)
+	if err != nil {
+		var apiErr *gitlab.Error
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%w: %s", ErrVCSPullRequestNotFound, prID)
+		}
+		return nil, fmt.Errorf("failed to get merge request: %w", err)
+	}
+
+	state := VCSPullRequestState{
+		Merged: mr.State == "merged",
+		Closed: mr.State == "closed",
+	}
+	for _, reviewer := range mr.Reviewers {
+		state.Reviewers = append(state.Reviewers, reviewer.Username)
+	}
+
+	return &state, nil
+}
+
+// parseGitLabPullRequestID splits "<group>/<project>!<iid>" the GitLab
+// webhooks create pull requests with.
+func parseGitLabPullRequestID(prID string) (project string, iid int, err error) {
+	separator := strings.LastIndex(prID, "!")
+	if separator > 0 {
+		project = prID[:separator]
+		iid, err = strconv.Atoi(prID[separator+1:])
+	}
+	if separator <= 0 || err != nil || iid <= 0 || !strings.Contains(project, "/") {
+		return "", 0, fmt.Errorf("%w: %s", ErrInvalidPullRequestID, prID)
+	}
+
+	return project, iid, nil
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/safehttp"
+)
+
+const (
+	DefaultWebhookDeliveriesLimit = 50
+	MaxWebhookDeliveriesLimit     = 500
+)
+
+// ErrURLNotAllowed is returned for URLs of webhooks and chat channels that
+// are not public http(s) addresses.
+var ErrURLNotAllowed = errors.New("url is not allowed")
+
+type WebhookService struct {
+	webhookRepo repo.Webhook
+}
+
+func NewWebhookService(webhookRepo repo.Webhook) *WebhookService {
+	return &WebhookService{webhookRepo: webhookRepo}
+}
+
+// AddSubscription registers the webhook. The secret is returned only here,
+// receivers use it to verify signatures of deliveries.
+func (s *WebhookService) AddSubscription(ctx context.Context, input WebhookSubscriptionInput) (*WebhookOutputSubscription, error) {
+	if err := safehttp.CheckURL(ctx, input.URL); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrURLNotAllowed, err)
+	}
+
+	secret := input.Secret
+	if secret == "" {
+		generated, err := generateSecret()
+		if err != nil {
+			return nil, err
+		}
+		secret = generated
+	}
+
+	subscription, err := s.webhookRepo.CreateWebhookSubscription(ctx, models.WebhookSubscription{
+		URL:        input.URL,
+		Secret:     secret,
+		EventTypes: input.EventTypes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	output := toWebhookOutputSubscription(*subscription)
+	output.Secret = secret
+
+	return &output, nil
+}
+
+func (s *WebhookService) GetSubscriptions(ctx context.Context) (*WebhookSubscriptionsOutput, error) {
+	subscriptions, err := s.webhookRepo.GetWebhookSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	output := WebhookSubscriptionsOutput{
+		Subscriptions: []WebhookOutputSubscription{},
+	}
+	for _, subscription := range subscriptions {
+		output.Subscriptions = append(output.Subscriptions, toWebhookOutputSubscription(subscription))
+	}
+
+	return &output, nil
+}
+
+func (s *WebhookService) SetSubscriptionIsActive(ctx context.Context, subscriptionID int, isActive bool) (*WebhookOutputSubscription, error) {
+	subscription, err := s.webhookRepo.SetWebhookSubscriptionIsActive(ctx, subscriptionID, isActive)
+	if err != nil {
+		return nil, err
+	}
+
+	output := toWebhookOutputSubscription(*subscription)
+
+	return &output, nil
+}
+
+func (s *WebhookService) DeleteSubscription(ctx context.Context, subscriptionID int) error {
+	return s.webhookRepo.DeleteWebhookSubscription(ctx, subscriptionID)
+}
+
+func (s *WebhookService) GetDeliveries(ctx context.Context, input WebhookDeliveriesInput) (*WebhookDeliveriesOutput, error) {
+	limit := input.Limit
+	if limit == 0 {
+		limit = DefaultWebhookDeliveriesLimit
+	}
+
+	deliveries, err := s.webhookRepo.GetWebhookDeliveries(ctx, models.WebhookDeliveryFilter{
+		SubscriptionID: input.SubscriptionID,
+		Status:         input.Status,
+		Cursor:         input.Cursor,
+		Limit:          limit + 1, // one more to know whether there is a next page
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	output := WebhookDeliveriesOutput{
+		Deliveries: []WebhookOutputDelivery{},
+	}
+
+	if uint64(len(deliveries)) > limit {
+		deliveries = deliveries[:limit]
+		output.NextCursor = deliveries[len(deliveries)-1].ID
+	}
+
+	for _, delivery := range deliveries {
+		output.Deliveries = append(output.Deliveries, toWebhookOutputDelivery(delivery))
+	}
+
+	return &output, nil
+}
+
+// RetryDelivery requeues the dead delivery with a fresh attempts budget.
+func (s *WebhookService) RetryDelivery(ctx context.Context, deliveryID int64) (*WebhookRetryOutput, error) {
+	delivery, requeued, err := s.webhookRepo.RetryWebhookDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebhookRetryOutput{
+		Delivery: toWebhookOutputDelivery(*delivery),
+		Requeued: requeued,
+	}, nil
+}
+
+func toWebhookOutputSubscription(subscription models.WebhookSubscription) WebhookOutputSubscription {
+	return WebhookOutputSubscription{
+		SubscriptionID: subscription.ID,
+		URL:            subscription.URL,
+		EventTypes:     subscription.EventTypes,
+		IsActive:       subscription.IsActive,
+		CreatedAt:      subscription.CreatedAt,
+	}
+}
+
+func toWebhookOutputDelivery(delivery models.WebhookDelivery) WebhookOutputDelivery {
+	output := WebhookOutputDelivery{
+		DeliveryID:     delivery.ID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.Event.EventType,
+		SubscriptionID: delivery.SubscriptionID,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		CreatedAt:      delivery.CreatedAt,
+		DeliveredAt:    delivery.DeliveredAt,
+	}
+
+	if delivery.Status == models.DeliveryStatusPending {
+		output.NextAttemptAt = &delivery.NextAttemptAt
+	}
+
+	return output
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/MatTwix/Pull-Request-Assigner/internal/metrics"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/models"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo"
+	"github.com/MatTwix/Pull-Request-Assigner/internal/repo/repoerrs"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/logger"
+	"github.com/MatTwix/Pull-Request-Assigner/pkg/safehttp"
+)
+
+// Headers of webhook requests. The signature is "sha256=" followed by the hex
+// HMAC-SHA256 of the body keyed with the subscription secret.
+const (
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookSignatureHeader = "X-Webhook-Signature-256"
+)
+
+type WebhookDispatcherConfig struct {
+	DispatchInterval time.Duration
+	BatchSize        uint64
+	Workers          int
+	RequestTimeout   time.Duration
+	MaxAttempts      int
+	RetryBaseDelay   time.Duration
+	RetryMaxDelay    time.Duration
+}
+
+// WebhookDispatcher moves events from the outbox to webhook subscriptions and
+// delivers them with retries. Several instances may run against the same
+// database, deliveries are claimed with row locks.
+type WebhookDispatcher struct {
+	webhookRepo repo.Webhook
+	client      *http.Client
+	cfg         WebhookDispatcherConfig
+	log         logger.Logger
+}
+
+func NewWebhookDispatcher(webhookRepo repo.Webhook, cfg WebhookDispatcherConfig, log logger.Logger) *WebhookDispatcher {
+	cfg.BatchSize = max(cfg.BatchSize, 1)
+	cfg.Workers = max(cfg.Workers, 1)
+	cfg.MaxAttempts = max(cfg.MaxAttempts, 1)
+	if cfg.DispatchInterval <= 0 {
+		cfg.DispatchInterval = time.Second
+	}
+
+	return &WebhookDispatcher{
+		webhookRepo: webhookRepo,
+		client:      safehttp.NewClient(cfg.RequestTimeout),
+		cfg:         cfg,
+		log:         log,
+	}
+}
+
+// Run dispatches webhooks every DispatchInterval until ctx is done.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.DispatchInterval)
+	defer ticker.Stop()
+
+	for {
+		d.dispatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *WebhookDispatcher) dispatch(ctx context.Context) {
+	for ctx.Err() == nil {
+		events, err := d.webhookRepo.FanOutOutboxEvents(ctx, d.cfg.BatchSize)
+		if err != nil {
+			d.log.Error("failed to fan out outbox events", map[string]any{"error": err})
+			break
+		}
+
+		if uint64(events) < d.cfg.BatchSize {
+			break
+		}
+	}
+
+	var wg sync.WaitGroup
+	for range d.cfg.Workers {
+		wg.Go(func() {
+			for ctx.Err() == nil {
+				if sent := d.deliverBatch(ctx); uint64(sent) < d.cfg.BatchSize {
+					return
+				}
+			}
+		})
+	}
+	wg.Wait()
+}
+
+// deliverBatch claims due deliveries and sends them one by one, returning the
+// number of claimed deliveries.
+func (d *WebhookDispatcher) deliverBatch(ctx context.Context) int {
+	// enough to send the whole batch even if every request times out
+	lease := d.cfg.RequestTimeout*time.Duration(d.cfg.BatchSize) + time.Minute
+
+	deliveries, err := d.webhookRepo.ClaimWebhookDeliveries(ctx, d.cfg.BatchSize, lease)
+	if err != nil {
+		d.log.Error("failed to claim webhook deliveries", map[string]any{"error": err})
+		return 0
+	}
+
+	for _, delivery := range deliveries {
+		d.deliver(ctx, delivery)
+	}
+
+	return len(deliveries)
+}
+
+func (d *WebhookDispatcher) deliver(ctx context.Context, delivery models.WebhookDelivery) {
+	statusCode, err := d.send(ctx, delivery)
+
+	// the attempt is recorded even when dispatching is being stopped
+	ctx = context.WithoutCancel(ctx)
+
+	if err == nil {
+		metrics.WebhookDeliveries.WithLabelValues("delivered").Inc()
+
+		d.finish(ctx, delivery, true, statusCode, "", nil)
+		return
+	}
+
+	fields := map[string]any{
+		"delivery_id": delivery.ID,
+		"event_type":  delivery.Event.EventType,
+		"url":         delivery.URL,
+		"attempt":     delivery.Attempts + 1,
+		"error":       err,
+	}
+
+	var retryAfter *time.Duration
+	if delivery.Attempts+1 < d.cfg.MaxAttempts {
+		delay := d.retryDelay(delivery.Attempts + 1)
+		retryAfter = &delay
+
+		metrics.WebhookDeliveries.WithLabelValues("failed").Inc()
+		d.log.Warn("webhook delivery failed, will retry", fields)
+	} else {
+		metrics.WebhookDeliveries.WithLabelValues("dead").Inc()
+		d.log.Error("webhook delivery is dead", fields)
+	}
+
+	d.finish(ctx, delivery, false, statusCode, err.Error(), retryAfter)
+}
+
+// finish records the attempt unless another dispatcher claimed the delivery
+// after its lease expired, then the attempt is left to that dispatcher.
+func (d *WebhookDispatcher) finish(ctx context.Context, delivery models.WebhookDelivery, delivered bool, statusCode int, lastError string, retryAfter *time.Duration) {
+	err := d.webhookRepo.FinishWebhookDelivery(ctx, delivery.ID, delivery.LeaseToken, delivered, statusCode, lastError, retryAfter)
+	switch {
+	case err == nil:
+	case errors.Is(err, repoerrs.ErrLeaseLost):
+		d.log.Warn("webhook delivery lease expired before the attempt was recorded", map[string]any{"delivery_id": delivery.ID})
+	default:
+		d.log.Error("failed to record webhook delivery attempt", map[string]any{"delivery_id": delivery.ID, "error": err})
+	}
+}
+
+type webhookEnvelope struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// send posts the event and returns the response status, non 2xx statuses are
+// errors.
+func (d *WebhookDispatcher) send(ctx context.Context, delivery models.WebhookDelivery) (int, error) {
+	body, err := json.Marshal(webhookEnvelope{
+		ID:        delivery.Event.ID,
+		Type:      delivery.Event.EventType,
+		CreatedAt: delivery.Event.CreatedAt,
+		Data:      delivery.Event.Payload,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, delivery.Event.EventType)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(delivery.Secret, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+func (d *WebhookDispatcher) retryDelay(attempts int) time.Duration {
+	return backoffDelay(d.cfg.RetryBaseDelay, d.cfg.RetryMaxDelay, attempts)
+}
+
+// backoffDelay doubles the base delay after every failed attempt, up to the
+// max delay, with up to 20% jitter so failed attempts do not retry in bursts.
+func backoffDelay(base, maxDelay time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxDelay)
+
+	return delay + rand.N(delay/5+1)
+}
+
+// SignWebhookPayload returns the value of the signature header for the body.
+func SignWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
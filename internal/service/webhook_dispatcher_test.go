@@ -0,0 +1,73 @@
+package service
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+		body   string
+		want   string
+	}{
+		{
+			name:   "event",
+			secret: "secret",
+			body:   `{"id":1}`,
+			want:   "sha256=03def589620c813f198fd03d7967e292b163ef0435ebf43071ce0e9519763cb7",
+		},
+		{
+			name:   "empty",
+			secret: "",
+			body:   "",
+			want:   "sha256=b613679a0814d9ec772f95d778c35fc5ff1697c493715653c6c712144292c5ad",
+		},
+		{
+			name:   "rfc vector",
+			secret: "key",
+			body:   "The quick brown fox jumps over the lazy dog",
+			want:   "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SignWebhookPayload(tt.secret, []byte(tt.body)); got != tt.want {
+				t.Errorf("SignWebhookPayload() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	const (
+		base     = time.Second
+		maxDelay = time.Minute
+	)
+
+	tests := []struct {
+		attempts int
+		want     time.Duration // without jitter
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{6, 32 * time.Second},
+		{7, time.Minute},
+		{50, time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.attempts), func(t *testing.T) {
+			for range 100 {
+				got := backoffDelay(base, maxDelay, tt.attempts)
+				if got < tt.want || got > tt.want+tt.want/5 {
+					t.Fatalf("backoffDelay(%d) = %s, want in [%s, %s]", tt.attempts, got, tt.want, tt.want+tt.want/5)
+				}
+			}
+		})
+	}
+}
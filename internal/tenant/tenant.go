@@ -0,0 +1,24 @@
+// Package tenant carries the tenant resolved from API credentials through
+// request context down to the repositories.
+package tenant
+
+import "context"
+
+// DefaultID is the tenant owning data created before multi-tenancy and
+// requests authorized with the keys from the service configuration.
+const DefaultID = 1
+
+type ctxKey struct{}
+
+func WithID(ctx context.Context, id int) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// ID returns the tenant of the request or DefaultID when none was set.
+func ID(ctx context.Context) int {
+	if id, ok := ctx.Value(ctxKey{}).(int); ok {
+		return id
+	}
+
+	return DefaultID
+}
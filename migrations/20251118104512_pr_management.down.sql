@@ -0,0 +1,9 @@
+ALTER TABLE users
+    DROP CONSTRAINT users_team_name_fkey,
+    ADD CONSTRAINT users_team_name_fkey
+        FOREIGN KEY (team_name) REFERENCES teams(team_name)
+        ON DELETE CASCADE;
+
+ALTER TABLE teams
+    DROP COLUMN archived_at,
+    DROP COLUMN is_archived;
@@ -0,0 +1,9 @@
+ALTER TABLE teams
+    ADD COLUMN is_archived BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN archived_at TIMESTAMP NULL;
+
+ALTER TABLE users
+    DROP CONSTRAINT users_team_name_fkey,
+    ADD CONSTRAINT users_team_name_fkey
+        FOREIGN KEY (team_name) REFERENCES teams(team_name)
+        ON UPDATE CASCADE ON DELETE RESTRICT;
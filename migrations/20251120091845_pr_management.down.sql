@@ -0,0 +1,14 @@
+ALTER TABLE users
+    ADD COLUMN team_name TEXT REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE RESTRICT;
+
+UPDATE users u
+SET team_name = tm.team_name
+FROM team_members tm
+WHERE tm.user_id = u.user_id AND tm.is_primary;
+
+CREATE INDEX IF NOT EXISTS idx_users_team_name_id
+    ON users (team_name, id);
+
+ALTER TABLE pull_requests DROP COLUMN team_name;
+
+DROP TABLE team_members;
@@ -0,0 +1,29 @@
+CREATE TABLE team_members (
+    user_id TEXT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    team_name TEXT NOT NULL REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE RESTRICT,
+    is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (user_id, team_name)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_team_members_primary
+    ON team_members (user_id) WHERE is_primary;
+
+CREATE INDEX IF NOT EXISTS idx_team_members_team_name_user_id
+    ON team_members (team_name, user_id);
+
+INSERT INTO team_members (user_id, team_name, is_primary)
+SELECT user_id, team_name, TRUE
+FROM users
+WHERE team_name IS NOT NULL;
+
+ALTER TABLE pull_requests
+    ADD COLUMN team_name TEXT REFERENCES teams(team_name) ON UPDATE CASCADE ON DELETE SET NULL;
+
+UPDATE pull_requests pr
+SET team_name = u.team_name
+FROM users u
+WHERE u.user_id = pr.author_id;
+
+DROP INDEX IF EXISTS idx_users_team_name_id;
+
+ALTER TABLE users DROP COLUMN team_name;
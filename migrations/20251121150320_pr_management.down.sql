@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_teams_parent_id;
+
+ALTER TABLE teams
+    DROP COLUMN fallback_depth,
+    DROP COLUMN parent_id;
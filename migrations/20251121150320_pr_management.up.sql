@@ -0,0 +1,6 @@
+ALTER TABLE teams
+    ADD COLUMN parent_id INT NULL REFERENCES teams(id) ON DELETE RESTRICT,
+    ADD COLUMN fallback_depth INT NULL CHECK (fallback_depth >= 0);
+
+CREATE INDEX IF NOT EXISTS idx_teams_parent_id
+    ON teams (parent_id);
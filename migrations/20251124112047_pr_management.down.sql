@@ -0,0 +1,77 @@
+-- only the default tenant survives the rollback
+UPDATE teams SET parent_id = NULL WHERE tenant_id != 1;
+DELETE FROM users WHERE tenant_id != 1;
+DELETE FROM teams WHERE tenant_id != 1;
+
+ALTER TABLE pull_request_reviewers
+    DROP CONSTRAINT pull_request_reviewers_pull_request_id_fkey,
+    DROP CONSTRAINT pull_request_reviewers_reviewer_id_fkey,
+    DROP CONSTRAINT pull_request_reviewers_pkey;
+
+ALTER TABLE pull_requests
+    DROP CONSTRAINT pull_requests_author_id_fkey,
+    DROP CONSTRAINT pull_requests_team_name_fkey,
+    DROP CONSTRAINT pull_requests_tenant_id_pull_request_id_key;
+
+ALTER TABLE team_members
+    DROP CONSTRAINT team_members_user_id_fkey,
+    DROP CONSTRAINT team_members_team_name_fkey,
+    DROP CONSTRAINT team_members_pkey;
+
+DROP INDEX IF EXISTS idx_team_members_primary;
+DROP INDEX IF EXISTS idx_team_members_team_name_user_id;
+
+ALTER TABLE users DROP CONSTRAINT users_tenant_id_user_id_key;
+ALTER TABLE teams DROP CONSTRAINT teams_tenant_id_team_name_key;
+
+ALTER TABLE pull_request_reviewers DROP COLUMN tenant_id;
+ALTER TABLE pull_requests DROP COLUMN tenant_id;
+ALTER TABLE team_members DROP COLUMN tenant_id;
+ALTER TABLE users DROP COLUMN tenant_id;
+ALTER TABLE teams DROP COLUMN tenant_id;
+
+ALTER TABLE teams ADD CONSTRAINT teams_team_name_key UNIQUE (team_name);
+ALTER TABLE users ADD CONSTRAINT users_user_id_key UNIQUE (user_id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_teams_team_name
+    ON teams (team_name);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_user_id
+    ON users (user_id);
+
+ALTER TABLE team_members
+    ADD PRIMARY KEY (user_id, team_name),
+    ADD CONSTRAINT team_members_user_id_fkey
+        FOREIGN KEY (user_id) REFERENCES users(user_id)
+        ON DELETE CASCADE,
+    ADD CONSTRAINT team_members_team_name_fkey
+        FOREIGN KEY (team_name) REFERENCES teams(team_name)
+        ON UPDATE CASCADE ON DELETE RESTRICT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_team_members_primary
+    ON team_members (user_id) WHERE is_primary;
+
+CREATE INDEX IF NOT EXISTS idx_team_members_team_name_user_id
+    ON team_members (team_name, user_id);
+
+ALTER TABLE pull_requests
+    ADD CONSTRAINT pull_requests_pull_request_id_key UNIQUE (pull_request_id),
+    ADD CONSTRAINT pull_requests_author_id_fkey
+        FOREIGN KEY (author_id) REFERENCES users(user_id)
+        ON DELETE CASCADE,
+    ADD CONSTRAINT pull_requests_team_name_fkey
+        FOREIGN KEY (team_name) REFERENCES teams(team_name)
+        ON UPDATE CASCADE ON DELETE SET NULL;
+
+ALTER TABLE pull_request_reviewers
+    ADD PRIMARY KEY (pull_request_id, reviewer_id),
+    ADD CONSTRAINT pull_request_reviewers_pull_request_id_fkey
+        FOREIGN KEY (pull_request_id) REFERENCES pull_requests(pull_request_id)
+        ON DELETE CASCADE,
+    ADD CONSTRAINT pull_request_reviewers_reviewer_id_fkey
+        FOREIGN KEY (reviewer_id) REFERENCES users(user_id)
+        ON DELETE CASCADE;
+
+DROP TABLE api_keys;
+
+DROP TABLE tenants;
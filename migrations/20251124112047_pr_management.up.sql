@@ -0,0 +1,90 @@
+CREATE TABLE tenants (
+    id SERIAL PRIMARY KEY,
+    tenant_name TEXT UNIQUE NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- data created before multi-tenancy belongs to the default tenant
+INSERT INTO tenants (id, tenant_name) VALUES (1, 'default');
+SELECT setval(pg_get_serial_sequence('tenants', 'id'), 1);
+
+CREATE TABLE api_keys (
+    id SERIAL PRIMARY KEY,
+    tenant_id INT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    key_hash TEXT UNIQUE NOT NULL,
+    role TEXT CHECK(role IN ('admin', 'user')) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMP NULL
+);
+
+ALTER TABLE pull_request_reviewers
+    DROP CONSTRAINT pull_request_reviewers_pull_request_id_fkey,
+    DROP CONSTRAINT pull_request_reviewers_reviewer_id_fkey,
+    DROP CONSTRAINT pull_request_reviewers_pkey;
+
+ALTER TABLE pull_requests
+    DROP CONSTRAINT pull_requests_author_id_fkey,
+    DROP CONSTRAINT pull_requests_team_name_fkey,
+    DROP CONSTRAINT pull_requests_pull_request_id_key;
+
+ALTER TABLE team_members
+    DROP CONSTRAINT team_members_user_id_fkey,
+    DROP CONSTRAINT team_members_team_name_fkey,
+    DROP CONSTRAINT team_members_pkey;
+
+DROP INDEX IF EXISTS idx_team_members_primary;
+DROP INDEX IF EXISTS idx_team_members_team_name_user_id;
+DROP INDEX IF EXISTS idx_users_user_id;
+DROP INDEX IF EXISTS idx_teams_team_name;
+
+ALTER TABLE users DROP CONSTRAINT users_user_id_key;
+ALTER TABLE teams DROP CONSTRAINT teams_team_name_key;
+
+ALTER TABLE teams ADD COLUMN tenant_id INT NOT NULL DEFAULT 1 REFERENCES tenants(id) ON DELETE CASCADE;
+ALTER TABLE users ADD COLUMN tenant_id INT NOT NULL DEFAULT 1 REFERENCES tenants(id) ON DELETE CASCADE;
+ALTER TABLE team_members ADD COLUMN tenant_id INT NOT NULL DEFAULT 1;
+ALTER TABLE pull_requests ADD COLUMN tenant_id INT NOT NULL DEFAULT 1;
+ALTER TABLE pull_request_reviewers ADD COLUMN tenant_id INT NOT NULL DEFAULT 1;
+
+ALTER TABLE teams ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE users ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE team_members ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE pull_requests ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE pull_request_reviewers ALTER COLUMN tenant_id DROP DEFAULT;
+
+ALTER TABLE teams ADD CONSTRAINT teams_tenant_id_team_name_key UNIQUE (tenant_id, team_name);
+ALTER TABLE users ADD CONSTRAINT users_tenant_id_user_id_key UNIQUE (tenant_id, user_id);
+ALTER TABLE pull_requests ADD CONSTRAINT pull_requests_tenant_id_pull_request_id_key UNIQUE (tenant_id, pull_request_id);
+
+ALTER TABLE team_members
+    ADD PRIMARY KEY (tenant_id, user_id, team_name),
+    ADD CONSTRAINT team_members_user_id_fkey
+        FOREIGN KEY (tenant_id, user_id) REFERENCES users(tenant_id, user_id)
+        ON DELETE CASCADE,
+    ADD CONSTRAINT team_members_team_name_fkey
+        FOREIGN KEY (tenant_id, team_name) REFERENCES teams(tenant_id, team_name)
+        ON UPDATE CASCADE ON DELETE RESTRICT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_team_members_primary
+    ON team_members (tenant_id, user_id) WHERE is_primary;
+
+CREATE INDEX IF NOT EXISTS idx_team_members_team_name_user_id
+    ON team_members (tenant_id, team_name, user_id);
+
+-- team_name is cleared by the application before a team is deleted
+ALTER TABLE pull_requests
+    ADD CONSTRAINT pull_requests_author_id_fkey
+        FOREIGN KEY (tenant_id, author_id) REFERENCES users(tenant_id, user_id)
+        ON DELETE CASCADE,
+    ADD CONSTRAINT pull_requests_team_name_fkey
+        FOREIGN KEY (tenant_id, team_name) REFERENCES teams(tenant_id, team_name)
+        ON UPDATE CASCADE;
+
+ALTER TABLE pull_request_reviewers
+    ADD PRIMARY KEY (tenant_id, pull_request_id, reviewer_id),
+    ADD CONSTRAINT pull_request_reviewers_pull_request_id_fkey
+        FOREIGN KEY (tenant_id, pull_request_id) REFERENCES pull_requests(tenant_id, pull_request_id)
+        ON DELETE CASCADE,
+    ADD CONSTRAINT pull_request_reviewers_reviewer_id_fkey
+        FOREIGN KEY (tenant_id, reviewer_id) REFERENCES users(tenant_id, user_id)
+        ON DELETE CASCADE;
@@ -0,0 +1,5 @@
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS repository_name;
+
+DROP TABLE IF EXISTS repositories;
+
+ALTER TABLE teams DROP COLUMN IF EXISTS reviewers_count;
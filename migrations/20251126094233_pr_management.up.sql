@@ -0,0 +1,25 @@
+ALTER TABLE teams ADD COLUMN reviewers_count INT NULL CHECK (reviewers_count > 0);
+
+-- owner team and settings override the defaults of the author's team
+CREATE TABLE repositories (
+    id SERIAL PRIMARY KEY,
+    tenant_id INT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    repository_name TEXT NOT NULL,
+    team_name TEXT NULL,
+    reviewers_count INT NULL CHECK (reviewers_count > 0),
+    fallback_depth INT NULL CHECK (fallback_depth >= 0),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT repositories_tenant_id_repository_name_key UNIQUE (tenant_id, repository_name),
+    -- team_name is cleared by the application before a team is deleted
+    CONSTRAINT repositories_team_name_fkey
+        FOREIGN KEY (tenant_id, team_name) REFERENCES teams(tenant_id, team_name)
+        ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_repositories_team_name ON repositories (tenant_id, team_name);
+
+ALTER TABLE pull_requests
+    ADD COLUMN repository_name TEXT NULL,
+    ADD CONSTRAINT pull_requests_repository_name_fkey
+        FOREIGN KEY (tenant_id, repository_name) REFERENCES repositories(tenant_id, repository_name)
+        ON UPDATE CASCADE;
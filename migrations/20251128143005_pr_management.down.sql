@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS idx_pull_requests_team_name;
+DROP INDEX IF EXISTS idx_pull_request_reviewers_reassigned_at;
+DROP INDEX IF EXISTS idx_pull_request_reviewers_reviewer_id_assigned_at;
+DROP INDEX IF EXISTS idx_pull_request_reviewers_current;
+
+DELETE FROM pull_request_reviewers WHERE reassigned_at IS NOT NULL;
+
+ALTER TABLE pull_request_reviewers
+    DROP COLUMN replaced_by,
+    DROP COLUMN reassigned_at,
+    DROP COLUMN assigned_at,
+    DROP CONSTRAINT pull_request_reviewers_pkey,
+    DROP COLUMN id,
+    ADD PRIMARY KEY (tenant_id, pull_request_id, reviewer_id);
@@ -0,0 +1,25 @@
+-- reassignments keep the replaced row, so a reviewer can appear on the same PR
+-- more than once, but only once among the current reviewers
+ALTER TABLE pull_request_reviewers
+    DROP CONSTRAINT pull_request_reviewers_pkey,
+    ADD COLUMN id SERIAL PRIMARY KEY,
+    ADD COLUMN assigned_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    ADD COLUMN reassigned_at TIMESTAMP NULL,
+    ADD COLUMN replaced_by TEXT NULL;
+
+UPDATE pull_request_reviewers prr
+SET assigned_at = pr.created_at
+FROM pull_requests pr
+WHERE pr.tenant_id = prr.tenant_id AND pr.pull_request_id = prr.pull_request_id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_pull_request_reviewers_current
+    ON pull_request_reviewers (tenant_id, pull_request_id, reviewer_id) WHERE reassigned_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_pull_request_reviewers_reviewer_id_assigned_at
+    ON pull_request_reviewers (tenant_id, reviewer_id, assigned_at);
+
+CREATE INDEX IF NOT EXISTS idx_pull_request_reviewers_reassigned_at
+    ON pull_request_reviewers (tenant_id, reassigned_at) WHERE reassigned_at IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_pull_requests_team_name
+    ON pull_requests (tenant_id, team_name);
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_pull_requests_merged_at ON pull_requests (tenant_id, merged_at) WHERE merged_at IS NOT NULL;
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS vcs_webhook_deliveries;
+DROP TABLE IF EXISTS vcs_identities;
+DROP TABLE IF EXISTS vcs_integrations;
+
+UPDATE pull_requests SET status = 'OPEN' WHERE status = 'CLOSED';
+ALTER TABLE pull_requests DROP CONSTRAINT pull_requests_status_check;
+ALTER TABLE pull_requests ADD CONSTRAINT pull_requests_status_check CHECK(status IN ('OPEN', 'MERGED'));
@@ -0,0 +1,39 @@
+-- pull requests closed without merge, reviewers are released as on merge
+ALTER TABLE pull_requests DROP CONSTRAINT pull_requests_status_check;
+ALTER TABLE pull_requests ADD CONSTRAINT pull_requests_status_check CHECK(status IN ('OPEN', 'MERGED', 'CLOSED'));
+
+CREATE TABLE vcs_integrations (
+    tenant_id INT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    provider TEXT CHECK(provider IN ('github', 'gitlab')) NOT NULL,
+    webhook_secret TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, provider)
+);
+
+-- logins of users in version control systems, logins without a mapping are
+-- taken as user ids
+CREATE TABLE vcs_identities (
+    tenant_id INT NOT NULL,
+    provider TEXT CHECK(provider IN ('github', 'gitlab')) NOT NULL,
+    login TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, provider, login),
+    FOREIGN KEY (tenant_id, user_id) REFERENCES users(tenant_id, user_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_vcs_identities_user_id ON vcs_identities (tenant_id, user_id);
+
+-- processed webhook deliveries, redeliveries with the same id are skipped
+CREATE TABLE vcs_webhook_deliveries (
+    tenant_id INT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    provider TEXT NOT NULL,
+    delivery_id TEXT NOT NULL,
+    event TEXT NOT NULL,
+    action TEXT NOT NULL,
+    pull_request_id TEXT NULL,
+    result TEXT NULL,
+    received_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, provider, delivery_id)
+);
@@ -0,0 +1,16 @@
+DROP INDEX IF EXISTS idx_pull_requests_reviewer_sync_due;
+
+ALTER TABLE pull_requests
+    DROP COLUMN IF EXISTS reviewer_synced_at,
+    DROP COLUMN IF EXISTS reviewer_sync_error,
+    DROP COLUMN IF EXISTS reviewer_sync_next_attempt_at,
+    DROP COLUMN IF EXISTS reviewer_sync_attempts,
+    DROP COLUMN IF EXISTS reviewer_sync_version,
+    DROP COLUMN IF EXISTS reviewer_sync_status,
+    DROP COLUMN IF EXISTS vcs_provider;
+
+DELETE FROM vcs_integrations WHERE webhook_secret IS NULL;
+
+ALTER TABLE vcs_integrations
+    DROP COLUMN IF EXISTS api_token,
+    ALTER COLUMN webhook_secret SET NOT NULL;
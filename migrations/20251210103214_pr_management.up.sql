@@ -0,0 +1,21 @@
+-- api token enables requesting assigned reviewers in the provider
+ALTER TABLE vcs_integrations
+    ALTER COLUMN webhook_secret DROP NOT NULL,
+    ADD COLUMN api_token TEXT NULL;
+
+ALTER TABLE pull_requests
+    ADD COLUMN vcs_provider TEXT NULL,
+    ADD COLUMN reviewer_sync_status TEXT NULL CHECK(reviewer_sync_status IN ('PENDING', 'SYNCED', 'FAILED')),
+    ADD COLUMN reviewer_sync_version INT NOT NULL DEFAULT 0, -- bumped on every change of reviewers
+    ADD COLUMN reviewer_sync_attempts INT NOT NULL DEFAULT 0,
+    ADD COLUMN reviewer_sync_next_attempt_at TIMESTAMP NULL,
+    ADD COLUMN reviewer_sync_error TEXT NULL,
+    ADD COLUMN reviewer_synced_at TIMESTAMP NULL;
+
+CREATE INDEX IF NOT EXISTS idx_pull_requests_reviewer_sync_due
+    ON pull_requests (reviewer_sync_next_attempt_at) WHERE reviewer_sync_status = 'PENDING';
+
+-- pull requests created from webhooks before the provider was recorded
+UPDATE pull_requests pr SET vcs_provider = d.provider
+FROM vcs_webhook_deliveries d
+WHERE d.tenant_id = pr.tenant_id AND d.pull_request_id = pr.pull_request_id AND d.result = 'created';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_pull_requests_open_vcs;
+
+DROP TABLE IF EXISTS reconciliation_corrections;
@@ -0,0 +1,19 @@
+-- corrections of drift between pull requests and their providers, made by
+-- the reconciliation job
+CREATE TABLE reconciliation_corrections (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id INT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    pull_request_id TEXT NOT NULL,
+    provider TEXT NOT NULL,
+    action TEXT CHECK(action IN ('merge', 'close', 'reassign')) NOT NULL,
+    reviewer_id TEXT NULL, -- reassigned away
+    replaced_by TEXT NULL,
+    error TEXT NULL, -- the correction failed
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_reconciliation_corrections_tenant ON reconciliation_corrections (tenant_id, id);
+
+-- open pull requests are paged by id
+CREATE INDEX IF NOT EXISTS idx_pull_requests_open_vcs
+    ON pull_requests (id) WHERE status = 'OPEN' AND vcs_provider IS NOT NULL;
@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS notifications;
+
+DROP TABLE IF EXISTS chat_channels;
+
+DROP INDEX IF EXISTS idx_outbox_events_unnotified;
+
+ALTER TABLE outbox_events DROP COLUMN IF EXISTS notified_at;
+
+DROP INDEX IF EXISTS idx_pull_request_reviewers_sla;
+
+ALTER TABLE pull_request_reviewers DROP COLUMN IF EXISTS sla_breached_at;
+
+ALTER TABLE teams DROP COLUMN IF EXISTS review_sla;
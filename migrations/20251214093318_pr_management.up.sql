@@ -0,0 +1,49 @@
+-- time a review may wait, assignments waiting longer breach the SLA once
+ALTER TABLE teams ADD COLUMN review_sla INTERVAL NULL;
+
+ALTER TABLE pull_request_reviewers ADD COLUMN sla_breached_at TIMESTAMP NULL;
+
+CREATE INDEX IF NOT EXISTS idx_pull_request_reviewers_sla
+    ON pull_request_reviewers (assigned_at) WHERE reassigned_at IS NULL AND sla_breached_at IS NULL;
+
+-- events are fanned out to notifications independently of webhooks, events
+-- raised before notifications existed are not notified
+ALTER TABLE outbox_events ADD COLUMN notified_at TIMESTAMP NULL;
+
+UPDATE outbox_events SET notified_at = created_at;
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_unnotified ON outbox_events (id) WHERE notified_at IS NULL;
+
+-- Slack/Mattermost compatible incoming webhooks of teams
+CREATE TABLE chat_channels (
+    id SERIAL PRIMARY KEY,
+    tenant_id INT NOT NULL,
+    team_name TEXT NOT NULL,
+    url TEXT NOT NULL,
+    event_types TEXT[] NOT NULL DEFAULT '{}', -- empty means all notified events
+    templates JSONB NOT NULL DEFAULT '{}', -- event type to text/template of the message, defaults otherwise
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (tenant_id, team_name, url),
+    FOREIGN KEY (tenant_id, team_name) REFERENCES teams(tenant_id, team_name) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE TABLE notifications (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id INT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    event_id BIGINT NOT NULL REFERENCES outbox_events(id) ON DELETE CASCADE,
+    channel TEXT CHECK(channel IN ('chat')) NOT NULL,
+    chat_channel_id INT NULL REFERENCES chat_channels(id) ON DELETE CASCADE,
+    recipient_id TEXT NOT NULL DEFAULT '', -- user the notification is about, empty for the whole team
+    status TEXT CHECK(status IN ('PENDING', 'SENT', 'DEAD')) NOT NULL DEFAULT 'PENDING',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_error TEXT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    sent_at TIMESTAMP NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_target
+    ON notifications (event_id, channel, COALESCE(chat_channel_id, 0), recipient_id);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_due ON notifications (next_attempt_at) WHERE status = 'PENDING';
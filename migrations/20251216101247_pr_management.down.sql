@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS idx_notifications_digest;
+
+DELETE FROM notifications WHERE channel = 'email';
+
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_source_check;
+ALTER TABLE notifications DROP COLUMN IF EXISTS digest_date;
+ALTER TABLE notifications ALTER COLUMN event_id SET NOT NULL;
+
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_channel_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_channel_check CHECK(channel IN ('chat'));
+
+ALTER TABLE users DROP COLUMN IF EXISTS email_mode;
+ALTER TABLE users DROP COLUMN IF EXISTS email;
@@ -0,0 +1,15 @@
+-- email notifications: immediately on assignment, in a daily digest of open
+-- reviews or never
+ALTER TABLE users ADD COLUMN email TEXT NULL;
+ALTER TABLE users ADD COLUMN email_mode TEXT CHECK(email_mode IN ('immediate', 'digest', 'off')) NOT NULL DEFAULT 'immediate';
+
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_channel_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_channel_check CHECK(channel IN ('chat', 'email'));
+
+-- digests are not caused by an event, they are sent once a day per user
+ALTER TABLE notifications ALTER COLUMN event_id DROP NOT NULL;
+ALTER TABLE notifications ADD COLUMN digest_date DATE NULL;
+ALTER TABLE notifications ADD CONSTRAINT notifications_source_check CHECK(event_id IS NOT NULL OR digest_date IS NOT NULL);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_digest
+    ON notifications (tenant_id, channel, recipient_id, digest_date) WHERE digest_date IS NOT NULL;
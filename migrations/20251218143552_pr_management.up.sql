@@ -0,0 +1,15 @@
+-- what notifications users get and when, users without preferences get all
+-- notifications at any time
+CREATE TABLE notification_preferences (
+    tenant_id INT NOT NULL,
+    user_id TEXT NOT NULL,
+    channels TEXT[] NOT NULL DEFAULT '{}', -- empty means all channels
+    event_types TEXT[] NOT NULL DEFAULT '{}', -- empty means all notified events
+    timezone TEXT NOT NULL DEFAULT 'UTC', -- IANA name quiet hours are in
+    quiet_hours_start TIME NULL, -- notifications are held till the end, which is before the start when they span midnight
+    quiet_hours_end TIME NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, user_id),
+    FOREIGN KEY (tenant_id, user_id) REFERENCES users(tenant_id, user_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CHECK ((quiet_hours_start IS NULL) = (quiet_hours_end IS NULL))
+);
@@ -0,0 +1,7 @@
+DELETE FROM notifications WHERE channel = 'telegram';
+
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_channel_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_channel_check CHECK(channel IN ('chat', 'email'));
+
+DROP TABLE IF EXISTS telegram_link_codes;
+DROP TABLE IF EXISTS telegram_links;
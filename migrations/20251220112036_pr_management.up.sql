@@ -0,0 +1,24 @@
+-- telegram chats of users, a chat is linked to one user
+CREATE TABLE telegram_links (
+    tenant_id INT NOT NULL,
+    user_id TEXT NOT NULL,
+    chat_id BIGINT NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, user_id),
+    FOREIGN KEY (tenant_id, user_id) REFERENCES users(tenant_id, user_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+-- one-time codes users link their chats with, the last issued one of the user
+-- is valid
+CREATE TABLE telegram_link_codes (
+    tenant_id INT NOT NULL,
+    user_id TEXT NOT NULL,
+    code_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, user_id),
+    FOREIGN KEY (tenant_id, user_id) REFERENCES users(tenant_id, user_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_channel_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_channel_check CHECK(channel IN ('chat', 'email', 'telegram'));
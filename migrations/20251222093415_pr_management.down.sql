@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_users_away_until;
+
+ALTER TABLE users DROP COLUMN IF EXISTS away_until;
+
+DROP TABLE IF EXISTS chatops_secrets;
@@ -0,0 +1,12 @@
+-- secrets slash commands of chat apps are signed with, one per tenant
+CREATE TABLE chatops_secrets (
+    tenant_id INT PRIMARY KEY REFERENCES tenants(id) ON DELETE CASCADE,
+    signing_secret TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- the day an inactive user is activated again
+ALTER TABLE users ADD COLUMN away_until DATE;
+
+CREATE INDEX idx_users_away_until ON users(away_until) WHERE away_until IS NOT NULL;
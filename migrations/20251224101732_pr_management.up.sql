@@ -0,0 +1,14 @@
+-- responses of POST requests sent with an Idempotency-Key, replayed for
+-- repeats of the request by the same caller
+CREATE TABLE idempotency_keys (
+    caller_hash TEXT NOT NULL, -- SHA-256 hash of the API key of the caller
+    idempotency_key TEXT NOT NULL,
+    request_hash TEXT NOT NULL, -- SHA-256 hash of the method, path and body
+    status_code INT, -- NULL while the request is in progress
+    response_body BYTEA,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (caller_hash, idempotency_key)
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
@@ -0,0 +1,5 @@
+-- versions of entities changed through the API, bumped by every update and
+-- compared with If-Match of requests changing the entity
+ALTER TABLE teams ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE users ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE pull_requests ADD COLUMN version INT NOT NULL DEFAULT 1;
@@ -0,0 +1,18 @@
+DROP VIEW IF EXISTS all_pull_request_reviewers;
+DROP VIEW IF EXISTS all_pull_requests;
+
+-- archived pull requests go back to the live tables
+INSERT INTO pull_requests (id, tenant_id, pull_request_id, pull_request_name, author_id, team_name, repository_name,
+    vcs_provider, status, needs_more_reviewers, created_at, merged_at)
+SELECT id, tenant_id, pull_request_id, pull_request_name, author_id, team_name, repository_name,
+    vcs_provider, status, needs_more_reviewers, created_at, merged_at
+FROM pull_requests_archive;
+
+INSERT INTO pull_request_reviewers (id, tenant_id, pull_request_id, reviewer_id, assigned_at, reassigned_at, replaced_by, sla_breached_at)
+SELECT id, tenant_id, pull_request_id, reviewer_id, assigned_at, reassigned_at, replaced_by, sla_breached_at
+FROM pull_request_reviewers_archive;
+
+DROP INDEX IF EXISTS idx_pull_requests_retention;
+
+DROP TABLE IF EXISTS pull_request_reviewers_archive;
+DROP TABLE IF EXISTS pull_requests_archive;
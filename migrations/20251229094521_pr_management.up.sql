@@ -0,0 +1,66 @@
+-- merged pull requests moved out of the live tables by the retention job,
+-- kept for stats and reports. Columns are those of the live tables the stats
+-- read, ids are kept from them.
+CREATE TABLE pull_requests_archive (
+    id INT NOT NULL,
+    tenant_id INT NOT NULL,
+    pull_request_id TEXT NOT NULL,
+    pull_request_name TEXT NOT NULL,
+    author_id TEXT NOT NULL,
+    team_name TEXT NULL,
+    repository_name TEXT NULL,
+    vcs_provider TEXT NULL,
+    status TEXT NOT NULL,
+    needs_more_reviewers BOOLEAN NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    merged_at TIMESTAMP NULL,
+    archived_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, pull_request_id),
+    FOREIGN KEY (tenant_id, author_id) REFERENCES users(tenant_id, user_id) ON DELETE CASCADE,
+    -- team_name is cleared by the application before a team is deleted
+    FOREIGN KEY (tenant_id, team_name) REFERENCES teams(tenant_id, team_name) ON UPDATE CASCADE,
+    FOREIGN KEY (tenant_id, repository_name) REFERENCES repositories(tenant_id, repository_name) ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_pull_requests_archive_team_name ON pull_requests_archive (tenant_id, team_name);
+CREATE INDEX IF NOT EXISTS idx_pull_requests_archive_merged_at ON pull_requests_archive (tenant_id, merged_at);
+
+CREATE TABLE pull_request_reviewers_archive (
+    id INT PRIMARY KEY,
+    tenant_id INT NOT NULL,
+    pull_request_id TEXT NOT NULL,
+    reviewer_id TEXT NOT NULL,
+    assigned_at TIMESTAMP NOT NULL,
+    reassigned_at TIMESTAMP NULL,
+    replaced_by TEXT NULL,
+    sla_breached_at TIMESTAMP NULL,
+    FOREIGN KEY (tenant_id, pull_request_id) REFERENCES pull_requests_archive(tenant_id, pull_request_id) ON DELETE CASCADE,
+    FOREIGN KEY (tenant_id, reviewer_id) REFERENCES users(tenant_id, user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_pull_request_reviewers_archive_pull_request_id
+    ON pull_request_reviewers_archive (tenant_id, pull_request_id);
+
+CREATE INDEX IF NOT EXISTS idx_pull_request_reviewers_archive_reviewer_id_assigned_at
+    ON pull_request_reviewers_archive (tenant_id, reviewer_id, assigned_at);
+
+-- pull requests due for retention, across tenants
+CREATE INDEX IF NOT EXISTS idx_pull_requests_retention
+    ON pull_requests (merged_at) WHERE status = 'MERGED';
+
+-- live and archived rows together, what stats and reports read
+CREATE VIEW all_pull_requests AS
+    SELECT id, tenant_id, pull_request_id, pull_request_name, author_id, team_name, repository_name, vcs_provider,
+        status, needs_more_reviewers, created_at, merged_at
+    FROM pull_requests
+    UNION ALL
+    SELECT id, tenant_id, pull_request_id, pull_request_name, author_id, team_name, repository_name, vcs_provider,
+        status, needs_more_reviewers, created_at, merged_at
+    FROM pull_requests_archive;
+
+CREATE VIEW all_pull_request_reviewers AS
+    SELECT id, tenant_id, pull_request_id, reviewer_id, assigned_at, reassigned_at, replaced_by, sla_breached_at
+    FROM pull_request_reviewers
+    UNION ALL
+    SELECT id, tenant_id, pull_request_id, reviewer_id, assigned_at, reassigned_at, replaced_by, sla_breached_at
+    FROM pull_request_reviewers_archive;
@@ -0,0 +1,3 @@
+-- token of the dispatcher that claimed the delivery, an attempt is recorded only
+-- by the dispatcher still holding the lease
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS lease_token UUID NULL;
@@ -0,0 +1 @@
+ALTER TABLE vcs_webhook_deliveries DROP COLUMN IF EXISTS locked_until;
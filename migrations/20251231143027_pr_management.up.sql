@@ -0,0 +1,2 @@
+-- deliveries left unfinished by a crashed instance are claimed again after the lease
+ALTER TABLE vcs_webhook_deliveries ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP NOT NULL DEFAULT NOW();
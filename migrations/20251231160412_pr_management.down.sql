@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_vcs_identities_account_id;
+
+ALTER TABLE vcs_identities DROP COLUMN IF EXISTS account_id;
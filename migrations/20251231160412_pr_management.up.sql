@@ -0,0 +1,5 @@
+-- numeric id of the account in the provider, GitLab merge request webhooks
+-- describe the author only by it
+ALTER TABLE vcs_identities ADD COLUMN IF NOT EXISTS account_id TEXT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_vcs_identities_account_id ON vcs_identities (tenant_id, provider, account_id) WHERE account_id IS NOT NULL;
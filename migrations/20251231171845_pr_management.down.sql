@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_reconciliation_corrections_failed;
+
+ALTER TABLE reconciliation_corrections DROP COLUMN IF EXISTS resolved_at;
+ALTER TABLE reconciliation_corrections DROP COLUMN IF EXISTS last_attempt_at;
+ALTER TABLE reconciliation_corrections DROP COLUMN IF EXISTS attempts;
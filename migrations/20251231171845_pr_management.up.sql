@@ -0,0 +1,29 @@
+-- a correction failing on every run is recorded once, with the number of attempts,
+-- until it is applied
+ALTER TABLE reconciliation_corrections ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 1;
+ALTER TABLE reconciliation_corrections ADD COLUMN IF NOT EXISTS last_attempt_at TIMESTAMP NULL;
+ALTER TABLE reconciliation_corrections ADD COLUMN IF NOT EXISTS resolved_at TIMESTAMP NULL;
+
+-- repeated failures recorded before are collapsed into the first one
+WITH repeated AS (
+    SELECT tenant_id, pull_request_id, action, COALESCE(reviewer_id, '') AS reviewer_id,
+        MIN(id) AS first_id, COUNT(*) AS attempts, MAX(created_at) AS last_attempt_at
+    FROM reconciliation_corrections
+    WHERE error IS NOT NULL
+    GROUP BY tenant_id, pull_request_id, action, COALESCE(reviewer_id, '')
+    HAVING COUNT(*) > 1
+), collapsed AS (
+    UPDATE reconciliation_corrections c
+    SET attempts = r.attempts, last_attempt_at = r.last_attempt_at
+    FROM repeated r
+    WHERE c.id = r.first_id
+)
+DELETE FROM reconciliation_corrections c
+USING repeated r
+WHERE c.error IS NOT NULL AND c.id <> r.first_id
+    AND c.tenant_id = r.tenant_id AND c.pull_request_id = r.pull_request_id
+    AND c.action = r.action AND COALESCE(c.reviewer_id, '') = r.reviewer_id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reconciliation_corrections_failed
+    ON reconciliation_corrections (tenant_id, pull_request_id, action, COALESCE(reviewer_id, ''))
+    WHERE error IS NOT NULL AND resolved_at IS NULL;
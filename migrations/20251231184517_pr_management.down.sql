@@ -0,0 +1,8 @@
+DELETE FROM notifications WHERE status = 'SKIPPED';
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_status_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_status_check CHECK(status IN ('PENDING', 'SENT', 'DEAD'));
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_mode TEXT CHECK(email_mode IN ('immediate', 'digest', 'off')) NOT NULL DEFAULT 'immediate';
+UPDATE users u SET email_mode = 'digest'
+FROM notification_preferences p
+WHERE p.tenant_id = u.tenant_id AND p.user_id = u.user_id AND 'review_digest' = ANY(p.event_types);
@@ -0,0 +1,26 @@
+-- email modes are replaced with notification preferences: users without emails
+-- lose the address, users of the digest choose it in their preferences, the
+-- digest is sent only to users who chose it
+UPDATE users SET email = NULL WHERE email_mode = 'off';
+
+INSERT INTO notification_preferences (tenant_id, user_id)
+SELECT tenant_id, user_id FROM users WHERE email_mode = 'digest'
+ON CONFLICT DO NOTHING;
+
+-- empty event types meant all events
+UPDATE notification_preferences p
+SET event_types = CASE
+        WHEN cardinality(p.event_types) = 0 THEN ARRAY['pull_request.created', 'pull_request.reviewer_reassigned',
+            'pull_request.sla_breached', 'pull_request.merged', 'review_digest']
+        ELSE array_append(p.event_types, 'review_digest')
+    END,
+    updated_at = NOW()
+FROM users u
+WHERE u.tenant_id = p.tenant_id AND u.user_id = p.user_id AND u.email_mode = 'digest'
+    AND NOT 'review_digest' = ANY(p.event_types);
+
+ALTER TABLE users DROP COLUMN IF EXISTS email_mode;
+
+-- nothing to send, e.g. reviews of a digest were finished before it was sent
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_status_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_status_check CHECK(status IN ('PENDING', 'SENT', 'DEAD', 'SKIPPED'));
@@ -0,0 +1,13 @@
+-- slack users slash commands are run on behalf of, the user name shown in
+-- slack can be changed by its owner, so users are mapped by the slack user id
+CREATE TABLE slack_identities (
+    tenant_id INT NOT NULL,
+    slack_user_id TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    is_admin BOOLEAN NOT NULL DEFAULT FALSE, -- may merge pull requests of others
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, slack_user_id),
+    FOREIGN KEY (tenant_id, user_id) REFERENCES users(tenant_id, user_id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_slack_identities_user_id ON slack_identities (tenant_id, user_id);
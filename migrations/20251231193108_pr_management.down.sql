@@ -0,0 +1,2 @@
+ALTER TABLE idempotency_keys DROP COLUMN IF EXISTS response_headers;
+ALTER TABLE idempotency_keys DROP COLUMN IF EXISTS lease_token;
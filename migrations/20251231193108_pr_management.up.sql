@@ -0,0 +1,10 @@
+-- token of the request that claimed the key, only it records the response or
+-- releases the key
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS lease_token UUID NULL;
+
+-- headers of the response replayed together with its body
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS response_headers JSONB NULL;
+
+-- recorded responses were written as JSON
+UPDATE idempotency_keys SET response_headers = '{"Content-Type": ["application/json"]}'
+WHERE status_code IS NOT NULL AND octet_length(response_body) > 0;
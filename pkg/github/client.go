@@ -0,0 +1,187 @@
+// Package github is a minimal client of the GitHub REST API.
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const DefaultBaseURL = "https://api.github.com"
+
+// Error is a response of the API with a non 2xx status.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("github api: status %d: %s", e.StatusCode, e.Message)
+}
+
+// Temporary reports whether the request may succeed when repeated: server
+// errors, timeouts and rate limits.
+func (e *Error) Temporary() bool {
+	switch {
+	case e.StatusCode >= http.StatusInternalServerError,
+		e.StatusCode == http.StatusRequestTimeout,
+		e.StatusCode == http.StatusTooManyRequests:
+		return true
+	case e.StatusCode == http.StatusForbidden:
+		return strings.Contains(strings.ToLower(e.Message), "rate limit")
+	}
+
+	return false
+}
+
+type Client struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewClient creates the client of the API at baseURL, DefaultBaseURL when
+// empty. Another base URL points the client to GitHub Enterprise or a mock.
+func NewClient(baseURL string, timeout time.Duration) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+type reviewersRequest struct {
+	Reviewers []string `json:"reviewers"`
+}
+
+// RequestReviewers requests reviews of the pull request from the users,
+// repository is "owner/repo".
+func (c *Client) RequestReviewers(ctx context.Context, token, repository string, number int, logins []string) error {
+	return c.do(ctx, http.MethodPost, token, requestedReviewersPath(repository, number), reviewersRequest{Reviewers: logins})
+}
+
+// RemoveRequestedReviewers withdraws review requests of the pull request from
+// the users, repository is "owner/repo".
+func (c *Client) RemoveRequestedReviewers(ctx context.Context, token, repository string, number int, logins []string) error {
+	return c.do(ctx, http.MethodDelete, token, requestedReviewersPath(repository, number), reviewersRequest{Reviewers: logins})
+}
+
+func requestedReviewersPath(repository string, number int) string {
+	return fmt.Sprintf("/repos/%s/pulls/%d/requested_reviewers", repository, number)
+}
+
+type User struct {
+	Login string `json:"login"`
+}
+
+type PullRequest struct {
+	State              string `json:"state"` // "open" or "closed"
+	Merged             bool   `json:"merged"`
+	RequestedReviewers []User `json:"requested_reviewers"`
+}
+
+// Review is a submitted review, its author is no longer among requested
+// reviewers of the pull request.
+type Review struct {
+	User  User   `json:"user"`
+	State string `json:"state"`
+}
+
+// GetPullRequest returns the pull request, repository is "owner/repo".
+func (c *Client) GetPullRequest(ctx context.Context, token, repository string, number int) (*PullRequest, error) {
+	var pr PullRequest
+	if err := c.get(ctx, token, fmt.Sprintf("/repos/%s/pulls/%d", repository, number), &pr); err != nil {
+		return nil, err
+	}
+
+	return &pr, nil
+}
+
+// ListReviews returns all reviews of the pull request, oldest first.
+func (c *Client) ListReviews(ctx context.Context, token, repository string, number int) ([]Review, error) {
+	const perPage = 100
+
+	var reviews []Review
+	for page := 1; ; page++ {
+		var batch []Review
+		path := fmt.Sprintf("/repos/%s/pulls/%d/reviews?per_page=%d&page=%d", repository, number, perPage, page)
+		if err := c.get(ctx, token, path, &batch); err != nil {
+			return nil, err
+		}
+
+		reviews = append(reviews, batch...)
+		if len(batch) < perPage {
+			return reviews, nil
+		}
+	}
+}
+
+func (c *Client) get(ctx context.Context, token, path string, out any) error {
+	return c.send(ctx, http.MethodGet, token, path, nil, out)
+}
+
+func (c *Client) do(ctx context.Context, method, token, path string, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	return c.send(ctx, method, token, path, data, nil)
+}
+
+// send makes the request with the body, when not nil, and decodes the response
+// into out, when not nil.
+func (c *Client) send(ctx context.Context, method, token, path string, body []byte, out any) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(respBody, &apiErr); err != nil || apiErr.Message == "" {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+
+		return &Error{StatusCode: resp.StatusCode, Message: apiErr.Message}
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
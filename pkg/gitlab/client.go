@@ -0,0 +1,101 @@
+// Package gitlab is a minimal client of the GitLab REST API.
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const DefaultBaseURL = "https://gitlab.com/api/v4"
+
+// Error is a response of the API with a non 2xx status.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("gitlab api: status %d: %s", e.StatusCode, e.Message)
+}
+
+// Temporary reports whether the request may succeed when repeated: server
+// errors, timeouts and rate limits.
+func (e *Error) Temporary() bool {
+	return e.StatusCode >= http.StatusInternalServerError ||
+		e.StatusCode == http.StatusRequestTimeout ||
+		e.StatusCode == http.StatusTooManyRequests
+}
+
+type Client struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewClient creates the client of the API at baseURL, DefaultBaseURL when
+// empty. Self-managed instances are at "https://<host>/api/v4".
+func NewClient(baseURL string, timeout time.Duration) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+type User struct {
+	Username string `json:"username"`
+}
+
+type MergeRequest struct {
+	State     string `json:"state"` // "opened", "closed", "locked" or "merged"
+	Reviewers []User `json:"reviewers"`
+}
+
+// GetMergeRequest returns the merge request, project is its full path
+// "group/project".
+func (c *Client) GetMergeRequest(ctx context.Context, token, project string, iid int) (*MergeRequest, error) {
+	path := fmt.Sprintf("/projects/%s/merge_requests/%d", url.PathEscape(project), iid)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("PRIVATE-TOKEN", token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+
+		var apiErr struct {
+			Message any `json:"message"` // a string or an object of field errors
+		}
+		message := http.StatusText(resp.StatusCode)
+		if err := json.Unmarshal(respBody, &apiErr); err == nil && apiErr.Message != nil {
+			message = fmt.Sprint(apiErr.Message)
+		}
+
+		return nil, &Error{StatusCode: resp.StatusCode, Message: message}
+	}
+
+	var mr MergeRequest
+	if err := json.NewDecoder(resp.Body).Decode(&mr); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &mr, nil
+}
@@ -0,0 +1,81 @@
+package secretbox
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testKey = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=" // "0123456789abcdef0123456789abcdef"
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{"valid", testKey, false},
+		{"empty", "", true},
+		{"not base64", "not a key", true},
+		{"short", "MDEyMzQ1Njc4OWFiY2RlZg==", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	box, err := New(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, plaintext := range []string{"", "ghp_token", strings.Repeat("x", 1000)} {
+		sealed, err := box.Seal(plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if plaintext != "" && strings.Contains(sealed, plaintext) {
+			t.Fatalf("Seal(%q) = %q contains the plaintext", plaintext, sealed)
+		}
+
+		got, err := box.Open(sealed)
+		if err != nil || got != plaintext {
+			t.Fatalf("Open(Seal(%q)) = %q, %v", plaintext, got, err)
+		}
+	}
+}
+
+func TestOpenRejects(t *testing.T) {
+	box, _ := New(testKey)
+	other, _ := New("ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA=")
+
+	sealed, _ := box.Seal("ghp_token")
+	foreign, _ := other.Seal("ghp_token")
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"plaintext", "ghp_token"},
+		{"other key", foreign},
+		{"tampered", sealed[:len(sealed)-4] + "AAA="},
+		{"truncated", sealed[:8]},
+		{"not base64", "v1:%%%"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := box.Open(tt.value); !errors.Is(err, ErrInvalidSealed) {
+				t.Fatalf("Open(%q) error = %v, want %v", tt.value, err, ErrInvalidSealed)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+// Package telegram is a minimal client of the Telegram Bot API.
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const DefaultBaseURL = "https://api.telegram.org"
+
+// Error is a response of the API with ok false.
+type Error struct {
+	StatusCode  int
+	Description string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("telegram bot api: status %d: %s", e.StatusCode, e.Description)
+}
+
+type Client struct {
+	baseURL string
+	token   string
+	timeout time.Duration
+	client  *http.Client
+}
+
+// NewClient creates the client of the bot with the token at baseURL,
+// DefaultBaseURL when empty. Another base URL points the client to a local Bot
+// API server or a stub. Every request is limited by timeout, long polling
+// requests additionally by their polling timeout.
+func NewClient(baseURL, token string, timeout time.Duration) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		timeout: timeout,
+		client:  &http.Client{},
+	}
+}
+
+type Chat struct {
+	ID   int64  `json:"id"`
+	Type string `json:"type"` // "private" for chats with the bot
+}
+
+type User struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+type Message struct {
+	MessageID int64  `json:"message_id"`
+	From      *User  `json:"from"`
+	Chat      Chat   `json:"chat"`
+	Text      string `json:"text"`
+}
+
+// Update is an incoming update, only messages are requested.
+type Update struct {
+	UpdateID int64    `json:"update_id"`
+	Message  *Message `json:"message"`
+}
+
+type getUpdatesRequest struct {
+	Offset         int64    `json:"offset,omitempty"`
+	Timeout        int      `json:"timeout"` // seconds
+	AllowedUpdates []string `json:"allowed_updates"`
+}
+
+// GetUpdates long polls updates starting with offset, which confirms the
+// updates before it, waiting up to pollTimeout for new ones.
+func (c *Client) GetUpdates(ctx context.Context, offset int64, pollTimeout time.Duration) ([]Update, error) {
+	var updates []Update
+	req := getUpdatesRequest{
+		Offset:         offset,
+		Timeout:        int(pollTimeout.Seconds()),
+		AllowedUpdates: []string{"message"},
+	}
+
+	if err := c.call(ctx, "getUpdates", req, &updates, pollTimeout); err != nil {
+		return nil, err
+	}
+
+	return updates, nil
+}
+
+type sendMessageRequest struct {
+	ChatID int64  `json:"chat_id"`
+	Text   string `json:"text"`
+}
+
+// SendMessage sends the plain text message to the chat.
+func (c *Client) SendMessage(ctx context.Context, chatID int64, text string) error {
+	return c.call(ctx, "sendMessage", sendMessageRequest{ChatID: chatID, Text: text}, nil, 0)
+}
+
+type response struct {
+	OK          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result"`
+	ErrorCode   int             `json:"error_code"`
+	Description string          `json:"description"`
+}
+
+// call invokes the method with the request and decodes its result into out,
+// when not nil. wait extends the timeout of the request.
+func (c *Client) call(ctx context.Context, method string, body, out any, wait time.Duration) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout+wait)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/bot"+c.token+"/"+method, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		// the url holds the token
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to send %s request: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	var result response
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 16<<20)).Decode(&result); err != nil {
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return &Error{StatusCode: resp.StatusCode, Description: http.StatusText(resp.StatusCode)}
+		}
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if !result.OK {
+		statusCode := result.ErrorCode
+		if statusCode == 0 {
+			statusCode = resp.StatusCode
+		}
+
+		return &Error{StatusCode: statusCode, Description: result.Description}
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	if err := json.Unmarshal(result.Result, out); err != nil {
+		return fmt.Errorf("failed to decode result: %w", err)
+	}
+
+	return nil
+}